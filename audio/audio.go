@@ -0,0 +1,163 @@
+// Package audio adds optional spoken questions and answer sounds to a quiz
+// session through its listener hooks. Sounds are external commands (afplay,
+// paplay, say, espeak, ...) or the terminal bell, so no audio library is
+// needed.
+package audio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// Config chooses what the player does. Empty commands are skipped.
+type Config struct {
+	// Speak reads each question aloud, e.g. "say" or "espeak -s 160". The
+	// text is passed as the last argument, or in place of a "{}" argument.
+	Speak string
+	// Correct and Incorrect run after a right or wrong answer, e.g.
+	// "paplay /usr/share/sounds/freedesktop/stereo/complete.oga".
+	Correct   string
+	Incorrect string
+	// Bell rings the terminal bell after a wrong answer when Incorrect is
+	// empty.
+	Bell bool
+}
+
+// Enabled reports whether cfg does anything.
+func (cfg Config) Enabled() bool {
+	return cfg.Speak != "" || cfg.Correct != "" || cfg.Incorrect != "" || cfg.Bell
+}
+
+// Player plays cfg's sounds for session events. Commands run in the
+// background; a new question or answer cuts off speech still in progress.
+type Player struct {
+	cfg      Config
+	bell     io.Writer
+	start    func(args []string) (stop func(), err error)
+	mu       sync.Mutex
+	speaking func()
+}
+
+// NewPlayer returns a Player for cfg that rings the bell on bell.
+func NewPlayer(cfg Config, bell io.Writer) *Player {
+	return &Player{cfg: cfg, bell: bell, start: startCommand}
+}
+
+// Listener returns the quiz.Listener that drives p.
+func (p *Player) Listener() quiz.Listener {
+	return quiz.ListenerFuncs{
+		QuestionShown: func(_ context.Context, _ int, q quiz.Question) {
+			p.Say(questionText(q))
+		},
+		Answered: func(_ context.Context, _ int, _ quiz.Question, res quiz.Result) {
+			p.stopSpeaking()
+			switch {
+			case res.Correct:
+				p.run(p.cfg.Correct, "")
+			case p.cfg.Incorrect != "":
+				p.run(p.cfg.Incorrect, "")
+			case p.cfg.Bell && p.bell != nil:
+				fmt.Fprint(p.bell, "\a")
+			}
+		},
+		Finished: func(context.Context, int, int) {
+			p.stopSpeaking()
+		},
+	}
+}
+
+// Say reads text with the Speak command, cutting off anything still being
+// read.
+func (p *Player) Say(text string) {
+	if p.cfg.Speak == "" {
+		return
+	}
+	p.stopSpeaking()
+	stop := p.run(p.cfg.Speak, text)
+	p.mu.Lock()
+	p.speaking = stop
+	p.mu.Unlock()
+}
+
+func (p *Player) stopSpeaking() {
+	p.mu.Lock()
+	stop := p.speaking
+	p.speaking = nil
+	p.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// run starts command with text substituted for "{}" or appended, returning
+// a func that stops it. Failures are ignored: sound is a nicety.
+func (p *Player) run(command, text string) func() {
+	args := commandArgs(command, text)
+	if len(args) == 0 {
+		return nil
+	}
+	stop, err := p.start(args)
+	if err != nil {
+		return nil
+	}
+	return stop
+}
+
+func commandArgs(command, text string) []string {
+	args := strings.Fields(command)
+	if len(args) == 0 || text == "" {
+		return args
+	}
+	for i, a := range args {
+		if a == "{}" {
+			args[i] = text
+			return args
+		}
+	}
+	return append(args, text)
+}
+
+func startCommand(args []string) (func(), error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	return func() {
+		select {
+		case <-done:
+		default:
+			cmd.Process.Kill()
+		}
+	}, nil
+}
+
+// questionText is what Speak reads: the prompt, the image's alt text, then
+// each option by letter.
+func questionText(q quiz.Question) string {
+	keys := make([]string, 0, len(q.Options))
+	for k := range q.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := []string{markdown.Plain(q.Prompt)}
+	if q.ImageAlt != "" {
+		parts = append(parts, "Image: "+q.ImageAlt+".")
+	}
+	for _, k := range keys {
+		parts = append(parts, k+". "+markdown.Plain(q.Options[k]))
+	}
+	return strings.Join(parts, " \n")
+}
@@ -0,0 +1,45 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"quiz-cli/quiz"
+)
+
+func TestPlayerSpeaksAndPlaysSounds(t *testing.T) {
+	var started [][]string
+	stopped := 0
+	var bell bytes.Buffer
+	p := NewPlayer(Config{Speak: "espeak -v {} -s 160", Correct: "play ok.wav", Bell: true}, &bell)
+	p.start = func(args []string) (func(), error) {
+		started = append(started, args)
+		return func() { stopped++ }, nil
+	}
+	l := p.Listener()
+	ctx := context.Background()
+	q := quiz.Question{Prompt: "Which **layer**?", ImageAlt: "OSI stack", Options: map[string]string{"B": "Seven", "A": "One"}}
+
+	l.OnQuestionShown(ctx, 0, q)
+	l.OnAnswered(ctx, 0, q, quiz.Result{Correct: true})
+	l.OnQuestionShown(ctx, 0, q)
+	l.OnAnswered(ctx, 0, q, quiz.Result{Correct: false})
+
+	if len(started) != 3 || started[1][0] != "play" {
+		t.Fatalf("started = %q", started)
+	}
+	if got := started[0][2]; got != "Which layer? \nImage: OSI stack. \nA. One \nB. Seven" || started[0][3] != "-s" {
+		t.Fatalf("speak args = %q", started[0])
+	}
+	if stopped != 2 {
+		t.Fatalf("speech should stop on each answer, stopped %d", stopped)
+	}
+	if bell.String() != "\a" {
+		t.Fatalf("bell = %q", bell.String())
+	}
+	if args := commandArgs("say", "hi there"); strings.Join(args, "|") != "say|hi there" {
+		t.Fatalf("commandArgs = %q", args)
+	}
+}
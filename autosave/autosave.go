@@ -0,0 +1,123 @@
+// Package autosave saves a quiz session's progress to a file as it is
+// answered, so a run cut short by a crash, a power cut or a dropped SSH
+// connection can resume where it stopped. The file is removed once the run
+// finishes.
+package autosave
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"quiz-cli/quiz"
+	"quiz-cli/storage"
+)
+
+// Path returns the default save file for runs of the bank at bankPath by the
+// named front end, in the temporary directory.
+func Path(bankPath, frontEnd string) string {
+	if abs, err := filepath.Abs(bankPath); err == nil {
+		bankPath = abs
+	}
+	sum := sha256.Sum256([]byte(frontEnd + "\x00" + bankPath))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("quiz-cli-%s-%s.json", frontEnd, hex.EncodeToString(sum[:6])))
+}
+
+// Load reads the run saved at path. ok is false when there is none.
+func Load(ctx context.Context, path string) (st quiz.State, ok bool, err error) {
+	data, err := storage.ReadFile(ctx, path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return quiz.State{}, false, nil
+	}
+	if err != nil {
+		return quiz.State{}, false, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return quiz.State{}, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return st, true, nil
+}
+
+// Saver writes a session's State to a file every few answers. A Saver is safe
+// for concurrent use.
+type Saver struct {
+	path  string
+	every int
+	mu    sync.Mutex
+	n     int
+	err   error
+}
+
+// New returns a Saver writing to path after every answers; every below one
+// saves after each answer.
+func New(path string, every int) *Saver {
+	return &Saver{path: path, every: max(every, 1)}
+}
+
+// Path returns the file the Saver writes.
+func (s *Saver) Path() string {
+	return s.path
+}
+
+// Save writes session's state now.
+func (s *Saver) Save(ctx context.Context, session *quiz.Session) error {
+	data, err := json.MarshalIndent(session.State(), "", "  ")
+	if err == nil {
+		err = storage.WriteFile(ctx, s.path, data)
+	}
+	s.fail(err)
+	return err
+}
+
+// Remove deletes the save file, such as when its run is abandoned.
+func (s *Saver) Remove() error {
+	s.mu.Lock()
+	s.n = 0
+	s.mu.Unlock()
+	err := os.Remove(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	s.fail(err)
+	return err
+}
+
+// Err returns the first error saving, if any.
+func (s *Saver) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Saver) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Listener returns a quiz.Listener saving session every few answers and
+// removing the file when it finishes.
+func (s *Saver) Listener(session *quiz.Session) quiz.Listener {
+	return quiz.ListenerFuncs{
+		Answered: func(ctx context.Context, _ int, _ quiz.Question, _ quiz.Result) {
+			s.mu.Lock()
+			s.n++
+			due := s.n%s.every == 0
+			s.mu.Unlock()
+			if due && !session.Completed() {
+				_ = s.Save(context.WithoutCancel(ctx), session)
+			}
+		},
+		Finished: func(context.Context, int, int) {
+			_ = s.Remove()
+		},
+	}
+}
@@ -0,0 +1,308 @@
+// Package backup copies the files a quiz server depends on, its answer
+// history and question banks, into compressed archives kept in a directory
+// or an S3-compatible bucket, prunes the old ones, and restores them, so a
+// failed disk does not take a group's study history with it.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"quiz-cli/storage"
+)
+
+const (
+	namePrefix = "quiz-backup-"
+	nameSuffix = ".tar.gz"
+	nameTime   = "20060102T150405Z"
+)
+
+// Source is one file to back up.
+type Source struct {
+	// Path is where the file lives, on disk or in storage (see
+	// storage.Resolve), and where Restore puts it back.
+	Path string
+	// Read returns the file's contents. When nil, Path is read.
+	Read func() ([]byte, error)
+}
+
+// Destination keeps backup archives by name.
+type Destination interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	// List returns the names of the archives kept, in any order.
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// Open resolves a destination: "s3://bucket/prefix" keeps the archives in S3
+// configured from the environment (see storage.NewS3FromEnv); anything else
+// is a local directory, created on the first backup.
+func Open(location string) (Destination, error) {
+	if rest, ok := strings.CutPrefix(location, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, errors.New("backup: s3 location must be s3://bucket or s3://bucket/prefix")
+		}
+		store, err := storage.NewS3FromEnv(bucket)
+		if err != nil {
+			return nil, err
+		}
+		return s3Dest{store: store, prefix: strings.Trim(prefix, "/")}, nil
+	}
+	if location == "" {
+		return nil, errors.New("backup: no destination")
+	}
+	return Dir(location), nil
+}
+
+// Dir is a Destination in a local directory.
+type Dir string
+
+// Put writes the archive name into d, creating d if needed.
+func (d Dir) Put(ctx context.Context, name string, data []byte) error {
+	// replaced atomically, so a crash never leaves a truncated archive that
+	// looks like the latest backup
+	return storage.FileStore{Dir: string(d)}.Put(ctx, name, data)
+}
+
+// Get reads the archive name from d.
+func (d Dir) Get(_ context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), name))
+}
+
+// List returns the names of the files in d; a missing d holds none.
+func (d Dir) List(context.Context) ([]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Delete removes the archive name from d.
+func (d Dir) Delete(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(string(d), name))
+}
+
+// Name is the name of the archive of a backup taken at t. Names sort in the
+// order the backups were taken.
+func Name(t time.Time) string {
+	return namePrefix + t.UTC().Format(nameTime) + nameSuffix
+}
+
+// takenAt reports when the archive name was taken, and whether name is a
+// backup archive at all.
+func takenAt(name string) (time.Time, bool) {
+	stamp, ok := strings.CutPrefix(name, namePrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, ok = strings.CutSuffix(stamp, nameSuffix)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(nameTime, stamp)
+	return t, err == nil
+}
+
+// Archives returns the backup archives in dest, oldest first. Other files
+// kept there are left out.
+func Archives(ctx context.Context, dest Destination) ([]string, error) {
+	names, err := dest.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, name := range names {
+		if _, ok := takenAt(name); ok {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Take archives sources into dest under Name(at) and returns the name. A
+// source that cannot be read fails the whole backup rather than leaving a
+// partial one.
+func Take(ctx context.Context, dest Destination, sources []Source, at time.Time) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, src := range sources {
+		read := src.Read
+		if read == nil {
+			read = func() ([]byte, error) { return storage.ReadFile(ctx, src.Path) }
+		}
+		data, err := read()
+		if err != nil {
+			return "", fmt.Errorf("backup %s: %w", src.Path, err)
+		}
+		hdr := &tar.Header{Name: src.Path, Mode: 0o644, Size: int64(len(data)), ModTime: at}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return "", err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	name := Name(at)
+	return name, dest.Put(ctx, name, buf.Bytes())
+}
+
+// Prune deletes all but the keep latest backup archives in dest and returns
+// the names deleted.
+func Prune(ctx context.Context, dest Destination, keep int) ([]string, error) {
+	names, err := Archives(ctx, dest)
+	if err != nil || len(names) <= keep {
+		return nil, err
+	}
+	old := names[:len(names)-max(keep, 0)]
+	for i, name := range old {
+		if err := dest.Delete(ctx, name); err != nil {
+			return old[:i], err
+		}
+	}
+	return old, nil
+}
+
+// Restore unpacks the backup archive name from dest and returns the paths
+// written. Each file goes back to the path it was taken from or, when dir is
+// not empty, into dir under its base name, to inspect a backup before
+// putting it in place.
+func Restore(ctx context.Context, dest Destination, name, dir string) ([]string, error) {
+	data, err := dest.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	var written []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		}
+		if err != nil {
+			return written, fmt.Errorf("%s: %w", name, err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return written, fmt.Errorf("%s: %w", name, err)
+		}
+		path := hdr.Name
+		if dir != "" {
+			path = filepath.Join(dir, filepath.Base(hdr.Name))
+		}
+		if err := storage.WriteFile(ctx, path, content); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+}
+
+// Scheduler takes a backup every day at a set time and prunes the old ones.
+type Scheduler struct {
+	Dest    Destination
+	Sources []Source
+	// At is the time of day to back up, as an offset from local midnight.
+	At time.Duration
+	// Keep is how many of the latest backups to keep.
+	Keep int
+	// Logf reports each backup taken and each failure.
+	Logf func(format string, args ...any)
+
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// Run backs up every day at s.At until ctx is done. A failed backup is
+// reported and tried again the next day.
+func (s *Scheduler) Run(ctx context.Context) {
+	now, after := s.now, s.after
+	if now == nil {
+		now = time.Now
+	}
+	if after == nil {
+		after = time.After
+	}
+	for {
+		t := now()
+		select {
+		case <-ctx.Done():
+			return
+		case <-after(Next(t, s.At).Sub(t)):
+		}
+		s.runOnce(ctx, now())
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, at time.Time) {
+	name, err := Take(ctx, s.Dest, s.Sources, at)
+	if err != nil {
+		s.logf("backup failed: %v", err)
+		return
+	}
+	pruned, err := Prune(ctx, s.Dest, s.Keep)
+	if err != nil {
+		s.logf("backup %s taken, but pruning old backups failed: %v", name, err)
+		return
+	}
+	s.logf("backup %s taken (%d old removed)", name, len(pruned))
+}
+
+func (s *Scheduler) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+// Next is the first time after now that falls at, an offset from local
+// midnight.
+func Next(now time.Time, at time.Duration) time.Time {
+	y, m, d := now.Date()
+	next := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).Add(at)
+	if !next.After(now) {
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Add(at)
+	}
+	return next
+}
+
+// ParseTimeOfDay reads a time of day such as "02:30" as an offset from
+// midnight.
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time of day %q: want HH:MM, e.g. 02:00", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
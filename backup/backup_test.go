@@ -0,0 +1,162 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTakePruneAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	bank := filepath.Join(dir, "questions.json")
+	if err := os.WriteFile(bank, []byte(`[{"question":"Sky?"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	history := `{"records":{}}`
+	sources := []Source{{Path: bank}, {Path: filepath.Join(dir, "stats.json"), Read: func() ([]byte, error) { return []byte(history), nil }}}
+	dest := Dir(filepath.Join(dir, "backups"))
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		if _, err := Take(ctx, dest, sources, day.AddDate(0, 0, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruned, err := Prune(ctx, dest, 2)
+	if err != nil || len(pruned) != 2 || pruned[0] != Name(day) {
+		t.Fatalf("pruned %v, %v", pruned, err)
+	}
+	names, _ := Archives(ctx, dest)
+	if len(names) != 2 || names[1] != "quiz-backup-20260304T020000Z.tar.gz" {
+		t.Fatalf("kept %v", names)
+	}
+
+	// into a directory to look at first, then back in place
+	out := filepath.Join(dir, "check")
+	written, err := Restore(ctx, dest, names[1], out)
+	if err != nil || len(written) != 2 {
+		t.Fatalf("restored %v, %v", written, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(out, "stats.json")); string(data) != history {
+		t.Fatalf("restored history %q", data)
+	}
+	os.Remove(bank)
+	if _, err := Restore(ctx, dest, names[1], ""); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(bank); string(data) != `[{"question":"Sky?"}]` {
+		t.Fatalf("restored bank %q", data)
+	}
+}
+
+func TestSchedulerBacksUpEveryDayAtItsTime(t *testing.T) {
+	dir := t.TempDir()
+	bank := filepath.Join(dir, "questions.json")
+	os.WriteFile(bank, []byte("[]"), 0o644)
+	now := time.Date(2026, 3, 1, 14, 0, 0, 0, time.Local)
+	var waits []time.Duration
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &Scheduler{Dest: Dir(filepath.Join(dir, "backups")), Sources: []Source{{Path: bank}}, At: 2 * time.Hour, Keep: 2}
+	s.now = func() time.Time { return now }
+	s.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		if len(waits) > 3 {
+			cancel()
+			return nil
+		}
+		now = now.Add(d)
+		ch := make(chan time.Time, 1)
+		ch <- now
+		return ch
+	}
+	s.Run(ctx)
+	if waits[0] != 12*time.Hour || waits[1] != 24*time.Hour {
+		t.Fatalf("waited %v", waits)
+	}
+	names, _ := Archives(context.Background(), s.Dest)
+	if len(names) != 2 || names[1] != Name(time.Date(2026, 3, 4, 2, 0, 0, 0, time.Local)) {
+		t.Fatalf("kept %v", names)
+	}
+}
+
+func TestS3DestinationListsAndDeletes(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			prefix := r.URL.Query().Get("prefix")
+			var keys []string
+			for path := range objects {
+				if key := strings.TrimPrefix(path, "/quiz/"); strings.HasPrefix(key, prefix) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			fmt.Fprint(w, "<ListBucketResult>")
+			for _, k := range keys {
+				fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", k)
+			}
+			fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+		case r.Method == http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case r.Method == http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case r.Method == http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	dest, err := Open("s3://quiz/nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects["/quiz/other/unrelated.json"] = []byte("{}")
+	ctx := context.Background()
+	day := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	sources := []Source{{Path: "stats.json", Read: func() ([]byte, error) { return []byte("{}"), nil }}}
+	for i := range 3 {
+		if _, err := Take(ctx, dest, sources, day.AddDate(0, 0, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Prune(ctx, dest, 1); err != nil {
+		t.Fatal(err)
+	}
+	names, err := Archives(ctx, dest)
+	if err != nil || len(names) != 1 || names[0] != Name(day.AddDate(0, 0, 2)) {
+		t.Fatalf("kept %v, %v", names, err)
+	}
+	if _, ok := objects["/quiz/nightly/"+names[0]]; !ok || len(objects) != 2 {
+		t.Fatalf("objects %v", objects)
+	}
+	if written, err := Restore(ctx, dest, names[0], t.TempDir()); err != nil || len(written) != 1 {
+		t.Fatalf("restored %v, %v", written, err)
+	}
+}
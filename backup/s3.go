@@ -0,0 +1,49 @@
+package backup
+
+import (
+	"context"
+	"strings"
+
+	"quiz-cli/storage"
+)
+
+// s3Dest keeps the archives in an S3-compatible bucket under prefix.
+type s3Dest struct {
+	store  *storage.S3Store
+	prefix string
+}
+
+// key is the object key of the archive name.
+func (s s3Dest) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + "/" + name
+}
+
+func (s s3Dest) Put(ctx context.Context, name string, data []byte) error {
+	return s.store.Put(ctx, s.key(name), data)
+}
+
+func (s s3Dest) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.store.Get(ctx, s.key(name))
+}
+
+func (s s3Dest) Delete(ctx context.Context, name string) error {
+	return s.store.Delete(ctx, s.key(name))
+}
+
+// List returns the names of the objects directly under the prefix.
+func (s s3Dest) List(ctx context.Context) ([]string, error) {
+	keys, err := s.store.List(ctx, s.key(""))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, k := range keys {
+		if name := strings.TrimPrefix(k, s.key("")); !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
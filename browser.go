@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+
+	"quiz-cli/qr"
+	"quiz-cli/webapp"
+)
+
+// openBrowser opens link in the desktop's default browser.
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// announce opens link in the browser and prints a QR code of the LAN address
+// for phones, reporting what it could not do to w.
+func announce(w io.Writer, link string, browser bool) {
+	if browser {
+		if err := openBrowser(link); err != nil {
+			fmt.Fprintf(w, "Could not open a browser (%v); visit %s\n", err, link)
+		}
+	}
+	lan := webapp.LANURL(link)
+	if lan == "" {
+		fmt.Fprintln(w, "Listening on this machine only; serve with -addr :PORT to reach it from a phone.")
+		return
+	}
+	code, err := qr.Encode(lan)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "On a phone on the same network, scan or visit %s\n", lan)
+	_ = code.WriteTerminal(w)
+}
+
+// presenterKey returns a random key for the presenter view's link.
+func presenterKey() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating presenter key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// announcePresenter prints the presenter view's private link and the join
+// address and, with browser, opens the presenter view. The join page's QR code
+// is on the presenter view, for the room to scan.
+func announcePresenter(w io.Writer, link, key string, browser bool) {
+	view := link + "/present?key=" + key
+	fmt.Fprintf(w, "Presenter view (keep this link to yourself): %s\n", view)
+	join := webapp.LANURL(link)
+	if join == "" {
+		fmt.Fprintln(w, "Listening on this machine only; serve with -addr :PORT so phones can join.")
+		join = link
+	}
+	fmt.Fprintf(w, "Participants join at %s/join\n", join)
+	if browser {
+		if err := openBrowser(view); err != nil {
+			fmt.Fprintf(w, "Could not open a browser (%v); visit the presenter view above.\n", err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package challenge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"quiz-cli/storage"
+)
+
+// Entry is one player's result on a challenge.
+type Entry struct {
+	Name     string    `json:"name"`
+	Score    int       `json:"score"`
+	Answered int       `json:"answered"`
+	Total    int       `json:"total"`
+	Seconds  float64   `json:"seconds"`
+	At       time.Time `json:"at"`
+}
+
+// Percent is the first-attempt score as a percentage of the challenge.
+func (e Entry) Percent() float64 {
+	if e.Total == 0 {
+		return 0
+	}
+	return float64(e.Score) * 100 / float64(e.Total)
+}
+
+// StreakKey is the key sudden-death runs are ranked under, whatever their
+// questions or order; their Score is the streak.
+const StreakKey = "sudden-death"
+
+// Board is a JSON-file backed leaderboard per challenge Key. A Board is safe
+// for concurrent use.
+type Board struct {
+	path    string
+	Entries map[string][]Entry `json:"challenges"`
+	mu      sync.Mutex
+}
+
+// OpenBoard loads the leaderboard at path, returning an empty board if it does
+// not exist yet. path may be an s3:// location (see storage.Resolve).
+func OpenBoard(ctx context.Context, path string) (*Board, error) {
+	b := &Board{path: path, Entries: map[string][]Entry{}}
+	data, err := storage.ReadFile(ctx, path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	if b.Entries == nil {
+		b.Entries = map[string][]Entry{}
+	}
+	return b, nil
+}
+
+// Add records e under the challenge key and returns its 1-based rank.
+func (b *Board) Add(key string, e Entry) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := append(b.Entries[key], e)
+	sortEntries(entries)
+	b.Entries[key] = entries
+	for i := range entries {
+		if entries[i] == e {
+			return i + 1
+		}
+	}
+	return len(entries)
+}
+
+// Top returns up to n entries for key, best first: highest score, then
+// fastest, then earliest.
+func (b *Board) Top(key string, n int) []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := b.Entries[key]
+	if n > len(entries) {
+		n = len(entries)
+	}
+	out := make([]Entry, n)
+	copy(out, entries)
+	return out
+}
+
+// Save writes the board back to its path.
+func (b *Board) Save(ctx context.Context) error {
+	b.mu.Lock()
+	data, err := json.MarshalIndent(b, "", "  ")
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(ctx, b.path, data)
+}
+
+func sortEntries(es []Entry) {
+	sort.SliceStable(es, func(i, j int) bool {
+		if es[i].Score != es[j].Score {
+			return es[i].Score > es[j].Score
+		}
+		if es[i].Seconds != es[j].Seconds {
+			return es[i].Seconds < es[j].Seconds
+		}
+		return es[i].At.Before(es[j].At)
+	})
+}
@@ -0,0 +1,97 @@
+// Package challenge turns a finished run into a shareable code that replays
+// the same questions in the same order, and keeps a leaderboard per code.
+package challenge
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// Challenge is a reproducible run: the questions it covers, by ID, and the
+// seed that orders them.
+type Challenge struct {
+	Seed int64
+	IDs  []string
+}
+
+// New returns the challenge that replays a session seeded with seed over qs.
+func New(seed int64, qs []quiz.Question) Challenge {
+	ids := make([]string, len(qs))
+	for i, q := range qs {
+		ids[i] = q.ID
+	}
+	return Challenge{Seed: seed, IDs: ids}
+}
+
+// Code encodes c as a URL-safe string.
+func (c Challenge) Code() string {
+	raw := strconv.FormatInt(c.Seed, 36) + ":" + strings.Join(c.IDs, ",")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Key is a short, stable name for c, used to group leaderboard entries.
+func (c Challenge) Key() string {
+	sum := sha256.Sum256([]byte(c.Code()))
+	return "c" + hex.EncodeToString(sum[:4])
+}
+
+// Parse decodes a code produced by Code.
+func Parse(code string) (Challenge, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(code))
+	if err != nil {
+		return Challenge{}, errors.New("challenge code is malformed")
+	}
+	seed, ids, ok := strings.Cut(string(raw), ":")
+	if !ok || ids == "" {
+		return Challenge{}, errors.New("challenge code is malformed")
+	}
+	c := Challenge{IDs: strings.Split(ids, ",")}
+	if c.Seed, err = strconv.ParseInt(seed, 36, 64); err != nil {
+		return Challenge{}, errors.New("challenge code is malformed")
+	}
+	return c, nil
+}
+
+// Select returns the challenge's questions from bank, in challenge order. It
+// fails if the bank no longer has one of them.
+func (c Challenge) Select(bank []quiz.Question) ([]quiz.Question, error) {
+	byID := make(map[string]quiz.Question, len(bank))
+	for _, q := range bank {
+		byID[q.ID] = q
+	}
+	out := make([]quiz.Question, 0, len(c.IDs))
+	var missing []string
+	for _, id := range c.IDs {
+		q, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		out = append(out, q)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("challenge needs questions missing from this bank: %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// Matches reports whether qs are exactly the challenge's questions in order,
+// so a session over qs seeded with c.Seed replays it.
+func (c Challenge) Matches(qs []quiz.Question) bool {
+	if len(qs) != len(c.IDs) {
+		return false
+	}
+	for i, q := range qs {
+		if q.ID != c.IDs[i] {
+			return false
+		}
+	}
+	return true
+}
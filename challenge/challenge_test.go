@@ -0,0 +1,58 @@
+package challenge
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+func TestCodeRoundTripAndSelect(t *testing.T) {
+	bank := []quiz.Question{{ID: "q1"}, {ID: "q2"}, {ID: "q3"}}
+	ch := New(-42, []quiz.Question{bank[2], bank[0]})
+	got, err := Parse(ch.Code())
+	if err != nil || got.Seed != -42 || strings.Join(got.IDs, ",") != "q3,q1" || got.Key() != ch.Key() {
+		t.Fatalf("Parse = %+v, %v", got, err)
+	}
+	qs, err := got.Select(bank)
+	if err != nil || !got.Matches(qs) || got.Matches(bank) {
+		t.Fatalf("Select = %+v, %v", qs, err)
+	}
+	if _, err := got.Select(bank[:2]); err == nil || !strings.Contains(err.Error(), "q3") {
+		t.Fatalf("Select on a bank without q3 = %v", err)
+	}
+	if _, err := Parse("not a code!"); err == nil {
+		t.Fatalf("bad code accepted")
+	}
+}
+
+func TestBoardRanksAndPersists(t *testing.T) {
+	ctx := context.Background()
+	b, err := OpenBoard(ctx, filepath.Join(t.TempDir(), "board.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.Add("c1", Entry{Name: "slow", Score: 8, Total: 10, Seconds: 90, At: at})
+	b.Add("c1", Entry{Name: "low", Score: 5, Total: 10, Seconds: 30, At: at})
+	if rank := b.Add("c1", Entry{Name: "fast", Score: 8, Total: 10, Seconds: 60, At: at}); rank != 1 {
+		t.Fatalf("rank = %d, want 1", rank)
+	}
+	if err := b.Save(ctx); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	reopened, err := OpenBoard(ctx, b.path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	top := reopened.Top("c1", 2)
+	if len(top) != 2 || top[0].Name != "fast" || top[1].Name != "slow" || top[0].Percent() != 80 {
+		t.Fatalf("top = %+v", top)
+	}
+	if len(reopened.Top("other", 5)) != 0 {
+		t.Fatalf("unknown challenge should have no entries")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"quiz-cli/backup"
+	"quiz-cli/stats"
+)
+
+// startBackups backs up the bank, and the history in store if any, every day
+// at the HH:MM time at, into the directory or s3:// location to, keeping the
+// latest keep backups.
+func startBackups(to, at string, keep int, bankPath string, store *stats.Store) error {
+	if keep < 1 {
+		return fmt.Errorf("-backup-keep must be at least 1, got %d", keep)
+	}
+	offset, err := backup.ParseTimeOfDay(at)
+	if err != nil {
+		return fmt.Errorf("-backup-at: %w", err)
+	}
+	dest, err := backup.Open(to)
+	if err != nil {
+		return err
+	}
+	sources := []backup.Source{{Path: bankPath}}
+	if store != nil {
+		// snapshot the history from memory, so a backup never catches the
+		// file halfway through a save
+		sources = append(sources, backup.Source{Path: store.Path(), Read: store.Snapshot})
+	}
+	s := &backup.Scheduler{Dest: dest, Sources: sources, At: offset, Keep: keep, Logf: log.Printf}
+	go s.Run(context.Background())
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := newFlagSet("restore", "[backup]")
+	from := fs.String("from", "", "directory or s3://bucket/prefix the backups were taken to (serve -backup-to)")
+	list := fs.Bool("list", false, "list the backups instead of restoring one")
+	dir := fs.String("to", "", "write the files into this directory instead of back to their original paths")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if *from == "" || fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("need -from and at most one backup name")
+	}
+	ctx := context.Background()
+	dest, err := backup.Open(*from)
+	if err != nil {
+		return err
+	}
+	names, err := backup.Archives(ctx, dest)
+	if err != nil {
+		return err
+	}
+	if *list {
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+	name := fs.Arg(0)
+	if name == "" {
+		if len(names) == 0 {
+			return fmt.Errorf("no backups in %s", *from)
+		}
+		name = names[len(names)-1]
+	}
+	written, err := backup.Restore(ctx, dest, name, *dir)
+	for _, path := range written {
+		fmt.Printf("restored %s\n", path)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored %d files from %s\n", len(written), name)
+	return nil
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-cli/enrich"
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+	"quiz-cli/stats"
+	"quiz-cli/storage"
+	"quiz-cli/ui/cli"
+)
+
+func runValidate(args []string) error {
+	fs := newFlagSet("validate", "bank.json...")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"questions.json"}
+	}
+	ctx := context.Background()
+	failed := 0
+	for _, path := range paths {
+		questions, err := readBank(ctx, path)
+		if err == nil {
+			err = quiz.Validate(questions)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("%s: invalid\n%v\n", path, err)
+			continue
+		}
+		fmt.Printf("%s: ok (%d questions)\n", path, len(questions))
+	}
+	if failed > 0 {
+		return &exitError{code: cli.ExitBankInvalid}
+	}
+	return nil
+}
+
+func runLint(args []string) error {
+	fs := newFlagSet("lint", "bank.json...")
+	minLevel := fs.String("min", "info", "least severe findings to list: info, warning or error (the summary counts them all)")
+	failLevel := fs.String("fail", "error", "exit with status 4 on findings this severe or worse: info, warning, error, or none")
+	asJSON := fs.Bool("json", false, "print each bank's report as JSON")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	least, err := quiz.ParseSeverity(*minLevel)
+	if err != nil {
+		return fmt.Errorf("-min: %w", err)
+	}
+	fail := quiz.Severity(-1)
+	if *failLevel != "none" {
+		if fail, err = quiz.ParseSeverity(*failLevel); err != nil {
+			return fmt.Errorf("-fail: %w", err)
+		}
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"questions.json"}
+	}
+
+	ctx := context.Background()
+	failed := false
+	type bankReport struct {
+		Bank string `json:"bank"`
+		quiz.LintReport
+	}
+	var reports []bankReport
+	for _, path := range paths {
+		questions, err := readBank(ctx, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		rep := quiz.Lint(questions)
+		if worst, ok := rep.Worst(); ok && fail >= 0 && worst >= fail {
+			failed = true
+		}
+		if *asJSON {
+			reports = append(reports, bankReport{path, rep})
+			continue
+		}
+		printLint(os.Stdout, path, rep, least)
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	if failed {
+		return &exitError{code: cli.ExitBankInvalid}
+	}
+	return nil
+}
+
+// printLint writes rep for the bank at path: the answers per letter, each
+// finding at least as severe as least, and a count of findings by severity and check.
+func printLint(w io.Writer, path string, rep quiz.LintReport, least quiz.Severity) {
+	fmt.Fprintf(w, "%s: %d questions\n", path, rep.Questions)
+	var letters []string
+	for _, ls := range rep.Letters {
+		share := 0.0
+		if rep.Questions > 0 {
+			share = float64(ls.Count) * 100 / float64(rep.Questions)
+		}
+		letters = append(letters, fmt.Sprintf("%s %d (%.0f%%)", ls.Letter, ls.Count, share))
+	}
+	fmt.Fprintf(w, "  answers: %s\n", strings.Join(letters, ", "))
+	for _, is := range rep.Issues {
+		if is.Severity < least {
+			continue
+		}
+		where := "bank"
+		if is.Question > 0 {
+			where = fmt.Sprintf("Q%d", is.Question)
+			if is.ID != "" {
+				where += " (" + is.ID + ")"
+			}
+		}
+		fmt.Fprintf(w, "  %-7s %-16s %s: %s\n", is.Severity, is.Check, where, is.Message)
+	}
+	checks := map[string]int{}
+	for _, is := range rep.Issues {
+		checks[is.Check]++
+	}
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var byCheck []string
+	for _, name := range names {
+		byCheck = append(byCheck, fmt.Sprintf("%s %d", name, checks[name]))
+	}
+	summary := fmt.Sprintf("  %s, %s, %s", plural(rep.Count(quiz.SeverityError), "error"), plural(rep.Count(quiz.SeverityWarning), "warning"), plural(rep.Count(quiz.SeverityInfo), "note"))
+	if len(byCheck) > 0 {
+		summary += " (" + strings.Join(byCheck, ", ") + ")"
+	}
+	fmt.Fprintln(w, summary)
+}
+
+func runStatsBank(args []string) error {
+	fs := newFlagSet("stats-bank", "bank.json...")
+	statsPath := fs.String("stats", "", "answer history to sort the questions into easy, medium and hard by how often they were missed")
+	asJSON := fs.Bool("json", false, "print each bank's statistics as JSON")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"questions.json"}
+	}
+
+	ctx := context.Background()
+	var store *stats.Store
+	if *statsPath != "" {
+		var err error
+		if store, err = stats.Open(ctx, *statsPath); err != nil {
+			return err
+		}
+	}
+	type bankStats struct {
+		Bank string `json:"bank"`
+		quiz.BankStats
+		Difficulty *stats.DifficultyBands `json:"difficulty,omitempty"`
+	}
+	var all []bankStats
+	for _, path := range paths {
+		questions, err := readBank(ctx, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		st := bankStats{Bank: path, BankStats: quiz.Describe(questions)}
+		if store != nil {
+			bands := stats.Bands(questions, store)
+			st.Difficulty = &bands
+		}
+		if *asJSON {
+			all = append(all, st)
+			continue
+		}
+		printBankStats(os.Stdout, path, st.BankStats, st.Difficulty, questions)
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(all, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+// printBankStats writes st for the bank at path, with the difficulty bands
+// when there is a history, and lists the questions of qs without an
+// explanation.
+func printBankStats(w io.Writer, path string, st quiz.BankStats, bands *stats.DifficultyBands, qs []quiz.Question) {
+	share := func(n int) string {
+		if st.Questions == 0 {
+			return fmt.Sprint(n)
+		}
+		return fmt.Sprintf("%d (%.0f%%)", n, float64(n)*100/float64(st.Questions))
+	}
+	fmt.Fprintf(w, "%s: %s, %.1f options on average\n", path, plural(st.Questions, "question"), st.AvgOptions)
+	var domains []string
+	for _, d := range st.Domains {
+		domains = append(domains, fmt.Sprintf("%d: %s", d.Domain, share(d.Questions)))
+	}
+	fmt.Fprintf(w, "  domains:    %s\n", strings.Join(domains, ", "))
+	var tags []string
+	for _, t := range st.Tags {
+		tags = append(tags, fmt.Sprintf("%s %d", t.Tag, t.Questions))
+	}
+	if st.Untagged > 0 {
+		tags = append(tags, fmt.Sprintf("untagged %d", st.Untagged))
+	}
+	fmt.Fprintf(w, "  tags:       %s\n", strings.Join(tags, ", "))
+	var letters []string
+	for _, ls := range st.Letters {
+		letters = append(letters, fmt.Sprintf("%s %s", ls.Letter, share(ls.Count)))
+	}
+	fmt.Fprintf(w, "  answers:    %s\n", strings.Join(letters, ", "))
+	if bands != nil {
+		fmt.Fprintf(w, "  difficulty: easy %d, medium %d, hard %d, not attempted %d\n", bands.Easy, bands.Medium, bands.Hard, bands.Unseen)
+	}
+	if len(st.NoExplanation) == 0 {
+		fmt.Fprintln(w, "  every question has an explanation")
+		return
+	}
+	fmt.Fprintf(w, "  %s without an explanation:\n", plural(len(st.NoExplanation), "question"))
+	for _, n := range st.NoExplanation {
+		q := qs[n-1]
+		where := fmt.Sprintf("Q%d", n)
+		if q.ID != "" {
+			where += " (" + q.ID + ")"
+		}
+		fmt.Fprintf(w, "    %s: %s\n", where, markdown.Plain(q.Prompt))
+	}
+}
+
+func runShow(args []string) error {
+	fs := newFlagSet("show", "<number|id>")
+	bankPath := fs.String("bank", "questions.json", "question bank to show the question from")
+	reveal := fs.Bool("reveal", false, "also print the answer, the explanation and the source")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("show takes one question: its number in the bank or its ID")
+	}
+	questions, err := loadBank(context.Background(), *bankPath)
+	if err != nil {
+		return err
+	}
+	n := findQuestion(questions, fs.Arg(0))
+	if n == 0 {
+		return fmt.Errorf("no question %q in %s; give its ID or a number from 1 to %d", fs.Arg(0), *bankPath, len(questions))
+	}
+	printQuestion(os.Stdout, n, questions[n-1], *reveal)
+	return nil
+}
+
+// findQuestion returns the number, counted from 1, of the question with ID
+// term, or else of the question numbered term, or 0 when there is neither.
+func findQuestion(questions []quiz.Question, term string) int {
+	for i, q := range questions {
+		if q.ID != "" && q.ID == term {
+			return i + 1
+		}
+	}
+	if n, err := strconv.Atoi(term); err == nil && n >= 1 && n <= len(questions) {
+		return n
+	}
+	return 0
+}
+
+// printQuestion writes question n as plain text, ready to paste into a chat:
+// its header, prompt and options, and with reveal the answer, explanation and
+// source.
+func printQuestion(w io.Writer, n int, q quiz.Question, reveal bool) {
+	header := fmt.Sprintf("Q%d (Domain %d", n, q.Domain)
+	if q.ID != "" {
+		header += ", " + q.ID
+	}
+	header += ")"
+	if len(q.Tags) > 0 {
+		header += " [" + strings.Join(q.Tags, ", ") + "]"
+	}
+	fmt.Fprintf(w, "%s\n\n", header)
+	if q.Stem != nil {
+		title := "Scenario"
+		if q.Stem.Title != "" {
+			title += ": " + q.Stem.Title
+		}
+		fmt.Fprintf(w, "%s\n%s\n\n", title, markdown.Plain(q.Stem.Text))
+	}
+	fmt.Fprintf(w, "%s\n\n", markdown.Plain(q.Prompt))
+	letters := make([]string, 0, len(q.Options))
+	for letter := range q.Options {
+		letters = append(letters, letter)
+	}
+	sort.Strings(letters)
+	for _, letter := range letters {
+		fmt.Fprintf(w, "  %s) %s\n", letter, markdown.Plain(q.Options[letter]))
+	}
+	if q.Image != "" {
+		fmt.Fprintf(w, "\nImage: %s\n", q.Image)
+	}
+	if !reveal {
+		return
+	}
+	fmt.Fprintf(w, "\nAnswer: %s) %s\n", q.Answer, markdown.Plain(q.Options[q.Answer]))
+	if q.Explanation != "" {
+		fmt.Fprintf(w, "\n%s\n", markdown.Plain(q.Explanation))
+	}
+	if q.Source != "" {
+		fmt.Fprintf(w, "\nSource: %s\n", q.Source)
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func runMerge(args []string) error {
+	fs := newFlagSet("merge", "a.json b.json...")
+	out := fs.String("o", "merged.json", "file to write the merged bank to")
+	similarity := fs.Float64("similarity", quiz.DefaultSimilarity, "word overlap (0-1) at which prompts are reported as near-duplicates")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return fmt.Errorf("need at least two banks to merge")
+	}
+
+	ctx := context.Background()
+	var banks [][]quiz.Question
+	for _, name := range fs.Args() {
+		qs, err := readBank(ctx, name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		banks = append(banks, qs)
+	}
+	res := quiz.Merge(*similarity, banks...)
+	for _, c := range res.Conflicts {
+		fmt.Printf("conflict: %q answers %s, but another bank answers %s (kept the first)\n", c.Kept.Prompt, c.Kept.Answer, c.Other.Answer)
+	}
+	for _, d := range res.Duplicates {
+		fmt.Printf("near-duplicate (%.0f%%): %q ~ %q\n", d.Similarity*100, d.Kept.Prompt, d.Other.Prompt)
+	}
+	// keep the case studies the merged questions belong to
+	data, err := quiz.MarshalBank(quiz.Bank{Scenarios: quiz.ScenariosOf(res.Questions), Questions: res.Questions})
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteFile(ctx, *out, data); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %d questions to %s (%d exact duplicates dropped, %d conflicts, %d near-duplicates)\n",
+		len(res.Questions), *out, res.Exact, len(res.Conflicts), len(res.Duplicates))
+	return nil
+}
+
+func runImportText(args []string) error {
+	fs := newFlagSet("import-text", "notes.txt")
+	out := fs.String("o", "imported.json", "file to write the imported bank to")
+	domain := fs.Int("domain", 1, "domain for questions before any \"Domain N\" heading")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need one text file to import (- for stdin)")
+	}
+
+	ctx := context.Background()
+	name := fs.Arg(0)
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = storage.ReadFile(ctx, name)
+	}
+	if err != nil {
+		return err
+	}
+	res, err := quiz.ParseText(bytes.NewReader(data), *domain)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	for _, p := range res.Problems {
+		fmt.Printf("%s: %s\n", name, p)
+	}
+	if len(res.Questions) == 0 {
+		return fmt.Errorf("no questions found in %s", name)
+	}
+	data, err = quiz.MarshalQuestions(res.Questions)
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteFile(ctx, *out, data); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %d questions to %s (%d problems to review)\n", len(res.Questions), *out, len(res.Problems))
+	return nil
+}
+
+func runGenerate(args []string) error {
+	fs := newFlagSet("generate", "glossary.csv")
+	out := fs.String("o", "generated.json", "file to write the generated bank to")
+	domain := fs.Int("domain", 1, "domain of the generated questions")
+	options := fs.Int("options", 4, "options per question, counting the right definition (2 to 8)")
+	seed := fs.Int64("seed", 0, "seed for placing answers and picking distractors, to generate the same bank again (0 picks one)")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need one term,definition CSV file (- for stdin)")
+	}
+
+	ctx := context.Background()
+	name := fs.Arg(0)
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = storage.ReadFile(ctx, name)
+	}
+	if err != nil {
+		return err
+	}
+	terms, problems, err := quiz.ParseGlossary(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	questions, more, err := quiz.GenerateQuestions(terms, *domain, *options, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		return err
+	}
+	problems = append(problems, more...)
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", name, p)
+	}
+	if len(questions) == 0 {
+		return fmt.Errorf("no questions generated from %s; it needs at least two terms with different definitions", name)
+	}
+	data, err = quiz.MarshalQuestions(questions)
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteFile(ctx, *out, data); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %d questions to %s (%d problems to review)\n", len(questions), *out, len(problems))
+	return nil
+}
+
+func runEnrich(args []string) error {
+	fs := newFlagSet("enrich", "")
+	bankPath := fs.String("bank", "questions.json", "question bank to fill in")
+	out := fs.String("o", "", "file to write the enriched bank to (default: overwrite -bank)")
+	command := fs.String("command", "", "program that reads a question on stdin and prints an explanation, e.g. \"llm -m gpt-4o\"")
+	endpoint := fs.String("endpoint", "", "OpenAI-compatible API root to ask instead, e.g. https://api.openai.com/v1 or http://localhost:11434/v1")
+	model := fs.String("model", "gpt-4o-mini", "model to request from -endpoint")
+	apiKey := fs.String("api-key", os.Getenv("OPENAI_API_KEY"), "bearer token for -endpoint (default $OPENAI_API_KEY)")
+	limit := fs.Int("limit", 0, "draft at most this many explanations (0 for all)")
+	timeout := fs.Duration("timeout", 2*time.Minute, "time allowed for each explanation")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var ex enrich.Explainer
+	switch {
+	case (*command == "") == (*endpoint == ""):
+		return fmt.Errorf("enrich needs exactly one of -command or -endpoint")
+	case *command != "":
+		ex = enrich.NewCommand(*command)
+	default:
+		ex = enrich.ChatEndpoint{BaseURL: *endpoint, Model: *model, APIKey: *apiKey}
+	}
+	if *out == "" {
+		*out = *bankPath
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// keep the header so it is written back unchanged
+	bank, err := loadBankFile(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	questions := bank.Questions
+	each := enrich.ExplainerFunc(func(ctx context.Context, q quiz.Question) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		return ex.Explain(ctx, q)
+	})
+	res, err := enrich.Bank(ctx, questions, each, *limit, func(i int, err error) {
+		if err != nil {
+			fmt.Printf("Q%d: failed: %v\n", i+1, err)
+			return
+		}
+		fmt.Printf("Q%d: %s\n  %s\n", i+1, markdown.Plain(questions[i].Prompt), questions[i].Explanation)
+	})
+	if err != nil {
+		fmt.Println("Interrupted; saving the explanations drafted so far.")
+	}
+	if len(res.Drafted) == 0 {
+		fmt.Printf("No explanations drafted (%d failed).\n", len(res.Failures))
+		return nil
+	}
+	data, err := quiz.MarshalBank(bank)
+	if err != nil {
+		return err
+	}
+	// The bank is written even if ctx was cancelled, to keep the drafts.
+	if err := storage.WriteFile(context.Background(), *out, data); err != nil {
+		return err
+	}
+	fmt.Printf("Drafted %d explanations into %s (%d failed). Review them before using the bank.\n", len(res.Drafted), *out, len(res.Failures))
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"quiz-cli/daily"
+)
+
+func runDaily(args []string) error {
+	fs := newFlagSet("daily", "")
+	bankPath := fs.String("bank", "questions.json", "question bank to pick from")
+	date := fs.String("date", "", "day to pick for (YYYY-MM-DD, default today)")
+	mailTo := fs.String("mail-to", "", "comma-separated addresses to mail the digest to instead of printing it")
+	mailFrom := fs.String("mail-from", "", "sender address for -mail-to")
+	smtpAddr := fs.String("smtp", "localhost:25", "SMTP server host:port for -mail-to")
+	smtpUser := fs.String("smtp-user", "", "SMTP username (PLAIN auth over STARTTLS)")
+	smtpPassword := fs.String("smtp-password", "", "SMTP password; prefer the QUIZ_SMTP_PASSWORD variable")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	day := time.Now()
+	if *date != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", *date, time.Local); err != nil {
+			return fmt.Errorf("bad -date %q, want YYYY-MM-DD", *date)
+		}
+	}
+
+	ctx := context.Background()
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	m, err := daily.NewMessage(bank, day)
+	if err != nil {
+		return err
+	}
+	if *mailTo == "" {
+		fmt.Print(m.Text())
+		return nil
+	}
+	var to []string
+	for _, addr := range strings.Split(*mailTo, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	mailer := daily.Mailer{Addr: *smtpAddr, Username: *smtpUser, Password: *smtpPassword, From: *mailFrom}
+	if err := mailer.Send(m, to); err != nil {
+		return err
+	}
+	fmt.Printf("Sent the question for %s to %d recipients.\n", day.Format("Jan 2"), len(to))
+	return nil
+}
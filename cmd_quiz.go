@@ -0,0 +1,1194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"quiz-cli/audio"
+	"quiz-cli/autosave"
+	"quiz-cli/challenge"
+	"quiz-cli/lti"
+	"quiz-cli/quiz"
+	"quiz-cli/replay"
+	"quiz-cli/report"
+	"quiz-cli/stats"
+	"quiz-cli/storage"
+	"quiz-cli/ui/cli"
+	"quiz-cli/webapp"
+	"quiz-cli/webhook"
+	"quiz-cli/xapi"
+)
+
+const underReviewBanner = "Under review: this question has been reported and is excluded from exams."
+
+// loadBank loads and validates a bank, wrapping failures so the process exits
+// with cli.ExitBankInvalid.
+func loadBank(ctx context.Context, path string) ([]quiz.Question, error) {
+	b, err := loadBankFile(ctx, path)
+	return b.Questions, err
+}
+
+// loadBankFile is loadBank keeping the bank's header.
+func loadBankFile(ctx context.Context, path string) (quiz.Bank, error) {
+	b, err := readBankFile(ctx, path)
+	if err == nil {
+		err = quiz.Validate(b.Questions)
+	}
+	if err != nil {
+		return quiz.Bank{}, &exitError{code: cli.ExitBankInvalid, err: fmt.Errorf("invalid question bank %s: %w", path, err)}
+	}
+	return b, nil
+}
+
+// readBank reads a bank's questions from a local path or an s3:// location.
+func readBank(ctx context.Context, path string) ([]quiz.Question, error) {
+	b, err := readBankFile(ctx, path)
+	return b.Questions, err
+}
+
+// readBankFile reads a bank with its header.
+func readBankFile(ctx context.Context, path string) (quiz.Bank, error) {
+	data, err := storage.ReadFile(ctx, path)
+	if err != nil {
+		return quiz.Bank{}, err
+	}
+	return quiz.ParseBank(data)
+}
+
+// openStats opens the history at statsPath and pins it to the bank at
+// bankPath. When the history was recorded against another bank or version,
+// it prints what changed to stderr and, if ask is set and stdin is a
+// terminal, offers to move the history of questions whose ID changed.
+func openStats(ctx context.Context, statsPath, bankPath string, ask bool) (*stats.Store, error) {
+	store, err := stats.Open(ctx, statsPath)
+	if err != nil {
+		return nil, err
+	}
+	bank, err := readBankFile(ctx, bankPath)
+	if err != nil {
+		return nil, err
+	}
+	check := store.CheckBank(bank.BankInfo, bank.Questions)
+	store.PinBank(bank.BankInfo)
+	if !check.Changed() {
+		return store, nil
+	}
+	w := os.Stderr
+	fmt.Fprintf(w, "Warning: %s was recorded against %s; this is %s.\n", statsPath, check.Pinned, check.Current)
+	for _, e := range bank.Since(check.Pinned.Version) {
+		fmt.Fprintf(w, "  v%s: %s\n", e.Version, e.Notes)
+	}
+	fmt.Fprintf(w, "Questions edited since (their history still applies): %d. History without a matching question: %d.\n",
+		len(check.Edited), len(check.Missing))
+	if len(check.Remap) == 0 {
+		return store, nil
+	}
+	fmt.Fprintf(w, "Of those, %d look like questions whose ID changed.\n", len(check.Remap))
+	if !ask || !isTerminal(os.Stdin) {
+		return store, nil
+	}
+	fmt.Fprint(w, "Move their history to the new IDs? [y/N] ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+		return store, nil
+	}
+	fmt.Fprintf(w, "Moved the history of %d questions.\n", store.Remap(check.Remap))
+	return store, store.Save(ctx)
+}
+
+// mediaDir returns the directory relative image paths in the bank at path are
+// resolved against, or "" for banks in object storage.
+func mediaDir(path string) string {
+	if strings.HasPrefix(path, "s3://") {
+		return ""
+	}
+	return filepath.Dir(path)
+}
+
+// examSections builds the section plan from the -sections and -section-time
+// flags, or returns nil for an unsectioned run.
+func examSections(questions []quiz.Question, spec string, perDomain time.Duration) ([]quiz.Section, error) {
+	if spec != "" {
+		return quiz.ParseSections(spec)
+	}
+	if perDomain > 0 {
+		return quiz.DomainSections(questions, perDomain), nil
+	}
+	return nil, nil
+}
+
+// mockExam samples questions to the blueprint at path (the CSSLP outline
+// when path is empty) and returns them with the single timed section they run
+// in. Departures from the blueprint are printed to w.
+func mockExam(ctx context.Context, questions []quiz.Question, path string, w io.Writer) ([]quiz.Question, []quiz.Section, error) {
+	bp := quiz.CSSLPBlueprint
+	if path != "" {
+		data, err := storage.ReadFile(ctx, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if bp, err = quiz.ParseBlueprint(data); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	m, err := bp.Sample(questions, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, note := range m.Notes {
+		fmt.Fprintln(w, note)
+	}
+	fmt.Fprintf(w, "Mock exam: %d questions in %s.\n", len(m.Questions), m.Budget.Round(time.Second))
+	return m.Questions, m.Sections(), nil
+}
+
+// openAutosave returns a saver writing every answers to path, or to the
+// default file for the bank and front end, and the unfinished run saved
+// there, if any.
+func openAutosave(ctx context.Context, path, bankPath, frontEnd string, every int) (*autosave.Saver, *quiz.State) {
+	if path == "" {
+		path = autosave.Path(bankPath, frontEnd)
+	}
+	st, ok, err := autosave.Load(ctx, path)
+	if err != nil {
+		slog.Warn("ignoring the saved run", "file", path, "err", err)
+	}
+	saver := autosave.New(path, every)
+	if !ok {
+		return saver, nil
+	}
+	return saver, &st
+}
+
+// resumeMockExam rebuilds the mock exam of the saved run, when there is one
+// whose questions are all still in the bank, and samples a new one (see
+// mockExam) otherwise. Questions under review in store, if any, are left out
+// of both, as they are from -exam runs.
+func resumeMockExam(ctx context.Context, store *stats.Store, bank []quiz.Question, path string, saved *quiz.State, w io.Writer) ([]quiz.Question, []quiz.Section, error) {
+	if store != nil {
+		bank = store.ExamQuestions(bank)
+	}
+	if saved != nil && len(saved.Sections) == 1 && saved.Sections[0].Name == "Mock exam" {
+		if questions, err := saved.Pick(bank); err == nil {
+			m := quiz.MockExam{Questions: questions, Budget: saved.Sections[0].Budget}
+			return m.Questions, m.Sections(), nil
+		}
+	}
+	return mockExam(ctx, bank, path, w)
+}
+
+// checkMockExam rejects flags that conflict with -mock-exam.
+func checkMockExam(mock bool, blueprint, sections string, sectionTime time.Duration, code string) error {
+	if !mock {
+		if blueprint != "" {
+			return fmt.Errorf("-blueprint only applies with -mock-exam")
+		}
+		return nil
+	}
+	if sections != "" || sectionTime > 0 {
+		return fmt.Errorf("-mock-exam sets its own time limit; drop -sections and -section-time")
+	}
+	if code != "" {
+		return fmt.Errorf("-mock-exam cannot replay a challenge")
+	}
+	return nil
+}
+
+// loadSelection loads a bank, applies the local overrides and narrows it
+// with quiz.Select.
+func loadSelection(ctx context.Context, path string, overrides quiz.Overrides, only, rng string) ([]quiz.Question, error) {
+	questions, err := loadBank(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if questions, _, err = overrides.Apply(questions); err != nil {
+		return nil, err
+	}
+	questions, err = quiz.Select(questions, only, rng)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no questions selected")
+	}
+	return questions, nil
+}
+
+func runQuiz(args []string) error {
+	fs := newFlagSet("quiz", "")
+	bankPath := fs.String("bank", "questions.json", "question bank to load")
+	overridesPath := fs.String("overrides", "", "local JSON file of corrected answers and explanations by question ID, applied over the bank, e.g. {\"q42\":{\"answer\":\"C\",\"reason\":\"upstream issue #12\"}}")
+	statsPath := fs.String("stats", "", "record answer history to this JSON file")
+	syncTo := fs.String("sync", "", "with -stats, pull the history from this state archive (s3://bucket/key, davs://host/path or a file) before the session and push it back after")
+	exam := fs.Bool("exam", false, "exam mode: skip questions under review")
+	penalty := fs.Float64("penalty", 0, "with -exam, share of a question's points each wrong answer costs, e.g. 0.25")
+	quiet := fs.Bool("quiet", false, "print only the final JSON result")
+	vault := fs.String("obsidian-vault", "", "after the run, write a note for each missed question into this Obsidian vault folder, and one for the run linking them")
+	reportPath := fs.String("report", "", "write a completion report to this .pdf or .html file after the run, or a study sheet of the missed questions to a .md file")
+	flashcards := fs.Bool("flashcards", false, "study as flashcards: recall the answer, reveal it with Space, grade yourself 1-4")
+	newCards := fs.Int("new", 20, "with -flashcards and -stats, new cards to add per session (-1 for all)")
+	output := fs.String("output", "text", "summary format: text, or json for a document to feed to jq or dashboards")
+	passMark := fs.Float64("pass", 0, "first-attempt percentage needed to pass (exit code 2 below it)")
+	only := fs.String("only", "", "drill only these questions: comma-separated IDs or positions, e.g. q42,q57")
+	rng := fs.String("range", "", "drill only bank positions FROM-TO, e.g. 10-30")
+	filter := fs.String("filter", "", "with -stats, drill only the questions matching this saved filter (see the filters command)")
+	sectionSpec := fs.String("sections", "", "run as a sectioned exam, e.g. 4=20m,5=15m (domains in order, each locked once done)")
+	sectionTime := fs.Duration("section-time", 0, "run one timed section per domain, each with this budget")
+	mock := fs.Bool("mock-exam", false, "sit a mock exam sampled to the CSSLP domain weighting, question count and time limit")
+	blueprint := fs.String("blueprint", "", "with -mock-exam, JSON blueprint to use instead, e.g. {\"questions\":100,\"minutes\":120,\"domains\":{\"4\":16,\"5\":20}}")
+	images := fs.String("images", "auto", "how to draw question images: auto, placeholder, iterm2 or sixel")
+	confidence := fs.Bool("confidence", false, "ask how sure you were after each answer and report calibration")
+	confirm := fs.Bool("confirm", false, "make typing A-D select an option instead of submitting it, so Enter confirms the answer")
+	challengeCode := fs.String("challenge", "", "replay a challenge code from another run (overrides -only and -range)")
+	boardPath := fs.String("board", "", "record the result on this challenge leaderboard file and show the standings")
+	name := fs.String("name", os.Getenv("USER"), "your name on the challenge leaderboard")
+	connect := fs.String("connect", "", "answer in the terminal on the session of a running quiz server, e.g. http://host:8080")
+	sudden := fs.Bool("sudden-death", false, "end the run at the first wrong answer and score the streak before it")
+	pace := fs.String("pace", "", "time each question, the limit shrinking over the run toward exam pace, e.g. 90s:45s from 90 seconds for the first question to 45 for the last; late answers count as misses")
+	masteryStop := fs.String("stop-at-mastery", "", "end the run once each domain's latest first tries reach an accuracy, e.g. 90/30 for 90% of the last 30, and report readiness")
+	hardest := fs.Bool("hardest-first", false, "ask the questions most often missed, then slowest answered, in the -stats history first")
+	shuffle := fs.String("shuffle", "all", "how to shuffle the questions: all across the bank, domain to keep the domains in bank order and shuffle within each, or none for bank order")
+	compact := fs.Bool("compact", false, "always use the compact layout: top-aligned screens, wrapped options and short hints (automatic under 60 columns or 20 rows)")
+	mouse := fs.Bool("mouse", false, "click an option to select it, double-click to submit it and scroll to move the selection (hold Shift to select text)")
+	domainBars := fs.Bool("domain-bars", false, "show a mini progress bar per domain beside the progress bar")
+	hideAccuracy := fs.Bool("hide-accuracy", false, "leave the first-attempt accuracy so far out of the progress line, as in an exam")
+	hook := fs.String("webhook", "", "POST a JSON summary of the finished run to this URL, e.g. a Slack incoming webhook")
+	lrs := xapiFlags(fs)
+	record := fs.String("record", "", "log the run, every selection change included, to this file for the replay command")
+	feedbackMode := fs.String("feedback", "full", "after each answer: full, no-reveal to keep the correct answer back after a miss, cram to flash right or wrong for 300ms and move on, none to say nothing until the summary (misses are not asked again), or blind to also hide the progress and counts")
+	advance := fs.Duration("advance", 0, "move on this long after the feedback instead of waiting for Enter, e.g. 3s")
+	autosaveEvery := fs.Int("autosave", 5, "save progress every N answers and resume an unfinished run on the next start (0 turns it off)")
+	autosaveFile := fs.String("autosave-file", "", "file to save progress in (default: one per bank in the temporary directory)")
+	breakEvery := fs.Int("break-every", 0, "suggest a break after this many answers, stopping the clock until you press Enter (0 never)")
+	breakAfter := fs.Duration("break-after", 0, "suggest a break after this long without one, e.g. 60m (0 never)")
+	var sound audio.Config
+	fs.StringVar(&sound.Speak, "speak", "", "command that reads each question aloud, e.g. say or espeak (text is the last argument, or replaces {})")
+	fs.StringVar(&sound.Correct, "sound-correct", "", "command to run after a correct answer, e.g. a player and sound file")
+	fs.StringVar(&sound.Incorrect, "sound-incorrect", "", "command to run after a wrong answer")
+	fs.BoolVar(&sound.Bell, "bell", false, "ring the terminal bell after a wrong answer")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	feedback, err := checkFeedback(*feedbackMode, *advance, *sudden)
+	if err != nil {
+		return err
+	}
+	if feedback.Silent && (*flashcards || sound.Correct != "" || sound.Incorrect != "" || sound.Bell) {
+		return fmt.Errorf("-feedback none cannot be combined with -flashcards, -sound-correct, -sound-incorrect or -bell")
+	}
+	if feedback.Blind && *domainBars {
+		return fmt.Errorf("-feedback blind hides the progress; drop -domain-bars")
+	}
+	if feedback.Brief && *flashcards {
+		return fmt.Errorf("-feedback cram does not apply to -flashcards")
+	}
+	breaks, err := checkBreaks(*breakEvery, *breakAfter)
+	if err != nil {
+		return err
+	}
+	if *flashcards && breaks != (quiz.Breaks{}) {
+		return fmt.Errorf("-break-every and -break-after do not apply to -flashcards")
+	}
+	if *flashcards && *vault != "" {
+		return fmt.Errorf("-obsidian-vault does not apply to -flashcards")
+	}
+	if *flashcards && *mouse {
+		return fmt.Errorf("-mouse does not apply to -flashcards")
+	}
+
+	ctx := context.Background()
+	if *connect != "" {
+		if feedback.Silent {
+			return fmt.Errorf("-connect runs the server's session; set -feedback none on the server")
+		}
+		if *flashcards || *mock || *challengeCode != "" || *reportPath != "" || *vault != "" || *output != "text" || *sudden || *hook != "" || lrs.Enabled() || *record != "" || *domainBars || *filter != "" || breaks != (quiz.Breaks{}) || *overridesPath != "" || *masteryStop != "" || *pace != "" {
+			return fmt.Errorf("-connect runs the server's session; -flashcards, -mock-exam, -challenge, -report, -obsidian-vault, -output, -sudden-death, -webhook, -lrs, -record, -domain-bars, -filter, -break-every, -break-after, -overrides, -stop-at-mastery and -pace do not apply")
+		}
+		opts := []cli.Option{cli.WithPassMark(*passMark), cli.WithFeedback(feedback)}
+		if *compact {
+			opts = append(opts, cli.WithCompactLayout())
+		}
+		if *mouse {
+			opts = append(opts, cli.WithMouse())
+		}
+		if *confidence {
+			opts = append(opts, cli.WithConfidence())
+		}
+		if *confirm {
+			opts = append(opts, cli.WithConfirmAnswers())
+		}
+		if *quiet {
+			opts = append(opts, cli.WithIO(os.Stdin, io.Discard), cli.WithJSONResult(os.Stdout))
+		}
+		return runRemote(ctx, *connect, opts...)
+	}
+	notes := io.Writer(os.Stderr)
+	if *quiet {
+		notes = io.Discard
+	}
+	overrides, err := loadOverrides(ctx, *overridesPath, *bankPath, notes)
+	if err != nil {
+		return err
+	}
+	questions, ch, err := loadChallenge(ctx, *bankPath, overrides, *challengeCode, *only, *rng)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPenalty(*penalty); err != nil {
+		return err
+	}
+	if err := checkMockExam(*mock, *blueprint, *sectionSpec, *sectionTime, *challengeCode); err != nil {
+		return err
+	}
+	if err := checkSuddenDeath(*sudden, *mock, *sectionSpec, *sectionTime); err != nil {
+		return err
+	}
+	if *sudden && *flashcards {
+		return fmt.Errorf("-sudden-death does not apply to -flashcards")
+	}
+	var stopRule *quiz.MasteryStop
+	if *masteryStop != "" {
+		r, err := quiz.ParseMasteryStop(*masteryStop)
+		if err != nil {
+			return fmt.Errorf("-stop-at-mastery: %w", err)
+		}
+		if *flashcards || *sudden || *mock || *sectionSpec != "" || *sectionTime > 0 {
+			return fmt.Errorf("-stop-at-mastery cannot be combined with -flashcards, -sudden-death, -mock-exam or sections")
+		}
+		stopRule = &r
+	}
+	if *pace != "" && *flashcards {
+		return fmt.Errorf("-pace does not apply to -flashcards")
+	}
+	pacing, err := checkPacing(*pace, *mock, *sectionSpec, *sectionTime)
+	if err != nil {
+		return err
+	}
+	if err := checkHardestFirst(*hardest, *statsPath, *challengeCode, *mock, *sectionSpec, *sectionTime); err != nil {
+		return err
+	}
+	ordering, err := checkOrdering(*shuffle, *hardest, *challengeCode, *mock, *sectionSpec, *sectionTime)
+	if err != nil {
+		return err
+	}
+	if ordering != quiz.ShuffleAll && *flashcards {
+		return fmt.Errorf("-shuffle does not apply to -flashcards")
+	}
+	if *penalty > 0 && !*exam {
+		return fmt.Errorf("-penalty only applies in exam mode; add -exam")
+	}
+	if *syncTo != "" && *statsPath == "" {
+		return fmt.Errorf("-sync keeps the -stats history in step; add -stats")
+	}
+	if err := checkFilter(*filter, *statsPath, *flashcards, *challengeCode, *mock, *sectionSpec, *sectionTime); err != nil {
+		return err
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("-output must be text or json, got %q", *output)
+	}
+	reportFormat := ""
+	if *reportPath != "" {
+		if reportFormat, err = report.Format(*reportPath); err != nil {
+			return err
+		}
+	}
+	imageMode, err := cli.ParseImageMode(*images)
+	if err != nil {
+		return err
+	}
+	sections, err := examSections(questions, *sectionSpec, *sectionTime)
+	if err != nil {
+		return err
+	}
+
+	if *flashcards {
+		cardOpts := []cli.Option{cli.WithImages(imageMode, mediaDir(*bankPath))}
+		if *compact {
+			cardOpts = append(cardOpts, cli.WithCompactLayout())
+		}
+		return studyFlashcards(ctx, questions, *bankPath, *statsPath, *syncTo, *newCards, cardOpts...)
+	}
+
+	var store *stats.Store
+	opts := []cli.Option{cli.WithPassMark(*passMark), cli.WithPenalty(*penalty), cli.WithImages(imageMode, mediaDir(*bankPath)), cli.WithOrdering(ordering)}
+	if ch != nil {
+		opts = append(opts, cli.WithSeed(ch.Seed))
+	}
+	if *statsPath != "" {
+		if store, err = openStats(ctx, *statsPath, *bankPath, !*quiet); err != nil {
+			return err
+		}
+		if *syncTo != "" {
+			syncState(ctx, store, *syncTo, false)
+			defer syncState(ctx, store, *syncTo, true)
+		}
+		if *filter != "" {
+			f, ok := store.SavedFilter(*filter)
+			if !ok {
+				return fmt.Errorf("no filter named %q; quiz-cli filters lists them", *filter)
+			}
+			if questions = store.Select(questions, f); len(questions) == 0 {
+				return fmt.Errorf("filter %s (%s) matches no questions", *filter, f)
+			}
+		}
+		opts = append(opts, noteOption(ctx, store), searchOption(ctx, store))
+		if *exam && ch == nil {
+			questions = store.ExamQuestions(questions)
+		} else {
+			opts = append(opts, cli.WithNotice(func(q quiz.Question) string {
+				if store.UnderReview(q) {
+					return underReviewBanner
+				}
+				return ""
+			}))
+		}
+	}
+	var saver *autosave.Saver
+	var saved *quiz.State
+	if *autosaveEvery > 0 {
+		saver, saved = openAutosave(ctx, *autosaveFile, *bankPath, "quiz", *autosaveEvery)
+	}
+	if *mock {
+		if questions, sections, err = resumeMockExam(ctx, store, questions, *blueprint, saved, os.Stderr); err != nil {
+			return err
+		}
+	}
+	if saver != nil {
+		opts = append(opts, cli.WithAutosave(saver, saved))
+		defer func() {
+			if err := saver.Err(); err != nil {
+				slog.Warn("saving progress", "file", saver.Path(), "err", err)
+			}
+		}()
+	}
+	if sections != nil {
+		opts = append(opts, cli.WithSections(sections))
+	}
+	if *confidence {
+		opts = append(opts, cli.WithConfidence())
+	}
+	if *confirm {
+		opts = append(opts, cli.WithConfirmAnswers())
+	}
+	if *sudden {
+		opts = append(opts, cli.WithSuddenDeath())
+	}
+	if stopRule != nil {
+		opts = append(opts, cli.WithMasteryStop(*stopRule))
+	}
+	if pacing != nil {
+		opts = append(opts, cli.WithPacing(*pacing))
+	}
+	opts = append(opts, cli.WithFeedback(feedback), cli.WithBreaks(breaks))
+	if *compact {
+		opts = append(opts, cli.WithCompactLayout())
+	}
+	if *mouse {
+		opts = append(opts, cli.WithMouse())
+	}
+	if *domainBars {
+		opts = append(opts, cli.WithDomainProgress())
+	}
+	if *hideAccuracy {
+		opts = append(opts, cli.WithoutAccuracy())
+	}
+	if *hardest {
+		opts = append(opts, cli.WithOrder(stats.HardestFirst(questions, store)))
+	}
+	// share is where the challenge code goes: stdout, unless stdout carries
+	// the JSON summary.
+	share := io.Writer(os.Stdout)
+	switch {
+	case *quiet:
+		opts = append(opts, cli.WithIO(os.Stdin, io.Discard), cli.WithJSONResult(os.Stdout))
+	case *output == "json" && !isTerminal(os.Stdout):
+		// Piped: draw the quiz on stderr so stdout is only the document.
+		opts = append(opts, cli.WithIO(os.Stdin, os.Stderr))
+	}
+	if *output == "json" {
+		opts = append(opts, cli.WithJSONSummary(os.Stdout))
+		share = os.Stderr
+	}
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rec := replay.NewRecorder(f)
+		defer func() {
+			if err := rec.Err(); err != nil {
+				slog.Warn("recording the run", "file", *record, "err", err)
+			}
+		}()
+		opts = append(opts, cli.WithReplay(rec))
+	}
+
+	app := cli.New(questions, opts...)
+	if store != nil {
+		app.AddListener(store.Listener())
+	}
+	if sound.Enabled() {
+		app.AddListener(audio.NewPlayer(sound, os.Stderr).Listener())
+	}
+	if lrs.Enabled() {
+		lrs.PassMark = *passMark
+		rec := xapi.NewRecorder(*lrs, warnWriter{})
+		defer rec.Close()
+		app.AddListener(rec.Listener())
+	}
+	start := time.Now()
+	logs.hold()
+	outcome := app.Run(ctx)
+	logs.release()
+	if !*quiet && !outcome.Interrupted {
+		entry := challenge.Entry{
+			Name:     *name,
+			Score:    outcome.Score,
+			Answered: outcome.Answered,
+			Total:    outcome.Total,
+			Seconds:  (time.Since(start) - app.Session().PausedFor()).Seconds(),
+			At:       time.Now(),
+		}
+		if err := shareChallenge(ctx, share, challenge.New(app.Session().Seed(), questions), *boardPath, entry, *sudden); err != nil {
+			return err
+		}
+	}
+	if *sudden && store != nil && !outcome.Interrupted {
+		longest, isNew := store.RecordStreak(outcome.Streak, time.Now())
+		if err := store.Save(ctx); err != nil {
+			return err
+		}
+		switch {
+		case *quiet:
+		case isNew:
+			fmt.Fprintf(share, "New longest streak: %d!\n", longest.Length)
+		case longest.Length > 0:
+			fmt.Fprintf(share, "Your longest streak is %d (%s).\n", longest.Length, longest.At.Format("2006-01-02"))
+		}
+	}
+	if *hook != "" && !outcome.Interrupted {
+		r := report.FromSession("CSSLP Review Quiz", app.Session(), start, time.Now())
+		r.Name, r.PassMark = *name, *passMark
+		if err := webhook.Post(ctx, *hook, webhook.FromReport(r)); err != nil {
+			slog.Warn("posting the webhook", "err", err)
+		}
+	}
+	if *reportPath != "" && outcome.Answered > 0 {
+		r := report.FromSession("CSSLP Review Quiz", app.Session(), start, time.Now())
+		r.Name, r.PassMark = *name, *passMark
+		var buf bytes.Buffer
+		if err := report.Write(&buf, r, reportFormat); err != nil {
+			return err
+		}
+		if err := storage.WriteFile(ctx, *reportPath, buf.Bytes()); err != nil {
+			return err
+		}
+		if !*quiet {
+			fmt.Fprintf(share, "Report written to %s\n", *reportPath)
+		}
+	}
+	if *vault != "" && outcome.Answered > 0 {
+		r := report.FromSession("CSSLP Review Quiz", app.Session(), start, time.Now())
+		r.Name, r.PassMark = *name, *passMark
+		if err := writeVault(*vault, r); err != nil {
+			return err
+		}
+		if !*quiet {
+			fmt.Fprintf(share, "%s written to %s\n", plural(len(r.Missed), "missed question note"), *vault)
+		}
+	}
+	// practise the questions requeued from the review, and those requeued
+	// from each quick session's review in turn; the first run's outcome sets
+	// the exit code
+	quick := []cli.Option{cli.WithPassMark(*passMark), cli.WithImages(imageMode, mediaDir(*bankPath)), cli.WithFeedback(feedback)}
+	if *confirm {
+		quick = append(quick, cli.WithConfirmAnswers())
+	}
+	if *compact {
+		quick = append(quick, cli.WithCompactLayout())
+	}
+	if *mouse {
+		quick = append(quick, cli.WithMouse())
+	}
+	if *hideAccuracy {
+		quick = append(quick, cli.WithoutAccuracy())
+	}
+	for requeued := outcome.Requeued; len(requeued) > 0 && ctx.Err() == nil; {
+		app := cli.New(requeued, quick...)
+		if store != nil {
+			app.AddListener(store.Listener())
+		}
+		requeued = app.Run(ctx).Requeued
+	}
+	if code := outcome.ExitCode(); code != cli.ExitPass {
+		return &exitError{code: code}
+	}
+	return nil
+}
+
+// runRemote answers the session of the quiz server at addr in the terminal;
+// the server's bank, sections and marking apply.
+func runRemote(ctx context.Context, addr string, opts ...cli.Option) error {
+	outcome, err := cli.New(nil, opts...).RunRemote(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if code := outcome.ExitCode(); code != cli.ExitPass {
+		return &exitError{code: code}
+	}
+	return nil
+}
+
+// studyFlashcards runs a flashcard session. With a stats file, it studies the
+// cards that are due plus up to newCards new ones and saves each grade to the
+// spaced-repetition schedule, syncing it with syncTo if set; otherwise it goes
+// through questions shuffled.
+func studyFlashcards(ctx context.Context, questions []quiz.Question, bankPath, statsPath, syncTo string, newCards int, opts ...cli.Option) error {
+	if statsPath == "" {
+		rand.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	} else {
+		store, err := openStats(ctx, statsPath, bankPath, true)
+		if err != nil {
+			return err
+		}
+		if syncTo != "" {
+			syncState(ctx, store, syncTo, false)
+			defer syncState(ctx, store, syncTo, true)
+		}
+		now := time.Now()
+		due := store.DueCards(questions, now, newCards)
+		if len(due) == 0 {
+			fmt.Println("No flashcards are due.")
+			if next := store.NextDue(questions, now); !next.IsZero() {
+				fmt.Printf("The next one is due %s.\n", next.Format("Mon Jan 2 15:04"))
+			}
+			return nil
+		}
+		questions = due
+		opts = append(opts, noteOption(ctx, store), cli.WithGrader(func(q quiz.Question, g quiz.Grade) {
+			store.Review(q, g, time.Now())
+			_ = store.Save(ctx)
+		}))
+	}
+	if o := cli.New(questions, opts...).RunFlashcards(ctx); o.Interrupted {
+		return &exitError{code: cli.ExitInterrupted}
+	}
+	return nil
+}
+
+// noteOption keeps the learner's question notes in store, saving after each
+// edit.
+func noteOption(ctx context.Context, store *stats.Store) cli.Option {
+	return cli.WithNotes(store.Note, func(q quiz.Question, text string) {
+		store.SetNote(q, text)
+		_ = store.Save(ctx)
+	})
+}
+
+// searchOption keeps the learner's recent searches in store, saving after
+// each search.
+func searchOption(ctx context.Context, store *stats.Store) cli.Option {
+	return cli.WithSearchHistory(store.RecentSearches(), func(term string) {
+		store.AddSearch(term)
+		_ = store.Save(ctx)
+	})
+}
+
+// loadChallenge loads the questions for a run: the challenge's, when code is
+// set, or the -only/-range selection otherwise. The local overrides apply to
+// either.
+func loadChallenge(ctx context.Context, path string, overrides quiz.Overrides, code, only, rng string) ([]quiz.Question, *challenge.Challenge, error) {
+	if code == "" {
+		questions, err := loadSelection(ctx, path, overrides, only, rng)
+		return questions, nil, err
+	}
+	ch, err := challenge.Parse(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	bank, err := loadBank(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	questions, err := ch.Select(bank)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the challenge picks from the shared bank, so the overrides come after
+	if questions, _, err = overrides.Apply(questions); err != nil {
+		return nil, nil, err
+	}
+	return questions, &ch, nil
+}
+
+// loadOverrides reads the overrides file at path, if one is given, and
+// reports to w what it corrects in the bank at bankPath.
+func loadOverrides(ctx context.Context, path, bankPath string, w io.Writer) (quiz.Overrides, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := storage.ReadFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := quiz.ParseOverrides(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	bank, err := loadBank(ctx, bankPath)
+	if err != nil {
+		return nil, err
+	}
+	_, rep, err := overrides.Apply(bank)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	fmt.Fprintf(w, "Overrides from %s correct %s of %s:\n", path, plural(len(rep.Applied), "question"), bankPath)
+	for _, a := range rep.Applied {
+		var fixes []string
+		if a.Answer != "" {
+			fixes = append(fixes, fmt.Sprintf("answer %s -> %s", a.Was, strings.ToUpper(a.Answer)))
+		}
+		if a.Explanation != "" {
+			fixes = append(fixes, "explanation")
+		}
+		if a.Source != "" {
+			fixes = append(fixes, "source")
+		}
+		line := fmt.Sprintf("  %s: %s", a.ID, strings.Join(fixes, ", "))
+		if a.Reason != "" {
+			line += " (" + a.Reason + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(rep.Unknown) > 0 {
+		fmt.Fprintf(w, "  not in the bank, so not applied: %s\n", strings.Join(rep.Unknown, ", "))
+	}
+	return overrides, nil
+}
+
+// shareChallenge prints the code that replays the run to w and, with a board,
+// records entry and prints the standings. Sudden-death runs are ranked by
+// streak on a board of their own.
+func shareChallenge(ctx context.Context, w io.Writer, ch challenge.Challenge, boardPath string, entry challenge.Entry, sudden bool) error {
+	fmt.Fprintf(w, "\nChallenge %s: others can take this exact run with\n  quiz-cli quiz -challenge %s\n", ch.Key(), ch.Code())
+	if boardPath == "" {
+		return nil
+	}
+	board, err := challenge.OpenBoard(ctx, boardPath)
+	if err != nil {
+		return err
+	}
+	key := ch.Key()
+	if sudden {
+		key = challenge.StreakKey
+	}
+	rank := board.Add(key, entry)
+	if err := board.Save(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nLeaderboard (you placed #%d):\n", rank)
+	for i, e := range board.Top(key, 10) {
+		result := fmt.Sprintf("%d/%d (%.0f%%)", e.Score, e.Total, e.Percent())
+		if sudden {
+			result = fmt.Sprintf("streak %d", e.Score)
+		}
+		fmt.Fprintf(w, "  %2d. %-16s %s  %s\n", i+1, e.Name, result, time.Duration(e.Seconds*float64(time.Second)).Round(time.Second))
+	}
+	return nil
+}
+
+func runServe(args []string) error {
+	fs := newFlagSet("serve", "")
+	addr := fs.String("addr", ":8080", "listen address")
+	bankPath := fs.String("bank", "questions.json", "question bank to load")
+	overridesPath := fs.String("overrides", "", "local JSON file of corrected answers and explanations by question ID, applied over the bank, e.g. {\"q42\":{\"answer\":\"C\",\"reason\":\"upstream issue #12\"}}")
+	statsPath := fs.String("stats", "", "record answer history to this JSON file and enable flagging")
+	reviewFlags := fs.Int("review-flags", stats.DefaultReviewPolicy.MaxFlags, "flags that put a question under review")
+	graphQL := fs.Bool("graphql", false, "also serve a GraphQL endpoint at /graphql")
+	backupTo := fs.String("backup-to", "", "back up the history and bank every night to this directory or s3://bucket/prefix")
+	backupAt := fs.String("backup-at", "02:00", "local time of day for the -backup-to backup")
+	backupKeep := fs.Int("backup-keep", 7, "how many of the latest -backup-to backups to keep")
+	grpc := fs.Bool("grpc", false, "also serve the gRPC API described at /quiz.proto")
+	tlsCert := fs.String("tls-cert", "", "serve HTTPS with this certificate file (requires -tls-key)")
+	tlsKey := fs.String("tls-key", "", "private key file for -tls-cert")
+	sectionSpec := fs.String("sections", "", "run as a sectioned exam, e.g. 4=20m,5=15m (domains in order, each locked once done)")
+	sectionTime := fs.Duration("section-time", 0, "run one timed section per domain, each with this budget")
+	only := fs.String("only", "", "serve only these questions: comma-separated IDs or positions")
+	rng := fs.String("range", "", "serve only bank positions FROM-TO")
+	confidence := fs.Bool("confidence", false, "ask how sure the learner is with each answer and report calibration")
+	confirm := fs.Bool("confirm", false, "have the page submit each answer in two steps, Submit then Confirm, by default (learners can switch it with Confirm answers)")
+	challengeCode := fs.String("challenge", "", "serve this challenge code instead of a fresh order (overrides -only and -range)")
+	boardPath := fs.String("board", "", "keep a challenge leaderboard in this file")
+	penalty := fs.Float64("penalty", 0, "share of a question's points each wrong answer costs, e.g. 0.25, as in exams that penalize guessing")
+	mock := fs.Bool("mock-exam", false, "sit a mock exam sampled to the CSSLP domain weighting, question count and time limit")
+	blueprint := fs.String("blueprint", "", "with -mock-exam, JSON blueprint to use instead, e.g. {\"questions\":100,\"minutes\":120,\"domains\":{\"4\":16,\"5\":20}}")
+	textDir := fs.String("dir", "ltr", "page text direction, ltr or rtl (questions can also set their own dir)")
+	open := fs.Bool("open", false, "open the quiz in the default browser once serving, and print a QR code for phones")
+	sudden := fs.Bool("sudden-death", false, "end each run at the first wrong answer; -board then ranks the longest streaks")
+	pace := fs.String("pace", "", "time each question, the limit shrinking over the run toward exam pace, e.g. 90s:45s; late answers count as misses")
+	hardest := fs.Bool("hardest-first", false, "order each session by the -stats history, questions most often missed and slowest answered first")
+	shuffle := fs.String("shuffle", "all", "how to shuffle each session's questions: all across the bank, domain to keep the domains in bank order and shuffle within each, or none for bank order")
+	hook := fs.String("webhook", "", "POST a JSON summary of each finished session to this URL, e.g. a Slack incoming webhook")
+	lrs := xapiFlags(fs)
+	present := fs.Bool("present", false, "instructor mode: project questions at /present and collect answers from phones at /join")
+	ltiPath := fs.String("lti", "", "act as an LTI 1.3 tool for the LMS platforms in this JSON file, posting grades back")
+	sessionTTL := fs.Duration("session-ttl", 2*time.Hour, "drop a learner's session after this long unused (0 keeps them)")
+	maxSessions := fs.Int("max-sessions", 1000, "refuse new learners while this many sessions are live (0 for no limit)")
+	adminKey := fs.String("admin-key", "", "key the /api/admin routes, /api/reload and listing /api/reports require in the X-Admin-Key header (default the -present key, else closed); QUIZ_ADMIN_KEY keeps it off the command line")
+	allowOrigins := fs.String("allow-origins", "", "comma-separated origins whose pages may call the API from the browser, e.g. https://lms.example.edu")
+	feedbackMode := fs.String("feedback", "full", "after each answer: full, no-reveal to keep the correct answer back after a miss, cram to flash right or wrong for 300ms and move on, none to say nothing until the summary (misses are not asked again), or blind to also hide the progress and counts")
+	advance := fs.Duration("advance", 0, "show the feedback this long before moving on, e.g. 3s (default 1.4s)")
+	autosaveOn := fs.Bool("autosave", true, "save progress after every answer and resume an unfinished session on the next start")
+	autosaveFile := fs.String("autosave-file", "", "file to save progress in (default: one per bank in the temporary directory)")
+	breakEvery := fs.Int("break-every", 0, "have each session suggest a break after this many answers, pausing its clock (0 never)")
+	breakAfter := fs.Duration("break-after", 0, "have each session suggest a break after this long without one, e.g. 60m (0 never)")
+	reload := fs.Bool("reload", true, "watch the bank and use edits from the next session on (POST /api/reload reloads on demand); off with -challenge, -mock-exam and sections, which fix the questions")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *textDir != "ltr" && *textDir != "rtl" {
+		return fmt.Errorf("-dir must be ltr or rtl, got %q", *textDir)
+	}
+	if err := checkPenalty(*penalty); err != nil {
+		return err
+	}
+	if err := checkMockExam(*mock, *blueprint, *sectionSpec, *sectionTime, *challengeCode); err != nil {
+		return err
+	}
+	if err := checkSuddenDeath(*sudden, *mock, *sectionSpec, *sectionTime); err != nil {
+		return err
+	}
+	if err := checkHardestFirst(*hardest, *statsPath, *challengeCode, *mock, *sectionSpec, *sectionTime); err != nil {
+		return err
+	}
+	ordering, err := checkOrdering(*shuffle, *hardest, *challengeCode, *mock, *sectionSpec, *sectionTime)
+	if err != nil {
+		return err
+	}
+	feedback, err := checkFeedback(*feedbackMode, *advance, *sudden)
+	if err != nil {
+		return err
+	}
+	breaks, err := checkBreaks(*breakEvery, *breakAfter)
+	if err != nil {
+		return err
+	}
+	pacing, err := checkPacing(*pace, *mock, *sectionSpec, *sectionTime)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	overrides, err := loadOverrides(ctx, *overridesPath, *bankPath, os.Stderr)
+	if err != nil {
+		return err
+	}
+	questions, ch, err := loadChallenge(ctx, *bankPath, overrides, *challengeCode, *only, *rng)
+	if err != nil {
+		return err
+	}
+	opts := []webapp.Option{webapp.WithMediaDir(mediaDir(*bankPath)), webapp.WithTextDir(*textDir), webapp.WithPenalty(*penalty), webapp.WithBuildInfo(buildInfo()), webapp.WithOrdering(ordering)}
+	if ch != nil {
+		opts = append(opts, webapp.WithChallenge(*ch))
+	}
+	if *boardPath != "" {
+		board, err := challenge.OpenBoard(ctx, *boardPath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, webapp.WithBoard(board))
+	}
+	sections, err := examSections(questions, *sectionSpec, *sectionTime)
+	if err != nil {
+		return err
+	}
+	var saver *autosave.Saver
+	var saved *quiz.State
+	if *autosaveOn {
+		saver, saved = openAutosave(ctx, *autosaveFile, *bankPath, "serve", 1)
+	}
+	var store *stats.Store
+	if *statsPath != "" {
+		if store, err = openStats(ctx, *statsPath, *bankPath, true); err != nil {
+			return err
+		}
+		policy := stats.ReviewPolicy{MaxFlags: *reviewFlags}
+		opts = append(opts, webapp.WithListener(store.Listener()), webapp.WithStats(store, policy))
+		if *sudden {
+			opts = append(opts, webapp.WithListener(store.StreakListener()))
+		}
+	}
+	if *mock {
+		if questions, sections, err = resumeMockExam(ctx, store, questions, *blueprint, saved, os.Stderr); err != nil {
+			return err
+		}
+	}
+	if sections != nil {
+		opts = append(opts, webapp.WithSections(sections))
+	}
+	if *reload && ch == nil && !*mock && sections == nil {
+		opts = append(opts, webapp.WithReload(*bankPath, func(ctx context.Context) ([]quiz.Question, error) {
+			return loadSelection(ctx, *bankPath, overrides, *only, *rng)
+		}))
+	}
+	if saver != nil {
+		opts = append(opts, webapp.WithAutosave(saver, saved, func(err error) {
+			slog.Warn("not resuming the saved session", "err", err)
+		}))
+	}
+	if *confidence {
+		opts = append(opts, webapp.WithConfidence())
+	}
+	if *confirm {
+		opts = append(opts, webapp.WithConfirmAnswers())
+	}
+	if *sudden {
+		opts = append(opts, webapp.WithSuddenDeath())
+	}
+	if *backupTo != "" {
+		if err := startBackups(*backupTo, *backupAt, *backupKeep, *bankPath, store); err != nil {
+			return err
+		}
+	}
+	if pacing != nil {
+		opts = append(opts, webapp.WithPacing(*pacing))
+	}
+	opts = append(opts, webapp.WithFeedback(feedback), webapp.WithBreaks(breaks))
+	if *hardest {
+		opts = append(opts, webapp.WithHardestFirst())
+	}
+	if lrs.Enabled() {
+		opts = append(opts, webapp.WithListener(xapi.NewRecorder(*lrs, warnWriter{}).Listener()))
+	}
+	if *hook != "" {
+		opts = append(opts, webapp.WithWebhook(*hook, func(err error) { slog.Warn("posting the webhook", "err", err) }))
+	}
+	if *ltiPath != "" {
+		cfg, err := lti.LoadConfig(*ltiPath)
+		if err != nil {
+			return err
+		}
+		tool, err := lti.NewTool(cfg)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, webapp.WithLTI(tool, func(err error) { slog.Warn("posting an LTI grade", "err", err) }))
+	}
+	if *sessionTTL < 0 || *maxSessions < 0 {
+		return fmt.Errorf("-session-ttl and -max-sessions must not be negative")
+	}
+	opts = append(opts, webapp.WithSessionLimits(*sessionTTL, *maxSessions))
+	if *adminKey != "" {
+		opts = append(opts, webapp.WithAdminKey(*adminKey))
+	}
+	if *allowOrigins != "" {
+		opts = append(opts, webapp.WithAllowedOrigins(strings.Split(*allowOrigins, ",")))
+	}
+	if *graphQL {
+		opts = append(opts, webapp.WithGraphQL())
+	}
+	if *grpc {
+		opts = append(opts, webapp.WithGRPC())
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return fmt.Errorf("-tls-cert and -tls-key must be given together")
+	}
+	if *tlsCert != "" {
+		opts = append(opts, webapp.WithTLS(*tlsCert, *tlsKey))
+	}
+	switch {
+	case *present:
+		key, err := presenterKey()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, webapp.WithPresenter(key), webapp.WithReady(func(url string) { announcePresenter(os.Stdout, url, key, *open) }))
+	case *open:
+		opts = append(opts, webapp.WithReady(func(url string) { announce(os.Stdout, url, true) }))
+	}
+	return webapp.Run(*addr, questions, opts...)
+}
+
+// buildInfo describes this build for the server's /version endpoint.
+func buildInfo() webapp.BuildInfo {
+	info := webapp.BuildInfo{Version: version, Commit: commit, Date: buildDate, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+func checkPenalty(p float64) error {
+	if p < 0 || p > 1 {
+		return fmt.Errorf("-penalty must be between 0 and 1, got %g", p)
+	}
+	return nil
+}
+
+// xapiFlags registers the flags that send xAPI statements to an LRS.
+func xapiFlags(fs *flag.FlagSet) *xapi.Config {
+	cfg := &xapi.Config{Title: "CSSLP Review Quiz"}
+	fs.StringVar(&cfg.Endpoint, "lrs", "", "send xAPI statements for each answer and finished run to this LRS endpoint, e.g. https://lrs.example.com/xapi")
+	fs.StringVar(&cfg.Username, "lrs-user", "", "LRS key (basic auth username)")
+	fs.StringVar(&cfg.Password, "lrs-password", "", "LRS secret (basic auth password); QUIZ_LRS_PASSWORD keeps it off the command line")
+	fs.StringVar(&cfg.Actor, "lrs-actor", os.Getenv("USER"), "learner the statements are about: an email address, or an account name")
+	fs.StringVar(&cfg.Activity, "lrs-activity", "urn:quiz-cli", "activity IRI of the quiz; questions are IRI/questions/ID")
+	return cfg
+}
+
+// checkSuddenDeath rejects -sudden-death with sections, which keep their own
+// order and clocks.
+func checkSuddenDeath(sudden, mock bool, sections string, sectionTime time.Duration) error {
+	if sudden && (mock || sections != "" || sectionTime > 0) {
+		return fmt.Errorf("-sudden-death cannot be combined with -sections, -section-time or -mock-exam")
+	}
+	return nil
+}
+
+// checkPacing parses -pace, which sections, keeping clocks of their own,
+// rule out. It returns nil without -pace.
+func checkPacing(spec string, mock bool, sections string, sectionTime time.Duration) (*quiz.Pacing, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	p, err := quiz.ParsePacing(spec)
+	if err != nil {
+		return nil, fmt.Errorf("-pace: %w", err)
+	}
+	if mock || sections != "" || sectionTime > 0 {
+		return nil, fmt.Errorf("-pace cannot be combined with -sections, -section-time or -mock-exam")
+	}
+	return &p, nil
+}
+
+// checkFeedback parses -feedback and -advance, which sets the mode's own
+// delay when given. Sudden death gives its answer away by ending the run, so
+// it cannot be silent.
+func checkFeedback(mode string, advance time.Duration, sudden bool) (quiz.Feedback, error) {
+	f, err := quiz.ParseFeedback(mode)
+	if err != nil {
+		return quiz.Feedback{}, fmt.Errorf("-%w", err)
+	}
+	if advance < 0 {
+		return quiz.Feedback{}, fmt.Errorf("-advance must not be negative, got %s", advance)
+	}
+	if f.Silent && advance > 0 {
+		return quiz.Feedback{}, fmt.Errorf("-advance does not apply to -feedback none")
+	}
+	if f.Silent && sudden {
+		return quiz.Feedback{}, fmt.Errorf("-feedback none cannot be combined with -sudden-death")
+	}
+	if advance > 0 {
+		f.Advance = advance
+	}
+	return f, nil
+}
+
+// writeVault writes a note for each question missed in r into the Obsidian
+// vault folder dir, keeping what the learner added to notes already there,
+// and a note on the run linking them.
+func writeVault(dir string, r report.Report) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	day := r.Finished.Format("2006-01-02")
+	var names []string
+	for _, m := range r.Missed {
+		name := report.VaultNoteName(m.Question)
+		path := filepath.Join(dir, name+".md")
+		existing, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.WriteFile(path, report.VaultNote(existing, m, day), 0o644); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	return os.WriteFile(filepath.Join(dir, report.VaultRunName(r)+".md"), report.VaultRunNote(r, names), 0o644)
+}
+
+// checkBreaks builds the break reminders from -break-every and -break-after.
+func checkBreaks(every int, after time.Duration) (quiz.Breaks, error) {
+	if every < 0 {
+		return quiz.Breaks{}, fmt.Errorf("-break-every must not be negative, got %d", every)
+	}
+	if after < 0 {
+		return quiz.Breaks{}, fmt.Errorf("-break-after must not be negative, got %s", after)
+	}
+	return quiz.Breaks{Every: every, After: after}, nil
+}
+
+func checkHardestFirst(hardest bool, statsPath, challengeCode string, mock bool, sections string, sectionTime time.Duration) error {
+	switch {
+	case !hardest:
+		return nil
+	case statsPath == "":
+		return fmt.Errorf("-hardest-first needs the answer history in -stats")
+	case challengeCode != "" || mock || sections != "" || sectionTime > 0:
+		return fmt.Errorf("-hardest-first cannot be combined with -challenge, -sections, -section-time or -mock-exam, which set their own order")
+	}
+	return nil
+}
+
+// checkFilter rejects -filter without a history to read it from, or with the
+// modes that fix their own questions.
+func checkFilter(name, statsPath string, flashcards bool, challengeCode string, mock bool, sections string, sectionTime time.Duration) error {
+	switch {
+	case name == "":
+		return nil
+	case statsPath == "":
+		return fmt.Errorf("-filter reads the saved filters in the -stats history; add -stats")
+	case flashcards:
+		return fmt.Errorf("-filter does not apply to -flashcards")
+	case challengeCode != "" || mock || sections != "" || sectionTime > 0:
+		return fmt.Errorf("-filter cannot be combined with -challenge, -mock-exam or sections, which fix the questions")
+	}
+	return nil
+}
+
+// checkOrdering parses -shuffle, which can only narrow the shuffle of an
+// order nothing else sets.
+func checkOrdering(shuffle string, hardest bool, challengeCode string, mock bool, sections string, sectionTime time.Duration) (quiz.Ordering, error) {
+	ordering, err := quiz.ParseOrdering(shuffle)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("-shuffle: %w", err)
+	case ordering == quiz.ShuffleAll:
+		return ordering, nil
+	case hardest || challengeCode != "" || mock || sections != "" || sectionTime > 0:
+		return 0, fmt.Errorf("-shuffle %s cannot be combined with -hardest-first, -challenge, -sections, -section-time or -mock-exam, which set their own order", ordering)
+	}
+	return ordering, nil
+}
+
+// isTerminal reports whether f is a character device rather than a pipe or
+// file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"quiz-cli/replay"
+	"quiz-cli/ui/cli"
+)
+
+func runReplay(args []string) error {
+	fs := newFlagSet("replay", "run.jsonl")
+	speed := fs.Float64("speed", 1, "playback speed: 2 plays twice as fast, 0.5 at half speed")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("replay takes one log recorded with quiz -record")
+	}
+	if *speed <= 0 {
+		return fmt.Errorf("-speed must be positive, got %g", *speed)
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	events, err := replay.Read(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = cli.New(nil).Replay(ctx, events, *speed)
+	if ctx.Err() != nil {
+		fmt.Println("\nReplay stopped.")
+		return nil
+	}
+	return err
+}
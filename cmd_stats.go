@@ -0,0 +1,652 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-cli/quiz"
+	"quiz-cli/stats"
+	"quiz-cli/storage"
+)
+
+func runStats(args []string) error {
+	fs := newFlagSet("stats", "[more-stats.json...]")
+	statsPath := fs.String("stats", "stats.json", "answer history file")
+	bankPath := fs.String("bank", "questions.json", "question bank to report on")
+	questions := fs.Bool("questions", false, "rank questions hardest first by miss rate and answer time, pooling any further history files given, e.g. a class's")
+	distractors := fs.Bool("distractors", false, "report how often each option was picked, flagging wrong options nobody picks and ones that trap most answers; pools further history files like -questions")
+	ability := fs.Bool("ability", false, "estimate your ability per domain, weighing each answer by how hard its question has proved; question difficulty pools further history files like -questions")
+	interval := fs.Bool("interval", false, "report each domain's recent first-attempt accuracy with a confidence interval, flagging domains whose lower bound is below -pass")
+	passMark := fs.Float64("pass", stats.DefaultIntervalPolicy.PassMark, "with -interval, percentage each domain needs")
+	recent := fs.Int("recent", stats.DefaultIntervalPolicy.Recent, "with -interval, latest first attempts per domain to count")
+	level := fs.Float64("level", stats.DefaultIntervalPolicy.Level*100, "with -interval, confidence level in percent")
+	minAnswers := fs.Int("min-answers", stats.DefaultDistractorPolicy.MinAnswers, "with -distractors, answers a question needs before its options are flagged")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	reports := 0
+	for _, on := range []bool{*questions, *distractors, *ability, *interval} {
+		if on {
+			reports++
+		}
+	}
+	if reports > 1 {
+		return fmt.Errorf("-questions, -distractors, -ability and -interval are separate reports; pick one")
+	}
+	intervals := stats.IntervalPolicy{PassMark: *passMark, Recent: *recent, Level: *level / 100}
+	if err := intervals.Check(); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 && !*questions && !*distractors && !*ability {
+		return fmt.Errorf("more history files only apply with -questions, -distractors or -ability")
+	}
+
+	ctx := context.Background()
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	if *interval {
+		printIntervals(store.Intervals(bank, intervals), intervals)
+		return nil
+	}
+	if *questions || *distractors || *ability {
+		stores := []*stats.Store{store}
+		for _, path := range fs.Args() {
+			more, err := stats.Open(ctx, path)
+			if err != nil {
+				return err
+			}
+			stores = append(stores, more)
+		}
+		switch {
+		case *ability:
+			printAbilities(bank, stores)
+		case *distractors:
+			policy := stats.DefaultDistractorPolicy
+			policy.MinAnswers = *minAnswers
+			printDistractors(bank, policy, stores)
+		default:
+			printDifficulties(bank, stores)
+		}
+		return nil
+	}
+	var seen, attempts, correct int
+	for i, q := range bank {
+		rec, ok := store.Lookup(q)
+		if !ok || rec.Attempts == 0 {
+			continue
+		}
+		seen++
+		attempts += rec.Attempts
+		correct += rec.Correct
+		status := ""
+		if rec.UnderReview {
+			status = "  [under review]"
+		}
+		fmt.Printf("Q%-4d %-11s %3d attempts  %5.1f%% correct  %d flags%s\n",
+			i+1, q.ID, rec.Attempts, float64(rec.Correct)*100/float64(rec.Attempts), rec.Flags, status)
+	}
+	if attempts == 0 {
+		fmt.Println("No answer history yet.")
+		return nil
+	}
+	fmt.Printf("\n%d of %d questions seen, %d attempts, %.1f%% correct overall, %d under review.\n",
+		seen, len(bank), attempts, float64(correct)*100/float64(attempts), len(store.Reviews()))
+	if best := store.LongestStreak; best != nil {
+		fmt.Printf("Longest sudden-death streak: %d (%s).\n", best.Length, best.At.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// printDifficulties lists the attempted questions of bank hardest first.
+func printDifficulties(bank []quiz.Question, stores []*stats.Store) {
+	ds := stats.Difficulties(bank, stores...)
+	if len(ds) == 0 {
+		fmt.Println("No answer history yet.")
+		return
+	}
+	for _, d := range ds {
+		avg := "     -"
+		if d.AvgSeconds > 0 {
+			avg = fmt.Sprintf("%5.1fs", d.AvgSeconds)
+		}
+		fmt.Printf("Q%-4d %-11s domain %-2d %3d attempts  %5.1f%% missed  %s avg\n",
+			d.Index+1, d.ID, d.Domain, d.Attempts, d.MissRate, avg)
+	}
+}
+
+// printAbilities lists the learner's estimated ability in each domain,
+// judged against the question difficulties of all of stores, the first of
+// which is the learner's.
+func printAbilities(bank []quiz.Question, stores []*stats.Store) {
+	as := stats.Abilities(bank, stores[0], stores[1:]...)
+	if len(as) == 0 {
+		fmt.Println("No answer history yet.")
+		return
+	}
+	for _, a := range as {
+		fmt.Printf("Domain %-3d ability %+5.2f ± %4.2f  expected %5.1f%% of %3d questions  raw %5.1f%% over %3d attempts\n",
+			a.Domain, a.Theta, a.StdErr, a.Expected, a.Total, a.Raw(), a.Attempts)
+	}
+	source := "your own history"
+	if len(stores) > 1 {
+		source = fmt.Sprintf("%d history files", len(stores))
+	}
+	fmt.Printf("\nAn ability of 0 means even odds on a question of middling difficulty; expected is the share of the domain you would get right, judging question difficulty from %s.\n", source)
+}
+
+// printIntervals lists each domain's recent first-attempt accuracy and
+// confidence interval, then the domains that may not be ready.
+func printIntervals(ds []stats.DomainInterval, p stats.IntervalPolicy) {
+	if len(ds) == 0 {
+		fmt.Println("No first attempts on record yet.")
+		return
+	}
+	var flagged []int
+	for _, d := range ds {
+		fmt.Printf("Domain %-3d %5.1f%% of the last %3d first attempts  %g%% interval %5.1f%%–%5.1f%%  %s\n",
+			d.Domain, d.Accuracy, d.Answers, p.Level*100, d.Lower, d.Upper, d.Verdict)
+		if d.Verdict != stats.Ready {
+			flagged = append(flagged, d.Domain)
+		}
+	}
+	if len(flagged) == 0 {
+		fmt.Printf("\nEvery domain is above the %g%% pass mark with %g%% confidence.\n", p.PassMark, p.Level*100)
+		return
+	}
+	noun := "Domain"
+	if len(flagged) > 1 {
+		noun = "Domains"
+	}
+	fmt.Printf("\n%s %s could be below the %g%% pass mark; practise them more before the exam.\n", noun, joinInts(flagged), p.PassMark)
+}
+
+// printDistractors lists the picks of each option of the questions with
+// recorded answers, problem questions first.
+func printDistractors(bank []quiz.Question, policy stats.DistractorPolicy, stores []*stats.Store) {
+	rs := stats.Distractors(bank, policy, stores...)
+	if len(rs) == 0 {
+		fmt.Println("No recorded answer choices yet.")
+		return
+	}
+	var traps, unpicked int
+	for _, r := range rs {
+		fmt.Printf("Q%-4d %-11s domain %-2d %3d answers\n", r.Index+1, r.ID, r.Domain, r.Answers)
+		for _, o := range r.Options {
+			note := ""
+			switch {
+			case o.Correct:
+				note = "  correct"
+			case o.Option == r.Trap:
+				note = "  trap"
+			case slices.Contains(r.Unpicked, o.Option):
+				note = "  never picked"
+			}
+			fmt.Printf("    %s %4d  %5.1f%%%s\n", o.Option, o.Picks, o.Percent, note)
+		}
+		if r.Trap != "" {
+			traps++
+		}
+		if len(r.Unpicked) > 0 {
+			unpicked++
+		}
+	}
+	fmt.Printf("\n%d questions with a trap distractor, %d with distractors nobody picks (judged after %d answers).\n",
+		traps, unpicked, policy.MinAnswers)
+}
+
+func runReadiness(args []string) error {
+	fs := newFlagSet("readiness", "")
+	statsPath := fs.String("stats", "stats.json", "answer history file")
+	bankPath := fs.String("bank", "questions.json", "question bank to report on")
+	passMark := fs.Float64("pass", 70, "percentage each domain needs to reach")
+	examDate := fs.String("exam", "", "exam date (YYYY-MM-DD) to check the forecast against")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var exam time.Time
+	if *examDate != "" {
+		var err error
+		if exam, err = time.ParseInLocation("2006-01-02", *examDate, time.Local); err != nil {
+			return fmt.Errorf("bad -exam date %q, want YYYY-MM-DD", *examDate)
+		}
+	}
+
+	ctx := context.Background()
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	forecasts := store.Forecast(bank, *passMark, now)
+	if len(forecasts) == 0 {
+		fmt.Println("No dated answer history yet.")
+		return nil
+	}
+	for _, f := range forecasts {
+		var eta string
+		switch {
+		case f.Reached(now):
+			eta = "at the pass mark"
+		case f.Ready.IsZero() && f.StudyDays < 2:
+			eta = "study on another day to see a trend"
+		case f.Ready.IsZero():
+			eta = "not improving yet"
+		default:
+			eta = "passes " + f.Ready.Format("Jan 2, 2006")
+		}
+		fmt.Printf("Domain %-3d %5.1f%% now  %+5.1f pts/week  %4d attempts over %2d days  %s\n",
+			f.Domain, f.Projected, f.WeeklyGain, f.Attempts, f.StudyDays, eta)
+	}
+	if !exam.IsZero() {
+		fmt.Println("\n" + readinessVerdict(stats.Behind(forecasts, exam), exam))
+	}
+	return nil
+}
+
+func runPlan(args []string) error {
+	fs := newFlagSet("plan", "")
+	statsPath := fs.String("stats", "stats.json", "answer history file")
+	bankPath := fs.String("bank", "questions.json", "question bank to plan around")
+	examDate := fs.String("exam", "", "exam date (YYYY-MM-DD), required")
+	perDay := fs.Int("per-day", 40, "questions to study each day")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *examDate == "" {
+		return fmt.Errorf("plan needs -exam YYYY-MM-DD")
+	}
+	exam, err := time.ParseInLocation("2006-01-02", *examDate, time.Local)
+	if err != nil {
+		return fmt.Errorf("bad -exam date %q, want YYYY-MM-DD", *examDate)
+	}
+
+	ctx := context.Background()
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	plan, err := store.Plan(bank, exam, time.Now(), *perDay)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d study days until your exam on %s, %d questions a day.\n\n", len(plan.Days), exam.Format("Jan 2"), *perDay)
+	for _, d := range plan.Domains {
+		fmt.Printf("Domain %-3d %5.1f%% correct  %3d unseen  %3d missed  %2d practice days\n", d.Domain, d.Accuracy, d.Unseen, d.Missed, d.Days)
+	}
+	fmt.Println()
+	for i, day := range plan.Days {
+		var what string
+		switch day.Kind {
+		case stats.PlanReview:
+			what = fmt.Sprintf("Review %d missed questions", day.Questions)
+		case stats.PlanMock:
+			what = fmt.Sprintf("Mock exam: %d questions from every domain", day.Questions)
+		default:
+			what = fmt.Sprintf("%d Domain %d questions", day.Questions, day.Domain)
+		}
+		fmt.Printf("Day %-3d %s  %s\n", i+1, day.Date.Format("Mon Jan 2"), what)
+	}
+	return nil
+}
+
+func readinessVerdict(behind []int, exam time.Time) string {
+	if len(behind) == 0 {
+		return fmt.Sprintf("On track for your exam on %s.", exam.Format("Jan 2"))
+	}
+	noun := "domain"
+	if len(behind) > 1 {
+		noun = "domains"
+	}
+	return fmt.Sprintf("Not on track for your exam on %s; focus on %s %s.", exam.Format("Jan 2"), noun, joinInts(behind))
+}
+
+func joinInts(ns []int) string {
+	parts := make([]string, len(ns))
+	for i, n := range ns {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runImport(args []string) error {
+	fs := newFlagSet("import", "results.csv...")
+	statsPath := fs.String("stats", "stats.json", "stats store to import into")
+	bankPath := fs.String("bank", "questions.json", "question bank to match rows against")
+	questionCol := fs.String("question-col", stats.DefaultColumns.Question, "CSV column holding the question text")
+	idCol := fs.String("id-col", stats.DefaultColumns.ID, "CSV column holding the question ID or 1-based question number")
+	answerCol := fs.String("answer-col", stats.DefaultColumns.Answer, "CSV column holding the chosen answer")
+	correctCol := fs.String("correct-col", stats.DefaultColumns.Correct, "CSV column holding a correct/incorrect flag")
+	timeCol := fs.String("time-col", stats.DefaultColumns.Time, "CSV column holding the attempt time")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no CSV files given")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	cols := stats.ColumnMap{
+		Question: *questionCol,
+		ID:       *idCol,
+		Answer:   *answerCol,
+		Correct:  *correctCol,
+		Time:     *timeCol,
+	}
+	for _, name := range fs.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		report, err := store.ImportCSV(ctx, f, bank, cols)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Printf("%s: imported %d attempts, %d unmatched\n", name, report.Imported, len(report.Unmatched))
+		for _, u := range report.Unmatched {
+			fmt.Printf("  unmatched: %s\n", u)
+		}
+	}
+	return store.Save(ctx)
+}
+
+func runExport(args []string) error {
+	fs := newFlagSet("export", "")
+	statsPath := fs.String("stats", "stats.json", "answer history file")
+	bankPath := fs.String("bank", "questions.json", "question bank to export history for")
+	out := fs.String("o", "", "file to write (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	var buf bytes.Buffer
+	if *out != "" {
+		w = &buf
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "question", "domain", "attempts", "correct", "flags", "under_review", "last_seen"})
+	for _, q := range bank {
+		rec, ok := store.Lookup(q)
+		if !ok {
+			continue
+		}
+		lastSeen := ""
+		if !rec.LastSeen.IsZero() {
+			lastSeen = rec.LastSeen.Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			q.ID,
+			q.Prompt,
+			strconv.Itoa(q.Domain),
+			strconv.Itoa(rec.Attempts),
+			strconv.Itoa(rec.Correct),
+			strconv.Itoa(rec.Flags),
+			strconv.FormatBool(rec.UnderReview),
+			lastSeen,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if *out != "" {
+		return storage.WriteFile(ctx, *out, buf.Bytes())
+	}
+	return nil
+}
+
+func runNotes(args []string) error {
+	fs := newFlagSet("notes", "")
+	statsPath := fs.String("stats", "stats.json", "answer history file holding the notes")
+	bankPath := fs.String("bank", "questions.json", "question bank, for ordering and prompts")
+	out := fs.String("o", "", "Markdown file to write (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return stats.WriteNotesMarkdown(os.Stdout, store.Notes(bank))
+	}
+	var buf bytes.Buffer
+	if err := stats.WriteNotesMarkdown(&buf, store.Notes(bank)); err != nil {
+		return err
+	}
+	return storage.WriteFile(ctx, *out, buf.Bytes())
+}
+
+func runReports(args []string) error {
+	fs := newFlagSet("reports", "")
+	statsPath := fs.String("stats", "stats.json", "answer history file the quiz server saved the reports in")
+	out := fs.String("o", "", "CSV file to write (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return stats.WriteIssuesCSV(os.Stdout, store.Issues())
+	}
+	var buf bytes.Buffer
+	if err := stats.WriteIssuesCSV(&buf, store.Issues()); err != nil {
+		return err
+	}
+	return storage.WriteFile(ctx, *out, buf.Bytes())
+}
+
+func runExportState(args []string) error {
+	fs := newFlagSet("export-state", "")
+	statsPath := fs.String("stats", "stats.json", "answer history file to export")
+	out := fs.String("o", "quiz-state.json.gz", "archive to write")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := store.WriteArchive(&buf, time.Now()); err != nil {
+		return err
+	}
+	if err := storage.WriteFile(ctx, *out, buf.Bytes()); err != nil {
+		return err
+	}
+	fmt.Printf("%s: exported %s\n", *out, *statsPath)
+	return nil
+}
+
+func runImportState(args []string) error {
+	fs := newFlagSet("import-state", "archive.json.gz...")
+	statsPath := fs.String("stats", "stats.json", "answer history file to import into")
+	replace := fs.Bool("replace", false, "replace the history with the archive instead of merging it in")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no archives given")
+	}
+	if *replace && fs.NArg() > 1 {
+		return fmt.Errorf("-replace takes a single archive")
+	}
+
+	ctx := context.Background()
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	for _, name := range fs.Args() {
+		data, err := storage.ReadFile(ctx, name)
+		if err != nil {
+			return err
+		}
+		archive, err := stats.ReadArchive(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if *replace {
+			store.ReplaceWith(archive)
+			fmt.Printf("%s: replaced the history with %d records exported %s\n", name, len(archive.Records), archive.Exported.Format(time.RFC3339))
+			continue
+		}
+		report := store.MergeArchive(archive)
+		fmt.Printf("%s: %d records added, %d updated, %d kept; %d new issue reports\n",
+			name, report.Added, report.Updated, report.Kept, report.Issues)
+	}
+	return store.Save(ctx)
+}
+
+// syncState pulls the state archive at location into store at the start of
+// a session, or pushes the store to it at the end, and saves the store.
+// Problems are logged rather than returned, so being offline never stops a
+// session.
+func syncState(ctx context.Context, store *stats.Store, location string, push bool) {
+	remote, key, err := storage.Resolve(location)
+	if err != nil {
+		slog.Warn("not syncing", "to", location, "err", err)
+		return
+	}
+	sync := store.Pull
+	if push {
+		sync = store.Push
+	}
+	report, err := sync(ctx, remote, key, time.Now())
+	if err != nil {
+		slog.Warn("syncing the history", "with", location, "err", err)
+		return
+	}
+	if len(report.Conflicts) > 0 {
+		slog.Warn("questions were studied here and on another machine since the last sync; kept the more recent answers",
+			"questions", strings.Join(report.Conflicts, ","))
+	}
+	if report.Pulled {
+		slog.Info("pulled the history", "from", location, "added", report.Merge.Added, "updated", report.Merge.Updated)
+	}
+	if err := store.Save(ctx); err != nil {
+		slog.Warn("saving the synced history", "err", err)
+	}
+}
+
+func runFilters(args []string) error {
+	fs := newFlagSet("filters", "")
+	statsPath := fs.String("stats", "stats.json", "answer history file holding the filters")
+	bankPath := fs.String("bank", "questions.json", "question bank to count each filter's questions in")
+	save := fs.String("save", "", "save a filter under this name from -search, -domain, -tag and -missed (quiz -filter NAME drills it)")
+	del := fs.String("delete", "", "delete the filter saved under this name")
+	search := fs.String("search", "", "with -save, match this text in the prompt or an option")
+	domain := fs.Int("domain", 0, "with -save, keep only this domain")
+	tags := fs.String("tag", "", "with -save, comma-separated tags a question must all carry")
+	missed := fs.Int("missed", 0, "with -save, keep questions answered wrongly at least this many times")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *save != "" && *del != "" {
+		return fmt.Errorf("-save and -delete are separate actions; pick one")
+	}
+	if *save == "" && (*search != "" || *domain != 0 || *tags != "" || *missed != 0) {
+		return fmt.Errorf("-search, -domain, -tag and -missed describe a filter to -save")
+	}
+	if *missed < 0 {
+		return fmt.Errorf("-missed must not be negative")
+	}
+
+	ctx := context.Background()
+	store, err := stats.Open(ctx, *statsPath)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *save != "":
+		f := stats.Filter{Search: strings.TrimSpace(*search), Domain: *domain, MinMisses: *missed}
+		for _, tag := range strings.Split(*tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				f.Tags = append(f.Tags, tag)
+			}
+		}
+		store.SaveFilter(*save, f)
+		if err := store.Save(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("Saved filter %s: %s\n", *save, f)
+		return nil
+	case *del != "":
+		if !store.DeleteFilter(*del) {
+			return fmt.Errorf("no filter named %q", *del)
+		}
+		return store.Save(ctx)
+	}
+	names := store.FilterNames()
+	if len(names) == 0 {
+		fmt.Println("No saved filters. Save one with -save NAME and -search, -domain, -tag or -missed.")
+		return nil
+	}
+	bank, err := loadBank(ctx, *bankPath)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		f, _ := store.SavedFilter(name)
+		fmt.Printf("%-16s %s (%d questions)\n", name, f, len(store.Select(bank, f)))
+	}
+	return nil
+}
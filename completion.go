@@ -0,0 +1,338 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+func init() {
+	// registered here, since runCompletion reads commands
+	commands["completion"] = command{"print a bash, zsh or fish completion script", runCompletion}
+}
+
+// describedCommand is a command's flags and the arguments its usage line
+// shows, e.g. "bank.json...".
+type describedCommand struct {
+	flags *flag.FlagSet
+	args  string
+}
+
+// describing, while set, receives the flags of the command commandFlags
+// runs.
+var describing *describedCommand
+
+// commandFlags returns the flags of the command name by running it only as
+// far as its flag definitions. It runs the command on a goroutine of its own,
+// which parseFlags ends once the flags are defined, so the command's body
+// never runs, whatever it does with errors.
+func commandFlags(name string) describedCommand {
+	var d describedCommand
+	describing = &d
+	defer func() { describing = nil }()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		commands[name].run(nil)
+	}()
+	<-done
+	return d
+}
+
+// completionFlag is one flag as the completion scripts offer it. Value is
+// "" for a switch, "bank" for a question bank, "domain" for a domain number,
+// "file" for another file or directory, and "value" for anything else,
+// which is left to be typed.
+type completionFlag struct {
+	Name, Usage, Value string
+}
+
+// completionCommand is one command as the completion scripts offer it.
+// Args is "bank" when it takes banks, "file" when it takes other files and
+// "" when it takes neither.
+type completionCommand struct {
+	Name, Summary, Args string
+	Flags               []completionFlag
+}
+
+func runCompletion(args []string) error {
+	fs := newFlagSet("completion", "bash|zsh|fish")
+	if err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("completion takes the shell: bash, zsh or fish")
+	}
+	cmds := completionCommands()
+	switch fs.Arg(0) {
+	case "bash":
+		writeBashCompletion(os.Stdout, cmds)
+	case "zsh":
+		writeZshCompletion(os.Stdout, cmds)
+	case "fish":
+		writeFishCompletion(os.Stdout, cmds)
+	default:
+		return fmt.Errorf("no completion for %q; pick bash, zsh or fish", fs.Arg(0))
+	}
+	return nil
+}
+
+// completionCommands describes every command but completion itself, by name.
+func completionCommands() []completionCommand {
+	var cmds []completionCommand
+	for name, cmd := range commands {
+		if name == "completion" {
+			cmds = append(cmds, completionCommand{Name: name, Summary: cmd.summary})
+			continue
+		}
+		d := commandFlags(name)
+		c := completionCommand{Name: name, Summary: cmd.summary}
+		switch {
+		case strings.HasSuffix(d.args, ".json") || strings.HasSuffix(d.args, ".json..."):
+			c.Args = "bank"
+		case d.args != "" && !strings.HasPrefix(d.args, "<"):
+			c.Args = "file"
+		}
+		d.flags.VisitAll(func(f *flag.Flag) {
+			cf := completionFlag{Name: f.Name, Usage: f.Usage, Value: "value"}
+			switch {
+			case isBoolFlag(f):
+				cf.Value = ""
+			case f.Name == "bank":
+				cf.Value = "bank"
+			case f.Name == "domain":
+				cf.Value = "domain"
+			case takesPath(f.Usage):
+				cf.Value = "file"
+			}
+			c.Flags = append(c.Flags, cf)
+		})
+		cmds = append(cmds, c)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// takesPath reports whether a flag's usage says it names a file or a
+// directory.
+func takesPath(usage string) bool {
+	usage = strings.ToLower(usage)
+	for _, word := range []string{"file", "path", "director", "vault", "archive", "history"} {
+		if strings.Contains(usage, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// completionDomains are the domain numbers offered for -domain: the CSSLP
+// exam's.
+func completionDomains() string {
+	var ds []string
+	for d := range quiz.CSSLPBlueprint.Domains {
+		ds = append(ds, strconv.Itoa(d))
+	}
+	sort.Strings(ds)
+	return strings.Join(ds, " ")
+}
+
+// flagsWith lists c's flags, each with a leading dash, whose value is one of
+// kinds.
+func (c completionCommand) flagsWith(kinds ...string) string {
+	var names []string
+	for _, f := range c.Flags {
+		for _, k := range kinds {
+			if f.Value == k {
+				names = append(names, "-"+f.Name)
+			}
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer, cmds []completionCommand) {
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Name)
+	}
+	fmt.Fprintf(w, `# bash completion for quiz-cli. Load it with
+#   source <(quiz-cli completion bash)
+_quiz_cli() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local cmd=quiz first=2
+    if [[ ${COMP_CWORD} -eq 1 && ${cur} != -* ]]; then
+        COMPREPLY=($(compgen -W "%s help" -- "$cur"))
+        return
+    fi
+    if [[ ${COMP_WORDS[1]} != -* ]]; then
+        cmd="${COMP_WORDS[1]}"
+    else
+        first=1
+    fi
+    local flags="" banks="" domains="" files="" values="" args=""
+    case "$cmd" in
+`, strings.Join(names, " "))
+	for _, c := range cmds {
+		fmt.Fprintf(w, "    %s)\n        flags=%q\n        banks=%q domains=%q files=%q values=%q args=%q\n        ;;\n",
+			c.Name, c.flagsWith("", "bank", "domain", "file", "value"), c.flagsWith("bank"), c.flagsWith("domain"), c.flagsWith("file"), c.flagsWith("value"), c.Args)
+	}
+	fmt.Fprintf(w, `    esac
+    if [[ ${COMP_CWORD} -gt ${first} || ${cmd} == quiz ]]; then
+        case " $banks " in *" $prev "*)
+            compopt -o filenames
+            COMPREPLY=($(compgen -o plusdirs -f -X '!*.json' -- "$cur"))
+            return;;
+        esac
+        case " $domains " in *" $prev "*)
+            COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            return;;
+        esac
+        case " $files " in *" $prev "*)
+            compopt -o filenames
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return;;
+        esac
+        case " $values " in *" $prev "*)
+            COMPREPLY=()
+            return;;
+        esac
+    fi
+    if [[ ${cur} == -* ]]; then
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+    elif [[ ${cmd} == completion ]]; then
+        COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+    elif [[ ${args} == bank ]]; then
+        compopt -o filenames
+        COMPREPLY=($(compgen -o plusdirs -f -X '!*.json' -- "$cur"))
+    elif [[ ${args} == file ]]; then
+        compopt -o filenames
+        COMPREPLY=($(compgen -f -- "$cur"))
+    fi
+}
+complete -F _quiz_cli quiz-cli
+`, completionDomains())
+}
+
+// zshQuote makes s safe inside a single-quoted _arguments or _describe
+// spec.
+func zshQuote(s string) string {
+	return strings.NewReplacer("'", "'\\''", "[", "(", "]", ")", ":", "\\:", "\\", "").Replace(s)
+}
+
+func writeZshCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprint(w, `#compdef quiz-cli
+# zsh completion for quiz-cli. Save it as _quiz-cli in a directory on
+# $fpath, or load it with
+#   source <(quiz-cli completion zsh)
+_quiz_cli() {
+  local -a commands
+  commands=(
+`)
+	for _, c := range cmds {
+		fmt.Fprintf(w, "    '%s:%s'\n", c.Name, zshQuote(c.Summary))
+	}
+	fmt.Fprint(w, `  )
+  local cmd=quiz
+  if [[ $words[2] != -* ]]; then
+    if (( CURRENT == 2 )); then
+      _describe 'command' commands
+      return
+    fi
+    cmd=$words[2]
+    shift words
+    (( CURRENT-- ))
+  fi
+  case $cmd in
+`)
+	for _, c := range cmds {
+		var specs []string
+		for _, f := range c.Flags {
+			spec := fmt.Sprintf("-%s[%s]", f.Name, strings.ReplaceAll(zshQuote(f.Usage), "\\:", ":"))
+			switch f.Value {
+			case "bank":
+				spec += `:bank:_files -g "*.json"`
+			case "domain":
+				spec += ":domain:(" + completionDomains() + ")"
+			case "file":
+				spec += ":" + f.Name + ":_files"
+			case "value":
+				spec += ":" + f.Name + ": "
+			}
+			specs = append(specs, "'"+spec+"'")
+		}
+		switch {
+		case c.Name == "completion":
+			specs = append(specs, "':shell:(bash zsh fish)'")
+		case c.Args == "bank":
+			specs = append(specs, `'*:bank:_files -g "*.json"'`)
+		case c.Args == "file":
+			specs = append(specs, "'*:file:_files'")
+		}
+		fmt.Fprintf(w, "  %s)\n    _arguments %s\n    ;;\n", c.Name, strings.Join(specs, " \\\n      "))
+	}
+	fmt.Fprint(w, `  esac
+}
+if [[ $zsh_eval_context[-1] == loadautofunc ]]; then
+  _quiz_cli "$@"
+else
+  compdef _quiz_cli quiz-cli
+fi
+`)
+}
+
+// fishQuote single-quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func writeFishCompletion(w io.Writer, cmds []completionCommand) {
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Name)
+	}
+	fmt.Fprint(w, "# fish completion for quiz-cli. Load it with\n#   quiz-cli completion fish | source\ncomplete -c quiz-cli -f\n")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "complete -c quiz-cli -n __fish_use_subcommand -a %s -d %s\n", c.Name, fishQuote(c.Summary))
+	}
+	for _, c := range cmds {
+		cond := fishQuote("__fish_seen_subcommand_from " + c.Name)
+		if c.Name == "quiz" {
+			// quiz is the default, so its flags also come before any command
+			cond = fishQuote("__fish_seen_subcommand_from quiz; or not __fish_seen_subcommand_from " + strings.Join(names, " "))
+		}
+		for _, f := range c.Flags {
+			line := fmt.Sprintf("complete -c quiz-cli -n %s -o %s -d %s", cond, f.Name, fishQuote(f.Usage))
+			switch f.Value {
+			case "bank":
+				line += " -r -a '(__fish_complete_suffix .json)'"
+			case "domain":
+				line += " -x -a " + fishQuote(completionDomains())
+			case "file":
+				line += " -r -F"
+			case "value":
+				line += " -x"
+			}
+			fmt.Fprintln(w, line)
+		}
+		switch {
+		case c.Name == "completion":
+			fmt.Fprintf(w, "complete -c quiz-cli -n %s -a 'bash zsh fish'\n", cond)
+		case c.Args == "bank":
+			fmt.Fprintf(w, "complete -c quiz-cli -n %s -a '(__fish_complete_suffix .json)'\n", cond)
+		case c.Args == "file":
+			fmt.Fprintf(w, "complete -c quiz-cli -n %s -F\n", cond)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package daily picks a question of the day and formats it as a plain-text
+// digest, optionally mailed over SMTP, that also reveals the previous day's
+// answer.
+package daily
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// dayNumber counts calendar days since the Unix epoch, ignoring time of day
+// and zone offset.
+func dayNumber(day time.Time) int64 {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// Pick returns the question for day. The bank is walked in a shuffled order
+// that depends only on the date and the bank's size, so every run on the same
+// day picks the same question and no question repeats until all have been
+// shown. Template questions are drawn with values seeded by the date too.
+func Pick(bank []quiz.Question, day time.Time) (quiz.Question, error) {
+	if len(bank) == 0 {
+		return quiz.Question{}, fmt.Errorf("bank has no questions")
+	}
+	n := int64(len(bank))
+	d := dayNumber(day)
+	cycle, pos := d/n, d%n
+	if pos < 0 {
+		cycle, pos = cycle-1, pos+n
+	}
+	order := rand.New(rand.NewSource(cycle)).Perm(len(bank))
+	q := bank[order[pos]]
+	q, _, err := q.Instantiate(rand.New(rand.NewSource(d)))
+	return q, err
+}
+
+// Message is one day's digest: the day's question and the answer to the one
+// before it.
+type Message struct {
+	Date      time.Time
+	Question  quiz.Question
+	Yesterday *quiz.Question
+}
+
+// NewMessage builds the digest for day from bank.
+func NewMessage(bank []quiz.Question, day time.Time) (Message, error) {
+	q, err := Pick(bank, day)
+	if err != nil {
+		return Message{}, err
+	}
+	m := Message{Date: day, Question: q}
+	if prev, err := Pick(bank, day.AddDate(0, 0, -1)); err == nil {
+		m.Yesterday = &prev
+	}
+	return m, nil
+}
+
+// Subject is the mail subject line.
+func (m Message) Subject() string {
+	return "CSSLP question of the day, " + m.Date.Format("Mon Jan 2")
+}
+
+// Text renders the digest: the question with its options, then yesterday's
+// question with its answer, explanation and source.
+func (m Message) Text() string {
+	var b strings.Builder
+	q := m.Question
+	fmt.Fprintf(&b, "Question of the day (Domain %d)\n\n%s\n\n", q.Domain, markdown.Plain(q.Prompt))
+	for _, k := range optionKeys(q) {
+		fmt.Fprintf(&b, "  %s) %s\n", k, markdown.Plain(q.Options[k]))
+	}
+	b.WriteString("\nThe answer comes with tomorrow's question.\n")
+	if y := m.Yesterday; y != nil {
+		fmt.Fprintf(&b, "\n---\nYesterday's question: %s\nAnswer: %s) %s\n", markdown.Plain(y.Prompt), y.Answer, markdown.Plain(y.Options[y.Answer]))
+		if y.Explanation != "" {
+			fmt.Fprintf(&b, "\n%s\n", markdown.Plain(y.Explanation))
+		}
+		if y.Source != "" {
+			fmt.Fprintf(&b, "Source: %s\n", y.Source)
+		}
+	}
+	return b.String()
+}
+
+func optionKeys(q quiz.Question) []string {
+	keys := make([]string, 0, len(q.Options))
+	for k := range q.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package daily
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+func TestPickCoversBankBeforeRepeating(t *testing.T) {
+	var bank []quiz.Question
+	for _, p := range []string{"one", "two", "three", "four", "five"} {
+		bank = append(bank, quiz.Question{ID: p, Prompt: p, Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"})
+	}
+	start := time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC) // a multiple of 5 days since the epoch
+	if dayNumber(start)%5 != 0 {
+		t.Fatalf("test start day %d does not begin a cycle", dayNumber(start))
+	}
+	seen := map[string]bool{}
+	for i := 0; i < len(bank); i++ {
+		q, err := Pick(bank, start.AddDate(0, 0, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[q.ID] = true
+	}
+	if len(seen) != len(bank) {
+		t.Fatalf("one cycle showed %d distinct questions, want %d", len(seen), len(bank))
+	}
+	evening := time.Date(2026, 3, 12, 23, 30, 0, 0, time.FixedZone("PST", -8*3600))
+	a, _ := Pick(bank, evening)
+	b, _ := Pick(bank, time.Date(2026, 3, 12, 1, 0, 0, 0, time.UTC))
+	if a.ID != b.ID {
+		t.Fatalf("same calendar day picked %s and %s", a.ID, b.ID)
+	}
+}
+
+func TestSendRevealsYesterdaysAnswer(t *testing.T) {
+	bank := []quiz.Question{
+		{ID: "a", Domain: 4, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B", Explanation: "Rayleigh scattering."},
+		{ID: "b", Domain: 5, Prompt: "Grass color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "A"},
+	}
+	day := time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)
+	m, err := NewMessage(bank, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Yesterday == nil || m.Yesterday.ID == m.Question.ID {
+		t.Fatalf("yesterday = %+v, today = %s", m.Yesterday, m.Question.ID)
+	}
+	var sent string
+	var rcpt []string
+	ml := Mailer{Addr: "smtp.example.com:587", Username: "u", Password: "p", From: "quiz@example.com",
+		send: func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+			sent, rcpt = string(msg), to
+			return nil
+		}}
+	if err := ml.Send(m, []string{"a@example.com", "b@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	y := m.Yesterday
+	for _, want := range []string{"Subject: CSSLP question of the day, Thu Mar 12\r\n", m.Question.Prompt, "Answer: " + y.Answer + ") " + y.Options[y.Answer]} {
+		if !strings.Contains(sent, want) {
+			t.Fatalf("message lacks %q:\n%s", want, sent)
+		}
+	}
+	if len(rcpt) != 2 {
+		t.Fatalf("recipients = %v", rcpt)
+	}
+	if err := (Mailer{Addr: "smtp.example.com:25", From: "x@example.com"}).Send(m, nil); err == nil {
+		t.Fatal("sending to nobody succeeded")
+	}
+}
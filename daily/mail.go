@@ -0,0 +1,60 @@
+package daily
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Mailer sends digests through an SMTP server.
+type Mailer struct {
+	// Addr is the server's host:port, e.g. "smtp.example.com:587". The
+	// connection is upgraded with STARTTLS when the server offers it.
+	Addr string
+	// Username and Password authenticate with PLAIN auth when Username is
+	// set.
+	Username string
+	Password string
+	From     string
+
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// Send mails m to each address in to.
+func (ml Mailer) Send(m Message, to []string) error {
+	if len(to) == 0 {
+		return errors.New("daily: no recipients")
+	}
+	if ml.From == "" {
+		return errors.New("daily: no sender address")
+	}
+	host, _, err := net.SplitHostPort(ml.Addr)
+	if err != nil {
+		return fmt.Errorf("daily: SMTP address %q: %w", ml.Addr, err)
+	}
+	var auth smtp.Auth
+	if ml.Username != "" {
+		auth = smtp.PlainAuth("", ml.Username, ml.Password, host)
+	}
+	send := ml.send
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return send(ml.Addr, auth, ml.From, to, compose(ml.From, to, m, time.Now()))
+}
+
+// compose renders m as an RFC 5322 plain-text message.
+func compose(from string, to []string, m Message, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject())
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(m.Text(), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,127 @@
+// Package enrich drafts explanations for questions that lack one, using an
+// external command or an OpenAI-compatible chat completions endpoint. The
+// drafts are written into the bank for a human to review before the bank is
+// used.
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// Explainer drafts an explanation for q.
+type Explainer interface {
+	Explain(ctx context.Context, q quiz.Question) (string, error)
+}
+
+// ExplainerFunc adapts a function to Explainer.
+type ExplainerFunc func(ctx context.Context, q quiz.Question) (string, error)
+
+// Explain calls f.
+func (f ExplainerFunc) Explain(ctx context.Context, q quiz.Question) (string, error) {
+	return f(ctx, q)
+}
+
+// Prompt is the request sent for q: the question, its options, and the
+// correct answer, with instructions to explain why the answer is right.
+func Prompt(q quiz.Question) string {
+	var b strings.Builder
+	b.WriteString("Explain in two to four sentences why the answer to this CSSLP practice question is correct")
+	b.WriteString(" and why the other options are not. Reply with the explanation only.\n\n")
+	fmt.Fprintf(&b, "Question: %s\n", markdown.Plain(q.Prompt))
+	keys := make([]string, 0, len(q.Options))
+	for k := range q.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s) %s\n", k, markdown.Plain(q.Options[k]))
+	}
+	fmt.Fprintf(&b, "Correct answer: %s\n", q.Answer)
+	return b.String()
+}
+
+// Command runs an external program for each question, e.g. "llm -m gpt-4o"
+// or "ollama run llama3", writing Prompt to its standard input and taking its
+// standard output as the explanation.
+type Command struct {
+	Args []string
+}
+
+// NewCommand splits command on whitespace.
+func NewCommand(command string) Command {
+	return Command{Args: strings.Fields(command)}
+}
+
+// Explain runs c with q's prompt.
+func (c Command) Explain(ctx context.Context, q quiz.Question) (string, error) {
+	if len(c.Args) == 0 {
+		return "", errors.New("enrich: empty command")
+	}
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	cmd.Stdin = strings.NewReader(Prompt(q))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", c.Args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", c.Args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Failure is a question Bank could not draft an explanation for.
+type Failure struct {
+	Index int
+	Err   error
+}
+
+// Result summarises Bank.
+type Result struct {
+	// Drafted lists the indexes of the questions given a new explanation.
+	Drafted  []int
+	Failures []Failure
+}
+
+// Bank drafts explanations with ex for the questions in qs that have none,
+// at most limit of them (all when limit is zero), and stores them in qs.
+// Template questions are skipped since their values change per draw. onDraft,
+// when set, is called after each attempt. Bank stops early with ctx.Err() if
+// ctx is cancelled.
+func Bank(ctx context.Context, qs []quiz.Question, ex Explainer, limit int, onDraft func(i int, err error)) (Result, error) {
+	var res Result
+	for i := range qs {
+		if limit > 0 && len(res.Drafted)+len(res.Failures) >= limit {
+			break
+		}
+		if strings.TrimSpace(qs[i].Explanation) != "" || qs[i].IsTemplate() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		text, err := ex.Explain(ctx, qs[i])
+		if err == nil && text == "" {
+			err = errors.New("empty explanation")
+		}
+		if err != nil {
+			res.Failures = append(res.Failures, Failure{Index: i, Err: err})
+		} else {
+			qs[i].Explanation = text
+			res.Drafted = append(res.Drafted, i)
+		}
+		if onDraft != nil {
+			onDraft(i, err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"quiz-cli/quiz"
+)
+
+func TestChatEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "tiny" || !strings.Contains(req.Messages[1].Content, "Correct answer: B") {
+			t.Errorf("body = %+v, %v", req, err)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  Blue light scatters most.\n"}}]}`))
+	}))
+	defer srv.Close()
+
+	q := quiz.Question{Prompt: "Sky **color**?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}
+	got, err := ChatEndpoint{BaseURL: srv.URL + "/v1/", Model: "tiny", APIKey: "sk-test"}.Explain(context.Background(), q)
+	if err != nil || got != "Blue light scatters most." {
+		t.Fatalf("Explain = %q, %v", got, err)
+	}
+}
+
+func TestBankFillsOnlyMissingExplanations(t *testing.T) {
+	qs := []quiz.Question{
+		{Prompt: "kept", Explanation: "already explained"},
+		{Prompt: "fails"},
+		{Prompt: "drafted"},
+		{Prompt: "over the limit"},
+	}
+	ex := ExplainerFunc(func(_ context.Context, q quiz.Question) (string, error) {
+		if q.Prompt == "fails" {
+			return "", errors.New("boom")
+		}
+		return "because " + q.Prompt, nil
+	})
+	res, err := Bank(context.Background(), qs, ex, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Drafted) != 1 || res.Drafted[0] != 2 || len(res.Failures) != 1 || res.Failures[0].Index != 1 {
+		t.Fatalf("result = %+v", res)
+	}
+	if qs[0].Explanation != "already explained" || qs[2].Explanation != "because drafted" || qs[3].Explanation != "" {
+		t.Fatalf("questions = %+v", qs)
+	}
+}
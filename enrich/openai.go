@@ -0,0 +1,97 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// ChatEndpoint drafts explanations through an OpenAI-compatible chat
+// completions API, such as OpenAI's, a local Ollama or llama.cpp server, or a
+// proxy.
+type ChatEndpoint struct {
+	// BaseURL is the API root, e.g. "https://api.openai.com/v1"; requests
+	// go to BaseURL + "/chat/completions".
+	BaseURL string
+	Model   string
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+	Client *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Explain asks the endpoint to explain q.
+func (e ChatEndpoint) Explain(ctx context.Context, q quiz.Question) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: e.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: "You are a CSSLP instructor writing answer explanations for a question bank."},
+			{Role: "user", Content: Prompt(q)},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	url := strings.TrimRight(e.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	var out chatResponse
+	jsonErr := json.Unmarshal(data, &out)
+	if resp.StatusCode != http.StatusOK {
+		if jsonErr == nil && out.Error != nil {
+			return "", fmt.Errorf("enrich: %s: %s", resp.Status, out.Error.Message)
+		}
+		return "", fmt.Errorf("enrich: %s", resp.Status)
+	}
+	if jsonErr != nil {
+		return "", fmt.Errorf("enrich: decode response: %w", jsonErr)
+	}
+	if len(out.Choices) == 0 {
+		return "", errors.New("enrich: response has no choices")
+	}
+	return strings.TrimSpace(out.Choices[0].Message.Content), nil
+}
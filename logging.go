@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// logs is where log messages go: stderr, or the -log-file.
+var logs = &logSink{w: os.Stderr}
+
+// logJSON is set by -log-format json, for which command errors are logged
+// too rather than printed.
+var logJSON bool
+
+// logFlags registers the logging flags every command takes.
+func logFlags(fs *flag.FlagSet) {
+	fs.String("log-level", "info", "least severe log messages to show: debug, info, warn or error")
+	fs.String("log-format", "text", "log message format: text, or json for log collectors")
+	fs.String("log-file", "", "append log messages to this file instead of writing them to stderr")
+}
+
+// setupLogging points slog's default logger at the output, level and format
+// fs's logging flags ask for.
+func setupLogging(fs *flag.FlagSet) error {
+	if fs.Lookup("log-level") == nil {
+		return nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(fs.Lookup("log-level").Value.String())); err != nil {
+		return fmt.Errorf("-log-level: want debug, info, warn or error, got %q", fs.Lookup("log-level").Value)
+	}
+	if path := fs.Lookup("log-file").Value.String(); path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		logs.setOutput(f)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	switch format := fs.Lookup("log-format").Value.String(); format {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(logs, opts)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(logs, opts)))
+		logJSON = true
+	default:
+		return fmt.Errorf("-log-format must be text or json, got %q", format)
+	}
+	return nil
+}
+
+// logSink writes log messages to w. While the quiz is drawn on the terminal
+// it holds them back, so they don't tear up the screen, and writes them once
+// the quiz is done.
+type logSink struct {
+	mu   sync.Mutex
+	w    io.Writer
+	held *bytes.Buffer
+}
+
+func (l *logSink) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held != nil {
+		return l.held.Write(p)
+	}
+	return l.w.Write(p)
+}
+
+func (l *logSink) setOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w = w
+}
+
+// hold keeps messages back until release when they would go to the
+// terminal.
+func (l *logSink) hold() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if f, ok := l.w.(*os.File); ok && isTerminal(f) && l.held == nil {
+		l.held = &bytes.Buffer{}
+	}
+}
+
+// release writes the messages held back and stops holding.
+func (l *logSink) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held != nil {
+		_, _ = l.w.Write(l.held.Bytes())
+		l.held = nil
+	}
+}
+
+// warnWriter logs each line written to it as a warning, for packages that
+// report problems to an io.Writer.
+type warnWriter struct{}
+
+func (warnWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line != "" {
+			slog.Warn(line)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,119 @@
+package lti
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Score is an AGS score for one learner on a line item.
+type Score struct {
+	UserID           string    `json:"userId"`
+	ScoreGiven       float64   `json:"scoreGiven"`
+	ScoreMaximum     float64   `json:"scoreMaximum"`
+	Comment          string    `json:"comment,omitempty"`
+	ActivityProgress string    `json:"activityProgress"`
+	GradingProgress  string    `json:"gradingProgress"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// PostScore records a finished attempt, given of max, as the launch's grade.
+// It does nothing for launches without a line item.
+func (t *Tool) PostScore(ctx context.Context, l *Launch, given, max float64) error {
+	if l.LineItem == "" {
+		return nil
+	}
+	token, err := t.accessToken(ctx, l.Platform)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(Score{
+		UserID:           l.UserID,
+		ScoreGiven:       given,
+		ScoreMaximum:     max,
+		ActivityProgress: "Completed",
+		GradingProgress:  "FullyGraded",
+		Timestamp:        t.now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, scoresURL(l.LineItem), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.ims.lis.v1.score+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("lti: posting score: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("lti: posting score: platform returned %s", resp.Status)
+	}
+	return nil
+}
+
+// scoresURL appends /scores to the path of a line item URL, which may carry
+// a query string.
+func scoresURL(lineItem string) string {
+	u, err := url.Parse(lineItem)
+	if err != nil {
+		return strings.TrimRight(lineItem, "/") + "/scores"
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/scores"
+	return u.String()
+}
+
+// accessToken gets a token for the score scope with the OAuth 2 client
+// credentials grant, authenticating with a JWT signed by the tool's key.
+func (t *Tool) accessToken(ctx context.Context, p *Platform) (string, error) {
+	if p.TokenURL == "" {
+		return "", errors.New("lti: platform has no token URL for grades")
+	}
+	now := t.now()
+	assertion, err := signJWT(map[string]any{
+		"iss": p.ClientID,
+		"sub": p.ClientID,
+		"aud": p.TokenURL,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+		"jti": randomString(),
+	}, t.key, t.kid)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+		"scope":                 {ScopeScore},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("lti: requesting grade token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("lti: requesting grade token: platform returned %s", resp.Status)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil || tok.AccessToken == "" {
+		return "", errors.New("lti: platform sent no grade token")
+	}
+	return tok.AccessToken, nil
+}
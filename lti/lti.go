@@ -0,0 +1,331 @@
+// Package lti makes the quiz an LTI 1.3 tool: it answers an LMS's OpenID
+// Connect login, validates the signed launch that follows, and posts grades
+// back through the Assignment and Grade Services (AGS) API.
+package lti
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ScopeScore is the AGS scope that lets the tool post scores to a line item.
+const ScopeScore = "https://purl.imsglobal.org/spec/lti-ags/scope/score"
+
+// Platform is an LMS (Canvas, Moodle, ...) the tool is registered with. The
+// values come from the LMS's developer key or tool registration screen.
+type Platform struct {
+	Issuer   string `json:"issuer"`
+	ClientID string `json:"clientId"`
+	// DeploymentIDs, if set, limits launches to these deployments.
+	DeploymentIDs []string `json:"deploymentIds,omitempty"`
+	// AuthURL is the OIDC authorization endpoint, TokenURL the OAuth 2
+	// token endpoint used for grades, and JWKSURL the platform's public keys.
+	AuthURL  string `json:"authUrl"`
+	TokenURL string `json:"tokenUrl"`
+	JWKSURL  string `json:"jwksUrl"`
+}
+
+// Config is the tool's LTI configuration file.
+type Config struct {
+	Platforms []Platform `json:"platforms"`
+	// KeyFile is the tool's RSA private key in PEM form, used to sign grade
+	// requests and published at the tool's JWKS URL. Without one a key is
+	// generated for each run.
+	KeyFile string `json:"keyFile,omitempty"`
+}
+
+// LoadConfig reads and checks the configuration file at path.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(cfg.Platforms) == 0 {
+		return cfg, fmt.Errorf("%s: no platforms", path)
+	}
+	for i, p := range cfg.Platforms {
+		if p.Issuer == "" || p.ClientID == "" || p.AuthURL == "" || p.JWKSURL == "" {
+			return cfg, fmt.Errorf("%s: platform %d needs issuer, clientId, authUrl and jwksUrl", path, i+1)
+		}
+	}
+	return cfg, nil
+}
+
+// Tool is the quiz's side of LTI. A Tool is safe for concurrent use.
+type Tool struct {
+	platforms []Platform
+	key       *rsa.PrivateKey
+	kid       string
+	client    *http.Client
+	now       func() time.Time
+	mu        sync.Mutex
+	// pending maps the state of each login in progress to its platform
+	// and nonce, holding at most maxPending; keysets caches platform keys
+	// by JWKS URL.
+	pending    map[string]login
+	maxPending int
+	keysets    map[string]map[string]*rsa.PublicKey
+}
+
+type login struct {
+	platform *Platform
+	nonce    string
+	expires  time.Time
+}
+
+// loginTTL bounds the time between login and launch.
+const loginTTL = 10 * time.Minute
+
+// maxPending bounds the logins in progress, so a flood of logins that are
+// never launched cannot grow memory for the whole loginTTL.
+const maxPending = 10000
+
+// NewTool returns a Tool for cfg, loading its key file or generating a key.
+func NewTool(cfg Config) (*Tool, error) {
+	var key *rsa.PrivateKey
+	var err error
+	if cfg.KeyFile != "" {
+		if key, err = loadKey(cfg.KeyFile); err != nil {
+			return nil, err
+		}
+	} else if key, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return nil, err
+	}
+	return &Tool{
+		platforms:  cfg.Platforms,
+		key:        key,
+		kid:        keyID(&key.PublicKey),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		pending:    map[string]login{},
+		maxPending: maxPending,
+		keysets:    map[string]map[string]*rsa.PublicKey{},
+	}, nil
+}
+
+func loadKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM key", path)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an RSA key", path)
+	}
+	return key, nil
+}
+
+// JWKS returns the tool's public key set, for platforms to verify the tool's
+// grade requests.
+func (t *Tool) JWKS() JWKS {
+	return JWKS{Keys: []JWK{publicJWK(&t.key.PublicKey, t.kid)}}
+}
+
+func (t *Tool) platform(issuer, clientID string) *Platform {
+	for i := range t.platforms {
+		p := &t.platforms[i]
+		if p.Issuer == issuer && (clientID == "" || p.ClientID == clientID) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Login answers a platform's third-party login initiation, whose parameters
+// are params, with the URL of the platform's authorization endpoint to
+// redirect the browser to. The platform then posts the launch to
+// redirectURI, the tool's launch URL.
+func (t *Tool) Login(params url.Values, redirectURI string) (string, error) {
+	p := t.platform(params.Get("iss"), params.Get("client_id"))
+	if p == nil {
+		return "", fmt.Errorf("lti: unknown platform %q", params.Get("iss"))
+	}
+	if params.Get("login_hint") == "" {
+		return "", errors.New("lti: login without login_hint")
+	}
+	state, nonce := randomString(), randomString()
+	now := t.now()
+	t.mu.Lock()
+	for s, l := range t.pending {
+		if now.After(l.expires) {
+			delete(t.pending, s)
+		}
+	}
+	if len(t.pending) >= t.maxPending {
+		// make room by dropping the oldest login still waiting
+		oldest := ""
+		for s, l := range t.pending {
+			if oldest == "" || l.expires.Before(t.pending[oldest].expires) {
+				oldest = s
+			}
+		}
+		delete(t.pending, oldest)
+	}
+	t.pending[state] = login{platform: p, nonce: nonce, expires: now.Add(loginTTL)}
+	t.mu.Unlock()
+
+	q := url.Values{
+		"scope":         {"openid"},
+		"response_type": {"id_token"},
+		"response_mode": {"form_post"},
+		"prompt":        {"none"},
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {redirectURI},
+		"login_hint":    {params.Get("login_hint")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	if hint := params.Get("lti_message_hint"); hint != "" {
+		q.Set("lti_message_hint", hint)
+	}
+	return p.AuthURL + "?" + q.Encode(), nil
+}
+
+// Launch is a validated resource link launch: who launched the quiz, from
+// where, and where their grade goes.
+type Launch struct {
+	Platform       *Platform
+	UserID         string
+	Name           string
+	Email          string
+	DeploymentID   string
+	ResourceLinkID string
+	ContextID      string
+	// LineItem is the AGS line item for the grade; empty when the platform
+	// does not take grades for this link.
+	LineItem string
+}
+
+// Key identifies the learner and link, so a relaunch finds the same run.
+func (l *Launch) Key() string {
+	return l.Platform.Issuer + "|" + l.UserID + "|" + l.ResourceLinkID
+}
+
+type idClaims struct {
+	Issuer       string          `json:"iss"`
+	Subject      string          `json:"sub"`
+	Audience     json.RawMessage `json:"aud"`
+	AuthorizedBy string          `json:"azp"`
+	Expires      int64           `json:"exp"`
+	Nonce        string          `json:"nonce"`
+	Name         string          `json:"name"`
+	Email        string          `json:"email"`
+	MessageType  string          `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version      string          `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID string          `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	ResourceLink struct {
+		ID string `json:"id"`
+	} `json:"https://purl.imsglobal.org/spec/lti/claim/resource_link"`
+	Context struct {
+		ID string `json:"id"`
+	} `json:"https://purl.imsglobal.org/spec/lti/claim/context"`
+	AGS *struct {
+		Scope    []string `json:"scope"`
+		LineItem string   `json:"lineitem"`
+	} `json:"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint"`
+}
+
+func (c idClaims) audiences() []string {
+	var one string
+	if json.Unmarshal(c.Audience, &one) == nil {
+		return []string{one}
+	}
+	var many []string
+	_ = json.Unmarshal(c.Audience, &many)
+	return many
+}
+
+// Launch validates the id_token and state a platform posts to the launch
+// URL: the login it answers, the platform's signature, audience, expiry and
+// nonce, and that it launches a resource link.
+func (t *Tool) Launch(ctx context.Context, idToken, state string) (*Launch, error) {
+	t.mu.Lock()
+	lg, ok := t.pending[state]
+	delete(t.pending, state)
+	t.mu.Unlock()
+	if !ok || t.now().After(lg.expires) {
+		return nil, errors.New("lti: launch does not match a login in progress")
+	}
+	var c idClaims
+	h, parts, err := parseJWT(idToken, &c)
+	if err != nil {
+		return nil, err
+	}
+	if h.Alg != "RS256" {
+		return nil, fmt.Errorf("lti: id_token signed with %q, want RS256", h.Alg)
+	}
+	p := lg.platform
+	key, err := t.platformKey(ctx, p.JWKSURL, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyRS256(parts, key); err != nil {
+		return nil, err
+	}
+	aud := c.audiences()
+	switch {
+	case c.Issuer != p.Issuer:
+		return nil, fmt.Errorf("lti: id_token from %q, want %q", c.Issuer, p.Issuer)
+	case !slices.Contains(aud, p.ClientID) || (len(aud) > 1 && c.AuthorizedBy != p.ClientID):
+		return nil, errors.New("lti: id_token is not for this tool")
+	case t.now().Unix() >= c.Expires:
+		return nil, errors.New("lti: id_token has expired")
+	case c.Nonce != lg.nonce:
+		return nil, errors.New("lti: id_token nonce does not match the login")
+	case c.MessageType != "LtiResourceLinkRequest":
+		return nil, fmt.Errorf("lti: unsupported message type %q", c.MessageType)
+	case c.Version != "1.3.0":
+		return nil, fmt.Errorf("lti: unsupported LTI version %q", c.Version)
+	case c.Subject == "":
+		return nil, errors.New("lti: launch without a user")
+	case len(p.DeploymentIDs) > 0 && !slices.Contains(p.DeploymentIDs, c.DeploymentID):
+		return nil, fmt.Errorf("lti: unknown deployment %q", c.DeploymentID)
+	}
+	l := &Launch{
+		Platform:       p,
+		UserID:         c.Subject,
+		Name:           c.Name,
+		Email:          c.Email,
+		DeploymentID:   c.DeploymentID,
+		ResourceLinkID: c.ResourceLink.ID,
+		ContextID:      c.Context.ID,
+	}
+	if c.AGS != nil && slices.Contains(c.AGS.Scope, ScopeScore) {
+		l.LineItem = c.AGS.LineItem
+	}
+	return l, nil
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
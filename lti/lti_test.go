@@ -0,0 +1,150 @@
+package lti
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPendingLoginsAreCapped(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	tool := &Tool{
+		platforms:  []Platform{{Issuer: "https://lms.example", ClientID: "client-1", AuthURL: "https://lms.example/auth"}},
+		now:        func() time.Time { return now },
+		pending:    map[string]login{},
+		maxPending: 3,
+	}
+	var states []string
+	for range 5 {
+		to, err := tool.Login(url.Values{"iss": {"https://lms.example"}, "login_hint": {"u1"}}, "https://tool.example/lti/launch")
+		if err != nil {
+			t.Fatal(err)
+		}
+		redirect, _ := url.Parse(to)
+		states = append(states, redirect.Query().Get("state"))
+		now = now.Add(time.Second)
+	}
+	if len(tool.pending) != 3 {
+		t.Fatalf("%d logins pending, want 3", len(tool.pending))
+	}
+	for i, s := range states {
+		if _, ok := tool.pending[s]; ok != (i >= 2) {
+			t.Fatalf("login %d pending = %v", i+1, ok)
+		}
+	}
+}
+
+func TestLaunchAndPostScore(t *testing.T) {
+	platformKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tool *Tool
+	var mu sync.Mutex
+	var got Score
+	var auth string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(JWKS{Keys: []JWK{publicJWK(&platformKey.PublicKey, "p1")}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		var claims map[string]any
+		_, parts, err := parseJWT(r.FormValue("client_assertion"), &claims)
+		if err != nil || verifyRS256(parts, &tool.key.PublicKey) != nil || claims["sub"] != "client-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("scope") != ScopeScore {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "tok"})
+	})
+	mux.HandleFunc("/items/7/scores", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	platform := httptest.NewServer(mux)
+	defer platform.Close()
+
+	tool, err = NewTool(Config{Platforms: []Platform{{
+		Issuer:   "https://lms.example",
+		ClientID: "client-1",
+		AuthURL:  platform.URL + "/auth",
+		TokenURL: platform.URL + "/token",
+		JWKSURL:  platform.URL + "/jwks",
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to, err := tool.Login(url.Values{"iss": {"https://lms.example"}, "login_hint": {"u1"}}, "https://tool.example/lti/launch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirect, _ := url.Parse(to)
+	q := redirect.Query()
+	if q.Get("redirect_uri") != "https://tool.example/lti/launch" || q.Get("client_id") != "client-1" {
+		t.Fatalf("login redirect = %s", to)
+	}
+	idToken := func(nonce string) string {
+		tok, err := signJWT(map[string]any{
+			"iss":   "https://lms.example",
+			"sub":   "u1",
+			"aud":   "client-1",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": nonce,
+			"name":  "Ada",
+			"https://purl.imsglobal.org/spec/lti/claim/message_type":  "LtiResourceLinkRequest",
+			"https://purl.imsglobal.org/spec/lti/claim/version":       "1.3.0",
+			"https://purl.imsglobal.org/spec/lti/claim/deployment_id": "d1",
+			"https://purl.imsglobal.org/spec/lti/claim/resource_link": map[string]string{"id": "link-1"},
+			"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint": map[string]any{
+				"scope":    []string{ScopeScore},
+				"lineitem": platform.URL + "/items/7?type=quiz",
+			},
+		}, platformKey, "p1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	ctx := context.Background()
+	launch, err := tool.Launch(ctx, idToken(q.Get("nonce")), q.Get("state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if launch.UserID != "u1" || launch.Name != "Ada" || launch.ResourceLinkID != "link-1" || launch.LineItem == "" {
+		t.Fatalf("launch = %+v", launch)
+	}
+	if _, err := tool.Launch(ctx, idToken(q.Get("nonce")), q.Get("state")); err == nil {
+		t.Fatal("replayed launch accepted")
+	}
+
+	to, _ = tool.Login(url.Values{"iss": {"https://lms.example"}, "login_hint": {"u1"}}, "https://tool.example/lti/launch")
+	redirect, _ = url.Parse(to)
+	if _, err := tool.Launch(ctx, idToken("wrong"), redirect.Query().Get("state")); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("launch with the wrong nonce: %v", err)
+	}
+
+	if err := tool.PostScore(ctx, launch, 8, 10); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if auth != "Bearer tok" || got.UserID != "u1" || got.ScoreGiven != 8 || got.ScoreMaximum != 10 || got.GradingProgress != "FullyGraded" {
+		t.Fatalf("score = %+v, auth %q", got, auth)
+	}
+}
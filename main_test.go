@@ -2,92 +2,296 @@ package main
 
 import (
 	"bytes"
-	"io"
+	"context"
+	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"quiz-cli/quiz"
+	"quiz-cli/stats"
 )
 
-func TestPadRight(t *testing.T) {
-	cases := []struct {
-		in     string
-		width  int
-		expect string
-	}{
-		{"abc", 5, "abc  "},
-		{"abc", 3, "abc"},
-		{"", 2, "  "},
+// captureOutput runs fn with the process's standard output and error sent to
+// files, and returns what it wrote to each.
+func captureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
 	}
-	for _, tc := range cases {
-		got := padRight(tc.in, tc.width)
-		if got != tc.expect {
-			t.Fatalf("padRight(%q, %d) = %q, want %q", tc.in, tc.width, got, tc.expect)
-		}
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
 	}
+	oldOut, oldErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outFile, errFile
+	defer func() { os.Stdout, os.Stderr = oldOut, oldErr }()
+	fn()
+	outFile.Close()
+	errFile.Close()
+	out, _ := os.ReadFile(outFile.Name())
+	errOut, _ := os.ReadFile(errFile.Name())
+	return string(out), string(errOut)
 }
 
-func TestUnicodeToLetter(t *testing.T) {
-	if got := unicodeToLetter('c'); got != 'C' {
-		t.Fatalf("unicodeToLetter lowercase => %c, want C", got)
+func TestCompletionRunsNoCommand(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var err error
+		stdout, stderr := captureOutput(t, func() { err = runCompletion([]string{shell}) })
+		if err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		if stderr != "" {
+			t.Errorf("%s completion wrote to stderr:\n%s", shell, stderr)
+		}
+		for name := range commands {
+			if !strings.Contains(stdout, name) {
+				t.Errorf("%s completion leaves out %s", shell, name)
+			}
+		}
 	}
-	if got := unicodeToLetter('Z'); got != 'Z' {
-		t.Fatalf("unicodeToLetter preserves => %c, want Z", got)
+}
+
+func TestRestoreStopsOnBadEnvironment(t *testing.T) {
+	t.Setenv("QUIZ_LIST", "maybe")
+	var err error
+	_, stderr := captureOutput(t, func() { err = runRestore([]string{"-from", t.TempDir()}) })
+	if err == nil || !strings.Contains(err.Error(), "QUIZ_LIST") || stderr != "" {
+		t.Fatalf("restore with a bad QUIZ_LIST: %v\n%s", err, stderr)
 	}
 }
 
-func TestPrintSummaryPlacesGradeLast(t *testing.T) {
-	questions := []question{
-		{Domain: 1, Prompt: "Q1", Answer: "A", Options: map[string]string{"A": "Yes", "B": "No"}},
-		{Domain: 1, Prompt: "Q2", Answer: "B", Options: map[string]string{"A": "Yes", "B": "No"}},
-		{Domain: 1, Prompt: "Q3", Answer: "C", Options: map[string]string{"C": "Maybe", "D": "No"}},
+func TestMockExamLeavesOutQuestionsUnderReview(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	bank := []quiz.Question{
+		{ID: "q1", Domain: 4, Prompt: "Sky color?", Options: map[string]string{"A": "Blue", "B": "Green"}, Answer: "A"},
+		{ID: "q2", Domain: 4, Prompt: "Grass color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "A"},
+		{ID: "q3", Domain: 4, Prompt: "Snow color?", Options: map[string]string{"A": "White", "B": "Blue"}, Answer: "A"},
 	}
-	results := []result{
-		{UserAnswer: "A", Correct: true},
-		{UserAnswer: "A", Correct: false},
-		{UserAnswer: "C", Correct: true},
+	store, err := stats.Open(ctx, filepath.Join(dir, "stats.json"))
+	if err != nil {
+		t.Fatal(err)
 	}
-
-	output := captureOutput(t, func() {
-		printSummary(len(results), questions, results)
-	})
-
-	lines := strings.Split(output, "\n")
-	var last string
-	for i := len(lines) - 1; i >= 0; i-- {
-		if strings.TrimSpace(lines[i]) == "" {
-			continue
+	store.Flag(bank[1], stats.ReviewPolicy{MaxFlags: 1})
+	blueprint := filepath.Join(dir, "blueprint.json")
+	if err := os.WriteFile(blueprint, []byte(`{"questions":3,"minutes":6,"domains":{"4":1}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	questions, _, err := resumeMockExam(ctx, store, bank, blueprint, nil, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("mock exam has %d questions, want the 2 not under review", len(questions))
+	}
+	for _, q := range questions {
+		if q.ID == "q2" {
+			t.Fatal("mock exam includes the question under review")
 		}
-		last = strings.TrimSpace(lines[i])
-		break
 	}
-	if !strings.HasPrefix(last, "You answered") {
-		t.Fatalf("expected grade line last, got %q", last)
+}
+
+func TestProfileArg(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-profile=work"}, "work"},
+		{[]string{"-profile", "work"}, "work"},
+		{[]string{"--profile", "work", "bank.json"}, "work"},
+		{[]string{"-profile", "home", "-profile=work"}, "work"},
+		{[]string{"-profiles=work"}, ""},
+		{[]string{"-profile"}, ""},
+		{[]string{"--", "-profile", "work"}, ""},
+		{[]string{"bank.json"}, ""},
+	} {
+		if got := profileArg(tc.args); got != tc.want {
+			t.Errorf("profileArg(%q) = %q, want %q", tc.args, got, tc.want)
+		}
 	}
 }
 
-func captureOutput(t *testing.T, fn func()) string {
-	t.Helper()
-	old := os.Stdout
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatalf("pipe: %v", err)
+// testFlags is a flag set like a command's, with a few of the flags
+// profiles and the environment set.
+func testFlags() *flag.FlagSet {
+	fs := newFlagSet("test", "")
+	fs.String("bank", "questions.json", "")
+	fs.String("stats", "", "")
+	fs.Int("pass", 70, "")
+	fs.Int("limit", 0, "")
+	fs.Bool("shuffle", false, "")
+	return fs
+}
+
+func TestApplyProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "profiles.json")
+	profiles := `{"work": {"bank": "work.json", "pass": 80, "shuffle": true, "addr": ":9000"},
+		"kept": {"stats": "kept.json"},
+		"bad": {"pass": "lots"}}`
+	if err := os.WriteFile(path, []byte(profiles), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	workStats := filepath.Join(dir, "work", "stats.json")
+	for _, tc := range []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "no profile",
+			want: map[string]string{"bank": "questions.json", "stats": "", "pass": "70"},
+		},
+		{
+			name: "profile with an equals sign",
+			args: []string{"-profile=work"},
+			want: map[string]string{"bank": "work.json", "stats": workStats, "pass": "80", "shuffle": "true"},
+		},
+		{
+			name: "profile as the next argument",
+			args: []string{"-profile", "work"},
+			want: map[string]string{"bank": "work.json", "stats": workStats, "pass": "80", "shuffle": "true"},
+		},
+		{
+			name: "profile from the environment",
+			env:  map[string]string{"QUIZ_PROFILE": "work"},
+			want: map[string]string{"bank": "work.json", "pass": "80"},
+		},
+		{
+			name: "profile over the environment",
+			env:  map[string]string{"QUIZ_BANK": "env.json", "QUIZ_PASS": "60", "QUIZ_LIMIT": "5"},
+			args: []string{"-profile=work"},
+			want: map[string]string{"bank": "work.json", "pass": "80", "limit": "5"},
+		},
+		{
+			name: "command line over the profile",
+			args: []string{"-profile=work", "-pass=90", "-bank", "cli.json"},
+			want: map[string]string{"bank": "cli.json", "pass": "90", "shuffle": "true"},
+		},
+		{
+			name: "profile with its own stats",
+			args: []string{"-profile=kept"},
+			want: map[string]string{"bank": "questions.json", "stats": "kept.json"},
+		},
+		{
+			name:    "unknown profile",
+			args:    []string{"-profile=gone"},
+			wantErr: `no profile "gone"`,
+		},
+		{
+			name:    "bad value",
+			args:    []string{"-profile=bad"},
+			wantErr: "profile bad: -pass",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(profilesEnv, path)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			fs := testFlags()
+			err := parseFlags(fs, tc.args)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tc.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("-%s = %q, want %q", name, got, want)
+				}
+			}
+		})
 	}
-	os.Stdout = w
-	defer func() {
-		os.Stdout = old
-	}()
 
-	done := make(chan string)
-	go func() {
-		var buf bytes.Buffer
-		_, _ = io.Copy(&buf, r)
-		done <- buf.String()
-	}()
+	t.Run("command without the flags", func(t *testing.T) {
+		t.Setenv(profilesEnv, path)
+		fs := newFlagSet("test", "")
+		fs.String("bank", "questions.json", "")
+		if err := applyProfile(fs, []string{"-profile=work"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := fs.Lookup("bank").Value.String(); got != "work.json" {
+			t.Fatalf("-bank = %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "work")); err == nil {
+			t.Fatal("applying a profile made its directory")
+		}
+	})
+}
 
-	fn()
+func TestApplyEnv(t *testing.T) {
+	for _, tc := range []struct {
+		env     map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{want: map[string]string{"bank": "questions.json", "pass": "70", "shuffle": "false"}},
+		{
+			env:  map[string]string{"QUIZ_BANK": "env.json", "QUIZ_PASS": "80", "QUIZ_SHUFFLE": "true", "QUIZ_LOG_LEVEL": "debug"},
+			want: map[string]string{"bank": "env.json", "pass": "80", "shuffle": "true", "log-level": "debug"},
+		},
+		{env: map[string]string{"QUIZ_PASS": "lots"}, wantErr: "QUIZ_PASS"},
+		{env: map[string]string{"QUIZ_SHUFFLE": "maybe"}, wantErr: "QUIZ_SHUFFLE"},
+	} {
+		t.Run(fmt.Sprint(tc.env), func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			fs := testFlags()
+			err := applyEnv(fs)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tc.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("-%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
 
-	w.Close()
-	out := <-done
-	r.Close()
-	return out
+func TestParseInterspersed(t *testing.T) {
+	for _, tc := range []struct {
+		args    []string
+		bank    string
+		shuffle bool
+		rest    []string
+	}{
+		{[]string{"-bank", "out.json", "a.json", "b.json"}, "out.json", false, []string{"a.json", "b.json"}},
+		{[]string{"a.json", "b.json", "-bank", "out.json"}, "out.json", false, []string{"a.json", "b.json"}},
+		{[]string{"a.json", "-bank=out.json", "b.json"}, "out.json", false, []string{"a.json", "b.json"}},
+		{[]string{"a.json", "-shuffle", "b.json"}, "questions.json", true, []string{"a.json", "b.json"}},
+		{[]string{"a.json", "--", "-bank", "b.json"}, "questions.json", false, []string{"a.json", "-bank", "b.json"}},
+		{[]string{"-", "-bank", "out.json"}, "out.json", false, []string{"-"}},
+	} {
+		fs := testFlags()
+		if err := parseInterspersed(fs, tc.args); err != nil {
+			t.Fatalf("parseInterspersed(%q): %v", tc.args, err)
+		}
+		bank, shuffle := fs.Lookup("bank").Value.String(), fs.Lookup("shuffle").Value.String() == "true"
+		if bank != tc.bank || shuffle != tc.shuffle || !slices.Equal(fs.Args(), tc.rest) {
+			t.Errorf("parseInterspersed(%q): -bank %q, -shuffle %v, args %q; want %q, %v, %q",
+				tc.args, bank, shuffle, fs.Args(), tc.bank, tc.shuffle, tc.rest)
+		}
+	}
 }
@@ -0,0 +1,259 @@
+// Package markdown renders the small Markdown subset allowed in question
+// prompts and options: **bold**, `inline code`, bullet and numbered lists, and
+// fenced code blocks. Output is ANSI-styled text for terminals or HTML for the
+// web UI; the HTML is built only from escaped text and a fixed set of tags, so
+// bank content cannot inject markup.
+package markdown
+
+import (
+	"html"
+	"strings"
+)
+
+// BlockKind identifies a block-level element.
+type BlockKind int
+
+const (
+	Paragraph BlockKind = iota
+	ListItem
+	Code
+)
+
+// Block is one block-level element. Paragraphs and list items carry inline
+// Markdown in Text; code blocks carry their lines verbatim.
+type Block struct {
+	Kind BlockKind
+	Text string
+	// Marker is the list bullet as written: "-", "*", "+" or "N.".
+	Marker string
+	Lines  []string
+}
+
+// Parse splits src into blocks. Consecutive text lines join into one
+// paragraph; blank lines separate paragraphs.
+func Parse(src string) []Block {
+	var blocks []Block
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, Block{Kind: Paragraph, Text: strings.Join(para, " ")})
+			para = nil
+		}
+	}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			code := Block{Kind: Code}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code.Lines = append(code.Lines, lines[i])
+			}
+			blocks = append(blocks, code)
+		case trimmed == "":
+			flush()
+		default:
+			if marker, text, ok := listItem(trimmed); ok {
+				flush()
+				blocks = append(blocks, Block{Kind: ListItem, Marker: marker, Text: text})
+				continue
+			}
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func listItem(line string) (marker, text string, ok bool) {
+	for _, bullet := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, bullet) {
+			return bullet[:1], strings.TrimSpace(line[2:]), true
+		}
+	}
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && strings.HasPrefix(line[digits:], ". ") {
+		return line[:digits+1], strings.TrimSpace(line[digits+2:]), true
+	}
+	return "", "", false
+}
+
+// span is a run of inline text with uniform style.
+type span struct {
+	text       string
+	bold, code bool
+}
+
+// parseInline splits s on **bold** and `code` markers. Unclosed markers are
+// kept as literal text, and a backslash escapes the next character.
+func parseInline(s string) []span {
+	var spans []span
+	var cur strings.Builder
+	bold := false
+	emit := func(code bool) {
+		if cur.Len() > 0 {
+			spans = append(spans, span{text: cur.String(), bold: bold, code: code})
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\\`*", s[i+1]) >= 0:
+			i++
+			cur.WriteByte(s[i])
+		case s[i] == '`':
+			end := strings.IndexByte(s[i+1:], '`')
+			if end < 0 {
+				cur.WriteByte('`')
+				continue
+			}
+			emit(false)
+			cur.WriteString(s[i+1 : i+1+end])
+			emit(true)
+			i += end + 1
+		case strings.HasPrefix(s[i:], "**") && (bold || strings.Contains(s[i+2:], "**")):
+			emit(false)
+			bold = !bold
+			i++
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	emit(false)
+	return spans
+}
+
+// Plain returns src with Markdown markers removed, one line per block.
+func Plain(src string) string {
+	var out []string
+	for _, b := range Parse(src) {
+		switch b.Kind {
+		case Code:
+			out = append(out, b.Lines...)
+		case ListItem:
+			out = append(out, b.Marker+" "+plainInline(b.Text))
+		default:
+			out = append(out, plainInline(b.Text))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func plainInline(s string) string {
+	var b strings.Builder
+	for _, sp := range parseInline(s) {
+		b.WriteString(sp.text)
+	}
+	return b.String()
+}
+
+const (
+	ansiBold  = "\033[1m"
+	ansiCode  = "\033[33m"
+	ansiReset = "\033[0m"
+)
+
+// InlineANSI renders the inline Markdown in s with ANSI styles. base is the
+// style in effect around s; it is restored after each styled span.
+func InlineANSI(s, base string) string {
+	var b strings.Builder
+	for _, sp := range parseInline(s) {
+		style := ""
+		if sp.bold {
+			style += ansiBold
+		}
+		if sp.code {
+			style += ansiCode
+		}
+		if style == "" {
+			b.WriteString(sp.text)
+			continue
+		}
+		b.WriteString(style + sp.text + ansiReset + base)
+	}
+	return b.String()
+}
+
+// ANSI renders src as terminal lines styled with base, with lists indented
+// and code blocks set off by a gutter.
+func ANSI(src, base string) []string {
+	var out []string
+	for _, b := range Parse(src) {
+		switch b.Kind {
+		case Code:
+			for _, l := range b.Lines {
+				out = append(out, ansiReset+"  │ "+ansiCode+l+ansiReset+base)
+			}
+		case ListItem:
+			marker := b.Marker
+			if len(marker) == 1 {
+				marker = "•"
+			}
+			out = append(out, "  "+marker+" "+InlineANSI(b.Text, base))
+		default:
+			out = append(out, InlineANSI(b.Text, base))
+		}
+	}
+	return out
+}
+
+// InlineHTML renders the inline Markdown in s as escaped HTML.
+func InlineHTML(s string) string {
+	var b strings.Builder
+	for _, sp := range parseInline(s) {
+		text := html.EscapeString(sp.text)
+		if sp.code {
+			text = "<code>" + text + "</code>"
+		}
+		if sp.bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// HTML renders src as escaped HTML. A single paragraph is returned without a
+// wrapping <p> so it can sit inline.
+func HTML(src string) string {
+	blocks := Parse(src)
+	if len(blocks) == 1 && blocks[0].Kind == Paragraph {
+		return InlineHTML(blocks[0].Text)
+	}
+	var b strings.Builder
+	list := ""
+	closeList := func() {
+		if list != "" {
+			b.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	for _, blk := range blocks {
+		switch blk.Kind {
+		case ListItem:
+			want := "ul"
+			if len(blk.Marker) > 1 {
+				want = "ol"
+			}
+			if list != want {
+				closeList()
+				b.WriteString("<" + want + ">")
+				list = want
+			}
+			b.WriteString("<li>" + InlineHTML(blk.Text) + "</li>")
+		case Code:
+			closeList()
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(blk.Lines, "\n")) + "</code></pre>")
+		default:
+			closeList()
+			b.WriteString("<p>" + InlineHTML(blk.Text) + "</p>")
+		}
+	}
+	closeList()
+	return b.String()
+}
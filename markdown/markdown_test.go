@@ -0,0 +1,30 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLEscapesAndRendersSubset(t *testing.T) {
+	src := "Which call is **unsafe**?\n\n```go\nif a < b {\n```\n- `strcpy`\n- <script>"
+	got := HTML(src)
+	want := "<p>Which call is <strong>unsafe</strong>?</p>" +
+		"<pre><code>if a &lt; b {</code></pre>" +
+		"<ul><li><code>strcpy</code></li><li>&lt;script&gt;</li></ul>"
+	if got != want {
+		t.Fatalf("HTML =\n%s\nwant\n%s", got, want)
+	}
+	if got := HTML("2 * 3 = 6 and a**b"); got != "2 * 3 = 6 and a**b" {
+		t.Fatalf("unpaired markers changed: %q", got)
+	}
+}
+
+func TestANSILines(t *testing.T) {
+	lines := ANSI("Pick one:\n1. `gets`\n2. **fgets**", "")
+	if len(lines) != 3 || lines[0] != "Pick one:" || !strings.HasPrefix(lines[1], "  1. ") {
+		t.Fatalf("ANSI = %q", lines)
+	}
+	if got := Plain("Use `fgets`, **not** gets"); got != "Use fgets, not gets" {
+		t.Fatalf("Plain = %q", got)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func init() {
+	commands["profiles"] = command{"list the profiles -profile switches between", runProfiles}
+}
+
+// profilesEnv names a profiles file to use instead of the one in the user's
+// config directory.
+const profilesEnv = "QUIZ_PROFILES_FILE"
+
+// profile is a named set of flag values, such as one bank with its own
+// history and settings, read from the profiles file:
+//
+//	{"csslp": {"bank": "/home/ann/banks/csslp.json", "pass": 70},
+//	 "golang": {"bank": "/home/ann/banks/go.json", "shuffle": "domain"}}
+//
+// Values are flag values as typed on the command line; numbers and booleans
+// may be given as JSON numbers and booleans.
+type profile map[string]string
+
+// profileFlag registers -profile, which every command takes.
+func profileFlag(fs *flag.FlagSet) {
+	fs.String("profile", "", "named profile whose bank, stats and settings to use (quiz-cli profiles lists them)")
+}
+
+// profilesPath is where the profiles file lives: $QUIZ_PROFILES_FILE, or
+// quiz-cli/profiles.json in the user's config directory.
+func profilesPath() (string, error) {
+	if p := os.Getenv(profilesEnv); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "quiz-cli", "profiles.json"), nil
+}
+
+// profileStats is the history a profile keeps when it sets no -stats of its
+// own: stats.json in a directory of its own next to the profiles file.
+func profileStats(path, name string) string {
+	return filepath.Join(filepath.Dir(path), name, "stats.json")
+}
+
+// readProfiles reads the profiles file at path. A missing file holds no
+// profiles.
+func readProfiles(path string) (map[string]profile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	profiles := make(map[string]profile, len(raw))
+	for name, settings := range raw {
+		if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return nil, fmt.Errorf("%s: %q cannot name a profile", path, name)
+		}
+		p := make(profile, len(settings))
+		for flagName, v := range settings {
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				var scalar any
+				if json.Unmarshal(v, &scalar) != nil {
+					return nil, fmt.Errorf("%s: %s: %s: %w", path, name, flagName, err)
+				}
+				switch scalar.(type) {
+				case float64, bool:
+					s = string(v)
+				default:
+					return nil, fmt.Errorf("%s: %s: %s must be a string, number or boolean", path, name, flagName)
+				}
+			}
+			p[strings.TrimLeft(flagName, "-")] = s
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// profileArg returns the -profile value given in args, if any, so the
+// profile can be applied before the command line is parsed.
+func profileArg(args []string) string {
+	var name string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || flagName != "profile" {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		name = value
+	}
+	return name
+}
+
+// applyProfile sets fs's flags from the profile args or the environment
+// picks. The profile's settings sit between the environment and the
+// command line: they override the former and the latter overrides them.
+// Settings for flags fs does not have are left out, so one profile serves
+// every command.
+func applyProfile(fs *flag.FlagSet, args []string) error {
+	f := fs.Lookup("profile")
+	if f == nil {
+		return nil
+	}
+	name := profileArg(args)
+	if name == "" {
+		name = f.Value.String()
+	}
+	if name == "" {
+		return nil
+	}
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	profiles, err := readProfiles(path)
+	if err != nil {
+		return err
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("no profile %q in %s", name, path)
+	}
+	// the profile's directory is made by the first save of its history,
+	// not here, so commands that only read leave no trace
+	if fs.Lookup("stats") != nil && p["stats"] == "" {
+		if err := fs.Set("stats", profileStats(path, name)); err != nil {
+			return err
+		}
+	}
+	for flagName, v := range p {
+		if flagName == "profile" || fs.Lookup(flagName) == nil {
+			continue
+		}
+		if err := fs.Set(flagName, v); err != nil {
+			return fmt.Errorf("profile %s: -%s: %w", name, flagName, err)
+		}
+	}
+	return nil
+}
+
+func runProfiles(args []string) error {
+	fs := newFlagSet("profiles", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	profiles, err := readProfiles(path)
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		fmt.Printf("No profiles in %s.\n", path)
+		return nil
+	}
+	fmt.Printf("Profiles in %s:\n", path)
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := profiles[name]
+		bank, stats := p["bank"], p["stats"]
+		if bank == "" {
+			bank = "questions.json"
+		}
+		if stats == "" {
+			stats = profileStats(path, name)
+		}
+		fmt.Printf("\n%s\n  bank   %s\n  stats  %s\n", name, bank, stats)
+		var rest []string
+		for flagName, v := range p {
+			if flagName != "bank" && flagName != "stats" {
+				rest = append(rest, fmt.Sprintf("-%s=%s", flagName, v))
+			}
+		}
+		sort.Strings(rest)
+		if len(rest) > 0 {
+			fmt.Printf("  flags  %s\n", strings.Join(rest, " "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+// Package qr encodes short text, such as a URL, as a QR code and draws it in
+// a terminal. It covers what the quiz needs: byte mode at error correction
+// level M, versions 1 to 10 (up to 213 bytes).
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrTooLong is returned by Encode for text that does not fit version 10.
+var ErrTooLong = errors.New("qr: text too long")
+
+// version describes the level M layout of one QR version.
+type version struct {
+	// ec is the error correction codewords per block; blocks lists the data
+	// codewords of each block.
+	ec     int
+	blocks []int
+	align  []int
+}
+
+var versions = []version{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v version) dataCodewords() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// Code is an encoded QR symbol.
+type Code struct {
+	// Size is the width and height in modules.
+	Size int
+	// Mask is the data mask pattern chosen, 0-7.
+	Mask    int
+	modules [][]bool
+	// function marks modules that are not data: finders, timing, alignment,
+	// format and version information.
+	function [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark. Modules
+// outside the symbol, in the quiet zone, are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// Encode returns the smallest QR code holding text.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	ver := 0
+	for v := 1; v < len(versions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*versions[v].dataCodewords() {
+			ver = v
+			break
+		}
+	}
+	if ver == 0 {
+		return nil, ErrTooLong
+	}
+	codewords := interleave(versions[ver], dataCodewords(ver, data))
+
+	c := newCode(ver)
+	c.placeData(codewords)
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // XOR again to undo
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+	c.Mask = best
+	return c, nil
+}
+
+// dataCodewords lays out data in byte mode for version ver and pads it to
+// the version's capacity.
+func dataCodewords(ver int, data []byte) []byte {
+	var b bitWriter
+	b.write(0b0100, 4)
+	if ver >= 10 {
+		b.write(len(data), 16)
+	} else {
+		b.write(len(data), 8)
+	}
+	for _, d := range data {
+		b.write(int(d), 8)
+	}
+	capacity := 8 * versions[ver].dataCodewords()
+	terminator := capacity - b.n
+	if terminator > 4 {
+		terminator = 4
+	}
+	b.write(0, terminator)
+	if b.n%8 != 0 {
+		b.write(0, 8-b.n%8)
+	}
+	for pad := 0xEC; b.n < capacity; pad ^= 0xEC ^ 0x11 {
+		b.write(pad, 8)
+	}
+	return b.bytes
+}
+
+type bitWriter struct {
+	bytes []byte
+	n     int
+}
+
+func (b *bitWriter) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if v>>i&1 == 1 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// interleave splits data into the version's blocks, adds each block's error
+// correction and interleaves the result.
+func interleave(v version, data []byte) []byte {
+	var blocks, ecs [][]byte
+	for _, size := range v.blocks {
+		blocks = append(blocks, data[:size])
+		ecs = append(ecs, reedSolomon(data[:size], v.ec))
+		data = data[size:]
+	}
+	var out []byte
+	for i := 0; i < v.blocks[len(v.blocks)-1]; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < v.ec; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// GF(256) with the QR polynomial x^8 + x^4 + x^3 + x^2 + 1.
+var gfExp, gfLog [256]int
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	gfExp[255] = gfExp[0]
+}
+
+func gfMul(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(gfLog[a]+gfLog[b])%255]
+}
+
+// reedSolomon returns the n error correction codewords for data.
+func reedSolomon(data []byte, n int) []byte {
+	// generator is the product of (x - a^i) for i < n, highest power first
+	// with the leading 1 dropped.
+	gen := make([]int, n)
+	gen[n-1] = 1
+	root := 1
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			gen[j] = gfMul(gen[j], root)
+			if j+1 < n {
+				gen[j] ^= gen[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	rem := make([]int, n)
+	for _, d := range data {
+		factor := int(d) ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := range rem {
+			rem[j] ^= gfMul(gen[j], factor)
+		}
+	}
+	out := make([]byte, n)
+	for i, r := range rem {
+		out[i] = byte(r)
+	}
+	return out
+}
+
+// newCode draws the function patterns of version ver.
+func newCode(ver int) *Code {
+	size := 17 + 4*ver
+	c := &Code{Size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for y := range c.modules {
+		c.modules[y] = make([]bool, size)
+		c.function[y] = make([]bool, size)
+	}
+	for i := 0; i < size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	for _, p := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x >= 0 && y >= 0 && x < size && y < size {
+					d := max(abs(dx), abs(dy))
+					c.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	align := versions[ver].align
+	for i, ax := range align {
+		for j, ay := range align {
+			last := len(align) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // under a finder
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(ax+dx, ay+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	c.drawFormat(0) // reserves the format areas until the mask is chosen
+	if ver >= 7 {
+		rem := ver
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := ver<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			c.set(a, b, dark)
+			c.set(b, a, dark)
+		}
+	}
+	return c
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+// drawFormat writes both copies of the format information for level M and
+// mask, and the dark module.
+func (c *Code) drawFormat(mask int) {
+	data := 0b00<<3 | mask // 00 is level M
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	size := c.Size
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, size-15+i, bit(i))
+	}
+	c.set(8, size-8, true)
+}
+
+// placeData fills the data modules with codewords in the standard zigzag,
+// two columns at a time from the bottom right, skipping the timing column.
+func (c *Code) placeData(codewords []byte) {
+	i := 0
+	c.eachDataModule(func(x, y int) {
+		if i < len(codewords)*8 {
+			c.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+		}
+		i++
+	})
+}
+
+func (c *Code) eachDataModule(fn func(x, y int)) {
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !c.function[y][x] {
+					fn(x, y)
+				}
+			}
+		}
+	}
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask flips the data modules selected by mask; applying it twice
+// restores them.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.function[y][x] && maskBit(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol by the four rules scanners dislike: long runs,
+// 2x2 blocks, finder-like patterns and unbalanced dark/light.
+func (c *Code) penalty() int {
+	size, p, dark := c.Size, 0, 0
+	line := make([]bool, size)
+	for pass := 0; pass < 2; pass++ {
+		for a := 0; a < size; a++ {
+			for b := 0; b < size; b++ {
+				if pass == 0 {
+					line[b] = c.modules[a][b]
+				} else {
+					line[b] = c.modules[b][a]
+				}
+			}
+			run := 1
+			for b := 1; b <= size; b++ {
+				if b < size && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += run - 2
+				}
+				run = 1
+			}
+			for b := 0; b+11 <= size; b++ {
+				if matches(line[b:b+11], "10111010000") || matches(line[b:b+11], "00001011101") {
+					p += 40
+				}
+			}
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				v := c.modules[y][x]
+				if c.modules[y][x+1] == v && c.modules[y+1][x] == v && c.modules[y+1][x+1] == v {
+					p += 3
+				}
+			}
+		}
+	}
+	total := size * size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return p + max(k, 0)*10
+}
+
+func matches(line []bool, pattern string) bool {
+	for i, r := range pattern {
+		if line[i] != (r == '1') {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// quiet is the light border drawn around the symbol, in modules. The
+// standard asks for four; two scans reliably from a screen and saves rows.
+const quiet = 2
+
+// WriteTerminal draws c with half-block characters, two module rows per text
+// line, in black on white whatever the terminal's colors.
+func (c *Code) WriteTerminal(w io.Writer) error {
+	var b strings.Builder
+	for y := -quiet; y < c.Size+quiet; y += 2 {
+		b.WriteString("\x1b[30;47m")
+		for x := -quiet; x < c.Size+quiet; x++ {
+			top, bottom := c.Dark(x, y), c.Dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSVG draws c as a scalable SVG image, one unit per module, with the
+// standard four-module quiet zone, for web pages and print.
+func (c *Code) WriteSVG(w io.Writer) error {
+	const border = 4
+	var b strings.Builder
+	n := c.Size + 2*border
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+border, y+border)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
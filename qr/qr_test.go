@@ -0,0 +1,154 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" at 1-M in alphanumeric mode, the usual worked example.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Fatalf("reedSolomon = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		size int
+	}{
+		{"http://10.0.0.5:8080", 25},
+		{"http://192.168.1.20:8080/", 25},
+		{"http://192.168.100.200:18080/quiz", 29},
+		{"https://" + strings.Repeat("a", 150) + ".example", 53},
+	} {
+		c, err := Encode(tc.text)
+		if err != nil {
+			t.Fatalf("Encode(%q): %v", tc.text, err)
+		}
+		if c.Size != tc.size {
+			t.Errorf("Encode(%q) size = %d, want %d", tc.text, c.Size, tc.size)
+		}
+		if got := decode(t, c); got != tc.text {
+			t.Errorf("decoded %q, want %q", got, tc.text)
+		}
+	}
+	if _, err := Encode(strings.Repeat("x", 214)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("Encode of 214 bytes: err = %v, want ErrTooLong", err)
+	}
+}
+
+// decode reads c back the way a scanner would once the grid is sampled:
+// format information, mask, codewords, error correction, then the payload.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	bits := 0
+	for i, xy := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if c.Dark(xy[0], xy[1]) {
+			bits |= 1 << i
+		}
+	}
+	bits ^= 0x5412
+	if level := bits >> 13; level != 0 {
+		t.Fatalf("format level bits = %b, want 00 (M)", level)
+	}
+	mask := bits >> 10 & 7
+	if mask != c.Mask {
+		t.Fatalf("format mask = %d, Code.Mask = %d", mask, c.Mask)
+	}
+
+	var raw []byte
+	n := 0
+	c.eachDataModule(func(x, y int) {
+		if n%8 == 0 {
+			raw = append(raw, 0)
+		}
+		if c.Dark(x, y) != maskBit(mask, x, y) {
+			raw[n/8] |= 0x80 >> (n % 8)
+		}
+		n++
+	})
+	v := versions[(c.Size-17)/4]
+	blocks := make([][]byte, len(v.blocks))
+	pos := 0
+	for i := 0; i < v.blocks[len(v.blocks)-1]+v.ec; i++ {
+		for b, size := range v.blocks {
+			if i < size || i >= v.blocks[len(v.blocks)-1] {
+				blocks[b] = append(blocks[b], raw[pos])
+				pos++
+			}
+		}
+	}
+	var data []byte
+	for b, size := range v.blocks {
+		if !bytes.Equal(reedSolomon(blocks[b][:size], v.ec), blocks[b][size:]) {
+			t.Fatalf("block %d fails error correction", b)
+		}
+		data = append(data, blocks[b][:size]...)
+	}
+
+	if data[0]>>4 != 0b0100 {
+		t.Fatalf("mode = %04b, want byte mode", data[0]>>4)
+	}
+	var length, start int
+	if c.Size >= 57 {
+		length, start = int(data[0]&0xF)<<12|int(data[1])<<4|int(data[2]>>4), 2
+	} else {
+		length, start = int(data[0]&0xF)<<4|int(data[1]>>4), 1
+	}
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = data[start+i]<<4 | data[start+i+1]>>4
+	}
+	return string(out)
+}
+
+func TestWriteTerminal(t *testing.T) {
+	c, err := Encode("hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := c.WriteTerminal(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if want := (c.Size + 2*quiet + 1) / 2; len(lines) != want {
+		t.Fatalf("%d lines, want %d", len(lines), want)
+	}
+	// Line 1 draws the finders' solid top row over their hollow second row.
+	if !strings.Contains(lines[1], "█▀▀▀▀▀█") {
+		t.Fatalf("finder row missing: %q", lines[1])
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	c, err := Encode("http://192.168.1.20:8080/join")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := c.WriteSVG(&b); err != nil {
+		t.Fatal(err)
+	}
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				dark++
+			}
+		}
+	}
+	svg := b.String()
+	if n := strings.Count(svg, "h1v1h-1z"); n != dark {
+		t.Fatalf("%d modules drawn, want %d", n, dark)
+	}
+	if want := fmt.Sprintf(`viewBox="0 0 %d %d"`, c.Size+8, c.Size+8); !strings.Contains(svg, want) {
+		t.Fatalf("svg lacks %s: %.80s", want, svg)
+	}
+}
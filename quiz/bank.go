@@ -0,0 +1,94 @@
+package quiz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// BankInfo is the optional header of a bank file, identifying which bank and
+// which edition of it the questions come from.
+type BankInfo struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Source is where the bank is published, e.g. a repository URL.
+	Source string `json:"source,omitempty"`
+	// Changelog lists what changed in each version, oldest first.
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+}
+
+// ChangelogEntry describes one version of a bank.
+type ChangelogEntry struct {
+	Version string `json:"version"`
+	Notes   string `json:"notes"`
+}
+
+// String names the bank and version for messages, e.g. "CSSLP review v2.1".
+func (b BankInfo) String() string {
+	name := b.Name
+	if name == "" {
+		name = "question bank"
+	}
+	if b.Version == "" {
+		return name + " (unversioned)"
+	}
+	return name + " v" + b.Version
+}
+
+// Same reports whether b and other are the same edition of the same bank.
+func (b BankInfo) Same(other BankInfo) bool {
+	return b.Name == other.Name && b.Version == other.Version
+}
+
+// Since returns the changelog entries after version, or the whole changelog
+// when version is not in it.
+func (b BankInfo) Since(version string) []ChangelogEntry {
+	for i, e := range b.Changelog {
+		if e.Version == version {
+			return b.Changelog[i+1:]
+		}
+	}
+	return b.Changelog
+}
+
+// Bank is a question bank file: its header, the scenarios its questions
+// share, and the questions.
+type Bank struct {
+	BankInfo
+	Scenarios []Scenario `json:"scenarios,omitempty"`
+	Questions []Question `json:"questions"`
+}
+
+// ParseBank decodes a bank file, either a bare JSON array of questions or an
+// object with header fields and a "questions" array, and assigns missing IDs.
+func ParseBank(data []byte) (Bank, error) {
+	var b Bank
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(data, &b); err != nil {
+			return Bank{}, err
+		}
+		if b.Questions == nil {
+			return Bank{}, fmt.Errorf("bank has no \"questions\" array")
+		}
+	} else if err := json.Unmarshal(data, &b.Questions); err != nil {
+		return Bank{}, err
+	}
+	AssignIDs(b.Questions)
+	if err := attachScenarios(&b); err != nil {
+		return Bank{}, err
+	}
+	return b, nil
+}
+
+// MarshalBank encodes b, keeping the bare array form for banks without a
+// header or scenarios.
+func MarshalBank(b Bank) ([]byte, error) {
+	if b.Name == "" && b.Version == "" && b.Source == "" && len(b.Changelog) == 0 && len(b.Scenarios) == 0 {
+		return MarshalQuestions(b.Questions)
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
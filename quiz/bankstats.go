@@ -0,0 +1,69 @@
+package quiz
+
+import (
+	"sort"
+	"strings"
+)
+
+// DomainCount is how many questions of a bank belong to one domain.
+type DomainCount struct {
+	Domain    int `json:"domain"`
+	Questions int `json:"questions"`
+}
+
+// TagCount is how many questions of a bank carry one tag.
+type TagCount struct {
+	Tag       string `json:"tag"`
+	Questions int    `json:"questions"`
+}
+
+// BankStats describes the make-up of a bank, for checking it over before
+// sharing it (see Describe).
+type BankStats struct {
+	Questions int           `json:"questions"`
+	Domains   []DomainCount `json:"domains"`
+	// Tags is sorted by tag; Untagged counts the questions with none.
+	Tags     []TagCount `json:"tags"`
+	Untagged int        `json:"untagged"`
+	// AvgOptions is the mean number of options per question.
+	AvgOptions float64       `json:"avgOptions"`
+	Letters    []LetterShare `json:"letters"`
+	// NoExplanation lists the 1-based positions of the questions without an
+	// explanation.
+	NoExplanation []int `json:"noExplanation"`
+}
+
+// Describe counts qs by domain and tag, averages their options, spreads
+// their answers over the option letters as Lint does, and lists the
+// questions that do not explain their answer.
+func Describe(qs []Question) BankStats {
+	st := BankStats{Questions: len(qs), Domains: []DomainCount{}, Tags: []TagCount{}, Letters: letterShares(qs), NoExplanation: []int{}}
+	domains := map[int]int{}
+	tags := map[string]int{}
+	options := 0
+	for i, q := range qs {
+		domains[q.Domain]++
+		for _, t := range q.Tags {
+			tags[t]++
+		}
+		if len(q.Tags) == 0 {
+			st.Untagged++
+		}
+		options += len(q.Options)
+		if strings.TrimSpace(q.Explanation) == "" {
+			st.NoExplanation = append(st.NoExplanation, i+1)
+		}
+	}
+	for d, n := range domains {
+		st.Domains = append(st.Domains, DomainCount{d, n})
+	}
+	sort.Slice(st.Domains, func(i, j int) bool { return st.Domains[i].Domain < st.Domains[j].Domain })
+	for t, n := range tags {
+		st.Tags = append(st.Tags, TagCount{t, n})
+	}
+	sort.Slice(st.Tags, func(i, j int) bool { return st.Tags[i].Tag < st.Tags[j].Tag })
+	if len(qs) > 0 {
+		st.AvgOptions = float64(options) / float64(len(qs))
+	}
+	return st
+}
@@ -0,0 +1,153 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Blueprint describes a mock exam: how many questions, how long, and what
+// share of the questions each domain gets.
+type Blueprint struct {
+	Questions int `json:"questions"`
+	Minutes   int `json:"minutes"`
+	// Domains maps each domain to its weight in percent.
+	Domains map[int]float64 `json:"domains"`
+}
+
+// CSSLPBlueprint is the official CSSLP exam outline: 125 questions in three
+// hours, weighted across the eight domains.
+var CSSLPBlueprint = Blueprint{
+	Questions: 125,
+	Minutes:   180,
+	Domains:   map[int]float64{1: 10, 2: 14, 3: 14, 4: 15, 5: 14, 6: 14, 7: 11, 8: 8},
+}
+
+// ParseBlueprint reads a blueprint such as
+//
+//	{"questions": 125, "minutes": 180, "domains": {"4": 16, "5": 20}}
+func ParseBlueprint(data []byte) (Blueprint, error) {
+	var b Blueprint
+	if err := json.Unmarshal(data, &b); err != nil {
+		return Blueprint{}, fmt.Errorf("blueprint: %w", err)
+	}
+	if b.Questions <= 0 {
+		return Blueprint{}, errors.New("blueprint: questions must be positive")
+	}
+	if b.Minutes < 0 {
+		return Blueprint{}, errors.New("blueprint: minutes must not be negative")
+	}
+	if len(b.Domains) == 0 {
+		return Blueprint{}, errors.New("blueprint: no domains")
+	}
+	for d, w := range b.Domains {
+		if w <= 0 {
+			return Blueprint{}, fmt.Errorf("blueprint: domain %d weight must be positive", d)
+		}
+	}
+	return b, nil
+}
+
+// MockExam is a bank sampled to a Blueprint.
+type MockExam struct {
+	Questions []Question
+	// Budget is the time allowed, scaled down from the blueprint's when the
+	// bank could not supply every question.
+	Budget time.Duration
+	// Notes explain where the exam departs from the blueprint.
+	Notes []string
+}
+
+// Sections runs the mock exam as one strictly timed section across all its
+// domains.
+func (m MockExam) Sections() []Section {
+	if len(m.Questions) == 0 {
+		return nil
+	}
+	return []Section{{Domain: m.Questions[0].Domain, Budget: m.Budget, Name: "Mock exam"}}
+}
+
+// Sample draws a mock exam from bank matching b's domain weights. Weights of
+// domains the bank lacks are spread over the rest, and a domain with too few
+// questions contributes all it has rather than borrowing from the others, so
+// the proportions hold; both cases are reported in Notes.
+func (b Blueprint) Sample(bank []Question, rng *rand.Rand) (MockExam, error) {
+	byDomain := map[int][]Question{}
+	for _, q := range bank {
+		if _, ok := b.Domains[q.Domain]; ok {
+			byDomain[q.Domain] = append(byDomain[q.Domain], q)
+		}
+	}
+	var domains, missing []int
+	total := 0.0
+	for d, w := range b.Domains {
+		if len(byDomain[d]) == 0 {
+			missing = append(missing, d)
+			continue
+		}
+		domains = append(domains, d)
+		total += w
+	}
+	if len(domains) == 0 {
+		return MockExam{}, errors.New("the bank has no questions in the blueprint's domains")
+	}
+	sort.Ints(domains)
+	sort.Ints(missing)
+
+	var m MockExam
+	if len(missing) > 0 {
+		m.Notes = append(m.Notes, fmt.Sprintf("The bank has no questions for domains %s; their share is spread over the others.", joinDomains(missing)))
+	}
+
+	// Largest remainder: floor each domain's share, then hand the leftover
+	// questions to the biggest fractions.
+	counts := map[int]int{}
+	type share struct {
+		domain int
+		frac   float64
+	}
+	var shares []share
+	given := 0
+	for _, d := range domains {
+		exact := float64(b.Questions) * b.Domains[d] / total
+		counts[d] = int(math.Floor(exact))
+		given += counts[d]
+		shares = append(shares, share{d, exact - math.Floor(exact)})
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].frac > shares[j].frac })
+	for i := 0; given < b.Questions; i++ {
+		counts[shares[i%len(shares)].domain]++
+		given++
+	}
+
+	for _, d := range domains {
+		pool := append([]Question(nil), byDomain[d]...)
+		rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		n := counts[d]
+		if n > len(pool) {
+			m.Notes = append(m.Notes, fmt.Sprintf("Domain %d has only %d of the %d questions the blueprint calls for.", d, len(pool), n))
+			n = len(pool)
+		}
+		m.Questions = append(m.Questions, pool[:n]...)
+	}
+	m.Budget = time.Duration(b.Minutes) * time.Minute
+	if got := len(m.Questions); got < b.Questions {
+		m.Budget = m.Budget * time.Duration(got) / time.Duration(b.Questions)
+		m.Notes = append(m.Notes, fmt.Sprintf("The exam has %d questions instead of %d, so the time limit is cut to match.", got, b.Questions))
+	}
+	return m, nil
+}
+
+func joinDomains(ds []int) string {
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = strconv.Itoa(d)
+	}
+	return strings.Join(parts, ", ")
+}
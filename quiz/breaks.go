@@ -0,0 +1,66 @@
+package quiz
+
+import "time"
+
+// Breaks says when a session suggests a break (see UseBreaks).
+type Breaks struct {
+	// Every suggests a break after this many answers; zero never does.
+	Every int
+	// After suggests one after this much time on the session clock, which
+	// leaves pauses out; zero never does.
+	After time.Duration
+}
+
+// UseBreaks makes the session suggest breaks as b says, counting from now.
+func (s *Session) UseBreaks(b Breaks) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breaks = b
+	s.sinceBreak = 0
+	s.breakAt = s.now()
+}
+
+// BreakDue reports whether a break is due: Every answers or After on the
+// session clock have gone by since the start or the last break. A paused or
+// finished session is never due one.
+func (s *Session) BreakDue() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case len(s.queue) == 0 || !s.pausedAt.IsZero():
+		return false
+	case s.breaks.Every > 0 && s.sinceBreak >= s.breaks.Every:
+		return true
+	}
+	return s.breaks.After > 0 && s.now().Sub(s.breakAt) >= s.breaks.After
+}
+
+// TakeBreak pauses the session for a break and starts counting towards the
+// next one. Resume ends it; like any pause, it is left out of the answer and
+// section times and counted in PausedFor.
+func (s *Session) TakeBreak() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pausedAt.IsZero() {
+		s.pausedAt = s.wall()
+	}
+	s.onBreak = true
+	s.breaksTaken++
+	s.sinceBreak = 0
+	s.breakAt = s.now()
+}
+
+// OnBreak reports whether the session is paused for a break taken with
+// TakeBreak.
+func (s *Session) OnBreak() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.onBreak
+}
+
+// BreaksTaken returns how many breaks have been taken.
+func (s *Session) BreaksTaken() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.breaksTaken
+}
@@ -0,0 +1,105 @@
+package quiz
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Confidence is how sure the learner said they were of an answer.
+type Confidence int
+
+const (
+	Unrated Confidence = iota
+	Guessing
+	Unsure
+	Sure
+)
+
+// Confidences lists the ratings a learner can give, least sure first.
+var Confidences = []Confidence{Guessing, Unsure, Sure}
+
+func (c Confidence) String() string {
+	switch c {
+	case Guessing:
+		return "guessing"
+	case Unsure:
+		return "unsure"
+	case Sure:
+		return "sure"
+	}
+	return "unrated"
+}
+
+// ParseConfidence accepts a rating as 1-3 or by name.
+func ParseConfidence(s string) (Confidence, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil && n >= int(Guessing) && n <= int(Sure) {
+		return Confidence(n), nil
+	}
+	for _, c := range Confidences {
+		if s == c.String() {
+			return c, nil
+		}
+	}
+	return Unrated, fmt.Errorf("confidence %q: want 1-3, guessing, unsure or sure", s)
+}
+
+// Rate records c against the first attempt at the question at idx. Only the
+// first attempt is graded, so ratings for retries and repeat ratings are
+// ignored; Rate reports whether c was recorded.
+func (s *Session) Rate(idx int, c Confidence) bool {
+	if c < Guessing || c > Sure {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if idx < 0 || idx >= len(s.results) || !s.attempted[idx] || s.results[idx].Confidence != Unrated {
+		return false
+	}
+	s.results[idx].Confidence = c
+	return true
+}
+
+// CalibrationBucket is the first-attempt accuracy of answers given at one
+// confidence level.
+type CalibrationBucket struct {
+	Confidence Confidence `json:"confidence"`
+	Label      string     `json:"label"`
+	Answered   int        `json:"answered"`
+	Correct    int        `json:"correct"`
+}
+
+// Percent is the bucket's accuracy, or 0 when it is empty.
+func (b CalibrationBucket) Percent() float64 {
+	if b.Answered == 0 {
+		return 0
+	}
+	return float64(b.Correct) * 100 / float64(b.Answered)
+}
+
+// Calibrate groups rated results by confidence, least sure first, so a
+// learner can see whether "sure" answers really are more accurate than
+// guesses. It returns nil when no result is rated.
+func Calibrate(results []Result) []CalibrationBucket {
+	buckets := make([]CalibrationBucket, len(Confidences))
+	rated := false
+	for i, c := range Confidences {
+		buckets[i] = CalibrationBucket{Confidence: c, Label: c.String()}
+	}
+	for _, r := range results {
+		if r.Confidence < Guessing || r.Confidence > Sure {
+			continue
+		}
+		rated = true
+		b := &buckets[r.Confidence-Guessing]
+		b.Answered++
+		if r.Correct {
+			b.Correct++
+		}
+	}
+	if !rated {
+		return nil
+	}
+	return buckets
+}
@@ -0,0 +1,271 @@
+package quiz
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EvalExpr evaluates an arithmetic expression over vars. It supports numbers,
+// variable names, + - * / % ^ (power, right-associative), unary minus,
+// parentheses, and the functions abs, sqrt, floor, ceil, round, min and max.
+func EvalExpr(src string, vars map[string]float64) (float64, error) {
+	p := &exprParser{src: src, vars: vars}
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return 0, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:], p.pos)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%q is not a finite number", src)
+	}
+	return v, nil
+}
+
+// exprVars returns the variable names referenced by src, ignoring function
+// names.
+func exprVars(src string) []string {
+	var out []string
+	for i := 0; i < len(src); {
+		if !isIdentStart(rune(src[i])) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(src) && isIdentPart(rune(src[j])) {
+			j++
+		}
+		if _, fn := exprFuncs[src[i:j]]; !fn {
+			out = append(out, src[i:j])
+		}
+		i = j
+	}
+	return out
+}
+
+var exprFuncs = map[string]func(args []float64) (float64, error){
+	"abs":   unary(math.Abs),
+	"sqrt":  unary(math.Sqrt),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"min": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("min needs at least one argument")
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Min(v, a)
+		}
+		return v, nil
+	},
+	"max": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("max needs at least one argument")
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Max(v, a)
+		}
+		return v, nil
+	},
+}
+
+func unary(f func(float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("want 1 argument, got %d", len(args))
+		}
+		return f(args[0]), nil
+	}
+}
+
+type exprParser struct {
+	src  string
+	pos  int
+	vars map[string]float64
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// accept consumes c if it is the next non-space character.
+func (p *exprParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expr = term { ("+" | "-") term }
+func (p *exprParser) expr() (float64, error) {
+	v, err := p.term()
+	for err == nil {
+		switch {
+		case p.accept('+'):
+			var r float64
+			r, err = p.term()
+			v += r
+		case p.accept('-'):
+			var r float64
+			r, err = p.term()
+			v -= r
+		default:
+			return v, nil
+		}
+	}
+	return 0, err
+}
+
+// term = unary { ("*" | "/" | "%") unary }
+func (p *exprParser) term() (float64, error) {
+	v, err := p.unary()
+	for err == nil {
+		var op byte
+		switch {
+		case p.accept('*'):
+			op = '*'
+		case p.accept('/'):
+			op = '/'
+		case p.accept('%'):
+			op = '%'
+		default:
+			return v, nil
+		}
+		var r float64
+		if r, err = p.unary(); err != nil {
+			break
+		}
+		switch {
+		case op == '*':
+			v *= r
+		case r == 0:
+			err = fmt.Errorf("division by zero")
+		case op == '/':
+			v /= r
+		default:
+			v = math.Mod(v, r)
+		}
+	}
+	return 0, err
+}
+
+// unary = "-" unary | power
+func (p *exprParser) unary() (float64, error) {
+	if p.accept('-') {
+		v, err := p.unary()
+		return -v, err
+	}
+	return p.power()
+}
+
+// power = primary [ "^" unary ]
+func (p *exprParser) power() (float64, error) {
+	v, err := p.primary()
+	if err != nil || !p.accept('^') {
+		return v, err
+	}
+	e, err := p.unary()
+	return math.Pow(v, e), err
+}
+
+// primary = number | name | name "(" args ")" | "(" expr ")"
+func (p *exprParser) primary() (float64, error) {
+	if p.accept('(') {
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(')') {
+			return 0, fmt.Errorf("missing ) at offset %d", p.pos)
+		}
+		return v, nil
+	}
+	p.skipSpace()
+	start := p.pos
+	if p.pos >= len(p.src) {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	c := rune(p.src[p.pos])
+	switch {
+	case c == '.' || unicode.IsDigit(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '.' || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad number %q", p.src[start:p.pos])
+		}
+		return v, nil
+	case isIdentStart(c):
+		for p.pos < len(p.src) && isIdentPart(rune(p.src[p.pos])) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if fn, ok := exprFuncs[name]; ok {
+			return p.call(name, fn)
+		}
+		v, ok := p.vars[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown variable %q", name)
+		}
+		return v, nil
+	}
+	return 0, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:], p.pos)
+}
+
+func (p *exprParser) call(name string, fn func([]float64) (float64, error)) (float64, error) {
+	if !p.accept('(') {
+		return 0, fmt.Errorf("%s needs (", name)
+	}
+	var args []float64
+	if !p.accept(')') {
+		for {
+			v, err := p.expr()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, v)
+			if p.accept(')') {
+				break
+			}
+			if !p.accept(',') {
+				return 0, fmt.Errorf("missing ) after %s arguments", name)
+			}
+		}
+	}
+	v, err := fn(args)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return v, nil
+}
+
+func isIdentStart(c rune) bool { return c == '_' || unicode.IsLetter(c) }
+
+func isIdentPart(c rune) bool { return isIdentStart(c) || unicode.IsDigit(c) }
+
+// formatNumber renders v without float noise, with at most decimals places
+// when decimals >= 0.
+func formatNumber(v float64, decimals int) string {
+	if decimals >= 0 {
+		return strconv.FormatFloat(v, 'f', decimals, 64)
+	}
+	s := strconv.FormatFloat(v, 'f', 6, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package quiz
+
+import (
+	"fmt"
+	"time"
+)
+
+// Feedback is how a front end responds to each answer.
+type Feedback struct {
+	// Advance moves on to the next question after this long instead of
+	// waiting for the learner; zero waits.
+	Advance time.Duration
+	// HideAnswer leaves the correct answer out after a miss, so it has to
+	// be worked out on the retry.
+	HideAnswer bool
+	// Silent says nothing about the answer, as in an exam; the results are
+	// only shown at the end. Silent sessions ask each question once (see
+	// Session.UseSinglePass), since a question coming back would give the
+	// miss away.
+	Silent bool
+	// Blind also keeps the progress bar, the counts and the section results
+	// off the screen until the end, so a slipping score cannot sway the
+	// learner mid-exam. It implies Silent.
+	Blind bool
+	// Brief cuts the feedback down to a flash of right or wrong, with the
+	// correct answer after a miss, leaving out the explanation, the source
+	// and the question.
+	Brief bool
+}
+
+// CramAdvance is how long cram feedback flashes before the next question.
+const CramAdvance = 300 * time.Millisecond
+
+// ParseFeedback returns the Feedback for a mode name: "full" shows whether
+// each answer was right and the correct answer, "no-reveal" withholds the
+// correct answer after a miss, "cram" flashes a Brief verdict for
+// CramAdvance and moves on, for high-volume review, "none" is Silent, and
+// "blind" is Blind.
+func ParseFeedback(mode string) (Feedback, error) {
+	switch mode {
+	case "full", "":
+		return Feedback{}, nil
+	case "no-reveal":
+		return Feedback{HideAnswer: true}, nil
+	case "cram":
+		return Feedback{Brief: true, Advance: CramAdvance}, nil
+	case "none":
+		return Feedback{Silent: true}, nil
+	case "blind":
+		return Feedback{Silent: true, Blind: true}, nil
+	}
+	return Feedback{}, fmt.Errorf("feedback %q: want full, no-reveal, cram, none or blind", mode)
+}
@@ -0,0 +1,169 @@
+package quiz
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GlossaryTerm is one row of a glossary: a term and its definition, with
+// the line it was read from.
+type GlossaryTerm struct {
+	Term       string
+	Definition string
+	Line       int
+}
+
+// ParseGlossary reads a two-column CSV of terms and their definitions. A
+// first row of "term,definition" headers is skipped. Rows without both
+// cells, and terms already defined above, are left out and reported.
+func ParseGlossary(r io.Reader) ([]GlossaryTerm, []TextProblem, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var (
+		terms    []GlossaryTerm
+		problems []TextProblem
+		seen     = map[string]bool{}
+	)
+	for row := 0; ; row++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if row == 0 {
+			// a byte order mark from a spreadsheet export is not part of the term
+			rec[0] = strings.TrimPrefix(rec[0], "\ufeff")
+			if len(rec) >= 2 && strings.EqualFold(strings.TrimSpace(rec[0]), "term") && strings.EqualFold(strings.TrimSpace(rec[1]), "definition") {
+				continue
+			}
+		}
+		text := strings.Join(rec, ",")
+		if len(rec) < 2 || strings.TrimSpace(rec[0]) == "" || strings.TrimSpace(rec[1]) == "" {
+			if strings.TrimSpace(text) != "" {
+				problems = append(problems, TextProblem{Line: line, Text: text, Reason: "needs a term and a definition"})
+			}
+			continue
+		}
+		t := GlossaryTerm{Term: strings.TrimSpace(rec[0]), Definition: strings.TrimSpace(rec[1]), Line: line}
+		if seen[strings.ToLower(t.Term)] {
+			problems = append(problems, TextProblem{Line: line, Text: text, Reason: "term defined twice"})
+			continue
+		}
+		seen[strings.ToLower(t.Term)] = true
+		terms = append(terms, t)
+	}
+	return terms, problems, nil
+}
+
+// GenerateQuestions turns terms into multiple-choice questions in domain,
+// one per term, asking which of options definitions is the term's. The
+// other definitions are drawn from the rest of the glossary, preferring
+// those that share words with the right one and are about as long, so the
+// wrong answers are plausible. rng places the answer and picks among the
+// likeliest distractors. Terms are reported when the glossary has too few
+// other definitions to give them options in all.
+func GenerateQuestions(terms []GlossaryTerm, domain, options int, rng *rand.Rand) ([]Question, []TextProblem, error) {
+	if options < 2 || options > 8 {
+		return nil, nil, fmt.Errorf("questions need 2 to 8 options, not %d", options)
+	}
+	words := make([]map[string]bool, len(terms))
+	for i, t := range terms {
+		words[i] = contentWords(t.Definition)
+	}
+	var (
+		questions []Question
+		problems  []TextProblem
+	)
+	for i, t := range terms {
+		type candidate struct {
+			idx   int
+			score float64
+		}
+		var cands []candidate
+		taken := map[string]bool{strings.ToLower(t.Definition): true}
+		for j, o := range terms {
+			if j == i || taken[strings.ToLower(o.Definition)] {
+				continue
+			}
+			taken[strings.ToLower(o.Definition)] = true
+			cands = append(cands, candidate{j, plausibility(words[i], words[j], t.Definition, o.Definition)})
+		}
+		if len(cands) == 0 {
+			problems = append(problems, TextProblem{Line: t.Line, Text: t.Term, Reason: "no other definition to offer as a wrong answer"})
+			continue
+		}
+		sort.SliceStable(cands, func(a, b int) bool { return cands[a].score > cands[b].score })
+		want := min(options-1, len(cands))
+		// pick among the likeliest, twice as many as needed, so each run of
+		// the generator does not offer the very same distractors
+		pool := cands[:min(2*want, len(cands))]
+		rng.Shuffle(len(pool), func(a, b int) { pool[a], pool[b] = pool[b], pool[a] })
+		defs := []string{t.Definition}
+		for _, c := range pool[:want] {
+			defs = append(defs, terms[c.idx].Definition)
+		}
+		rng.Shuffle(len(defs), func(a, b int) { defs[a], defs[b] = defs[b], defs[a] })
+		q := Question{Domain: domain, Prompt: fmt.Sprintf("Which of these defines %q?", t.Term), Options: map[string]string{}}
+		for k, d := range defs {
+			letter := string(rune('A' + k))
+			q.Options[letter] = d
+			if d == t.Definition {
+				q.Answer = letter
+			}
+		}
+		questions = append(questions, q)
+	}
+	return questions, problems, nil
+}
+
+// glossaryStopWords are left out of contentWords, as too common to make two
+// definitions alike.
+var glossaryStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "that": true, "with": true, "from": true,
+	"are": true, "its": true, "into": true, "which": true, "this": true, "when": true,
+	"not": true, "all": true, "any": true, "one": true, "has": true, "have": true,
+	"used": true, "such": true, "than": true, "can": true, "how": true, "their": true,
+}
+
+// contentWords is the set of lower-cased words in s of three letters or
+// more, leaving out glossaryStopWords.
+func contentWords(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= 3 && !glossaryStopWords[w] {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+// plausibility scores other as a wrong answer where def is right: the
+// share of their content words they have in common, and a little for being
+// of similar length, so a one-line definition is not offered beside a
+// paragraph.
+func plausibility(words, otherWords map[string]bool, def, other string) float64 {
+	shared := 0
+	for w := range words {
+		if otherWords[w] {
+			shared++
+		}
+	}
+	var overlap float64
+	if union := len(words) + len(otherWords) - shared; union > 0 {
+		overlap = float64(shared) / float64(union)
+	}
+	a, b := float64(len(def)), float64(len(other))
+	return overlap + 0.5*min(a, b)/max(a, b)
+}
@@ -0,0 +1,49 @@
+package quiz
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Grade is how well a learner recalled a flashcard's answer, on the four-step
+// scale spaced-repetition tools use.
+type Grade int
+
+const (
+	Again Grade = iota + 1
+	Hard
+	Good
+	Easy
+)
+
+// Grades lists the grades a learner can give, worst first.
+var Grades = []Grade{Again, Hard, Good, Easy}
+
+func (g Grade) String() string {
+	switch g {
+	case Again:
+		return "again"
+	case Hard:
+		return "hard"
+	case Good:
+		return "good"
+	case Easy:
+		return "easy"
+	}
+	return "ungraded"
+}
+
+// ParseGrade accepts a grade as 1-4 or by name.
+func ParseGrade(s string) (Grade, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil && n >= int(Again) && n <= int(Easy) {
+		return Grade(n), nil
+	}
+	for _, g := range Grades {
+		if s == g.String() {
+			return g, nil
+		}
+	}
+	return 0, fmt.Errorf("grade %q: want 1-4, again, hard, good or easy", s)
+}
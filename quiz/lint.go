@@ -0,0 +1,237 @@
+package quiz
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity ranks a lint finding.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "info"
+}
+
+// MarshalText encodes s by name, as in JSON reports.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity returns the Severity named s: info, warning or error.
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if s == sev.String() {
+			return sev, nil
+		}
+	}
+	return 0, fmt.Errorf("severity %q: want info, warning or error", s)
+}
+
+// The checks Lint runs.
+const (
+	CheckAnswerBalance = "answer-balance"
+	CheckOptionLength  = "option-length"
+	CheckAboveOption   = "all-of-the-above"
+	CheckNoExplanation = "no-explanation"
+)
+
+// minBalanceQuestions is the smallest bank whose answer spread is judged.
+const minBalanceQuestions = 12
+
+// LintIssue is one finding. Question is the 1-based position of the
+// question in the bank, or 0 for findings about the whole bank.
+type LintIssue struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	Question int      `json:"question,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// LetterShare is how often one option letter is the answer, against how
+// often it would be if answers were spread evenly over each question's
+// options.
+type LetterShare struct {
+	Letter   string  `json:"letter"`
+	Count    int     `json:"count"`
+	Expected float64 `json:"expected"`
+}
+
+// LintReport is the result of Lint.
+type LintReport struct {
+	Questions int           `json:"questions"`
+	Letters   []LetterShare `json:"letters"`
+	Issues    []LintIssue   `json:"issues"`
+}
+
+// Count is the number of issues of severity sev.
+func (r LintReport) Count(sev Severity) int {
+	n := 0
+	for _, is := range r.Issues {
+		if is.Severity == sev {
+			n++
+		}
+	}
+	return n
+}
+
+// Worst is the highest severity among the issues; ok is false when there
+// are none.
+func (r LintReport) Worst() (sev Severity, ok bool) {
+	for _, is := range r.Issues {
+		if !ok || is.Severity > sev {
+			sev, ok = is.Severity, true
+		}
+	}
+	return sev, ok
+}
+
+// aboveOption matches options that only make sense in a fixed order next to
+// the others, such as "All of the above".
+var aboveOption = regexp.MustCompile(`(?i)\b(all|none|both|neither) of the (above|preceding)\b`)
+
+// Lint checks qs for habits that make a bank easier to game or harder to
+// learn from, beyond what Validate rejects: correct answers piling up on one
+// letter, a correct option that stands out by its length, "all of the above"
+// options, and questions without explanations. Issues come bank-wide first,
+// then in bank order.
+func Lint(qs []Question) LintReport {
+	r := LintReport{Questions: len(qs), Letters: letterShares(qs), Issues: []LintIssue{}}
+	r.Issues = append(r.Issues, balanceIssues(r.Letters, len(qs))...)
+	for i, q := range qs {
+		add := func(sev Severity, check, format string, args ...any) {
+			r.Issues = append(r.Issues, LintIssue{Severity: sev, Check: check, Question: i + 1, ID: q.ID, Message: fmt.Sprintf(format, args...)})
+		}
+		if letter, ratio, ok := longOption(q); ok {
+			if strings.EqualFold(letter, q.Answer) {
+				add(SeverityWarning, CheckOptionLength, "the answer, %s, is %.1f times as long as the other options, which gives it away", letter, ratio)
+			} else {
+				add(SeverityInfo, CheckOptionLength, "option %s is %.1f times as long as the other options", letter, ratio)
+			}
+		}
+		for _, letter := range sortedOptionKeys(q) {
+			if m := aboveOption.FindString(q.Options[letter]); m != "" {
+				add(SeverityWarning, CheckAboveOption, "option %s is %q, which depends on the option order and can be answered by elimination", letter, m)
+			}
+		}
+		if strings.TrimSpace(q.Explanation) == "" {
+			add(SeverityInfo, CheckNoExplanation, "no explanation of why %s is right", q.Answer)
+		}
+	}
+	return r
+}
+
+// letterShares counts the answers per option letter. A letter's expected
+// count sums, over the questions offering it, one over their option count.
+func letterShares(qs []Question) []LetterShare {
+	byLetter := map[string]*LetterShare{}
+	for _, q := range qs {
+		if len(q.Options) == 0 {
+			continue
+		}
+		for k := range q.Options {
+			letter := strings.ToUpper(strings.TrimSpace(k))
+			ls := byLetter[letter]
+			if ls == nil {
+				ls = &LetterShare{Letter: letter}
+				byLetter[letter] = ls
+			}
+			ls.Expected += 1 / float64(len(q.Options))
+			if strings.EqualFold(letter, strings.TrimSpace(q.Answer)) {
+				ls.Count++
+			}
+		}
+	}
+	out := make([]LetterShare, 0, len(byLetter))
+	for _, ls := range byLetter {
+		out = append(out, *ls)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Letter < out[j].Letter })
+	return out
+}
+
+// balanceIssues flags letters that are the answer far more or less often
+// than an even spread would make them. Small banks are too noisy to judge.
+func balanceIssues(letters []LetterShare, n int) []LintIssue {
+	if n < minBalanceQuestions {
+		return []LintIssue{{Severity: SeverityInfo, Check: CheckAnswerBalance, Message: fmt.Sprintf("too few questions (%d) to judge the answer spread; %d are needed", n, minBalanceQuestions)}}
+	}
+	var out []LintIssue
+	for _, ls := range letters {
+		if ls.Expected < 3 {
+			continue
+		}
+		ratio := float64(ls.Count) / ls.Expected
+		share := float64(ls.Count) * 100 / float64(n)
+		switch {
+		case ratio >= 2:
+			out = append(out, LintIssue{Severity: SeverityError, Check: CheckAnswerBalance, Message: fmt.Sprintf("%s is the answer to %d of %d questions (%.0f%%), %.1f times its fair share; always guessing %s would pay", ls.Letter, ls.Count, n, share, ratio, ls.Letter)})
+		case ratio >= 1.5:
+			out = append(out, LintIssue{Severity: SeverityWarning, Check: CheckAnswerBalance, Message: fmt.Sprintf("%s is the answer to %d of %d questions (%.0f%%), %.1f times its fair share", ls.Letter, ls.Count, n, share, ratio)})
+		case ratio <= 0.5:
+			out = append(out, LintIssue{Severity: SeverityWarning, Check: CheckAnswerBalance, Message: fmt.Sprintf("%s is the answer to only %d of %d questions (%.0f%%), %.1f times its fair share", ls.Letter, ls.Count, n, share, ratio)})
+		}
+	}
+	return out
+}
+
+// longOption reports the option that is at least twice as long as the median
+// of the others, and 20 characters longer, with how many times as long it
+// is.
+func longOption(q Question) (letter string, ratio float64, ok bool) {
+	if len(q.Options) < 2 {
+		return "", 0, false
+	}
+	keys := sortedOptionKeys(q)
+	longest := keys[0]
+	for _, k := range keys[1:] {
+		if optionLen(q, k) > optionLen(q, longest) {
+			longest = k
+		}
+	}
+	var others []int
+	for _, k := range keys {
+		if k != longest {
+			others = append(others, optionLen(q, k))
+		}
+	}
+	slices.Sort(others)
+	median := float64(others[len(others)/2])
+	if len(others)%2 == 0 {
+		median = float64(others[len(others)/2-1]+others[len(others)/2]) / 2
+	}
+	l := float64(optionLen(q, longest))
+	if median == 0 || l < 2*median || l-median < 20 {
+		return "", 0, false
+	}
+	return longest, l / median, true
+}
+
+func optionLen(q Question, key string) int {
+	return utf8.RuneCountInString(strings.TrimSpace(q.Options[key]))
+}
+
+func sortedOptionKeys(q Question) []string {
+	keys := make([]string, 0, len(q.Options))
+	for k := range q.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,41 @@
+package quiz
+
+import "context"
+
+// Listener receives session events. Implementations can layer features such as
+// logging, sounds, or streak tracking on top of a Session without touching the
+// core queue logic. Callbacks run synchronously, outside the session lock, so
+// they may safely call back into the Session. ctx is the context passed to the
+// Session operation that raised the event; long-running listeners should honour
+// its cancellation.
+type Listener interface {
+	OnQuestionShown(ctx context.Context, index int, q Question)
+	OnAnswered(ctx context.Context, index int, q Question, res Result)
+	OnFinished(ctx context.Context, score, answered int)
+}
+
+// ListenerFuncs adapts plain functions to the Listener interface. Nil fields are
+// ignored, so callers only set the events they care about.
+type ListenerFuncs struct {
+	QuestionShown func(ctx context.Context, index int, q Question)
+	Answered      func(ctx context.Context, index int, q Question, res Result)
+	Finished      func(ctx context.Context, score, answered int)
+}
+
+func (l ListenerFuncs) OnQuestionShown(ctx context.Context, index int, q Question) {
+	if l.QuestionShown != nil {
+		l.QuestionShown(ctx, index, q)
+	}
+}
+
+func (l ListenerFuncs) OnAnswered(ctx context.Context, index int, q Question, res Result) {
+	if l.Answered != nil {
+		l.Answered(ctx, index, q, res)
+	}
+}
+
+func (l ListenerFuncs) OnFinished(ctx context.Context, score, answered int) {
+	if l.Finished != nil {
+		l.Finished(ctx, score, answered)
+	}
+}
@@ -0,0 +1,135 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MasteryStop ends a session early once the learner has shown mastery of
+// every domain in it: at least Accuracy of the domain's latest Window first
+// attempts right. A domain with fewer questions than Window is judged on all
+// of them.
+type MasteryStop struct {
+	// Accuracy is the share of first attempts needed, from 0 to 1.
+	Accuracy float64
+	Window   int
+}
+
+// ParseMasteryStop reads a rule such as "90/30", 90% right over each
+// domain's last 30 first attempts.
+func ParseMasteryStop(spec string) (MasteryStop, error) {
+	pct, window, ok := strings.Cut(strings.TrimSpace(spec), "/")
+	if !ok {
+		return MasteryStop{}, fmt.Errorf("mastery rule %q: want accuracy/window, e.g. 90/30", spec)
+	}
+	p, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(pct), "%"), 64)
+	if err != nil || p <= 0 || p > 100 {
+		return MasteryStop{}, fmt.Errorf("mastery rule %q: accuracy must be a percentage above 0 and up to 100", spec)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(window))
+	if err != nil || n < 1 {
+		return MasteryStop{}, fmt.Errorf("mastery rule %q: window must be a whole number of questions, at least 1", spec)
+	}
+	return MasteryStop{Accuracy: p / 100, Window: n}, nil
+}
+
+// String formats r as ParseMasteryStop reads it.
+func (r MasteryStop) String() string {
+	return fmt.Sprintf("%g/%d", r.Accuracy*100, r.Window)
+}
+
+// DomainMastery is how far one domain is toward a MasteryStop.
+type DomainMastery struct {
+	Domain int `json:"domain"`
+	// Window is how many of the domain's latest first attempts count: the
+	// rule's window, or the domain's question count when that is smaller.
+	Window int `json:"window"`
+	// Recent is how many of those first attempts have been made, and
+	// Correct how many of them were right.
+	Recent  int  `json:"recent"`
+	Correct int  `json:"correct"`
+	Met     bool `json:"met"`
+}
+
+// Percent is the domain's rolling first-attempt accuracy, or 0 before any
+// attempt.
+func (d DomainMastery) Percent() float64 {
+	if d.Recent == 0 {
+		return 0
+	}
+	return float64(d.Correct) * 100 / float64(d.Recent)
+}
+
+// UseMasteryStop ends the session as soon as every domain meets r. It must
+// be called before any answer and cannot be combined with sections, which
+// keep their own time and order.
+func (s *Session) UseMasteryStop(r MasteryStop) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.sections != nil:
+		return errors.New("a mastery stop cannot be combined with sections")
+	case s.attemptedCount > 0:
+		return errors.New("the session has already started")
+	case r.Window < 1 || r.Accuracy <= 0 || r.Accuracy > 1:
+		return fmt.Errorf("mastery rule %s is out of range", r)
+	}
+	s.masteryStop = r
+	s.firstTries = map[int][]bool{}
+	return nil
+}
+
+// MasteryStop reports how far each domain is toward the rule set with
+// UseMasteryStop, in domain order, and whether the session ended because
+// all of them met it. Both are empty without a rule.
+func (s *Session) MasteryStop() (reached bool, domains []DomainMastery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.masteryReached, s.domainMasteryLocked()
+}
+
+func (s *Session) domainMasteryLocked() []DomainMastery {
+	if s.masteryStop.Window == 0 {
+		return nil
+	}
+	counts := map[int]int{}
+	for _, q := range s.Questions {
+		counts[q.Domain]++
+	}
+	out := make([]DomainMastery, 0, len(counts))
+	for domain, n := range counts {
+		d := DomainMastery{Domain: domain, Window: min(s.masteryStop.Window, n)}
+		tries := s.firstTries[domain]
+		recent := tries[max(len(tries)-d.Window, 0):]
+		d.Recent = len(recent)
+		for _, ok := range recent {
+			if ok {
+				d.Correct++
+			}
+		}
+		// a hair of tolerance, so 27 of 30 meets 90% despite rounding
+		d.Met = d.Recent == d.Window && float64(d.Correct)/float64(d.Window) >= s.masteryStop.Accuracy-1e-9
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+// recordFirstTryLocked notes the first attempt at the question at idx for
+// the mastery stop, and ends the session once every domain meets it.
+func (s *Session) recordFirstTryLocked(idx int, correct bool) {
+	if s.masteryStop.Window == 0 {
+		return
+	}
+	domain := s.Questions[idx].Domain
+	s.firstTries[domain] = append(s.firstTries[domain], correct)
+	for _, d := range s.domainMasteryLocked() {
+		if !d.Met {
+			return
+		}
+	}
+	s.masteryReached = true
+}
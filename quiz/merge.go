@@ -0,0 +1,119 @@
+package quiz
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultSimilarity is the token overlap above which two prompts are reported
+// as near-duplicates by Merge.
+const DefaultSimilarity = 0.85
+
+// Duplicate pairs a merged question with a near-identical prompt from a later
+// bank. Both are kept; the pair is reported for a human to resolve.
+type Duplicate struct {
+	Kept       Question
+	Other      Question
+	Similarity float64
+}
+
+// Conflict is a prompt that appears in more than one bank with different
+// correct answers. The first bank's version is kept.
+type Conflict struct {
+	Kept  Question
+	Other Question
+}
+
+// MergeResult is the outcome of Merge.
+type MergeResult struct {
+	Questions  []Question
+	Exact      int
+	Duplicates []Duplicate
+	Conflicts  []Conflict
+}
+
+// Merge combines banks in order. Questions whose normalised prompts match an
+// earlier question are dropped (and reported as a Conflict if the correct
+// answer text differs); prompts whose word overlap is at least similarity are
+// kept but reported as Duplicates.
+func Merge(similarity float64, banks ...[]Question) MergeResult {
+	var res MergeResult
+	seen := map[string]int{}
+	var tokens [][]string
+	for _, bank := range banks {
+		for _, q := range bank {
+			norm := normalizePrompt(q.Prompt)
+			if i, ok := seen[norm]; ok {
+				kept := res.Questions[i]
+				if !strings.EqualFold(answerText(kept), answerText(q)) {
+					res.Conflicts = append(res.Conflicts, Conflict{Kept: kept, Other: q})
+				} else {
+					res.Exact++
+				}
+				continue
+			}
+			words := strings.Fields(norm)
+			for i, other := range tokens {
+				if sim := jaccard(words, other); sim >= similarity {
+					res.Duplicates = append(res.Duplicates, Duplicate{Kept: res.Questions[i], Other: q, Similarity: sim})
+					break
+				}
+			}
+			seen[norm] = len(res.Questions)
+			tokens = append(tokens, words)
+			res.Questions = append(res.Questions, q)
+		}
+	}
+	return res
+}
+
+// PromptSimilarity is the word overlap of two prompts, from 0 to 1, ignoring
+// case and punctuation: the measure Merge compares against its threshold.
+func PromptSimilarity(a, b string) float64 {
+	return jaccard(strings.Fields(normalizePrompt(a)), strings.Fields(normalizePrompt(b)))
+}
+
+func answerText(q Question) string {
+	for k, v := range q.Options {
+		if strings.EqualFold(k, strings.TrimSpace(q.Answer)) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return q.Answer
+}
+
+// normalizePrompt lowercases s, drops punctuation, and collapses whitespace.
+func normalizePrompt(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	union := len(set)
+	inter := 0
+	other := make(map[string]bool, len(b))
+	for _, w := range b {
+		if other[w] {
+			continue
+		}
+		other[w] = true
+		if set[w] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
@@ -0,0 +1,47 @@
+package quiz
+
+import "slices"
+
+// QueueEntry describes a question waiting in the queue, for the predicates
+// NextWhere takes.
+type QueueEntry struct {
+	// Index is the question's index in Questions.
+	Index    int
+	Question Question
+	// Attempted is set once the question has been answered at all, and
+	// Completed once it has been answered correctly; a completed question
+	// is only queued when it is asked again (see Requeue).
+	Attempted bool
+	Completed bool
+}
+
+// Unattempted matches the questions never answered yet.
+func Unattempted(e QueueEntry) bool {
+	return !e.Attempted
+}
+
+// Missed matches the questions answered wrongly and not yet answered right.
+func Missed(e QueueEntry) bool {
+	return e.Attempted && !e.Completed
+}
+
+// NextWhere skips ahead to the first question after the current one that
+// pred matches, moving the current question and the ones passed over to the
+// back of the queue in their order, so calling it again walks on through the
+// matches and comes round to the skipped ones last. It returns the question's
+// index, or -1 and false when no other queued question matches (within the
+// current section, for a sectioned session).
+func (s *Session) NextWhere(pred func(QueueEntry) bool) (int, Question, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for pos := 1; pos < len(s.queue); pos++ {
+		idx := s.queue[pos]
+		e := QueueEntry{Index: idx, Question: s.Questions[idx], Attempted: s.attempted[idx], Completed: s.completed[idx]}
+		if !pred(e) {
+			continue
+		}
+		s.queue = slices.Concat(s.queue[pos:], s.queue[:pos])
+		return idx, e.Question, true
+	}
+	return -1, Question{}, false
+}
@@ -0,0 +1,77 @@
+package quiz
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// Ordering is how a session shuffles its questions (see UseOrdering).
+type Ordering int
+
+const (
+	// ShuffleAll shuffles the whole bank, the default.
+	ShuffleAll Ordering = iota
+	// ShuffleWithinDomains keeps the domains in the order they first appear
+	// in the bank and shuffles the questions within each.
+	ShuffleWithinDomains
+	// FileOrder asks the questions in bank order.
+	FileOrder
+)
+
+var orderingNames = []string{"all", "domain", "none"}
+
+// String returns the name ParseOrdering accepts for o.
+func (o Ordering) String() string {
+	if o < 0 || int(o) >= len(orderingNames) {
+		return fmt.Sprintf("Ordering(%d)", int(o))
+	}
+	return orderingNames[o]
+}
+
+// ParseOrdering reads an ordering name: "all", "domain" or "none".
+func ParseOrdering(name string) (Ordering, error) {
+	if i := slices.Index(orderingNames, name); i >= 0 {
+		return Ordering(i), nil
+	}
+	return 0, fmt.Errorf("ordering must be all, domain or none, got %q", name)
+}
+
+// UseOrdering orders the questions by o instead of shuffling the whole bank.
+// Within-domain shuffles are drawn from the session's seed, so a seeded
+// session still replays the same run. Like UseOrder, it must be called
+// before any answer and cannot be combined with sections.
+func (s *Session) UseOrdering(o Ordering) error {
+	if o == ShuffleAll {
+		return nil
+	}
+	return s.UseOrder(o.order(s.Questions, s.Seed()))
+}
+
+// order returns the question indexes of qs in ordering o, drawing shuffles
+// from seed.
+func (o Ordering) order(qs []Question, seed int64) []int {
+	order := make([]int, len(qs))
+	for i := range order {
+		order[i] = i
+	}
+	if o != ShuffleWithinDomains {
+		return order
+	}
+	var domains []int
+	byDomain := map[int][]int{}
+	for i, q := range qs {
+		if _, ok := byDomain[q.Domain]; !ok {
+			domains = append(domains, q.Domain)
+		}
+		byDomain[q.Domain] = append(byDomain[q.Domain], i)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	order = order[:0]
+	for _, d := range domains {
+		idx := byDomain[d]
+		rng.Shuffle(len(idx), func(i, j int) { idx[i], idx[j] = idx[j], idx[i] })
+		order = append(order, idx...)
+	}
+	return order
+}
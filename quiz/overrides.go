@@ -0,0 +1,110 @@
+package quiz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Override corrects one question of a shared bank locally, for answers
+// known to be wrong that cannot be fixed upstream yet. Empty fields leave
+// the bank's value as it is.
+type Override struct {
+	Answer      string `json:"answer,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+	Source      string `json:"source,omitempty"`
+	// Reason says why the question is overridden, e.g. a link to the
+	// upstream issue; it is only reported.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Overrides maps question IDs to their corrections, as read from a file
+// such as
+//
+//	{"q42": {"answer": "C", "reason": "upstream issue #12"}}
+type Overrides map[string]Override
+
+// ParseOverrides reads an overrides file. Every entry must correct
+// something.
+func ParseOverrides(data []byte) (Overrides, error) {
+	var o Overrides
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("overrides: %w", err)
+	}
+	var errs []error
+	for id, ov := range o {
+		if ov.Answer == "" && ov.Explanation == "" && ov.Source == "" {
+			errs = append(errs, fmt.Errorf("overrides: %s sets no answer, explanation or source", id))
+		}
+	}
+	return o, errors.Join(errs...)
+}
+
+// AppliedOverride is an override applied to the question with ID, whose
+// answer was Was before it.
+type AppliedOverride struct {
+	ID string
+	Override
+	Was string
+}
+
+// OverrideReport says which overrides Apply used, in bank order, and lists
+// the IDs it found no question for, sorted, since a bank update may have
+// dropped or renamed them.
+type OverrideReport struct {
+	Applied []AppliedOverride
+	Unknown []string
+}
+
+// Apply returns a copy of questions with the overrides for their IDs
+// applied. An answer that names none of its question's options is an
+// error.
+func (o Overrides) Apply(questions []Question) ([]Question, OverrideReport, error) {
+	var rep OverrideReport
+	if len(o) == 0 {
+		return questions, rep, nil
+	}
+	out := make([]Question, len(questions))
+	copy(out, questions)
+	seen := map[string]bool{}
+	var errs []error
+	for i, q := range out {
+		ov, ok := o[q.ID]
+		if !ok {
+			continue
+		}
+		seen[q.ID] = true
+		applied := AppliedOverride{ID: q.ID, Override: ov, Was: q.Answer}
+		if ov.Answer != "" {
+			if !hasOption(q, ov.Answer) {
+				errs = append(errs, fmt.Errorf("overrides: %s: answer %q is not one of its options", q.ID, ov.Answer))
+				continue
+			}
+			for k := range q.Options {
+				if strings.EqualFold(k, strings.TrimSpace(ov.Answer)) {
+					q.Answer = k
+				}
+			}
+		}
+		if ov.Explanation != "" {
+			q.Explanation = ov.Explanation
+		}
+		if ov.Source != "" {
+			q.Source = ov.Source
+		}
+		out[i] = q
+		rep.Applied = append(rep.Applied, applied)
+	}
+	for id := range o {
+		if !seen[id] {
+			rep.Unknown = append(rep.Unknown, id)
+		}
+	}
+	sort.Strings(rep.Unknown)
+	if err := errors.Join(errs...); err != nil {
+		return nil, OverrideReport{}, err
+	}
+	return out, rep, nil
+}
@@ -0,0 +1,104 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Pacing gives each question a time limit that shrinks evenly over the
+// session, from Start for the first question to End for the last, to work up
+// to exam pace gradually instead of facing it from the first question. An
+// answer given after its question's limit counts as a miss.
+type Pacing struct {
+	Start, End time.Duration
+}
+
+// ParsePacing reads a pacing such as "90s:45s", 90 seconds for the first
+// question shrinking to 45 for the last.
+func ParsePacing(spec string) (Pacing, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	if !ok {
+		return Pacing{}, fmt.Errorf("pacing %q: want start:end time per question, e.g. 90s:45s", spec)
+	}
+	start, err := time.ParseDuration(strings.TrimSpace(from))
+	if err != nil {
+		return Pacing{}, fmt.Errorf("pacing %q: %w", spec, err)
+	}
+	end, err := time.ParseDuration(strings.TrimSpace(to))
+	if err != nil {
+		return Pacing{}, fmt.Errorf("pacing %q: %w", spec, err)
+	}
+	p := Pacing{Start: start, End: end}
+	return p, p.check()
+}
+
+func (p Pacing) check() error {
+	switch {
+	case p.Start < time.Second || p.End < time.Second:
+		return fmt.Errorf("pacing %s: each question needs at least a second", p)
+	case p.End > p.Start:
+		return fmt.Errorf("pacing %s: the time per question must not grow", p)
+	}
+	return nil
+}
+
+// String formats p as ParsePacing reads it.
+func (p Pacing) String() string {
+	return p.Start.String() + ":" + p.End.String()
+}
+
+// Limit is the time allowed for the question asked after answered others,
+// out of total.
+func (p Pacing) Limit(answered, total int) time.Duration {
+	if total < 2 {
+		return p.Start
+	}
+	step := min(max(answered, 0), total-1)
+	return p.Start - (p.Start-p.End)*time.Duration(step)/time.Duration(total-1)
+}
+
+// UsePacing gives each question the time limit p sets for it; see Pacing.
+// It must be called before any answer and cannot be combined with sections,
+// which keep time of their own.
+func (s *Session) UsePacing(p Pacing) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.sections != nil:
+		return errors.New("pacing cannot be combined with sections")
+	case s.attemptedCount > 0:
+		return errors.New("the session has already started")
+	}
+	if err := p.check(); err != nil {
+		return err
+	}
+	s.pacing = p
+	return nil
+}
+
+// Pacing returns the pacing set with UsePacing, if any.
+func (s *Session) Pacing() (Pacing, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pacing, s.pacing.Start > 0
+}
+
+// TimeLimit reports the time allowed for the question on screen and how much
+// of it is left on the session clock, which stops while paused. ok is false
+// without pacing or before Current has shown a question.
+func (s *Session) TimeLimit() (limit, left time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pacing.Start == 0 || s.shown < 0 {
+		return 0, 0, false
+	}
+	limit = s.limitLocked()
+	return limit, max(limit-s.now().Sub(s.shownAt), 0), true
+}
+
+// limitLocked is the time allowed for the question on screen.
+func (s *Session) limitLocked() time.Duration {
+	return s.pacing.Limit(s.attemptedCount, len(s.Questions))
+}
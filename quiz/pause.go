@@ -0,0 +1,51 @@
+package quiz
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPaused is returned by Answer while the session is paused.
+var ErrPaused = errors.New("the session is paused")
+
+// Pause stops the session clock, so section budgets stop running down and
+// the time until Resume is left out of the answer's Elapsed. Answers are
+// refused while paused; frontends should hide the question. Pausing a paused
+// session does nothing.
+func (s *Session) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pausedAt.IsZero() {
+		s.pausedAt = s.wall()
+	}
+}
+
+// Resume restarts the session clock after Pause or TakeBreak.
+func (s *Session) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.pausedAt.IsZero() {
+		s.pausedFor += s.wall().Sub(s.pausedAt)
+		s.pausedAt = time.Time{}
+	}
+	s.onBreak = false
+}
+
+// Paused reports whether the session is paused.
+func (s *Session) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.pausedAt.IsZero()
+}
+
+// PausedFor returns the total time the session has spent paused, including
+// a pause still running, for frontends to take off wall-clock totals.
+func (s *Session) PausedFor() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.pausedFor
+	if !s.pausedAt.IsZero() {
+		d += s.wall().Sub(s.pausedAt)
+	}
+	return d
+}
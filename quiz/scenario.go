@@ -0,0 +1,90 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Scenario is a case study several questions share, such as a system
+// description followed by questions about it. Its text is kept once, in the
+// bank's "scenarios" list, and shown above each of its questions, which are
+// asked one after the other in bank order (see keepScenariosTogether).
+type Scenario struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	// Text is the scenario in Markdown.
+	Text string `json:"text"`
+}
+
+// attachScenarios points each question of b that names a scenario at it.
+// Questions naming a scenario b lacks keep a nil Stem for Validate to
+// report.
+func attachScenarios(b *Bank) error {
+	byID := make(map[string]*Scenario, len(b.Scenarios))
+	var errs []error
+	for i := range b.Scenarios {
+		sc := &b.Scenarios[i]
+		switch {
+		case strings.TrimSpace(sc.ID) == "":
+			errs = append(errs, fmt.Errorf("scenario %d has no id", i+1))
+		case byID[sc.ID] != nil:
+			errs = append(errs, fmt.Errorf("scenario %q is defined twice", sc.ID))
+		case strings.TrimSpace(sc.Text) == "":
+			errs = append(errs, fmt.Errorf("scenario %q has no text", sc.ID))
+		}
+		byID[sc.ID] = sc
+	}
+	for i := range b.Questions {
+		if id := b.Questions[i].Scenario; id != "" {
+			b.Questions[i].Stem = byID[id]
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ScenariosOf lists the scenarios qs belong to, in the order they first
+// appear, for writing qs out as a bank of their own.
+func ScenariosOf(qs []Question) []Scenario {
+	var out []Scenario
+	seen := map[string]bool{}
+	for _, q := range qs {
+		if q.Stem != nil && !seen[q.Stem.ID] {
+			seen[q.Stem.ID] = true
+			out = append(out, *q.Stem)
+		}
+	}
+	return out
+}
+
+// keepScenariosTogether returns order, a permutation of the indexes of qs,
+// with the questions of each scenario gathered where the first of them
+// comes, in bank order, so a case study's questions are asked one after the
+// other however the rest is shuffled. Orders without scenarios come back
+// unchanged.
+func keepScenariosTogether(qs []Question, order []int) []int {
+	members := map[string][]int{}
+	for i, q := range qs {
+		if q.Scenario != "" {
+			members[q.Scenario] = append(members[q.Scenario], i)
+		}
+	}
+	if len(members) == 0 {
+		return order
+	}
+	out := make([]int, 0, len(order))
+	placed := map[string]bool{}
+	for _, idx := range order {
+		id := qs[idx].Scenario
+		if id == "" {
+			out = append(out, idx)
+			continue
+		}
+		if placed[id] {
+			continue
+		}
+		placed[id] = true
+		out = append(out, members[id]...)
+	}
+	return out
+}
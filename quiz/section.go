@@ -0,0 +1,285 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSectionTimeUp is returned by Answer when the current section's time
+// budget ran out before the answer arrived. The answer is not recorded and the
+// session has moved on to the next section.
+var ErrSectionTimeUp = errors.New("section time is up")
+
+// Section is one block of a sectioned exam, covering the questions of a
+// single domain. Sections run in order; once a section is finished or its
+// Budget runs out it is locked and its remaining questions are skipped.
+type Section struct {
+	Domain int
+	// Budget is the time allowed for the section, starting when its first
+	// question is shown. Zero means untimed.
+	Budget time.Duration
+	// Name replaces "Domain N" where the section is shown, e.g. for a
+	// single section spanning every domain.
+	Name string
+}
+
+// Title is how the section is labelled: its Name, or its domain.
+func (sec Section) Title() string {
+	if sec.Name != "" {
+		return sec.Name
+	}
+	return "Domain " + strconv.Itoa(sec.Domain)
+}
+
+// SectionSummary reports the state of one section.
+type SectionSummary struct {
+	Section
+	Index    int
+	Total    int
+	Answered int
+	Correct  int
+	Started  bool
+	// Elapsed is the time spent in the section so far, or in total once it
+	// is locked.
+	Elapsed time.Duration
+	// Remaining is the time left in the budget; zero for untimed or locked
+	// sections.
+	Remaining time.Duration
+	Locked    bool
+	TimedOut  bool
+}
+
+type sectionState struct {
+	Section
+	queue    []int
+	total    int
+	started  time.Time
+	ended    time.Time
+	timedOut bool
+}
+
+// DomainSections returns one section per domain in qs, in order of first
+// appearance, each with the given budget.
+func DomainSections(qs []Question, budget time.Duration) []Section {
+	var out []Section
+	seen := map[int]bool{}
+	for _, q := range qs {
+		if !seen[q.Domain] {
+			seen[q.Domain] = true
+			out = append(out, Section{Domain: q.Domain, Budget: budget})
+		}
+	}
+	return out
+}
+
+// ParseSections parses a comma-separated list of DOMAIN=DURATION entries,
+// such as "4=20m,5=15m,6". An entry without a duration is untimed.
+func ParseSections(spec string) ([]Section, error) {
+	var out []Section
+	seen := map[int]bool{}
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		domain, budget, hasBudget := strings.Cut(tok, "=")
+		d, err := strconv.Atoi(strings.TrimSpace(domain))
+		if err != nil {
+			return nil, fmt.Errorf("bad section %q, want DOMAIN=DURATION", tok)
+		}
+		if seen[d] {
+			return nil, fmt.Errorf("domain %d has more than one section", d)
+		}
+		seen[d] = true
+		sec := Section{Domain: d}
+		if hasBudget {
+			if sec.Budget, err = time.ParseDuration(strings.TrimSpace(budget)); err != nil || sec.Budget < 0 {
+				return nil, fmt.Errorf("bad section %q: invalid duration", tok)
+			}
+		}
+		out = append(out, sec)
+	}
+	return out, nil
+}
+
+// UseSections splits the session into sections, which must be set before the
+// first answer. Questions whose domain has no section join the last section,
+// and sections without questions are dropped.
+func (s *Session) UseSections(sections []Section) error {
+	if len(sections) == 0 {
+		return errors.New("no sections given")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attemptedCount > 0 {
+		return errors.New("sections must be set before the first answer")
+	}
+	if s.suddenDeath {
+		return errors.New("sudden death cannot be combined with sections")
+	}
+	if s.masteryStop.Window > 0 {
+		return errors.New("a mastery stop cannot be combined with sections")
+	}
+	if s.pacing.Start > 0 {
+		return errors.New("pacing cannot be combined with sections")
+	}
+	pos := make(map[int]int, len(sections))
+	states := make([]*sectionState, len(sections))
+	for i, sec := range sections {
+		if _, dup := pos[sec.Domain]; dup {
+			return fmt.Errorf("domain %d has more than one section", sec.Domain)
+		}
+		pos[sec.Domain] = i
+		states[i] = &sectionState{Section: sec}
+	}
+	for _, idx := range s.queue {
+		i, ok := pos[s.Questions[idx].Domain]
+		if !ok {
+			i = len(states) - 1
+		}
+		states[i].queue = append(states[i].queue, idx)
+		states[i].total++
+	}
+	s.sections = []*sectionState{}
+	s.sectionOf = make([]int, len(s.Questions))
+	for _, st := range states {
+		if st.total == 0 {
+			continue
+		}
+		for _, idx := range st.queue {
+			s.sectionOf[idx] = len(s.sections)
+		}
+		s.sections = append(s.sections, st)
+	}
+	s.section = -1
+	s.advanceSectionLocked()
+	return nil
+}
+
+// StartSection starts the current section's clock if it is not running yet.
+// Current starts it implicitly; frontends that show an intro screen call
+// StartSection when the learner is ready.
+func (s *Session) StartSection() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startSectionLocked()
+}
+
+// CurrentSection reports the section in progress, without starting its clock.
+// ok is false when the session has no sections or all are locked.
+func (s *Session) CurrentSection() (SectionSummary, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sections == nil || s.section >= len(s.sections) {
+		return SectionSummary{}, false
+	}
+	return s.sectionSummaryLocked(s.section), true
+}
+
+// Sections summarizes every section, or returns nil for an unsectioned
+// session.
+func (s *Session) Sections() []SectionSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sections == nil {
+		return nil
+	}
+	out := make([]SectionSummary, len(s.sections))
+	for i := range s.sections {
+		out[i] = s.sectionSummaryLocked(i)
+	}
+	return out
+}
+
+func (s *Session) sectionSummaryLocked(i int) SectionSummary {
+	st := s.sections[i]
+	sum := SectionSummary{
+		Section:  st.Section,
+		Index:    i,
+		Total:    st.total,
+		Started:  !st.started.IsZero(),
+		Locked:   i < s.section,
+		TimedOut: st.timedOut,
+	}
+	for idx, sec := range s.sectionOf {
+		if sec == i && s.attempted[idx] {
+			sum.Answered++
+			if s.results[idx].Correct {
+				sum.Correct++
+			}
+		}
+	}
+	switch {
+	case !st.ended.IsZero():
+		sum.Elapsed = st.ended.Sub(st.started)
+	case sum.Started:
+		sum.Elapsed = s.now().Sub(st.started)
+		if st.Budget > 0 {
+			sum.Remaining = max(st.Budget-sum.Elapsed, 0)
+		}
+	default:
+		sum.Remaining = st.Budget
+	}
+	return sum
+}
+
+func (s *Session) startSectionLocked() {
+	if s.sections == nil || s.section >= len(s.sections) {
+		return
+	}
+	if st := s.sections[s.section]; st.started.IsZero() {
+		st.started = s.now()
+	}
+}
+
+// sectionExpiredLocked reports whether the current section's budget has run
+// out.
+func (s *Session) sectionExpiredLocked() bool {
+	if s.sections == nil || s.section >= len(s.sections) {
+		return false
+	}
+	st := s.sections[s.section]
+	return st.Budget > 0 && !st.started.IsZero() && !s.now().Before(st.started.Add(st.Budget))
+}
+
+// endSectionLocked locks the current section and moves to the next one.
+func (s *Session) endSectionLocked(timedOut bool) {
+	st := s.sections[s.section]
+	st.ended = s.now()
+	if st.started.IsZero() {
+		st.started = st.ended
+	}
+	st.timedOut = timedOut
+	s.advanceSectionLocked()
+}
+
+func (s *Session) advanceSectionLocked() {
+	s.section++
+	s.shown = -1
+	if s.section >= len(s.sections) {
+		s.queue = nil
+		return
+	}
+	st := s.sections[s.section]
+	s.queue, st.queue = st.queue, nil
+}
+
+// now is the session clock: wall time less the time spent paused, standing
+// still during a pause. Section budgets and answer times run on it.
+func (s *Session) now() time.Time {
+	t := s.wall()
+	if !s.pausedAt.IsZero() {
+		t = s.pausedAt
+	}
+	return t.Add(-s.pausedFor)
+}
+
+func (s *Session) wall() time.Time {
+	if s.clock != nil {
+		return s.clock()
+	}
+	return time.Now()
+}
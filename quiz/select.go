@@ -0,0 +1,65 @@
+package quiz
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Select narrows qs to the questions named by only and/or rng, keeping bank
+// order. only is a comma-separated list of question IDs or 1-based positions
+// (written "42" or "q42", unless a question has that ID); rng is an inclusive 1-based position range such as
+// "10-30". Empty arguments select everything. When both are given, questions
+// matching either are kept.
+func Select(qs []Question, only, rng string) ([]Question, error) {
+	only, rng = strings.TrimSpace(only), strings.TrimSpace(rng)
+	if only == "" && rng == "" {
+		return qs, nil
+	}
+	keep := make([]bool, len(qs))
+	if only != "" {
+		byID := make(map[string]int, len(qs))
+		for i, q := range qs {
+			byID[strings.ToLower(q.ID)] = i
+		}
+		for _, tok := range strings.Split(only, ",") {
+			tok = strings.ToLower(strings.TrimSpace(tok))
+			if tok == "" {
+				continue
+			}
+			if i, ok := byID[tok]; ok {
+				keep[i] = true
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimPrefix(tok, "q"))
+			if err != nil || n < 1 || n > len(qs) {
+				return nil, fmt.Errorf("no question %q", tok)
+			}
+			keep[n-1] = true
+		}
+	}
+	if rng != "" {
+		lo, hi, ok := strings.Cut(rng, "-")
+		from, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		to, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if !ok || err1 != nil || err2 != nil || from < 1 || to < from {
+			return nil, fmt.Errorf("bad range %q, want FROM-TO", rng)
+		}
+		if from > len(qs) {
+			return nil, fmt.Errorf("range %q starts past the last question (%d)", rng, len(qs))
+		}
+		if to > len(qs) {
+			to = len(qs)
+		}
+		for i := from - 1; i < to; i++ {
+			keep[i] = true
+		}
+	}
+	var out []Question
+	for i, q := range qs {
+		if keep[i] {
+			out = append(out, q)
+		}
+	}
+	return out, nil
+}
@@ -1,27 +1,115 @@
+// Package quiz implements the question model and the answer queue that drives
+// a quiz session, independent of any particular frontend.
 package quiz
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/rand"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// Question is a single multiple-choice item as stored in a question bank.
 type Question struct {
+	// ID identifies the question independently of its position in the bank.
+	// LoadQuestions derives one from the prompt when the bank leaves it blank.
+	ID      string            `json:"id,omitempty"`
 	Domain  int               `json:"domain"`
 	Prompt  string            `json:"question"`
 	Options map[string]string `json:"options"`
 	Answer  string            `json:"answer"`
+	// Weight is how much the question counts toward the weighted score;
+	// zero means 1 (see Points).
+	Weight float64 `json:"weight,omitempty"`
+	// Explanation optionally says why Answer is right; it is shown after the
+	// question is answered and when a flashcard is revealed.
+	Explanation string `json:"explanation,omitempty"`
+	// Source optionally points to the material the question is drawn
+	// from, such as a book chapter or a URL; it is shown with the feedback
+	// and in summaries, for reading up on misses.
+	Source string `json:"source,omitempty"`
+	// Image optionally illustrates the question: an http(s) URL, or a file
+	// path relative to the bank.
+	Image string `json:"image,omitempty"`
+	// ImageAlt describes Image for learners who can't see it. Validate
+	// requires it whenever Image is set.
+	ImageAlt string `json:"imageAlt,omitempty"`
+	// Dir is the text direction of the prompt and options: "rtl", "ltr", or
+	// "auto" (the default), which follows the first strong character.
+	Dir string `json:"dir,omitempty"`
+	// Params makes the question a template: each presentation draws fresh
+	// values and fills in the {{expr}} placeholders in the prompt and
+	// options (see Render).
+	Params map[string]Param `json:"params,omitempty"`
+	// Updated is an optional ISO 8601 date recording when the question was
+	// last edited.
+	Updated string `json:"updated,omitempty"`
+	// Tags optionally label the question by topic, e.g. "crypto", for
+	// filtering.
+	Tags []string `json:"tags,omitempty"`
+	// Scenario optionally names the case study in the bank's scenarios the
+	// question belongs to, and Stem is that scenario, filled in by
+	// ParseBank.
+	Scenario string    `json:"scenario,omitempty"`
+	Stem     *Scenario `json:"-"`
 }
 
+// Points is q's weight in the weighted score: Weight, or 1 when unset.
+func (q Question) Points() float64 {
+	if q.Weight > 0 {
+		return q.Weight
+	}
+	return 1
+}
+
+// HasTag reports whether q is tagged tag, ignoring case.
+func (q Question) HasTag(tag string) bool {
+	tag = strings.TrimSpace(tag)
+	for _, t := range q.Tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageIsURL reports whether q.Image is a remote URL rather than a local path.
+func (q Question) ImageIsURL() bool {
+	return strings.HasPrefix(q.Image, "http://") || strings.HasPrefix(q.Image, "https://") || strings.HasPrefix(q.Image, "data:")
+}
+
+// Result records how a question was answered.
 type Result struct {
 	UserAnswer string `json:"userAnswer"`
 	Correct    bool   `json:"correct"`
+	// Params holds the template values the question was answered with.
+	Params map[string]float64 `json:"params,omitempty"`
+	// Confidence is the learner's rating of the answer (see Session.Rate).
+	Confidence Confidence `json:"confidence,omitempty"`
+	// Elapsed is how long the question was on screen before the answer;
+	// zero when it was answered without being shown by Current.
+	Elapsed time.Duration `json:"-"`
+	// Retry marks an answer to a question already attempted in the
+	// session, which does not count toward the first-attempt score.
+	Retry bool `json:"-"`
+	// TimedOut marks an answer given after the question's time limit (see
+	// UsePacing), which counts as a miss whatever was picked.
+	TimedOut bool `json:"timedOut,omitempty"`
 }
 
+// Session tracks progress through a shuffled question queue. Incorrectly
+// answered questions are requeued until answered correctly, while Results keeps
+// the first attempt at each question for grading. A Session is safe for
+// concurrent use.
 type Session struct {
 	Questions      []Question
 	attempted      []bool
@@ -30,50 +118,231 @@ type Session struct {
 	queue          []int
 	completedCount int
 	attemptedCount int
-	mu             sync.Mutex
+	shown          int
+	shownAt        time.Time
+	listeners      []Listener
+	// sections is nil unless UseSections was called; section indexes the
+	// one in progress and sectionOf maps question indexes to sections.
+	sections  []*sectionState
+	section   int
+	sectionOf []int
+	clock     func() time.Time
+	// presented and params hold the variation of each template question
+	// currently on screen; rng, seeded from seed, drives the draws.
+	presented []Question
+	params    []map[string]float64
+	rng       *rand.Rand
+	seed      int64
+	// penalty is the share of a question's points a wrong first attempt
+	// costs in WeightedScore (see UsePenalty).
+	penalty float64
+	// suddenDeath ends the session at the first wrong answer (see
+	// UseSuddenDeath); streak and longest count correct answers in a row.
+	suddenDeath     bool
+	streak, longest int
+	// singlePass asks each question once (see UseSinglePass).
+	singlePass bool
+	// masteryStop ends the session early (see UseMasteryStop); firstTries
+	// holds each domain's first attempts in order, and masteryReached is
+	// set once the rule is met.
+	masteryStop    MasteryStop
+	firstTries     map[int][]bool
+	masteryReached bool
+	// pacing sets each question's time limit (see UsePacing).
+	pacing Pacing
+	// pausedAt is when the running pause began, and pausedFor the length
+	// of the pauses before it; the session clock leaves both out (see Pause).
+	pausedAt  time.Time
+	pausedFor time.Duration
+	// breaks says when to suggest a break (see UseBreaks); sinceBreak counts
+	// the answers and breakAt marks the session clock since the last one.
+	// onBreak is set while paused for one.
+	breaks      Breaks
+	sinceBreak  int
+	breakAt     time.Time
+	onBreak     bool
+	breaksTaken int
+	mu          sync.Mutex
 }
 
+// LoadQuestions reads the questions of the bank file at path.
 func LoadQuestions(path string) ([]Question, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	var qs []Question
-	if err := json.Unmarshal(data, &qs); err != nil {
+	return ParseQuestions(data)
+}
+
+// ParseQuestions decodes the questions of a bank file (see ParseBank) and
+// assigns missing IDs.
+func ParseQuestions(data []byte) ([]Question, error) {
+	b, err := ParseBank(data)
+	return b.Questions, err
+}
+
+// AssignIDs gives every question without an ID one derived from its prompt
+// (see HashID), so saved progress survives reordering the bank. Repeated
+// prompts get "-2", "-3", ... suffixes.
+func AssignIDs(qs []Question) {
+	used := make(map[string]bool, len(qs))
+	for _, q := range qs {
+		if q.ID != "" {
+			used[q.ID] = true
+		}
+	}
+	for i := range qs {
+		if qs[i].ID != "" {
+			continue
+		}
+		base := HashID(qs[i].Prompt)
+		id := base
+		for n := 2; used[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		used[id] = true
+		qs[i].ID = id
+	}
+}
+
+// HashID returns the ID AssignIDs derives from prompt: "q" followed by the
+// first 8 hex digits of the SHA-256 of the prompt, lowercased with whitespace
+// collapsed.
+func HashID(prompt string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.Join(strings.Fields(prompt), " "))))
+	return "q" + hex.EncodeToString(sum[:4])
+}
+
+// SaveQuestions writes qs to path as an indented JSON array.
+func SaveQuestions(path string, qs []Question) error {
+	data, err := MarshalQuestions(qs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// MarshalQuestions encodes qs the way SaveQuestions writes them.
+func MarshalQuestions(qs []Question) ([]byte, error) {
+	data, err := json.MarshalIndent(qs, "", "  ")
+	if err != nil {
 		return nil, err
 	}
-	return qs, nil
+	return append(data, '\n'), nil
 }
 
+// NewSession returns a session over qs in random order.
 func NewSession(qs []Question) *Session {
-	rand.Seed(time.Now().UnixNano())
-	queue := rand.Perm(len(qs))
+	return NewSeededSession(qs, time.Now().UnixNano())
+}
+
+// NewSeededSession returns a session over qs whose order and template draws
+// are fixed by seed, so the same questions and seed replay the same run.
+func NewSeededSession(qs []Question, seed int64) *Session {
+	rng := rand.New(rand.NewSource(seed))
+	queue := keepScenariosTogether(qs, rng.Perm(len(qs)))
 	return &Session{
 		Questions: qs,
 		attempted: make([]bool, len(qs)),
 		completed: make([]bool, len(qs)),
 		results:   make([]Result, len(qs)),
+		presented: make([]Question, len(qs)),
+		params:    make([]map[string]float64, len(qs)),
 		queue:     queue,
+		shown:     -1,
+		seed:      seed,
+		rng:       rng,
 	}
 }
 
-func (s *Session) Current() (int, Question, bool) {
+// Seed returns the seed the session's order was drawn from.
+func (s *Session) Seed() int64 {
+	return s.seed
+}
+
+// AddListener registers l to receive session events.
+func (s *Session) AddListener(l Listener) {
+	if l == nil {
+		return
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, l)
+}
+
+func (s *Session) snapshotListeners() []Listener {
+	if len(s.listeners) == 0 {
+		return nil
+	}
+	out := make([]Listener, len(s.listeners))
+	copy(out, s.listeners)
+	return out
+}
+
+// Current returns the question at the front of the queue and its index in
+// Questions. ok is false once the queue is empty. ctx is handed to listeners
+// notified that the question is being shown.
+func (s *Session) Current(ctx context.Context) (int, Question, bool) {
+	s.mu.Lock()
+	if s.sectionExpiredLocked() {
+		s.endSectionLocked(true)
+		if len(s.queue) == 0 {
+			s.finishLocked(ctx)
+			return -1, Question{}, false
+		}
+	}
 	if len(s.queue) == 0 {
+		s.mu.Unlock()
 		return -1, Question{}, false
 	}
+	s.startSectionLocked()
 	idx := s.queue[0]
-	return idx, s.Questions[idx], true
+	var listeners []Listener
+	if idx != s.shown {
+		s.shown, s.shownAt = idx, s.now()
+		s.presentLocked(idx)
+		listeners = s.snapshotListeners()
+	}
+	q := s.questionLocked(idx)
+	s.mu.Unlock()
+	for _, l := range listeners {
+		l.OnQuestionShown(ctx, idx, q)
+	}
+	return idx, q, true
 }
 
-func (s *Session) Answer(answer string) (Result, bool, error) {
+// Answer grades answer against the current question and advances the queue.
+// finished reports whether the queue is now empty. If ctx is already done the
+// answer is not recorded and ctx.Err() is returned.
+func (s *Session) Answer(ctx context.Context, answer string) (Result, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, false, err
+	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	if len(s.queue) == 0 {
+		s.mu.Unlock()
 		return Result{}, true, errors.New("quiz already completed")
 	}
+	if !s.pausedAt.IsZero() {
+		s.mu.Unlock()
+		return Result{}, false, ErrPaused
+	}
+	if s.sectionExpiredLocked() {
+		s.endSectionLocked(true)
+		if len(s.queue) == 0 {
+			s.finishLocked(ctx)
+			return Result{}, true, ErrSectionTimeUp
+		}
+		s.mu.Unlock()
+		return Result{}, false, ErrSectionTimeUp
+	}
 	idx := s.queue[0]
+	var elapsed time.Duration
+	if s.shown != idx {
+		s.presentLocked(idx)
+	} else {
+		elapsed = s.now().Sub(s.shownAt)
+	}
 	s.queue = s.queue[1:]
 	ansRune := normalize(answer)
 	userAnswer := ""
@@ -83,6 +352,12 @@ func (s *Session) Answer(answer string) (Result, bool, error) {
 	res := Result{
 		UserAnswer: userAnswer,
 		Correct:    strings.EqualFold(strings.TrimSpace(answer), s.Questions[idx].Answer),
+		Params:     s.params[idx],
+		Elapsed:    elapsed,
+		Retry:      s.attempted[idx],
+	}
+	if s.pacing.Start > 0 && elapsed > s.limitLocked() {
+		res.Correct, res.TimedOut = false, true
 	}
 	if res.Correct && !s.completed[idx] {
 		s.completed[idx] = true
@@ -92,49 +367,176 @@ func (s *Session) Answer(answer string) (Result, bool, error) {
 		s.attempted[idx] = true
 		s.results[idx] = res
 		s.attemptedCount++
+		s.recordFirstTryLocked(idx, res.Correct)
 	}
-	if !res.Correct {
+	switch {
+	case res.Correct:
+		s.streak++
+		s.longest = max(s.longest, s.streak)
+	case s.suddenDeath:
+		s.streak = 0
+		s.queue = nil
+	case s.singlePass:
+		s.streak = 0
+	default:
+		s.streak = 0
 		s.queue = append(s.queue, idx)
 	}
+	if s.masteryReached {
+		s.queue = nil
+	}
+	s.shown = -1
+	s.sinceBreak++
+	if s.sections != nil && len(s.queue) == 0 {
+		s.endSectionLocked(false)
+	}
 	finished := len(s.queue) == 0
+	q := s.questionLocked(idx)
+	listeners := s.snapshotListeners()
+	score, answered := s.scoreLocked()
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		l.OnAnswered(ctx, idx, q, res)
+	}
+	if finished {
+		for _, l := range listeners {
+			l.OnFinished(ctx, score, answered)
+		}
+	}
 	return res, finished, nil
 }
 
-func (s *Session) BringToFront(target int) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if target < 0 || target >= len(s.completed) || s.completed[target] {
+// presentLocked draws a fresh variation of the question at idx if it is a
+// template. A template that fails to render is shown as written.
+func (s *Session) presentLocked(idx int) {
+	q := s.Questions[idx]
+	if !q.IsTemplate() {
 		return
 	}
-	pos := -1
-	for i, v := range s.queue {
-		if v == target {
-			pos = i
-			break
-		}
+	inst, params, err := q.Instantiate(s.rng)
+	if err != nil {
+		inst, params = q, nil
 	}
-	if pos == 0 {
-		return
+	s.presented[idx], s.params[idx] = inst, params
+}
+
+// questionLocked returns the question at idx as currently presented.
+func (s *Session) questionLocked(idx int) Question {
+	if s.params[idx] != nil {
+		return s.presented[idx]
+	}
+	return s.Questions[idx]
+}
+
+// finishLocked unlocks s and notifies listeners that the session ended
+// without a final answer, such as when the last section times out.
+func (s *Session) finishLocked(ctx context.Context) {
+	listeners := s.snapshotListeners()
+	score, answered := s.scoreLocked()
+	s.mu.Unlock()
+	for _, l := range listeners {
+		l.OnFinished(ctx, score, answered)
 	}
-	if pos == -1 {
+}
+
+// ErrMastered is returned by Requeue for a question already answered
+// correctly, unless it is forced.
+var ErrMastered = errors.New("the question is already answered correctly")
+
+// BringToFront moves the question at index target to the front of the queue.
+// Completed questions, questions outside the current section, and
+// out-of-range indexes are ignored; Requeue says why.
+func (s *Session) BringToFront(target int) {
+	_ = s.Requeue(target, false)
+}
+
+// Requeue moves the question at index target to the front of the queue. A
+// question already answered correctly is refused with ErrMastered unless
+// force is set, in which case it is asked again, to re-verify it: the
+// re-attempt leaves its first-attempt result and the progress count as they
+// were, and a miss requeues it as usual. Questions outside the current
+// section, out-of-range indexes and finished sessions are refused.
+func (s *Session) Requeue(target int, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case target < 0 || target >= len(s.completed):
+		return fmt.Errorf("no question %d", target+1)
+	case len(s.queue) == 0:
+		return errors.New("the session has finished")
+	case s.sections != nil && (s.section >= len(s.sections) || s.sectionOf[target] != s.section):
+		return errors.New("the question is in another section")
+	case s.completed[target] && !force:
+		return ErrMastered
+	}
+	pos := slices.Index(s.queue, target)
+	switch pos {
+	case 0:
+	case -1:
 		s.queue = append([]int{target}, s.queue...)
-		return
+	default:
+		s.queue = append([]int{target}, append(s.queue[:pos], s.queue[pos+1:]...)...)
 	}
-	s.queue = append([]int{target}, append(s.queue[:pos], s.queue[pos+1:]...)...)
+	return nil
 }
 
+// Progress reports how many questions have been answered correctly.
 func (s *Session) Progress() (completed, total int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return s.completedCount, len(s.Questions)
 }
 
+// DomainProgress is the progress through one domain's questions.
+type DomainProgress struct {
+	Domain    int
+	Total     int
+	Attempted int
+	Completed int
+}
+
+// DomainProgress reports the progress through each domain of the session, in
+// domain order.
+func (s *Session) DomainProgress() []DomainProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos := map[int]int{}
+	var out []DomainProgress
+	for i, q := range s.Questions {
+		j, ok := pos[q.Domain]
+		if !ok {
+			j = len(out)
+			pos[q.Domain] = j
+			out = append(out, DomainProgress{Domain: q.Domain})
+		}
+		out[j].Total++
+		if s.attempted[i] {
+			out[j].Attempted++
+		}
+		if s.completed[i] {
+			out[j].Completed++
+		}
+	}
+	slices.SortFunc(out, func(a, b DomainProgress) int { return a.Domain - b.Domain })
+	return out
+}
+
+// AttemptedCount reports how many distinct questions have been answered.
 func (s *Session) AttemptedCount() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return s.attemptedCount
 }
 
+// Attempted reports whether the question at idx has been answered.
+func (s *Session) Attempted(idx int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return idx >= 0 && idx < len(s.attempted) && s.attempted[idx]
+}
+
+// Results returns a copy of the first-attempt results, indexed like Questions.
 func (s *Session) Results() []Result {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -143,9 +545,14 @@ func (s *Session) Results() []Result {
 	return out
 }
 
+// Score reports the first-attempt score over the questions answered so far.
 func (s *Session) Score() (score, answered int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.scoreLocked()
+}
+
+func (s *Session) scoreLocked() (score, answered int) {
 	for i, res := range s.results {
 		if s.attempted[i] {
 			answered++
@@ -157,6 +564,111 @@ func (s *Session) Score() (score, answered int) {
 	return score, answered
 }
 
+// WeightedScore is Score with each question counting its Points: earned is
+// the points for correct first attempts, less the penalty for wrong ones, and
+// possible the points for every question answered so far. earned can be
+// negative under a penalty.
+func (s *Session) WeightedScore() (earned, possible float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, res := range s.results {
+		if !s.attempted[i] {
+			continue
+		}
+		points := s.Questions[i].Points()
+		possible += points
+		if res.Correct {
+			earned += points
+		} else {
+			earned -= s.penalty * points
+		}
+	}
+	return earned, possible
+}
+
+// UsePenalty makes each wrong first attempt cost penalty times the question's
+// Points in WeightedScore, like certification exams that penalize guessing;
+// 0.25 takes a quarter point off a one-point question. Unanswered questions
+// cost nothing.
+func (s *Session) UsePenalty(penalty float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.penalty = penalty
+}
+
+// Penalty returns the penalty set by UsePenalty.
+func (s *Session) Penalty() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.penalty
+}
+
+// UseOrder replaces the shuffled order with order, a permutation of the
+// question indexes, such as hardest first, still keeping each scenario's
+// questions together. It must be called before any answer and cannot be
+// combined with sections, which keep their own order.
+func (s *Session) UseOrder(order []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.sections != nil:
+		return errors.New("a fixed order cannot be combined with sections")
+	case s.attemptedCount > 0:
+		return errors.New("the session has already started")
+	case len(order) != len(s.Questions):
+		return fmt.Errorf("order has %d questions, want %d", len(order), len(s.Questions))
+	}
+	seen := make([]bool, len(order))
+	for _, idx := range order {
+		if idx < 0 || idx >= len(order) || seen[idx] {
+			return errors.New("order is not a permutation of the questions")
+		}
+		seen[idx] = true
+	}
+	s.queue = keepScenariosTogether(s.Questions, slices.Clone(order))
+	s.shown = -1
+	return nil
+}
+
+// UseSuddenDeath ends the session at the first wrong answer instead of
+// requeueing it, so the score is the streak of correct answers before the
+// miss. It cannot be combined with sections.
+func (s *Session) UseSuddenDeath() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sections != nil {
+		return errors.New("sudden death cannot be combined with sections")
+	}
+	s.suddenDeath = true
+	return nil
+}
+
+// UseSinglePass asks each question once, as in an exam: a wrong answer is
+// graded and not requeued, so the session ends after one pass through the
+// questions.
+func (s *Session) UseSinglePass() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.singlePass = true
+}
+
+// SuddenDeath reports whether UseSuddenDeath was called.
+func (s *Session) SuddenDeath() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.suddenDeath
+}
+
+// Streak returns the number of correct answers in a row up to now and the
+// longest such run this session.
+func (s *Session) Streak() (current, longest int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streak, s.longest
+}
+
+// Completed reports whether every question has been answered correctly, or,
+// under sudden death, whether the session has ended.
 func (s *Session) Completed() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -0,0 +1,1066 @@
+package quiz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenerReceivesEvents(t *testing.T) {
+	qs := []Question{
+		{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"},
+	}
+	s := NewSession(qs)
+
+	var shown, answered, finished int
+	s.AddListener(ListenerFuncs{
+		QuestionShown: func(context.Context, int, Question) { shown++ },
+		Answered:      func(context.Context, int, Question, Result) { answered++ },
+		Finished: func(_ context.Context, score, total int) {
+			finished++
+			if score != 0 || total != 1 {
+				t.Fatalf("unexpected final score %d/%d", score, total)
+			}
+		},
+	})
+
+	ctx := context.Background()
+	s.Current(ctx)
+	s.Current(ctx)
+	if shown != 1 {
+		t.Fatalf("repeated Current should notify once, got %d", shown)
+	}
+	s.Answer(ctx, "A")
+	s.Current(ctx)
+	if shown != 2 {
+		t.Fatalf("requeued question should be shown again, got %d", shown)
+	}
+	s.Answer(ctx, "B")
+	if answered != 2 || finished != 1 {
+		t.Fatalf("answered=%d finished=%d, want 2 and 1", answered, finished)
+	}
+}
+
+func TestAnswerHonoursCancelledContext(t *testing.T) {
+	s := NewSession([]Question{{Prompt: "Sky color?", Options: map[string]string{"A": "Blue"}, Answer: "A"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := s.Answer(ctx, "A"); err == nil {
+		t.Fatalf("expected error from cancelled context")
+	}
+	if s.AttemptedCount() != 0 || s.Completed() {
+		t.Fatalf("cancelled answer should not be recorded")
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	qs := []Question{
+		{Prompt: "ok", Options: map[string]string{"A": "x", "B": "y"}, Answer: "b"},
+		{Prompt: " ", Options: map[string]string{"A": "x"}, Answer: "C"},
+		{Prompt: "diagram", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A", Image: "net.png", ImageAlt: " "},
+	}
+	err := Validate(qs)
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{"question 2: empty prompt", "at least two options", `answer "C"`, `question 3: image "net.png" has no imageAlt`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q missing %q", err, want)
+		}
+	}
+	if err := Validate(qs[:1]); err != nil {
+		t.Fatalf("valid bank rejected: %v", err)
+	}
+}
+
+func TestMergeDedupsAndReportsConflicts(t *testing.T) {
+	opts := map[string]string{"A": "Blue", "B": "Green"}
+	a := []Question{
+		{Prompt: "What colour is the clear daytime sky?", Options: opts, Answer: "A"},
+		{Prompt: "Which layer handles routing?", Options: opts, Answer: "A"},
+	}
+	b := []Question{
+		{Prompt: "what colour is the clear daytime sky", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"},
+		{Prompt: "Which layer handles routing!", Options: opts, Answer: "B"},
+		{Prompt: "What colour is the clear daytime sky today?", Options: opts, Answer: "A"},
+	}
+	res := Merge(DefaultSimilarity, a, b)
+	if len(res.Questions) != 3 || res.Exact != 1 {
+		t.Fatalf("questions=%d exact=%d, want 3 and 1", len(res.Questions), res.Exact)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Other.Prompt != "Which layer handles routing!" {
+		t.Fatalf("conflicts = %+v", res.Conflicts)
+	}
+	if len(res.Duplicates) != 1 || res.Duplicates[0].Kept.Prompt != a[0].Prompt {
+		t.Fatalf("duplicates = %+v", res.Duplicates)
+	}
+}
+
+func TestSelectByIDPositionAndRange(t *testing.T) {
+	qs := make([]Question, 5)
+	for i := range qs {
+		qs[i].Prompt = strconv.Itoa(i + 1)
+	}
+	qs[4].ID = "routing"
+	AssignIDs(qs)
+
+	got, err := Select(qs, "q2, routing", "")
+	if err != nil || len(got) != 2 || got[0].Prompt != "2" || got[1].Prompt != "5" {
+		t.Fatalf("Select only = %+v, %v", got, err)
+	}
+	got, err = Select(qs, "1", "3-9")
+	if err != nil || len(got) != 4 || got[0].Prompt != "1" || got[1].Prompt != "3" {
+		t.Fatalf("Select range = %+v, %v", got, err)
+	}
+	if _, err := Select(qs, "q9", ""); err == nil {
+		t.Fatalf("expected error for unknown question")
+	}
+}
+
+func TestAssignIDsStableAcrossReordering(t *testing.T) {
+	a := []Question{{Prompt: "What is TLS?"}, {Prompt: "What is  SSH?"}, {Prompt: "what is tls?"}}
+	b := []Question{{Prompt: "New question"}, {Prompt: "What is ssh?"}, {Prompt: "What is TLS?"}}
+	AssignIDs(a)
+	AssignIDs(b)
+	if a[1].ID != b[1].ID || a[0].ID != b[2].ID {
+		t.Fatalf("IDs changed with order: %+v vs %+v", a, b)
+	}
+	if a[2].ID != a[0].ID+"-2" {
+		t.Fatalf("repeated prompt ID = %q, want %q", a[2].ID, a[0].ID+"-2")
+	}
+}
+
+func TestSectionsLockInOrderAndTimeOut(t *testing.T) {
+	qs := []Question{
+		{Domain: 1, Prompt: "a", Answer: "A"},
+		{Domain: 2, Prompt: "b", Answer: "A"},
+		{Domain: 2, Prompt: "c", Answer: "A"},
+	}
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewSession(qs)
+	s.clock = func() time.Time { return now }
+	if err := s.UseSections([]Section{{Domain: 1}, {Domain: 2, Budget: time.Minute}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	idx, _, _ := s.Current(ctx)
+	if idx != 0 {
+		t.Fatalf("first question = %d, want the domain 1 question", idx)
+	}
+	s.BringToFront(1) // outside the current section: ignored
+	if idx, _, _ := s.Current(ctx); idx != 0 {
+		t.Fatalf("BringToFront crossed a section boundary")
+	}
+	if _, finished, err := s.Answer(ctx, "A"); err != nil || finished {
+		t.Fatalf("answer = %v, %v", finished, err)
+	}
+	if sec, ok := s.CurrentSection(); !ok || sec.Domain != 2 || sec.Started {
+		t.Fatalf("current section = %+v, %v", sec, ok)
+	}
+
+	s.Current(ctx) // starts the clock
+	now = now.Add(2 * time.Minute)
+	if _, finished, err := s.Answer(ctx, "A"); !errors.Is(err, ErrSectionTimeUp) || !finished {
+		t.Fatalf("late answer = %v, %v; want ErrSectionTimeUp and finished", finished, err)
+	}
+	secs := s.Sections()
+	if len(secs) != 2 || !secs[0].Locked || secs[0].Correct != 1 || !secs[1].TimedOut || secs[1].Answered != 0 {
+		t.Fatalf("sections = %+v", secs)
+	}
+}
+
+func TestEvalExpr(t *testing.T) {
+	vars := map[string]float64{"a": 6, "b": 4}
+	for src, want := range map[string]float64{
+		"a * b + 1":         25,
+		"-(a - b) ^ 2":      -4,
+		"2 ^ 3 ^ 2":         512,
+		"a % b + a / b":     3.5,
+		"max(a, b, 10) - 1": 9,
+		"round(sqrt(a*b))":  5,
+	} {
+		got, err := EvalExpr(src, vars)
+		if err != nil || got != want {
+			t.Errorf("EvalExpr(%q) = %v, %v; want %v", src, got, err, want)
+		}
+	}
+	for _, src := range []string{"a / (b - 4)", "c + 1", "a +", "min()", "(a"} {
+		if _, err := EvalExpr(src, vars); err == nil {
+			t.Errorf("EvalExpr(%q) should fail", src)
+		}
+	}
+}
+
+func TestTemplateQuestionsDrawPerPresentation(t *testing.T) {
+	q := Question{
+		Prompt:  "What is {{a}} times {{b}}?",
+		Params:  map[string]Param{"a": {Min: 2, Max: 9}, "b": {Min: 0.5, Max: 1.5, Step: 0.5}},
+		Options: map[string]string{"A": "{{a + b}}", "B": "{{a * b:1}}"},
+		Answer:  "B",
+	}
+	s := NewSession([]Question{q})
+	s.rng = rand.New(rand.NewSource(1))
+	ctx := context.Background()
+
+	_, shown, _ := s.Current(ctx)
+	if strings.Contains(shown.Prompt, "{{") || shown.Options["A"] == shown.Options["B"] {
+		t.Fatalf("template not rendered: %+v", shown)
+	}
+	res, _, _ := s.Answer(ctx, "A")
+	want, _ := EvalExpr("a * b", res.Params)
+	if res.Params == nil || shown.Options["B"] != strconv.FormatFloat(want, 'f', 1, 64) {
+		t.Fatalf("result params %v don't match option %q", res.Params, shown.Options["B"])
+	}
+	if got := s.Results()[0].Params; got["a"] != res.Params["a"] {
+		t.Fatalf("Results lost params: %v", got)
+	}
+
+	bad := q
+	bad.Options = map[string]string{"A": "{{a / (b - b)}}", "B": "{{a}}"}
+	err := Validate([]Question{bad})
+	if err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Fatalf("Validate = %v, want template error", err)
+	}
+	if err := Validate([]Question{q}); err != nil {
+		t.Fatalf("valid template rejected: %v", err)
+	}
+}
+
+func TestRateAndCalibrate(t *testing.T) {
+	qs := []Question{
+		{Prompt: "one", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{Prompt: "two", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	s := NewSession(qs)
+	s.queue = []int{0, 1}
+	ctx := context.Background()
+	if s.Rate(0, Sure) {
+		t.Fatalf("rated an unanswered question")
+	}
+	s.Answer(ctx, "A")
+	s.Answer(ctx, "B")
+	if !s.Rate(0, Sure) || !s.Rate(1, Sure) || s.Rate(1, Guessing) {
+		t.Fatalf("want each first attempt rated exactly once")
+	}
+	if Calibrate(nil) != nil {
+		t.Fatalf("no ratings should give no calibration")
+	}
+	b := Calibrate(s.Results())
+	if len(b) != 3 || b[2].Answered != 2 || b[2].Correct != 1 || b[2].Percent() != 50 || b[0].Answered != 0 {
+		t.Fatalf("calibration = %+v", b)
+	}
+	if c, err := ParseConfidence("Unsure"); err != nil || c != Unsure {
+		t.Fatalf("ParseConfidence = %v, %v", c, err)
+	}
+}
+
+func TestSeededSessionsReplay(t *testing.T) {
+	var qs []Question
+	for i := 0; i < 20; i++ {
+		qs = append(qs, Question{Prompt: strconv.Itoa(i), Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"})
+	}
+	a, b := NewSeededSession(qs, 7), NewSeededSession(qs, 7)
+	if a.Seed() != 7 || len(a.queue) != len(qs) {
+		t.Fatalf("seed = %d", a.Seed())
+	}
+	for i := range a.queue {
+		if a.queue[i] != b.queue[i] {
+			t.Fatalf("same seed gave different orders: %v vs %v", a.queue, b.queue)
+		}
+	}
+}
+
+func TestWeightedScore(t *testing.T) {
+	qs := []Question{
+		{ID: "big", Weight: 3, Prompt: "big", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{ID: "small", Prompt: "small", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	s := NewSession(qs)
+	ctx := context.Background()
+	for range qs {
+		_, q, _ := s.Current(ctx)
+		ans := "B"
+		if q.ID == "big" {
+			ans = "A"
+		}
+		s.Answer(ctx, ans)
+	}
+	if score, answered := s.Score(); score != 1 || answered != 2 {
+		t.Fatalf("Score = %d/%d", score, answered)
+	}
+	if earned, possible := s.WeightedScore(); earned != 3 || possible != 4 {
+		t.Fatalf("WeightedScore = %g/%g, want 3/4", earned, possible)
+	}
+	s.UsePenalty(0.5)
+	if earned, possible := s.WeightedScore(); earned != 2.5 || possible != 4 {
+		t.Fatalf("WeightedScore with penalty = %g/%g, want 2.5/4", earned, possible)
+	}
+	if err := Validate([]Question{{Prompt: "p", Weight: -1, Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"}}); err == nil || !strings.Contains(err.Error(), "weight") {
+		t.Fatalf("negative weight accepted: %v", err)
+	}
+}
+
+func TestSuddenDeathEndsAtFirstMiss(t *testing.T) {
+	var qs []Question
+	for i := 0; i < 5; i++ {
+		qs = append(qs, Question{Prompt: "q" + strconv.Itoa(i), Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"})
+	}
+	s := NewSession(qs)
+	if err := s.UseSuddenDeath(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	var finished bool
+	for _, ans := range []string{"A", "A", "A", "B"} {
+		if _, finished, _ = s.Answer(ctx, ans); finished != (ans == "B") {
+			t.Fatalf("answer %s: finished = %v", ans, finished)
+		}
+	}
+	if !s.Completed() {
+		t.Fatal("session not over after the miss")
+	}
+	if current, longest := s.Streak(); current != 0 || longest != 3 {
+		t.Fatalf("Streak = %d, %d, want 0, 3", current, longest)
+	}
+	if score, answered := s.Score(); score != 3 || answered != 4 {
+		t.Fatalf("Score = %d/%d, want 3/4", score, answered)
+	}
+	fresh := NewSession(qs)
+	fresh.UseSuddenDeath()
+	if err := fresh.UseSections([]Section{{Domain: 0}}); err == nil {
+		t.Fatal("sections accepted under sudden death")
+	}
+}
+
+func TestSinglePassAsksEachQuestionOnce(t *testing.T) {
+	var qs []Question
+	for i := 0; i < 3; i++ {
+		qs = append(qs, Question{Prompt: "q" + strconv.Itoa(i), Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"})
+	}
+	s := NewSession(qs)
+	s.UseSinglePass()
+	ctx := context.Background()
+	var finished bool
+	for i, ans := range []string{"B", "A", "B"} {
+		if _, finished, _ = s.Answer(ctx, ans); finished != (i == 2) {
+			t.Fatalf("answer %d: finished = %v", i, finished)
+		}
+	}
+	if score, answered := s.Score(); score != 1 || answered != 3 {
+		t.Fatalf("Score = %d/%d, want 1/3", score, answered)
+	}
+	for mode, want := range map[string]Feedback{"full": {}, "no-reveal": {HideAnswer: true}, "none": {Silent: true}, "blind": {Silent: true, Blind: true}} {
+		if got, err := ParseFeedback(mode); err != nil || got != want {
+			t.Errorf("ParseFeedback(%q) = %+v, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseFeedback("quiet"); err == nil {
+		t.Error("ParseFeedback accepted an unknown mode")
+	}
+}
+
+func TestLintFindsImbalanceAndGiveaways(t *testing.T) {
+	var qs []Question
+	for i := 0; i < 16; i++ {
+		q := Question{ID: "q" + strconv.Itoa(i), Prompt: "p", Options: map[string]string{"A": "one", "B": "two", "C": "three", "D": "four"}, Answer: "C", Explanation: "why"}
+		if i%4 == 0 {
+			q.Answer = string(rune('A' + i/4))
+		}
+		qs = append(qs, q)
+	}
+	qs[1].Options = map[string]string{"A": "one", "B": "two", "C": "a far longer and more carefully hedged option", "D": "four"}
+	qs[2].Options = map[string]string{"A": "one", "B": "two", "C": "three", "D": "None of the above"}
+	qs[3].Explanation = ""
+
+	r := Lint(qs)
+	found := map[string]Severity{}
+	for _, is := range r.Issues {
+		key := is.Check
+		if is.Question > 0 {
+			key += " Q" + strconv.Itoa(is.Question)
+		}
+		found[key] = max(found[key], is.Severity)
+	}
+	want := map[string]Severity{
+		CheckAnswerBalance:         SeverityError,
+		CheckOptionLength + " Q2":  SeverityWarning,
+		CheckAboveOption + " Q3":   SeverityWarning,
+		CheckNoExplanation + " Q4": SeverityInfo,
+	}
+	for key, sev := range want {
+		if got, ok := found[key]; !ok || got != sev {
+			t.Errorf("%s: got %v (found %v), want %v", key, got, ok, sev)
+		}
+	}
+	if worst, _ := r.Worst(); worst != SeverityError || r.Count(SeverityWarning) != 5 {
+		t.Fatalf("worst = %v, warnings = %d: %+v", worst, r.Count(SeverityWarning), r.Issues)
+	}
+	if small := Lint(qs[:4]); small.Count(SeverityError) != 0 {
+		t.Fatalf("small bank judged for balance: %+v", small.Issues)
+	}
+}
+
+func TestDescribeCountsTheBank(t *testing.T) {
+	qs := []Question{
+		{Domain: 5, Options: map[string]string{"A": "x", "B": "y"}, Answer: "A", Tags: []string{"crypto"}, Explanation: "why"},
+		{Domain: 4, Options: map[string]string{"A": "x", "B": "y", "C": "z"}, Answer: "C", Tags: []string{"crypto", "web"}},
+		{Domain: 5, Options: map[string]string{"A": "x", "B": "y", "C": "z", "D": "w"}, Answer: "A"},
+	}
+	st := Describe(qs)
+	if st.Questions != 3 || st.AvgOptions != 3 || st.Untagged != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+	if !slices.Equal(st.Domains, []DomainCount{{4, 1}, {5, 2}}) || !slices.Equal(st.Tags, []TagCount{{"crypto", 2}, {"web", 1}}) {
+		t.Fatalf("domains = %v, tags = %v", st.Domains, st.Tags)
+	}
+	if len(st.Letters) != 4 || st.Letters[0].Count != 2 || st.Letters[2].Count != 1 {
+		t.Fatalf("letters = %+v", st.Letters)
+	}
+	if !slices.Equal(st.NoExplanation, []int{2, 3}) {
+		t.Fatalf("no explanation = %v", st.NoExplanation)
+	}
+}
+
+func TestBlueprintSample(t *testing.T) {
+	var bank []Question
+	for d, n := range map[int]int{4: 30, 5: 30, 6: 3} {
+		for i := 0; i < n; i++ {
+			bank = append(bank, Question{Domain: d, Prompt: "p", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"})
+		}
+	}
+	bp, err := ParseBlueprint([]byte(`{"questions": 20, "minutes": 40, "domains": {"4": 40, "5": 20, "6": 20, "7": 20}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := bp.Sample(bank, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := map[int]int{}
+	for _, q := range m.Questions {
+		count[q.Domain]++
+	}
+	// Domain 7's share is spread over 4, 5 and 6 (10, 5, 5), and domain 6 can
+	// only supply 3.
+	if count[4] != 10 || count[5] != 5 || count[6] != 3 || len(m.Questions) != 18 {
+		t.Fatalf("sampled %v", count)
+	}
+	if m.Budget != 36*time.Minute {
+		t.Fatalf("Budget = %v, want 36m", m.Budget)
+	}
+	if len(m.Notes) != 3 {
+		t.Fatalf("Notes = %q", m.Notes)
+	}
+	if secs := m.Sections(); len(secs) != 1 || secs[0].Budget != m.Budget || secs[0].Title() != "Mock exam" {
+		t.Fatalf("Sections = %+v", secs)
+	}
+	if _, err := ParseBlueprint([]byte(`{"questions": 10, "domains": {"4": 0}}`)); err == nil {
+		t.Fatal("zero weight accepted")
+	}
+}
+
+func TestParseText(t *testing.T) {
+	doc := `CSSLP practice set
+Domain 4: Secure Software Implementation
+
+1. Which practice best prevents SQL injection
+   in a web application?
+A) Input length checks
+B) Parameterized
+   queries
+C) Output encoding
+D) TLS
+Answer: B
+Explanation: Binding parameters keeps data
+out of the query text.
+Reference: OWASP SQL Injection Prevention Cheat Sheet
+
+Q2: Which of these are memory-safe?
+1. Rust
+2. Go
+a. 1 only
+b. 1 and 2
+Correct answer is b.
+
+3) An orphan question without options
+
+Domain 5
+4. Threat modeling happens in which phase?
+(a) Design
+(b) Testing
+
+Answer key
+4. A
+`
+	res, err := ParseText(strings.NewReader(doc), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Questions) != 3 {
+		t.Fatalf("got %d questions: %+v", len(res.Questions), res.Questions)
+	}
+	q := res.Questions[0]
+	if q.Domain != 4 || q.Prompt != "Which practice best prevents SQL injection in a web application?" ||
+		q.Options["B"] != "Parameterized queries" || q.Answer != "B" || q.Explanation != "Binding parameters keeps data out of the query text." ||
+		q.Source != "OWASP SQL Injection Prevention Cheat Sheet" {
+		t.Fatalf("first question = %+v", q)
+	}
+	if q := res.Questions[1]; q.Prompt != "Which of these are memory-safe? 1. Rust 2. Go" || q.Answer != "B" || len(q.Options) != 2 {
+		t.Fatalf("second question = %+v", q)
+	}
+	if q := res.Questions[2]; q.Domain != 5 || q.Answer != "A" || q.ID == "" {
+		t.Fatalf("answer-key question = %+v", q)
+	}
+	if len(res.Problems) != 2 || res.Problems[0].Line != 1 || res.Problems[1].Line != 23 {
+		t.Fatalf("problems = %v", res.Problems)
+	}
+	if err := Validate(res.Questions); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseBankHeader(t *testing.T) {
+	data := []byte(`{"name": "Colors", "version": "2", "source": "https://example.com/colors",
+		"changelog": [{"version": "1", "notes": "First"}, {"version": "2", "notes": "Fixed Q2"}],
+		"questions": [{"domain": 1, "question": "Sky?", "options": {"A": "Blue", "B": "Red"}, "answer": "A"}]}`)
+	b, err := ParseBank(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "Colors v2" || len(b.Questions) != 1 || b.Questions[0].ID == "" {
+		t.Fatalf("bank = %+v", b)
+	}
+	if since := b.Since("1"); len(since) != 1 || since[0].Notes != "Fixed Q2" {
+		t.Fatalf("Since(1) = %+v", since)
+	}
+	out, err := MarshalBank(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseBank(out)
+	if err != nil || again.Version != "2" || again.Source != b.Source || len(again.Changelog) != 2 {
+		t.Fatalf("round trip = %+v, %v", again, err)
+	}
+
+	bare, err := MarshalBank(Bank{Questions: b.Questions})
+	if err != nil || !bytes.HasPrefix(bare, []byte("[")) {
+		t.Fatalf("headerless bank should stay an array: %s", bare)
+	}
+	if _, err := ParseBank([]byte(`{"name": "x"}`)); err == nil {
+		t.Fatal("expected an error for a header without questions")
+	}
+}
+
+func TestUseOrderAndAnswerTime(t *testing.T) {
+	qs := []Question{{Prompt: "a", Answer: "A"}, {Prompt: "b", Answer: "A"}, {Prompt: "c", Answer: "A"}}
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewSession(qs)
+	s.clock = func() time.Time { return now }
+	if err := s.UseOrder([]int{2, 2, 0}); err == nil {
+		t.Fatal("UseOrder accepted a repeated index")
+	}
+	if err := s.UseOrder([]int{2, 0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, want := range []int{2, 0, 1} {
+		idx, _, _ := s.Current(ctx)
+		if idx != want {
+			t.Fatalf("question = %d, want %d", idx, want)
+		}
+		now = now.Add(3 * time.Second)
+		if res, _, _ := s.Answer(ctx, "A"); res.Elapsed != 3*time.Second {
+			t.Fatalf("elapsed = %v", res.Elapsed)
+		}
+	}
+	if err := NewSession(qs).UseOrder([]int{0, 1}); err == nil {
+		t.Fatal("UseOrder accepted a short order")
+	}
+}
+
+func TestPauseStopsTheClocks(t *testing.T) {
+	qs := []Question{{Domain: 1, Prompt: "a", Answer: "A"}, {Domain: 1, Prompt: "b", Answer: "A"}}
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewSession(qs)
+	s.clock = func() time.Time { return now }
+	if err := s.UseSections([]Section{{Domain: 1, Budget: time.Minute}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s.Current(ctx)
+	now = now.Add(10 * time.Second)
+	s.Pause()
+	now = now.Add(5 * time.Minute) // well past the budget
+	if _, _, err := s.Answer(ctx, "A"); !errors.Is(err, ErrPaused) {
+		t.Fatalf("answer while paused: %v", err)
+	}
+	if sec, _ := s.CurrentSection(); sec.Remaining != 50*time.Second {
+		t.Fatalf("remaining while paused = %v", sec.Remaining)
+	}
+	s.Resume()
+	now = now.Add(5 * time.Second)
+	res, _, err := s.Answer(ctx, "A")
+	if err != nil || res.Elapsed != 15*time.Second {
+		t.Fatalf("answer after resume = %+v, %v; want 15s elapsed", res, err)
+	}
+	if d := s.PausedFor(); d != 5*time.Minute {
+		t.Fatalf("paused for %v", d)
+	}
+}
+
+func TestBreaksFallDueByAnswersAndTime(t *testing.T) {
+	qs := []Question{{Prompt: "a", Answer: "A"}, {Prompt: "b", Answer: "A"}, {Prompt: "c", Answer: "A"}, {Prompt: "d", Answer: "A"}}
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewSession(qs)
+	s.clock = func() time.Time { return now }
+	s.UseBreaks(Breaks{Every: 2, After: time.Hour})
+	ctx := context.Background()
+	answer := func() {
+		s.Current(ctx)
+		if _, _, err := s.Answer(ctx, "A"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	answer()
+	if s.BreakDue() {
+		t.Fatal("break due after one answer")
+	}
+	answer()
+	if !s.BreakDue() {
+		t.Fatal("no break due after two answers")
+	}
+	s.TakeBreak()
+	now = now.Add(10 * time.Minute)
+	if !s.Paused() || !s.OnBreak() || s.BreakDue() {
+		t.Fatalf("on break: paused %v, onBreak %v, due %v", s.Paused(), s.OnBreak(), s.BreakDue())
+	}
+	s.Resume()
+	if s.OnBreak() || s.BreakDue() || s.PausedFor() != 10*time.Minute {
+		t.Fatalf("after the break: onBreak %v, due %v, paused for %v", s.OnBreak(), s.BreakDue(), s.PausedFor())
+	}
+	now = now.Add(time.Hour)
+	if !s.BreakDue() {
+		t.Fatal("no break due after an hour")
+	}
+	s.TakeBreak()
+	s.Resume()
+	if s.BreaksTaken() != 2 {
+		t.Fatalf("breaks taken = %d", s.BreaksTaken())
+	}
+}
+
+func TestStateRestoresProgressAndSectionClock(t *testing.T) {
+	qs := []Question{
+		{ID: "a", Domain: 1, Prompt: "a", Answer: "A"},
+		{ID: "b", Domain: 1, Prompt: "b", Answer: "A"},
+		{ID: "c", Domain: 2, Prompt: "c", Answer: "A"},
+	}
+	sections := []Section{{Domain: 1, Budget: time.Minute}, {Domain: 2}}
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewSeededSession(qs, 7)
+	s.clock = func() time.Time { return now }
+	if err := s.UseSections(sections); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s.Current(ctx)
+	now = now.Add(20 * time.Second)
+	s.Answer(ctx, "B") // wrong, so requeued
+	data, err := json.Marshal(s.State())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an hour later, in a new process
+	now = now.Add(time.Hour)
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatal(err)
+	}
+	r := NewSeededSession(qs, 1)
+	r.clock = func() time.Time { return now }
+	r.UseSections(sections)
+	if err := r.Restore(st); err != nil {
+		t.Fatal(err)
+	}
+	if r.Seed() != 7 || r.AttemptedCount() != 1 {
+		t.Fatalf("restored seed %d, attempted %d", r.Seed(), r.AttemptedCount())
+	}
+	if sec, _ := r.CurrentSection(); sec.Domain != 1 || sec.Remaining != 40*time.Second {
+		t.Fatalf("restored section = %+v", sec)
+	}
+	if score, answered := r.Score(); score != 0 || answered != 1 {
+		t.Fatalf("restored score %d/%d", score, answered)
+	}
+
+	other := NewSession(qs[:2])
+	if err := other.Restore(st); err == nil {
+		t.Fatal("restored a run over other questions")
+	}
+}
+
+func TestUseOrderingKeepsDomainsOrFileOrder(t *testing.T) {
+	qs := []Question{
+		{Domain: 5, Prompt: "a", Answer: "A"},
+		{Domain: 4, Prompt: "b", Answer: "A"},
+		{Domain: 5, Prompt: "c", Answer: "A"},
+		{Domain: 4, Prompt: "d", Answer: "A"},
+		{Domain: 5, Prompt: "e", Answer: "A"},
+	}
+	ctx := context.Background()
+	askOrder := func(s *Session) []int {
+		var got []int
+		for {
+			idx, _, ok := s.Current(ctx)
+			if !ok {
+				return got
+			}
+			got = append(got, idx)
+			s.Answer(ctx, "A")
+		}
+	}
+
+	s := NewSeededSession(qs, 7)
+	if err := s.UseOrdering(FileOrder); err != nil {
+		t.Fatal(err)
+	}
+	if got := askOrder(s); !slices.Equal(got, []int{0, 1, 2, 3, 4}) {
+		t.Fatalf("file order = %v", got)
+	}
+
+	s = NewSeededSession(qs, 7)
+	s.UseOrdering(ShuffleWithinDomains)
+	got := askOrder(s)
+	for i, idx := range got {
+		want := 5 // first in the bank
+		if i >= 3 {
+			want = 4
+		}
+		if qs[idx].Domain != want {
+			t.Fatalf("within domains = %v: position %d is domain %d, want %d", got, i, qs[idx].Domain, want)
+		}
+	}
+	replay := NewSeededSession(qs, 7)
+	replay.UseOrdering(ShuffleWithinDomains)
+	if again := askOrder(replay); !slices.Equal(again, got) {
+		t.Fatalf("same seed ordered %v, then %v", got, again)
+	}
+
+	if _, err := ParseOrdering("domain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseOrdering("random"); err == nil {
+		t.Fatal("ParseOrdering accepted an unknown name")
+	}
+}
+
+func TestRequeueAsksMasteredQuestionWithoutRescoring(t *testing.T) {
+	qs := []Question{{Prompt: "a", Answer: "A"}, {Prompt: "b", Answer: "A"}, {Prompt: "c", Answer: "A"}}
+	s := NewSession(qs)
+	if err := s.UseOrder([]int{0, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s.Answer(ctx, "A")
+	if err := s.Requeue(0, false); !errors.Is(err, ErrMastered) {
+		t.Fatalf("requeue mastered without force = %v, want ErrMastered", err)
+	}
+	if err := s.Requeue(7, true); err == nil {
+		t.Fatal("requeued an index out of range")
+	}
+	if err := s.Requeue(0, true); err != nil {
+		t.Fatal(err)
+	}
+	if idx, _, _ := s.Current(ctx); idx != 0 {
+		t.Fatalf("current = %d, want the requeued question", idx)
+	}
+	if res, _, _ := s.Answer(ctx, "B"); res.Correct {
+		t.Fatal("wrong re-attempt graded correct")
+	}
+	if done, _ := s.Progress(); done != 1 {
+		t.Fatalf("completed = %d after a missed re-attempt, want 1", done)
+	}
+	if score, answered := s.Score(); score != 1 || answered != 1 {
+		t.Fatalf("score = %d/%d, want the first attempt's 1/1", score, answered)
+	}
+	var order []int
+	for {
+		idx, _, ok := s.Current(ctx)
+		if !ok {
+			break
+		}
+		order = append(order, idx)
+		s.Answer(ctx, "A")
+	}
+	if !slices.Equal(order, []int{1, 2, 0}) {
+		t.Fatalf("rest of the run = %v, want the missed re-attempt requeued last", order)
+	}
+	if err := s.Requeue(1, true); err == nil {
+		t.Fatal("requeued into a finished session")
+	}
+}
+
+func TestNextWhereSkipsToUnattemptedAndMissed(t *testing.T) {
+	qs := []Question{{Prompt: "a", Answer: "A"}, {Prompt: "b", Answer: "A"}, {Prompt: "c", Answer: "A"}, {Prompt: "d", Answer: "A"}}
+	s := NewSession(qs)
+	if err := s.UseOrder([]int{0, 1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s.Answer(ctx, "B") // 0 missed, queue 1 2 3 0
+	if idx, _, ok := s.NextWhere(Missed); !ok || idx != 0 {
+		t.Fatalf("next missed = %d, %v, want 0", idx, ok)
+	}
+	// repeated skips walk on through the unattempted questions and wrap
+	var got []int
+	for range 4 {
+		idx, _, ok := s.NextWhere(Unattempted)
+		if !ok {
+			t.Fatal("no unattempted question found")
+		}
+		got = append(got, idx)
+	}
+	if want := []int{1, 2, 3, 1}; !slices.Equal(got, want) {
+		t.Fatalf("skips = %v, want %v", got, want)
+	}
+	if idx, _, ok := s.NextWhere(Missed); !ok || idx != 0 {
+		t.Fatalf("next missed = %d, %v, want 0", idx, ok)
+	}
+	if _, _, ok := s.NextWhere(Missed); ok {
+		t.Fatal("skipped to the current question")
+	}
+	if idx, _, _ := s.Current(ctx); idx != 0 {
+		t.Fatalf("current = %d after a failed skip, want 0", idx)
+	}
+}
+
+func TestOverridesCorrectQuestionsByID(t *testing.T) {
+	o, err := ParseOverrides([]byte(`{"q1": {"answer": "b", "reason": "upstream #12"}, "q2": {"explanation": "Because."}, "gone": {"source": "x"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bank := []Question{
+		{ID: "q1", Prompt: "a", Answer: "A", Options: map[string]string{"A": "yes", "B": "no"}},
+		{ID: "q2", Prompt: "b", Answer: "A", Options: map[string]string{"A": "yes", "B": "no"}, Explanation: "Wrong."},
+	}
+	got, rep, err := o.Apply(bank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Answer != "B" || got[1].Explanation != "Because." || got[1].Answer != "A" {
+		t.Fatalf("applied = %+v", got)
+	}
+	if bank[0].Answer != "A" || bank[1].Explanation != "Wrong." {
+		t.Fatal("Apply changed the bank it was given")
+	}
+	if len(rep.Applied) != 2 || rep.Applied[0].Was != "A" || rep.Applied[0].Reason != "upstream #12" {
+		t.Fatalf("applied = %+v", rep.Applied)
+	}
+	if !slices.Equal(rep.Unknown, []string{"gone"}) {
+		t.Fatalf("unknown = %v, want [gone]", rep.Unknown)
+	}
+
+	if _, _, err := (Overrides{"q1": {Answer: "Z"}}).Apply(bank); err == nil {
+		t.Fatal("applied an answer that is not an option")
+	}
+	if _, err := ParseOverrides([]byte(`{"q1": {"reason": "why"}}`)); err == nil {
+		t.Fatal("parsed an override that corrects nothing")
+	}
+}
+
+func TestGenerateQuestionsFromGlossary(t *testing.T) {
+	csv := "Term,Definition\n" +
+		"Salt,Random data added to a password before hashing\n" +
+		"Pepper,Secret value added to a password before hashing\n" +
+		"Nonce,A number used once\n" +
+		"Fuzzing,Feeding a program malformed input to find crashes\n" +
+		"Canary,\n" +
+		"salt,again\n"
+	terms, problems, err := ParseGlossary(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(terms) != 4 || terms[0].Term != "Salt" || terms[2].Line != 4 {
+		t.Fatalf("terms = %+v", terms)
+	}
+	if len(problems) != 2 || problems[0].Line != 6 || problems[1].Reason != "term defined twice" {
+		t.Fatalf("problems = %+v", problems)
+	}
+
+	for seed := range int64(20) {
+		qs, problems, err := GenerateQuestions(terms, 3, 2, rand.New(rand.NewSource(seed)))
+		if err != nil || len(problems) != 0 {
+			t.Fatalf("generate = %v, %v", problems, err)
+		}
+		if err := Validate(qs); err != nil {
+			t.Fatal(err)
+		}
+		salt := qs[0]
+		if salt.Domain != 3 || len(salt.Options) != 2 || salt.Options[salt.Answer] != terms[0].Definition {
+			t.Fatalf("salt = %+v", salt)
+		}
+		// the distractor is one of the two likeliest: Pepper's, which shares
+		// words with Salt's, or Fuzzing's, which is as long; never Nonce's
+		if slices.Contains(slices.Collect(maps.Values(salt.Options)), terms[2].Definition) {
+			t.Fatalf("seed %d: salt offered the short, unrelated %q", seed, terms[2].Definition)
+		}
+	}
+
+	if _, _, err := GenerateQuestions(terms, 1, 9, rand.New(rand.NewSource(1))); err == nil {
+		t.Fatal("generated questions with 9 options")
+	}
+}
+
+func TestScenarioQuestionsStayTogether(t *testing.T) {
+	bank := []byte(`{"scenarios": [{"id": "acme", "title": "Acme", "text": "Acme runs a payment API."}],
+	"questions": [
+		{"question": "solo 1", "answer": "A", "options": {"A": "x", "B": "y"}},
+		{"question": "acme 1", "answer": "A", "options": {"A": "x", "B": "y"}, "scenario": "acme"},
+		{"question": "solo 2", "answer": "A", "options": {"A": "x", "B": "y"}},
+		{"question": "acme 2", "answer": "A", "options": {"A": "x", "B": "y"}, "scenario": "acme"},
+		{"question": "solo 3", "answer": "A", "options": {"A": "x", "B": "y"}},
+		{"question": "acme 3", "answer": "A", "options": {"A": "x", "B": "y"}, "scenario": "acme"}]}`)
+	b, err := ParseBank(bank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(b.Questions); err != nil {
+		t.Fatal(err)
+	}
+	if b.Questions[3].Stem == nil || b.Questions[3].Stem.Title != "Acme" || b.Questions[0].Stem != nil {
+		t.Fatalf("stems = %v, %v", b.Questions[3].Stem, b.Questions[0].Stem)
+	}
+	together := func(order []int) bool {
+		at := slices.Index(order, 1)
+		return at >= 0 && at+2 < len(order) && slices.Equal(order[at:at+3], []int{1, 3, 5})
+	}
+	for seed := range int64(20) {
+		s := NewSeededSession(b.Questions, seed)
+		if !together(s.queue) {
+			t.Fatalf("seed %d: queue %v splits the scenario", seed, s.queue)
+		}
+	}
+	s := NewSession(b.Questions)
+	if err := s.UseOrder([]int{5, 0, 4, 3, 2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 3, 5, 0, 4, 2}; !slices.Equal(s.queue, want) {
+		t.Fatalf("UseOrder queue = %v, want %v", s.queue, want)
+	}
+
+	data, err := MarshalBank(Bank{Scenarios: ScenariosOf(b.Questions[2:4]), Questions: b.Questions[2:4]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := ParseBank(data); err != nil || again.Questions[1].Stem == nil {
+		t.Fatalf("written bank lost its scenario: %v", err)
+	}
+
+	orphan := b.Questions[1]
+	orphan.Stem = nil
+	if err := Validate([]Question{orphan}); err == nil {
+		t.Fatal("validated a question whose scenario is missing")
+	}
+	if _, err := ParseBank([]byte(`{"scenarios": [{"id": "a", "text": "x"}, {"id": "a", "text": "y"}], "questions": []}`)); err == nil {
+		t.Fatal("parsed a bank defining a scenario twice")
+	}
+}
+
+func TestMasteryStopEndsOnceEveryDomainIsMet(t *testing.T) {
+	if _, err := ParseMasteryStop("90"); err == nil {
+		t.Fatal("parsed a rule without a window")
+	}
+	r, err := ParseMasteryStop("75%/4")
+	if err != nil || r.Accuracy != 0.75 || r.Window != 4 {
+		t.Fatalf("rule = %+v, %v", r, err)
+	}
+	var qs []Question
+	for i := range 20 {
+		qs = append(qs, Question{Domain: 4 + i%2, Prompt: strconv.Itoa(i), Answer: "A", Options: map[string]string{"A": "x", "B": "y"}})
+	}
+	s := NewSession(qs)
+	order := make([]int, len(qs))
+	for i := range order {
+		order[i] = i
+	}
+	if err := s.UseOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UseMasteryStop(r); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	// domain 4 misses its first question, so it needs four more to have 3
+	// of its last 4 right
+	answers := []string{"B", "A", "A", "A", "A", "A", "A", "A"}
+	for i, ans := range answers {
+		_, finished, err := s.Answer(ctx, ans)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if finished != (i == len(answers)-1) {
+			t.Fatalf("answer %d: finished = %v", i+1, finished)
+		}
+	}
+	reached, domains := s.MasteryStop()
+	if !reached || len(domains) != 2 {
+		t.Fatalf("reached = %v, domains = %+v", reached, domains)
+	}
+	if d := domains[0]; d.Domain != 4 || d.Recent != 4 || d.Correct != 3 || !d.Met || d.Percent() != 75 {
+		t.Fatalf("domain 4 = %+v", d)
+	}
+	if _, answered := s.Score(); answered != 8 {
+		t.Fatalf("answered = %d, want 8 of 20", answered)
+	}
+}
+
+func TestPacingShrinksLimitsAndMissesLateAnswers(t *testing.T) {
+	if _, err := ParsePacing("45s:90s"); err == nil {
+		t.Fatal("accepted a pacing that grows")
+	}
+	p, err := ParsePacing("90s:30s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := []Question{
+		{Prompt: "a", Answer: "A"}, {Prompt: "b", Answer: "A"},
+		{Prompt: "c", Answer: "A"}, {Prompt: "d", Answer: "A"},
+	}
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewSession(qs)
+	s.clock = func() time.Time { return now }
+	if err := s.UsePacing(p); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i, want := range []time.Duration{90 * time.Second, 70 * time.Second, 50 * time.Second, 30 * time.Second} {
+		s.Current(ctx)
+		now = now.Add(10 * time.Second)
+		limit, left, ok := s.TimeLimit()
+		if !ok || limit != want || left != want-10*time.Second {
+			t.Fatalf("question %d: limit %v, left %v, want %v", i+1, limit, left, want)
+		}
+		if i == 2 {
+			// the third answer comes too late, though right
+			now = now.Add(time.Minute)
+		}
+		res, _, err := s.Answer(ctx, "A")
+		if err != nil || res.TimedOut != (i == 2) || res.Correct != (i != 2) {
+			t.Fatalf("answer %d = %+v, %v", i+1, res, err)
+		}
+	}
+	sectioned := NewSession(qs)
+	sectioned.UseSections([]Section{{}})
+	if err := sectioned.UsePacing(p); err == nil {
+		t.Fatal("pacing combined with sections")
+	}
+}
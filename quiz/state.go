@@ -0,0 +1,193 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// State is a snapshot of a session's progress that can be saved and restored
+// in another process, so a run survives a crash (see Session.State and
+// Session.Restore). Question positions index IDs, the questions of the run in
+// Questions order.
+type State struct {
+	SavedAt   time.Time `json:"savedAt"`
+	Seed      int64     `json:"seed"`
+	IDs       []string  `json:"ids"`
+	Queue     []int     `json:"queue"`
+	Attempted []int     `json:"attempted,omitempty"`
+	Completed []int     `json:"completed,omitempty"`
+	// Results holds the first attempt at each question in Attempted.
+	Results  map[int]Result `json:"results,omitempty"`
+	Sections []SectionState `json:"sections,omitempty"`
+	Section  int            `json:"section,omitempty"`
+	Streak   int            `json:"streak,omitempty"`
+	Longest  int            `json:"longest,omitempty"`
+}
+
+// SectionState is one section's part of a State. Elapsed is measured on the
+// session clock, so the time between saving and restoring is not counted.
+type SectionState struct {
+	Domain   int           `json:"domain"`
+	Budget   time.Duration `json:"budget,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	Queue    []int         `json:"queue,omitempty"`
+	Total    int           `json:"total"`
+	Started  bool          `json:"started,omitempty"`
+	Ended    bool          `json:"ended,omitempty"`
+	Elapsed  time.Duration `json:"elapsed,omitempty"`
+	TimedOut bool          `json:"timedOut,omitempty"`
+}
+
+// Answered is the number of distinct questions answered in st.
+func (st State) Answered() int {
+	return len(st.Attempted)
+}
+
+// Pick returns the questions of bank named by st.IDs, in that order, such as
+// to resume a mock exam whose questions were sampled at random.
+func (st State) Pick(bank []Question) ([]Question, error) {
+	byID := make(map[string]Question, len(bank))
+	for _, q := range bank {
+		byID[q.ID] = q
+	}
+	out := make([]Question, len(st.IDs))
+	for i, id := range st.IDs {
+		q, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("question %s is no longer in the bank", id)
+		}
+		out[i] = q
+	}
+	return out, nil
+}
+
+// State snapshots the session's progress. Listeners, settings such as the
+// penalty, and a running pause are not part of it.
+func (s *Session) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := State{
+		SavedAt: time.Now(),
+		Seed:    s.seed,
+		IDs:     make([]string, len(s.Questions)),
+		Queue:   slices.Clone(s.queue),
+		Section: s.section,
+		Streak:  s.streak,
+		Longest: s.longest,
+	}
+	for i, q := range s.Questions {
+		st.IDs[i] = q.ID
+		if s.attempted[i] {
+			st.Attempted = append(st.Attempted, i)
+			if st.Results == nil {
+				st.Results = map[int]Result{}
+			}
+			st.Results[i] = s.results[i]
+		}
+		if s.completed[i] {
+			st.Completed = append(st.Completed, i)
+		}
+	}
+	now := s.now()
+	for _, sec := range s.sections {
+		ss := SectionState{
+			Domain:   sec.Domain,
+			Budget:   sec.Budget,
+			Name:     sec.Name,
+			Queue:    slices.Clone(sec.queue),
+			Total:    sec.total,
+			Started:  !sec.started.IsZero(),
+			Ended:    !sec.ended.IsZero(),
+			TimedOut: sec.timedOut,
+		}
+		switch {
+		case ss.Ended:
+			ss.Elapsed = sec.ended.Sub(sec.started)
+		case ss.Started:
+			ss.Elapsed = now.Sub(sec.started)
+		}
+		st.Sections = append(st.Sections, ss)
+	}
+	return st
+}
+
+// Restore replaces the progress of a session that has not been answered yet
+// with st. The session must be over the same questions, with the same IDs in
+// the same order, and split into the same sections. The question on screen
+// when st was saved is shown afresh, and template questions get new values.
+func (s *Session) Restore(st State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attemptedCount > 0 {
+		return errors.New("the session has already started")
+	}
+	if len(st.IDs) != len(s.Questions) {
+		return fmt.Errorf("the saved run has %d questions, this one %d", len(st.IDs), len(s.Questions))
+	}
+	for i, q := range s.Questions {
+		if st.IDs[i] != q.ID {
+			return errors.New("the saved run is over different questions")
+		}
+	}
+	if len(st.Sections) != len(s.sections) {
+		return fmt.Errorf("the saved run has %d sections, this one %d", len(st.Sections), len(s.sections))
+	}
+	for i, sec := range s.sections {
+		if st.Sections[i].Domain != sec.Domain || st.Sections[i].Budget != sec.Budget {
+			return errors.New("the saved run has different sections")
+		}
+	}
+	valid := func(idxs []int) bool {
+		for _, idx := range idxs {
+			if idx < 0 || idx >= len(s.Questions) {
+				return false
+			}
+		}
+		return true
+	}
+	if !valid(st.Queue) || !valid(st.Attempted) || !valid(st.Completed) {
+		return errors.New("the saved run is corrupt")
+	}
+	for _, sec := range st.Sections {
+		if !valid(sec.Queue) {
+			return errors.New("the saved run is corrupt")
+		}
+	}
+
+	n := len(s.Questions)
+	s.seed = st.Seed
+	s.rng = rand.New(rand.NewSource(st.Seed + int64(len(st.Attempted))))
+	s.queue = slices.Clone(st.Queue)
+	s.attempted, s.completed = make([]bool, n), make([]bool, n)
+	s.results = make([]Result, n)
+	s.presented, s.params = make([]Question, n), make([]map[string]float64, n)
+	s.attemptedCount, s.completedCount = len(st.Attempted), len(st.Completed)
+	for _, idx := range st.Attempted {
+		s.attempted[idx] = true
+		s.results[idx] = st.Results[idx]
+	}
+	for _, idx := range st.Completed {
+		s.completed[idx] = true
+	}
+	now := s.now()
+	for i, sec := range s.sections {
+		saved := st.Sections[i]
+		sec.queue, sec.total, sec.timedOut = slices.Clone(saved.Queue), saved.Total, saved.TimedOut
+		sec.started, sec.ended = time.Time{}, time.Time{}
+		if saved.Started || saved.Ended {
+			sec.started = now.Add(-saved.Elapsed)
+		}
+		if saved.Ended {
+			sec.ended = now
+		}
+	}
+	if s.sections != nil {
+		s.section = st.Section
+	}
+	s.streak, s.longest = st.Streak, st.Longest
+	s.shown = -1
+	return nil
+}
@@ -0,0 +1,180 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Param is a template variable drawn at random each time its question is
+// presented: a multiple of Step (default 1) between Min and Max inclusive.
+type Param struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Step float64 `json:"step,omitempty"`
+}
+
+// IsTemplate reports whether q has parameters to fill in.
+func (q Question) IsTemplate() bool {
+	return len(q.Params) > 0
+}
+
+// maxDraws bounds how many times Instantiate redraws parameters looking for
+// a variation whose options are all distinct.
+const maxDraws = 20
+
+// Instantiate draws a value for each of q's parameters from rng and renders
+// the result (see Render). Draws that make two options read the same are
+// retried a few times. Non-template questions are returned unchanged.
+func (q Question) Instantiate(rng *rand.Rand) (Question, map[string]float64, error) {
+	if !q.IsTemplate() {
+		return q, nil, nil
+	}
+	var (
+		out    Question
+		params map[string]float64
+		err    error
+	)
+	for range maxDraws {
+		params = q.drawParams(rng)
+		if out, err = q.Render(params); err != nil {
+			return q, nil, err
+		}
+		if distinctOptions(out.Options) {
+			break
+		}
+	}
+	return out, params, nil
+}
+
+func (q Question) drawParams(rng *rand.Rand) map[string]float64 {
+	names := make([]string, 0, len(q.Params))
+	for name := range q.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names) // draw in a fixed order so a seeded rng is repeatable
+	params := make(map[string]float64, len(names))
+	for _, name := range names {
+		p := q.Params[name]
+		step := p.Step
+		if step <= 0 {
+			step = 1
+		}
+		n := int(math.Floor((p.Max-p.Min)/step + 1e-9))
+		k := 0
+		if n > 0 {
+			if rng != nil {
+				k = rng.Intn(n + 1)
+			} else {
+				k = rand.Intn(n + 1)
+			}
+		}
+		// Round away float noise from repeated steps such as 0.1.
+		params[name] = math.Round((p.Min+float64(k)*step)*1e9) / 1e9
+	}
+	return params
+}
+
+func distinctOptions(opts map[string]string) bool {
+	seen := make(map[string]bool, len(opts))
+	for _, text := range opts {
+		if seen[text] {
+			return false
+		}
+		seen[text] = true
+	}
+	return true
+}
+
+// Render returns q with every {{expr}} placeholder in its prompt and options
+// replaced by the value of expr over params. A placeholder may end in
+// ":N" to print N decimal places, as in {{price*qty:2}}; otherwise up to six
+// significant decimals are shown.
+func (q Question) Render(params map[string]float64) (Question, error) {
+	out := q
+	var err error
+	if out.Prompt, err = renderTemplate(q.Prompt, params); err != nil {
+		return q, fmt.Errorf("prompt: %w", err)
+	}
+	out.Options = make(map[string]string, len(q.Options))
+	for k, text := range q.Options {
+		if out.Options[k], err = renderTemplate(text, params); err != nil {
+			return q, fmt.Errorf("option %s: %w", k, err)
+		}
+	}
+	return out, nil
+}
+
+func renderTemplate(s string, params map[string]float64) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return "", errors.New("unclosed {{")
+		}
+		b.WriteString(s[:start])
+		src, decimals := splitPlaceholder(s[start+2 : start+end])
+		v, err := EvalExpr(src, params)
+		if err != nil {
+			return "", fmt.Errorf("{{%s}}: %w", src, err)
+		}
+		b.WriteString(formatNumber(v, decimals))
+		s = s[start+end+2:]
+	}
+}
+
+// splitPlaceholder separates a trailing ":N" precision from a placeholder,
+// returning -1 when there is none.
+func splitPlaceholder(src string) (string, int) {
+	if i := strings.LastIndexByte(src, ':'); i >= 0 {
+		if n, err := strconv.Atoi(strings.TrimSpace(src[i+1:])); err == nil && n >= 0 {
+			return strings.TrimSpace(src[:i]), n
+		}
+	}
+	return strings.TrimSpace(src), -1
+}
+
+// validateTemplate reports problems with q's parameters and placeholders by
+// rendering it at the low and high end of every range.
+func validateTemplate(q Question) []error {
+	if !q.IsTemplate() {
+		return nil
+	}
+	var errs []error
+	low := make(map[string]float64, len(q.Params))
+	high := make(map[string]float64, len(q.Params))
+	for name, p := range q.Params {
+		if !isIdentStart(firstRune(name)) || len(exprVars(name)) != 1 || exprVars(name)[0] != name {
+			errs = append(errs, fmt.Errorf("param %q is not a valid name", name))
+		}
+		if p.Max < p.Min || p.Step < 0 {
+			errs = append(errs, fmt.Errorf("param %q has an empty range", name))
+		}
+		low[name], high[name] = p.Min, p.Max
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	for _, params := range []map[string]float64{low, high} {
+		if _, err := q.Render(params); err != nil {
+			return append(errs, err)
+		}
+	}
+	return nil
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
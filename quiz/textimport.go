@@ -0,0 +1,173 @@
+package quiz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TextProblem is a line ParseText could not use.
+type TextProblem struct {
+	Line   int
+	Text   string
+	Reason string
+}
+
+func (p TextProblem) String() string {
+	return fmt.Sprintf("line %d: %s: %q", p.Line, p.Reason, p.Text)
+}
+
+// TextImport is the result of ParseText.
+type TextImport struct {
+	Questions []Question
+	Problems  []TextProblem
+}
+
+var (
+	textQuestion = regexp.MustCompile(`^(?i:q(?:uestion)?\s*)?(\d+)\s*[.):]\s*(.+)$`)
+	textOption   = regexp.MustCompile(`^\(?([A-Ha-h])\s*[.):\]]\s*(.+)$`)
+	// textAnswer needs a separator ("Answer: c") or the letter alone ("Answer
+	// is B."), so prose such as "Answer a question" is not mistaken for one.
+	textAnswer      = regexp.MustCompile(`^(?i:(?:correct\s+)?ans(?:wer)?(?:\s+is)?)(?:\s*[:=\-]\s*\(?([A-Ha-h])\b|\s+\(?([A-Ha-h])[).]?\s*$)`)
+	textExplanation = regexp.MustCompile(`^(?i:explanation|rationale|reason)\s*[:.\-]\s*(.*)$`)
+	textSource      = regexp.MustCompile(`^(?i:source|reference|ref)\s*[:.\-]\s*(.*)$`)
+	textDomain      = regexp.MustCompile(`^(?i:domain)\s+(\d+)\s*(?:[:.\-–—]|$)`)
+	textKeyHeading  = regexp.MustCompile(`^(?i:answer\s+key|answers)\s*:?\s*$`)
+	textKeyEntry    = regexp.MustCompile(`(\d+)\s*[.):\-=]?\s*([A-Ha-h])\b`)
+)
+
+// textDraft is a question being assembled by ParseText.
+type textDraft struct {
+	q      Question
+	number int
+	line   int
+	// last is the option letter that continuation lines extend, or "" while
+	// the prompt is still being read.
+	last        string
+	explanation bool
+}
+
+// startedBy reports whether line begins a new question after d. A numbered
+// line inside a prompt is taken as a list item, unless it carries the next
+// question number.
+func (d *textDraft) startedBy(line string) bool {
+	m := textQuestion.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	if d == nil || d.last != "" || d.explanation || d.q.Answer != "" {
+		return true
+	}
+	num, _ := strconv.Atoi(m[1])
+	return num == d.number+1
+}
+
+// ParseText turns a loosely formatted study document into questions. It
+// recognises numbered questions ("12. What ..." or "Q12: What ..."), lettered
+// options ("A) ..." or "(b) ..."), "Answer: C" lines, "Explanation:"
+// paragraphs, "Source:" or "Reference:" lines, "Domain 4" headings that set
+// the domain of the questions after them, and an "Answer key" section of
+// "12. C" pairs. Lines that wrap continue the prompt, option or explanation
+// above them. Questions start in domain, and those left without a prompt, two
+// options or a valid answer are dropped and reported along with any line that
+// fit nowhere.
+func ParseText(r io.Reader, domain int) (TextImport, error) {
+	var (
+		res    TextImport
+		drafts []*textDraft
+		cur    *textDraft
+		inKey  bool
+		key    = map[int]string{}
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(strings.ReplaceAll(sc.Text(), "\u00a0", " "))
+		if line == "" {
+			continue
+		}
+		if inKey {
+			entries := textKeyEntry.FindAllStringSubmatch(line, -1)
+			if len(entries) == 0 {
+				res.Problems = append(res.Problems, TextProblem{n, line, "not an answer key entry"})
+			}
+			for _, m := range entries {
+				num, _ := strconv.Atoi(m[1])
+				key[num] = strings.ToUpper(m[2])
+			}
+			continue
+		}
+		switch {
+		case textKeyHeading.MatchString(line):
+			inKey, cur = true, nil
+		case textDomain.MatchString(line) && !textQuestion.MatchString(line):
+			domain, _ = strconv.Atoi(textDomain.FindStringSubmatch(line)[1])
+			cur = nil
+		case textAnswer.MatchString(line) && cur != nil:
+			m := textAnswer.FindStringSubmatch(line)
+			cur.q.Answer = strings.ToUpper(m[1] + m[2])
+			cur.explanation = false
+		case textExplanation.MatchString(line) && cur != nil:
+			cur.q.Explanation = textExplanation.FindStringSubmatch(line)[1]
+			cur.explanation = true
+		case textSource.MatchString(line) && cur != nil:
+			cur.q.Source = textSource.FindStringSubmatch(line)[1]
+			cur.explanation = false
+		case textOption.MatchString(line) && cur != nil && !cur.explanation:
+			m := textOption.FindStringSubmatch(line)
+			letter := strings.ToUpper(m[1])
+			if cur.q.Options == nil {
+				cur.q.Options = map[string]string{}
+			}
+			cur.q.Options[letter] = m[2]
+			cur.last = letter
+		case cur.startedBy(line):
+			m := textQuestion.FindStringSubmatch(line)
+			num, _ := strconv.Atoi(m[1])
+			cur = &textDraft{q: Question{Domain: domain, Prompt: m[2]}, number: num, line: n}
+			drafts = append(drafts, cur)
+		case cur == nil:
+			res.Problems = append(res.Problems, TextProblem{n, line, "outside any question"})
+		case cur.explanation:
+			cur.q.Explanation += " " + line
+		case cur.q.Answer != "":
+			res.Problems = append(res.Problems, TextProblem{n, line, "after the answer"})
+		case cur.last != "":
+			cur.q.Options[cur.last] += " " + line
+		default:
+			cur.q.Prompt += " " + line
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return res, err
+	}
+
+	for _, d := range drafts {
+		if d.q.Answer == "" {
+			d.q.Answer = key[d.number]
+		}
+		var reason string
+		switch {
+		case len(d.q.Options) < 2:
+			reason = "question has fewer than two options"
+		case d.q.Answer == "":
+			reason = "question has no answer"
+		case !hasOption(d.q, d.q.Answer):
+			reason = fmt.Sprintf("answer %s is not one of the options", d.q.Answer)
+		}
+		if reason != "" {
+			res.Problems = append(res.Problems, TextProblem{d.line, d.q.Prompt, reason})
+			continue
+		}
+		res.Questions = append(res.Questions, d.q)
+	}
+	sort.SliceStable(res.Problems, func(i, j int) bool { return res.Problems[i].Line < res.Problems[j].Line })
+	AssignIDs(res.Questions)
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validate checks that qs is usable as a question bank: it is non-empty and
+// every question has a prompt, at least two options, an answer that names one
+// of them, alt text for any image, and, for templates, parameters and
+// placeholders that evaluate. All problems are reported together.
+func Validate(qs []Question) error {
+	if len(qs) == 0 {
+		return errors.New("bank has no questions")
+	}
+	var errs []error
+	for i, q := range qs {
+		n := i + 1
+		if strings.TrimSpace(q.Prompt) == "" {
+			errs = append(errs, fmt.Errorf("question %d: empty prompt", n))
+		}
+		if len(q.Options) < 2 {
+			errs = append(errs, fmt.Errorf("question %d: needs at least two options", n))
+		}
+		if !hasOption(q, q.Answer) {
+			errs = append(errs, fmt.Errorf("question %d: answer %q is not one of its options", n, q.Answer))
+		}
+		for _, err := range validateTemplate(q) {
+			errs = append(errs, fmt.Errorf("question %d: %w", n, err))
+		}
+		switch q.Dir {
+		case "", "auto", "ltr", "rtl":
+		default:
+			errs = append(errs, fmt.Errorf("question %d: dir %q must be auto, ltr or rtl", n, q.Dir))
+		}
+		if q.Weight < 0 {
+			errs = append(errs, fmt.Errorf("question %d: weight %g must not be negative", n, q.Weight))
+		}
+		if q.Scenario != "" && q.Stem == nil {
+			errs = append(errs, fmt.Errorf("question %d: scenario %q is not among the bank's scenarios", n, q.Scenario))
+		}
+		if q.Image != "" && strings.TrimSpace(q.ImageAlt) == "" {
+			errs = append(errs, fmt.Errorf("question %d: image %q has no imageAlt text", n, q.Image))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func hasOption(q Question, key string) bool {
+	key = strings.TrimSpace(key)
+	for k := range q.Options {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Package replay records a quiz run as a log of timed events, one JSON object
+// per line, and reads the log back so the run can be played again. Each
+// question is logged as it was shown, so a log replays without its bank.
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// Kind is what happened in an Event.
+type Kind string
+
+// Event kinds.
+const (
+	// Shown puts a question on screen; Question holds it.
+	Shown Kind = "shown"
+	// Select moves the selection to Option, and Strike strikes Option out
+	// or restores it.
+	Select Kind = "select"
+	Strike Kind = "strike"
+	// Answer submits Option, graded in Correct.
+	Answer Kind = "answer"
+	// Finish ends the run with Score of Answered.
+	Finish Kind = "finish"
+	// Pause hides the question and stops the clocks until Resume.
+	Pause  Kind = "pause"
+	Resume Kind = "resume"
+)
+
+// Event is one entry in a run's log.
+type Event struct {
+	At       time.Time      `json:"at"`
+	Kind     Kind           `json:"kind"`
+	Index    int            `json:"index"`
+	Question *quiz.Question `json:"question,omitempty"`
+	Option   string         `json:"option,omitempty"`
+	Correct  bool           `json:"correct,omitempty"`
+	Score    int            `json:"score,omitempty"`
+	Answered int            `json:"answered,omitempty"`
+}
+
+// Recorder writes events to a log as they happen. A Recorder is safe for
+// concurrent use.
+type Recorder struct {
+	w   io.Writer
+	now func() time.Time
+	mu  sync.Mutex
+	err error
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w, now: time.Now}
+}
+
+// Record stamps e with the current time, unless it has one, and logs it.
+func (r *Recorder) Record(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.At.IsZero() {
+		e.At = r.now()
+	}
+	line, err := json.Marshal(e)
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error writing the log, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Listener returns a quiz.Listener logging the questions shown, the answers
+// and the end of the run. Selection changes are up to the front end.
+func (r *Recorder) Listener() quiz.Listener {
+	return quiz.ListenerFuncs{
+		QuestionShown: func(_ context.Context, index int, q quiz.Question) {
+			r.Record(Event{Kind: Shown, Index: index, Question: &q})
+		},
+		Answered: func(_ context.Context, index int, _ quiz.Question, res quiz.Result) {
+			r.Record(Event{Kind: Answer, Index: index, Option: res.UserAnswer, Correct: res.Correct})
+		},
+		Finished: func(_ context.Context, score, answered int) {
+			r.Record(Event{Kind: Finish, Score: score, Answered: answered})
+		},
+	}
+}
+
+// Read parses a log written by a Recorder.
+func Read(rd io.Reader) ([]Event, error) {
+	var events []Event
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	return events, sc.Err()
+}
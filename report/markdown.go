@@ -0,0 +1,66 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown renders r as a study sheet: the report's facts and domain
+// table, then every question missed with the answer given, the correct
+// answer, the explanation and the source, ready to paste into notes apps
+// such as Obsidian or Notion.
+func WriteMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: study sheet\n\n", r.Title)
+	for _, f := range r.facts() {
+		fmt.Fprintf(&b, "- **%s:** %s\n", f[0], f[1])
+	}
+	b.WriteString("\n| Domain | Questions | Answered | Correct | Score |\n|---|--:|--:|--:|--:|\n")
+	for _, d := range r.Domains {
+		fmt.Fprintf(&b, "| Domain %d | %d | %d | %d | %.1f%% |\n", d.Domain, d.Questions, d.Answered, d.Correct, d.Percent())
+	}
+	b.WriteString("\n## Missed questions\n")
+	if len(r.Missed) == 0 {
+		b.WriteString("\nNo questions missed.\n")
+	}
+	for _, m := range r.Missed {
+		fmt.Fprintf(&b, "\n### Q%d (Domain %d)\n\n", m.Index, m.Question.Domain)
+		writeMissed(&b, m)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMissed writes m's prompt, options, both answers, explanation and
+// source.
+func writeMissed(b *strings.Builder, m Missed) {
+	q := m.Question
+	fmt.Fprintf(b, "%s\n\n", strings.TrimSpace(q.Prompt))
+	letters := make([]string, 0, len(q.Options))
+	for letter := range q.Options {
+		letters = append(letters, letter)
+	}
+	sort.Strings(letters)
+	for _, letter := range letters {
+		fmt.Fprintf(b, "- %s. %s\n", letter, q.Options[letter])
+	}
+	fmt.Fprintf(b, "\n**Correct answer:** %s\n\n**Your answer:** %s\n", option(q.Options, q.Answer), option(q.Options, m.UserAnswer))
+	if e := strings.TrimSpace(q.Explanation); e != "" {
+		fmt.Fprintf(b, "\n**Why:** %s\n", e)
+	}
+	if q.Source != "" {
+		fmt.Fprintf(b, "\n**Source:** %s\n", q.Source)
+	}
+}
+
+// option spells out an answer letter with its option text, e.g. "B. Blue",
+// or gives the answer as it is when it names no option.
+func option(options map[string]string, answer string) string {
+	letter := strings.ToUpper(strings.TrimSpace(answer))
+	if text, ok := options[letter]; ok {
+		return letter + ". " + text
+	}
+	return answer
+}
@@ -0,0 +1,185 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 portrait, in points, with 72pt margins.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 72
+)
+
+// WritePDF renders r as a PDF document. It uses the standard Helvetica fonts,
+// which every viewer has, so nothing is embedded; characters outside the
+// Windows-1252 set print as "?".
+func WritePDF(w io.Writer, r Report) error {
+	p := &pdfPages{}
+	p.newPage()
+	p.text(margin, 24, true, r.Title)
+	p.y -= 8
+	p.text(margin, 14, false, "Completion report")
+	p.y -= 6
+	p.rule()
+	p.y -= 10
+	for _, f := range r.facts() {
+		p.need(18)
+		p.textAt(margin, p.y, 11, true, f[0])
+		p.textAt(margin+100, p.y, 11, false, f[1])
+		p.y -= 18
+	}
+	p.y -= 18
+	columns := []float64{margin, margin + 150, margin + 230, margin + 310, margin + 390}
+	header := func() {
+		for i, h := range []string{"Domain", "Questions", "Answered", "Correct", "Score"} {
+			p.textAt(columns[i], p.y, 11, true, h)
+		}
+		p.y -= 6
+		p.rule()
+		p.y -= 12
+	}
+	header()
+	for _, d := range r.Domains {
+		if p.need(16) {
+			header()
+		}
+		row := []string{
+			fmt.Sprintf("Domain %d", d.Domain),
+			fmt.Sprint(d.Questions),
+			fmt.Sprint(d.Answered),
+			fmt.Sprint(d.Correct),
+			fmt.Sprintf("%.1f%%", d.Percent()),
+		}
+		for i, cell := range row {
+			p.textAt(columns[i], p.y, 11, false, cell)
+		}
+		p.y -= 16
+	}
+	if len(r.Reading) > 0 {
+		p.y -= 18
+		p.text(margin, 14, true, "Further reading")
+		p.y -= 8
+		for _, rd := range r.Reading {
+			p.need(16)
+			p.textAt(margin, p.y, 11, true, fmt.Sprintf("Q%d", rd.Index))
+			p.textAt(margin+40, p.y, 11, false, truncate(rd.Source, 75))
+			p.y -= 16
+		}
+	}
+	return p.write(w, r.Title)
+}
+
+// pdfPages lays text out top to bottom, starting a new page when one fills.
+type pdfPages struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (p *pdfPages) newPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.y = pageHeight - margin
+}
+
+// need starts a new page unless height points are left on this one, and
+// reports whether it did.
+func (p *pdfPages) need(height float64) bool {
+	if p.y-height >= margin {
+		return false
+	}
+	p.newPage()
+	return true
+}
+
+// text writes s as a line of the given size at x and moves down past it.
+func (p *pdfPages) text(x, size float64, bold bool, s string) {
+	p.need(size)
+	p.y -= size
+	p.textAt(x, p.y, size, bold, s)
+}
+
+func (p *pdfPages) textAt(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// rule draws a horizontal line across the text width.
+func (p *pdfPages) rule() {
+	fmt.Fprintf(p.pages[len(p.pages)-1], "0.5 w %d %g m %d %g l S\n", margin, p.y, pageWidth-margin, p.y)
+}
+
+// write assembles the document: catalog, page tree, fonts, info, then each
+// page and its content stream, followed by the cross-reference table.
+func (p *pdfPages) write(w io.Writer, title string) error {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	const firstPage = 6
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (quiz-cli) >>", pdfString(title)))
+	for i, content := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// winAnsi maps the typographic characters Windows-1252 places in 0x80-0x9f.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfString encodes s for a PDF literal string in WinAnsiEncoding.
+// truncate shortens s to n characters, as the PDF does not wrap lines.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
+
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		case winAnsi[r] != 0:
+			b.WriteByte(winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,255 @@
+// Package report renders a completion report for a finished quiz run (score,
+// per-domain breakdown, date and duration) as a PDF or a printable HTML page,
+// for learners who need to show evidence of study, or as a Markdown study
+// sheet of the questions missed.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// Domain is one domain's first-attempt results.
+type Domain struct {
+	Domain    int `json:"domain"`
+	Questions int `json:"questions"`
+	Answered  int `json:"answered"`
+	Correct   int `json:"correct"`
+}
+
+// Percent is the share of answered questions that were correct.
+func (d Domain) Percent() float64 {
+	if d.Answered == 0 {
+		return 0
+	}
+	return float64(d.Correct) * 100 / float64(d.Answered)
+}
+
+// Reading points to the material behind a question missed on the first
+// attempt.
+type Reading struct {
+	// Index is the question's 1-based position in the run.
+	Index  int
+	Source string
+}
+
+// Missed is a question answered wrongly on the first attempt.
+type Missed struct {
+	// Index is the question's 1-based position in the run.
+	Index int
+	// Question is as it was asked, with any template values filled in.
+	Question   quiz.Question
+	UserAnswer string
+}
+
+// Report is what a completion report shows.
+type Report struct {
+	Title string
+	// Name is the learner's name; it is left off when empty.
+	Name     string
+	Score    int
+	Answered int
+	// Mastered counts the questions answered that were eventually answered
+	// correctly, retries included.
+	Mastered int
+	Total    int
+	// PassMark is the first-attempt percentage needed to pass; zero leaves
+	// the pass/fail line off.
+	PassMark float64
+	Started  time.Time
+	Finished time.Time
+	// Paused is the time spent paused between Started and Finished, which
+	// Duration leaves out.
+	Paused  time.Duration
+	Domains []Domain
+	// Reading lists the sources of the questions missed, for follow-up
+	// study; it is left off when empty.
+	Reading []Reading
+	// Missed lists the questions missed in full, for the Markdown study
+	// sheet.
+	Missed []Missed
+}
+
+// FromSession builds the report for session, run between started and
+// finished.
+func FromSession(title string, session *quiz.Session, started, finished time.Time) Report {
+	r := Report{Title: title, Started: started, Finished: finished, Paused: session.PausedFor()}
+	r.Score, r.Answered = session.Score()
+	r.Mastered, _ = session.Progress()
+	r.Total = len(session.Questions)
+	results := session.Results()
+	byDomain := map[int]*Domain{}
+	for i, q := range session.Questions {
+		d := byDomain[q.Domain]
+		if d == nil {
+			d = &Domain{Domain: q.Domain}
+			byDomain[q.Domain] = d
+		}
+		d.Questions++
+		if i < len(results) && results[i].UserAnswer != "" {
+			d.Answered++
+			if results[i].Correct {
+				d.Correct++
+				continue
+			}
+			if q.Source != "" {
+				r.Reading = append(r.Reading, Reading{Index: i + 1, Source: q.Source})
+			}
+			asked := q
+			if q.IsTemplate() && results[i].Params != nil {
+				if rendered, err := q.Render(results[i].Params); err == nil {
+					asked = rendered
+				}
+			}
+			r.Missed = append(r.Missed, Missed{Index: i + 1, Question: asked, UserAnswer: results[i].UserAnswer})
+		}
+	}
+	for _, d := range byDomain {
+		r.Domains = append(r.Domains, *d)
+	}
+	sort.Slice(r.Domains, func(i, j int) bool { return r.Domains[i].Domain < r.Domains[j].Domain })
+	return r
+}
+
+// Percent is the first-attempt score over the questions answered.
+func (r Report) Percent() float64 {
+	if r.Answered == 0 {
+		return 0
+	}
+	return float64(r.Score) * 100 / float64(r.Answered)
+}
+
+// MasteredPercent is the share of the questions answered that were mastered,
+// retries included.
+func (r Report) MasteredPercent() float64 {
+	if r.Answered == 0 {
+		return 0
+	}
+	return float64(r.Mastered) * 100 / float64(r.Answered)
+}
+
+// Passed reports whether the run met the pass mark.
+func (r Report) Passed() bool {
+	return r.PassMark > 0 && r.Percent() >= r.PassMark
+}
+
+// Duration is how long the run took, less the time paused, to the second.
+func (r Report) Duration() time.Duration {
+	if r.Started.IsZero() || r.Finished.Before(r.Started) {
+		return 0
+	}
+	return max(r.Finished.Sub(r.Started)-r.Paused, 0).Round(time.Second)
+}
+
+// facts are the labelled lines both formats show above the domain table.
+func (r Report) facts() [][2]string {
+	facts := [][2]string{}
+	if r.Name != "" {
+		facts = append(facts, [2]string{"Learner", r.Name})
+	}
+	facts = append(facts,
+		[2]string{"Date", r.Finished.Format("January 2, 2006")},
+		[2]string{"Duration", r.Duration().String()},
+		[2]string{"Score", fmt.Sprintf("%d of %d correct on first attempt (%.1f%%)", r.Score, r.Answered, r.Percent())},
+		[2]string{"Mastered", fmt.Sprintf("%d of %d after retries (%.1f%%)", r.Mastered, r.Answered, r.MasteredPercent())},
+	)
+	if r.Answered < r.Total {
+		facts = append(facts, [2]string{"Coverage", fmt.Sprintf("%d of %d questions answered", r.Answered, r.Total)})
+	}
+	if r.PassMark > 0 {
+		result := "Not passed"
+		if r.Passed() {
+			result = "Passed"
+		}
+		facts = append(facts, [2]string{"Result", fmt.Sprintf("%s (pass mark %.0f%%)", result, r.PassMark)})
+	}
+	return facts
+}
+
+// Format picks "pdf", "html" or "md" from path's extension.
+func Format(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return "pdf", nil
+	case ".html", ".htm":
+		return "html", nil
+	case ".md", ".markdown":
+		return "md", nil
+	}
+	return "", fmt.Errorf("report %q must end in .pdf, .html or .md", path)
+}
+
+// Write renders r to w in format, "pdf", "html" or "md".
+func Write(w io.Writer, r Report, format string) error {
+	switch format {
+	case "pdf":
+		return WritePDF(w, r)
+	case "html":
+		return WriteHTML(w, r)
+	case "md":
+		return WriteMarkdown(w, r)
+	}
+	return fmt.Errorf("unknown report format %q", format)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} – completion report</title>
+<style>
+  body { font-family: Georgia, serif; max-width: 42rem; margin: 3rem auto; color: #111; }
+  h1 { margin-bottom: 0; }
+  .sub { color: #555; margin-top: .25rem; }
+  dl { display: grid; grid-template-columns: max-content 1fr; gap: .4rem 1.5rem; margin: 2rem 0; }
+  dt { font-weight: bold; }
+  dd { margin: 0; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { border-bottom: 1px solid #ccc; padding: .4rem; text-align: right; }
+  th:first-child, td:first-child { text-align: left; }
+  h2 { font-size: 1.1rem; margin-top: 2rem; }
+  @media print { body { margin: 1cm; } }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="sub">Completion report</p>
+<dl>
+{{- range .Facts}}
+  <dt>{{index . 0}}</dt><dd>{{index . 1}}</dd>
+{{- end}}
+</dl>
+<table>
+  <thead><tr><th>Domain</th><th>Questions</th><th>Answered</th><th>Correct</th><th>Score</th></tr></thead>
+  <tbody>
+{{- range .Domains}}
+    <tr><td>Domain {{.Domain}}</td><td>{{.Questions}}</td><td>{{.Answered}}</td><td>{{.Correct}}</td><td>{{printf "%.1f%%" .Percent}}</td></tr>
+{{- end}}
+  </tbody>
+</table>
+{{- if .Reading}}
+<h2>Further reading</h2>
+<ul>
+{{- range .Reading}}
+  <li>Q{{.Index}}: {{.Source}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders r as a standalone page meant to be printed or saved.
+func WriteHTML(w io.Writer, r Report) error {
+	return htmlTemplate.Execute(w, struct {
+		Report
+		Facts [][2]string
+	}{r, r.facts()})
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+func sampleReport(t *testing.T) Report {
+	t.Helper()
+	qs := []quiz.Question{
+		{ID: "a", Domain: 5, Prompt: "A?", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}, Source: "Chapter a"},
+		{ID: "b", Domain: 4, Prompt: "B?", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}, Source: "Chapter b"},
+		{ID: "c", Domain: 4, Prompt: "C?", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}, Source: "Chapter c"},
+	}
+	s := quiz.NewSeededSession(qs, 1)
+	ctx := context.Background()
+	// answer the first two questions shown: one right, one wrong
+	for _, ans := range []string{"A", "B"} {
+		if _, _, ok := s.Current(ctx); !ok {
+			t.Fatalf("session ended early")
+		}
+		s.Answer(ctx, ans)
+	}
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	r := FromSession("Review (Quiz)", s, start, start.Add(95*time.Second))
+	r.Name = "Zoë"
+	r.PassMark = 70
+	return r
+}
+
+func TestFromSession(t *testing.T) {
+	r := sampleReport(t)
+	if r.Score != 1 || r.Answered != 2 || r.Total != 3 || r.Passed() || r.Duration() != 95*time.Second {
+		t.Fatalf("report = %+v", r)
+	}
+	if len(r.Domains) != 2 || r.Domains[0].Domain != 4 || r.Domains[0].Questions != 2 {
+		t.Fatalf("domains = %+v", r.Domains)
+	}
+	if len(r.Reading) != 1 || !strings.HasPrefix(r.Reading[0].Source, "Chapter ") {
+		t.Fatalf("reading = %+v", r.Reading)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, sampleReport(t)); err != nil {
+		t.Fatal(err)
+	}
+	doc := buf.Bytes()
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4")) || !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF:\n%s", doc)
+	}
+	for _, want := range []string{"(Review \\(Quiz\\)) Tj", "(Zo\xeb) Tj", "(1 of 2 correct on first attempt \\(50.0%\\)) Tj", "(Not passed \\(pass mark 70%\\)) Tj", "(Domain 5) Tj", "(Further reading) Tj"} {
+		if !bytes.Contains(doc, []byte(want)) {
+			t.Fatalf("PDF missing %q", want)
+		}
+	}
+	// every xref entry must point at its object
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(doc)
+	xref, _ := strconv.Atoi(string(m[1]))
+	lines := strings.Split(string(doc[xref:]), "\n")
+	for i := 1; ; i++ {
+		entry := lines[2+i]
+		if !strings.HasSuffix(entry, " n ") {
+			break
+		}
+		off, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj", i); !bytes.HasPrefix(doc[off:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q", i, doc[off:off+10])
+		}
+	}
+}
+
+func TestWriteMarkdownListsMisses(t *testing.T) {
+	r := sampleReport(t)
+	if len(r.Missed) != 1 || r.Missed[0].UserAnswer != "B" {
+		t.Fatalf("missed = %+v", r.Missed)
+	}
+	r.Missed[0].Question.Explanation = "Because *x*."
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	q := r.Missed[0].Question
+	for _, want := range []string{
+		"# Review (Quiz): study sheet\n",
+		"- **Learner:** Zoë\n",
+		"| Domain 4 | 2 |",
+		fmt.Sprintf("### Q%d (Domain %d)\n\n%s\n\n- A. x\n- B. y\n", r.Missed[0].Index, q.Domain, q.Prompt),
+		"**Correct answer:** A. x\n\n**Your answer:** B. y\n",
+		"**Why:** Because *x*.\n",
+		"**Source:** " + q.Source + "\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("Markdown missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestVaultNotesKeepTheBodyAndAddTheDay(t *testing.T) {
+	r := sampleReport(t)
+	m := r.Missed[0]
+	m.Question.Tags = []string{"secure design"}
+	m.Question.Prompt = "Which *one*: a/b?"
+	if name := VaultNoteName(m.Question); name != "Which one a b ("+m.Question.ID+")" {
+		t.Fatalf("name = %q", name)
+	}
+	note := string(VaultNote(nil, m, "2026-03-01"))
+	for _, want := range []string{"id: \"" + m.Question.ID + "\"\n", "tags:\n  - quiz/missed\n  - \"secure-design\"\n", "missed:\n  - 2026-03-01\n---\n", "Domain: [[Domain ", "**Your answer:** B. y\n"} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("note missing %q:\n%s", want, note)
+		}
+	}
+	// a second miss keeps what the learner wrote and adds the day
+	edited := note + "\nMy own note.\n"
+	again := string(VaultNote([]byte(edited), m, "2026-03-08"))
+	if !strings.Contains(again, "missed:\n  - 2026-03-01\n  - 2026-03-08\n---\n") || !strings.HasSuffix(again, "**Source:** "+m.Question.Source+"\n\nMy own note.\n") || strings.Count(again, "Domain: ") != 1 {
+		t.Fatalf("updated note:\n%s", again)
+	}
+	run := string(VaultRunNote(r, []string{"Which one a b"}))
+	if !strings.Contains(run, "# Quiz 2026-03-01 09.01\n") || !strings.Contains(run, "- [[Which one a b]]\n") {
+		t.Fatalf("run note:\n%s", run)
+	}
+}
+
+func TestWriteHTMLAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, sampleReport(t)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<h1>Review (Quiz)</h1>", "<dt>Learner</dt><dd>Zoë</dd>", "<dd>1m35s</dd>", "<td>Domain 4</td><td>2</td>", "<h2>Further reading</h2>"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("HTML missing %q:\n%s", want, buf.String())
+		}
+	}
+	if f, err := Format("out.PDF"); f != "pdf" || err != nil {
+		t.Fatalf("Format(out.PDF) = %q, %v", f, err)
+	}
+	if f, err := Format("notes/missed.md"); f != "md" || err != nil {
+		t.Fatalf("Format(missed.md) = %q, %v", f, err)
+	}
+	if _, err := Format("out.txt"); err == nil {
+		t.Fatalf("Format accepted .txt")
+	}
+}
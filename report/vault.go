@@ -0,0 +1,131 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// VaultNoteName is the name, without ".md", of q's note in an Obsidian
+// vault: the start of the prompt and the ID, without the characters vaults
+// reject in note names or read as link syntax.
+func VaultNoteName(q quiz.Question) string {
+	words := strings.Fields(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>|#^[]`, r) {
+			return ' '
+		}
+		return r
+	}, markdown.Plain(q.Prompt)))
+	name := ""
+	for _, w := range words {
+		if name != "" && len([]rune(name))+1+len([]rune(w)) > 60 {
+			break
+		}
+		name = strings.TrimSpace(name + " " + w)
+	}
+	if q.ID != "" {
+		name = strings.TrimSpace(name + " (" + strings.Trim(q.ID, `\/:*?"<>|#^[]`) + ")")
+	}
+	if name == "" {
+		name = "Question"
+	}
+	return name
+}
+
+// VaultRunName is the name, without ".md", of the note on r's run, e.g.
+// "Quiz 2026-03-01 09.01".
+func VaultRunName(r Report) string {
+	return "Quiz " + r.Finished.Format("2006-01-02 15.04")
+}
+
+// VaultNote returns the note on m for an Obsidian vault, missed on day
+// (YYYY-MM-DD). Its frontmatter holds the ID, domain, tags and the days the
+// question was missed, and its body links to the domain's note. existing is
+// the note already in the vault, if any: its body, which may have been added
+// to since, is kept as it is and day joins the days missed.
+func VaultNote(existing []byte, m Missed, day string) []byte {
+	q := m.Question
+	front, body := splitFrontmatter(existing)
+	days := missedDays(front)
+	if !slices.Contains(days, day) {
+		days = append(days, day)
+	}
+	var b strings.Builder
+	b.WriteString("---\n")
+	if q.ID != "" {
+		fmt.Fprintf(&b, "id: %q\n", q.ID)
+	}
+	fmt.Fprintf(&b, "domain: %d\ntags:\n  - quiz/missed\n", q.Domain)
+	for _, t := range q.Tags {
+		fmt.Fprintf(&b, "  - %q\n", strings.Join(strings.Fields(t), "-"))
+	}
+	b.WriteString("missed:\n")
+	for _, d := range days {
+		fmt.Fprintf(&b, "  - %s\n", d)
+	}
+	b.WriteString("---\n")
+	if body == nil {
+		fmt.Fprintf(&b, "\nDomain: [[Domain %d]]\n\n", q.Domain)
+		writeMissed(&b, m)
+		return []byte(b.String())
+	}
+	return append([]byte(b.String()), body...)
+}
+
+// VaultRunNote returns the note on r's run: its facts, and links to the
+// notes named for the questions missed, which the vault then shows as
+// backlinks on each.
+func VaultRunNote(r Report, names []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ndate: %s\nscore: %d/%d\ntags:\n  - quiz/run\n---\n\n# %s\n\n", r.Finished.Format("2006-01-02"), r.Score, r.Answered, VaultRunName(r))
+	for _, f := range r.facts() {
+		fmt.Fprintf(&b, "- **%s:** %s\n", f[0], f[1])
+	}
+	b.WriteString("\n## Missed questions\n\n")
+	if len(names) == 0 {
+		b.WriteString("No questions missed.\n")
+	}
+	for _, name := range names {
+		fmt.Fprintf(&b, "- [[%s]]\n", name)
+	}
+	return []byte(b.String())
+}
+
+// splitFrontmatter splits a note into its frontmatter lines and the body
+// after them. A note without frontmatter is all body; a missing or empty
+// note has a nil body.
+func splitFrontmatter(note []byte) (front []string, body []byte) {
+	if len(note) == 0 {
+		return nil, nil
+	}
+	rest, ok := bytes.CutPrefix(note, []byte("---\n"))
+	if !ok {
+		return nil, note
+	}
+	head, body, ok := bytes.Cut(rest, []byte("\n---\n"))
+	if !ok {
+		return nil, note
+	}
+	return strings.Split(string(head), "\n"), body
+}
+
+// missedDays reads the list under "missed:" in frontmatter lines.
+func missedDays(front []string) []string {
+	var days []string
+	in := false
+	for _, line := range front {
+		switch {
+		case line == "missed:":
+			in = true
+		case in && strings.HasPrefix(line, "  - "):
+			days = append(days, strings.TrimSpace(strings.TrimPrefix(line, "  - ")))
+		default:
+			in = false
+		}
+	}
+	return days
+}
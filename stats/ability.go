@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"quiz-cli/quiz"
+)
+
+// Ability is a learner's estimated ability in one domain under a Rasch
+// (one-parameter item response) model, which takes into account how hard
+// the questions answered have proved: half right on questions most people
+// miss is worth more than half right on ones everybody gets.
+type Ability struct {
+	Domain int `json:"domain"`
+	// Total is how many questions the domain has; Questions is how many of
+	// them the learner has attempted, Attempts and Correct how often and how
+	// often rightly.
+	Total     int `json:"total"`
+	Questions int `json:"questions"`
+	Attempts  int `json:"attempts"`
+	Correct   int `json:"correct"`
+	// Theta is the ability on the logit scale difficulties are measured on:
+	// 0 answers a question missed half the time right half the time, and
+	// each point up multiplies the odds of a right answer by e. StdErr is
+	// its standard error.
+	Theta  float64 `json:"theta"`
+	StdErr float64 `json:"stdErr"`
+	// Expected is the percentage of the domain's questions the learner is
+	// expected to answer right at Theta, unseen ones counting as of middling
+	// difficulty; unlike the raw percentage correct, it does not rise by
+	// practising only the easy questions.
+	Expected float64 `json:"expected"`
+}
+
+// Raw is the plain percentage of the learner's attempts answered right.
+func (a Ability) Raw() float64 {
+	return float64(a.Correct) * 100 / float64(a.Attempts)
+}
+
+// Abilities estimates the learner's ability in each domain of bank from the
+// history in learner. Question difficulties come from the history pooled
+// over learner and others, such as the files of a class; the more learners
+// pooled, the more the estimate says beyond the learner's own miss rates.
+// Domains are returned in ascending order; domains the learner has not
+// attempted are omitted.
+func Abilities(bank []quiz.Question, learner *Store, others ...*Store) []Ability {
+	ds := difficulties(bank, append([]*Store{learner}, others...))
+	type item struct {
+		b                 float64
+		attempts, correct int
+	}
+	items := map[int][]item{}
+	for i, q := range bank {
+		it := item{b: ds[i].logitDifficulty()}
+		if rec, ok := learner.Lookup(q); ok {
+			it.attempts, it.correct = rec.Attempts, rec.Correct
+		}
+		items[q.Domain] = append(items[q.Domain], it)
+	}
+	var out []Ability
+	for domain, its := range items {
+		a := Ability{Domain: domain, Total: len(its)}
+		for _, it := range its {
+			if it.attempts > 0 {
+				a.Questions++
+				a.Attempts += it.attempts
+				a.Correct += it.correct
+			}
+		}
+		if a.Attempts == 0 {
+			continue
+		}
+		// Newton's method on the log posterior, with a standard normal
+		// prior on theta so a perfect or empty record still has a finite
+		// estimate. The posterior is concave, so this converges.
+		var info float64
+		for range 50 {
+			grad, hess := -a.Theta, 1.0
+			for _, it := range its {
+				p := logistic(a.Theta - it.b)
+				grad += float64(it.correct) - float64(it.attempts)*p
+				hess += float64(it.attempts) * p * (1 - p)
+			}
+			info = hess
+			step := math.Max(-1, math.Min(1, grad/hess))
+			a.Theta += step
+			if math.Abs(step) < 1e-6 {
+				break
+			}
+		}
+		a.StdErr = 1 / math.Sqrt(info)
+		var expected float64
+		for _, it := range its {
+			expected += logistic(a.Theta - it.b)
+		}
+		a.Expected = expected * 100 / float64(len(its))
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+// logitDifficulty is the question's difficulty on the logit scale: the log
+// odds of its smoothed miss rate, so unseen questions sit at 0.
+func (d Difficulty) logitDifficulty() float64 {
+	return math.Log(float64(d.Misses+1) / float64(d.Attempts-d.Misses+1))
+}
+
+func logistic(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"sort"
+
+	"quiz-cli/quiz"
+)
+
+// BankCheck compares the bank being studied with the one the history was
+// pinned to (see PinBank).
+type BankCheck struct {
+	Pinned, Current quiz.BankInfo
+	// Edited lists questions whose prompt differs from the one their history
+	// was recorded under. Their history still applies, by ID.
+	Edited []quiz.Question
+	// Missing lists history keys that match no question in the bank: the
+	// question was removed, or its ID changed.
+	Missing []string
+	// Remap proposes a question for Missing history to move to: old key to
+	// the ID of a question with the same or a nearly identical prompt and no
+	// history of its own.
+	Remap map[string]string
+}
+
+// Changed reports whether the history was pinned to another bank or version.
+func (c BankCheck) Changed() bool {
+	return !c.Pinned.Same(c.Current)
+}
+
+// CheckBank compares bank, described by info, with the pinned bank. A history
+// that was never pinned, or is pinned to the same edition, reports no change.
+func (s *Store) CheckBank(info quiz.BankInfo, bank []quiz.Question) BankCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Bank == nil || s.Bank.Same(info) {
+		return BankCheck{Pinned: info, Current: info}
+	}
+	c := BankCheck{Pinned: *s.Bank, Current: info, Remap: map[string]string{}}
+	known := map[string]bool{}
+	var free []quiz.Question
+	for _, q := range bank {
+		known[Key(q)] = true
+		known[keyForPrompt(q.Prompt)] = true
+		rec, ok := s.Records[Key(q)]
+		if !ok {
+			rec, ok = s.Records[keyForPrompt(q.Prompt)]
+		}
+		switch {
+		case !ok:
+			free = append(free, q)
+		case !q.IsTemplate() && keyForPrompt(rec.Prompt) != keyForPrompt(q.Prompt):
+			c.Edited = append(c.Edited, q)
+		}
+	}
+	for key := range s.Records {
+		if !known[key] {
+			c.Missing = append(c.Missing, key)
+		}
+	}
+	sort.Strings(c.Missing)
+	taken := map[string]bool{}
+	for _, key := range c.Missing {
+		best, bestSim := "", quiz.DefaultSimilarity
+		for _, q := range free {
+			if sim := quiz.PromptSimilarity(s.Records[key].Prompt, q.Prompt); sim >= bestSim && !taken[q.ID] {
+				best, bestSim = q.ID, sim
+			}
+		}
+		if best != "" {
+			c.Remap[key] = best
+			taken[best] = true
+		}
+	}
+	return c
+}
+
+// PinBank records info as the bank the history is kept against. Attempts
+// recorded from now on carry its version.
+func (s *Store) PinBank(info quiz.BankInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Bank = &quiz.BankInfo{Name: info.Name, Version: info.Version, Source: info.Source}
+}
+
+// Remap moves the history under each old key of m to the new ID, unless the
+// new ID already has history. It returns how many records moved.
+func (s *Store) Remap(m map[string]string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	moved := 0
+	for from, to := range m {
+		rec, ok := s.Records[from]
+		if _, taken := s.Records[to]; !ok || taken {
+			continue
+		}
+		delete(s.Records, from)
+		s.Records[to] = rec
+		moved++
+	}
+	return moved
+}
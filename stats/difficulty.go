@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"cmp"
+	"slices"
+
+	"quiz-cli/quiz"
+)
+
+// Difficulty is how hard a question has proved in the recorded history.
+type Difficulty struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Domain   int    `json:"domain"`
+	Prompt   string `json:"prompt"`
+	Attempts int    `json:"attempts"`
+	Misses   int    `json:"misses"`
+	// MissRate is the percentage of attempts answered wrongly.
+	MissRate float64 `json:"missRate"`
+	// AvgSeconds is the mean answer time over the timed attempts; zero
+	// when none were timed.
+	AvgSeconds float64 `json:"avgSeconds"`
+}
+
+// Difficulties reports the questions of bank that have been attempted,
+// hardest first, pooling the history in stores, such as the files of several
+// learners. Questions are ranked by a miss rate smoothed towards 50%, so one
+// lucky or unlucky attempt does not outrank a long record, then by answer
+// time.
+func Difficulties(bank []quiz.Question, stores ...*Store) []Difficulty {
+	var out []Difficulty
+	for i, d := range difficulties(bank, stores) {
+		if d.Attempts > 0 {
+			d.Index = i
+			out = append(out, d)
+		}
+	}
+	slices.SortStableFunc(out, compareDifficulty)
+	return out
+}
+
+// HardestFirst returns the indexes of bank ordered hardest first, as for
+// quiz.Session.UseOrder. Unseen questions count as middling, between those
+// mostly missed and those mostly answered.
+func HardestFirst(bank []quiz.Question, stores ...*Store) []int {
+	ds := difficulties(bank, stores)
+	for i := range ds {
+		ds[i].Index = i
+	}
+	slices.SortStableFunc(ds, compareDifficulty)
+	order := make([]int, len(ds))
+	for i, d := range ds {
+		order[i] = d.Index
+	}
+	return order
+}
+
+// DifficultyBands counts the questions of a bank by how often they have been
+// missed: under a quarter of the attempts is easy, under half medium, and
+// the rest hard.
+type DifficultyBands struct {
+	Easy   int `json:"easy"`
+	Medium int `json:"medium"`
+	Hard   int `json:"hard"`
+	// Unseen counts the questions never attempted.
+	Unseen int `json:"unseen"`
+}
+
+// Bands sorts the questions of bank into DifficultyBands, pooling the
+// history in stores.
+func Bands(bank []quiz.Question, stores ...*Store) DifficultyBands {
+	var b DifficultyBands
+	for _, d := range difficulties(bank, stores) {
+		switch {
+		case d.Attempts == 0:
+			b.Unseen++
+		case d.MissRate < 25:
+			b.Easy++
+		case d.MissRate < 50:
+			b.Medium++
+		default:
+			b.Hard++
+		}
+	}
+	return b
+}
+
+func difficulties(bank []quiz.Question, stores []*Store) []Difficulty {
+	out := make([]Difficulty, len(bank))
+	for i, q := range bank {
+		d := Difficulty{ID: q.ID, Domain: q.Domain, Prompt: q.Prompt}
+		var timed int
+		var seconds float64
+		for _, s := range stores {
+			rec, ok := s.Lookup(q)
+			if !ok {
+				continue
+			}
+			d.Attempts += rec.Attempts
+			d.Misses += rec.Attempts - rec.Correct
+			timed += rec.Timed
+			seconds += rec.Seconds
+		}
+		if d.Attempts > 0 {
+			d.MissRate = float64(d.Misses) * 100 / float64(d.Attempts)
+		}
+		if timed > 0 {
+			d.AvgSeconds = seconds / float64(timed)
+		}
+		out[i] = d
+	}
+	return out
+}
+
+// smoothedMissRate is the miss rate with one hit and one miss added, so
+// unseen questions sit at one half.
+func (d Difficulty) smoothedMissRate() float64 {
+	return float64(d.Misses+1) / float64(d.Attempts+2)
+}
+
+func compareDifficulty(a, b Difficulty) int {
+	if c := cmp.Compare(b.smoothedMissRate(), a.smoothedMissRate()); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.AvgSeconds, a.AvgSeconds)
+}
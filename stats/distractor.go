@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// DistractorPolicy decides which options Distractors flags.
+type DistractorPolicy struct {
+	// MinAnswers is the number of recorded picks a question needs before
+	// its options are judged, so a handful of answers flag nothing.
+	MinAnswers int
+	// TrapShare is the share of all answers, from 0 to 1, a single wrong
+	// option must draw to count as a trap.
+	TrapShare float64
+}
+
+// DefaultDistractorPolicy judges questions after ten answers and calls a
+// wrong option picked by half of them a trap.
+var DefaultDistractorPolicy = DistractorPolicy{MinAnswers: 10, TrapShare: 0.5}
+
+// OptionPicks is how often one option of a question was picked.
+type OptionPicks struct {
+	Option  string  `json:"option"`
+	Picks   int     `json:"picks"`
+	Percent float64 `json:"percent"`
+	Correct bool    `json:"correct,omitempty"`
+}
+
+// DistractorReport breaks down the answers to one question by option.
+type DistractorReport struct {
+	Index   int           `json:"index"`
+	ID      string        `json:"id"`
+	Domain  int           `json:"domain"`
+	Answers int           `json:"answers"`
+	Options []OptionPicks `json:"options"`
+	// Unpicked lists the wrong options nobody chose, and Trap the wrong
+	// option drawing at least the policy's TrapShare of answers; both are
+	// left empty until the question has MinAnswers answers.
+	Unpicked []string `json:"unpicked,omitempty"`
+	Trap     string   `json:"trap,omitempty"`
+}
+
+// Distractors reports, for each question of bank with recorded picks, how
+// often each option was chosen, pooling the history in stores. Questions with
+// a trap come first, then those with unpicked distractors, then the rest in
+// bank order.
+func Distractors(bank []quiz.Question, policy DistractorPolicy, stores ...*Store) []DistractorReport {
+	var out []DistractorReport
+	for i, q := range bank {
+		picks := map[string]int{}
+		r := DistractorReport{Index: i, ID: q.ID, Domain: q.Domain}
+		for _, s := range stores {
+			rec, _ := s.Lookup(q)
+			for opt, n := range rec.Choices {
+				picks[opt] += n
+				r.Answers += n
+			}
+		}
+		if r.Answers == 0 {
+			continue
+		}
+		judged := r.Answers >= policy.MinAnswers
+		for _, opt := range optionKeys(q) {
+			p := OptionPicks{
+				Option:  opt,
+				Picks:   picks[opt],
+				Percent: float64(picks[opt]) * 100 / float64(r.Answers),
+				Correct: strings.EqualFold(opt, q.Answer),
+			}
+			r.Options = append(r.Options, p)
+			switch {
+			case p.Correct || !judged:
+			case p.Picks == 0:
+				r.Unpicked = append(r.Unpicked, opt)
+			case policy.TrapShare > 0 && p.Percent >= policy.TrapShare*100:
+				r.Trap = opt
+			}
+		}
+		out = append(out, r)
+	}
+	rank := func(r DistractorReport) int {
+		switch {
+		case r.Trap != "":
+			return 0
+		case len(r.Unpicked) > 0:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(out, func(i, j int) bool { return rank(out[i]) < rank(out[j]) })
+	return out
+}
+
+func optionKeys(q quiz.Question) []string {
+	keys := make([]string, 0, len(q.Options))
+	for k := range q.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
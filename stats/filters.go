@@ -0,0 +1,154 @@
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// MaxSearches is how many recent search terms AddSearch keeps.
+const MaxSearches = 20
+
+// AddSearch remembers term as the most recent search, dropping an earlier
+// copy of it and the oldest terms beyond MaxSearches.
+func (s *Store) AddSearch(term string) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Searches = slices.DeleteFunc(s.Searches, func(t string) bool { return t == term })
+	s.Searches = append(s.Searches, term)
+	if n := len(s.Searches) - MaxSearches; n > 0 {
+		s.Searches = slices.Delete(s.Searches, 0, n)
+	}
+}
+
+// RecentSearches returns the remembered search terms, oldest first.
+func (s *Store) RecentSearches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.Searches)
+}
+
+// Filter picks questions by their text, domain, tags and history, e.g.
+// "crypto questions I've missed twice". The zero Filter matches every
+// question.
+type Filter struct {
+	// Search matches the prompt or an option, ignoring case.
+	Search string `json:"search,omitempty"`
+	// Domain, when set, keeps only that domain's questions.
+	Domain int `json:"domain,omitempty"`
+	// Tags keeps the questions carrying every one of them.
+	Tags []string `json:"tags,omitempty"`
+	// MinMisses keeps the questions answered wrongly at least this often.
+	MinMisses int `json:"minMisses,omitempty"`
+}
+
+// String describes f, e.g. `"crypto", domain 4, missed at least 2 times`.
+func (f Filter) String() string {
+	var parts []string
+	if f.Search != "" {
+		parts = append(parts, fmt.Sprintf("%q", f.Search))
+	}
+	if f.Domain != 0 {
+		parts = append(parts, fmt.Sprintf("domain %d", f.Domain))
+	}
+	for _, t := range f.Tags {
+		parts = append(parts, "tag "+t)
+	}
+	if f.MinMisses > 0 {
+		parts = append(parts, fmt.Sprintf("missed at least %d times", f.MinMisses))
+	}
+	if parts == nil {
+		return "every question"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Select returns the questions of qs that f matches, in bank order. Misses
+// are counted in s's history.
+func (s *Store) Select(qs []quiz.Question, f Filter) []quiz.Question {
+	needle := strings.ToLower(strings.TrimSpace(f.Search))
+	var out []quiz.Question
+	for _, q := range qs {
+		if f.Domain != 0 && q.Domain != f.Domain {
+			continue
+		}
+		if slices.ContainsFunc(f.Tags, func(t string) bool { return !q.HasTag(t) }) {
+			continue
+		}
+		if needle != "" && !mentions(q, needle) {
+			continue
+		}
+		if f.MinMisses > 0 {
+			rec, _ := s.Lookup(q)
+			if rec.Attempts-rec.Correct < f.MinMisses {
+				continue
+			}
+		}
+		out = append(out, q)
+	}
+	return out
+}
+
+// mentions reports whether q's prompt or an option contains needle, which is
+// lower case.
+func mentions(q quiz.Question, needle string) bool {
+	if strings.Contains(strings.ToLower(markdown.Plain(q.Prompt)), needle) {
+		return true
+	}
+	for _, text := range q.Options {
+		if strings.Contains(strings.ToLower(markdown.Plain(text)), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveFilter stores f under name, replacing a filter saved under it before.
+func (s *Store) SaveFilter(name string, f Filter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Filters == nil {
+		s.Filters = map[string]Filter{}
+	}
+	s.Filters[name] = f
+}
+
+// SavedFilter returns the filter saved under name.
+func (s *Store) SavedFilter(name string) (Filter, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.Filters[name]
+	return f, ok
+}
+
+// DeleteFilter removes the filter saved under name, returning false if there
+// is none.
+func (s *Store) DeleteFilter(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Filters[name]; !ok {
+		return false
+	}
+	delete(s.Filters, name)
+	return true
+}
+
+// FilterNames returns the names of the saved filters, sorted.
+func (s *Store) FilterNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.Filters))
+	for name := range s.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
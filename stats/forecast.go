@@ -0,0 +1,183 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// dayLayout formats the dates that DayTally entries are keyed by.
+const dayLayout = "2006-01-02"
+
+// DayTally counts the attempts at one question on one (UTC) day.
+type DayTally struct {
+	Day      string `json:"day"`
+	Attempts int    `json:"attempts"`
+	Correct  int    `json:"correct"`
+}
+
+// addDay counts an attempt at time at in rec's daily history, keeping it
+// sorted by day. Attempts without a time only count toward the totals.
+func (rec *Record) addDay(correct bool, at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	day := at.UTC().Format(dayLayout)
+	i := sort.Search(len(rec.Days), func(i int) bool { return rec.Days[i].Day >= day })
+	if i == len(rec.Days) || rec.Days[i].Day != day {
+		rec.Days = append(rec.Days, DayTally{})
+		copy(rec.Days[i+1:], rec.Days[i:])
+		rec.Days[i] = DayTally{Day: day}
+	}
+	rec.Days[i].Attempts++
+	if correct {
+		rec.Days[i].Correct++
+	}
+}
+
+// maxForecast bounds how far ahead Forecast projects; a curve that only
+// crosses the pass mark later than this is reported as having no forecast.
+const maxForecast = 3 * 365 * 24 * time.Hour
+
+// DomainForecast is one domain's learning curve, fitted to its daily
+// accuracy, and the date it is projected to reach the pass mark.
+type DomainForecast struct {
+	Domain   int `json:"domain"`
+	Attempts int `json:"attempts"`
+	// StudyDays is the number of distinct days with attempts; at least two
+	// are needed to fit a curve.
+	StudyDays int `json:"studyDays"`
+	// Accuracy is the overall percentage correct; Projected is the curve's
+	// value today.
+	Accuracy  float64 `json:"accuracy"`
+	Projected float64 `json:"projected"`
+	// WeeklyGain is the curve's current slope in percentage points per week.
+	WeeklyGain float64 `json:"weeklyGain"`
+	// Ready is when Projected is expected to reach the pass mark: now if it
+	// already has, zero if the curve never gets there.
+	Ready time.Time `json:"ready,omitempty"`
+}
+
+// Reached reports whether the domain is already at the pass mark.
+func (f DomainForecast) Reached(now time.Time) bool {
+	return !f.Ready.IsZero() && !f.Ready.After(now)
+}
+
+// Forecast fits a learning curve per domain of bank to the recorded daily
+// accuracy, accuracy = a + b·ln(1 + days since the first attempt), weighting
+// each day by its attempts, and projects when it reaches passMark percent.
+// Domains are returned in ascending order; domains without history are
+// omitted.
+func (s *Store) Forecast(bank []quiz.Question, passMark float64, now time.Time) []DomainForecast {
+	type tally struct{ attempts, correct int }
+	days := map[int]map[string]*tally{}
+	s.mu.Lock()
+	for _, q := range bank {
+		rec, ok := s.find(q)
+		if !ok {
+			continue
+		}
+		if days[q.Domain] == nil {
+			days[q.Domain] = map[string]*tally{}
+		}
+		for _, d := range rec.Days {
+			t := days[q.Domain][d.Day]
+			if t == nil {
+				t = &tally{}
+				days[q.Domain][d.Day] = t
+			}
+			t.attempts += d.Attempts
+			t.correct += d.Correct
+		}
+	}
+	s.mu.Unlock()
+
+	var out []DomainForecast
+	for domain, byDay := range days {
+		if len(byDay) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(byDay))
+		for day := range byDay {
+			keys = append(keys, day)
+		}
+		sort.Strings(keys)
+		first, _ := time.Parse(dayLayout, keys[0])
+		f := DomainForecast{Domain: domain, StudyDays: len(keys)}
+		var pts []curvePoint
+		correct := 0
+		for _, day := range keys {
+			t := byDay[day]
+			at, _ := time.Parse(dayLayout, day)
+			pts = append(pts, curvePoint{
+				x: math.Log1p(at.Sub(first).Hours() / 24),
+				y: float64(t.correct) * 100 / float64(t.attempts),
+				w: float64(t.attempts),
+			})
+			f.Attempts += t.attempts
+			correct += t.correct
+		}
+		f.Accuracy = float64(correct) * 100 / float64(f.Attempts)
+		elapsed := math.Max(now.Sub(first).Hours()/24, 0)
+		a, b, ok := fitLine(pts)
+		if !ok {
+			// One study day: no trend yet, so the best estimate is the
+			// accuracy so far.
+			a, b = f.Accuracy, 0
+		}
+		f.Projected = clampPercent(a + b*math.Log1p(elapsed))
+		f.WeeklyGain = 7 * b / (1 + elapsed)
+		switch {
+		case f.Projected >= passMark:
+			f.Ready = now
+		case b > 0:
+			reach := math.Expm1((passMark - a) / b)
+			if d := time.Duration(reach * 24 * float64(time.Hour)); reach >= 0 && d <= maxForecast {
+				f.Ready = first.Add(d)
+			}
+		}
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+// Behind returns the domains in fs not projected to reach the pass mark by
+// exam, or at all when exam is zero.
+func Behind(fs []DomainForecast, exam time.Time) []int {
+	var out []int
+	for _, f := range fs {
+		if f.Ready.IsZero() || (!exam.IsZero() && f.Ready.After(exam)) {
+			out = append(out, f.Domain)
+		}
+	}
+	return out
+}
+
+type curvePoint struct{ x, y, w float64 }
+
+// fitLine is weighted least squares for y = a + b·x. ok is false when the
+// points don't span more than one x.
+func fitLine(pts []curvePoint) (a, b float64, ok bool) {
+	var sw, sx, sy, sxx, sxy float64
+	for _, p := range pts {
+		sw += p.w
+		sx += p.w * p.x
+		sy += p.w * p.y
+		sxx += p.w * p.x * p.x
+		sxy += p.w * p.x * p.y
+	}
+	den := sw*sxx - sx*sx
+	if sw == 0 || math.Abs(den) < 1e-12 {
+		return 0, 0, false
+	}
+	b = (sw*sxy - sx*sy) / den
+	a = (sy - b*sx) / sw
+	return a, b, true
+}
+
+func clampPercent(v float64) float64 {
+	return math.Min(math.Max(v, 0), 100)
+}
@@ -0,0 +1,150 @@
+package stats
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// ColumnMap names the CSV header columns to read when importing results from
+// another tool. Question and ID identify the question; at least one must be
+// set. Correct is read as a boolean; when it is empty, Answer is compared with
+// the bank's answer instead. Time is optional and parsed as RFC 3339 or a
+// plain date.
+type ColumnMap struct {
+	Question string
+	ID       string
+	Answer   string
+	Correct  string
+	Time     string
+}
+
+// DefaultColumns matches a CSV with "question", "answer", "correct" and "time"
+// headers.
+var DefaultColumns = ColumnMap{
+	Question: "question",
+	Answer:   "answer",
+	Correct:  "correct",
+	Time:     "time",
+}
+
+// ImportReport summarises an import.
+type ImportReport struct {
+	Imported  int
+	Unmatched []string
+}
+
+// ImportCSV reads attempts from r and records those that match a question in
+// bank. Rows are matched by ID (a question ID or 1-based question number) when
+// that column is mapped, otherwise by normalised question text. The import stops with
+// ctx.Err() if ctx is cancelled part way through.
+func (s *Store) ImportCSV(ctx context.Context, r io.Reader, bank []quiz.Question, cols ColumnMap) (ImportReport, error) {
+	var report ImportReport
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return report, fmt.Errorf("read header: %w", err)
+	}
+	pos := map[string]int{}
+	for i, h := range header {
+		pos[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	col := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		if i, ok := pos[strings.ToLower(name)]; ok {
+			return i
+		}
+		return -1
+	}
+	qCol, idCol, ansCol, okCol, timeCol := col(cols.Question), col(cols.ID), col(cols.Answer), col(cols.Correct), col(cols.Time)
+	if qCol < 0 && idCol < 0 {
+		return report, errors.New("csv has no question or id column")
+	}
+	if okCol < 0 && ansCol < 0 {
+		return report, errors.New("csv has no correct or answer column")
+	}
+
+	byPrompt := make(map[string]int, len(bank))
+	byID := make(map[string]int, len(bank))
+	for i, q := range bank {
+		byPrompt[keyForPrompt(q.Prompt)] = i
+		byID[q.ID] = i
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for line := 2; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		idx := -1
+		if i, ok := byID[field(idCol)]; ok && field(idCol) != "" {
+			idx = i
+		} else if n, err := strconv.Atoi(field(idCol)); err == nil && n >= 1 && n <= len(bank) {
+			idx = n - 1
+		} else if i, ok := byPrompt[keyForPrompt(field(qCol))]; ok {
+			idx = i
+		}
+		if idx < 0 {
+			label := field(qCol)
+			if label == "" {
+				label = field(idCol)
+			}
+			report.Unmatched = append(report.Unmatched, label)
+			continue
+		}
+		q := bank[idx]
+
+		var correct bool
+		if okCol >= 0 {
+			correct = parseBool(field(okCol))
+		} else {
+			correct = strings.EqualFold(field(ansCol), q.Answer)
+		}
+		s.recordLocked(q, correct, parseTime(field(timeCol)))
+		s.choiceLocked(q, field(ansCol))
+		report.Imported++
+	}
+	return report, nil
+}
+
+func parseBool(v string) bool {
+	switch strings.ToLower(v) {
+	case "1", "true", "t", "yes", "y", "correct", "right", "pass", "✓", "✅":
+		return true
+	}
+	return false
+}
+
+func parseTime(v string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// maxFirstTries bounds the first attempts a record keeps; older ones say
+// little about where the learner stands now.
+const maxFirstTries = 20
+
+// FirstTry is a session's first attempt at a question.
+type FirstTry struct {
+	At      time.Time `json:"at"`
+	Correct bool      `json:"correct"`
+}
+
+// RecordFirstTry notes a session's first attempt at q, which Record has
+// already counted, for Intervals.
+func (s *Store) RecordFirstTry(q quiz.Question, correct bool, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.recordFor(q)
+	rec.FirstTries = append(rec.FirstTries, FirstTry{At: at, Correct: correct})
+	if n := len(rec.FirstTries); n > maxFirstTries {
+		rec.FirstTries = slices.Delete(rec.FirstTries, 0, n-maxFirstTries)
+	}
+}
+
+// IntervalPolicy sets how Intervals judges readiness.
+type IntervalPolicy struct {
+	// PassMark is the percentage each domain needs.
+	PassMark float64
+	// Recent is how many of each domain's latest first attempts count.
+	Recent int
+	// Level is the confidence level of the interval, e.g. 0.95.
+	Level float64
+}
+
+// DefaultIntervalPolicy judges the last 50 first attempts per domain at 95%
+// confidence against a pass mark of 70%.
+var DefaultIntervalPolicy = IntervalPolicy{PassMark: 70, Recent: 50, Level: 0.95}
+
+// Check reports a policy Intervals cannot use.
+func (p IntervalPolicy) Check() error {
+	switch {
+	case p.PassMark < 0 || p.PassMark > 100:
+		return fmt.Errorf("pass mark %g is not a percentage", p.PassMark)
+	case p.Recent < 1:
+		return fmt.Errorf("at least one recent answer must count, not %d", p.Recent)
+	case p.Level <= 0 || p.Level >= 1:
+		return fmt.Errorf("confidence level %g%% must be above 0 and below 100", p.Level*100)
+	}
+	return nil
+}
+
+// Verdict is how a domain's interval stands against the pass mark.
+type Verdict string
+
+const (
+	// Ready means the whole interval is at or above the pass mark.
+	Ready Verdict = "ready"
+	// AtRisk means the interval's lower bound is below the pass mark but
+	// its upper bound is not: the recent accuracy may be luck.
+	AtRisk Verdict = "at risk"
+	// NotReady means the whole interval is below the pass mark.
+	NotReady Verdict = "not ready"
+)
+
+// DomainInterval is a domain's recent first-attempt accuracy with a Wilson
+// score interval around it, which stays within 0 to 100% and is sound for
+// few answers and accuracies near either end.
+type DomainInterval struct {
+	Domain int `json:"domain"`
+	// Answers is how many recent first attempts count, Correct how many of
+	// them were right.
+	Answers  int     `json:"answers"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+	// Lower and Upper bound the true accuracy, in percent, at the policy's
+	// confidence level.
+	Lower   float64 `json:"lower"`
+	Upper   float64 `json:"upper"`
+	Verdict Verdict `json:"verdict"`
+}
+
+// Intervals reports each domain of bank's recent first-attempt accuracy and
+// its confidence interval under p, flagging domains whose lower bound is
+// below the pass mark. Domains are returned in ascending order; domains
+// without first attempts on record are omitted.
+func (s *Store) Intervals(bank []quiz.Question, p IntervalPolicy) []DomainInterval {
+	tries := map[int][]FirstTry{}
+	s.mu.Lock()
+	for _, q := range bank {
+		if rec, ok := s.find(q); ok {
+			tries[q.Domain] = append(tries[q.Domain], rec.FirstTries...)
+		}
+	}
+	s.mu.Unlock()
+
+	z := math.Sqrt2 * math.Erfinv(p.Level)
+	var out []DomainInterval
+	for domain, ts := range tries {
+		if len(ts) == 0 {
+			continue
+		}
+		slices.SortStableFunc(ts, func(a, b FirstTry) int { return a.At.Compare(b.At) })
+		ts = ts[max(len(ts)-p.Recent, 0):]
+		d := DomainInterval{Domain: domain, Answers: len(ts)}
+		for _, t := range ts {
+			if t.Correct {
+				d.Correct++
+			}
+		}
+		n := float64(d.Answers)
+		phat := float64(d.Correct) / n
+		centre := (phat + z*z/(2*n)) / (1 + z*z/n)
+		half := z / (1 + z*z/n) * math.Sqrt(phat*(1-phat)/n+z*z/(4*n*n))
+		d.Accuracy = phat * 100
+		d.Lower = clampPercent((centre - half) * 100)
+		d.Upper = clampPercent((centre + half) * 100)
+		switch {
+		case d.Lower >= p.PassMark:
+			d.Verdict = Ready
+		case d.Upper >= p.PassMark:
+			d.Verdict = AtRisk
+		default:
+			d.Verdict = NotReady
+		}
+		out = append(out, d)
+	}
+	slices.SortFunc(out, func(a, b DomainInterval) int { return cmp.Compare(a.Domain, b.Domain) })
+	return out
+}
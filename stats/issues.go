@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// Issue is a learner's report of a problem with a question, such as a wrong
+// answer key or a typo, for the bank's maintainers.
+type Issue struct {
+	Key    string `json:"key"`
+	Domain int    `json:"domain,omitempty"`
+	Prompt string `json:"prompt"`
+	// Comment says what is wrong, in the reporter's words.
+	Comment  string    `json:"comment"`
+	Reporter string    `json:"reporter,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// ReportIssue records comment as an issue with q, reported by reporter
+// (optional) at at, and returns it.
+func (s *Store) ReportIssue(q quiz.Question, comment, reporter string, at time.Time) Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue := Issue{
+		Key:      Key(q),
+		Domain:   q.Domain,
+		Prompt:   q.Prompt,
+		Comment:  strings.TrimSpace(comment),
+		Reporter: strings.TrimSpace(reporter),
+		At:       at,
+	}
+	s.IssueLog = append(s.IssueLog, issue)
+	return issue
+}
+
+// Issues returns every reported issue, oldest first.
+func (s *Store) Issues() []Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.IssueLog)
+}
+
+// WriteIssuesCSV writes issues to w as CSV with a header row.
+func WriteIssuesCSV(w io.Writer, issues []Issue) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"reported_at", "id", "domain", "question", "comment", "reporter"})
+	for _, is := range issues {
+		domain := ""
+		if is.Domain != 0 {
+			domain = strconv.Itoa(is.Domain)
+		}
+		cw.Write([]string{is.At.Format(time.RFC3339), is.Key, domain, is.Prompt, is.Comment, is.Reporter})
+	}
+	cw.Flush()
+	return cw.Error()
+}
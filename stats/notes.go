@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// NoteEntry is one learner note, as listed by Notes.
+type NoteEntry struct {
+	Key    string `json:"key"`
+	Domain int    `json:"domain,omitempty"`
+	Prompt string `json:"prompt"`
+	Note   string `json:"note"`
+}
+
+// SetNote stores text as the learner's note on q; blank text removes it.
+func (s *Store) SetNote(q quiz.Question, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	text = strings.TrimSpace(text)
+	if text == "" {
+		if rec, ok := s.find(q); ok {
+			rec.Note = ""
+		}
+		return
+	}
+	s.recordFor(q).Note = text
+}
+
+// Note returns the learner's note on q, or "" if there is none.
+func (s *Store) Note(q quiz.Question) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.find(q); ok {
+		return rec.Note
+	}
+	return ""
+}
+
+// Notes returns every note: those on questions in bank first, in bank order,
+// then notes on questions no longer in it, by key.
+func (s *Store) Notes(bank []quiz.Question) []NoteEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []NoteEntry
+	seen := map[string]bool{}
+	for _, q := range bank {
+		rec, ok := s.find(q)
+		if !ok || rec.Note == "" {
+			continue
+		}
+		key := Key(q)
+		seen[key] = true
+		out = append(out, NoteEntry{Key: key, Domain: q.Domain, Prompt: q.Prompt, Note: rec.Note})
+	}
+	var rest []NoteEntry
+	for key, rec := range s.Records {
+		if rec.Note != "" && !seen[key] {
+			rest = append(rest, NoteEntry{Key: key, Prompt: rec.Prompt, Note: rec.Note})
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i].Key < rest[j].Key })
+	return append(out, rest...)
+}
+
+// WriteNotesMarkdown writes notes to w as a Markdown document, one section
+// per question with the prompt quoted above the note.
+func WriteNotesMarkdown(w io.Writer, notes []NoteEntry) error {
+	var b strings.Builder
+	b.WriteString("# Question notes\n")
+	if len(notes) == 0 {
+		b.WriteString("\nNo notes yet.\n")
+	}
+	for i, n := range notes {
+		// Keys of questions without an ID are just their prompt, quoted below.
+		heading := n.Key
+		if n.Key == keyForPrompt(n.Prompt) {
+			heading = fmt.Sprintf("Note %d", i+1)
+		}
+		if n.Domain != 0 {
+			heading += fmt.Sprintf(" (Domain %d)", n.Domain)
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", heading)
+		for _, line := range strings.Split(markdown.Plain(n.Prompt), "\n") {
+			b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		fmt.Fprintf(&b, "\n%s\n", n.Note)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,154 @@
+package stats
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// PlanKind says what a study day is for.
+type PlanKind string
+
+const (
+	// PlanPractice drills one domain.
+	PlanPractice PlanKind = "practice"
+	// PlanReview re-answers the questions most often missed.
+	PlanReview PlanKind = "review"
+	// PlanMock is a practice exam across every domain.
+	PlanMock PlanKind = "mock"
+)
+
+// missedBelow is the accuracy under which an answered question counts as
+// missed and goes into review days.
+const missedBelow = 0.8
+
+// reviewEvery puts a review day after every three practice days.
+const reviewEvery = 4
+
+// DomainNeed is how much of a domain is left to learn.
+type DomainNeed struct {
+	Domain    int `json:"domain"`
+	Questions int `json:"questions"`
+	// Unseen counts questions never answered; Missed counts answered ones
+	// still below 80% accuracy.
+	Unseen int `json:"unseen"`
+	Missed int `json:"missed"`
+	// Accuracy is the percentage correct over every recorded attempt.
+	Accuracy float64 `json:"accuracy"`
+	// Days is the number of practice days the plan gives the domain.
+	Days int `json:"days"`
+}
+
+// weight ranks domains for practice days: every unseen or missed question
+// counts fully, and a tenth of the rest keeps strong domains ticking over.
+func (d DomainNeed) weight() float64 {
+	return float64(d.Unseen+d.Missed) + 0.1*float64(d.Questions)
+}
+
+// PlanDay is one day of a StudyPlan.
+type PlanDay struct {
+	Date      time.Time `json:"date"`
+	Kind      PlanKind  `json:"kind"`
+	Domain    int       `json:"domain,omitempty"`
+	Questions int       `json:"questions"`
+}
+
+// StudyPlan is a daily schedule leading up to an exam.
+type StudyPlan struct {
+	Exam    time.Time    `json:"exam"`
+	Domains []DomainNeed `json:"domains"`
+	Days    []PlanDay    `json:"days"`
+}
+
+// Plan proposes a schedule of perDay questions a day from today until the day
+// before exam. The last day is a mock exam, every fourth day (and the one
+// before the mock) reviews missed questions when there are any, and the
+// remaining days drill domains in proportion to how much of each is unseen or
+// missed, weakest first. Questions under review are left out.
+func (s *Store) Plan(bank []quiz.Question, exam, now time.Time, perDay int) (StudyPlan, error) {
+	if perDay <= 0 {
+		return StudyPlan{}, errors.New("questions per day must be positive")
+	}
+	today := midnight(now)
+	exam = midnight(exam.In(now.Location()))
+	n := 0
+	for d := today; d.Before(exam); d = d.AddDate(0, 0, 1) {
+		n++
+	}
+	if n == 0 {
+		return StudyPlan{}, errors.New("exam date must be after today")
+	}
+
+	byDomain := map[int]*DomainNeed{}
+	attempts, correct := map[int]int{}, map[int]int{}
+	missed, pool := 0, 0
+	s.mu.Lock()
+	for _, q := range bank {
+		rec, ok := s.find(q)
+		if ok && rec.UnderReview {
+			continue
+		}
+		d := byDomain[q.Domain]
+		if d == nil {
+			d = &DomainNeed{Domain: q.Domain}
+			byDomain[q.Domain] = d
+		}
+		d.Questions++
+		pool++
+		switch {
+		case !ok || rec.Attempts == 0:
+			d.Unseen++
+		case float64(rec.Correct) < missedBelow*float64(rec.Attempts):
+			d.Missed++
+			missed++
+		}
+		if ok {
+			attempts[q.Domain] += rec.Attempts
+			correct[q.Domain] += rec.Correct
+		}
+	}
+	s.mu.Unlock()
+	if pool == 0 {
+		return StudyPlan{}, errors.New("no questions to plan around")
+	}
+
+	plan := StudyPlan{Exam: exam}
+	for _, d := range byDomain {
+		if attempts[d.Domain] > 0 {
+			d.Accuracy = float64(correct[d.Domain]) * 100 / float64(attempts[d.Domain])
+		}
+		plan.Domains = append(plan.Domains, *d)
+	}
+	sort.Slice(plan.Domains, func(i, j int) bool { return plan.Domains[i].Domain < plan.Domains[j].Domain })
+
+	for i := 0; i < n; i++ {
+		day := PlanDay{Date: today.AddDate(0, 0, i), Kind: PlanPractice}
+		switch {
+		case i == n-1 && n > 1:
+			day.Kind, day.Questions = PlanMock, min(perDay, pool)
+		case missed > 0 && (i%reviewEvery == reviewEvery-1 || (i == n-2 && n > 2)):
+			day.Kind, day.Questions = PlanReview, min(perDay, missed)
+		default:
+			// D'Hondt: the next practice day goes to the domain with the
+			// highest weight per day already given to it.
+			best := 0
+			for j, d := range plan.Domains {
+				if d.weight()/float64(d.Days+1) > plan.Domains[best].weight()/float64(plan.Domains[best].Days+1) {
+					best = j
+				}
+			}
+			plan.Domains[best].Days++
+			day.Domain = plan.Domains[best].Domain
+			day.Questions = min(perDay, plan.Domains[best].Questions)
+		}
+		plan.Days = append(plan.Days, day)
+	}
+	return plan, nil
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
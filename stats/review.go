@@ -0,0 +1,109 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+
+	"quiz-cli/quiz"
+)
+
+// ReviewPolicy decides when a question is pulled for review.
+type ReviewPolicy struct {
+	// MaxFlags is the number of learner flags that puts a question under
+	// review; zero never does.
+	MaxFlags int
+}
+
+// DefaultReviewPolicy pulls a question after three flags.
+var DefaultReviewPolicy = ReviewPolicy{MaxFlags: 3}
+
+// ReviewItem is one entry in the admin review queue.
+type ReviewItem struct {
+	Key    string `json:"key"`
+	Prompt string `json:"prompt"`
+	Flags  int    `json:"flags"`
+	Reason string `json:"reason"`
+}
+
+func (p ReviewPolicy) check(rec *Record) {
+	if rec.UnderReview {
+		return
+	}
+	if p.MaxFlags > 0 && rec.Flags >= p.MaxFlags {
+		rec.UnderReview = true
+		rec.ReviewReason = fmt.Sprintf("flagged %d times", rec.Flags)
+	}
+}
+
+func (s *Store) recordFor(q quiz.Question) *Record {
+	rec, ok := s.find(q)
+	if !ok {
+		rec = &Record{Prompt: q.Prompt}
+		s.Records[Key(q)] = rec
+	}
+	return rec
+}
+
+// Flag records a learner report against q and applies policy, returning the
+// updated record.
+func (s *Store) Flag(q quiz.Question, policy ReviewPolicy) Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.recordFor(q)
+	rec.Flags++
+	policy.check(rec)
+	return *rec
+}
+
+// UnderReview reports whether q has been pulled for review.
+func (s *Store) UnderReview(q quiz.Question) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.find(q)
+	return ok && rec.UnderReview
+}
+
+// Reviews returns the review queue, most-flagged first.
+func (s *Store) Reviews() []ReviewItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []ReviewItem
+	for key, rec := range s.Records {
+		if rec.UnderReview {
+			out = append(out, ReviewItem{Key: key, Prompt: rec.Prompt, Flags: rec.Flags, Reason: rec.ReviewReason})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Flags != out[j].Flags {
+			return out[i].Flags > out[j].Flags
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// Resolve clears the review state and flag count for key, returning false if
+// the key is not under review.
+func (s *Store) Resolve(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.Records[key]
+	if !ok || !rec.UnderReview {
+		return false
+	}
+	rec.UnderReview = false
+	rec.ReviewReason = ""
+	rec.Flags = 0
+	return true
+}
+
+// ExamQuestions returns qs without the questions currently under review.
+func (s *Store) ExamQuestions(qs []quiz.Question) []quiz.Question {
+	out := make([]quiz.Question, 0, len(qs))
+	for _, q := range qs {
+		if !s.UnderReview(q) {
+			out = append(out, q)
+		}
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// Card is a question's spaced-repetition schedule, updated by flashcard
+// grades with the SM-2 rules Anki popularized.
+type Card struct {
+	Due time.Time `json:"due"`
+	// Interval is the gap, in days, between the last review and Due.
+	Interval float64 `json:"interval"`
+	// Ease multiplies Interval after each successful review.
+	Ease   float64 `json:"ease"`
+	Reps   int     `json:"reps"`
+	Lapses int     `json:"lapses"`
+}
+
+const (
+	startEase = 2.5
+	minEase   = 1.3
+)
+
+// review applies grade g, given at time at, to c.
+func (c *Card) review(g quiz.Grade, at time.Time) {
+	if c.Ease == 0 {
+		c.Ease = startEase
+	}
+	switch g {
+	case quiz.Again:
+		// Relearn from scratch; the card is due again straight away.
+		c.Lapses++
+		c.Reps = 0
+		c.Interval = 0
+		c.Ease = math.Max(minEase, c.Ease-0.2)
+	case quiz.Hard:
+		c.Interval = math.Max(1, c.Interval*1.2)
+		c.Ease = math.Max(minEase, c.Ease-0.15)
+		c.Reps++
+	case quiz.Good, quiz.Easy:
+		switch c.Reps {
+		case 0:
+			c.Interval = 1
+		case 1:
+			c.Interval = 3
+		default:
+			c.Interval = math.Max(c.Interval+1, c.Interval*c.Ease)
+		}
+		if g == quiz.Easy {
+			c.Interval *= 1.3
+			c.Ease += 0.15
+		}
+		c.Reps++
+	}
+	c.Due = at.Add(time.Duration(c.Interval * 24 * float64(time.Hour)))
+}
+
+// Review records grade g for q's flashcard at time at and returns the updated
+// schedule.
+func (s *Store) Review(q quiz.Question, g quiz.Grade, at time.Time) Card {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.recordFor(q)
+	if rec.Card == nil {
+		rec.Card = &Card{}
+	}
+	rec.Card.review(g, at)
+	return *rec.Card
+}
+
+// DueCards returns the flashcards to study at now: cards whose review is due,
+// most overdue first, then up to newLimit questions never studied as cards,
+// in bank order (all of them when newLimit is negative). Questions under
+// review are left out.
+func (s *Store) DueCards(bank []quiz.Question, now time.Time, newLimit int) []quiz.Question {
+	type due struct {
+		q   quiz.Question
+		due time.Time
+	}
+	var reviews []due
+	var fresh []quiz.Question
+	s.mu.Lock()
+	for _, q := range bank {
+		rec, ok := s.find(q)
+		switch {
+		case ok && rec.UnderReview:
+		case !ok || rec.Card == nil:
+			if newLimit < 0 || len(fresh) < newLimit {
+				fresh = append(fresh, q)
+			}
+		case !rec.Card.Due.After(now):
+			reviews = append(reviews, due{q, rec.Card.Due})
+		}
+	}
+	s.mu.Unlock()
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].due.Before(reviews[j].due) })
+	out := make([]quiz.Question, 0, len(reviews)+len(fresh))
+	for _, r := range reviews {
+		out = append(out, r.q)
+	}
+	return append(out, fresh...)
+}
+
+// NextDue returns when the earliest card of bank not due at now comes due,
+// or the zero time if none is scheduled.
+func (s *Store) NextDue(bank []quiz.Question, now time.Time) time.Time {
+	var next time.Time
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range bank {
+		rec, ok := s.find(q)
+		if !ok || rec.Card == nil || !rec.Card.Due.After(now) {
+			continue
+		}
+		if next.IsZero() || rec.Card.Due.Before(next) {
+			next = rec.Card.Due
+		}
+	}
+	return next
+}
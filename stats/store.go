@@ -0,0 +1,243 @@
+// Package stats persists per-question answer history across sessions.
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-cli/quiz"
+	"quiz-cli/storage"
+)
+
+// Record aggregates every recorded attempt at one question.
+type Record struct {
+	Prompt   string    `json:"prompt"`
+	Attempts int       `json:"attempts"`
+	Correct  int       `json:"correct"`
+	LastSeen time.Time `json:"lastSeen,omitempty"`
+	// Timed counts the attempts whose answer time is known, and Seconds
+	// totals those times, for Difficulties.
+	Timed   int     `json:"timed,omitempty"`
+	Seconds float64 `json:"seconds,omitempty"`
+	// Choices counts how often each option was picked, for Distractors.
+	Choices map[string]int `json:"choices,omitempty"`
+	// Days breaks the attempts down by day, for Forecast.
+	Days []DayTally `json:"days,omitempty"`
+	// FirstTries holds the latest first attempts at the question, one per
+	// session, for Intervals.
+	FirstTries []FirstTry `json:"firstTries,omitempty"`
+	// Card is the flashcard schedule, once the question has been studied
+	// as a flashcard.
+	Card *Card `json:"card,omitempty"`
+	// Note is the learner's own note on the question.
+	Note string `json:"note,omitempty"`
+	// BankVersion is the version of the pinned bank at the latest attempt.
+	BankVersion string `json:"bankVersion,omitempty"`
+
+	Flags        int    `json:"flags,omitempty"`
+	UnderReview  bool   `json:"underReview,omitempty"`
+	ReviewReason string `json:"reviewReason,omitempty"`
+}
+
+// Store is a JSON-file backed map of question history keyed by Key. A Store is
+// safe for concurrent use.
+type Store struct {
+	path string
+	// Bank is the bank the history is pinned to, if any (see PinBank).
+	Bank    *quiz.BankInfo     `json:"bank,omitempty"`
+	Records map[string]*Record `json:"records"`
+	// LongestStreak is the best sudden-death run (see RecordStreak).
+	LongestStreak *Streak `json:"longestStreak,omitempty"`
+	// IssueLog holds the issues learners reported with questions (see
+	// ReportIssue).
+	IssueLog []Issue `json:"issues,omitempty"`
+	// Synced marks the last sync with a remote state archive (see Pull).
+	Synced *SyncMark `json:"synced,omitempty"`
+	// Searches holds the recent search terms, oldest first (see AddSearch).
+	Searches []string `json:"searches,omitempty"`
+	// Filters holds the saved question filters by name (see SaveFilter).
+	Filters map[string]Filter `json:"filters,omitempty"`
+	mu      sync.Mutex
+}
+
+// Key returns the store key for q: its ID, so reordering the bank keeps its
+// history. Questions without an ID fall back to the prompt, compared
+// case-insensitively with surrounding whitespace removed.
+func Key(q quiz.Question) string {
+	if q.ID != "" {
+		return q.ID
+	}
+	return keyForPrompt(q.Prompt)
+}
+
+func keyForPrompt(prompt string) string {
+	return strings.ToLower(strings.Join(strings.Fields(prompt), " "))
+}
+
+// find returns the record for q. Histories written before questions had IDs
+// are keyed by prompt; such a record is moved to q's ID the first time it is
+// found. s.mu must be held.
+func (s *Store) find(q quiz.Question) (*Record, bool) {
+	key := Key(q)
+	if rec, ok := s.Records[key]; ok {
+		return rec, true
+	}
+	legacy := keyForPrompt(q.Prompt)
+	rec, ok := s.Records[legacy]
+	if !ok || legacy == key {
+		return nil, false
+	}
+	delete(s.Records, legacy)
+	s.Records[key] = rec
+	return rec, true
+}
+
+// Open loads the store at path, returning an empty store if the file does not
+// exist yet. Paths of the form s3://bucket/key live in object storage (see
+// storage.Resolve).
+func Open(ctx context.Context, path string) (*Store, error) {
+	s := &Store{path: path, Records: map[string]*Record{}}
+	data, err := storage.ReadFile(ctx, path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Records == nil {
+		s.Records = map[string]*Record{}
+	}
+	return s, nil
+}
+
+// Path returns the file the store saves to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Record adds one attempt at q.
+func (s *Store) Record(q quiz.Question, correct bool, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordLocked(q, correct, at)
+}
+
+func (s *Store) recordLocked(q quiz.Question, correct bool, at time.Time) {
+	rec := s.recordFor(q)
+	rec.Attempts++
+	if correct {
+		rec.Correct++
+	}
+	if at.After(rec.LastSeen) {
+		rec.LastSeen = at
+	}
+	rec.addDay(correct, at)
+	if s.Bank != nil {
+		rec.BankVersion = s.Bank.Version
+	}
+}
+
+// RecordTime adds the time taken by an attempt at q already recorded.
+func (s *Store) RecordTime(q quiz.Question, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.recordFor(q)
+	rec.Timed++
+	rec.Seconds += d.Seconds()
+}
+
+// RecordChoice counts option as picked in an attempt at q already recorded.
+// Answers that are not one of q's options are ignored.
+func (s *Store) RecordChoice(q quiz.Question, option string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.choiceLocked(q, option)
+}
+
+func (s *Store) choiceLocked(q quiz.Question, option string) {
+	option = strings.ToUpper(strings.TrimSpace(option))
+	if _, ok := q.Options[option]; !ok {
+		return
+	}
+	rec := s.recordFor(q)
+	if rec.Choices == nil {
+		rec.Choices = map[string]int{}
+	}
+	rec.Choices[option]++
+}
+
+// Lookup returns the history for q, if any.
+func (s *Store) Lookup(q quiz.Question) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.find(q)
+	if !ok {
+		return Record{}, false
+	}
+	out := *rec
+	out.Choices = maps.Clone(rec.Choices)
+	return out, true
+}
+
+// Snapshot returns the store as Save would write it, for a backup taken
+// while answers are being recorded.
+func (s *Store) Snapshot() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Check reports whether the store's location can be read, for readiness
+// probes. A history not written yet counts as reachable.
+func (s *Store) Check(ctx context.Context) error {
+	_, err := storage.ReadFile(ctx, s.path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Save writes the store back to its path. Nothing is written if ctx is done.
+func (s *Store) Save(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(ctx, s.path, data)
+}
+
+// Listener returns a quiz.Listener that records every answer into s: whether
+// it was the session's first attempt, the option picked and the time it
+// took. The store is saved after each answer so an interrupted session keeps
+// its history.
+func (s *Store) Listener() quiz.Listener {
+	return quiz.ListenerFuncs{
+		Answered: func(ctx context.Context, _ int, q quiz.Question, res quiz.Result) {
+			now := time.Now()
+			s.Record(q, res.Correct, now)
+			if !res.Retry {
+				s.RecordFirstTry(q, res.Correct, now)
+			}
+			s.RecordChoice(q, res.UserAnswer)
+			if res.Elapsed > 0 {
+				s.RecordTime(q, res.Elapsed)
+			}
+			// the answer is in the history now, so it is written even if
+			// the run or the request behind ctx has ended since
+			_ = s.Save(context.WithoutCancel(ctx))
+		},
+	}
+}
@@ -0,0 +1,604 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"quiz-cli/quiz"
+	"quiz-cli/storage"
+)
+
+func TestImportCSVMatchesByTextAndID(t *testing.T) {
+	bank := []quiz.Question{
+		{Domain: 1, Prompt: "Sky color?", Answer: "B"},
+		{Domain: 1, Prompt: "Grass color?", Answer: "C"},
+	}
+	csv := "Item,Num,Response,When\n" +
+		"  sky   COLOR? ,,B,2024-01-02\n" +
+		",2,A,\n" +
+		"Unknown prompt,,A,\n"
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	report, err := s.ImportCSV(context.Background(), strings.NewReader(csv), bank, ColumnMap{Question: "item", ID: "num", Answer: "response", Time: "when"})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Imported != 2 || len(report.Unmatched) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if rec, _ := s.Lookup(bank[0]); rec.Attempts != 1 || rec.Correct != 1 || rec.LastSeen.IsZero() {
+		t.Fatalf("sky record = %+v", rec)
+	}
+	if rec, _ := s.Lookup(bank[1]); rec.Attempts != 1 || rec.Correct != 0 {
+		t.Fatalf("grass record = %+v", rec)
+	}
+
+	if err := s.Save(context.Background()); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	reopened, err := Open(context.Background(), s.Path())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if rec, ok := reopened.Lookup(bank[0]); !ok || rec.Correct != 1 {
+		t.Fatalf("record not persisted: %+v", rec)
+	}
+}
+
+func TestListenerSavesAnswerAfterCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := quiz.Question{Domain: 1, Prompt: "Sky color?", Answer: "B"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Listener().OnAnswered(ctx, 0, q, quiz.Result{Correct: true, UserAnswer: "B"})
+	reloaded, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, ok := reloaded.Lookup(q); !ok || rec.Attempts != 1 || rec.Correct != 1 {
+		t.Fatalf("reloaded record = %+v, %v", rec, ok)
+	}
+}
+
+func TestFlagsPutQuestionUnderReview(t *testing.T) {
+	bank := []quiz.Question{{Prompt: "Sky color?"}, {Prompt: "Grass color?"}}
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	policy := ReviewPolicy{MaxFlags: 2}
+	s.Flag(bank[0], policy)
+	if s.UnderReview(bank[0]) {
+		t.Fatalf("one flag should not trigger review")
+	}
+	if rec := s.Flag(bank[0], policy); !rec.UnderReview {
+		t.Fatalf("second flag should trigger review: %+v", rec)
+	}
+	if got := s.ExamQuestions(bank); len(got) != 1 || got[0].Prompt != "Grass color?" {
+		t.Fatalf("exam questions = %+v", got)
+	}
+	queue := s.Reviews()
+	if len(queue) != 1 || !s.Resolve(queue[0].Key) || s.UnderReview(bank[0]) {
+		t.Fatalf("resolve failed: %+v", queue)
+	}
+}
+
+func TestPromptKeyedHistoryMovesToID(t *testing.T) {
+	s := &Store{Records: map[string]*Record{"sky color?": {Prompt: "Sky color?", Attempts: 3}}}
+	q := quiz.Question{ID: "sky", Prompt: "Sky  color?"}
+	if rec, ok := s.Lookup(q); !ok || rec.Attempts != 3 {
+		t.Fatalf("lookup = %+v, %v", rec, ok)
+	}
+	if _, ok := s.Records["sky"]; !ok || len(s.Records) != 1 {
+		t.Fatalf("record not rekeyed: %v", s.Records)
+	}
+}
+
+func TestForecastProjectsPassDate(t *testing.T) {
+	bank := []quiz.Question{{ID: "a", Domain: 4}, {ID: "b", Domain: 4}, {ID: "c", Domain: 5}}
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Domain 4 improves 40% -> 60% over a week; domain 5 was seen once.
+	for day, correct := range map[int]int{0: 4, 7: 6} {
+		for i := 0; i < 10; i++ {
+			s.Record(bank[i%2], i < correct, start.AddDate(0, 0, day))
+		}
+	}
+	s.Record(bank[2], true, start)
+	s.Record(bank[2], true, time.Time{})
+
+	now := start.AddDate(0, 0, 7)
+	fs := s.Forecast(bank, 70, now)
+	if len(fs) != 2 || fs[0].Domain != 4 || fs[1].Domain != 5 {
+		t.Fatalf("forecasts = %+v", fs)
+	}
+	d4 := fs[0]
+	if d4.StudyDays != 2 || d4.Attempts != 20 || d4.Accuracy != 50 || d4.WeeklyGain <= 0 {
+		t.Fatalf("domain 4 = %+v", d4)
+	}
+	if d4.Ready.IsZero() || !d4.Ready.After(now) || d4.Reached(now) {
+		t.Fatalf("domain 4 should pass later, got %v", d4.Ready)
+	}
+	if d5 := fs[1]; d5.Attempts != 1 || !d5.Reached(now) {
+		t.Fatalf("undated attempt counted or domain 5 not ready: %+v", d5)
+	}
+	if got := Behind(fs, d4.Ready.Add(-time.Hour)); len(got) != 1 || got[0] != 4 {
+		t.Fatalf("Behind = %v", got)
+	}
+	if got := Behind(fs, d4.Ready); len(got) != 0 {
+		t.Fatalf("Behind at the pass date = %v", got)
+	}
+}
+
+func TestIntervalsFlagLowerBoundBelowPassMark(t *testing.T) {
+	var bank []quiz.Question
+	for i := 0; i < 30; i++ {
+		bank = append(bank, quiz.Question{ID: fmt.Sprintf("q%d", i), Domain: 1 + i/10})
+	}
+	s, _ := Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(n int) time.Time { return start.Add(time.Duration(n) * time.Minute) }
+	// domain 1: 20 old misses, then 45 of the latest 50 right
+	for i := 0; i < 70; i++ {
+		s.RecordFirstTry(bank[i%10], i >= 20 && i%10 != 0, at(i))
+	}
+	// domain 2: 8 of 10, domain 3: 2 of 10
+	for i := 0; i < 10; i++ {
+		s.RecordFirstTry(bank[10+i], i < 8, at(i))
+		s.RecordFirstTry(bank[20+i], i < 2, at(i))
+	}
+	// retries count toward the totals but not the first attempts
+	s.Listener().OnAnswered(context.Background(), 0, bank[29], quiz.Result{Correct: true, Retry: true})
+
+	ds := s.Intervals(bank, DefaultIntervalPolicy)
+	if len(ds) != 3 {
+		t.Fatalf("intervals = %+v", ds)
+	}
+	want := []struct {
+		answers, correct int
+		lower, upper     float64
+		verdict          Verdict
+	}{
+		{50, 45, 78.6, 95.7, Ready},
+		{10, 8, 49.0, 94.3, AtRisk},
+		{10, 2, 5.7, 51.0, NotReady},
+	}
+	for i, w := range want {
+		d := ds[i]
+		if d.Domain != i+1 || d.Answers != w.answers || d.Correct != w.correct || d.Verdict != w.verdict ||
+			math.Abs(d.Lower-w.lower) > 0.1 || math.Abs(d.Upper-w.upper) > 0.1 {
+			t.Fatalf("domain %d = %+v, want %+v", i+1, d, w)
+		}
+	}
+	extra := quiz.Question{ID: "extra"}
+	for i := 0; i < maxFirstTries+5; i++ {
+		s.RecordFirstTry(extra, true, at(i))
+	}
+	if rec, _ := s.Lookup(extra); len(rec.FirstTries) != maxFirstTries {
+		t.Fatalf("kept %d first attempts, want %d", len(rec.FirstTries), maxFirstTries)
+	}
+	if err := (IntervalPolicy{PassMark: 70, Recent: 50, Level: 1}).Check(); err == nil {
+		t.Fatal("accepted a 100% confidence level")
+	}
+}
+
+func TestPlanSchedulesWeakDomainsReviewAndMock(t *testing.T) {
+	bank := []quiz.Question{{ID: "a", Domain: 4}, {ID: "b", Domain: 4}, {ID: "c", Domain: 5}, {ID: "d", Domain: 5}, {ID: "e", Domain: 5}}
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+	// Domain 4 is mastered; domain 5 has one miss and two unseen questions.
+	s.Record(bank[0], true, now)
+	s.Record(bank[1], true, now)
+	s.Record(bank[2], false, now)
+
+	plan, err := s.Plan(bank, now.AddDate(0, 0, 8), now, 40)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(plan.Days) != 8 || !plan.Days[0].Date.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("days = %+v", plan.Days)
+	}
+	if d := plan.Days[0]; d.Kind != PlanPractice || d.Domain != 5 || d.Questions != 3 {
+		t.Fatalf("first day = %+v, want domain 5 practice", d)
+	}
+	if d := plan.Days[3]; d.Kind != PlanReview || d.Questions != 1 {
+		t.Fatalf("day 4 = %+v, want review", d)
+	}
+	if d := plan.Days[7]; d.Kind != PlanMock || d.Questions != 5 {
+		t.Fatalf("last day = %+v, want mock exam", d)
+	}
+	if d5 := plan.Domains[1]; d5.Unseen != 2 || d5.Missed != 1 || d5.Days <= plan.Domains[0].Days {
+		t.Fatalf("domains = %+v", plan.Domains)
+	}
+	if _, err := s.Plan(bank, now, now, 40); err == nil {
+		t.Fatalf("plan for an exam today succeeded")
+	}
+}
+
+func TestFlashcardScheduling(t *testing.T) {
+	bank := []quiz.Question{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if c := s.Review(bank[0], quiz.Good, now); c.Interval != 1 || !c.Due.Equal(now.AddDate(0, 0, 1)) {
+		t.Fatalf("first good = %+v", c)
+	}
+	if c := s.Review(bank[0], quiz.Good, now.AddDate(0, 0, 1)); c.Interval != 3 {
+		t.Fatalf("second good = %+v", c)
+	}
+	if c := s.Review(bank[1], quiz.Again, now); c.Lapses != 1 || c.Ease != 2.3 || !c.Due.Equal(now) {
+		t.Fatalf("again = %+v", c)
+	}
+
+	// b is due now, a is not, c is new
+	got := s.DueCards(bank, now, 5)
+	if len(got) != 2 || got[0].ID != "b" || got[1].ID != "c" {
+		t.Fatalf("due = %+v", got)
+	}
+	if got := s.DueCards(bank, now, 0); len(got) != 1 {
+		t.Fatalf("due without new cards = %+v", got)
+	}
+	if next := s.NextDue(bank, now); !next.Equal(now.AddDate(0, 0, 4)) {
+		t.Fatalf("next due = %v", next)
+	}
+}
+
+func TestNotesPersistAndExport(t *testing.T) {
+	bank := []quiz.Question{
+		{ID: "q1", Domain: 4, Prompt: "Sky color?", Answer: "B"},
+		{Domain: 5, Prompt: "Grass color?", Answer: "C"},
+	}
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetNote(bank[1], "  chlorophyll  ")
+	s.SetNote(bank[0], "Rayleigh scattering")
+	s.SetNote(bank[0], "")
+	s.SetNote(bank[0], "Think **Rayleigh**")
+	if err := s.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Note(bank[1]); got != "chlorophyll" {
+		t.Fatalf("Note = %q", got)
+	}
+	notes := s.Notes(bank)
+	if len(notes) != 2 || notes[0].Key != "q1" {
+		t.Fatalf("Notes = %+v", notes)
+	}
+	var md strings.Builder
+	if err := WriteNotesMarkdown(&md, notes); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## q1 (Domain 4)\n\n> Sky color?\n\nThink **Rayleigh**\n", "## Note 2 (Domain 5)\n\n> Grass color?\n\nchlorophyll\n"} {
+		if !strings.Contains(md.String(), want) {
+			t.Fatalf("Markdown lacks %q:\n%s", want, md.String())
+		}
+	}
+}
+
+func TestBankPinningReportsChangesAndRemaps(t *testing.T) {
+	v1 := []quiz.Question{
+		{Domain: 1, Prompt: "Which color is the sky on a clear day?", Answer: "B"},
+		{ID: "grass", Domain: 1, Prompt: "Grass color?", Answer: "C"},
+		{ID: "gone", Domain: 1, Prompt: "Dropped question?", Answer: "A"},
+	}
+	quiz.AssignIDs(v1)
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := quiz.BankInfo{Name: "Colors", Version: "1"}
+	if c := s.CheckBank(info, v1); c.Changed() {
+		t.Fatalf("unpinned history reported a change: %+v", c)
+	}
+	s.PinBank(info)
+	for _, q := range v1 {
+		s.Record(q, true, time.Now())
+	}
+	if rec, _ := s.Lookup(v1[1]); rec.BankVersion != "1" {
+		t.Fatalf("bank version = %q, want 1", rec.BankVersion)
+	}
+	if err := s.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// v2 repunctuated the sky question (so its derived ID changed),
+	// edited the grass one in place and dropped the third.
+	v2 := []quiz.Question{
+		{Domain: 1, Prompt: "Which color is the sky, on a clear day?", Answer: "B"},
+		{ID: "grass", Domain: 1, Prompt: "What color is grass?", Answer: "C"},
+	}
+	quiz.AssignIDs(v2)
+	s, _ = Open(context.Background(), path)
+	c := s.CheckBank(quiz.BankInfo{Name: "Colors", Version: "2"}, v2)
+	if !c.Changed() || c.Pinned.Version != "1" {
+		t.Fatalf("check = %+v", c)
+	}
+	if len(c.Edited) != 1 || c.Edited[0].ID != "grass" {
+		t.Fatalf("edited = %+v", c.Edited)
+	}
+	if len(c.Missing) != 2 || len(c.Remap) != 1 || c.Remap[v1[0].ID] != v2[0].ID {
+		t.Fatalf("missing = %v, remap = %v", c.Missing, c.Remap)
+	}
+	if n := s.Remap(c.Remap); n != 1 {
+		t.Fatalf("moved %d records", n)
+	}
+	if rec, ok := s.Lookup(v2[0]); !ok || rec.Attempts != 1 {
+		t.Fatalf("remapped history = %+v, %v", rec, ok)
+	}
+}
+
+func TestLongestStreakPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, _ := Open(context.Background(), path)
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	if best, isNew := s.RecordStreak(7, day); !isNew || best.Length != 7 {
+		t.Fatalf("first streak = %+v, %v", best, isNew)
+	}
+	if best, isNew := s.RecordStreak(4, day.Add(time.Hour)); isNew || best.Length != 7 {
+		t.Fatalf("shorter streak = %+v, %v", best, isNew)
+	}
+	if err := s.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = Open(context.Background(), path)
+	if s.LongestStreak == nil || s.LongestStreak.Length != 7 || !s.LongestStreak.At.Equal(day) {
+		t.Fatalf("reloaded streak = %+v", s.LongestStreak)
+	}
+}
+
+func TestDifficultiesPoolLearnersHardestFirst(t *testing.T) {
+	bank := []quiz.Question{{ID: "easy"}, {ID: "hard"}, {ID: "unseen"}, {ID: "slow"}}
+	a, _ := Open(context.Background(), filepath.Join(t.TempDir(), "a.json"))
+	b, _ := Open(context.Background(), filepath.Join(t.TempDir(), "b.json"))
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		a.Record(bank[0], true, now)
+		b.Record(bank[1], i == 0, now)
+	}
+	a.Record(bank[1], false, now)
+	for _, s := range []*Store{a, b} {
+		s.Record(bank[3], true, now)
+		s.Record(bank[3], true, now)
+		s.RecordTime(bank[3], 40*time.Second)
+	}
+	a.RecordTime(bank[0], 10*time.Second)
+
+	ds := Difficulties(bank, a, b)
+	if len(ds) != 3 || ds[0].ID != "hard" || ds[0].Attempts != 5 || ds[0].Misses != 4 || ds[0].MissRate != 80 {
+		t.Fatalf("difficulties = %+v", ds)
+	}
+	if ds[1].ID != "slow" || ds[1].AvgSeconds != 40 || ds[2].ID != "easy" {
+		t.Fatalf("difficulties = %+v", ds)
+	}
+	if got := HardestFirst(bank, a, b); !slices.Equal(got, []int{1, 2, 3, 0}) {
+		t.Fatalf("hardest first = %v", got)
+	}
+	if got := Bands(bank, a, b); got != (DifficultyBands{Easy: 2, Hard: 1, Unseen: 1}) {
+		t.Fatalf("bands = %+v", got)
+	}
+}
+
+func TestAbilityWeighsAnswersByDifficulty(t *testing.T) {
+	bank := []quiz.Question{
+		{ID: "easy1", Domain: 1}, {ID: "hard1", Domain: 1},
+		{ID: "easy2", Domain: 2}, {ID: "hard2", Domain: 2},
+		{ID: "other", Domain: 3},
+	}
+	class, _ := Open(context.Background(), filepath.Join(t.TempDir(), "class.json"))
+	me, _ := Open(context.Background(), filepath.Join(t.TempDir(), "me.json"))
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		for _, q := range bank[:4] {
+			class.Record(q, strings.HasPrefix(q.ID, "easy") || i == 0, now)
+		}
+	}
+	// half right in both domains, but on the hard question in domain 1 and
+	// the easy one in domain 2
+	for _, correct := range []bool{true, false} {
+		me.Record(bank[1], correct, now)
+		me.Record(bank[2], correct, now)
+	}
+
+	as := Abilities(bank, me, class)
+	if len(as) != 2 || as[0].Domain != 1 || as[1].Domain != 2 {
+		t.Fatalf("abilities = %+v", as)
+	}
+	for _, a := range as {
+		if a.Raw() != 50 || a.Questions != 1 || a.Total != 2 || a.StdErr <= 0 {
+			t.Fatalf("domain %d = %+v", a.Domain, a)
+		}
+	}
+	if as[0].Theta <= as[1].Theta || as[0].Expected <= as[1].Expected {
+		t.Fatalf("answering the hard question should count for more: %+v", as)
+	}
+}
+
+func TestDistractorsFlagTrapsAndUnpickedOptions(t *testing.T) {
+	q := quiz.Question{ID: "q1", Options: map[string]string{"A": "a", "B": "b", "C": "c", "D": "d"}, Answer: "B"}
+	other := quiz.Question{ID: "q2", Options: map[string]string{"A": "a", "B": "b"}, Answer: "A"}
+	s, _ := Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	listener := s.Listener()
+	for i, pick := range strings.Split("AAAAAABBBC", "") {
+		listener.OnAnswered(context.Background(), i, q, quiz.Result{UserAnswer: pick, Correct: pick == "B"})
+	}
+	s.RecordChoice(other, "B")
+	s.RecordChoice(other, "Z") // not an option
+
+	rs := Distractors([]quiz.Question{other, q}, DefaultDistractorPolicy, s)
+	if len(rs) != 2 || rs[0].ID != "q1" || rs[0].Answers != 10 || rs[0].Trap != "A" || !slices.Equal(rs[0].Unpicked, []string{"D"}) {
+		t.Fatalf("reports = %+v", rs)
+	}
+	if rs[0].Options[0].Percent != 60 || !rs[0].Options[1].Correct {
+		t.Fatalf("options = %+v", rs[0].Options)
+	}
+	if rs[1].Answers != 1 || rs[1].Trap != "" || rs[1].Unpicked != nil {
+		t.Fatalf("too few answers judged: %+v", rs[1])
+	}
+}
+
+func TestStateArchiveMergesNewerRecords(t *testing.T) {
+	bank := []quiz.Question{{ID: "a", Prompt: "A?"}, {ID: "b", Prompt: "B?"}, {ID: "c", Prompt: "C?"}}
+	ctx := context.Background()
+	dir := t.TempDir()
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	desktop, _ := Open(ctx, filepath.Join(dir, "desktop.json"))
+	desktop.Record(bank[0], true, day.AddDate(0, 0, 2))
+	desktop.Record(bank[1], false, day)
+	desktop.SetNote(bank[1], "watch the wording")
+	desktop.Review(bank[2], quiz.Good, day)
+	desktop.ReportIssue(bank[0], "typo", "", day)
+	desktop.RecordStreak(5, day)
+	var archive bytes.Buffer
+	if err := desktop.WriteArchive(&archive, day.AddDate(0, 0, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	laptop, _ := Open(ctx, filepath.Join(dir, "laptop.json"))
+	laptop.Record(bank[0], false, day)
+	laptop.Record(bank[1], true, day.AddDate(0, 0, 3))
+	laptop.Record(bank[1], true, day.AddDate(0, 0, 3))
+	laptop.ReportIssue(bank[0], "typo", "", day)
+	laptop.ReportIssue(bank[1], "two right answers", "sam", day.AddDate(0, 0, 1))
+
+	a, err := ReadArchive(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := laptop.MergeArchive(a)
+	if report != (MergeReport{Added: 1, Updated: 1, Kept: 1}) {
+		t.Fatalf("report = %+v", report)
+	}
+	if rec, _ := laptop.Lookup(bank[0]); rec.Attempts != 1 || rec.Correct != 1 {
+		t.Fatalf("a = %+v, want the desktop's newer record", rec)
+	}
+	if rec, _ := laptop.Lookup(bank[1]); rec.Attempts != 2 || rec.Note != "watch the wording" {
+		t.Fatalf("b = %+v, want the laptop's record with the desktop's note", rec)
+	}
+	if rec, _ := laptop.Lookup(bank[2]); rec.Card == nil || rec.Card.Reps != 1 {
+		t.Fatalf("c = %+v, want the desktop's card", rec)
+	}
+	if issues := laptop.Issues(); len(issues) != 2 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if laptop.LongestStreak == nil || laptop.LongestStreak.Length != 5 {
+		t.Fatalf("streak = %+v", laptop.LongestStreak)
+	}
+
+	if _, err := ReadArchive(strings.NewReader(`{"records": {}}`)); err == nil {
+		t.Fatal("a history file read as an archive")
+	}
+}
+
+func TestPullAndPushReportConflicts(t *testing.T) {
+	bank := []quiz.Question{{ID: "a", Prompt: "A?"}, {ID: "b", Prompt: "B?"}}
+	ctx := context.Background()
+	dir := t.TempDir()
+	remote := storage.FileStore{Dir: dir}
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	desktop, _ := Open(ctx, filepath.Join(dir, "desktop.json"))
+	laptop, _ := Open(ctx, filepath.Join(dir, "laptop.json"))
+	if r, err := laptop.Pull(ctx, remote, "state.gz", day); err != nil || r.Pulled {
+		t.Fatalf("pull before any push = %+v, %v", r, err)
+	}
+	desktop.Record(bank[0], true, day)
+	if r, err := desktop.Push(ctx, remote, "state.gz", day.Add(time.Hour)); err != nil || !r.Pushed {
+		t.Fatalf("first push = %+v, %v", r, err)
+	}
+	if r, err := laptop.Pull(ctx, remote, "state.gz", day.Add(2*time.Hour)); err != nil || !r.Pulled || r.Merge.Added != 1 {
+		t.Fatalf("laptop pull = %+v, %v", r, err)
+	}
+	if r, _ := laptop.Pull(ctx, remote, "state.gz", day.Add(3*time.Hour)); r.Pulled {
+		t.Fatalf("pulled an unchanged archive: %+v", r)
+	}
+
+	// Both study a after the sync; the desktop pushes first.
+	laptop.Record(bank[0], false, day.Add(4*time.Hour))
+	laptop.Record(bank[1], true, day.Add(4*time.Hour))
+	desktop.Record(bank[0], true, day.Add(5*time.Hour))
+	if _, err := desktop.Push(ctx, remote, "state.gz", day.Add(6*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := laptop.Push(ctx, remote, "state.gz", day.Add(7*time.Hour))
+	if err != nil || !r.Pulled || !r.Pushed || !slices.Equal(r.Conflicts, []string{"a"}) {
+		t.Fatalf("laptop push = %+v, %v", r, err)
+	}
+	if rec, _ := laptop.Lookup(bank[0]); rec.Correct != 2 {
+		t.Fatalf("a = %+v, want the desktop's later answers", rec)
+	}
+	if r, err := desktop.Pull(ctx, remote, "state.gz", day.Add(8*time.Hour)); err != nil || r.Merge.Added != 1 || len(r.Conflicts) != 0 {
+		t.Fatalf("desktop pull = %+v, %v", r, err)
+	}
+}
+
+func TestSavedFilterSelectsMissedQuestions(t *testing.T) {
+	bank := []quiz.Question{
+		{ID: "a", Domain: 4, Prompt: "Which cipher is symmetric?", Options: map[string]string{"A": "AES", "B": "RSA"}, Tags: []string{"crypto"}},
+		{ID: "b", Domain: 4, Prompt: "Pick the hash", Options: map[string]string{"A": "SHA-256", "B": "AES"}, Tags: []string{"crypto"}},
+		{ID: "c", Domain: 5, Prompt: "Which AES mode is authenticated?", Options: map[string]string{"A": "GCM", "B": "ECB"}},
+	}
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range bank {
+		s.Record(q, false, time.Now())
+	}
+	s.Record(bank[0], false, time.Now())
+	s.Record(bank[2], false, time.Now())
+	s.SaveFilter("crypto", Filter{Search: "aes", Tags: []string{"Crypto"}, MinMisses: 2})
+	for i := 0; i < MaxSearches+2; i++ {
+		s.AddSearch(fmt.Sprint("term ", i))
+	}
+	s.AddSearch("term 5")
+	if err := s.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := s.SavedFilter("crypto")
+	if !ok {
+		t.Fatal("filter not saved")
+	}
+	if got := s.Select(bank, f); len(got) != 1 || got[0].ID != "a" {
+		t.Fatalf("Select = %v, want only the crypto question mentioning AES missed twice", got)
+	}
+	searches := s.RecentSearches()
+	if len(searches) != MaxSearches || searches[0] != "term 2" || searches[len(searches)-1] != "term 5" {
+		t.Fatalf("searches %q, want the last %d with term 5 most recent", searches, MaxSearches)
+	}
+}
@@ -0,0 +1,42 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// Streak is a sudden-death run: the correct answers in a row before the
+// first miss (see quiz.Session.UseSuddenDeath).
+type Streak struct {
+	Length int       `json:"length"`
+	At     time.Time `json:"at"`
+}
+
+// RecordStreak keeps a sudden-death run of length correct answers if it is
+// the longest so far, and returns the longest run (zero if there is none) and
+// whether this one is it.
+func (s *Store) RecordStreak(length int, at time.Time) (longest Streak, isNew bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.LongestStreak != nil {
+		longest = *s.LongestStreak
+	}
+	if length <= longest.Length {
+		return longest, false
+	}
+	s.LongestStreak = &Streak{Length: length, At: at}
+	return *s.LongestStreak, true
+}
+
+// StreakListener returns a quiz.Listener that records the score of each
+// finished sudden-death session as a streak and saves the store.
+func (s *Store) StreakListener() quiz.Listener {
+	return quiz.ListenerFuncs{
+		Finished: func(ctx context.Context, score, _ int) {
+			s.RecordStreak(score, time.Now())
+			_ = s.Save(ctx)
+		},
+	}
+}
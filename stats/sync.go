@@ -0,0 +1,306 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	"quiz-cli/quiz"
+	"quiz-cli/storage"
+)
+
+// archiveFormat marks a state archive, so import-state can tell one from a
+// history file or any other JSON.
+const archiveFormat = "quiz-cli-state"
+
+// archiveVersion is the archive layout WriteArchive writes; ReadArchive
+// refuses newer ones.
+const archiveVersion = 1
+
+// Archive is a portable copy of a store: every record with its history,
+// flashcard schedule, note and flags, the pinned bank, the longest streak,
+// the reported issues and the saved filters. WriteArchive writes it gzipped.
+type Archive struct {
+	Format        string             `json:"format"`
+	Version       int                `json:"version"`
+	Exported      time.Time          `json:"exported"`
+	Bank          *quiz.BankInfo     `json:"bank,omitempty"`
+	Records       map[string]*Record `json:"records"`
+	LongestStreak *Streak            `json:"longestStreak,omitempty"`
+	Issues        []Issue            `json:"issues,omitempty"`
+	Filters       map[string]Filter  `json:"filters,omitempty"`
+}
+
+// MergeReport counts what MergeArchive did with the archive's records.
+type MergeReport struct {
+	// Added records were only in the archive.
+	Added int
+	// Updated records were studied more recently in the archive and replaced
+	// the store's.
+	Updated int
+	// Kept records were studied at least as recently in the store.
+	Kept int
+	// Issues counts the reported issues new to the store.
+	Issues int
+}
+
+// WriteArchive writes the whole store to w as a gzipped archive stamped with
+// at.
+func (s *Store) WriteArchive(w io.Writer, at time.Time) error {
+	s.mu.Lock()
+	a := Archive{
+		Format:        archiveFormat,
+		Version:       archiveVersion,
+		Exported:      at,
+		Bank:          s.Bank,
+		Records:       s.Records,
+		LongestStreak: s.LongestStreak,
+		Issues:        s.IssueLog,
+		Filters:       s.Filters,
+	}
+	data, err := json.Marshal(a)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadArchive reads an archive written by WriteArchive. An archive that was
+// unzipped along the way is read as plain JSON.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		src = zr
+	}
+	var a Archive
+	if err := json.NewDecoder(src).Decode(&a); err != nil {
+		return nil, fmt.Errorf("not a state archive: %w", err)
+	}
+	if a.Format != archiveFormat {
+		return nil, fmt.Errorf("not a state archive (written by export-state)")
+	}
+	if a.Version > archiveVersion {
+		return nil, fmt.Errorf("state archive version %d is newer than this build reads (%d)", a.Version, archiveVersion)
+	}
+	if a.Records == nil {
+		a.Records = map[string]*Record{}
+	}
+	return &a, nil
+}
+
+// ReplaceWith makes the store a copy of a, dropping its own state.
+func (s *Store) ReplaceWith(a *Archive) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Bank = a.Bank
+	s.Records = a.Records
+	s.LongestStreak = a.LongestStreak
+	s.IssueLog = a.Issues
+	s.Filters = a.Filters
+}
+
+// MergeArchive folds a into the store, for picking up on one machine where
+// another left off. Of two records for the same question, the one studied
+// more recently wins whole, since both usually carry the history they shared
+// at the last sync; a note or flashcard schedule only the other one has is
+// kept. Issues are merged without duplicates, the longer streak is kept, a
+// filter is added unless one of the same name is saved already, and a store
+// not pinned to a bank takes the archive's pin.
+func (s *Store) MergeArchive(a *Archive) MergeReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var report MergeReport
+	for key, theirs := range a.Records {
+		ours, ok := s.Records[key]
+		switch {
+		case !ok:
+			s.Records[key] = theirs
+			report.Added++
+		case lastActive(theirs).After(lastActive(ours)):
+			s.Records[key] = theirs
+			fillIn(theirs, ours)
+			report.Updated++
+		default:
+			fillIn(ours, theirs)
+			report.Kept++
+		}
+	}
+	seen := map[issueKey]bool{}
+	for _, is := range s.IssueLog {
+		seen[keyOf(is)] = true
+	}
+	for _, is := range a.Issues {
+		if !seen[keyOf(is)] {
+			seen[keyOf(is)] = true
+			s.IssueLog = append(s.IssueLog, is)
+			report.Issues++
+		}
+	}
+	slices.SortStableFunc(s.IssueLog, func(x, y Issue) int { return x.At.Compare(y.At) })
+	if a.LongestStreak != nil && (s.LongestStreak == nil || a.LongestStreak.Length > s.LongestStreak.Length) {
+		s.LongestStreak = a.LongestStreak
+	}
+	for name, f := range a.Filters {
+		if _, ok := s.Filters[name]; !ok {
+			if s.Filters == nil {
+				s.Filters = map[string]Filter{}
+			}
+			s.Filters[name] = f
+		}
+	}
+	if s.Bank == nil {
+		s.Bank = a.Bank
+	}
+	return report
+}
+
+// issueKey identifies a reported issue however its time was stored.
+type issueKey struct {
+	key, comment, reporter string
+	at                     int64
+}
+
+func keyOf(is Issue) issueKey {
+	return issueKey{is.Key, is.Comment, is.Reporter, is.At.UnixNano()}
+}
+
+// lastActive is when rec was last studied: its latest attempt or flashcard
+// review, whichever is later.
+func lastActive(rec *Record) time.Time {
+	at := rec.LastSeen
+	if rec.Card != nil {
+		reviewed := rec.Card.Due.Add(-time.Duration(rec.Card.Interval * float64(24*time.Hour)))
+		if reviewed.After(at) {
+			at = reviewed
+		}
+	}
+	return at
+}
+
+// fillIn copies into rec the note and flashcard schedule only other has.
+func fillIn(rec, other *Record) {
+	if rec.Note == "" {
+		rec.Note = other.Note
+	}
+	if rec.Card == nil && other.Card != nil {
+		card := *other.Card
+		rec.Card = &card
+	}
+}
+
+// SyncMark records a store's last sync with a remote state archive.
+type SyncMark struct {
+	// Remote is the export time of the remote archive as of the sync.
+	Remote time.Time `json:"remote"`
+	// At is when the sync happened, by this machine's clock.
+	At time.Time `json:"at"`
+}
+
+// SyncReport says what Pull or Push did.
+type SyncReport struct {
+	// Pulled is set when the remote archive had changes to merge in.
+	Pulled bool
+	Merge  MergeReport
+	// Conflicts lists the keys of questions studied both here and on another
+	// machine since the last sync. The more recently studied side was kept.
+	Conflicts []string
+	Pushed    bool
+}
+
+// Pull merges in the state archive at key in remote when it has changed
+// since the last sync, and marks the store synced at now. A missing archive
+// is not an error; there is nothing to pull yet. remote can be any
+// storage.Store: S3, WebDAV, or a directory another tool syncs.
+func (s *Store) Pull(ctx context.Context, remote storage.Store, key string, now time.Time) (SyncReport, error) {
+	var report SyncReport
+	data, err := remote.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	a, err := ReadArchive(bytes.NewReader(data))
+	if err != nil {
+		return report, err
+	}
+	s.mu.Lock()
+	mark := s.Synced
+	s.mu.Unlock()
+	if mark != nil && !a.Exported.After(mark.Remote) {
+		return report, nil
+	}
+	report.Conflicts = s.conflicts(a, mark)
+	report.Merge = s.MergeArchive(a)
+	report.Pulled = true
+	s.mu.Lock()
+	s.Synced = &SyncMark{Remote: a.Exported, At: now}
+	s.mu.Unlock()
+	return report, nil
+}
+
+// Push writes the store as a state archive to key in remote, stamped with
+// now. It pulls first, so answers another machine pushed in the meantime are
+// merged rather than overwritten.
+func (s *Store) Push(ctx context.Context, remote storage.Store, key string, now time.Time) (SyncReport, error) {
+	report, err := s.Pull(ctx, remote, key, now)
+	if err != nil {
+		return report, err
+	}
+	var buf bytes.Buffer
+	if err := s.WriteArchive(&buf, now); err != nil {
+		return report, err
+	}
+	if err := remote.Put(ctx, key, buf.Bytes()); err != nil {
+		return report, err
+	}
+	report.Pushed = true
+	s.mu.Lock()
+	s.Synced = &SyncMark{Remote: now, At: now}
+	s.mu.Unlock()
+	return report, nil
+}
+
+// conflicts returns the keys of records that differ between the store and a
+// and were studied on both sides since mark; with no mark, every record that
+// differs.
+func (s *Store) conflicts(a *Archive, mark *SyncMark) []string {
+	var since time.Time
+	if mark != nil {
+		since = mark.At
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for key, theirs := range a.Records {
+		ours, ok := s.Records[key]
+		if !ok || reflect.DeepEqual(ours, theirs) {
+			continue
+		}
+		if lastActive(ours).After(since) && lastActive(theirs).After(since) {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,118 @@
+// Package storage reads and writes the tool's files (question banks, answer
+// history, exports) on local disk, on a WebDAV server, or in S3-compatible
+// object storage, so stateless containers can run without persistent volumes.
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned by Get when the object does not exist.
+var ErrNotFound = errors.New("storage: not found")
+
+// Store is a flat key/value blob store.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// FileStore stores objects as files under Dir.
+type FileStore struct {
+	Dir string
+}
+
+// Get reads the file for key.
+func (f FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(f.Dir, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put writes data to the file for key, replacing it atomically. Missing
+// directories are created, as WebDAVStore creates collections.
+func (f FileStore) Put(ctx context.Context, key string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path := filepath.Join(f.Dir, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Resolve maps a location to a Store and key. "s3://bucket/path/key" uses
+// S3 configured from the environment (see NewS3FromEnv); "davs://host/path"
+// and "dav://host/path" use WebDAV over HTTPS or plain HTTP (see NewWebDAV);
+// anything else is a local file path.
+func Resolve(location string) (Store, string, error) {
+	for scheme, web := range map[string]string{"davs://": "https://", "dav://": "http://"} {
+		if rest, ok := strings.CutPrefix(location, scheme); ok {
+			host, key, _ := strings.Cut(rest, "/")
+			if host == "" || key == "" {
+				return nil, "", errors.New("storage: webdav location must be " + scheme + "host/path")
+			}
+			dav, err := NewWebDAV(web + host)
+			if err != nil {
+				return nil, "", err
+			}
+			return dav, key, nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(location, "s3://"); ok {
+		bucket, key, _ := strings.Cut(rest, "/")
+		if bucket == "" || key == "" {
+			return nil, "", errors.New("storage: s3 location must be s3://bucket/key")
+		}
+		s3, err := NewS3FromEnv(bucket)
+		if err != nil {
+			return nil, "", err
+		}
+		return s3, key, nil
+	}
+	return FileStore{Dir: filepath.Dir(location)}, filepath.Base(location), nil
+}
+
+// ReadFile reads the object at location.
+func ReadFile(ctx context.Context, location string) ([]byte, error) {
+	store, key, err := Resolve(location)
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(ctx, key)
+}
+
+// WriteFile writes data to the object at location.
+func WriteFile(ctx context.Context, location string, data []byte) error {
+	store, key, err := Resolve(location)
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, key, data)
+}
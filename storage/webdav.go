@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// WebDAVStore stores objects on a WebDAV server such as Nextcloud, ownCloud
+// or Apache mod_dav, under BaseURL.
+type WebDAVStore struct {
+	// BaseURL is the collection keys are relative to, e.g.
+	// "https://cloud.example.com/remote.php/dav/files/me".
+	BaseURL  string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// NewWebDAV configures a WebDAVStore for baseURL, taking credentials from
+// the URL's user info or else from WEBDAV_USERNAME and WEBDAV_PASSWORD.
+func NewWebDAV(baseURL string) (*WebDAVStore, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	d := &WebDAVStore{Username: os.Getenv("WEBDAV_USERNAME"), Password: os.Getenv("WEBDAV_PASSWORD")}
+	if u.User != nil {
+		d.Username = u.User.Username()
+		d.Password, _ = u.User.Password()
+		u.User = nil
+	}
+	d.BaseURL = strings.TrimSuffix(u.String(), "/")
+	return d, nil
+}
+
+// Get downloads the object at key.
+func (d *WebDAVStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := d.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, webdavError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put uploads data to key. When the server reports that the collection key
+// belongs in is missing, it is created and the upload retried.
+func (d *WebDAVStore) Put(ctx context.Context, key string, data []byte) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := d.do(ctx, http.MethodPut, key, data)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusNoContent:
+			return nil
+		case resp.StatusCode == http.StatusConflict && attempt == 0:
+			if err := d.makeCollections(ctx, path.Dir(key)); err != nil {
+				return err
+			}
+		default:
+			return webdavError(resp)
+		}
+	}
+}
+
+// makeCollections creates dir and its parents, skipping those that exist.
+func (d *WebDAVStore) makeCollections(ctx context.Context, dir string) error {
+	if dir == "." || dir == "/" || dir == "" {
+		return nil
+	}
+	if err := d.makeCollections(ctx, path.Dir(dir)); err != nil {
+		return err
+	}
+	resp, err := d.do(ctx, "MKCOL", dir+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	// 405 Method Not Allowed means the collection is already there.
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return webdavError(resp)
+	}
+	return nil
+}
+
+func webdavError(resp *http.Response) error {
+	return fmt.Errorf("storage: webdav %s %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+}
+
+func (d *WebDAVStore) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.BaseURL+"/"+strings.Join(segments, "/"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	req.ContentLength = int64(len(body))
+	if d.Username != "" {
+		req.SetBasicAuth(d.Username, d.Password)
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
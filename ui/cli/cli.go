@@ -0,0 +1,1083 @@
+// Package cli implements the interactive terminal frontend for a quiz.Session.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"quiz-cli/autosave"
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+	"quiz-cli/replay"
+)
+
+// App is an interactive terminal quiz over a fixed question set. The zero value
+// is not usable; construct one with New.
+type App struct {
+	questions  []quiz.Question
+	session    *quiz.Session
+	in         *bufio.Reader
+	out        io.Writer
+	term       Terminal
+	restoreRaw func()
+	listeners  []quiz.Listener
+	notice     func(quiz.Question) string
+	passMark   float64
+	penalty    float64
+	sudden     bool
+	order      []int
+	ordering   quiz.Ordering
+	replay     *replay.Recorder
+	autosave   *autosave.Saver
+	resume     *quiz.State
+	feedback   quiz.Feedback
+	breaks     quiz.Breaks
+	// pending is closed once input waited for by waitInput arrives; reads
+	// wait for it first so only one goroutine uses in at a time.
+	pending    chan struct{}
+	resultOut  io.Writer
+	summaryOut io.Writer
+	signals    bool
+	imageMode  ImageMode
+	mediaDir   string
+	sections   []quiz.Section
+	confidence bool
+	confirm    bool
+	// domainBars adds a mini progress bar per domain to the header.
+	domainBars bool
+	grader     func(quiz.Question, quiz.Grade)
+	noteGet    func(quiz.Question) string
+	noteSet    func(quiz.Question, string)
+	seed       int64
+	seeded     bool
+	// searches are the earlier search terms, oldest first, and searched
+	// keeps each new one (see WithSearchHistory).
+	searches []string
+	searched func(string)
+	// compact forces the compact layout (see WithCompactLayout).
+	compact bool
+	// hideAccuracy leaves the running accuracy out of the progress line
+	// (see WithoutAccuracy).
+	hideAccuracy bool
+	// masteryStop ends the run early (see WithMasteryStop).
+	masteryStop *quiz.MasteryStop
+	// pacing shrinks each question's time limit over the run (see
+	// WithPacing).
+	pacing *quiz.Pacing
+	// mouse turns on mouse reporting on the question screen (see
+	// WithMouse); mouseOn is set while it is on.
+	mouse   bool
+	mouseOn bool
+	// remote is the server session RunRemote is driving, if any.
+	remote *remote
+	// quit is set once the learner has quit with q, so the run ends as
+	// interrupted instead of reporting that input ended.
+	quit bool
+	// interrupt stops the run as its interrupt handler does, for Ctrl+C
+	// read as a key, and stop is closed once it has (see catchInterrupts).
+	// sigs, when set, delivers the signals in place of the process's.
+	interrupt func()
+	stop      chan struct{}
+	sigs      <-chan os.Signal
+	// startedAt, spent and tries time the current run for the JSON summary.
+	startedAt time.Time
+	spent     []time.Duration
+	tries     []int
+	mu        sync.Mutex
+}
+
+// Option configures an App.
+type Option func(*App)
+
+// WithIO reads keys and lines from in and draws to out instead of the process's
+// standard streams.
+func WithIO(in io.Reader, out io.Writer) Option {
+	return func(a *App) {
+		a.in = bufio.NewReader(in)
+		a.out = out
+		f, isFile := in.(*os.File)
+		a.signals = isFile
+		if of, ok := out.(*os.File); ok && isFile {
+			a.term = NewTTY(f, of)
+			return
+		}
+		a.term = plainTerminal{}
+	}
+}
+
+// WithTerminal overrides the terminal used for sizing and raw key input.
+func WithTerminal(t Terminal) Option {
+	return func(a *App) {
+		a.term = t
+	}
+}
+
+// WithNotice shows the text returned by fn as a banner above each question;
+// an empty string shows nothing.
+func WithNotice(fn func(quiz.Question) string) Option {
+	return func(a *App) {
+		a.notice = fn
+	}
+}
+
+// WithPassMark sets the first-attempt percentage a run needs to pass.
+func WithPassMark(percent float64) Option {
+	return func(a *App) {
+		a.passMark = percent
+	}
+}
+
+// WithPenalty deducts penalty times a question's weight for each wrong first
+// attempt, as exams that penalize guessing do (see quiz.Session.UsePenalty).
+func WithPenalty(penalty float64) Option {
+	return func(a *App) {
+		a.penalty = penalty
+	}
+}
+
+// WithSuddenDeath ends the run at the first wrong answer and reports the
+// streak before it (see quiz.Session.UseSuddenDeath).
+func WithSuddenDeath() Option {
+	return func(a *App) {
+		a.sudden = true
+	}
+}
+
+// WithMasteryStop ends the run as soon as every domain meets r, instead of
+// going through the whole bank, and reports each domain's rolling accuracy
+// as its estimated readiness (see quiz.Session.UseMasteryStop).
+func WithMasteryStop(r quiz.MasteryStop) Option {
+	return func(a *App) {
+		a.masteryStop = &r
+	}
+}
+
+// WithPacing gives each question a time limit that shrinks over the run as p
+// sets, counting down on the question screen; a question whose time runs out
+// is answered blank and counts as a miss (see quiz.Session.UsePacing).
+func WithPacing(p quiz.Pacing) Option {
+	return func(a *App) {
+		a.pacing = &p
+	}
+}
+
+// WithDomainProgress adds a mini progress bar for each domain to the
+// progress line, to show which domains lag behind in a mixed run.
+func WithDomainProgress() Option {
+	return func(a *App) {
+		a.domainBars = true
+	}
+}
+
+// WithoutAccuracy leaves the first-attempt accuracy so far out of the
+// progress line, for exam practice where a slipping score could sway the
+// learner.
+func WithoutAccuracy() Option {
+	return func(a *App) {
+		a.hideAccuracy = true
+	}
+}
+
+// WithConfirmAnswers makes typing an option's letter select it, like the
+// arrow keys, instead of submitting it at once; Enter then submits, so a
+// mistyped letter can still be changed.
+func WithConfirmAnswers() Option {
+	return func(a *App) {
+		a.confirm = true
+	}
+}
+
+// WithOrder asks the questions in order, a permutation of their indexes,
+// instead of shuffled (see quiz.Session.UseOrder).
+func WithOrder(order []int) Option {
+	return func(a *App) {
+		a.order = order
+	}
+}
+
+// WithOrdering sets how the questions are shuffled: across the whole bank
+// (the default), within each domain, or not at all (see
+// quiz.Session.UseOrdering).
+func WithOrdering(o quiz.Ordering) Option {
+	return func(a *App) {
+		a.ordering = o
+	}
+}
+
+// WithReplay logs the run to rec for the replay command: the questions shown,
+// every selection change and strike-out, and the answers.
+func WithReplay(rec *replay.Recorder) Option {
+	return func(a *App) {
+		a.replay = rec
+	}
+}
+
+// WithFeedback sets how each answer is responded to: moving on by itself
+// after a delay, keeping the correct answer back after a miss, or saying
+// nothing until the summary (see quiz.Feedback).
+func WithFeedback(f quiz.Feedback) Option {
+	return func(a *App) {
+		a.feedback = f
+	}
+}
+
+// WithAutosave saves the run's progress with saver as it is answered and,
+// when resume is not nil, picks up the run it saved before. A saved run that
+// does not fit this one, say over other questions, is reported and ignored.
+func WithAutosave(saver *autosave.Saver, resume *quiz.State) Option {
+	return func(a *App) {
+		a.autosave, a.resume = saver, resume
+	}
+}
+
+// WithJSONResult writes the final Outcome as JSON to w instead of printing the
+// review summary. Combined with WithIO(os.Stdin, io.Discard) it gives a quiet
+// mode whose only output is the result.
+func WithJSONResult(w io.Writer) Option {
+	return func(a *App) {
+		a.resultOut = w
+	}
+}
+
+// WithSeed fixes the question order (and template draws) to seed, replaying
+// a challenge; see quiz.NewSeededSession.
+func WithSeed(seed int64) Option {
+	return func(a *App) {
+		a.seed = seed
+		a.seeded = true
+	}
+}
+
+// New returns an App that quizzes over questions. By default it talks to the
+// process's standard input and output.
+func New(questions []quiz.Question, opts ...Option) *App {
+	a := &App{
+		questions: questions,
+		in:        bufio.NewReader(os.Stdin),
+		out:       os.Stdout,
+		term:      NewTTY(os.Stdin, os.Stdout),
+		signals:   true,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// AddListener registers l on every session the App starts.
+func (a *App) AddListener(l quiz.Listener) {
+	a.listeners = append(a.listeners, l)
+}
+
+// Session returns the session started by Run, or nil before Run is called.
+func (a *App) Session() *quiz.Session {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.session
+}
+
+// Run starts a new session and drives it until the queue is exhausted, input
+// ends, or ctx is cancelled, then prints the review summary and returns the
+// outcome. When reading from the process's stdin, an interrupt, SIGTERM or
+// SIGHUP cancels the context handed to session listeners, saves the progress
+// as q does, prints the partial result and returns an Outcome marked
+// Interrupted; the caller picks the exit code (see Outcome.ExitCode).
+func (a *App) Run(ctx context.Context) Outcome {
+	defer a.restoreOnPanic()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session := quiz.NewSession(a.questions)
+	if a.seeded {
+		session = quiz.NewSeededSession(a.questions, a.seed)
+	}
+	for _, l := range a.listeners {
+		session.AddListener(l)
+	}
+	if a.replay != nil {
+		session.AddListener(a.replay.Listener())
+	}
+	session.UsePenalty(a.penalty)
+	if a.feedback.Silent {
+		session.UseSinglePass()
+	}
+	if a.sections != nil {
+		if err := session.UseSections(a.sections); err != nil {
+			fmt.Fprintf(a.out, "Ignoring sections: %v\n", err)
+		}
+	}
+	if a.sudden {
+		if err := session.UseSuddenDeath(); err != nil {
+			fmt.Fprintf(a.out, "Ignoring sudden death: %v\n", err)
+		}
+	}
+	if a.masteryStop != nil {
+		if err := session.UseMasteryStop(*a.masteryStop); err != nil {
+			fmt.Fprintf(a.out, "Ignoring the mastery stop: %v\n", err)
+		}
+	}
+	if a.pacing != nil {
+		if err := session.UsePacing(*a.pacing); err != nil {
+			fmt.Fprintf(a.out, "Ignoring pacing: %v\n", err)
+		}
+	}
+	if a.order != nil {
+		if err := session.UseOrder(a.order); err != nil {
+			fmt.Fprintf(a.out, "Ignoring question order: %v\n", err)
+		}
+	}
+	if err := session.UseOrdering(a.ordering); err != nil {
+		fmt.Fprintf(a.out, "Ignoring question ordering %s: %v\n", a.ordering, err)
+	}
+	session.UseBreaks(a.breaks)
+	resumed := false
+	if a.resume != nil {
+		if err := session.Restore(*a.resume); err != nil {
+			fmt.Fprintf(a.out, "Not resuming the saved run: %v\n", err)
+		} else {
+			resumed = true
+		}
+	}
+	if a.autosave != nil {
+		session.AddListener(a.autosave.Listener(session))
+	}
+	a.mu.Lock()
+	a.session = session
+	a.mu.Unlock()
+	a.startTiming(len(a.questions))
+	if a.signals {
+		defer a.catchInterrupts(cancel)()
+	}
+
+	fmt.Fprintln(a.out, colorize("CSSLP Review Quiz (Domains 4-8)", colorBold+colorCyan))
+	fmt.Fprintln(a.out, "-------------------------------")
+	fmt.Fprintln(a.out, "Answer each question with A, B, C, or D. Press Enter after each choice.")
+	if resumed {
+		fmt.Fprintf(a.out, "Resuming the run saved %s, %d answered.\n", a.resume.SavedAt.Local().Format("Jan 2 15:04"), a.resume.Answered())
+	}
+
+	lastSection := -1
+	for ctx.Err() == nil {
+		if sec, ok := session.CurrentSection(); ok && sec.Index != lastSection && a.resultOut == nil {
+			lastSection = sec.Index
+			if !a.showSectionTransition(session, sec) {
+				return a.endEarly(session)
+			}
+		}
+		idx, q, ok := session.Current(ctx)
+		if !ok {
+			break
+		}
+		if session.BreakDue() && a.resultOut == nil {
+			if !a.takeBreak(idx) {
+				return a.endEarly(session)
+			}
+		}
+		completed, total := session.Progress()
+		if a.feedback.Silent {
+			// a miss is not requeued, so count every answer as done
+			completed = session.AttemptedCount()
+		}
+		userChoice, inputOK, jump := a.promptWithArrows(q, idx+1, completed, total)
+		if jump >= 0 {
+			// a search or a skip has brought it to the front
+			continue
+		}
+		if a.quit || !inputOK {
+			return a.endEarly(session)
+		}
+
+		attempted := session.AttemptedCount()
+		answer := string(userChoice)
+		if userChoice == 0 {
+			// the question's time ran out with nothing chosen
+			answer = ""
+		}
+		res, finished, err := session.Answer(ctx, answer)
+		if errors.Is(err, quiz.ErrSectionTimeUp) {
+			fmt.Fprintln(a.out, colorize("\nTime is up for this section; that answer was not recorded.", colorRed+colorBold))
+			fmt.Fprintln(a.out, "Press Enter to continue...")
+			a.readLine()
+			if finished {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			break
+		}
+		a.recordAttempt(idx, res.Elapsed)
+
+		if a.confidence && session.AttemptedCount() > attempted {
+			c, ok := a.askConfidence()
+			if !ok {
+				return a.endEarly(session)
+			}
+			session.Rate(idx, c)
+			res.Confidence = c
+		}
+
+		// brief feedback before continuing
+		if !a.feedback.Silent {
+			a.showFeedback(q, res)
+			a.waitToContinue()
+		}
+		if finished {
+			break
+		}
+	}
+
+	if a.stopped() {
+		return a.saveAndQuit(session)
+	}
+	return a.finish(session, ctx.Err() != nil)
+}
+
+// endEarly ends a run whose input stopped before the last question: one the
+// learner quit or interrupted is saved and summarized, and otherwise input
+// ran out.
+func (a *App) endEarly(session *quiz.Session) Outcome {
+	if a.quit || a.stopped() {
+		return a.saveAndQuit(session)
+	}
+	fmt.Fprintln(a.out, "\nInput ended unexpectedly. Exiting quiz.")
+	return a.finish(session, true)
+}
+
+// finish reports the outcome as a JSON summary, as a JSON result, or as the
+// review table.
+func (a *App) finish(session *quiz.Session, interrupted bool) Outcome {
+	o := a.outcome(session, interrupted)
+	if a.summaryOut != nil {
+		writeJSON(a.summaryOut, a.summary(o, session))
+		return o
+	}
+	if a.resultOut != nil {
+		writeJSON(a.resultOut, o)
+		return o
+	}
+	if interrupted && o.Answered == 0 {
+		fmt.Fprintln(a.out, "\nNo answers recorded. Exiting.")
+		return o
+	}
+	if interrupted {
+		fmt.Fprintln(a.out)
+	}
+	questions, results := a.questions, session.Results()
+	if o.Answered < len(questions) {
+		// a run that stopped early reviews the questions it asked
+		questions, results = attemptedOnly(session, questions, results)
+	}
+	a.printSummary(o.Answered, questions, results)
+	a.printMastery(o)
+	a.printSections(session.Sections())
+	a.printCalibration(o.Calibration)
+	a.printStreak(o)
+	a.printMasteryStop(o)
+	a.printPacing(o)
+	if !interrupted {
+		o.Requeued = a.reviewAnswers(o.Answered, questions, results)
+	}
+	return o
+}
+
+// attemptedOnly narrows questions and their results to those session has
+// asked, in bank order.
+func attemptedOnly(session *quiz.Session, questions []quiz.Question, results []quiz.Result) ([]quiz.Question, []quiz.Result) {
+	var qs []quiz.Question
+	var rs []quiz.Result
+	for i := range min(len(questions), len(results)) {
+		if session.Attempted(i) {
+			qs = append(qs, questions[i])
+			rs = append(rs, results[i])
+		}
+	}
+	return qs, rs
+}
+
+// promptWithArrows renders a selectable list with arrow key navigation.
+// Returns selected answer, ok, and jumpIndex (>=0 when a search or a skip with
+// u or m has brought another question to the front).
+func (a *App) promptWithArrows(q quiz.Question, number int, completed, total int) (rune, bool, int) {
+	letters := sortedKeys(q.Options)
+	if len(letters) == 0 {
+		return 0, false, -1
+	}
+
+	choiceIdx := 0
+	// struck holds the options struck out with x; it is only a visual aid,
+	// so a struck option can still be chosen.
+	struck := map[rune]bool{}
+	// status reports a skip with u or m that found nothing, until the next
+	// key.
+	var status string
+	var inline string
+	if q.Image != "" {
+		inline = a.inlineImage(q)
+	}
+	// clickRows maps the screen rows of the options, as last drawn, to
+	// their indexes for mouse clicks.
+	var clickRows map[int]int
+	render := func() {
+		width, rows := a.term.Size()
+		compact := a.compactLayout(width, rows)
+		a.clearScreen()
+		var lines []string
+		switch {
+		case a.feedback.Blind:
+		case compact:
+			lines = append(lines, formatCompactProgress(completed, total)+a.accuracyNote(true))
+		default:
+			lines = append(lines, formatProgress(completed, total, a.domainProgress())+a.accuracyNote(false))
+		}
+		if line := a.sectionLine(); line != "" {
+			lines = append(lines, line)
+		}
+		if line := a.paceLine(); line != "" {
+			lines = append(lines, line)
+		}
+		if a.notice != nil {
+			if text := a.notice(q); text != "" {
+				lines = append(lines, colorize(text, colorRed+colorBold))
+			}
+		}
+		lines = append(lines, scenarioLines(q)...)
+		label := fmt.Sprintf("Q%d (Domain %d):", number, q.Domain)
+		if compact {
+			label = fmt.Sprintf("Q%d D%d:", number, q.Domain)
+		}
+		lines = append(lines, promptLines(label, q.Prompt, colorBold+colorCyan)...)
+		lines = append(lines, a.noteLines(q)...)
+		if !compact {
+			lines = append(lines, "")
+		}
+		switch {
+		case q.Image != "" && inline == "":
+			lines = append(lines, a.imagePlaceholder(q), "")
+		case inline != "":
+			lines = append(lines, colorize(imageCaption(q), colorYellow), "")
+		}
+		optionLine := make([]int, len(letters))
+		for i, letter := range letters {
+			optionLine[i] = len(lines)
+			prefix := "  "
+			if i == choiceIdx {
+				prefix = colorize("> ", colorYellow)
+			}
+			line := fmt.Sprintf("%s%c) %s", prefix, letter, markdown.InlineANSI(q.Options[string(letter)], ""))
+			if struck[letter] {
+				line = prefix + colorize(fmt.Sprintf("%c) %s", letter, markdown.Plain(q.Options[string(letter)])), colorDim+colorStrike)
+			}
+			lines = append(lines, line)
+		}
+		if compact {
+			lines = append(lines, colorize(a.compactHint(), colorYellow))
+			if status != "" {
+				lines = append(lines, colorize(status, colorRed))
+			}
+			top := 1
+			if inline != "" {
+				fmt.Fprintln(a.out, inline)
+				top = 0
+			}
+			clickRows = optionRows(lines, optionLine, width, top)
+			a.renderLeft(lines, width)
+			return
+		}
+		hint := "Use ↑/↓ to select, Enter to confirm (A–D also works), x to strike out, p to pause, q to quit."
+		if a.confirm {
+			hint = "Use ↑/↓ or A–D to select, Enter to confirm, x to strike out, p to pause, q to quit."
+		}
+		if a.noteSet != nil {
+			hint = strings.Replace(hint, ", p to pause", ", n for a note, p to pause", 1)
+		}
+		lines = append(lines, "", colorize(hint, colorYellow))
+		switch {
+		case a.remote != nil:
+		case a.feedback.Silent:
+			// misses are not asked again, so there are none to skip to
+			lines = append(lines, colorize("u skips to the next unanswered question.", colorYellow))
+		default:
+			lines = append(lines, colorize("u skips to the next unanswered question, m to the next missed one.", colorYellow))
+		}
+		if status != "" {
+			lines = append(lines, colorize(status, colorRed))
+		}
+		// centre the lines as wrapped
+		wrapped := wrapLines(lines, width)
+		linesCount := len(wrapped)
+		topPad := 0
+		if inline != "" {
+			// the image's height is unknown, so draw it at the top instead of
+			// centring the block
+			fmt.Fprintln(a.out, inline)
+			rows = 0
+		}
+		if rows > 0 {
+			if pad := (rows - linesCount) / 2; pad > 0 {
+				topPad = pad
+			}
+		}
+		top := topPad + 1
+		if inline != "" {
+			top = 0
+		}
+		clickRows = optionRows(lines, optionLine, width, top)
+		for i := 0; i < topPad; i++ {
+			fmt.Fprintln(a.out)
+		}
+		a.renderBlock(wrapped, width)
+	}
+
+	render()
+
+	// switch to raw mode to capture arrow keys
+	if err := a.enableRaw(); err != nil {
+		// fallback to typed input
+		r, ok := a.fallbackPrompt(letters, number-1)
+		return r, ok, -1
+	}
+	defer a.leaveRaw()
+	a.enableMouse()
+
+	// move shifts the selection by d options, if there is one there.
+	move := func(d int) {
+		if i := choiceIdx + d; i >= 0 && i < len(letters) {
+			choiceIdx = i
+			a.logSelection(replay.Select, number-1, letters[choiceIdx])
+			render()
+		}
+	}
+	// lastClick is the option last clicked and when, to spot double clicks.
+	lastClick, lastClickAt := -1, time.Time{}
+	for {
+		if !a.waitPaced(render) {
+			// out of time: answer blank, which counts as a miss
+			return 0, true, -1
+		}
+		key, seq, err := a.readKey()
+		if err != nil {
+			return 0, false, -1
+		}
+		status = ""
+		switch {
+		case key == '\n' || key == '\r':
+			return letters[choiceIdx], true, -1
+		case key == 27 && len(seq) == 2 && seq[0] == '[' && seq[1] == '<' && a.mouse:
+			ev, ok := a.readMouse()
+			switch {
+			case !ok || ev.release:
+			case ev.button == mouseWheelUp:
+				move(-1)
+			case ev.button == mouseWheelDown:
+				move(1)
+			case ev.button == mouseLeft:
+				i, ok := clickRows[ev.row]
+				if !ok {
+					break
+				}
+				if i == lastClick && time.Since(lastClickAt) <= doubleClick {
+					return letters[i], true, -1
+				}
+				lastClick, lastClickAt = i, time.Now()
+				move(i - choiceIdx)
+			}
+		case key == 27 && len(seq) == 2 && seq[0] == '[': // escape sequence
+			switch seq[1] {
+			case 'A': // up
+				move(-1)
+			case 'B': // down
+				move(1)
+			}
+		case key == 'x' || key == 'X':
+			letter := letters[choiceIdx]
+			struck[letter] = !struck[letter]
+			a.logSelection(replay.Strike, number-1, letter)
+			render()
+		case strings.ContainsRune("AaBbCcDd", rune(key)):
+			// allow direct letter entry
+			ch := unicodeToLetter(rune(key))
+			for i, l := range letters {
+				if l == ch {
+					choiceIdx = i
+					a.logSelection(replay.Select, number-1, l)
+					render()
+					if a.confirm {
+						break
+					}
+					return l, true, -1
+				}
+			}
+		case key == 'p' || key == 'P':
+			if !a.pause(number-1, a.waitKeyResume) {
+				return 0, false, -1
+			}
+			render()
+		case key == 'q' || key == 'Q':
+			status = a.quitPrompt()
+			render()
+			if a.confirmQuitKey() {
+				a.quit = true
+				return 0, false, -1
+			}
+			status = ""
+			render()
+		case (key == 'n' || key == 'N') && a.noteSet != nil:
+			a.leaveRaw()
+			ok := a.editNote(q)
+			a.enableRaw()
+			a.enableMouse()
+			if !ok {
+				return 0, false, -1
+			}
+			render()
+		case strings.ContainsRune("Uu", rune(key)) && a.remote == nil,
+			strings.ContainsRune("Mm", rune(key)) && a.remote == nil && !a.feedback.Silent:
+			pred, what := quiz.Unattempted, "unanswered"
+			if key == 'm' || key == 'M' {
+				pred, what = quiz.Missed, "missed"
+			}
+			if target, _, ok := a.Session().NextWhere(pred); ok {
+				return 0, true, target
+			}
+			status = fmt.Sprintf("No other %s question is waiting.", what)
+			render()
+		case key == '/':
+			// searchQuestions reads the term in raw mode of its own
+			a.leaveRaw()
+			target, ok := a.searchQuestions()
+			a.enableRaw()
+			a.enableMouse()
+			if target >= 0 && ok {
+				return 0, true, target
+			}
+			render()
+			continue
+		}
+	}
+}
+
+// logSelection records a selection change on the question at index for
+// WithReplay.
+func (a *App) logSelection(kind replay.Kind, index int, letter rune) {
+	if a.replay != nil {
+		a.replay.Record(replay.Event{Kind: kind, Index: index, Option: string(letter)})
+	}
+}
+
+func (a *App) fallbackPrompt(letters []rune, index int) (rune, bool) {
+	for {
+		fmt.Fprint(a.out, "Your answer (A-D, p to pause, q to quit): ")
+		line, ok := a.readLine()
+		if !ok {
+			return 0, false
+		}
+		input := strings.TrimSpace(line)
+		if len(input) == 0 {
+			continue
+		}
+		if strings.EqualFold(input, "p") {
+			if !a.pause(index, a.waitLineResume) {
+				return 0, false
+			}
+			continue
+		}
+		if strings.EqualFold(input, "q") {
+			if a.confirmQuitLine() {
+				a.quit = true
+				return 0, false
+			}
+			continue
+		}
+		ch := unicodeToLetter(rune(input[0]))
+		for _, l := range letters {
+			if ch == l {
+				return ch, true
+			}
+		}
+	}
+}
+
+// searchQuestions asks for a search term and brings the first question
+// matching it to the front of the queue. A question already answered
+// correctly is only asked again, to re-verify it, if the learner confirms.
+// An empty term goes back to the question. It returns (index, true) when the
+// queue changed, or (-1, false) otherwise.
+func (a *App) searchQuestions() (int, bool) {
+	a.clearScreen()
+	if len(a.searches) > 0 {
+		fmt.Fprintln(a.out, "↑ recalls an earlier search; Enter alone goes back.")
+	}
+	line, ok := a.readSearch()
+	term := strings.TrimSpace(line)
+	if !ok || term == "" {
+		return -1, false
+	}
+	a.rememberSearch(term)
+	idx, q, err := a.jumpTo(term, false)
+
+	found := []string{fmt.Sprintf("Found at question %d (Domain %d)", idx+1, q.Domain), "", markdown.Plain(q.Prompt), ""}
+	var lines []string
+	switch {
+	case idx == -1:
+		lines = []string{"NOT FOUND", "", "Press Enter to return..."}
+	case errors.Is(err, quiz.ErrMastered):
+		lines = append(found,
+			"You have already answered this one correctly.",
+			"Type y and press Enter to ask it again (your score stays as it is),",
+			"or press Enter to return...")
+	case err != nil:
+		lines = append(found, fmt.Sprintf("Cannot jump to it: %v.", err), "", "Press Enter to return...")
+	default:
+		lines = append(found, "Press Enter to jump to this question...")
+	}
+	width, rows := a.term.Size()
+	a.clearScreen()
+	a.renderBlockWithVerticalCenter(lines, width, rows)
+	reply, _ := a.readLine()
+
+	if errors.Is(err, quiz.ErrMastered) && strings.EqualFold(strings.TrimSpace(reply), "y") {
+		_, _, err = a.jumpTo(term, true)
+	}
+	if idx == -1 || err != nil {
+		return -1, false
+	}
+	return idx, true
+}
+
+// jumpTo brings the first question whose prompt contains term to the front
+// of the queue (see quiz.Session.Requeue, and force for again), and returns
+// its position and question, or -1. Against a remote session the server
+// does the search.
+func (a *App) jumpTo(term string, again bool) (int, quiz.Question, error) {
+	if a.remote != nil {
+		return a.remote.jump(term, again)
+	}
+	term = strings.ToLower(term)
+	for i, q := range a.questions {
+		if strings.Contains(strings.ToLower(markdown.Plain(q.Prompt)), term) {
+			return i, q, a.Session().Requeue(i, again)
+		}
+	}
+	return -1, quiz.Question{}, nil
+}
+
+// errInterrupted is returned by readKey for Ctrl+C.
+var errInterrupted = errors.New("interrupted")
+
+// catchInterrupts calls stop, which is to cancel the run, once on an
+// interrupt, on SIGTERM or SIGHUP (as when an SSH connection drops), or when
+// readKey reads Ctrl+C: in raw mode terminals differ on whether the key
+// still sends the signal. Reads waiting for input then give up, so the run
+// unwinds and returns. The returned function stops catching them, so a run
+// started after it gets its own.
+func (a *App) catchInterrupts(stop func()) (release func()) {
+	sigs, unsubscribe := a.sigs, func() {}
+	if sigs == nil {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+		sigs, unsubscribe = ch, func() { signal.Stop(ch) }
+	}
+	stopped, done := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	interrupt := func() {
+		once.Do(func() {
+			close(stopped)
+			stop()
+		})
+	}
+	a.mu.Lock()
+	a.interrupt, a.stop = interrupt, stopped
+	a.mu.Unlock()
+	go func() {
+		defer a.restoreOnPanic()
+		select {
+		case <-sigs:
+			interrupt()
+		case <-done:
+		}
+	}()
+	return func() {
+		unsubscribe()
+		close(done)
+		a.mu.Lock()
+		a.interrupt, a.stop = nil, nil
+		a.mu.Unlock()
+	}
+}
+
+// stopped reports whether the run has been interrupted (see
+// catchInterrupts).
+func (a *App) stopped() bool {
+	a.mu.Lock()
+	stop := a.stop
+	a.mu.Unlock()
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *App) enableRaw() error {
+	restore, err := a.term.MakeRaw()
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.restoreRaw = restore
+	a.mu.Unlock()
+	return nil
+}
+
+// leaveRaw restores the terminal if raw mode is active.
+func (a *App) leaveRaw() {
+	a.mu.Lock()
+	restore := a.restoreRaw
+	a.restoreRaw = nil
+	mouseOn := a.mouseOn
+	a.mouseOn = false
+	a.mu.Unlock()
+	if mouseOn {
+		fmt.Fprint(a.out, "\033[?1006l\033[?1000l")
+	}
+	if restore != nil {
+		restore()
+	}
+}
+
+// waitToContinue holds the feedback on screen until Enter is pressed or, with
+// an Advance delay, until the delay is up.
+func (a *App) waitToContinue() {
+	if a.feedback.Advance <= 0 {
+		fmt.Fprintln(a.out, "Press Enter to continue...")
+		a.readLine()
+		fmt.Fprintln(a.out)
+		return
+	}
+	if !a.feedback.Brief {
+		fmt.Fprintf(a.out, "Next question in %s; press Enter to go on now...\n", a.feedback.Advance)
+	}
+	if a.waitInput(a.feedback.Advance) {
+		a.readLine()
+	}
+	fmt.Fprintln(a.out)
+}
+
+// waitInput waits up to d for input without consuming it and reports whether
+// any arrived. On a timeout the wait goes on in the background, and the next
+// read picks up where it left off.
+func (a *App) waitInput(d time.Duration) bool {
+	a.peekInput()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	a.mu.Lock()
+	stop := a.stop
+	a.mu.Unlock()
+	select {
+	case <-a.pending:
+		a.pending = nil
+		return true
+	case <-stop:
+		return false
+	case <-timer.C:
+		return false
+	}
+}
+
+// peekInput starts waiting in the background for input without consuming
+// it, unless such a wait is already going on; a.pending is closed once input
+// arrives.
+func (a *App) peekInput() {
+	if a.pending != nil {
+		return
+	}
+	done := make(chan struct{})
+	a.pending = done
+	go func() {
+		a.in.Peek(1)
+		close(done)
+	}()
+}
+
+// settleInput waits for a background wait for input to finish before
+// reading. While interrupts are caught every read waits that way, so that an
+// interrupt can end the wait; settleInput reports false then.
+func (a *App) settleInput() bool {
+	a.mu.Lock()
+	stop := a.stop
+	a.mu.Unlock()
+	if stop != nil {
+		a.peekInput()
+	}
+	if a.pending == nil {
+		return true
+	}
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+	select {
+	case <-a.pending:
+		a.pending = nil
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// readKey reads one keypress in raw mode. Escape sequences that arrived in the
+// same read (such as arrow keys) are returned in seq.
+func (a *App) readKey() (key byte, seq []byte, err error) {
+	if !a.settleInput() {
+		return 0, nil, errInterrupted
+	}
+	key, err = a.in.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	if key == 3 {
+		// Ctrl+C that reached us as a key rather than as an interrupt
+		a.mu.Lock()
+		interrupt := a.interrupt
+		a.mu.Unlock()
+		if interrupt != nil {
+			interrupt()
+		}
+		return 0, nil, errInterrupted
+	}
+	if key == 27 && a.in.Buffered() >= 2 {
+		seq = make([]byte, 2)
+		if _, err := io.ReadFull(a.in, seq); err != nil {
+			return 0, nil, err
+		}
+	}
+	return key, seq, nil
+}
+
+// readLine reads one line of typed input without its trailing newline.
+func (a *App) readLine() (string, bool) {
+	if !a.settleInput() {
+		return "", false
+	}
+	line, err := a.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+func (a *App) clearScreen() {
+	clearScreen(a.out)
+}
@@ -0,0 +1,825 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"quiz-cli/autosave"
+	"quiz-cli/quiz"
+	"quiz-cli/replay"
+	"quiz-cli/webapp"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestPadRight(t *testing.T) {
+	cases := []struct {
+		in     string
+		width  int
+		expect string
+	}{
+		{"abc", 5, "abc  "},
+		{"abc", 3, "abc"},
+		{"", 2, "  "},
+	}
+	for _, tc := range cases {
+		got := padRight(tc.in, tc.width)
+		if got != tc.expect {
+			t.Fatalf("padRight(%q, %d) = %q, want %q", tc.in, tc.width, got, tc.expect)
+		}
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	cases := map[string]int{
+		"abc":                      3,
+		"日本語":                      6,
+		checkMark + " correct":     10,
+		colorize("Blue", colorRed): 4,
+		"e\u0301te":                3,
+		"שלום":                     4,
+	}
+	for in, want := range cases {
+		if got := displayWidth(in); got != want {
+			t.Errorf("displayWidth(%q) = %d, want %d", in, got, want)
+		}
+	}
+	if got := padRight("日本", 6); got != "日本  " {
+		t.Fatalf("padRight with wide runes = %q", got)
+	}
+}
+
+func TestUnicodeToLetter(t *testing.T) {
+	if got := unicodeToLetter('c'); got != 'C' {
+		t.Fatalf("unicodeToLetter lowercase => %c, want C", got)
+	}
+	if got := unicodeToLetter('Z'); got != 'Z' {
+		t.Fatalf("unicodeToLetter preserves => %c, want Z", got)
+	}
+}
+
+func TestPrintSummaryPlacesGradeLast(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 1, Prompt: "Q1", Answer: "A", Options: map[string]string{"A": "Yes", "B": "No"}},
+		{Domain: 1, Prompt: "Q2", Answer: "B", Options: map[string]string{"A": "Yes", "B": "No"}},
+		{Domain: 1, Prompt: "Q3", Answer: "C", Options: map[string]string{"C": "Maybe", "D": "No"}},
+	}
+	results := []quiz.Result{
+		{UserAnswer: "A", Correct: true},
+		{UserAnswer: "A", Correct: false},
+		{UserAnswer: "C", Correct: true},
+	}
+
+	var out bytes.Buffer
+	app := New(questions, WithIO(strings.NewReader(""), &out), WithTerminal(fixedTerminal{}))
+	app.printSummary(len(results), questions, results)
+	output := out.String()
+
+	lines := strings.Split(output, "\n")
+	var last string
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		last = strings.TrimSpace(lines[i])
+		break
+	}
+	if !strings.HasPrefix(last, "You answered") {
+		t.Fatalf("expected grade line last, got %q", last)
+	}
+}
+
+func TestPrintSummaryShowsWeightedScore(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 1, Prompt: "Q1", Answer: "A", Weight: 3, Options: map[string]string{"A": "Yes", "B": "No"}},
+		{Domain: 1, Prompt: "Q2", Answer: "B", Options: map[string]string{"A": "Yes", "B": "No"}},
+	}
+	results := []quiz.Result{{UserAnswer: "A", Correct: true}, {UserAnswer: "A"}}
+	var out bytes.Buffer
+	app := New(questions, WithIO(strings.NewReader(""), &out), WithTerminal(fixedTerminal{}))
+	app.printSummary(len(results), questions, results)
+	if !strings.Contains(out.String(), "Weighted score: 3 of 4 points (75.0%).") {
+		t.Fatalf("no weighted score:\n%s", out.String())
+	}
+
+	out.Reset()
+	New(questions, WithIO(strings.NewReader(""), &out), WithTerminal(fixedTerminal{}), WithPenalty(0.25)).printSummary(len(results), questions, results)
+	if !strings.Contains(out.String(), "Marked score (wrong answers cost 0.25 of their points): 2.75 of 4 points (68.8%).") {
+		t.Fatalf("no marked score:\n%s", out.String())
+	}
+}
+
+// fixedTerminal reports a constant size and refuses raw mode unless raw is set,
+// in which case the App reads keypresses straight from its reader.
+type fixedTerminal struct {
+	width, rows int
+	raw         bool
+}
+
+func (t fixedTerminal) Size() (int, int) { return t.width, t.rows }
+
+func (t fixedTerminal) MakeRaw() (func(), error) {
+	if !t.raw {
+		return nil, errors.New("no tty")
+	}
+	return func() {}, nil
+}
+
+func TestRunGolden(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	cases := []struct {
+		name  string
+		term  fixedTerminal
+		input string
+	}{
+		// typed answers: wrong first, then right
+		{"typed", fixedTerminal{width: 60}, "a\n\nB\n\n"},
+		// raw keys: arrow down + Enter, then direct letter entry
+		{"raw", fixedTerminal{width: 60, rows: 24, raw: true}, "\x1b[B\r\na\n"},
+		// the same keys on a small terminal, in the compact layout
+		{"compact", fixedTerminal{width: 40, rows: 12, raw: true}, "\x1b[B\r\na\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			New(questions, WithIO(strings.NewReader(tc.input), &out), WithTerminal(tc.term)).Run(context.Background())
+			golden := filepath.Join("testdata", tc.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatalf("write golden: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden: %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Fatalf("output mismatch for %s; rerun with -update to inspect\ngot:\n%s", golden, out.String())
+			}
+		})
+	}
+}
+
+func TestStrikeOutResetsPerQuestion(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "One?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+		{Domain: 4, Prompt: "Two?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var out bytes.Buffer
+	// strike A, move down (redrawing A struck), choose B; then choose A on
+	// the next question without striking anything
+	o := New(questions, WithIO(strings.NewReader("x\x1b[B\r\n\r\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true})).Run(context.Background())
+	if o.Answered != 2 || o.Score != 1 {
+		t.Fatalf("outcome %+v, want the struck-out session to answer both", o)
+	}
+	if n := strings.Count(out.String(), colorDim+colorStrike+"A) Green"); n != 2 {
+		t.Fatalf("A drawn struck %d times, want 2 (struck state should not carry over)\n%s", n, out.String())
+	}
+}
+
+func TestNotes(t *testing.T) {
+	questions := []quiz.Question{
+		{ID: "q1", Domain: 4, Prompt: "One?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	notes := map[string]string{}
+	get := func(q quiz.Question) string { return notes[q.ID] }
+	set := func(q quiz.Question, text string) { notes[q.ID] = text }
+	var out bytes.Buffer
+	New(questions, WithIO(strings.NewReader("nremember blue\n\rb\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}), WithNotes(get, set)).Run(context.Background())
+	if notes["q1"] != "remember blue" {
+		t.Fatalf("notes = %v", notes)
+	}
+	if !strings.Contains(out.String(), "Note: remember blue") {
+		t.Fatalf("note not shown after editing:\n%s", out.String())
+	}
+}
+
+func TestQuestionImages(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 7))
+	img.Set(3, 3, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "diagram.png"), buf.Bytes(), 0o644)
+	questions := []quiz.Question{
+		{Domain: 1, Prompt: "Which tier?", Answer: "A", Options: map[string]string{"A": "Web", "B": "DB"}, Image: "diagram.png", ImageAlt: "Three-tier diagram"},
+	}
+
+	var out bytes.Buffer
+	// wide enough that the temporary directory's path is not wrapped
+	New(questions, WithIO(strings.NewReader("a\n\n"), &out), WithTerminal(fixedTerminal{width: 200}),
+		WithImages(ImagesPlaceholder, dir)).Run(context.Background())
+	if want := "[image: Three-tier diagram] " + filepath.Join(dir, "diagram.png"); !strings.Contains(out.String(), want) {
+		t.Fatalf("placeholder %q missing from output:\n%s", want, out.String())
+	}
+
+	out.Reset()
+	New(questions, WithIO(strings.NewReader("a\n\n"), &out), WithTerminal(fixedTerminal{width: 60}),
+		WithImages(ImagesSixel, dir)).Run(context.Background())
+	if !strings.Contains(out.String(), "\x1bPq\"1;1;8;7") || !strings.Contains(out.String(), "-\x1b\\") || !strings.Contains(out.String(), "[image: Three-tier diagram]") {
+		t.Fatalf("sixel image missing from output:\n%q", out.String())
+	}
+}
+
+func TestSectionTransitions(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Q1", Answer: "A", Options: map[string]string{"A": "Yes", "B": "No"}},
+		{Domain: 5, Prompt: "Q2", Answer: "A", Options: map[string]string{"A": "Yes", "B": "No"}},
+	}
+	var out bytes.Buffer
+	o := New(questions, WithIO(strings.NewReader("\na\n\n\na\n\n"), &out), WithTerminal(fixedTerminal{width: 80}),
+		WithSections([]quiz.Section{{Domain: 4}, {Domain: 5, Budget: time.Hour}})).Run(context.Background())
+	got := out.String()
+	for _, want := range []string{
+		"Section 1 of 2: Domain 4",
+		"Section 1 (Domain 4): 1 of 1 answered, 1 correct",
+		"That section is now locked.",
+		"Section 2 of 2 (Domain 5) · ",
+		"Sections:",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output missing %q:\n%s", want, got)
+		}
+	}
+	if o.Interrupted || len(o.Sections) != 2 || o.Sections[1].Correct != 1 {
+		t.Fatalf("outcome = %+v", o)
+	}
+}
+
+func TestConfidenceCalibration(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 1, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var out bytes.Buffer
+	// wrong and "sure", then a bad rating, then the retry (not rated again)
+	o := New(questions, WithIO(strings.NewReader("a\nmaybe\n3\n\nb\n\n"), &out), WithTerminal(fixedTerminal{width: 60}),
+		WithConfidence()).Run(context.Background())
+	got := out.String()
+	for _, want := range []string{"How sure were you?", "Please enter 1, 2 or 3.", "Confidence calibration:", "sure      0 of 1 correct (0.0%)", "felt sure about"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "How sure were you?") != 1 {
+		t.Fatalf("retry should not be rated:\n%s", got)
+	}
+	if len(o.Calibration) != 3 || o.Calibration[2].Answered != 1 {
+		t.Fatalf("calibration = %+v", o.Calibration)
+	}
+}
+
+func TestJSONSummary(t *testing.T) {
+	questions := []quiz.Question{
+		{ID: "q1", Domain: 5, Prompt: "Sky **color**?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+		{ID: "q2", Domain: 4, Prompt: "Grass color?", Answer: "A", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var out, doc bytes.Buffer
+	// each question gets at least one attempt whatever the order
+	New(questions, WithIO(strings.NewReader("a\n\na\n\nb\n\nb\n\n"), &out), WithTerminal(fixedTerminal{width: 60}),
+		WithJSONSummary(&doc)).Run(context.Background())
+	if strings.Contains(out.String(), "Review:") {
+		t.Fatalf("table printed alongside JSON:\n%s", out.String())
+	}
+	var s Summary
+	if err := json.Unmarshal(doc.Bytes(), &s); err != nil {
+		t.Fatalf("summary is not JSON: %v\n%s", err, doc.String())
+	}
+	if s.Answered != 2 || s.Total != 2 || len(s.Questions) != 2 || s.Seconds < 0 {
+		t.Fatalf("summary = %+v", s)
+	}
+	if q := s.Questions[0]; q.ID != "q1" || q.Prompt != "Sky color?" || q.CorrectAnswer != "B" || q.Attempts == 0 {
+		t.Fatalf("question = %+v", q)
+	}
+	if len(s.Domains) != 2 || s.Domains[0].Domain != 4 || s.Domains[0].Questions != 1 {
+		t.Fatalf("domains = %+v", s.Domains)
+	}
+}
+
+func TestFlashcardsRevealAndGrade(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 1, Prompt: "Sky color?", Answer: "B", Explanation: "Rayleigh scattering.", Options: map[string]string{"A": "Green", "B": "Blue"}},
+		{Domain: 1, Prompt: "Grass color?", Answer: "A", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var out bytes.Buffer
+	var graded []quiz.Grade
+	o := New(questions, WithIO(strings.NewReader("\n5\n3\n\n1\n"), &out), WithTerminal(fixedTerminal{width: 60}),
+		WithGrader(func(_ quiz.Question, g quiz.Grade) { graded = append(graded, g) })).RunFlashcards(context.Background())
+	got := out.String()
+	for _, want := range []string{"Card 1 of 2", "press Space to reveal", "Answer: B) ", "Rayleigh scattering.", "Please enter 1, 2, 3 or 4.", "Reviewed 2 of 2 cards: 1 again, 0 hard, 1 good, 0 easy."} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "A) Green") {
+		t.Fatalf("options shown before reveal:\n%s", got)
+	}
+	if o.Interrupted || o.Reviewed != 2 || len(graded) != 2 || graded[0] != quiz.Good || graded[1] != quiz.Again {
+		t.Fatalf("outcome = %+v, grades = %v", o, graded)
+	}
+}
+
+func TestRunRemoteSharesServerSession(t *testing.T) {
+	questions := []quiz.Question{
+		{ID: "sky", Domain: 1, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	ts := httptest.NewServer(webapp.NewServer(questions).Handler())
+	defer ts.Close()
+
+	var out bytes.Buffer
+	// wrong, then right once the server requeues it
+	o, err := New(nil, WithIO(strings.NewReader("a\n\nb\n\n"), &out), WithTerminal(fixedTerminal{width: 60}),
+		WithPassMark(50)).RunRemote(context.Background(), ts.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{"Connected to " + ts.URL, "Sky color?", "Correct answer: B", "You answered 0 of 1 correctly"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output missing %q:\n%s", want, got)
+		}
+	}
+	if o.Score != 0 || o.Answered != 1 || o.Passed || o.Interrupted {
+		t.Fatalf("outcome = %+v", o)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var state struct {
+		Finished bool `json:"finished"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil || !state.Finished {
+		t.Fatalf("server session not finished: %+v, %v", state, err)
+	}
+
+	if _, err := New(nil).RunRemote(context.Background(), "localhost:8080"); err == nil {
+		t.Fatal("expected an error for an address without a scheme")
+	}
+}
+
+func TestReplayLogPlaysBack(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var log, out bytes.Buffer
+	rec := replay.NewRecorder(&log)
+	// strike A, move down, choose B
+	New(questions, WithIO(strings.NewReader("x\x1b[B\r\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}), WithReplay(rec)).Run(context.Background())
+	events, err := replay.Read(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, string(e.Kind))
+	}
+	if got := strings.Join(kinds, " "); got != "shown strike select answer finish" {
+		t.Fatalf("logged %s", got)
+	}
+	if events[0].Question == nil || events[0].Question.Prompt != "Sky color?" || events[2].Option != "B" || !events[3].Correct {
+		t.Fatalf("events = %+v", events)
+	}
+
+	out.Reset()
+	if err := New(nil, WithIO(strings.NewReader(""), &out), WithTerminal(fixedTerminal{width: 60})).Replay(context.Background(), events, 1000); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{colorDim + colorStrike + "A) Green", "Answered B", "Finished: 1 of 1 correct"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("replay lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDomainProgressBars(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 5, Prompt: "b", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}},
+		{Domain: 4, Prompt: "a", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}},
+		{Domain: 4, Prompt: "c", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}},
+	}
+	session := quiz.NewSession(questions)
+	session.UseOrder([]int{1, 0, 2})
+	session.Answer(context.Background(), "A")
+	got := formatProgress(1, 3, session.DomainProgress())
+	if want := "D4 ▓▓░░ D5 ░░░░"; !strings.HasSuffix(got, want) {
+		t.Fatalf("progress = %q, want suffix %q", got, want)
+	}
+	if got := formatProgress(1, 3, nil); strings.Contains(got, "D4") {
+		t.Fatalf("progress without domains = %q", got)
+	}
+}
+
+func TestRunningAccuracyInHeader(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "a", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}},
+		{Domain: 4, Prompt: "b", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}},
+	}
+	run := func(opts ...Option) string {
+		var out bytes.Buffer
+		opts = append([]Option{WithIO(strings.NewReader("A\n\nB\n\nA\n\n"), &out), WithTerminal(fixedTerminal{width: 80}), WithOrdering(quiz.FileOrder)}, opts...)
+		New(questions, opts...).Run(context.Background())
+		return out.String()
+	}
+	out := run(WithPassMark(70))
+	if !strings.Contains(out, colorize("100% so far", colorGreen)) || !strings.Contains(out, colorize("50% so far", colorYellow)) {
+		t.Fatalf("header did not track the accuracy against the pass mark:\n%s", out)
+	}
+	if out := run(WithoutAccuracy()); strings.Contains(out, "so far") {
+		t.Fatalf("WithoutAccuracy showed it:\n%s", out)
+	}
+	if out := run(WithFeedback(quiz.Feedback{Silent: true})); strings.Contains(out, "so far") {
+		t.Fatalf("silent run showed the accuracy:\n%s", out)
+	}
+}
+
+func TestPacingCountsDownAndReports(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "a", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}},
+		{Domain: 4, Prompt: "b", Answer: "A", Options: map[string]string{"A": "x", "B": "y"}},
+	}
+	var out bytes.Buffer
+	app := New(questions, WithIO(strings.NewReader("A\n\nA\n\n"), &out), WithTerminal(fixedTerminal{width: 80}),
+		WithOrdering(quiz.FileOrder), WithPacing(quiz.Pacing{Start: time.Minute, End: 30 * time.Second}))
+	o := app.Run(context.Background())
+	if o.Pacing == nil || o.Pacing.Rule != "1m0s:30s" || o.Pacing.Late != 0 || o.Score != 2 {
+		t.Fatalf("outcome = %+v, pacing %+v", o, o.Pacing)
+	}
+	text := out.String()
+	for _, want := range []string{"1:00 left of 1:00 for this question", "0:30 left of 0:30 for this question", "every answer came in time"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestFeedbackModes(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}, Explanation: "Rayleigh scattering.", Source: "Optics, ch. 3"},
+	}
+	var out bytes.Buffer
+	app := New(questions, WithIO(strings.NewReader("A\n\nB\n\n"), &out), WithTerminal(fixedTerminal{width: 60}), WithFeedback(quiz.Feedback{HideAnswer: true}))
+	if o := app.Run(context.Background()); o.Score != 0 || o.Mastered != 1 {
+		t.Fatalf("no-reveal outcome = %+v", o)
+	}
+	miss := out.String()[:strings.Index(out.String(), "Correct!")]
+	if strings.Contains(miss, "Correct answer: B") || strings.Contains(miss, "Rayleigh") {
+		t.Fatalf("no-reveal gave the answer away after the miss:\n%s", out.String())
+	}
+	if !strings.Contains(miss, "Source: Optics, ch. 3") || !strings.Contains(out.String(), "Read up on:\n  Q1   Optics, ch. 3") {
+		t.Fatalf("source not shown after the miss and in the review:\n%s", out.String())
+	}
+
+	out.Reset()
+	app = New(questions, WithIO(strings.NewReader("A\n"), &out), WithTerminal(fixedTerminal{width: 60}), WithFeedback(quiz.Feedback{Silent: true}))
+	if o := app.Run(context.Background()); o.Answered != 1 || o.Score != 0 {
+		t.Fatalf("silent outcome = %+v", o)
+	}
+	summary := strings.Index(out.String(), "Review:")
+	if summary < 0 || strings.Contains(out.String()[:summary], "Incorrect") || strings.Contains(out.String(), "Press Enter to continue") {
+		t.Fatalf("silent run gave feedback:\n%s", out.String())
+	}
+	if !strings.Contains(out.String()[:summary], "0/1 answered") {
+		t.Fatalf("silent run hid the progress:\n%s", out.String())
+	}
+
+	out.Reset()
+	app = New(questions, WithIO(strings.NewReader("A\n"), &out), WithTerminal(fixedTerminal{width: 60}), WithFeedback(quiz.Feedback{Silent: true, Blind: true}))
+	app.Run(context.Background())
+	summary = strings.Index(out.String(), "Review:")
+	if summary < 0 || strings.Contains(out.String()[:summary], "/1 answered") {
+		t.Fatalf("blind run showed the progress:\n%s", out.String())
+	}
+
+	out.Reset()
+	cram, _ := quiz.ParseFeedback("cram")
+	app = New(questions, WithIO(strings.NewReader("A\n\nB\n\n"), &out), WithTerminal(fixedTerminal{width: 60}), WithFeedback(cram))
+	if o := app.Run(context.Background()); o.Score != 0 || o.Mastered != 1 {
+		t.Fatalf("cram outcome = %+v", o)
+	}
+	summary = strings.Index(out.String(), "Review:")
+	if summary < 0 || !strings.Contains(out.String()[:summary], "Incorrect"+colorReset+colorGreen+"  B) Blue") {
+		t.Fatalf("cram run did not flash the right answer:\n%s", out.String())
+	}
+	for _, long := range []string{"Rayleigh", "Source:", "Press Enter to continue", "Next question in"} {
+		if strings.Contains(out.String()[:summary], long) {
+			t.Fatalf("cram run showed %q:\n%s", long, out.String())
+		}
+	}
+}
+
+func TestPauseKeyBlanksQuestionAndResumes(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var log, out bytes.Buffer
+	app := New(questions, WithIO(strings.NewReader("pxp\x1b[B\r\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}), WithReplay(replay.NewRecorder(&log)))
+	o := app.Run(context.Background())
+	if o.Score != 1 || app.Session().Paused() {
+		t.Fatalf("outcome = %+v, paused %v", o, app.Session().Paused())
+	}
+	// x is ignored while paused, so nothing is struck
+	paused := out.String()[strings.Index(out.String(), "Paused"):]
+	if strings.Contains(paused[:strings.Index(paused, "Q1")], "Sky color?") || strings.Contains(out.String(), colorStrike) {
+		t.Fatalf("question shown or struck while paused:\n%s", out.String())
+	}
+	events, _ := replay.Read(&log)
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, string(e.Kind))
+	}
+	if got := strings.Join(kinds, " "); got != "shown pause resume select answer finish" {
+		t.Fatalf("logged %s", got)
+	}
+}
+
+func TestQuitKeySavesAndSummarizes(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+		{Domain: 4, Prompt: "Grass color?", Answer: "A", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	path := filepath.Join(t.TempDir(), "run.json")
+	var out bytes.Buffer
+	// a declined quit carries on; the second one, confirmed, ends the run
+	app := New(questions, WithIO(strings.NewReader("qnB\nqy"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}),
+		WithAutosave(autosave.New(path, 100), nil), WithSeed(1))
+	o := app.Run(context.Background())
+	if !o.Interrupted || o.Answered != 1 {
+		t.Fatalf("outcome = %+v", o)
+	}
+	st, ok, err := autosave.Load(context.Background(), path)
+	if err != nil || !ok || st.Answered() != 1 {
+		t.Fatalf("saved %+v, %v, %v", st, ok, err)
+	}
+	for _, want := range []string{"Save and quit? (y/n)", "Progress saved", "You answered"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Input ended") {
+		t.Fatalf("quit reported as ended input:\n%s", out.String())
+	}
+}
+
+func TestCtrlCKeyAndHangUpInterrupt(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var out bytes.Buffer
+	app := New(questions, WithIO(strings.NewReader("\x03"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}))
+	app.signals, app.sigs = true, make(chan os.Signal)
+	if o := app.Run(context.Background()); !o.Interrupted || !strings.Contains(out.String(), "No answers recorded") {
+		t.Fatalf("Ctrl+C outcome %+v\n%s", o, out.String())
+	}
+
+	// a hang-up while the run waits for a key ends the wait, and Run
+	// returns for the caller to clean up
+	in, w := io.Pipe()
+	defer w.Close()
+	sigs := make(chan os.Signal, 1)
+	app = New(questions, WithIO(in, &out), WithTerminal(fixedTerminal{width: 60, raw: true}))
+	app.signals, app.sigs = true, sigs
+	done := make(chan Outcome)
+	go func() { done <- app.Run(context.Background()) }()
+	sigs <- syscall.SIGHUP
+	select {
+	case o := <-done:
+		if !o.Interrupted || o.ExitCode() != ExitInterrupted {
+			t.Fatalf("hang-up outcome %+v", o)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after SIGHUP")
+	}
+}
+
+// restoreCounter is a raw terminal that counts how often raw mode is left.
+type restoreCounter struct {
+	fixedTerminal
+	restored *int
+}
+
+func (t restoreCounter) MakeRaw() (func(), error) {
+	return func() { *t.restored++ }, nil
+}
+
+func TestPanicRestoresTerminalAndShowsMessage(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var out bytes.Buffer
+	restored := 0
+	app := New(questions, WithIO(strings.NewReader("B\n"), &out), WithTerminal(restoreCounter{fixedTerminal{width: 60}, &restored}))
+	// a listener that crashes with the terminal raw, as a bug drawing might
+	app.AddListener(quiz.ListenerFuncs{Answered: func(context.Context, int, quiz.Question, quiz.Result) {
+		app.enableRaw()
+		app.enableMouse()
+		panic("boom")
+	}})
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("recovered %v, want the panic passed on", r)
+		}
+		if restored != 2 || app.mouseOn {
+			t.Fatalf("raw mode left %d times, mouse on %v", restored, app.mouseOn)
+		}
+		crash := out.String()[strings.LastIndex(out.String(), "\033[2J\033[H"):]
+		if !strings.Contains(crash, "The quiz crashed: boom") {
+			t.Fatalf("no crash message on a clear screen:\n%q", out.String())
+		}
+	}()
+	app.Run(context.Background())
+}
+
+func TestBreakEveryPausesBetweenQuestions(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "A", Options: map[string]string{"A": "Blue", "B": "Green"}},
+		{Domain: 4, Prompt: "Grass color?", Answer: "A", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	var out, doc bytes.Buffer
+	app := New(questions, WithIO(strings.NewReader("a\n\n\na\n\n"), &out), WithTerminal(fixedTerminal{width: 60}),
+		WithBreaks(quiz.Breaks{Every: 1}), WithJSONSummary(&doc))
+	if o := app.Run(context.Background()); o.Score != 2 {
+		t.Fatalf("outcome = %+v", o)
+	}
+	// the break comes between the two questions, not before the first
+	brk := strings.Index(out.String(), "Time for a break")
+	if brk < 0 || brk < strings.Index(out.String(), "Correct") || strings.Count(out.String(), "Time for a break") != 1 {
+		t.Fatalf("break screen missing or misplaced:\n%s", out.String())
+	}
+	var s Summary
+	if err := json.Unmarshal(doc.Bytes(), &s); err != nil || s.Breaks != 1 {
+		t.Fatalf("summary breaks = %d, %v", s.Breaks, err)
+	}
+}
+
+func TestCompactLayoutWrapsOptions(t *testing.T) {
+	if got := wrapText("> A) ", "one two three four five", 15); !slices.Equal(got, []string{"> A) one two", "     three four", "     five"}) {
+		t.Fatalf("wrapText = %q", got)
+	}
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue, as seen on a clear day away from the city lights"}},
+	}
+	var out bytes.Buffer
+	New(questions, WithIO(strings.NewReader("b\n\n"), &out), WithTerminal(fixedTerminal{width: 30, rows: 40}), WithCompactLayout()).Run(context.Background())
+	screen := out.String()[strings.Index(out.String(), "Q1 D4:"):]
+	if !strings.Contains(screen, "  B) Blue, as seen on a clear\n     day away from the city\n     lights\n") {
+		t.Fatalf("option not wrapped under its text:\n%s", out.String())
+	}
+}
+
+func TestWrapLineIndentsUnderOptionText(t *testing.T) {
+	if got := wrapLine("  B) one two three four", 14); !slices.Equal(got, []string{"  B) one two", "     three", "     four"}) {
+		t.Fatalf("option = %q", got)
+	}
+	if got := wrapLine("Q1 (Domain 4): which one", 16); !slices.Equal(got, []string{"Q1 (Domain 4):", "which one"}) {
+		t.Fatalf("prompt = %q", got)
+	}
+	// the strike-out is taken up again after the indent, not before it
+	struck := "> " + colorize("A) one two three", colorDim+colorStrike)
+	want := []string{"> " + colorDim + colorStrike + "A) one two" + colorReset, "     " + colorDim + colorStrike + "three" + colorReset}
+	if got := wrapLine(struck, 12); !slices.Equal(got, want) {
+		t.Fatalf("struck option = %q, want %q", got, want)
+	}
+}
+
+func TestMouseSelectsAndSubmitsOptions(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	// the compact layout draws the progress line on row 1, the prompt on
+	// row 2 and the options from row 3
+	for name, input := range map[string]string{
+		"double click": "\x1b[<0;4;4M\x1b[<0;4;4m\x1b[<0;4;4M",
+		"click":        "\x1b[<0;4;4M\x1b[<0;4;4m\r\n",
+		"wheel":        "\x1b[<65;4;1M\r\n",
+	} {
+		var out bytes.Buffer
+		o := New(questions, WithIO(strings.NewReader(input+"\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}), WithCompactLayout(), WithMouse()).Run(context.Background())
+		if o.Answered != 1 || o.Score != 1 {
+			t.Fatalf("%s: outcome %+v, want B answered", name, o)
+		}
+		if !strings.Contains(out.String(), "\x1b[?1000h\x1b[?1006h") || !strings.Contains(out.String(), "\x1b[?1006l\x1b[?1000l") {
+			t.Fatalf("%s: mouse reporting not turned on and off:\n%q", name, out.String())
+		}
+	}
+}
+
+func TestReviewPagesWhenTooTall(t *testing.T) {
+	var questions []quiz.Question
+	for i := 0; i < 12; i++ {
+		answer := "A"
+		if i%3 == 0 {
+			answer = "B"
+		}
+		questions = append(questions, quiz.Question{Domain: 4, Prompt: fmt.Sprintf("Question %d?", i+1), Answer: answer, Options: map[string]string{"A": "Yes", "B": "No"}})
+	}
+	// twelve answers in one column do not fit in eight rows: Space turns to
+	// the second page, i keeps the four misses, which fit on one, and q ends
+	var out bytes.Buffer
+	o := New(questions, WithIO(strings.NewReader(strings.Repeat("a", 12)+" iq"), &out), WithTerminal(fixedTerminal{width: 40, rows: 8, raw: true}),
+		WithOrdering(quiz.FileOrder), WithFeedback(quiz.Feedback{Silent: true})).Run(context.Background())
+	if o.Answered != 12 || o.Score != 8 {
+		t.Fatalf("outcome %+v, want 8 of 12", o)
+	}
+	for _, want := range []string{"Review: page 1 of 2, all 12 answers", "Review: page 2 of 2, all 12 answers", "Review: page 1 of 1, 4 incorrect of 12", "Review: 12 answered, 4 incorrect (paged above)."} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, out.String())
+		}
+	}
+	incorrect := out.String()[strings.Index(out.String(), "4 incorrect of 12"):]
+	if strings.Contains(incorrect[:strings.Index(incorrect, "q done")], checkMark+" correct") {
+		t.Fatalf("incorrect-only page lists a correct answer:\n%s", incorrect)
+	}
+}
+
+func TestReviewReopensAndRequeuesQuestions(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "A", Options: map[string]string{"A": "Blue", "B": "Green"}},
+		{Domain: 5, Prompt: "Grass color?", Answer: "A", Options: map[string]string{"A": "Green", "B": "Blue"}, Explanation: "Chlorophyll."},
+	}
+	// Q2 is missed, reopened and requeued; Q9 does not exist, and Q1 is
+	// only looked at
+	var out bytes.Buffer
+	o := New(questions, WithIO(strings.NewReader("ab"+"9\r2\rr\r1\r\r\r"), &out), WithTerminal(fixedTerminal{width: 60, rows: 24, raw: true}),
+		WithOrdering(quiz.FileOrder), WithFeedback(quiz.Feedback{Silent: true})).Run(context.Background())
+	if len(o.Requeued) != 1 || o.Requeued[0].Prompt != "Grass color?" {
+		t.Fatalf("requeued %+v, want the grass question", o.Requeued)
+	}
+	for _, want := range []string{"There is no Q9 in the review.", "Chlorophyll.", "Correct answer: A", "Requeued. r takes it off again", "Requeued 1 question for a quick session."} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestConfirmAnswersMakesLettersSelect(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "B", Options: map[string]string{"A": "Green", "B": "Blue"}},
+	}
+	// a slip on A is changed to B before Enter confirms it
+	var out bytes.Buffer
+	o := New(questions, WithIO(strings.NewReader("ab\r\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}), WithConfirmAnswers()).Run(context.Background())
+	if o.Answered != 1 || o.Score != 1 {
+		t.Fatalf("outcome %+v, want B confirmed on the first attempt", o)
+	}
+	if !strings.Contains(out.String(), "Use ↑/↓ or A–D to select, Enter to confirm") {
+		t.Fatalf("hint does not say letters only select:\n%s", out.String())
+	}
+}
+
+func TestSearchRecallsEarlierTerms(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "A", Options: map[string]string{"A": "Blue", "B": "Green"}},
+		{Domain: 4, Prompt: "Grass color?", Answer: "B", Options: map[string]string{"A": "Blue", "B": "Green"}},
+	}
+	var added []string
+	// ↑ recalls "grass", which jumps past the sky question to be answered B
+	var out bytes.Buffer
+	o := New(questions, WithIO(strings.NewReader("/\x1b[A\r\nb\r\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}),
+		WithOrdering(quiz.FileOrder), WithSearchHistory([]string{"grass"}, func(term string) { added = append(added, term) })).Run(context.Background())
+	if o.Answered != 1 || o.Score != 1 {
+		t.Fatalf("outcome %+v, want the grass question answered right", o)
+	}
+	if !slices.Equal(added, []string{"grass"}) {
+		t.Fatalf("searches kept %q, want [grass]", added)
+	}
+}
+
+func TestSkipKeysJumpToUnansweredAndMissed(t *testing.T) {
+	questions := []quiz.Question{
+		{Domain: 4, Prompt: "Sky color?", Answer: "A", Options: map[string]string{"A": "Blue", "B": "Green"}},
+		{Domain: 4, Prompt: "Grass color?", Answer: "B", Options: map[string]string{"A": "Blue", "B": "Green"}},
+	}
+	// m finds nothing yet; u skips the sky question for the grass one
+	var out bytes.Buffer
+	o := New(questions, WithIO(strings.NewReader("mub\r\n"), &out), WithTerminal(fixedTerminal{width: 60, raw: true}),
+		WithOrdering(quiz.FileOrder)).Run(context.Background())
+	if o.Answered != 1 || o.Score != 1 {
+		t.Fatalf("outcome %+v, want the grass question answered right", o)
+	}
+	if !strings.Contains(out.String(), "No other missed question is waiting.") {
+		t.Fatalf("m with no misses did not say so:\n%s", out.String())
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// WithConfidence asks how sure the learner was after each first attempt, and
+// adds a calibration report to the summary.
+func WithConfidence() Option {
+	return func(a *App) {
+		a.confidence = true
+	}
+}
+
+// askConfidence prompts for a 1-3 rating before the answer is revealed. An
+// empty line skips the rating. ok is false if input ended.
+func (a *App) askConfidence() (quiz.Confidence, bool) {
+	fmt.Fprintln(a.out, colorize("\nHow sure were you?", colorCyan+colorBold))
+	for _, c := range quiz.Confidences {
+		fmt.Fprintf(a.out, "  %d) %s\n", c, c)
+	}
+	for {
+		fmt.Fprint(a.out, "Rating (Enter to skip): ")
+		line, ok := a.readLine()
+		if !ok {
+			return quiz.Unrated, false
+		}
+		if strings.TrimSpace(line) == "" {
+			return quiz.Unrated, true
+		}
+		if c, err := quiz.ParseConfidence(line); err == nil {
+			return c, true
+		}
+		fmt.Fprintln(a.out, colorize("Please enter 1, 2 or 3.", colorRed))
+	}
+}
+
+// printCalibration compares accuracy across confidence ratings.
+func (a *App) printCalibration(buckets []quiz.CalibrationBucket) {
+	if len(buckets) == 0 {
+		return
+	}
+	fmt.Fprintln(a.out, "\nConfidence calibration:")
+	for _, b := range buckets {
+		if b.Answered == 0 {
+			fmt.Fprintf(a.out, "  %-9s no answers\n", b.Label)
+			continue
+		}
+		fmt.Fprintf(a.out, "  %-9s %d of %d correct (%.1f%%)\n", b.Label, b.Correct, b.Answered, b.Percent())
+	}
+	if note := calibrationNote(buckets); note != "" {
+		fmt.Fprintln(a.out, colorize(note, colorYellow))
+	}
+}
+
+// calibrationNote flags the two common miscalibrations: confident answers
+// that are often wrong, and guesses that are usually right.
+func calibrationNote(buckets []quiz.CalibrationBucket) string {
+	var guess, sure quiz.CalibrationBucket
+	for _, b := range buckets {
+		switch b.Confidence {
+		case quiz.Guessing:
+			guess = b
+		case quiz.Sure:
+			sure = b
+		}
+	}
+	switch {
+	case sure.Answered > 0 && sure.Percent() < 75:
+		return "You were wrong on a lot of answers you felt sure about; slow down and re-read those topics."
+	case guess.Answered > 0 && guess.Percent() >= 75:
+		return "Your guesses were mostly right; you know more than you think."
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// FlashcardOutcome is the result of RunFlashcards.
+type FlashcardOutcome struct {
+	Reviewed    int                `json:"reviewed"`
+	Grades      map[quiz.Grade]int `json:"grades"`
+	Interrupted bool               `json:"interrupted"`
+}
+
+// WithGrader calls fn with each flashcard's self-grade, e.g. to update a
+// spaced-repetition schedule.
+func WithGrader(fn func(quiz.Question, quiz.Grade)) Option {
+	return func(a *App) {
+		a.grader = fn
+	}
+}
+
+// RunFlashcards shows each question as a flashcard, in order: the prompt
+// without its options, then, on Space, the answer and explanation, which the
+// learner grades from 1 (again) to 4 (easy). It stops when the cards run out,
+// input ends, or ctx is cancelled.
+func (a *App) RunFlashcards(ctx context.Context) FlashcardOutcome {
+	defer a.restoreOnPanic()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	o := FlashcardOutcome{Grades: map[quiz.Grade]int{}}
+	if a.signals {
+		defer a.catchInterrupts(cancel)()
+	}
+
+	seed := time.Now().UnixNano()
+	if a.seeded {
+		seed = a.seed
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for i, q := range a.questions {
+		if ctx.Err() != nil {
+			o.Interrupted = true
+			break
+		}
+		card := q
+		if q.IsTemplate() {
+			if v, _, err := q.Instantiate(rng); err == nil {
+				card = v
+			}
+		}
+		a.showCard(card, i+1, false)
+		if !a.waitForReveal() {
+			o.Interrupted = true
+			break
+		}
+		a.showCard(card, i+1, true)
+		g, ok := a.askGrade()
+		if !ok {
+			o.Interrupted = true
+			break
+		}
+		o.Reviewed++
+		o.Grades[g]++
+		if a.grader != nil {
+			a.grader(q, g)
+		}
+	}
+
+	a.clearScreen()
+	fmt.Fprintf(a.out, "Reviewed %d of %d cards", o.Reviewed, len(a.questions))
+	if o.Reviewed > 0 {
+		parts := make([]string, 0, len(quiz.Grades))
+		for _, g := range quiz.Grades {
+			parts = append(parts, fmt.Sprintf("%d %s", o.Grades[g], g))
+		}
+		fmt.Fprintf(a.out, ": %s", strings.Join(parts, ", "))
+	}
+	fmt.Fprintln(a.out, ".")
+	return o
+}
+
+// showCard draws card number n: the prompt, and once revealed the answer and
+// explanation with the grading keys.
+func (a *App) showCard(q quiz.Question, n int, revealed bool) {
+	width, rows := a.term.Size()
+	a.clearScreen()
+	lines := []string{fmt.Sprintf("Card %d of %d", n, len(a.questions))}
+	lines = append(lines, promptLines(fmt.Sprintf("Q (Domain %d):", q.Domain), q.Prompt, colorBold+colorCyan)...)
+	lines = append(lines, a.noteLines(q)...)
+	lines = append(lines, "")
+	if q.Image != "" {
+		lines = append(lines, a.imagePlaceholder(q), "")
+	}
+	if !revealed {
+		lines = append(lines, colorize("Recall the answer, then press Space to reveal it.", colorYellow))
+		a.renderBlockWithVerticalCenter(lines, width, rows)
+		return
+	}
+	answer := markdown.InlineANSI(q.Options[q.Answer], colorGreen)
+	lines = append(lines, colorize(fmt.Sprintf("Answer: %s) ", q.Answer), colorGreen+colorBold)+answer)
+	if q.Explanation != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(markdown.Plain(q.Explanation), "\n")...)
+	}
+	if q.Source != "" {
+		lines = append(lines, "", colorize("Source: "+q.Source, colorDim))
+	}
+	lines = append(lines, "", colorize("How well did you recall it? 1 again · 2 hard · 3 good · 4 easy", colorYellow))
+	a.renderBlockWithVerticalCenter(lines, width, rows)
+}
+
+// waitForReveal waits for Space (or Enter when typing lines). It reports
+// false if input ended or the learner pressed q.
+func (a *App) waitForReveal() bool {
+	if err := a.enableRaw(); err != nil {
+		line, ok := a.readLine()
+		return ok && strings.TrimSpace(strings.ToLower(line)) != "q"
+	}
+	defer a.leaveRaw()
+	for {
+		key, _, err := a.readKey()
+		if err != nil {
+			return false
+		}
+		switch key {
+		case ' ', '\n', '\r':
+			return true
+		case 'q', 'Q':
+			return false
+		}
+	}
+}
+
+// askGrade reads a 1-4 grade. ok is false if input ended or the learner
+// pressed q.
+func (a *App) askGrade() (quiz.Grade, bool) {
+	if err := a.enableRaw(); err == nil {
+		defer a.leaveRaw()
+		for {
+			key, _, err := a.readKey()
+			if err != nil || key == 'q' || key == 'Q' {
+				return 0, false
+			}
+			if g, err := quiz.ParseGrade(string(key)); err == nil {
+				return g, true
+			}
+		}
+	}
+	for {
+		fmt.Fprint(a.out, "Grade (1-4): ")
+		line, ok := a.readLine()
+		if !ok || strings.TrimSpace(strings.ToLower(line)) == "q" {
+			return 0, false
+		}
+		if g, err := quiz.ParseGrade(line); err == nil {
+			return g, true
+		}
+		fmt.Fprintln(a.out, colorize("Please enter 1, 2, 3 or 4.", colorRed))
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	_ "image/gif" // register decoders for question images
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// ImageMode selects how question images are drawn in the terminal.
+type ImageMode int
+
+const (
+	// ImagesAuto draws inline when the terminal is known to support it and
+	// falls back to a placeholder otherwise.
+	ImagesAuto ImageMode = iota
+	// ImagesPlaceholder prints the image's path or URL instead of the image.
+	ImagesPlaceholder
+	// ImagesITerm2 uses the iTerm2 inline image protocol (also understood by
+	// WezTerm and others).
+	ImagesITerm2
+	// ImagesSixel encodes images as DEC sixel graphics.
+	ImagesSixel
+)
+
+// ParseImageMode parses "auto", "placeholder", "iterm2" or "sixel".
+func ParseImageMode(s string) (ImageMode, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return ImagesAuto, nil
+	case "placeholder", "off", "none":
+		return ImagesPlaceholder, nil
+	case "iterm2":
+		return ImagesITerm2, nil
+	case "sixel":
+		return ImagesSixel, nil
+	}
+	return ImagesAuto, fmt.Errorf("unknown image mode %q (want auto, placeholder, iterm2 or sixel)", s)
+}
+
+// WithImages sets how question images are drawn. Relative image paths are
+// resolved against dir, normally the directory holding the bank.
+func WithImages(mode ImageMode, dir string) Option {
+	return func(a *App) {
+		a.imageMode = mode
+		a.mediaDir = dir
+	}
+}
+
+// maxSixelWidth caps the width in pixels of sixel output so large diagrams
+// don't overflow the terminal.
+const maxSixelWidth = 800
+
+// detectImageMode guesses the terminal's inline image support from the
+// environment.
+func detectImageMode() ImageMode {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode":
+		return ImagesITerm2
+	}
+	term := os.Getenv("TERM")
+	if strings.Contains(term, "sixel") || term == "mlterm" || strings.HasPrefix(term, "foot") {
+		return ImagesSixel
+	}
+	return ImagesPlaceholder
+}
+
+// imagePath returns the local path of q's image, or its URL.
+func (a *App) imagePath(q quiz.Question) string {
+	if q.ImageIsURL() || filepath.IsAbs(q.Image) {
+		return q.Image
+	}
+	return filepath.Join(a.mediaDir, q.Image)
+}
+
+// inlineImage returns the escape sequence that draws q's image, or "" when it
+// should be shown as a placeholder instead.
+func (a *App) inlineImage(q quiz.Question) string {
+	mode := a.imageMode
+	if mode == ImagesAuto {
+		if _, ok := a.term.(ttyTerminal); !ok {
+			return ""
+		}
+		mode = detectImageMode()
+	}
+	if mode == ImagesPlaceholder || q.Image == "" || q.ImageIsURL() {
+		return ""
+	}
+	data, err := os.ReadFile(a.imagePath(q))
+	if err != nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	switch mode {
+	case ImagesITerm2:
+		fmt.Fprintf(&buf, "\033]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a",
+			len(data), base64.StdEncoding.EncodeToString(data))
+	case ImagesSixel:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return ""
+		}
+		writeSixel(&buf, img)
+	}
+	return buf.String()
+}
+
+// imagePlaceholder is the line shown in place of an image that isn't drawn:
+// its alt text followed by where to find it.
+func (a *App) imagePlaceholder(q quiz.Question) string {
+	return colorize(imageCaption(q)+" "+a.imagePath(q), colorYellow)
+}
+
+// imageCaption is the alt text line shown with every image, so screen readers
+// and braille displays get the description even when the image is drawn.
+func imageCaption(q quiz.Question) string {
+	alt := strings.TrimSpace(q.ImageAlt)
+	if alt == "" {
+		alt = "no description"
+	}
+	return "[image: " + alt + "]"
+}
+
+// writeSixel encodes img as a sixel graphic quantized to the web-safe palette.
+func writeSixel(w io.Writer, img image.Image) {
+	img = shrink(img, maxSixelWidth)
+	b := img.Bounds()
+	p := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), palette.WebSafe)
+	draw.FloydSteinberg.Draw(p, p.Bounds(), img, b.Min)
+	width, height := p.Rect.Dx(), p.Rect.Dy()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\033Pq\"1;1;%d;%d", width, height)
+	for i, c := range p.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+	row := make([]byte, width)
+	for y := 0; y < height; y += 6 {
+		var used [256]bool
+		for dy := 0; dy < 6 && y+dy < height; dy++ {
+			for x := 0; x < width; x++ {
+				used[p.ColorIndexAt(x, y+dy)] = true
+			}
+		}
+		first := true
+		for c := range used {
+			if !used[c] {
+				continue
+			}
+			if !first {
+				bw.WriteByte('$')
+			}
+			first = false
+			for x := 0; x < width; x++ {
+				bits := 0
+				for dy := 0; dy < 6 && y+dy < height; dy++ {
+					if int(p.ColorIndexAt(x, y+dy)) == c {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = byte(63 + bits)
+			}
+			fmt.Fprintf(bw, "#%d", c)
+			writeSixelRow(bw, row)
+		}
+		bw.WriteByte('-')
+	}
+	bw.WriteString("\033\\")
+	bw.Flush()
+}
+
+// writeSixelRow writes row with runs of four or more repeated sixels
+// compressed as "!<count><sixel>".
+func writeSixelRow(w *bufio.Writer, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n >= 4 {
+			fmt.Fprintf(w, "!%d%c", n, row[i])
+		} else {
+			w.Write(row[i:j])
+		}
+		i = j
+	}
+}
+
+// shrink scales img down with nearest-neighbour sampling so it is at most
+// maxWidth pixels wide.
+func shrink(img image.Image, maxWidth int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= maxWidth {
+		return img
+	}
+	height := max(b.Dy()*maxWidth/b.Dx(), 1)
+	out := image.NewRGBA(image.Rect(0, 0, maxWidth, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < maxWidth; x++ {
+			out.Set(x, y, img.At(b.Min.X+x*b.Dx()/maxWidth, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	return out
+}
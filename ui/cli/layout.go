@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The compact layout is used on terminals narrower than compactWidth
+// columns or shorter than compactRows rows, such as tmux splits, where the
+// centred layout would overflow.
+const (
+	compactWidth = 60
+	compactRows  = 20
+)
+
+// WithCompactLayout always draws the compact layout: screens start at the
+// top instead of being centred, option text wraps under itself, and the
+// header and key hints are abbreviated. Without it the compact layout is
+// only used on small terminals.
+func WithCompactLayout() Option {
+	return func(a *App) {
+		a.compact = true
+	}
+}
+
+// compactLayout reports whether to draw the compact layout on a terminal of
+// the given size; zeros mean the size is unknown.
+func (a *App) compactLayout(width, rows int) bool {
+	return a.compact || (width > 0 && width < compactWidth) || (rows > 0 && rows < compactRows)
+}
+
+// formatCompactProgress is the progress line of the compact layout, e.g.
+// "3/10 answered, 7 left".
+func formatCompactProgress(completed, total int) string {
+	if total <= 0 {
+		return ""
+	}
+	completed = min(max(completed, 0), total)
+	return fmt.Sprintf("%s%d/%d answered%s, %d left", colorGreen, completed, total, colorReset, total-completed)
+}
+
+// compactHint is the key hint line of the compact layout.
+func (a *App) compactHint() string {
+	keys := []string{"↑/↓ A–D Enter"}
+	if a.confirm {
+		keys[0] = "↑/↓ A–D select, Enter"
+	}
+	keys = append(keys, "x strike")
+	if a.noteSet != nil {
+		keys = append(keys, "n note")
+	}
+	keys = append(keys, "p pause", "q quit")
+	switch {
+	case a.remote != nil:
+	case a.feedback.Silent:
+		keys = append(keys, "u skip")
+	default:
+		keys = append(keys, "u/m skip")
+	}
+	return strings.Join(keys, " · ")
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// doubleClick is the longest gap between two clicks on an option that
+// submits it.
+const doubleClick = 400 * time.Millisecond
+
+// WithMouse turns on xterm mouse reporting on the question screen: clicking
+// an option selects it, double-clicking submits it, and the scroll wheel
+// moves the selection. The terminal's own text selection needs Shift while
+// it is on.
+func WithMouse() Option {
+	return func(a *App) {
+		a.mouse = true
+	}
+}
+
+// enableMouse starts mouse reporting, in SGR encoding, if WithMouse asked
+// for it; leaveRaw stops it again.
+func (a *App) enableMouse() {
+	if !a.mouse {
+		return
+	}
+	a.mu.Lock()
+	a.mouseOn = true
+	a.mu.Unlock()
+	fmt.Fprint(a.out, "\033[?1000h\033[?1006h")
+}
+
+// mouseEvent is a button press or release, or a wheel step, at a screen
+// cell counted from 1.
+type mouseEvent struct {
+	button   int
+	col, row int
+	release  bool
+}
+
+const (
+	mouseLeft      = 0
+	mouseWheelUp   = 64
+	mouseWheelDown = 65
+)
+
+// readMouse reads the rest of an SGR mouse report, "button;col;row" and M
+// for a press or m for a release, once readKey has returned its ESC [ <.
+func (a *App) readMouse() (mouseEvent, bool) {
+	var b strings.Builder
+	for b.Len() < 32 {
+		c, err := a.in.ReadByte()
+		if err != nil {
+			return mouseEvent{}, false
+		}
+		if c == 'M' || c == 'm' {
+			parts := strings.Split(b.String(), ";")
+			if len(parts) != 3 {
+				return mouseEvent{}, false
+			}
+			var n [3]int
+			for i, p := range parts {
+				if n[i], err = strconv.Atoi(p); err != nil {
+					return mouseEvent{}, false
+				}
+			}
+			return mouseEvent{button: n[0], col: n[1], row: n[2], release: c == 'm'}, true
+		}
+		b.WriteByte(c)
+	}
+	return mouseEvent{}, false
+}
+
+// optionRows maps the screen rows of the options among lines, drawn from
+// row top on and wrapped to width, to the options' indexes; optionLine holds
+// the line each option is drawn on before wrapping. A top of zero, for a
+// screen whose layout is unknown, maps no rows.
+func optionRows(lines []string, optionLine []int, width, top int) map[int]int {
+	if top <= 0 {
+		return nil
+	}
+	owner := make(map[int]int, len(optionLine))
+	for i, l := range optionLine {
+		owner[l] = i
+	}
+	rows := map[int]int{}
+	row := top
+	for i, l := range lines {
+		n := 1
+		if width > 0 {
+			n = len(wrapLine(l, width))
+		}
+		if opt, ok := owner[i]; ok {
+			for r := row; r < row+n; r++ {
+				rows[r] = opt
+			}
+		}
+		row += n
+	}
+	return rows
+}
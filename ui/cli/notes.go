@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// WithNotes lets the learner keep a note on each question: the n key edits
+// it through set, and get supplies the note shown whenever the question
+// comes up.
+func WithNotes(get func(quiz.Question) string, set func(quiz.Question, string)) Option {
+	return func(a *App) {
+		a.noteGet = get
+		a.noteSet = set
+	}
+}
+
+// noteLines returns the lines showing q's note, if it has one.
+func (a *App) noteLines(q quiz.Question) []string {
+	if a.noteGet == nil {
+		return nil
+	}
+	note := a.noteGet(q)
+	if note == "" {
+		return nil
+	}
+	return []string{"", colorize("Note: "+note, colorYellow)}
+}
+
+// editNote reads a new note for q in line mode. An empty line keeps the note
+// and "-" deletes it. It reports false if input ended.
+func (a *App) editNote(q quiz.Question) bool {
+	a.clearScreen()
+	current := a.noteGet(q)
+	if current != "" {
+		fmt.Fprintf(a.out, "Current note: %s\n", current)
+		fmt.Fprintln(a.out, "Type a new note, - to delete it, or just Enter to keep it.")
+	} else {
+		fmt.Fprintln(a.out, "Type a note for this question, or just Enter to cancel.")
+	}
+	fmt.Fprint(a.out, "Note: ")
+	line, ok := a.readLine()
+	if !ok {
+		return false
+	}
+	switch line = strings.TrimSpace(line); line {
+	case "":
+	case "-":
+		a.noteSet(q, "")
+	default:
+		a.noteSet(q, line)
+	}
+	return true
+}
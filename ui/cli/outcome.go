@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+
+	"quiz-cli/quiz"
+)
+
+// Exit codes for the quiz-cli binary, so wrapper scripts and CI jobs can branch
+// on the outcome of a run.
+const (
+	ExitPass        = 0
+	ExitFail        = 2
+	ExitInterrupted = 3
+	ExitBankInvalid = 4
+)
+
+// Outcome is the final result of a Run, graded on first attempts.
+type Outcome struct {
+	Score    int     `json:"score"`
+	Answered int     `json:"answered"`
+	Total    int     `json:"total"`
+	Percent  float64 `json:"percent"`
+	// Mastered counts the questions answered that were eventually answered
+	// correctly, retries included; MasteredPercent is its share of Answered,
+	// beside Percent for the first try.
+	Mastered        int     `json:"mastered"`
+	MasteredPercent float64 `json:"masteredPercent"`
+	// Points, PossiblePoints and WeightedPercent grade the same answers with
+	// each question counting its weight and wrong answers losing Penalty of
+	// it; the pass mark applies to WeightedPercent, which equals Percent when
+	// no question is weighted and there is no penalty.
+	Points          float64 `json:"points"`
+	PossiblePoints  float64 `json:"possiblePoints"`
+	WeightedPercent float64 `json:"weightedPercent"`
+	Penalty         float64 `json:"penalty,omitempty"`
+	PassMark        float64 `json:"passMark"`
+	Passed          bool    `json:"passed"`
+	Interrupted     bool    `json:"interrupted"`
+	// Sections is set for sectioned exams (see WithSections).
+	Sections []SectionOutcome `json:"sections,omitempty"`
+	// Calibration is set when answers were rated (see WithConfidence).
+	Calibration []quiz.CalibrationBucket `json:"calibration,omitempty"`
+	// SuddenDeath is set when the run ended at its first miss (see
+	// WithSuddenDeath); Streak is the correct answers before it.
+	SuddenDeath bool `json:"suddenDeath,omitempty"`
+	Streak      int  `json:"streak,omitempty"`
+	// MasteryStop is set when the run had a mastery rule (see
+	// WithMasteryStop).
+	MasteryStop *MasteryStopOutcome `json:"masteryStop,omitempty"`
+	// Pacing is set when questions had shrinking time limits (see
+	// WithPacing).
+	Pacing *PacingOutcome `json:"pacing,omitempty"`
+	// Requeued holds the questions picked from the review for a quick
+	// session of their own.
+	Requeued []quiz.Question `json:"-"`
+}
+
+// MasteryStopOutcome says whether a run with a mastery rule ended early
+// because every domain met it, and where each domain stood.
+type MasteryStopOutcome struct {
+	Rule    string               `json:"rule"`
+	Reached bool                 `json:"reached"`
+	Domains []quiz.DomainMastery `json:"domains"`
+}
+
+// PacingOutcome says how many first attempts of a paced run came after
+// their question's time limit.
+type PacingOutcome struct {
+	Rule string `json:"rule"`
+	Late int    `json:"late"`
+}
+
+// ExitCode maps the outcome to one of the Exit* codes.
+func (o Outcome) ExitCode() int {
+	switch {
+	case o.Interrupted:
+		return ExitInterrupted
+	case o.Passed:
+		return ExitPass
+	default:
+		return ExitFail
+	}
+}
+
+func (a *App) outcome(session *quiz.Session, interrupted bool) Outcome {
+	o := Outcome{
+		Total:       len(a.questions),
+		PassMark:    a.passMark,
+		Interrupted: interrupted,
+		Penalty:     a.penalty,
+	}
+	if session != nil {
+		o.Score, o.Answered = session.Score()
+		o.Mastered, _ = session.Progress()
+		o.Points, o.PossiblePoints = session.WeightedScore()
+		o.Sections = sectionOutcomes(session.Sections())
+		o.Calibration = quiz.Calibrate(session.Results())
+		if o.SuddenDeath = session.SuddenDeath(); o.SuddenDeath {
+			_, o.Streak = session.Streak()
+		}
+		if reached, domains := session.MasteryStop(); domains != nil {
+			o.MasteryStop = &MasteryStopOutcome{Rule: a.masteryStop.String(), Reached: reached, Domains: domains}
+		}
+		if p, ok := session.Pacing(); ok {
+			o.Pacing = &PacingOutcome{Rule: p.String()}
+			for _, res := range session.Results() {
+				if res.TimedOut {
+					o.Pacing.Late++
+				}
+			}
+		}
+	}
+	if o.Answered > 0 {
+		o.Percent = float64(o.Score) * 100 / float64(o.Answered)
+		o.MasteredPercent = float64(o.Mastered) * 100 / float64(o.Answered)
+	}
+	if o.PossiblePoints > 0 {
+		o.WeightedPercent = o.Points * 100 / o.PossiblePoints
+	}
+	o.Passed = !interrupted && o.WeightedPercent >= a.passMark
+	return o
+}
+
+func writeJSON(w io.Writer, v any) {
+	enc := json.NewEncoder(w)
+	_ = enc.Encode(v)
+}
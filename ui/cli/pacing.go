@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+)
+
+// paceLine is the countdown shown above each question of a paced run: the
+// time left of the question's limit, turning red in its last quarter.
+func (a *App) paceLine() string {
+	session := a.Session()
+	if session == nil {
+		return ""
+	}
+	limit, left, ok := session.TimeLimit()
+	if !ok {
+		return ""
+	}
+	// count whole seconds down from the limit, reaching 0:00 as time runs out
+	left = (left + time.Second - 1).Truncate(time.Second)
+	line := fmt.Sprintf("⏱ %s left of %s for this question", formatDuration(left), formatDuration(limit))
+	if left <= limit/4 {
+		return colorize(line, colorRed+colorBold)
+	}
+	return colorize(line, colorYellow)
+}
+
+// waitPaced waits for a key on the question screen of a paced run, redrawing
+// it with render as each second of the question's time limit goes by, and
+// reports false if the time ran out first. Without pacing it returns true
+// at once.
+func (a *App) waitPaced(render func()) bool {
+	session := a.Session()
+	if session == nil {
+		return true
+	}
+	for {
+		_, left, ok := session.TimeLimit()
+		if !ok {
+			return true
+		}
+		if left <= 0 {
+			return false
+		}
+		tick := left % time.Second
+		if tick == 0 {
+			tick = time.Second
+		}
+		if a.waitInput(tick) {
+			return true
+		}
+		render()
+	}
+}
+
+// printPacing reports how a paced run's answers kept to their time limits.
+func (a *App) printPacing(o Outcome) {
+	p := o.Pacing
+	if p == nil {
+		return
+	}
+	if p.Late == 0 {
+		fmt.Fprintln(a.out, colorize(fmt.Sprintf("Pacing %s: every answer came in time.", p.Rule), colorGreen))
+		return
+	}
+	fmt.Fprintln(a.out, colorize(fmt.Sprintf("Pacing %s: %d of %d answers ran out of time and count as misses.", p.Rule, p.Late, o.Answered), colorYellow))
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reviewColumns lays the review rows out down cols columns of colWidth
+// cells, filling each column before the next.
+func reviewColumns(rows []string, cols, colWidth int) []string {
+	cols = max(cols, 1)
+	perCol := (len(rows) + cols - 1) / cols
+	lines := make([]string, 0, perCol)
+	for r := 0; r < perCol; r++ {
+		var parts []string
+		for c := 0; c < cols; c++ {
+			if idx := c*perCol + r; idx < len(rows) {
+				parts = append(parts, padRight(rows[idx], colWidth))
+			}
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(parts, ""), " "))
+	}
+	return lines
+}
+
+// pageReview shows the review rows a page at a time when, laid out in cols
+// columns, they would not fit on the terminal. Space, → and ↓ turn a page,
+// ←, ↑ and b turn back, i switches to the incorrect answers only and back,
+// and q or Enter closes it. It reports false, having shown nothing, when the
+// rows fit or the terminal has no raw mode, so the caller prints them.
+func (a *App) pageReview(rows []string, correct []bool, cols, colWidth int) bool {
+	_, termRows := a.term.Size()
+	cols = max(cols, 1)
+	// a page leaves a line each for the title and the key hints
+	height := termRows - 2
+	if termRows <= 0 || (len(rows)+cols-1)/cols < termRows || height < 1 {
+		return false
+	}
+	if err := a.enableRaw(); err != nil {
+		return false
+	}
+	defer a.leaveRaw()
+
+	var missed []string
+	for i, row := range rows {
+		if !correct[i] {
+			missed = append(missed, row)
+		}
+	}
+	perPage := cols * height
+	onlyMissed, page := false, 0
+	for {
+		shown := rows
+		if onlyMissed {
+			shown = missed
+		}
+		pages := max((len(shown)+perPage-1)/perPage, 1)
+		page = min(max(page, 0), pages-1)
+		title := fmt.Sprintf("Review: page %d of %d, all %d answers", page+1, pages, len(rows))
+		if onlyMissed {
+			title = fmt.Sprintf("Review: page %d of %d, %d incorrect of %d", page+1, pages, len(missed), len(rows))
+		}
+		a.clearScreen()
+		fmt.Fprintln(a.out, colorize(title, colorBold+colorCyan))
+		lines := reviewColumns(shown[min(page*perPage, len(shown)):min((page+1)*perPage, len(shown))], cols, colWidth)
+		if len(shown) == 0 {
+			lines = []string{"No incorrect answers."}
+		}
+		for _, line := range lines {
+			fmt.Fprintln(a.out, line)
+		}
+		for i := len(lines); i < height; i++ {
+			fmt.Fprintln(a.out)
+		}
+		filter := "i incorrect only"
+		if onlyMissed {
+			filter = "i all answers"
+		}
+		fmt.Fprint(a.out, colorize("Space/→ next · ←/b back · "+filter+" · q done", colorYellow))
+
+		key, seq, err := a.readKey()
+		if err != nil {
+			break
+		}
+		switch {
+		case key == ' ':
+			page++
+		case key == 'b' || key == 'B':
+			page--
+		case key == 'i' || key == 'I':
+			onlyMissed, page = !onlyMissed, 0
+		case key == 'q' || key == 'Q' || key == '\n' || key == '\r':
+			a.clearScreen()
+			return true
+		case key == 27 && len(seq) == 2 && seq[0] == '[':
+			switch seq[1] {
+			case 'B', 'C': // down, right
+				page++
+			case 'A', 'D': // up, left
+				page--
+			}
+		}
+	}
+	a.clearScreen()
+	return true
+}
@@ -0,0 +1,19 @@
+package cli
+
+import "fmt"
+
+// restoreOnPanic, deferred at the top of each run and of the goroutines that
+// draw, puts the terminal back the way the shell had it when a panic gets
+// that far: raw mode and mouse reporting off and the half-drawn question
+// cleared. It then shows the panic and panics on with it, so the program
+// still ends with the stack trace and a failing exit code.
+func (a *App) restoreOnPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	a.leaveRaw()
+	clearScreen(a.out)
+	fmt.Fprintf(a.out, "The quiz crashed: %v\n", r)
+	panic(r)
+}
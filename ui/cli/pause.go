@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+
+	"quiz-cli/quiz"
+	"quiz-cli/replay"
+)
+
+// WithBreaks suggests breaks in long runs as b says: the clocks stop, as for
+// a pause, until the learner presses Enter.
+func WithBreaks(b quiz.Breaks) Option {
+	return func(a *App) {
+		a.breaks = b
+	}
+}
+
+// pause pauses the session for the p key: the question is blanked, the
+// session's clocks stop, and both start again once resume returns; resume
+// reports false when input ends. The time paused is left out of the answer
+// and section times. Connected to a server, its session is paused instead.
+func (a *App) pause(index int, resume func() bool) bool {
+	if session := a.Session(); session != nil {
+		session.Pause()
+	}
+	return a.hold(index, []string{
+		colorize("Paused", colorBold+colorCyan),
+		"",
+		colorize("The clock is stopped. Press p or Enter to resume.", colorYellow),
+	}, resume)
+}
+
+// takeBreak suggests the break the session says is due before the question
+// at index (see WithBreaks) and waits for Enter. It reports false when input
+// ends.
+func (a *App) takeBreak(index int) bool {
+	session := a.Session()
+	session.TakeBreak()
+	return a.hold(index, []string{
+		colorize("Time for a break", colorBold+colorCyan),
+		"",
+		fmt.Sprintf("Break %d. Stand up, stretch and look away from the screen for a few minutes.", session.BreaksTaken()),
+		colorize("The clock is stopped. Press Enter when you're back.", colorYellow),
+	}, a.waitLineResume)
+}
+
+// hold shows lines while the session stands paused, until resume returns,
+// then resumes it.
+func (a *App) hold(index int, lines []string, resume func() bool) bool {
+	if session := a.Session(); session != nil {
+		defer session.Resume()
+	}
+	if r := a.remote; r != nil {
+		r.pause(true)
+		defer r.pause(false)
+	}
+	if a.replay != nil {
+		a.replay.Record(replay.Event{Kind: replay.Pause, Index: index})
+		defer a.replay.Record(replay.Event{Kind: replay.Resume, Index: index})
+	}
+	width, rows := a.term.Size()
+	a.clearScreen()
+	a.renderBlockWithVerticalCenter(lines, width, rows)
+	return resume()
+}
+
+// waitKeyResume waits in raw mode for p or Enter.
+func (a *App) waitKeyResume() bool {
+	for {
+		key, _, err := a.readKey()
+		if err != nil {
+			return false
+		}
+		switch key {
+		case 'p', 'P', '\n', '\r':
+			return true
+		}
+	}
+}
+
+// waitLineResume waits for a line of typed input.
+func (a *App) waitLineResume() bool {
+	_, ok := a.readLine()
+	return ok
+}
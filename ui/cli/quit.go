@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"quiz-cli/quiz"
+)
+
+// quitPrompt asks to confirm the q key, saying what becomes of the run.
+func (a *App) quitPrompt() string {
+	switch {
+	case a.remote != nil:
+		return "Quit? The server keeps your place. (y/n)"
+	case a.autosave != nil:
+		return "Save and quit? (y/n)"
+	default:
+		return "Quit? Autosave is off, so this run cannot be resumed. (y/n)"
+	}
+}
+
+// confirmQuitKey waits in raw mode for the answer to quitPrompt, with the
+// session's clocks stopped, and reports whether it was y.
+func (a *App) confirmQuitKey() bool {
+	if session := a.Session(); session != nil {
+		session.Pause()
+		defer session.Resume()
+	}
+	key, _, err := a.readKey()
+	return err == nil && (key == 'y' || key == 'Y')
+}
+
+// confirmQuitLine asks quitPrompt on a line of its own for typed input and
+// reports whether the answer was y.
+func (a *App) confirmQuitLine() bool {
+	fmt.Fprint(a.out, a.quitPrompt()+" ")
+	line, ok := a.readLine()
+	return ok && (line == "y" || line == "Y" || line == "yes")
+}
+
+// saveAndQuit ends a run the learner quit with q: it saves the progress for
+// the next start, when autosave is on and there is any, and reports the run
+// so far as interrupted.
+func (a *App) saveAndQuit(session *quiz.Session) Outcome {
+	a.leaveRaw()
+	fmt.Fprintln(a.out)
+	if a.autosave != nil && session.AttemptedCount() > 0 {
+		if err := a.autosave.Save(context.Background(), session); err != nil {
+			fmt.Fprintln(a.out, colorize("Could not save your progress: "+err.Error(), colorRed))
+		} else {
+			fmt.Fprintln(a.out, colorize("Progress saved; start the quiz again to pick up where you left off.", colorGreen))
+		}
+	}
+	return a.finish(session, true)
+}
@@ -0,0 +1,434 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// remote is a webapp server's session, driven through its REST API so the
+// terminal and any browsers on the same server share one session.
+type remote struct {
+	base   string
+	client *http.Client
+	ctx    context.Context
+	// section is the current section of a sectioned exam, one of
+	// sectionCount, as of the last state fetched.
+	section      *quiz.SectionSummary
+	sectionCount int
+}
+
+// remoteState mirrors the server's /api/state response.
+type remoteState struct {
+	Finished bool `json:"finished"`
+	Question *struct {
+		ID      string            `json:"id"`
+		Index   int               `json:"index"`
+		Domain  int               `json:"domain"`
+		Prompt  string            `json:"prompt"`
+		Options map[string]string `json:"options"`
+		Notice  string            `json:"notice"`
+	} `json:"question"`
+	Progress struct {
+		Completed int `json:"completed"`
+		Total     int `json:"total"`
+	} `json:"progress"`
+	Section         *remoteSection `json:"section"`
+	SectionIntro    bool           `json:"sectionIntro"`
+	PreviousSection *remoteSection `json:"previousSection"`
+	Confidence      bool           `json:"confidence"`
+	Paused          bool           `json:"paused"`
+	Blind           bool           `json:"blind"`
+}
+
+type remoteSection struct {
+	Index            int     `json:"index"`
+	Count            int     `json:"count"`
+	Domain           int     `json:"domain"`
+	Title            string  `json:"title"`
+	Total            int     `json:"total"`
+	Answered         int     `json:"answered"`
+	Correct          int     `json:"correct"`
+	BudgetSeconds    float64 `json:"budgetSeconds"`
+	ElapsedSeconds   float64 `json:"elapsedSeconds"`
+	RemainingSeconds float64 `json:"remainingSeconds"`
+	Started          bool    `json:"started"`
+	Locked           bool    `json:"locked"`
+	TimedOut         bool    `json:"timedOut"`
+}
+
+func (s remoteSection) summary() quiz.SectionSummary {
+	return quiz.SectionSummary{
+		Section: quiz.Section{
+			Domain: s.Domain,
+			Budget: seconds(s.BudgetSeconds),
+			Name:   s.Title,
+		},
+		Index:     s.Index,
+		Total:     s.Total,
+		Answered:  s.Answered,
+		Correct:   s.Correct,
+		Started:   s.Started,
+		Elapsed:   seconds(s.ElapsedSeconds),
+		Remaining: seconds(s.RemainingSeconds),
+		Locked:    s.Locked,
+		TimedOut:  s.TimedOut,
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+type remoteAnswer struct {
+	Result        quiz.Result `json:"result"`
+	Finished      bool        `json:"finished"`
+	TimeUp        bool        `json:"timeUp"`
+	CorrectAnswer string      `json:"correctAnswer"`
+	Source        string      `json:"source"`
+	Silent        bool        `json:"silent"`
+}
+
+type remoteSummary struct {
+	Score           int     `json:"score"`
+	Answered        int     `json:"answered"`
+	Total           int     `json:"total"`
+	Percent         float64 `json:"percent"`
+	Mastered        int     `json:"mastered"`
+	MasteredPercent float64 `json:"masteredPercent"`
+	Points          float64 `json:"points"`
+	PossiblePoints  float64 `json:"possiblePoints"`
+	WeightedPercent float64 `json:"weightedPercent"`
+	Penalty         float64 `json:"penalty"`
+	Rows            []struct {
+		Correct       bool            `json:"correct"`
+		UserAnswer    string          `json:"userAnswer"`
+		CorrectAnswer string          `json:"correctAnswer"`
+		Source        string          `json:"source"`
+		Weight        float64         `json:"weight"`
+		Confidence    quiz.Confidence `json:"confidence"`
+	} `json:"rows"`
+	Sections    []remoteSection          `json:"sections"`
+	Calibration []quiz.CalibrationBucket `json:"calibration"`
+	SuddenDeath bool                     `json:"suddenDeath"`
+	Streak      int                      `json:"streak"`
+}
+
+func newRemote(ctx context.Context, baseURL string) (*remote, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("server address must be an http:// or https:// URL, got %q", baseURL)
+	}
+	return &remote{
+		base:   strings.TrimRight(u.String(), "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+		ctx:    ctx,
+	}, nil
+}
+
+// call sends body (if any) as JSON to path and decodes the response into out.
+func (r *remote) call(method, path string, body, out any) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(r.ctx, method, r.base+path, &payload)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func (r *remote) state() (remoteState, error) {
+	var st remoteState
+	err := r.call(http.MethodGet, "/api/state", nil, &st)
+	if err == nil {
+		r.track(st)
+	}
+	return st, err
+}
+
+func (r *remote) startSection() (remoteState, error) {
+	var st remoteState
+	err := r.call(http.MethodPost, "/api/section/start", struct{}{}, &st)
+	if err == nil {
+		r.track(st)
+	}
+	return st, err
+}
+
+func (r *remote) track(st remoteState) {
+	r.section, r.sectionCount = nil, 0
+	if st.Section != nil {
+		sec := st.Section.summary()
+		r.section, r.sectionCount = &sec, st.Section.Count
+	}
+}
+
+// pause pauses or resumes the server's session. It is best effort: a server
+// without /api/pause just keeps its clock running.
+func (r *remote) pause(on bool) {
+	var st remoteState
+	if r.call(http.MethodPost, "/api/pause", struct {
+		Paused bool `json:"paused"`
+	}{on}, &st) == nil {
+		r.track(st)
+	}
+}
+
+func (r *remote) answer(answer string, c quiz.Confidence) (remoteAnswer, error) {
+	req := struct {
+		Answer     string `json:"answer"`
+		Confidence string `json:"confidence,omitempty"`
+	}{Answer: answer}
+	if c != quiz.Unrated {
+		req.Confidence = c.String()
+	}
+	var resp remoteAnswer
+	err := r.call(http.MethodPost, "/api/answer", req, &resp)
+	return resp, err
+}
+
+// jump asks the server to bring the question matching term to the front,
+// asking it again if again is set, and returns its bank position and prompt,
+// or -1, with why it could not be brought forward.
+func (r *remote) jump(term string, again bool) (int, quiz.Question, error) {
+	var resp struct {
+		Found    bool   `json:"found"`
+		Index    int    `json:"index"`
+		Domain   int    `json:"domain"`
+		Prompt   string `json:"prompt"`
+		Mastered bool   `json:"mastered"`
+		Error    string `json:"error"`
+	}
+	req := struct {
+		Term  string `json:"term"`
+		Again bool   `json:"again,omitempty"`
+	}{term, again}
+	if err := r.call(http.MethodPost, "/api/jump", req, &resp); err != nil || !resp.Found {
+		return -1, quiz.Question{}, nil
+	}
+	q := quiz.Question{Domain: resp.Domain, Prompt: resp.Prompt}
+	switch {
+	case resp.Mastered:
+		return resp.Index - 1, q, quiz.ErrMastered
+	case resp.Error != "":
+		return resp.Index - 1, q, errors.New(resp.Error)
+	}
+	return resp.Index - 1, q, nil
+}
+
+func (r *remote) summary() (remoteSummary, error) {
+	var sum remoteSummary
+	err := r.call(http.MethodGet, "/api/summary", nil, &sum)
+	return sum, err
+}
+
+// RunRemote drives the session of the quiz server at baseURL (as started by
+// "quiz-cli serve") instead of a local one: questions come from the server's
+// API and answers are submitted to it, so browsers on the same server see
+// every answer and vice versa. The App's questions are not used. It returns
+// once the session finishes or input ends, with the outcome graded by the
+// server; an interrupt leaves the session running on the server.
+func (a *App) RunRemote(ctx context.Context, baseURL string) (o Outcome, err error) {
+	defer a.restoreOnPanic()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r, err := newRemote(ctx, baseURL)
+	if err != nil {
+		return Outcome{}, err
+	}
+	st, err := r.state()
+	if err != nil {
+		return Outcome{}, fmt.Errorf("connect to %s: %w", r.base, err)
+	}
+	a.remote = r
+	defer func() { a.remote = nil }()
+	if a.signals {
+		defer a.catchInterrupts(cancel)()
+		defer func() {
+			if a.stopped() {
+				// whatever the interrupt cut short, the server has the
+				// session, so there is nothing to save or grade here
+				a.leaveRaw()
+				fmt.Fprintf(a.out, "\nDisconnected; the session continues on %s.\n", r.base)
+				o, err = Outcome{Interrupted: true}, nil
+			}
+		}()
+	}
+	notice := a.notice
+	defer func() { a.notice = notice }()
+
+	fmt.Fprintln(a.out, colorize("CSSLP Review Quiz (Domains 4-8)", colorBold+colorCyan))
+	fmt.Fprintln(a.out, "-------------------------------")
+	fmt.Fprintf(a.out, "Connected to %s; answers are shared with its web UI.\n", r.base)
+
+	// first holds the questions this client has answered, so a requeued
+	// question is not rated again.
+	first := map[string]bool{}
+	interrupted := false
+	for !st.Finished {
+		// the server's -feedback blind hides the progress here too
+		a.feedback.Blind = st.Blind
+		if st.SectionIntro && st.Section != nil {
+			var prev *quiz.SectionSummary
+			if st.PreviousSection != nil {
+				sec := st.PreviousSection.summary()
+				prev = &sec
+			}
+			if !a.showSectionIntro(prev, st.Section.summary(), st.Section.Count) {
+				interrupted = true
+				break
+			}
+			if st, err = r.startSection(); err != nil {
+				return Outcome{}, err
+			}
+			continue
+		}
+		if st.Paused {
+			// paused from the web UI; Enter here resumes it
+			if !a.pause(-1, a.waitLineResume) {
+				interrupted = true
+				break
+			}
+			if st, err = r.state(); err != nil {
+				return Outcome{}, err
+			}
+			continue
+		}
+		if st.Question == nil {
+			break
+		}
+		p := st.Question
+		q := quiz.Question{ID: p.ID, Domain: p.Domain, Prompt: p.Prompt, Options: p.Options}
+		a.notice = func(quiz.Question) string { return p.Notice }
+		userChoice, inputOK, jump := a.promptWithArrows(q, p.Index+1, st.Progress.Completed, st.Progress.Total)
+		if jump < 0 && !inputOK {
+			interrupted = true
+			break
+		}
+		if jump < 0 {
+			rating := quiz.Unrated
+			if (a.confidence || st.Confidence) && !first[p.ID] {
+				c, ok := a.askConfidence()
+				if !ok {
+					interrupted = true
+					break
+				}
+				rating = c
+			}
+			res, err := r.answer(string(userChoice), rating)
+			if err != nil {
+				if now, serr := r.state(); serr == nil && now.Paused {
+					// paused from the web UI before the answer arrived
+					st = now
+					continue
+				}
+				return Outcome{}, err
+			}
+			first[p.ID] = true
+			switch {
+			case res.TimeUp:
+				fmt.Fprintln(a.out, colorize("\nTime is up for this section; that answer was not recorded.", colorRed+colorBold))
+				fmt.Fprintln(a.out, "Press Enter to continue...")
+				a.readLine()
+				fmt.Fprintln(a.out)
+			case !res.Silent:
+				q.Answer, q.Source = res.CorrectAnswer, res.Source
+				a.showFeedback(q, res.Result)
+				a.waitToContinue()
+			}
+		}
+		if st, err = r.state(); err != nil {
+			return Outcome{}, err
+		}
+	}
+	switch {
+	case a.stopped():
+		return Outcome{Interrupted: true}, nil
+	case a.quit:
+		a.leaveRaw()
+		fmt.Fprintln(a.out, "\nQuit; the server keeps the session to pick up later.")
+	case interrupted:
+		fmt.Fprintln(a.out, "\nInput ended unexpectedly. Exiting quiz.")
+	}
+	return a.finishRemote(r, interrupted)
+}
+
+// finishRemote grades the server's session and reports it like finish does.
+func (a *App) finishRemote(r *remote, interrupted bool) (Outcome, error) {
+	sum, err := r.summary()
+	if err != nil {
+		return Outcome{}, err
+	}
+	o := Outcome{
+		Score:           sum.Score,
+		Answered:        sum.Answered,
+		Total:           sum.Total,
+		Percent:         sum.Percent,
+		Points:          sum.Points,
+		PossiblePoints:  sum.PossiblePoints,
+		WeightedPercent: sum.WeightedPercent,
+		Penalty:         sum.Penalty,
+		PassMark:        a.passMark,
+		Interrupted:     interrupted,
+		Calibration:     sum.Calibration,
+		SuddenDeath:     sum.SuddenDeath,
+		Streak:          sum.Streak,
+	}
+	o.Passed = !interrupted && o.WeightedPercent >= a.passMark
+	secs := make([]quiz.SectionSummary, len(sum.Sections))
+	for i, sec := range sum.Sections {
+		secs[i] = sec.summary()
+	}
+	if len(secs) > 0 {
+		o.Sections = sectionOutcomes(secs)
+	}
+	if a.resultOut != nil {
+		writeJSON(a.resultOut, o)
+		return o, nil
+	}
+	if o.Answered == 0 {
+		fmt.Fprintln(a.out, "\nNo answers recorded. Exiting.")
+		return o, nil
+	}
+	questions := make([]quiz.Question, len(sum.Rows))
+	results := make([]quiz.Result, len(sum.Rows))
+	for i, row := range sum.Rows {
+		questions[i] = quiz.Question{Answer: row.CorrectAnswer, Source: row.Source, Weight: row.Weight}
+		results[i] = quiz.Result{UserAnswer: row.UserAnswer, Correct: row.Correct, Confidence: row.Confidence}
+	}
+	penalty := a.penalty
+	a.penalty = sum.Penalty
+	a.printSummary(o.Answered, questions, results)
+	a.penalty = penalty
+	a.printMastery(o)
+	a.printSections(secs)
+	a.printCalibration(o.Calibration)
+	a.printStreak(o)
+	return o, nil
+}
@@ -0,0 +1,491 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorGreen  = "\033[32m"
+	colorRed    = "\033[31m"
+	colorCyan   = "\033[36m"
+	colorYellow = "\033[33m"
+	colorBold   = "\033[1m"
+	colorDim    = "\033[2m"
+	colorStrike = "\033[9m"
+
+	checkMark = "✅"
+	crossMark = "❌"
+)
+
+func sortedKeys(opts map[string]string) []rune {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	letters := make([]rune, 0, len(keys))
+	for _, k := range keys {
+		if len(k) > 0 {
+			letters = append(letters, rune(strings.ToUpper(k)[0]))
+		}
+	}
+	return letters
+}
+
+// formatProgress draws the progress bar, followed by a mini bar per domain
+// when domains is not empty, so domains lagging behind stand out in a mixed
+// run.
+func formatProgress(completed, total int, domains []quiz.DomainProgress) string {
+	if total <= 0 {
+		return ""
+	}
+	if completed < 0 {
+		completed = 0
+	}
+	if completed > total {
+		completed = total
+	}
+	barWidth := 20
+	filled := 0
+	if total > 0 {
+		filled = completed * barWidth / total
+	}
+	filledPart := colorize(strings.Repeat("#", filled), colorGreen+colorBold)
+	emptyPart := strings.Repeat("-", barWidth-filled)
+	bar := "[" + filledPart + emptyPart + "]"
+	left := total - completed
+	if left < 0 {
+		left = 0
+	}
+	line := fmt.Sprintf("%s %s%d/%d answered%s, %d left", bar, colorGreen, completed, total, colorReset, left)
+	if len(domains) > 0 {
+		line += " " + formatDomainBars(domains)
+	}
+	return line
+}
+
+// domainBarWidth is the width of each domain's mini bar.
+const domainBarWidth = 4
+
+// formatDomainBars draws one mini bar per domain, such as "D4 ▓▓░░ D5 ▓░░░",
+// from each domain's Completed share of its Total. Finished domains are green.
+func formatDomainBars(domains []quiz.DomainProgress) string {
+	parts := make([]string, 0, len(domains))
+	for _, d := range domains {
+		if d.Total <= 0 {
+			continue
+		}
+		filled := min(max(d.Completed, 0), d.Total) * domainBarWidth / d.Total
+		bar := strings.Repeat("▓", filled) + strings.Repeat("░", domainBarWidth-filled)
+		if d.Completed >= d.Total {
+			bar = colorize(bar, colorGreen)
+		}
+		parts = append(parts, fmt.Sprintf("D%d %s", d.Domain, bar))
+	}
+	return strings.Join(parts, " ")
+}
+
+// domainProgress is the per-domain progress for the header, or nil when it
+// is off or the session is a server's. Silent runs count answered questions,
+// as the overall bar does.
+func (a *App) domainProgress() []quiz.DomainProgress {
+	if !a.domainBars || a.remote != nil {
+		return nil
+	}
+	session := a.Session()
+	if session == nil {
+		return nil
+	}
+	domains := session.DomainProgress()
+	if a.feedback.Silent {
+		for i := range domains {
+			domains[i].Completed = domains[i].Attempted
+		}
+	}
+	return domains
+}
+
+// accuracyNote follows the progress line with the first-attempt accuracy so
+// far, such as " · 78% so far", yellow below the pass mark and green at it.
+// It is empty before the first answer, when WithoutAccuracy hides it, for
+// silent runs, which must not give a miss away, and for a server's session.
+func (a *App) accuracyNote(compact bool) string {
+	if a.hideAccuracy || a.feedback.Silent || a.remote != nil {
+		return ""
+	}
+	session := a.Session()
+	if session == nil {
+		return ""
+	}
+	score, answered := session.Score()
+	if answered == 0 {
+		return ""
+	}
+	percent := float64(score) / float64(answered) * 100
+	note := fmt.Sprintf("%.0f%%", percent)
+	if !compact {
+		note += " so far"
+	}
+	var color string
+	switch {
+	case a.passMark <= 0:
+	case percent >= a.passMark:
+		color = colorGreen
+	default:
+		color = colorYellow
+	}
+	return " · " + colorize(note, color)
+}
+
+func unicodeToLetter(ch rune) rune {
+	ch = rune(strings.ToUpper(string(ch))[0])
+	if ch >= 'A' && ch <= 'D' {
+		return ch
+	}
+	return ch
+}
+
+// scenarioLines draws the case study q belongs to, if any, above its
+// prompt: the title, then the text.
+func scenarioLines(q quiz.Question) []string {
+	if q.Stem == nil {
+		return nil
+	}
+	title := "Scenario"
+	if q.Stem.Title != "" {
+		title += ": " + q.Stem.Title
+	}
+	lines := []string{colorize(title, colorBold+colorYellow)}
+	lines = append(lines, markdown.ANSI(q.Stem.Text, "")...)
+	return append(lines, "")
+}
+
+// promptLines renders a Markdown prompt after label in style. A prompt that
+// renders to one line shares the label's line; longer prompts (lists, code)
+// start on the next line.
+func promptLines(label, prompt, style string) []string {
+	rendered := markdown.ANSI(prompt, style)
+	if len(rendered) <= 1 {
+		return []string{colorize(strings.TrimSpace(label+" "+strings.Join(rendered, "")), style)}
+	}
+	lines := []string{colorize(label, style)}
+	for _, l := range rendered {
+		lines = append(lines, colorize(l, style))
+	}
+	return lines
+}
+
+func colorize(s, color string) string {
+	if color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func (a *App) showFeedback(q quiz.Question, res quiz.Result) {
+	// keep the answer back after a miss, explanation included, when asked
+	// to or when the server did
+	hide := !res.Correct && (a.feedback.HideAnswer || q.Answer == "")
+	if a.feedback.Brief {
+		a.flashResult(q, res, hide)
+		return
+	}
+	a.showResult(q, res, hide)
+}
+
+// flashResult is the Brief feedback: one line saying whether res was right
+// and, after a miss, which option was, unless hide.
+func (a *App) flashResult(q quiz.Question, res quiz.Result, hide bool) {
+	a.clearScreen()
+	width, rows := a.term.Size()
+	line := colorize(checkMark+" Correct", colorGreen+colorBold)
+	if !res.Correct {
+		line = colorize(crossMark+" Incorrect", colorRed+colorBold)
+		if res.TimedOut {
+			line = colorize(crossMark+" Out of time", colorRed+colorBold)
+		}
+		if !hide {
+			answer := "  " + q.Answer
+			if text, ok := q.Options[q.Answer]; ok {
+				answer += ") " + markdown.Plain(text)
+			}
+			line += colorize(answer, colorGreen)
+		}
+	}
+	a.renderBlockWithVerticalCenter([]string{line}, width, rows)
+}
+
+// showResult draws q as answered in res, with the correct answer and the
+// explanation unless hide, then the footer lines.
+func (a *App) showResult(q quiz.Question, res quiz.Result, hide bool, footer ...string) {
+	a.clearScreen()
+	width, rows := a.term.Size()
+	lines := []string{
+		"",
+		"",
+	}
+	userLetter := '-'
+	if res.UserAnswer != "" {
+		userLetter = rune(res.UserAnswer[0])
+	}
+	switch {
+	case res.Correct:
+		lines = append(lines, colorize(checkMark+" Correct!", colorGreen+colorBold))
+	case res.TimedOut:
+		lines = append(lines, colorize(crossMark+" Out of time; this counts as a miss.", colorRed+colorBold))
+	default:
+		lines = append(lines, colorize(crossMark+" Incorrect.", colorRed+colorBold))
+	}
+	lines = append(lines, colorize(fmt.Sprintf("Your answer: %c", userLetter), colorYellow))
+	if hide {
+		lines = append(lines, colorize("Work out the right answer; the summary will show it.", colorGreen), "")
+	} else {
+		lines = append(lines, colorize(fmt.Sprintf("Correct answer: %s", q.Answer), colorGreen), "")
+	}
+	if q.Explanation != "" && !hide {
+		lines = append(lines, strings.Split(markdown.Plain(q.Explanation), "\n")...)
+		lines = append(lines, "")
+	}
+	if q.Source != "" {
+		lines = append(lines, colorize("Source: "+q.Source, colorDim), "")
+	}
+	lines = append(lines, promptLines(fmt.Sprintf("Q (Domain %d):", q.Domain), q.Prompt, colorCyan+colorBold)...)
+	for _, letter := range sortedKeys(q.Options) {
+		style := ""
+		if letter == unicodeToLetter(userLetter) {
+			style = colorYellow
+		}
+		option := markdown.InlineANSI(q.Options[string(letter)], style)
+		line := colorize(fmt.Sprintf("  %c) %s", letter, option), style)
+		lines = append(lines, line)
+	}
+	lines = append(lines, footer...)
+	a.renderBlockWithVerticalCenter(lines, width, rows)
+}
+
+func (a *App) printSummary(answered int, questions []quiz.Question, results []quiz.Result) {
+	if answered > len(questions) {
+		answered = len(questions)
+	}
+	if answered > len(results) {
+		answered = len(results)
+	}
+
+	score := 0
+	for i := 0; i < answered; i++ {
+		if results[i].Correct {
+			score++
+		}
+	}
+
+	rows := make([]string, answered)
+	correct := make([]bool, answered)
+	maxLen := 0
+	for i := 0; i < answered; i++ {
+		q := questions[i]
+		user := "-"
+		if results[i].UserAnswer != "" {
+			user = results[i].UserAnswer
+		}
+		status := colorize(crossMark+" incorrect", colorRed+colorBold)
+		if results[i].Correct {
+			status = colorize(checkMark+" correct", colorGreen+colorBold)
+		}
+		line := fmt.Sprintf("Q%-3d %-9s Your:%s Correct:%s", i+1, status, user, q.Answer)
+		rows[i], correct[i] = line, results[i].Correct
+		if l := displayWidth(line); l > maxLen {
+			maxLen = l
+		}
+	}
+
+	width, _ := a.term.Size()
+	colWidth := maxLen + 2
+	cols := 1
+	if width > 0 && colWidth > 0 {
+		if c := width / colWidth; c > 0 {
+			cols = c
+		}
+	}
+	if a.pageReview(rows, correct, cols, colWidth) {
+		fmt.Fprintf(a.out, "\nReview: %d answered, %d incorrect (paged above).\n", answered, answered-score)
+	} else {
+		fmt.Fprintln(a.out, "\nReview:")
+		for _, line := range reviewColumns(rows, cols, colWidth) {
+			fmt.Fprintln(a.out, line)
+		}
+	}
+	a.printSources(questions[:answered], results[:answered])
+	if weighted(questions) || a.penalty > 0 {
+		var earned, possible float64
+		for i := 0; i < answered; i++ {
+			points := questions[i].Points()
+			possible += points
+			if results[i].Correct {
+				earned += points
+			} else {
+				earned -= a.penalty * points
+			}
+		}
+		label := "Weighted score"
+		if a.penalty > 0 {
+			label = fmt.Sprintf("Marked score (wrong answers cost %g of their points)", a.penalty)
+		}
+		fmt.Fprintf(a.out, "%s: %g of %g points (%.1f%%).\n", label, roundPoints(earned), roundPoints(possible), earned*100/possible)
+	}
+	fmt.Fprintf(a.out, "You answered %d of %d correctly (%.1f%%).\n", score, answered, float64(score)*100/float64(answered))
+}
+
+// printSources lists where to read up on each missed question that names a
+// source.
+func (a *App) printSources(questions []quiz.Question, results []quiz.Result) {
+	var lines []string
+	for i, q := range questions {
+		if !results[i].Correct && q.Source != "" {
+			lines = append(lines, fmt.Sprintf("  Q%-3d %s", i+1, q.Source))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintln(a.out, "\nRead up on:")
+	for _, line := range lines {
+		fmt.Fprintln(a.out, line)
+	}
+}
+
+// printMastery sets the first-try score beside the share of questions
+// mastered once retries are counted. Sudden-death runs have no retries.
+func (a *App) printMastery(o Outcome) {
+	if o.SuddenDeath || a.feedback.Silent || o.Answered == 0 {
+		return
+	}
+	fmt.Fprintf(a.out, "First try %.1f%%, mastered %.1f%% after retries (%d of %d).\n", o.Percent, o.MasteredPercent, o.Mastered, o.Answered)
+}
+
+// printMasteryStop reports whether the run ended early at its mastery rule,
+// with each domain's rolling first-try accuracy as its estimated readiness.
+func (a *App) printMasteryStop(o Outcome) {
+	m := o.MasteryStop
+	if m == nil {
+		return
+	}
+	if m.Reached {
+		fmt.Fprintln(a.out, colorize(fmt.Sprintf("Mastery reached (%s) after %d of %d questions, so the run ended early.", m.Rule, o.Answered, o.Total), colorGreen+colorBold))
+	} else {
+		fmt.Fprintf(a.out, "Mastery (%s) not reached yet.\n", m.Rule)
+	}
+	fmt.Fprintln(a.out, "Estimated readiness, from each domain's latest first tries:")
+	for _, d := range m.Domains {
+		status := colorize("short", colorYellow)
+		if d.Met {
+			status = colorize("met", colorGreen)
+		}
+		fmt.Fprintf(a.out, "  Domain %-3d %5.1f%%  %d of the last %d right  %s\n", d.Domain, d.Percent(), d.Correct, d.Recent, status)
+	}
+}
+
+// printStreak reports a sudden-death run's streak.
+func (a *App) printStreak(o Outcome) {
+	if !o.SuddenDeath {
+		return
+	}
+	if o.Streak == o.Answered {
+		fmt.Fprintln(a.out, colorize(fmt.Sprintf("Sudden death: all %d correct, no misses!", o.Streak), colorGreen+colorBold))
+		return
+	}
+	fmt.Fprintf(a.out, "Sudden death: a streak of %d before the first miss.\n", o.Streak)
+}
+
+// roundPoints rounds to hundredths so fractional penalties print cleanly.
+func roundPoints(p float64) float64 {
+	return math.Round(p*100) / 100
+}
+
+// weighted reports whether any question carries a weight other than 1.
+func weighted(qs []quiz.Question) bool {
+	for _, q := range qs {
+		if q.Points() != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// padRight pads s with spaces to width terminal cells.
+func padRight(s string, width int) string {
+	w := displayWidth(s)
+	if w >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-w)
+}
+
+func centerLine(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	pad := (width - displayWidth(s)) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	return strings.Repeat(" ", pad) + s
+}
+
+// renderBlock prints lines left-aligned within a centered block, wrapping
+// those wider than the terminal at spaces.
+func (a *App) renderBlock(lines []string, width int) {
+	lines = wrapLines(lines, width)
+	maxLen := 0
+	for _, l := range lines {
+		if w := displayWidth(l); w > maxLen {
+			maxLen = w
+		}
+	}
+	margin := 0
+	if width > 0 && maxLen < width {
+		margin = (width - maxLen) / 2
+	}
+	space := strings.Repeat(" ", margin)
+	for _, l := range lines {
+		fmt.Fprintln(a.out, space+l)
+	}
+}
+
+// renderLeft prints lines from the left edge, wrapped to width, for the
+// compact layout.
+func (a *App) renderLeft(lines []string, width int) {
+	for _, l := range wrapLines(lines, width) {
+		fmt.Fprintln(a.out, l)
+	}
+}
+
+func (a *App) renderBlockWithVerticalCenter(lines []string, width, rows int) {
+	if a.compactLayout(width, rows) {
+		// start at the top, without the spacing some screens lead with
+		for len(lines) > 0 && lines[0] == "" {
+			lines = lines[1:]
+		}
+		a.renderLeft(lines, width)
+		return
+	}
+	if rows <= 0 {
+		a.renderBlock(lines, width)
+		return
+	}
+	lines = wrapLines(lines, width)
+	topPad := (rows - len(lines)) / 2
+	if topPad < 0 {
+		topPad = 0
+	}
+	for i := 0; i < topPad; i++ {
+		fmt.Fprintln(a.out)
+	}
+	a.renderBlock(lines, width)
+}
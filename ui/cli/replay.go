@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+	"quiz-cli/replay"
+)
+
+// Replay plays a run logged with WithReplay back on the App's output at speed
+// times the original pace: each question as it was on screen, the selection
+// moving and options struck out as they were, and each answer, under a clock
+// of the time spent on the question and in the run. Hesitations are kept, so a
+// long one shows as one, but a stretch paused with the p key is skipped and
+// left off the clocks. Replay returns ctx.Err() if ctx is done first.
+func (a *App) Replay(ctx context.Context, events []replay.Event, speed float64) error {
+	if speed <= 0 {
+		return errors.New("replay speed must be positive")
+	}
+	if len(events) == 0 {
+		return errors.New("the log has no events")
+	}
+	p := &player{app: a, start: events[0].At}
+	for i, e := range events {
+		if i > 0 && p.pausedAt.IsZero() {
+			if err := p.wait(ctx, events[i-1].At, e.At, speed); err != nil {
+				return err
+			}
+		}
+		p.apply(e)
+		p.draw(e.At)
+	}
+	return nil
+}
+
+// player is the screen state of a replay.
+type player struct {
+	app      *App
+	start    time.Time
+	shownAt  time.Time
+	question *quiz.Question
+	index    int
+	selected string
+	struck   map[string]bool
+	// answer is the answer to the question on screen, once given; score
+	// and answers count first attempts over the run.
+	answer   *replay.Event
+	answered map[int]bool
+	score    int
+	answers  int
+	finish   *replay.Event
+	// pausedAt is when a running pause began and pausedFor the length of
+	// the pauses before it; the clocks drawn leave both out.
+	pausedAt  time.Time
+	pausedFor time.Duration
+}
+
+// wait sleeps through the gap between two events, redrawing the clock each
+// second of replay time.
+func (p *player) wait(ctx context.Context, from, to time.Time, speed float64) error {
+	for at := from; at.Before(to); {
+		step := min(to.Sub(at), time.Second)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(float64(step) / speed)):
+		}
+		at = at.Add(step)
+		if at.Before(to) && p.question != nil && p.finish == nil {
+			p.draw(at)
+		}
+	}
+	return nil
+}
+
+func (p *player) apply(e replay.Event) {
+	switch e.Kind {
+	case replay.Shown:
+		p.question, p.index, p.shownAt = e.Question, e.Index, e.At.Add(-p.pausedFor)
+		p.struck, p.answer = map[string]bool{}, nil
+		p.selected = ""
+		if e.Question != nil {
+			if letters := sortedKeys(e.Question.Options); len(letters) > 0 {
+				p.selected = string(letters[0])
+			}
+		}
+	case replay.Select:
+		p.selected = e.Option
+	case replay.Strike:
+		if p.struck == nil {
+			p.struck = map[string]bool{}
+		}
+		p.struck[e.Option] = !p.struck[e.Option]
+	case replay.Answer:
+		e.At = e.At.Add(-p.pausedFor)
+		p.selected, p.answer = e.Option, &e
+		if p.answered == nil {
+			p.answered = map[int]bool{}
+		}
+		if !p.answered[e.Index] {
+			p.answered[e.Index] = true
+			p.answers++
+			if e.Correct {
+				p.score++
+			}
+		}
+	case replay.Finish:
+		e.At = e.At.Add(-p.pausedFor)
+		p.finish = &e
+	case replay.Pause:
+		if p.pausedAt.IsZero() {
+			p.pausedAt = e.At
+		}
+	case replay.Resume:
+		if !p.pausedAt.IsZero() {
+			p.pausedFor += e.At.Sub(p.pausedAt)
+			p.pausedAt = time.Time{}
+		}
+	}
+}
+
+func (p *player) draw(at time.Time) {
+	a := p.app
+	width, rows := a.term.Size()
+	a.clearScreen()
+	if !p.pausedAt.IsZero() {
+		at = p.pausedAt
+	}
+	at = at.Add(-p.pausedFor)
+	lines := []string{
+		colorize(fmt.Sprintf("Replay of the run on %s", p.start.Local().Format("Jan 2, 2006 15:04")), colorBold+colorCyan),
+		fmt.Sprintf("Run time %s   %d/%d first attempts correct", formatDuration(at.Sub(p.start)), p.score, p.answers),
+		"",
+	}
+	if p.finish != nil {
+		lines = append(lines, colorize(fmt.Sprintf("Finished: %d of %d correct on the first attempt in %s.",
+			p.finish.Score, p.finish.Answered, formatDuration(p.finish.At.Sub(p.start))), colorGreen+colorBold))
+		a.renderBlockWithVerticalCenter(lines, width, rows)
+		return
+	}
+	q := p.question
+	if !p.pausedAt.IsZero() {
+		lines = append(lines, colorize("Paused", colorYellow+colorBold))
+		a.renderBlockWithVerticalCenter(lines, width, rows)
+		return
+	}
+	if q == nil {
+		a.renderBlockWithVerticalCenter(lines, width, rows)
+		return
+	}
+	spent := at.Sub(p.shownAt)
+	if p.answer != nil {
+		spent = p.answer.At.Sub(p.shownAt)
+	}
+	lines = append(lines, colorize(fmt.Sprintf("%s on this question", formatDuration(spent)), colorYellow))
+	lines = append(lines, promptLines(fmt.Sprintf("Q%d (Domain %d):", p.index+1, q.Domain), q.Prompt, colorBold+colorCyan)...)
+	lines = append(lines, "")
+	for _, letter := range sortedKeys(q.Options) {
+		key := string(letter)
+		prefix := "  "
+		if key == p.selected {
+			prefix = colorize("> ", colorYellow)
+		}
+		line := fmt.Sprintf("%s%s) %s", prefix, key, markdown.InlineANSI(q.Options[key], ""))
+		if p.struck[key] {
+			line = prefix + colorize(fmt.Sprintf("%s) %s", key, markdown.Plain(q.Options[key])), colorDim+colorStrike)
+		}
+		lines = append(lines, line)
+	}
+	if p.answer != nil {
+		verdict := colorize(fmt.Sprintf("Answered %s %s", p.answer.Option, checkMark), colorGreen+colorBold)
+		if !p.answer.Correct {
+			verdict = colorize(fmt.Sprintf("Answered %s %s  (correct: %s)", p.answer.Option, crossMark, q.Answer), colorRed+colorBold)
+		}
+		lines = append(lines, "", verdict)
+	}
+	a.renderBlockWithVerticalCenter(lines, width, rows)
+}
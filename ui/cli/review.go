@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"quiz-cli/quiz"
+)
+
+// reviewAnswers lets the learner reopen questions once the review table is
+// printed: a question's number and Enter shows it in full as answered, with
+// the correct answer and the explanation, where r requeues it for a quick
+// session and Enter goes back to the review. Enter on its own ends it. It
+// needs raw mode, and returns the questions requeued in the order picked.
+func (a *App) reviewAnswers(answered int, questions []quiz.Question, results []quiz.Result) []quiz.Question {
+	answered = min(answered, len(questions), len(results))
+	if answered == 0 || a.remote != nil {
+		return nil
+	}
+	var picked []int
+	for {
+		n, ok := a.readQuestionNumber(answered)
+		if !ok {
+			break
+		}
+		if !a.reopen(questions[n-1], results[n-1], n-1, &picked) {
+			break
+		}
+		a.clearScreen()
+		a.printSummary(answered, questions, results)
+	}
+	requeued := make([]quiz.Question, len(picked))
+	for i, idx := range picked {
+		requeued[i] = questions[idx]
+	}
+	if len(requeued) > 0 {
+		fmt.Fprintf(a.out, "Requeued %d question%s for a quick session.\n", len(requeued), plural(len(requeued)))
+	}
+	return requeued
+}
+
+// readQuestionNumber asks in raw mode for a question number from 1 to last,
+// echoing the digits typed. It reports false for Enter on its own, when
+// input ends, or when there is no raw mode.
+func (a *App) readQuestionNumber(last int) (int, bool) {
+	if err := a.enableRaw(); err != nil {
+		return 0, false
+	}
+	defer a.leaveRaw()
+
+	prompt := fmt.Sprintf("Reopen a question: type its number (1-%d) and Enter, or Enter to finish: ", last)
+	fmt.Fprint(a.out, "\n"+prompt)
+	var digits []byte
+	for {
+		key, _, err := a.readKey()
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case key >= '0' && key <= '9' && len(digits) < 6:
+			digits = append(digits, key)
+			fmt.Fprint(a.out, string(key))
+		case (key == 127 || key == 8) && len(digits) > 0: // Backspace
+			digits = digits[:len(digits)-1]
+			fmt.Fprint(a.out, "\b \b")
+		case key == '\n' || key == '\r':
+			fmt.Fprintln(a.out)
+			if len(digits) == 0 {
+				return 0, false
+			}
+			if n, _ := strconv.Atoi(string(digits)); n >= 1 && n <= last {
+				return n, true
+			}
+			fmt.Fprintf(a.out, "There is no Q%s in the review. %s", digits, prompt)
+			digits = nil
+		}
+	}
+}
+
+// reopen shows q, the question at idx, as answered in res until Enter, with r
+// adding idx to picked or taking it off again. It reports false when input
+// ends.
+func (a *App) reopen(q quiz.Question, res quiz.Result, idx int, picked *[]int) bool {
+	if err := a.enableRaw(); err != nil {
+		return false
+	}
+	defer a.leaveRaw()
+
+	for {
+		hint := "r requeue for a quick session · Enter back to the review"
+		if slices.Contains(*picked, idx) {
+			hint = "Requeued. r takes it off again · Enter back to the review"
+		}
+		a.showResult(q, res, q.Answer == "", "", colorize(hint, colorYellow))
+		key, _, err := a.readKey()
+		if err != nil {
+			return false
+		}
+		switch key {
+		case 'r', 'R':
+			if i := slices.Index(*picked, idx); i >= 0 {
+				*picked = slices.Delete(*picked, i, i+1)
+			} else {
+				*picked = append(*picked, idx)
+			}
+		case '\n', '\r', 'q', 'Q':
+			return true
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+)
+
+// WithSearchHistory seeds the terms ↑ recalls at the / search prompt with
+// recent, oldest first, and calls add with each new search so it can be kept
+// for the next run. Without it searches are only recalled within the run.
+func WithSearchHistory(recent []string, add func(term string)) Option {
+	return func(a *App) {
+		a.searches = slices.Clone(recent)
+		a.searched = add
+	}
+}
+
+// rememberSearch makes term the most recent search.
+func (a *App) rememberSearch(term string) {
+	a.searches = slices.DeleteFunc(a.searches, func(t string) bool { return t == term })
+	a.searches = append(a.searches, term)
+	if a.searched != nil {
+		a.searched(term)
+	}
+}
+
+// readSearch reads a search term after a "Search: " prompt. In raw mode ↑
+// and ↓ step through the earlier searches, newest first, and Backspace
+// edits; otherwise it reads a plain line.
+func (a *App) readSearch() (string, bool) {
+	fmt.Fprint(a.out, "Search: ")
+	if err := a.enableRaw(); err != nil {
+		return a.readLine()
+	}
+	defer a.leaveRaw()
+
+	var line []rune
+	// pos is the recalled search, or len(a.searches) for the line being
+	// typed, which draft keeps while an earlier one is shown.
+	pos, draft := len(a.searches), ""
+	show := func(text string) {
+		line = []rune(text)
+		fmt.Fprintf(a.out, "\r\033[KSearch: %s", text)
+	}
+	for {
+		key, seq, err := a.readKey()
+		if err != nil {
+			return "", false
+		}
+		switch {
+		case key == '\n' || key == '\r':
+			fmt.Fprintln(a.out)
+			return string(line), true
+		case key == 27 && len(seq) == 2 && seq[0] == '[':
+			switch {
+			case seq[1] == 'A' && pos > 0: // up
+				if pos == len(a.searches) {
+					draft = string(line)
+				}
+				pos--
+				show(a.searches[pos])
+			case seq[1] == 'B' && pos < len(a.searches): // down
+				pos++
+				if pos == len(a.searches) {
+					show(draft)
+				} else {
+					show(a.searches[pos])
+				}
+			}
+		case key == 127 || key == 8: // Backspace
+			if len(line) > 0 {
+				show(string(line[:len(line)-1]))
+			}
+		case key >= 0x80:
+			// the first byte of a multibyte character; read it whole
+			a.in.UnreadByte()
+			r, _, err := a.in.ReadRune()
+			if err != nil {
+				return "", false
+			}
+			line = append(line, r)
+			fmt.Fprint(a.out, string(r))
+		case key >= ' ':
+			line = append(line, rune(key))
+			fmt.Fprint(a.out, string(rune(key)))
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// SectionOutcome is one section's part of an Outcome.
+type SectionOutcome struct {
+	Domain   int     `json:"domain"`
+	Total    int     `json:"total"`
+	Answered int     `json:"answered"`
+	Correct  int     `json:"correct"`
+	Seconds  float64 `json:"seconds"`
+	Budget   float64 `json:"budgetSeconds,omitempty"`
+	TimedOut bool    `json:"timedOut"`
+}
+
+// WithSections runs the quiz as a sectioned exam: one section per entry, in
+// order, each locked once finished or out of time. A transition screen
+// summarizes each finished section and introduces the next.
+func WithSections(sections []quiz.Section) Option {
+	return func(a *App) {
+		a.sections = sections
+	}
+}
+
+func sectionOutcomes(secs []quiz.SectionSummary) []SectionOutcome {
+	if secs == nil {
+		return nil
+	}
+	out := make([]SectionOutcome, len(secs))
+	for i, s := range secs {
+		out[i] = SectionOutcome{
+			Domain:   s.Domain,
+			Total:    s.Total,
+			Answered: s.Answered,
+			Correct:  s.Correct,
+			Seconds:  s.Elapsed.Seconds(),
+			Budget:   s.Budget.Seconds(),
+			TimedOut: s.TimedOut,
+		}
+	}
+	return out
+}
+
+// formatDuration renders d as m:ss, or h:mm:ss for long budgets.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// sectionLine is the status shown above each question in a sectioned exam.
+func (a *App) sectionLine() string {
+	var (
+		sec   quiz.SectionSummary
+		count int
+	)
+	if a.remote != nil {
+		if a.remote.section == nil {
+			return ""
+		}
+		sec, count = *a.remote.section, a.remote.sectionCount
+	} else {
+		session := a.Session()
+		if session == nil {
+			return ""
+		}
+		var ok bool
+		if sec, ok = session.CurrentSection(); !ok {
+			return ""
+		}
+		count = len(session.Sections())
+	}
+	line := fmt.Sprintf("Section %d of %d (%s)", sec.Index+1, count, sec.Title())
+	if sec.Budget > 0 {
+		line += " · " + formatDuration(sec.Remaining) + " left"
+	}
+	return colorize(line, colorYellow)
+}
+
+func sectionResultLine(sec quiz.SectionSummary) string {
+	line := fmt.Sprintf("Section %d (%s): %d of %d answered, %d correct in %s",
+		sec.Index+1, sec.Title(), sec.Answered, sec.Total, sec.Correct, formatDuration(sec.Elapsed))
+	if sec.TimedOut {
+		line += " (time ran out)"
+	}
+	return line
+}
+
+// showSectionTransition summarizes the section just locked, if any, and
+// introduces sec, waiting for Enter before the section's clock starts. It
+// returns false if input ended.
+func (a *App) showSectionTransition(session *quiz.Session, sec quiz.SectionSummary) bool {
+	all := session.Sections()
+	var prev *quiz.SectionSummary
+	if sec.Index > 0 {
+		prev = &all[sec.Index-1]
+	}
+	if !a.showSectionIntro(prev, sec, len(all)) {
+		return false
+	}
+	session.StartSection()
+	return true
+}
+
+// showSectionIntro draws the transition screen into sec, one of count
+// sections, after prev (nil for the first section) and waits for Enter. It
+// returns false if input ended.
+func (a *App) showSectionIntro(prev *quiz.SectionSummary, sec quiz.SectionSummary, count int) bool {
+	var lines []string
+	if prev != nil {
+		status := colorize(sectionResultLine(*prev), colorGreen)
+		if prev.TimedOut {
+			status = colorize(sectionResultLine(*prev), colorRed)
+		}
+		if a.feedback.Blind {
+			status = fmt.Sprintf("Section %d (%s) is over.", prev.Index+1, prev.Title())
+		}
+		lines = append(lines, status, "That section is now locked.", "")
+	}
+	budget := "untimed"
+	if sec.Budget > 0 {
+		budget = formatDuration(sec.Budget)
+	}
+	lines = append(lines,
+		colorize(fmt.Sprintf("Section %d of %d: %s", sec.Index+1, count, sec.Title()), colorBold+colorCyan),
+		fmt.Sprintf("%d question%s, %s", sec.Total, plural(sec.Total), budget),
+		"",
+		"Press Enter to start the section...",
+	)
+	width, rows := a.term.Size()
+	a.clearScreen()
+	a.renderBlockWithVerticalCenter(lines, width, rows)
+	_, ok := a.readLine()
+	return ok
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// printSections lists each section's result after the review summary.
+func (a *App) printSections(secs []quiz.SectionSummary) {
+	if len(secs) == 0 {
+		return
+	}
+	fmt.Fprintln(a.out, "\nSections:")
+	for _, sec := range secs {
+		fmt.Fprintln(a.out, "  "+sectionResultLine(sec))
+	}
+}
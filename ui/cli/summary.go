@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// Summary is the review document WithJSONSummary writes in place of the
+// colored table: the Outcome plus per-question and per-domain detail.
+type Summary struct {
+	Outcome
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// Seconds is the time taken, leaving out PausedSeconds spent paused.
+	Seconds       float64 `json:"seconds"`
+	PausedSeconds float64 `json:"pausedSeconds,omitempty"`
+	// Breaks counts the breaks taken (see WithBreaks); their time is part of
+	// PausedSeconds.
+	Breaks int `json:"breaks,omitempty"`
+	// Domains lists first-attempt results per domain, in ascending order.
+	Domains []DomainSummary `json:"domains"`
+	// Questions lists every question of the run in bank order, answered or
+	// not.
+	Questions []QuestionSummary `json:"questions"`
+}
+
+// DomainSummary is one domain's part of a Summary.
+type DomainSummary struct {
+	Domain    int     `json:"domain"`
+	Questions int     `json:"questions"`
+	Answered  int     `json:"answered"`
+	Correct   int     `json:"correct"`
+	Percent   float64 `json:"percent"`
+	Seconds   float64 `json:"seconds"`
+}
+
+// QuestionSummary is one question's part of a Summary. UserAnswer and
+// Correct grade the first attempt; Attempts and Seconds cover every attempt
+// until it was answered correctly.
+type QuestionSummary struct {
+	Index         int                `json:"index"`
+	ID            string             `json:"id,omitempty"`
+	Domain        int                `json:"domain"`
+	Prompt        string             `json:"prompt"`
+	Answered      bool               `json:"answered"`
+	UserAnswer    string             `json:"userAnswer,omitempty"`
+	CorrectAnswer string             `json:"correctAnswer"`
+	Source        string             `json:"source,omitempty"`
+	Correct       bool               `json:"correct"`
+	Weight        float64            `json:"weight"`
+	Attempts      int                `json:"attempts"`
+	Seconds       float64            `json:"seconds"`
+	Params        map[string]float64 `json:"params,omitempty"`
+	Confidence    quiz.Confidence    `json:"confidence,omitempty"`
+}
+
+// WithJSONSummary writes the review as a JSON Summary document to w instead
+// of printing the table; the quiz itself is still interactive. It takes
+// precedence over WithJSONResult.
+func WithJSONSummary(w io.Writer) Option {
+	return func(a *App) {
+		a.summaryOut = w
+	}
+}
+
+// startTiming resets the per-question clocks for a run of n questions.
+func (a *App) startTiming(n int) {
+	a.startedAt = time.Now()
+	a.spent = make([]time.Duration, n)
+	a.tries = make([]int, n)
+}
+
+// recordAttempt counts an attempt at question idx that took d.
+func (a *App) recordAttempt(idx int, d time.Duration) {
+	if idx < 0 || idx >= len(a.tries) {
+		return
+	}
+	a.spent[idx] += d
+	a.tries[idx]++
+}
+
+func (a *App) summary(o Outcome, session *quiz.Session) Summary {
+	finished := time.Now()
+	s := Summary{
+		Outcome:    o,
+		StartedAt:  a.startedAt,
+		FinishedAt: finished,
+		Questions:  []QuestionSummary{},
+		Domains:    []DomainSummary{},
+	}
+	var results []quiz.Result
+	var paused time.Duration
+	if session != nil {
+		results = session.Results()
+		paused = session.PausedFor()
+		s.Breaks = session.BreaksTaken()
+	}
+	s.Seconds = (finished.Sub(a.startedAt) - paused).Seconds()
+	s.PausedSeconds = paused.Seconds()
+	domains := map[int]*DomainSummary{}
+	for i, q := range a.questions {
+		qs := QuestionSummary{
+			Index:         i + 1,
+			ID:            q.ID,
+			Domain:        q.Domain,
+			Prompt:        markdown.Plain(q.Prompt),
+			CorrectAnswer: q.Answer,
+			Source:        q.Source,
+			Weight:        q.Points(),
+		}
+		if i < len(a.tries) {
+			qs.Attempts = a.tries[i]
+			qs.Seconds = a.spent[i].Seconds()
+		}
+		if i < len(results) {
+			res := results[i]
+			qs.Answered = res.UserAnswer != ""
+			qs.UserAnswer = res.UserAnswer
+			qs.Correct = res.Correct
+			qs.Params = res.Params
+			qs.Confidence = res.Confidence
+		}
+		s.Questions = append(s.Questions, qs)
+
+		d := domains[q.Domain]
+		if d == nil {
+			d = &DomainSummary{Domain: q.Domain}
+			domains[q.Domain] = d
+		}
+		d.Questions++
+		d.Seconds += qs.Seconds
+		if qs.Answered {
+			d.Answered++
+			if qs.Correct {
+				d.Correct++
+			}
+		}
+	}
+	for _, d := range domains {
+		if d.Answered > 0 {
+			d.Percent = float64(d.Correct) * 100 / float64(d.Answered)
+		}
+		s.Domains = append(s.Domains, *d)
+	}
+	sort.Slice(s.Domains, func(i, j int) bool { return s.Domains[i].Domain < s.Domains[j].Domain })
+	return s
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Terminal is the platform layer an App draws on. Swapping it out lets tests
+// and alternate frontends drive the interactive flow without a real TTY.
+type Terminal interface {
+	// Size reports the visible width and height in cells, or zeros when unknown.
+	Size() (width, rows int)
+	// MakeRaw switches input to unbuffered, unechoed mode and returns a function
+	// that restores the previous mode. Callers fall back to line input when it
+	// fails.
+	MakeRaw() (restore func(), err error)
+}
+
+// NewTTY returns a Terminal backed by the given input and output files.
+func NewTTY(in, out *os.File) Terminal {
+	return ttyTerminal{in: in, out: out}
+}
+
+type ttyTerminal struct {
+	in, out *os.File
+}
+
+func (t ttyTerminal) Size() (int, int) {
+	return termSize(t.out)
+}
+
+func (t ttyTerminal) MakeRaw() (func(), error) {
+	fd := int(t.in.Fd())
+	state, err := makeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	return func() { restore(fd, state) }, nil
+}
+
+// plainTerminal is used for non-file readers: it has no size and never enters
+// raw mode, so prompts use typed line input.
+type plainTerminal struct{}
+
+func (plainTerminal) Size() (int, int) { return 0, 0 }
+
+func (plainTerminal) MakeRaw() (func(), error) {
+	return nil, errors.New("raw mode not supported")
+}
+
+// makeRaw sets the terminal into raw mode; returns previous state.
+func makeRaw(fd int) (*syscall.Termios, error) {
+	var oldState syscall.Termios
+	if _, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TCGETS), uintptr(unsafe.Pointer(&oldState)), 0, 0, 0); err != 0 {
+		return nil, err
+	}
+	newState := oldState
+	newState.Lflag &^= syscall.ICANON | syscall.ECHO
+	newState.Iflag &^= syscall.ICRNL
+	if _, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TCSETS), uintptr(unsafe.Pointer(&newState)), 0, 0, 0); err != 0 {
+		return nil, err
+	}
+	return &oldState, nil
+}
+
+func restore(fd int, state *syscall.Termios) {
+	syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TCSETS), uintptr(unsafe.Pointer(state)), 0, 0, 0)
+}
+
+func termSize(f *os.File) (int, int) {
+	type winsize struct {
+		Row    uint16
+		Col    uint16
+		Xpixel uint16
+		Ypixel uint16
+	}
+	ws := &winsize{}
+	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(f.Fd()), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(ws)), 0, 0, 0)
+	if err != 0 {
+		return 0, 0
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+func clearScreen(w io.Writer) {
+	fmt.Fprint(w, "\033[2J\033[H")
+}
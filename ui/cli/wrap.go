@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+)
+
+// optionMarker matches the start of an option line, such as "  B) " or
+// "> B) ", whose continuation lines indent under the option text.
+var optionMarker = regexp.MustCompile(`^ *(?:> )?[A-Za-z0-9]\) `)
+
+// wrapLines soft-wraps the lines wider than width cells at spaces (see
+// wrapLine). A width of zero or less leaves them as they are.
+func wrapLines(lines []string, width int) []string {
+	if width <= 0 {
+		return lines
+	}
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, wrapLine(l, width)...)
+	}
+	return out
+}
+
+// wrapLine soft-wraps line to width cells. Continuation lines indent under
+// the text of an option line and keep the leading spaces of any other.
+func wrapLine(line string, width int) []string {
+	if displayWidth(line) <= width {
+		return []string{line}
+	}
+	plain := stripANSI(line)
+	hang := len(plain) - len(strings.TrimLeft(plain, " "))
+	if m := optionMarker.FindString(plain); m != "" {
+		hang = displayWidth(m)
+	}
+	if hang > width/2 {
+		hang = 0
+	}
+	prefix, text := splitCells(line, hang)
+	return wrapText(prefix, text, width)
+}
+
+// wrapText breaks prefix followed by text into lines of at most width
+// cells, at spaces, indenting the lines after the first under the text. A
+// word longer than a line gets a line of its own. Styles still in effect at
+// a break are reset at the end of the line and taken up again after the
+// indent, so the indent is never struck out or underlined. A width of zero
+// or less leaves the text on one line.
+func wrapText(prefix, text string, width int) []string {
+	words := strings.Fields(text)
+	if width <= 0 || len(words) == 0 {
+		return []string{prefix + text}
+	}
+	indent := strings.Repeat(" ", displayWidth(prefix))
+	active := sgrState("", prefix+words[0])
+	var lines []string
+	line, used := prefix+words[0], displayWidth(prefix)+displayWidth(words[0])
+	for _, w := range words[1:] {
+		if used+1+displayWidth(w) > width {
+			if active != "" {
+				line += colorReset
+			}
+			lines = append(lines, line)
+			line, used = indent+active+w, len(indent)+displayWidth(w)
+		} else {
+			line += " " + w
+			used += 1 + displayWidth(w)
+		}
+		active = sgrState(active, w)
+	}
+	return append(lines, line)
+}
+
+// sgrState returns the styles in effect after s, given those in effect
+// before it: the SGR sequences since the last reset.
+func sgrState(active, s string) string {
+	for {
+		i := strings.Index(s, "\033[")
+		if i < 0 {
+			return active
+		}
+		s = s[i+2:]
+		end := strings.IndexFunc(s, func(r rune) bool { return r >= '@' && r <= '~' })
+		if end < 0 {
+			return active
+		}
+		seq := "\033[" + s[:end+1]
+		s = s[end+1:]
+		switch {
+		case seq[len(seq)-1] != 'm':
+		case seq == colorReset || seq == "\033[m":
+			active = ""
+		default:
+			active += seq
+		}
+	}
+}
+
+// splitCells splits s after its first n display cells, keeping the escape
+// sequences before that point in the head.
+func splitCells(s string, n int) (head, tail string) {
+	cells := 0
+	inEscape := false
+	for i, r := range s {
+		switch {
+		case inEscape:
+			if r != '[' && r >= '@' && r <= '~' {
+				inEscape = false
+			}
+		case r == '\033':
+			inEscape = true
+		default:
+			if cells >= n {
+				return s[:i], s[i:]
+			}
+			cells += runeWidth(r)
+		}
+	}
+	return s, ""
+}
+
+// stripANSI removes the escape sequences from s.
+func stripANSI(s string) string {
+	var b strings.Builder
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			if r != '[' && r >= '@' && r <= '~' {
+				inEscape = false
+			}
+		case r == '\033':
+			inEscape = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
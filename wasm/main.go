@@ -0,0 +1,102 @@
+//go:build js && wasm
+
+// Command wasm runs the quiz engine and the web UI entirely in the browser, so
+// a bank can be hosted on a static site such as GitHub Pages with no Go server.
+// index.html loads this module, which fetches the bank, serves the webapp
+// handler in memory, and exposes it to JavaScript as quizServe.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall/js"
+
+	"quiz-cli/quiz"
+	"quiz-cli/webapp"
+)
+
+// memResponse is a minimal in-memory http.ResponseWriter.
+type memResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (m *memResponse) Header() http.Header { return m.header }
+
+func (m *memResponse) Write(p []byte) (int, error) {
+	if m.status == 0 {
+		m.status = http.StatusOK
+	}
+	return m.body.Write(p)
+}
+
+func (m *memResponse) WriteHeader(status int) {
+	if m.status == 0 {
+		m.status = status
+	}
+}
+
+func main() {
+	global := js.Global()
+	bankURL := "questions.json"
+	if v := global.Get("quizBankURL"); v.Type() == js.TypeString {
+		bankURL = v.String()
+	}
+	questions, err := loadBank(bankURL)
+	if err != nil {
+		global.Call("quizFailed", err.Error())
+		return
+	}
+
+	handler := webapp.NewServer(questions).Handler()
+	global.Set("quizServe", js.FuncOf(func(_ js.Value, args []js.Value) any {
+		method, path, body := args[0].String(), args[1].String(), ""
+		if len(args) > 2 && args[2].Type() == js.TypeString {
+			body = args[2].String()
+		}
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		if err != nil {
+			return map[string]any{"status": http.StatusBadRequest, "body": err.Error(), "contentType": "text/plain"}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res := &memResponse{header: http.Header{}}
+		handler.ServeHTTP(res, req)
+		if res.status == 0 {
+			res.status = http.StatusOK
+		}
+		return map[string]any{
+			"status":      res.status,
+			"body":        res.body.String(),
+			"contentType": res.header.Get("Content-Type"),
+		}
+	}))
+	global.Call("quizReady")
+	select {}
+}
+
+func loadBank(url string) ([]quiz.Question, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	qs, err := quiz.ParseQuestions(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := quiz.Validate(qs); err != nil {
+		return nil, err
+	}
+	return qs, nil
+}
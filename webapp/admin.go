@@ -0,0 +1,193 @@
+package webapp
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 500
+)
+
+// WithAdminKey guards the /api/admin routes, which expose the bank with its
+// answers, the review queue and the learners' sessions, as well as
+// /api/reload, which swaps the bank learners are quizzed on, and the
+// listing of issue reports (GET /api/reports): only requests
+// carrying key in the X-Admin-Key header reach them. Without an admin key
+// the presenter key (see WithPresenter) opens them, and with neither they
+// are closed.
+func WithAdminKey(key string) Option {
+	return func(s *Server) {
+		s.adminKey = key
+	}
+}
+
+// admin serves h only to requests carrying the admin key, refusing the rest
+// with 403 Forbidden.
+func (s *Server) admin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAdmin(r) {
+			http.Error(w, "admin key required", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// isAdmin reports whether r carries the admin key, falling back to the
+// presenter key when no admin key is set.
+func (s *Server) isAdmin(r *http.Request) bool {
+	switch {
+	case s.adminKey != "":
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Key")), []byte(s.adminKey)) == 1
+	case s.presenterKey != "":
+		return s.isPresenter(r)
+	}
+	return false
+}
+
+type adminQuestion struct {
+	ID          string            `json:"id"`
+	Index       int               `json:"index"`
+	Domain      int               `json:"domain"`
+	Prompt      string            `json:"prompt"`
+	Options     map[string]string `json:"options"`
+	Answer      string            `json:"answer"`
+	Image       string            `json:"image,omitempty"`
+	ImageAlt    string            `json:"imageAlt,omitempty"`
+	Updated     string            `json:"updated,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Attempts    int               `json:"attempts"`
+	Difficulty  float64           `json:"difficulty"`
+	Flags       int               `json:"flags"`
+	UnderReview bool              `json:"underReview"`
+}
+
+type adminQuestionPage struct {
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+	Items    []adminQuestion `json:"items"`
+}
+
+// handleAdminQuestions lists the bank for the admin editor, filtered, sorted
+// and paginated on the server. Query parameters:
+//
+//	query     case-insensitive match against prompt and option text
+//	domain    only questions in this domain
+//	tag       only questions with this tag; repeat to require several
+//	sort      index (default), domain, flags, difficulty, or updated
+//	order     asc (default) or desc
+//	page      1-based page number
+//	pageSize  items per page (default 50, max 500)
+func (s *Server) handleAdminQuestions(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	needle := strings.ToLower(strings.TrimSpace(params.Get("query")))
+	domain, domainErr := strconv.Atoi(params.Get("domain"))
+	filterDomain := params.Get("domain") != ""
+	if filterDomain && domainErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	filter := questionFilter{domain: domain, hasDomain: filterDomain, needle: needle}
+	for _, tag := range params["tag"] {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.tags = append(filter.tags, tag)
+		}
+	}
+
+	questions := s.bank()
+	items := make([]adminQuestion, 0, len(questions))
+	for i, q := range questions {
+		if !filter.match(q) {
+			continue
+		}
+		item := adminQuestion{
+			ID:       q.ID,
+			Index:    i,
+			Domain:   q.Domain,
+			Prompt:   q.Prompt,
+			Options:  q.Options,
+			Answer:   q.Answer,
+			Image:    q.Image,
+			ImageAlt: q.ImageAlt,
+			Updated:  q.Updated,
+			Tags:     q.Tags,
+		}
+		if s.stats != nil {
+			if rec, ok := s.stats.Lookup(q); ok {
+				item.Attempts = rec.Attempts
+				item.Flags = rec.Flags
+				item.UnderReview = rec.UnderReview
+				if rec.Attempts > 0 {
+					item.Difficulty = 1 - float64(rec.Correct)/float64(rec.Attempts)
+				}
+			}
+		}
+		items = append(items, item)
+	}
+
+	var less func(a, b adminQuestion) bool
+	switch params.Get("sort") {
+	case "", "index":
+		less = func(a, b adminQuestion) bool { return a.Index < b.Index }
+	case "domain":
+		less = func(a, b adminQuestion) bool { return a.Domain < b.Domain }
+	case "flags":
+		less = func(a, b adminQuestion) bool { return a.Flags < b.Flags }
+	case "difficulty":
+		less = func(a, b adminQuestion) bool { return a.Difficulty < b.Difficulty }
+	case "updated":
+		less = func(a, b adminQuestion) bool { return a.Updated < b.Updated }
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	desc := params.Get("order") == "desc"
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+
+	page := positiveInt(params.Get("page"), 1)
+	pageSize := positiveInt(params.Get("pageSize"), defaultAdminPageSize)
+	if pageSize > maxAdminPageSize {
+		pageSize = maxAdminPageSize
+	}
+	resp := adminQuestionPage{Total: len(items), Page: page, PageSize: pageSize, Items: []adminQuestion{}}
+	if start := (page - 1) * pageSize; start < len(items) {
+		end := start + pageSize
+		if end > len(items) {
+			end = len(items)
+		}
+		resp.Items = items[start:end]
+	}
+	writeJSON(w, r, resp)
+}
+
+func questionMatches(prompt string, options map[string]string, needle string) bool {
+	if strings.Contains(strings.ToLower(prompt), needle) {
+		return true
+	}
+	for _, text := range options {
+		if strings.Contains(strings.ToLower(text), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func positiveInt(v string, fallback int) int {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
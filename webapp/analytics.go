@@ -0,0 +1,38 @@
+package webapp
+
+import (
+	"net/http"
+
+	"quiz-cli/stats"
+)
+
+// WithHardestFirst orders each new session hardest question first, by the
+// miss rate and answer time in the WithStats history (see
+// stats.HardestFirst). It does nothing without WithStats.
+func WithHardestFirst() Option {
+	return func(s *Server) {
+		s.hardestFirst = true
+	}
+}
+
+type analyticsResponse struct {
+	Questions []stats.Difficulty `json:"questions"`
+}
+
+// handleAnalytics reports per-question difficulty from the -stats history,
+// which pools every learner the server has quizzed, hardest first.
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	resp := analyticsResponse{Questions: stats.Difficulties(s.bank(), s.stats)}
+	if resp.Questions == nil {
+		resp.Questions = []stats.Difficulty{}
+	}
+	writeJSON(w, r, resp)
+}
@@ -0,0 +1,26 @@
+package webapp
+
+import (
+	"quiz-cli/autosave"
+	"quiz-cli/quiz"
+)
+
+// WithAutosave saves the session with saver after every answer and, when
+// resume is not nil, starts from the run it saved before. Starting over with
+// Try Again or a challenge discards the saved run. failed is called when the
+// saved run does not fit the questions served.
+func WithAutosave(saver *autosave.Saver, resume *quiz.State, failed func(error)) Option {
+	return func(s *Server) {
+		s.autosave, s.resume, s.autosaveFailed = saver, resume, failed
+	}
+}
+
+// resumeSaved restores the saved run into the first session.
+func (s *Server) resumeSaved() {
+	if s.resume == nil {
+		return
+	}
+	if err := s.session.Restore(*s.resume); err != nil && s.autosaveFailed != nil {
+		s.autosaveFailed(err)
+	}
+}
@@ -0,0 +1,146 @@
+package webapp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// minGzipSize is the smallest body worth compressing; below it the gzip
+// header and trailer outweigh the savings.
+const minGzipSize = 1024
+
+// cacheable wraps h so successful GET responses carry an ETag, answer a
+// matching If-None-Match with 304 Not Modified, and are gzipped for clients
+// that accept it. The dashboard polls /api/state; on a large bank over a slow
+// link most polls then cost a header exchange rather than the whole state.
+// gRPC calls and other methods pass straight through.
+func cacheable(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || strings.HasPrefix(r.URL.Path, grpcPrefix) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		buf := &bufferedResponse{header: w.Header()}
+		h.ServeHTTP(buf, r)
+		buf.flush(w, r)
+	})
+}
+
+// bufferedResponse holds a response back until the handler is done, so its
+// ETag can be computed from the whole body.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) flush(w http.ResponseWriter, r *http.Request) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	body := b.body.Bytes()
+	// a nested handler, such as an LTI learner's, may have been here already
+	if b.status != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+		w.WriteHeader(b.status)
+		_, _ = w.Write(body)
+		return
+	}
+	h := w.Header()
+	etag := h.Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `W/"` + hex.EncodeToString(sum[:12]) + `"`
+		h.Set("ETag", etag)
+	}
+	if h.Get("Cache-Control") == "" {
+		// let browsers keep the body but check back every time
+		h.Set("Cache-Control", "no-cache")
+	}
+	compressible := compressibleType(h.Get("Content-Type"))
+	if compressible && !strings.Contains(h.Get("Vary"), "Accept-Encoding") {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if !compressible || len(body) < minGzipSize || !acceptsGzip(r) {
+		_, _ = w.Write(body)
+		return
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(body)
+	if err := zw.Close(); err != nil {
+		_, _ = w.Write(body)
+		return
+	}
+	h.Set("Content-Encoding", "gzip")
+	h.Set("Content-Length", strconv.Itoa(gz.Len()))
+	_, _ = w.Write(gz.Bytes())
+}
+
+// compressibleType reports whether a body of the given Content-Type is text
+// that gzip shrinks; images other than SVG are compressed already.
+func compressibleType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mt, "text/"):
+		return true
+	case mt == "application/json", mt == "application/javascript", mt == "image/svg+xml", mt == "application/xml":
+		return true
+	}
+	return false
+}
+
+// acceptsGzip reports whether r's Accept-Encoding allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		return !ok || (q != "0" && q != "0.0" && q != "0.00" && q != "0.000")
+	}
+	return false
+}
+
+// etagMatches compares an If-None-Match header against etag, weakly, as RFC
+// 9110 asks for GET.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package webapp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"quiz-cli/challenge"
+	"quiz-cli/quiz"
+)
+
+// WithChallenge makes every session replay c. The server's questions must be
+// the challenge's, in order (see challenge.Challenge.Select).
+func WithChallenge(c challenge.Challenge) Option {
+	return func(s *Server) {
+		s.seed = c.Seed
+		s.seeded = true
+	}
+}
+
+// WithBoard lets learners post finished runs to board and see the standings
+// for their challenge.
+func WithBoard(board *challenge.Board) Option {
+	return func(s *Server) {
+		s.board = board
+	}
+}
+
+type challengeStartRequest struct {
+	Code string `json:"code"`
+}
+
+type scoreRequest struct {
+	Name string `json:"name"`
+}
+
+type leaderboardResponse struct {
+	Key     string            `json:"key"`
+	Code    string            `json:"code"`
+	Entries []challenge.Entry `json:"entries"`
+	// Rank is the posted entry's place, set by /api/challenge/score.
+	Rank int `json:"rank,omitempty"`
+}
+
+// maxNameLen bounds leaderboard names.
+const maxNameLen = 40
+
+// sessionChallenge returns the challenge that replays session.
+func sessionChallenge(session *quiz.Session) challenge.Challenge {
+	return challenge.New(session.Seed(), session.Questions)
+}
+
+// boardKey is the leaderboard key ch's runs are ranked under: the
+// challenge's own, or the shared streak board under sudden death.
+func (s *Server) boardKey(ch challenge.Challenge) string {
+	if s.suddenDeath {
+		return challenge.StreakKey
+	}
+	return ch.Key()
+}
+
+func (s *Server) leaderboard(ch challenge.Challenge) leaderboardResponse {
+	resp := leaderboardResponse{Key: s.boardKey(ch), Code: ch.Code(), Entries: []challenge.Entry{}}
+	if s.board != nil {
+		resp.Entries = s.board.Top(s.boardKey(ch), 10)
+	}
+	return resp
+}
+
+// handleChallenge reports the current session's challenge code and standings.
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, s.leaderboard(sessionChallenge(s.current())))
+}
+
+// handleStartChallenge restarts the quiz as the challenge in the request. The
+// challenge must cover exactly the questions this server is serving.
+func (s *Server) handleStartChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req challengeStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ch, err := challenge.Parse(req.Code)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !ch.Matches(s.bank()) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	s.setSession(s.sessionWithSeed(ch.Seed))
+	writeJSON(w, r, s.buildState(r.Context()))
+}
+
+// handleScore posts the finished session's result to the leaderboard, once.
+func (s *Server) handleScore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.board == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req scoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if runes := []rune(name); len(runes) > maxNameLen {
+		name = string(runes[:maxNameLen])
+	}
+
+	s.mu.Lock()
+	session, started, finished, posted := s.session, s.started, s.finished, s.posted
+	if session.Completed() && !posted {
+		s.posted = true
+	}
+	s.mu.Unlock()
+	if !session.Completed() || posted {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	score, answered := session.Score()
+	ch := sessionChallenge(session)
+	rank := s.board.Add(s.boardKey(ch), challenge.Entry{
+		Name:     name,
+		Score:    score,
+		Answered: answered,
+		Total:    len(session.Questions),
+		Seconds:  (finished.Sub(started) - session.PausedFor()).Seconds(),
+		At:       time.Now(),
+	})
+	_ = s.board.Save(r.Context())
+	resp := s.leaderboard(ch)
+	resp.Rank = rank
+	writeJSON(w, r, resp)
+}
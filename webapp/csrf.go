@@ -0,0 +1,150 @@
+package webapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// csrfHeader carries the page's CSRF token on requests that change state.
+const csrfHeader = "X-CSRF-Token"
+
+// csrfCookie names a browser session for its CSRF token, when no LTI launch
+// already does.
+const csrfCookie = "quiz_csrf"
+
+// csrfScript makes the pages send their CSRF token, which servePage fills
+// in, with every request other than a GET.
+const csrfScript = `
+    const csrfToken = "{{.CSRF}}";
+    const plainFetch = window.fetch.bind(window);
+    window.fetch = (url, opts = {}) => {
+      if ((opts.method || "GET") !== "GET") {
+        opts = { ...opts, headers: { ...opts.headers, "X-CSRF-Token": csrfToken } };
+      }
+      return plainFetch(url, opts);
+    };
+`
+
+// WithAllowedOrigins lets pages on these origins, such as
+// "https://lms.example.edu", call the API from the browser. They read the
+// CSRF token from GET /api/csrf and send it in an X-CSRF-Token header like
+// the server's own pages. Other origins get no CORS headers, and their
+// requests that change state are refused.
+func WithAllowedOrigins(origins []string) Option {
+	return func(s *Server) {
+		for _, o := range origins {
+			if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+				s.allowedOrigins = append(s.allowedOrigins, strings.ToLower(o))
+			}
+		}
+	}
+}
+
+type csrfResponse struct {
+	Token string `json:"token"`
+}
+
+func (s *Server) handleCSRF(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, csrfResponse{Token: s.csrfToken(w, r)})
+}
+
+// csrfToken returns the CSRF token of r's browser session, starting a session
+// with a cookie when r has none. The token is an HMAC of the session cookie
+// under the server's key, so it is good only alongside that cookie, and one
+// learner's token cannot forge requests for another.
+func (s *Server) csrfToken(w http.ResponseWriter, r *http.Request) string {
+	session := csrfSession(r)
+	if session == "" {
+		session = randomToken()
+		cookie := &http.Cookie{Name: csrfCookie, Value: session, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode}
+		if strings.HasPrefix(requestBase(r), "https:") {
+			// sent cross-site, as the LTI cookie is, for pages an LMS embeds
+			cookie.Secure, cookie.SameSite, cookie.Partitioned = true, http.SameSiteNoneMode, true
+		}
+		http.SetCookie(w, cookie)
+	}
+	return s.csrfFor(session)
+}
+
+// csrfSession is the cookie naming r's browser session: its LTI launch, or
+// the one csrfToken started.
+func csrfSession(r *http.Request) string {
+	for _, name := range []string{ltiCookie, csrfCookie} {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// csrfFor is the CSRF token of the browser session named session.
+func (s *Server) csrfFor(session string) string {
+	mac := hmac.New(sha256.New, s.csrfKey)
+	mac.Write([]byte(session))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// guard applies the CORS policy and refuses browser requests that change
+// state without the CSRF token. Requests with no sign of a browser, such as
+// `quiz -connect` or scripts, need no token: they cannot be forged from
+// another site. LTI launches are cross-site posts by design, and gRPC is
+// not for browsers.
+func (s *Server) guard(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		cross := origin != "" && !sameOrigin(origin, r)
+		allowed := cross && slices.Contains(s.allowedOrigins, strings.ToLower(origin))
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Add("Vary", "Origin")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+csrfHeader)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if safeMethod(r.Method) || strings.HasPrefix(r.URL.Path, "/lti/") || strings.HasPrefix(r.URL.Path, grpcPrefix) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if (cross && !allowed) || (fromBrowser(r) && !s.validCSRF(r)) {
+			http.Error(w, "missing or invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// validCSRF reports whether r carries the CSRF token of its own session.
+func (s *Server) validCSRF(r *http.Request) bool {
+	token, session := r.Header.Get(csrfHeader), csrfSession(r)
+	return token != "" && session != "" && hmac.Equal([]byte(token), []byte(s.csrfFor(session)))
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// fromBrowser reports whether r carries what browsers add and other clients
+// rarely do: an Origin, fetch metadata, or cookies.
+func fromBrowser(r *http.Request) bool {
+	return r.Header.Get("Origin") != "" || r.Header.Get("Sec-Fetch-Site") != "" || r.Header.Get("Cookie") != ""
+}
+
+// sameOrigin reports whether origin names the host r was sent to.
+func sameOrigin(origin string, r *http.Request) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
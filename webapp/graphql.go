@@ -0,0 +1,463 @@
+package webapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"quiz-cli/quiz"
+)
+
+// The GraphQL endpoint supports the subset of the language a frontend needs to
+// drive the quiz: a single query or mutation with nested selection sets, field
+// arguments (string, number, boolean and $variable values), and aliases.
+// Fragments and directives are not supported. Root fields are resolved to the
+// same payloads the REST endpoints return, then projected onto the selection.
+//
+//	type Query {
+//	  questions(domain: Int, tag: String, search: String, offset: Int, limit: Int): [Question]
+//	  session: State
+//	  summary: Summary
+//	  attempts: [SummaryRow]
+//	  stats: [StatRecord]
+//	}
+//	type Mutation {
+//	  answer(answer: String!, confidence: String): AnswerResult
+//	  reset: Boolean
+//	  jump(term: String!, again: Boolean): JumpResult
+//	}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type graphQLResponse struct {
+	Data   map[string]any `json:"data,omitempty"`
+	Errors []graphQLError `json:"errors,omitempty"`
+}
+
+type gqlField struct {
+	alias string
+	name  string
+	args  map[string]any
+	sel   []gqlField
+}
+
+type statPayload struct {
+	ID          string `json:"id"`
+	Prompt      string `json:"prompt"`
+	Attempts    int    `json:"attempts"`
+	Correct     int    `json:"correct"`
+	Flags       int    `json:"flags"`
+	UnderReview bool   `json:"underReview"`
+}
+
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	op, fields, err := parseGraphQL(req.Query, req.Variables)
+	if err != nil {
+		writeJSON(w, r, graphQLResponse{Errors: []graphQLError{{Message: err.Error()}}})
+		return
+	}
+	if op == "mutation" && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	resp := graphQLResponse{Data: map[string]any{}}
+	for _, f := range fields {
+		v, err := s.resolveGraphQL(r.Context(), op, f)
+		if err != nil {
+			resp.Errors = append(resp.Errors, graphQLError{Message: fmt.Sprintf("%s: %v", f.name, err)})
+			resp.Data[f.alias] = nil
+			continue
+		}
+		projected, err := project(v, f.sel)
+		if err != nil {
+			resp.Errors = append(resp.Errors, graphQLError{Message: fmt.Sprintf("%s: %v", f.name, err)})
+		}
+		resp.Data[f.alias] = projected
+	}
+	writeJSON(w, r, resp)
+}
+
+func (s *Server) resolveGraphQL(ctx context.Context, op string, f gqlField) (any, error) {
+	if op == "mutation" {
+		switch f.name {
+		case "answer":
+			answer, _ := f.args["answer"].(string)
+			var confidence quiz.Confidence
+			if c, ok := f.args["confidence"].(string); ok {
+				var err error
+				if confidence, err = quiz.ParseConfidence(c); err != nil {
+					return nil, err
+				}
+			}
+			return s.answer(ctx, answer, confidence)
+		case "reset":
+			s.reset()
+			return true, nil
+		case "jump":
+			term, _ := f.args["term"].(string)
+			again, _ := f.args["again"].(bool)
+			return s.jump(term, again), nil
+		}
+		return nil, fmt.Errorf("unknown mutation field")
+	}
+	switch f.name {
+	case "questions":
+		var filter questionFilter
+		filter.domain, filter.hasDomain = intArg(f.args, "domain")
+		if tag, ok := f.args["tag"].(string); ok {
+			filter.tags = []string{tag}
+		}
+		search, _ := f.args["search"].(string)
+		filter.needle = strings.ToLower(strings.TrimSpace(search))
+		offset, _ := intArg(f.args, "offset")
+		limit, hasLimit := intArg(f.args, "limit")
+		out := []questionPayload{}
+		for i, q := range s.bank() {
+			if filter.match(q) {
+				out = append(out, *s.payloadFor(i, q))
+			}
+		}
+		if offset > len(out) {
+			offset = len(out)
+		}
+		out = out[offset:]
+		if hasLimit && limit >= 0 && limit < len(out) {
+			out = out[:limit]
+		}
+		return out, nil
+	case "session":
+		return s.buildState(ctx), nil
+	case "summary":
+		return s.buildSummary(), nil
+	case "attempts":
+		return s.buildSummary().Rows, nil
+	case "stats":
+		if s.stats == nil {
+			return nil, fmt.Errorf("stats are not enabled")
+		}
+		out := []statPayload{}
+		for _, q := range s.bank() {
+			rec, ok := s.stats.Lookup(q)
+			if !ok {
+				continue
+			}
+			out = append(out, statPayload{
+				ID:          q.ID,
+				Prompt:      q.Prompt,
+				Attempts:    rec.Attempts,
+				Correct:     rec.Correct,
+				Flags:       rec.Flags,
+				UnderReview: rec.UnderReview,
+			})
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown query field")
+}
+
+func intArg(args map[string]any, name string) (int, bool) {
+	switch v := args[name].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// project converts v to its JSON shape and keeps only the selected fields.
+// Objects selected without a sub-selection are returned whole.
+func project(v any, sel []gqlField) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return projectValue(generic, sel)
+}
+
+func projectValue(v any, sel []gqlField) (any, error) {
+	if len(sel) == 0 {
+		return v, nil
+	}
+	switch t := v.(type) {
+	case []any:
+		out := make([]any, len(t))
+		for i, item := range t {
+			p, err := projectValue(item, sel)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = p
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(sel))
+		for _, f := range sel {
+			child, ok := t[f.name]
+			if !ok {
+				child = nil
+			}
+			p, err := projectValue(child, f.sel)
+			if err != nil {
+				return nil, err
+			}
+			out[f.alias] = p
+		}
+		return out, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("cannot select fields on a scalar")
+}
+
+// gqlParser is a small recursive-descent parser over the query text.
+type gqlParser struct {
+	src  string
+	pos  int
+	vars map[string]any
+}
+
+func parseGraphQL(src string, vars map[string]any) (string, []gqlField, error) {
+	p := &gqlParser{src: src, vars: vars}
+	op := "query"
+	p.skip()
+	if name := p.peekName(); name == "query" || name == "mutation" {
+		op = p.name()
+		p.skip()
+		if p.peekName() != "" {
+			p.name()
+		}
+		p.skip()
+		if p.peek() == '(' {
+			if err := p.skipVariableDefinitions(); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	fields, err := p.selectionSet()
+	if err != nil {
+		return "", nil, err
+	}
+	p.skip()
+	if p.pos < len(p.src) {
+		return "", nil, p.errorf("unexpected trailing input")
+	}
+	return op, fields, nil
+}
+
+func (p *gqlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("graphql: offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *gqlParser) skip() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *gqlParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *gqlParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *gqlParser) peekName() string {
+	save := p.pos
+	n := p.name()
+	p.pos = save
+	return n
+}
+
+func (p *gqlParser) name() string {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := rune(p.src[p.pos])
+		if c == '_' || unicode.IsLetter(c) || (p.pos > start && unicode.IsDigit(c)) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *gqlParser) skipVariableDefinitions() error {
+	depth := 0
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+		p.pos++
+	}
+	return p.errorf("unterminated variable definitions")
+}
+
+func (p *gqlParser) selectionSet() ([]gqlField, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	var fields []gqlField
+	for p.peek() != '}' {
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated selection set")
+		}
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	p.pos++
+	return fields, nil
+}
+
+func (p *gqlParser) field() (gqlField, error) {
+	name := p.name()
+	if name == "" {
+		return gqlField{}, p.errorf("expected field name")
+	}
+	f := gqlField{alias: name, name: name}
+	if p.peek() == ':' {
+		p.pos++
+		f.name = p.name()
+		if f.name == "" {
+			return gqlField{}, p.errorf("expected field name after alias")
+		}
+	}
+	if p.peek() == '(' {
+		p.pos++
+		f.args = map[string]any{}
+		for p.peek() != ')' {
+			arg := p.name()
+			if arg == "" {
+				return gqlField{}, p.errorf("expected argument name")
+			}
+			if err := p.expect(':'); err != nil {
+				return gqlField{}, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return gqlField{}, err
+			}
+			f.args[arg] = v
+		}
+		p.pos++
+	}
+	if p.peek() == '{' {
+		sel, err := p.selectionSet()
+		if err != nil {
+			return gqlField{}, err
+		}
+		f.sel = sel
+	}
+	return f, nil
+}
+
+func (p *gqlParser) value() (any, error) {
+	switch c := p.peek(); {
+	case c == '$':
+		p.pos++
+		return p.vars[p.name()], nil
+	case c == '"':
+		end := p.pos + 1
+		for end < len(p.src) && p.src[end] != '"' {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			return nil, p.errorf("unterminated string")
+		}
+		v, err := strconv.Unquote(p.src[p.pos : end+1])
+		if err != nil {
+			return nil, p.errorf("bad string: %v", err)
+		}
+		p.pos = end + 1
+		return v, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		lit := p.src[start:p.pos]
+		if n, err := strconv.Atoi(lit); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return nil, p.errorf("bad number %q", lit)
+		}
+		return f, nil
+	default:
+		switch word := p.name(); word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		case "":
+			return nil, p.errorf("expected value")
+		default:
+			return word, nil // enum value
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package webapp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"quiz-cli/quiz"
+)
+
+func TestGraphQLQueryAndMutation(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"},
+		{Domain: 2, Prompt: "Grass color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "A"},
+	}
+	h := NewServer(qs, WithGraphQL()).Handler()
+
+	post := func(body string) graphQLResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(body)))
+		var resp graphQLResponse
+		decodeBody(t, rr.Body.Bytes(), &resp)
+		if len(resp.Errors) > 0 {
+			t.Fatalf("unexpected errors: %+v", resp.Errors)
+		}
+		return resp
+	}
+
+	resp := post(`{"query":"{ qs: questions(domain: 2) { prompt } session { progress { total } } }"}`)
+	list, _ := resp.Data["qs"].([]any)
+	if len(list) != 1 || list[0].(map[string]any)["prompt"] != "Grass color?" {
+		t.Fatalf("questions = %#v", resp.Data["qs"])
+	}
+	if _, ok := list[0].(map[string]any)["domain"]; ok {
+		t.Fatalf("unselected field leaked: %#v", list[0])
+	}
+	total := resp.Data["session"].(map[string]any)["progress"].(map[string]any)["total"]
+	if total != float64(2) {
+		t.Fatalf("total = %v", total)
+	}
+
+	resp = post(`{"query":"mutation Pick($a: String!) { answer(answer: $a) { result { correct } } }","variables":{"a":"Z"}}`)
+	correct := resp.Data["answer"].(map[string]any)["result"].(map[string]any)["correct"]
+	if correct != false {
+		t.Fatalf("answer result = %#v", resp.Data["answer"])
+	}
+}
@@ -0,0 +1,194 @@
+package webapp
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// The gRPC API (see quiz.proto) is served by hand on top of net/http's
+// HTTP/2 support: each unary call is a POST to /quiz.v1.Quiz/<Method> whose
+// body is one length-prefixed protocol buffers message, answered the same way
+// with the status in the grpc-status trailer. Compression is not supported.
+
+//go:embed quiz.proto
+var quizProto []byte
+
+const grpcPrefix = "/quiz.v1.Quiz/"
+
+// gRPC status codes used by the API.
+const (
+	grpcOK              = 0
+	grpcInvalidArgument = 3
+	grpcUnimplemented   = 12
+	grpcInternal        = 13
+	grpcUnavailable     = 14
+)
+
+type grpcError struct {
+	code int
+	msg  string
+}
+
+func (e *grpcError) Error() string { return e.msg }
+
+// WithGRPC serves the gRPC API described by quiz.proto alongside the web UI.
+// Without TLS, Run then accepts cleartext HTTP/2 (h2c) as gRPC clients expect.
+func WithGRPC() Option {
+	return func(s *Server) {
+		s.grpc = true
+	}
+}
+
+func (s *Server) handleProto(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(quizProto)
+}
+
+func (s *Server) handleGRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc+proto")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	req, err := readGRPCMessage(r.Body)
+	var reply pbMessage
+	if err == nil {
+		reply, err = s.callGRPC(r, strings.TrimPrefix(r.URL.Path, grpcPrefix), req)
+	}
+	code, msg := grpcOK, ""
+	if err != nil {
+		code, msg = grpcInternal, err.Error()
+		var ge *grpcError
+		if errors.As(err, &ge) {
+			code = ge.code
+		}
+	} else {
+		frame := make([]byte, 5, 5+len(reply))
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(reply)))
+		_, _ = w.Write(append(frame, reply...))
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(code))
+	w.Header().Set("Grpc-Message", msg)
+}
+
+// readGRPCMessage reads the single message of a unary request.
+func readGRPCMessage(body io.Reader) ([]byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(body, head[:]); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "missing request message"}
+	}
+	if head[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed messages are not supported"}
+	}
+	size := binary.BigEndian.Uint32(head[1:])
+	if size > 1<<20 {
+		return nil, &grpcError{grpcInvalidArgument, "request message too large"}
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(body, msg); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "truncated request message"}
+	}
+	return msg, nil
+}
+
+func (s *Server) callGRPC(r *http.Request, method string, req []byte) (pbMessage, error) {
+	fields, err := pbStrings(req)
+	if err != nil {
+		return nil, &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	switch method {
+	case "GetState":
+		return stateMessage(s.buildState(r.Context())), nil
+	case "Answer":
+		var confidence quiz.Confidence
+		if fields[2] != "" {
+			if confidence, err = quiz.ParseConfidence(fields[2]); err != nil {
+				return nil, &grpcError{grpcInvalidArgument, err.Error()}
+			}
+		}
+		resp, err := s.answer(r.Context(), fields[1], confidence)
+		if err != nil {
+			return nil, &grpcError{grpcUnavailable, err.Error()}
+		}
+		return pbMessage(nil).
+			bool(1, resp.Result.Correct).
+			string(2, resp.Result.UserAnswer).
+			string(3, resp.CorrectAnswer).
+			bool(4, resp.Finished).
+			bool(5, resp.TimeUp).
+			message(6, progressMessage(resp.Progress)).
+			string(7, resp.Source), nil
+	case "Jump":
+		resp := s.jump(fields[1], pbBool(req, 2))
+		return pbMessage(nil).
+			bool(1, resp.Found).
+			string(2, resp.ID).
+			int(3, resp.Index).
+			int(4, resp.Domain).
+			string(5, resp.Prompt).
+			bool(6, resp.Mastered).
+			string(7, resp.Error), nil
+	case "Summary":
+		return summaryMessage(s.buildSummary()), nil
+	case "Reset":
+		s.reset()
+		return stateMessage(s.buildState(r.Context())), nil
+	}
+	return nil, &grpcError{grpcUnimplemented, "unknown method " + method}
+}
+
+func stateMessage(st stateResponse) pbMessage {
+	m := pbMessage(nil).bool(1, st.Finished)
+	if q := st.Question; q != nil {
+		m = m.message(2, pbMessage(nil).
+			string(1, q.ID).
+			int(2, q.Index).
+			int(3, q.Domain).
+			string(4, q.Prompt).
+			stringMap(5, q.Options).
+			string(6, q.Image).
+			string(7, q.ImageAlt).
+			string(8, q.Notice).
+			string(9, q.Note))
+	}
+	m = m.message(3, progressMessage(st.Progress))
+	if st.Summary != nil {
+		m = m.message(4, summaryMessage(*st.Summary))
+	}
+	m = m.bool(5, st.SectionIntro)
+	if st.Section != nil {
+		m = m.string(6, st.Section.Title)
+	}
+	return m
+}
+
+func progressMessage(p progressPayload) pbMessage {
+	return pbMessage(nil).int(1, p.Completed).int(2, p.Total).int(3, p.Remaining).int(4, p.Attempted)
+}
+
+func summaryMessage(sum summaryPayload) pbMessage {
+	m := pbMessage(nil).
+		int(1, sum.Score).
+		int(2, sum.Answered).
+		int(3, sum.Total).
+		double(4, sum.Percent)
+	for _, row := range sum.Rows {
+		m = m.message(5, pbMessage(nil).
+			string(1, row.ID).
+			int(2, row.Index).
+			bool(3, row.Correct).
+			string(4, row.UserAnswer).
+			string(5, row.CorrectAnswer).
+			string(6, row.Source))
+	}
+	return m.double(6, sum.Points).double(7, sum.PossiblePoints).double(8, sum.WeightedPercent).
+		int(9, sum.Mastered).double(10, sum.MasteredPercent)
+}
@@ -0,0 +1,15 @@
+//go:build go1.24
+
+package webapp
+
+import "net/http"
+
+// enableH2C lets server accept cleartext HTTP/2, which gRPC clients use when
+// there is no TLS.
+func enableH2C(server *http.Server) error {
+	server.Protocols = new(http.Protocols)
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(true)
+	server.Protocols.SetUnencryptedHTTP2(true)
+	return nil
+}
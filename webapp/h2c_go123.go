@@ -0,0 +1,14 @@
+//go:build !go1.24
+
+package webapp
+
+import (
+	"errors"
+	"net/http"
+)
+
+// enableH2C needs Go 1.24's http.Protocols; older builds only serve gRPC
+// over TLS.
+func enableH2C(*http.Server) error {
+	return errors.New("gRPC without TLS needs a Go 1.24 build; pass -tls-cert and -tls-key")
+}
@@ -0,0 +1,90 @@
+package webapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// readyTimeout bounds the checks behind /readyz, so a hung store fails the
+// probe instead of stalling it.
+const readyTimeout = 3 * time.Second
+
+var errNoQuestions = errors.New("no questions loaded")
+
+// BuildInfo describes the running build for /version.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// WithBuildInfo sets what /version reports.
+func WithBuildInfo(info BuildInfo) Option {
+	return func(s *Server) {
+		s.build = info
+	}
+}
+
+// readiness is the /readyz response: Checks maps each dependency to "ok" or
+// what is wrong with it.
+type readiness struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+// handleHealthz answers liveness probes: the process is up and serving.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, r, map[string]string{"status": "ok"})
+}
+
+// handleReadyz answers readiness probes with 200 when the bank is loaded and
+// the stats store, if any, can be read, and 503 otherwise.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	resp := readiness{Ready: true, Checks: map[string]string{}}
+	check := func(name string, err error) {
+		if err != nil {
+			resp.Ready = false
+			resp.Checks[name] = err.Error()
+			return
+		}
+		resp.Checks[name] = "ok"
+	}
+	var bankErr error
+	if len(s.bank()) == 0 {
+		bankErr = errNoQuestions
+	}
+	check("bank", bankErr)
+	if s.stats != nil {
+		check("stats", s.stats.Check(ctx))
+	}
+	if !resp.Ready {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, r, resp)
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	info := s.build
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+	writeJSON(w, r, info)
+}
@@ -0,0 +1,88 @@
+package webapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"quiz-cli/stats"
+)
+
+// maxCommentLen bounds the comment on a reported issue.
+const maxCommentLen = 2000
+
+// issueRequest reports an issue with the question named by ID, or by Index
+// when ID is empty.
+type issueRequest struct {
+	ID      string `json:"id"`
+	Index   int    `json:"index"`
+	Comment string `json:"comment"`
+	Name    string `json:"name,omitempty"`
+}
+
+// handleReports takes issue reports against questions (POST) and lists them
+// for the bank's maintainers (GET), as JSON or, with format=csv, as a CSV
+// download. Listing needs the admin key (see WithAdminKey), since reports
+// carry learners' names and comments.
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodGet {
+		s.admin(s.listReports)(w, r)
+		return
+	}
+	var req issueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	questions := s.bank()
+	if req.ID != "" {
+		req.Index = questionByID(questions, req.ID)
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if req.Index < 0 || req.Index >= len(questions) || comment == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if runes := []rune(comment); len(runes) > maxCommentLen {
+		comment = string(runes[:maxCommentLen])
+	}
+	name := strings.TrimSpace(req.Name)
+	if runes := []rune(name); len(runes) > maxNameLen {
+		name = string(runes[:maxNameLen])
+	}
+	issue := s.stats.ReportIssue(questions[req.Index], comment, name, time.Now())
+	_ = s.stats.Save(r.Context())
+	writeJSON(w, r, issue)
+}
+
+func (s *Server) listReports(w http.ResponseWriter, r *http.Request) {
+	issues := s.stats.Issues()
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		if issues == nil {
+			issues = []stats.Issue{}
+		}
+		writeJSON(w, r, issues)
+	case "csv":
+		var buf bytes.Buffer
+		if err := stats.WriteIssuesCSV(&buf, issues); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="question-reports.csv"`)
+		_, _ = w.Write(buf.Bytes())
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
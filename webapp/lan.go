@@ -0,0 +1,50 @@
+package webapp
+
+import (
+	"net"
+	"net/url"
+)
+
+// LANURL rewrites link, as printed by Run, to an address other devices on the
+// network can reach: a server listening on every interface gets this machine's
+// first private IPv4 address. It returns "" when the server only listens on
+// loopback or no such address exists.
+func LANURL(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() {
+			return ""
+		}
+		return link
+	}
+	if host != "localhost" {
+		return link
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	var fallback net.IP
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.To4() == nil || ipnet.IP.IsLoopback() || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipnet.IP.IsPrivate() {
+			fallback = ipnet.IP
+			break
+		}
+		if fallback == nil {
+			fallback = ipnet.IP
+		}
+	}
+	if fallback == nil {
+		return ""
+	}
+	u.Host = net.JoinHostPort(fallback.String(), u.Port())
+	return u.String()
+}
@@ -0,0 +1,229 @@
+package webapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-cli/lti"
+	"quiz-cli/quiz"
+)
+
+// ltiCookie carries a launched learner's token, mapping their browser to
+// their own session.
+const ltiCookie = "quiz_lti"
+
+// WithLTI makes the server an LTI 1.3 tool (see package lti). An LMS starts
+// a launch at /lti/login and completes it at /lti/launch; the tool's keys are
+// at /lti/jwks. Each learner launched gets a session of their own, and their
+// first-attempt score on finishing is posted to the LMS gradebook. Grade
+// passback happens in the background; failed is called with any error, and
+// may be nil. Browsers that arrive without a launch share the server's
+// session as usual.
+func WithLTI(tool *lti.Tool, failed func(error)) Option {
+	return func(s *Server) {
+		s.lti = &ltiState{
+			tool:     tool,
+			failed:   failed,
+			learners: map[string]*learner{},
+			tokens:   map[string]*learner{},
+		}
+	}
+}
+
+// ltiState tracks the learners launched from an LMS.
+type ltiState struct {
+	tool   *lti.Tool
+	failed func(error)
+	mu     sync.Mutex
+	// learners are keyed by lti.Launch.Key and tokens by cookie value.
+	learners map[string]*learner
+	tokens   map[string]*learner
+}
+
+// learner is one LMS user on one resource link, with a Server of their own
+// configured like the main one.
+// id names the session in /api/admin/sessions.
+type learner struct {
+	server   *Server
+	handler  http.Handler
+	id       string
+	mu       sync.Mutex
+	launch   *lti.Launch
+	started  time.Time
+	lastSeen time.Time
+}
+
+func (l *learner) currentLaunch() *lti.Launch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.launch
+}
+
+// routeLearners adds the LTI routes to mux, and serves launched learners from
+// their own server and everyone else from h.
+func (s *Server) routeLearners(mux *http.ServeMux, h http.Handler) http.Handler {
+	mux.HandleFunc("/lti/login", s.handleLTILogin)
+	mux.HandleFunc("/lti/launch", s.handleLTILaunch)
+	mux.HandleFunc("/lti/jwks", s.handleLTIKeys)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/lti/") {
+			if l := s.learnerFor(r); l != nil {
+				l.touch(time.Now())
+				l.handler.ServeHTTP(w, r)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// learnerFor returns the learner r's cookie names, or nil. A learner whose
+// session has expired is dropped.
+func (s *Server) learnerFor(r *http.Request) *learner {
+	c, err := r.Cookie(ltiCookie)
+	if err != nil {
+		return nil
+	}
+	s.lti.mu.Lock()
+	defer s.lti.mu.Unlock()
+	l := s.lti.tokens[c.Value]
+	if l != nil && s.expired(l, time.Now()) {
+		s.dropLearner(l.currentLaunch().Key(), l)
+		return nil
+	}
+	return l
+}
+
+// handleLTILogin answers the platform's login initiation, which may come as
+// a GET or a form POST, by redirecting to its authorization endpoint.
+func (s *Server) handleLTILogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	redirect := r.Form.Get("target_link_uri")
+	if redirect == "" || !strings.HasSuffix(strings.TrimRight(redirect, "/"), "/lti/launch") {
+		redirect = requestBase(r) + "/lti/launch"
+	}
+	to, err := s.lti.tool.Login(r.Form, redirect)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, to, http.StatusFound)
+}
+
+// handleLTILaunch validates a launch, finds or creates the learner's session
+// and sends their browser to the quiz with a cookie naming it.
+func (s *Server) handleLTILaunch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	launch, err := s.lti.tool.Launch(r.Context(), r.PostForm.Get("id_token"), r.PostForm.Get("state"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	l, err := s.launchLearner(launch)
+	if err != nil {
+		s.refuseSession(w)
+		return
+	}
+	token := randomToken()
+	s.lti.mu.Lock()
+	s.lti.tokens[token] = l
+	s.lti.mu.Unlock()
+
+	// the quiz runs in an LMS iframe, so the cookie must be sent cross-site,
+	// which browsers only allow over HTTPS
+	cookie := &http.Cookie{Name: ltiCookie, Value: token, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode}
+	if strings.HasPrefix(requestBase(r), "https:") {
+		// partitioned, so browsers that block third-party cookies still
+		// keep it for the LMS that embeds the quiz
+		cookie.Secure, cookie.SameSite, cookie.Partitioned = true, http.SameSiteNoneMode, true
+	}
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handleLTIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, r, s.lti.tool.JWKS())
+}
+
+// launchLearner returns the learner for launch, creating their server on the
+// first launch. A relaunch keeps the learner's progress, unless it expired,
+// and takes the launch's line item. A new learner is refused with
+// errTooManySessions while the session cap is reached.
+func (s *Server) launchLearner(launch *lti.Launch) (*learner, error) {
+	now := time.Now()
+	s.lti.mu.Lock()
+	defer s.lti.mu.Unlock()
+	s.sweepSessions(now)
+	if l, ok := s.lti.learners[launch.Key()]; ok {
+		l.mu.Lock()
+		l.launch, l.lastSeen = launch, now
+		l.mu.Unlock()
+		return l, nil
+	}
+	if s.maxSessions > 0 && len(s.lti.learners) >= s.maxSessions {
+		return nil, errTooManySessions
+	}
+	l := &learner{launch: launch, id: randomToken()[:12], started: now, lastSeen: now}
+	opts := append(slices.Clip(s.opts), func(ls *Server) {
+		ls.lti = nil
+		ls.presenterKey = ""
+		ls.autosave, ls.resume = nil, nil
+		ls.grade = func(session *quiz.Session) { s.gradeLearner(l, session) }
+	})
+	l.server = NewServer(s.bank(), opts...)
+	l.handler = l.server.Handler()
+	s.lti.learners[launch.Key()] = l
+	return l, nil
+}
+
+// gradeLearner posts the first-attempt score of l's finished session,
+// counting every question in the quiz.
+func (s *Server) gradeLearner(l *learner, session *quiz.Session) {
+	score, _ := session.Score()
+	_, total := session.Progress()
+	err := s.lti.tool.PostScore(context.Background(), l.currentLaunch(), float64(score), float64(total))
+	if err != nil && s.lti.failed != nil {
+		s.lti.failed(err)
+	}
+}
+
+// requestBase is the scheme and host r was sent to, honoring a TLS-ending
+// proxy's X-Forwarded-Proto.
+func requestBase(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// randomToken returns an unguessable cookie value.
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
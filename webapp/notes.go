@@ -0,0 +1,70 @@
+package webapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"quiz-cli/stats"
+)
+
+// noteRequest sets the note on the question named by ID, or by Index when ID
+// is empty. A blank Note deletes it.
+type noteRequest struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+	Note  string `json:"note"`
+}
+
+type noteResponse struct {
+	Note string `json:"note"`
+}
+
+// handleNote saves the learner's note on a question.
+func (s *Server) handleNote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req noteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	questions := s.bank()
+	if req.ID != "" {
+		req.Index = questionByID(questions, req.ID)
+	}
+	if req.Index < 0 || req.Index >= len(questions) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	q := questions[req.Index]
+	s.stats.SetNote(q, req.Note)
+	_ = s.stats.Save(r.Context())
+	writeJSON(w, r, noteResponse{Note: s.stats.Note(q)})
+}
+
+// handleNotes downloads every note as a Markdown document.
+func (s *Server) handleNotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var buf bytes.Buffer
+	if err := stats.WriteNotesMarkdown(&buf, s.stats.Notes(s.bank())); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="notes.md"`)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,32 @@
+package webapp
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type pauseRequest struct {
+	Paused bool `json:"paused"`
+}
+
+// handlePause pauses or resumes the current session. While paused the
+// session's clocks stand still, /api/state withholds the question and
+// /api/answer is refused with 409 Conflict.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req pauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	session := s.current()
+	if req.Paused {
+		session.Pause()
+	} else {
+		session.Resume()
+	}
+	writeJSON(w, r, s.buildState(r.Context()))
+}
@@ -0,0 +1,518 @@
+package webapp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"html/template"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+
+	"quiz-cli/qr"
+	"quiz-cli/quiz"
+)
+
+// WithPresenter turns on instructor mode: /present projects one question at a
+// time with a live chart of the answers participants send from their phones at
+// /join. Only requests carrying key can open the presenter view, reveal the
+// answer or move on.
+func WithPresenter(key string) Option {
+	return func(s *Server) {
+		s.presenterKey = key
+	}
+}
+
+// presentation is the instructor-mode poll: the question on screen and the
+// answers collected for it. It is separate from the server's session, so
+// projecting a class quiz does not touch anyone's own run or history.
+type presentation struct {
+	questions []quiz.Question
+	order     []int
+	pos       int
+	// round numbers the questions shown, so a late vote for one the
+	// presenter has moved on from is turned away.
+	round    int
+	question quiz.Question
+	// votes maps each participant to the option they chose.
+	votes    map[string]string
+	revealed bool
+	rng      *rand.Rand
+	mu       sync.Mutex
+}
+
+func newPresentation(questions []quiz.Question, seed int64) *presentation {
+	rng := rand.New(rand.NewSource(seed))
+	p := &presentation{questions: questions, order: rng.Perm(len(questions)), rng: rng}
+	p.showLocked()
+	return p
+}
+
+// showLocked puts the question at pos on screen with an empty tally,
+// drawing fresh values for a template. p.mu must be held.
+func (p *presentation) showLocked() {
+	p.round++
+	p.votes = map[string]string{}
+	p.revealed = false
+	if p.pos >= len(p.order) {
+		return
+	}
+	q := p.questions[p.order[p.pos]]
+	if q.IsTemplate() {
+		if inst, _, err := q.Instantiate(p.rng); err == nil {
+			q = inst
+		}
+	}
+	p.question = q
+}
+
+type presentState struct {
+	Round    int              `json:"round"`
+	Number   int              `json:"number"`
+	Total    int              `json:"total"`
+	Finished bool             `json:"finished"`
+	Question *questionPayload `json:"question,omitempty"`
+	// Votes counts the answers per option and Voters the participants who
+	// answered. Participants only get Votes once the answer is revealed.
+	Votes    map[string]int `json:"votes,omitempty"`
+	Voters   int            `json:"voters"`
+	Revealed bool           `json:"revealed"`
+	Answer   string         `json:"answer,omitempty"`
+	// Join is the address participants open on their phones.
+	Join string `json:"join"`
+}
+
+type voteRequest struct {
+	Round int `json:"round"`
+	// Voter is an ID the participant's browser keeps, so answering again
+	// changes the vote instead of adding one.
+	Voter  string `json:"voter"`
+	Answer string `json:"answer"`
+}
+
+// maxVoterLen bounds participant IDs.
+const maxVoterLen = 64
+
+// isPresenter reports whether r carries the presenter key, in the
+// X-Presenter-Key header or the key query parameter.
+func (s *Server) isPresenter(r *http.Request) bool {
+	key := r.Header.Get("X-Presenter-Key")
+	if key == "" {
+		key = r.URL.Query().Get("key")
+	}
+	return subtle.ConstantTimeCompare([]byte(key), []byte(s.presenterKey)) == 1
+}
+
+// joinURL is the join page's address as phones on the network reach it.
+func (s *Server) joinURL(r *http.Request) string {
+	base := s.baseURL
+	if lan := LANURL(base); lan != "" {
+		base = lan
+	}
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return base + "/join"
+}
+
+func (s *Server) presentState(r *http.Request) presentState {
+	p := s.present
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := presentState{
+		Round:    p.round,
+		Number:   p.pos + 1,
+		Total:    len(p.order),
+		Finished: p.pos >= len(p.order),
+		Voters:   len(p.votes),
+		Revealed: p.revealed,
+		Join:     s.joinURL(r),
+	}
+	if st.Finished {
+		st.Number = len(p.order)
+		return st
+	}
+	st.Question = s.payloadFor(p.order[p.pos], p.question)
+	if p.revealed {
+		st.Answer = strings.ToUpper(p.question.Answer)
+	}
+	if p.revealed || s.isPresenter(r) {
+		st.Votes = make(map[string]int, len(p.question.Options))
+		for k := range p.question.Options {
+			st.Votes[k] = 0
+		}
+		for _, v := range p.votes {
+			st.Votes[v]++
+		}
+	}
+	return st
+}
+
+// handlePresent serves the presenter view to holders of the key.
+func (s *Server) handlePresent(w http.ResponseWriter, r *http.Request) {
+	if !s.isPresenter(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.servePage(w, r, presentHTML)
+}
+
+// handleJoin serves the participants' answer page.
+func (s *Server) handleJoin(w http.ResponseWriter, r *http.Request) {
+	s.servePage(w, r, joinHTML)
+}
+
+// servePage serves page with the server's text direction, and with
+// csrfScript run first so its requests carry r's CSRF token.
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request, page string) {
+	page = strings.Replace(page, "<script>", "<script>"+csrfScript, 1)
+	t := template.Must(template.New("page").Parse(page))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// the token is the session's own, so shared caches must not keep it
+	w.Header().Set("Cache-Control", "private, no-cache")
+	dir := "ltr"
+	if s.textDir == "rtl" {
+		dir = "rtl"
+	}
+	_ = t.Execute(w, struct{ Dir, CSRF string }{dir, s.csrfToken(w, r)})
+}
+
+func (s *Server) handlePresentState(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, s.presentState(r))
+}
+
+// handleVote records a participant's answer to the question on screen. A
+// vote after the reveal, or for an earlier question, gets 409 Conflict.
+func (s *Server) handleVote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req voteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	voter := strings.TrimSpace(req.Voter)
+	answer := strings.ToUpper(strings.TrimSpace(req.Answer))
+	if voter == "" || len(voter) > maxVoterLen {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	p := s.present
+	p.mu.Lock()
+	if req.Round != p.round || p.revealed || p.pos >= len(p.order) {
+		p.mu.Unlock()
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if _, ok := p.question.Options[answer]; !ok {
+		p.mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	p.votes[voter] = answer
+	p.mu.Unlock()
+	writeJSON(w, r, map[string]string{"answer": answer})
+}
+
+// handleReveal shows the correct answer and the tally to everyone.
+func (s *Server) handleReveal(w http.ResponseWriter, r *http.Request) {
+	s.presenterAction(w, r, func(p *presentation) {
+		p.revealed = true
+	})
+}
+
+// handlePresentNext moves on to the next question.
+func (s *Server) handlePresentNext(w http.ResponseWriter, r *http.Request) {
+	s.presenterAction(w, r, func(p *presentation) {
+		if p.pos < len(p.order) {
+			p.pos++
+		}
+		p.showLocked()
+	})
+}
+
+// handlePresentRestart starts the questions over in a new order.
+func (s *Server) handlePresentRestart(w http.ResponseWriter, r *http.Request) {
+	s.presenterAction(w, r, func(p *presentation) {
+		p.order, p.pos = p.rng.Perm(len(p.questions)), 0
+		p.showLocked()
+	})
+}
+
+// presenterAction applies fn to the presentation for a POST carrying the
+// presenter key and responds with the new state.
+func (s *Server) presenterAction(w http.ResponseWriter, r *http.Request, fn func(*presentation)) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.isPresenter(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.present.mu.Lock()
+	fn(s.present)
+	s.present.mu.Unlock()
+	writeJSON(w, r, s.presentState(r))
+}
+
+// handleJoinQR draws the join address as a QR code for the room to scan.
+func (s *Server) handleJoinQR(w http.ResponseWriter, r *http.Request) {
+	code, err := qr.Encode(s.joinURL(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_ = code.WriteSVG(w)
+}
+
+// presentStyle is shared by the presenter view and the join page.
+const presentStyle = `
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <style>
+    :root {
+      --bg: #0f172a;
+      --panel: rgba(255, 255, 255, 0.05);
+      --text: #e2e8f0;
+      --muted: #94a3b8;
+      --accent: #22d3ee;
+      --good: #34d399;
+      --bad: #f43f5e;
+      font-family: "Space Grotesk", "Segoe UI", "Helvetica Neue", sans-serif;
+    }
+    * { box-sizing: border-box; }
+    body { margin: 0; min-height: 100vh; background: var(--bg); color: var(--text); padding: 24px; }
+    .muted { color: var(--muted); }
+    .prompt { font-weight: 700; line-height: 1.3; margin: 12px 0 20px; }
+    .prompt img, .prompt pre { max-width: 100%; }
+    img.figure { max-width: 100%; max-height: 40vh; border-radius: 12px; }
+    .letter { display: inline-flex; width: 1.6em; height: 1.6em; align-items: center; justify-content: center; border-radius: 8px; background: rgba(34,211,238,0.25); font-weight: 700; margin-right: 10px; flex: none; }
+    .good { color: var(--good); }
+    .bad { color: var(--bad); }
+    button { font: inherit; cursor: pointer; }
+    .cta { background: var(--accent); border: none; border-radius: 12px; color: #0b1221; font-weight: 700; padding: 12px 18px; }
+    .cta.ghost { background: transparent; color: var(--accent); border: 1px solid rgba(34,211,238,0.5); }
+    .cta:disabled { opacity: 0.4; cursor: default; }
+  </style>`
+
+const presentHTML = `<!doctype html>
+<html lang="en" dir="{{.Dir}}">
+<head>
+  <title>Quiz Presenter</title>` + presentStyle + `
+  <style>
+    body { font-size: 22px; }
+    .layout { display: grid; grid-template-columns: 1fr 260px; gap: 32px; max-width: 1400px; margin: 0 auto; }
+    .prompt { font-size: 40px; }
+    .row { display: flex; align-items: center; gap: 12px; margin: 14px 0; }
+    .row .text { flex: 1; font-size: 26px; }
+    .bar { height: 14px; border-radius: 7px; background: var(--accent); transition: width 300ms ease; margin-top: 6px; }
+    .row.correct .bar { background: var(--good); }
+    .row.correct .letter { background: var(--good); color: #0b1221; }
+    .count { width: 4em; text-align: end; color: var(--muted); }
+    .join { background: var(--panel); border-radius: 18px; padding: 16px; text-align: center; align-self: start; }
+    .join img { width: 100%; background: #fff; border-radius: 8px; }
+    .join .url { font-size: 16px; word-break: break-all; margin-top: 8px; }
+    .controls { display: flex; gap: 12px; margin-top: 24px; }
+  </style>
+</head>
+<body>
+  <div class="layout">
+    <main>
+      <div class="muted" id="progress"></div>
+      <div class="prompt" id="prompt"></div>
+      <img class="figure" id="figure" alt="" style="display:none;">
+      <div id="rows"></div>
+      <div class="controls">
+        <button class="cta" id="revealBtn">Reveal answer</button>
+        <button class="cta ghost" id="nextBtn">Next question</button>
+        <button class="cta ghost" id="restartBtn" style="display:none;">Start over</button>
+      </div>
+    </main>
+    <aside class="join">
+      <div>Answer on your phone</div>
+      <img id="joinQR" alt="QR code for the join page">
+      <div class="url" id="joinURL"></div>
+      <div class="muted" id="voters"></div>
+    </aside>
+  </div>
+  <script>
+    // The key this page was opened with authorizes the presenter's actions.
+    const key = new URLSearchParams(location.search).get("key") || "";
+    let round = 0;
+
+    async function call(path, method) {
+      const res = await fetch(path, { method: method || "GET", headers: { "X-Presenter-Key": key } });
+      if (res.ok) render(await res.json());
+    }
+
+    function render(st) {
+      document.getElementById("joinURL").innerText = st.join;
+      document.getElementById("voters").innerText = st.voters + (st.voters === 1 ? " answer" : " answers");
+      document.getElementById("restartBtn").style.display = st.finished ? "inline-block" : "none";
+      document.getElementById("revealBtn").disabled = st.finished || st.revealed;
+      document.getElementById("nextBtn").disabled = st.finished;
+      const rows = document.getElementById("rows");
+      const figure = document.getElementById("figure");
+      if (st.finished) {
+        document.getElementById("progress").innerText = "";
+        document.getElementById("prompt").innerText = "That's all " + st.total + " questions.";
+        figure.style.display = "none";
+        rows.innerHTML = "";
+        return;
+      }
+      document.getElementById("progress").innerText = "Question " + st.number + " of " + st.total;
+      const q = st.question;
+      if (st.round !== round) {
+        round = st.round;
+        const prompt = document.getElementById("prompt");
+        prompt.innerHTML = q.promptHtml;
+        prompt.dir = q.dir;
+        figure.style.display = q.image ? "block" : "none";
+        figure.src = q.image || "";
+        figure.alt = q.imageAlt || "";
+        rows.innerHTML = "";
+        Object.keys(q.options).sort().forEach(letter => {
+          const row = document.createElement("div");
+          row.className = "row";
+          row.dataset.letter = letter;
+          row.innerHTML = '<span class="letter"></span><div class="text"><span></span><div class="bar" style="width:0"></div></div><span class="count"></span>';
+          row.querySelector(".letter").innerText = letter;
+          const text = row.querySelector(".text span");
+          text.innerHTML = q.optionsHtml[letter];
+          text.dir = q.dir;
+          rows.appendChild(row);
+        });
+      }
+      const votes = st.votes || {};
+      const most = Math.max(1, ...Object.values(votes));
+      rows.querySelectorAll(".row").forEach(row => {
+        const n = votes[row.dataset.letter] || 0;
+        row.querySelector(".bar").style.width = (n / most * 100) + "%";
+        row.querySelector(".count").innerText = n;
+        row.classList.toggle("correct", st.revealed && row.dataset.letter === st.answer);
+      });
+    }
+
+    document.getElementById("joinQR").src = "/api/present/join.svg";
+    document.getElementById("revealBtn").onclick = () => call("/api/present/reveal", "POST");
+    document.getElementById("nextBtn").onclick = () => call("/api/present/next", "POST");
+    document.getElementById("restartBtn").onclick = () => call("/api/present/restart", "POST");
+    call("/api/present");
+    setInterval(() => call("/api/present"), 1000);
+  </script>
+</body>
+</html>
+`
+
+const joinHTML = `<!doctype html>
+<html lang="en" dir="{{.Dir}}">
+<head>
+  <title>Join the Quiz</title>` + presentStyle + `
+  <style>
+    body { font-size: 18px; max-width: 640px; margin: 0 auto; }
+    .prompt { font-size: 22px; }
+    .choice { display: flex; align-items: center; width: 100%; text-align: start; margin: 10px 0; padding: 14px; border-radius: 14px; border: 1px solid rgba(255,255,255,0.12); background: var(--panel); color: var(--text); }
+    .choice.chosen { border-color: var(--accent); background: rgba(34,211,238,0.15); }
+    .choice.correct { border-color: var(--good); background: rgba(52,211,153,0.18); }
+    .choice .count { margin-inline-start: auto; color: var(--muted); padding-inline-start: 10px; }
+  </style>
+</head>
+<body>
+  <div class="muted" id="progress">Waiting for the presenter...</div>
+  <div class="prompt" id="prompt"></div>
+  <img class="figure" id="figure" alt="" style="display:none;">
+  <div id="choices"></div>
+  <div class="muted" id="status"></div>
+  <script>
+    // voter identifies this phone, so answering again changes the vote.
+    let voter = localStorage.getItem("quizVoter");
+    if (!voter) {
+      voter = (crypto.randomUUID ? crypto.randomUUID() : String(Math.random()).slice(2));
+      localStorage.setItem("quizVoter", voter);
+    }
+    let round = 0;
+    let chosen = "";
+
+    async function poll() {
+      const res = await fetch("/api/present");
+      if (res.ok) render(await res.json());
+    }
+
+    function render(st) {
+      const choices = document.getElementById("choices");
+      const figure = document.getElementById("figure");
+      if (st.finished) {
+        document.getElementById("progress").innerText = "";
+        document.getElementById("prompt").innerText = "That's the end of the quiz. Thanks for playing!";
+        figure.style.display = "none";
+        choices.innerHTML = "";
+        document.getElementById("status").innerText = "";
+        round = st.round;
+        return;
+      }
+      document.getElementById("progress").innerText = "Question " + st.number + " of " + st.total;
+      const q = st.question;
+      if (st.round !== round) {
+        round = st.round;
+        chosen = "";
+        const prompt = document.getElementById("prompt");
+        prompt.innerHTML = q.promptHtml;
+        prompt.dir = q.dir;
+        figure.style.display = q.image ? "block" : "none";
+        figure.src = q.image || "";
+        figure.alt = q.imageAlt || "";
+        choices.innerHTML = "";
+        Object.keys(q.options).sort().forEach(letter => {
+          const btn = document.createElement("button");
+          btn.className = "choice";
+          btn.dataset.letter = letter;
+          btn.innerHTML = '<span class="letter"></span><span class="text"></span><span class="count"></span>';
+          btn.querySelector(".letter").innerText = letter;
+          const text = btn.querySelector(".text");
+          text.innerHTML = q.optionsHtml[letter];
+          text.dir = q.dir;
+          btn.onclick = () => vote(letter);
+          choices.appendChild(btn);
+        });
+      }
+      choices.querySelectorAll(".choice").forEach(btn => {
+        const letter = btn.dataset.letter;
+        btn.disabled = st.revealed;
+        btn.classList.toggle("chosen", letter === chosen);
+        btn.classList.toggle("correct", st.revealed && letter === st.answer);
+        btn.querySelector(".count").innerText = st.votes ? st.votes[letter] || 0 : "";
+      });
+      const status = document.getElementById("status");
+      if (st.revealed) {
+        status.className = chosen === st.answer ? "good" : chosen ? "bad" : "muted";
+        status.innerText = chosen === st.answer ? "Correct!" : chosen ? "Not this time: the answer is " + st.answer + "." : "The answer is " + st.answer + ".";
+      } else {
+        status.className = "muted";
+        status.innerText = chosen ? "Answer sent. You can change it until the reveal." : "Tap your answer.";
+      }
+    }
+
+    async function vote(letter) {
+      const res = await fetch("/api/present/vote", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ round, voter, answer: letter })
+      });
+      if (res.ok) chosen = letter;
+      poll();
+    }
+
+    poll();
+    setInterval(poll, 1000);
+  </script>
+</body>
+</html>
+`
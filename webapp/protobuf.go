@@ -0,0 +1,160 @@
+package webapp
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// pbMessage builds a protocol buffers message field by field, for the few
+// message shapes the gRPC API needs. Zero values are omitted, as proto3 does.
+type pbMessage []byte
+
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+func (m pbMessage) tag(field, wire int) pbMessage {
+	return binary.AppendUvarint(m, uint64(field)<<3|uint64(wire))
+}
+
+func (m pbMessage) int(field int, v int) pbMessage {
+	if v == 0 {
+		return m
+	}
+	// int32 fields carry negative values sign-extended to 64 bits.
+	return binary.AppendUvarint(m.tag(field, pbVarint), uint64(int64(v)))
+}
+
+func (m pbMessage) bool(field int, v bool) pbMessage {
+	if !v {
+		return m
+	}
+	return append(m.tag(field, pbVarint), 1)
+}
+
+func (m pbMessage) double(field int, v float64) pbMessage {
+	if v == 0 {
+		return m
+	}
+	return binary.LittleEndian.AppendUint64(m.tag(field, pbFixed64), math.Float64bits(v))
+}
+
+func (m pbMessage) string(field int, v string) pbMessage {
+	if v == "" {
+		return m
+	}
+	return m.bytes(field, []byte(v))
+}
+
+func (m pbMessage) bytes(field int, v []byte) pbMessage {
+	m = binary.AppendUvarint(m.tag(field, pbBytes), uint64(len(v)))
+	return append(m, v...)
+}
+
+// message embeds sub; unlike scalars it is written even when empty, so the
+// reader sees the field as set.
+func (m pbMessage) message(field int, sub pbMessage) pbMessage {
+	return m.bytes(field, sub)
+}
+
+// stringMap writes a map<string, string> field, sorted by key so the output
+// is stable.
+func (m pbMessage) stringMap(field int, v map[string]string) pbMessage {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m = m.message(field, pbMessage(nil).string(1, k).string(2, v[k]))
+	}
+	return m
+}
+
+var errPBMalformed = errors.New("malformed protocol buffers message")
+
+// pbField is one decoded field: Varint holds varint and fixed values, Bytes
+// the payload of length-delimited ones.
+type pbField struct {
+	Num    int
+	Wire   int
+	Varint uint64
+	Bytes  []byte
+}
+
+// pbDecode splits data into its fields, in order.
+func pbDecode(data []byte) ([]pbField, error) {
+	var out []pbField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errPBMalformed
+		}
+		data = data[n:]
+		f := pbField{Num: int(key >> 3), Wire: int(key & 7)}
+		switch f.Wire {
+		case pbVarint:
+			if f.Varint, n = binary.Uvarint(data); n <= 0 {
+				return nil, errPBMalformed
+			}
+			data = data[n:]
+		case pbFixed64:
+			if len(data) < 8 {
+				return nil, errPBMalformed
+			}
+			f.Varint, data = binary.LittleEndian.Uint64(data), data[8:]
+		case pbFixed32:
+			if len(data) < 4 {
+				return nil, errPBMalformed
+			}
+			f.Varint, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case pbBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return nil, errPBMalformed
+			}
+			f.Bytes, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return nil, errPBMalformed
+		}
+		if f.Num == 0 {
+			return nil, errPBMalformed
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// pbBool reports whether data sets the bool field num; malformed data sets
+// nothing.
+func pbBool(data []byte, num int) bool {
+	fields, _ := pbDecode(data)
+	set := false
+	for _, f := range fields {
+		if f.Num == num && f.Wire == pbVarint {
+			set = f.Varint != 0
+		}
+	}
+	return set
+}
+
+// pbStrings decodes data and returns its string fields by number; later
+// occurrences win, as in proto3.
+func pbStrings(data []byte) (map[int]string, error) {
+	fields, err := pbDecode(data)
+	if err != nil {
+		return nil, err
+	}
+	out := map[int]string{}
+	for _, f := range fields {
+		if f.Wire == pbBytes {
+			out[f.Num] = string(f.Bytes)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package webapp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"quiz-cli/quiz"
+)
+
+// questionFilter selects questions by domain, tags (all of them) and a
+// case-insensitive search of the prompt and options.
+type questionFilter struct {
+	domain    int
+	hasDomain bool
+	tags      []string
+	needle    string
+}
+
+func (f questionFilter) match(q quiz.Question) bool {
+	if f.hasDomain && q.Domain != f.domain {
+		return false
+	}
+	for _, tag := range f.tags {
+		if !q.HasTag(tag) {
+			return false
+		}
+	}
+	return f.needle == "" || questionMatches(q.Prompt, q.Options, f.needle)
+}
+
+type questionPage struct {
+	Total  int               `json:"total"`
+	Offset int               `json:"offset"`
+	Limit  int               `json:"limit"`
+	Items  []questionPayload `json:"items"`
+}
+
+// handleQuestions lists the bank a page at a time, without the answers, for
+// tools that browse it. Query parameters:
+//
+//	domain  only questions in this domain
+//	tag     only questions with this tag; repeat to require several
+//	q       case-insensitive match against prompt and option text
+//	offset  questions to skip (default 0)
+//	limit   questions to return (default 50, max 500)
+//
+// Total counts every match, so a client can page with offset.
+func (s *Server) handleQuestions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	filter := questionFilter{needle: strings.ToLower(strings.TrimSpace(params.Get("q")))}
+	for _, tag := range params["tag"] {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.tags = append(filter.tags, tag)
+		}
+	}
+	var err error
+	if v := params.Get("domain"); v != "" {
+		if filter.domain, err = strconv.Atoi(v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		filter.hasDomain = true
+	}
+	offset, limit := 0, defaultAdminPageSize
+	if v := params.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	limit = min(limit, maxAdminPageSize)
+
+	resp := questionPage{Offset: offset, Limit: limit, Items: []questionPayload{}}
+	for i, q := range s.bank() {
+		if !filter.match(q) {
+			continue
+		}
+		if resp.Total >= offset && len(resp.Items) < limit {
+			resp.Items = append(resp.Items, *s.payloadFor(i, q))
+		}
+		resp.Total++
+	}
+	writeJSON(w, r, resp)
+}
@@ -0,0 +1,86 @@
+package webapp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"quiz-cli/stats"
+)
+
+type readinessResponse struct {
+	PassMark float64                `json:"passMark"`
+	Domains  []stats.DomainForecast `json:"domains"`
+	// Intervals is each domain's recent first-attempt accuracy with its
+	// confidence interval; Level is the interval's confidence in percent.
+	Intervals []stats.DomainInterval `json:"intervals"`
+	Level     float64                `json:"level"`
+	// Exam, OnTrack and Behind are set when the request names an exam date.
+	Exam    string `json:"exam,omitempty"`
+	OnTrack *bool  `json:"onTrack,omitempty"`
+	Behind  []int  `json:"behind,omitempty"`
+}
+
+// handleReadiness reports the per-domain learning-curve forecast from the
+// -stats history, and the confidence interval around each domain's recent
+// first-attempt accuracy. Query parameters: pass (percentage, default 70),
+// exam (YYYY-MM-DD), recent (first attempts per domain, default 50) and
+// level (confidence in percent, default 95).
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	resp := readinessResponse{PassMark: 70}
+	if v := r.URL.Query().Get("pass"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p < 0 || p > 100 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp.PassMark = p
+	}
+	policy := stats.DefaultIntervalPolicy
+	policy.PassMark = resp.PassMark
+	if v := r.URL.Query().Get("recent"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		policy.Recent = n
+	}
+	if v := r.URL.Query().Get("level"); v != "" {
+		l, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		policy.Level = l / 100
+	}
+	if policy.Check() != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	resp.Level = policy.Level * 100
+	resp.Intervals = s.stats.Intervals(s.bank(), policy)
+	if resp.Intervals == nil {
+		resp.Intervals = []stats.DomainInterval{}
+	}
+	resp.Domains = s.stats.Forecast(s.bank(), resp.PassMark, time.Now())
+	if resp.Domains == nil {
+		resp.Domains = []stats.DomainForecast{}
+	}
+	if v := r.URL.Query().Get("exam"); v != "" {
+		exam, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp.Exam = v
+		resp.Behind = stats.Behind(resp.Domains, exam)
+		onTrack := len(resp.Domains) > 0 && len(resp.Behind) == 0
+		resp.OnTrack = &onTrack
+	}
+	writeJSON(w, r, resp)
+}
@@ -0,0 +1,164 @@
+package webapp
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"reflect"
+	"time"
+
+	"quiz-cli/quiz"
+	"quiz-cli/stats"
+)
+
+// reloadPoll is how often Run checks the bank file for changes.
+const reloadPoll = 2 * time.Second
+
+// reloader loads the bank again (see WithReload).
+type reloader struct {
+	path string
+	load func(context.Context) ([]quiz.Question, error)
+}
+
+// WithReload lets the server pick up edits to the question bank without a
+// restart. load reads the bank again, applying the same selection as at
+// startup; POST /api/reload, which needs the admin key (see WithAdminKey),
+// calls it, and Run also calls it whenever the
+// file at path changes. A changed bank is used from the next session on, so
+// a session in progress keeps the questions it started with; a session that
+// has not been answered yet is restarted on the new bank at once. Reloads
+// and bad edits are logged.
+func WithReload(path string, load func(context.Context) ([]quiz.Question, error)) Option {
+	return func(s *Server) {
+		s.reload = &reloader{path: path, load: load}
+	}
+}
+
+// reloadResponse describes a reload: the bank's size and how it differs from
+// the one it replaces. Applied is set when the current session was restarted
+// on it; otherwise it waits for the next session.
+type reloadResponse struct {
+	Questions int  `json:"questions"`
+	Added     int  `json:"added"`
+	Changed   int  `json:"changed"`
+	Removed   int  `json:"removed"`
+	Applied   bool `json:"applied"`
+}
+
+// bank returns the questions of the current session, in bank order.
+func (s *Server) bank() []quiz.Question {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.questions
+}
+
+// takeBank returns the questions a new session should use: a reloaded bank
+// waiting for it, if any, or else the current one.
+func (s *Server) takeBank() []quiz.Question {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending != nil {
+		questions := s.pending
+		s.pending = nil
+		return questions
+	}
+	return s.questions
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.reload == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	resp, err := s.reloadBank(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, r, resp)
+}
+
+// reloadBank loads the bank and, when it differs from the latest one, stages
+// it for the next session.
+func (s *Server) reloadBank(ctx context.Context) (reloadResponse, error) {
+	questions, err := s.reload.load(ctx)
+	if err != nil {
+		return reloadResponse{}, err
+	}
+	s.mu.Lock()
+	latest := s.questions
+	if s.pending != nil {
+		latest = s.pending
+	}
+	resp := compareBanks(latest, questions)
+	changed := !reflect.DeepEqual(latest, questions)
+	if changed {
+		s.pending = questions
+	}
+	session := s.session
+	s.mu.Unlock()
+	if changed && session.AttemptedCount() == 0 {
+		s.reset()
+		resp.Applied = true
+	}
+	return resp, nil
+}
+
+// compareBanks counts the questions next adds to, changes in and removes
+// from prev, matching them as the stats history does.
+func compareBanks(prev, next []quiz.Question) reloadResponse {
+	resp := reloadResponse{Questions: len(next)}
+	before := make(map[string]quiz.Question, len(prev))
+	for _, q := range prev {
+		before[stats.Key(q)] = q
+	}
+	for _, q := range next {
+		key := stats.Key(q)
+		old, ok := before[key]
+		switch {
+		case !ok:
+			resp.Added++
+		case !reflect.DeepEqual(old, q):
+			resp.Changed++
+		}
+		delete(before, key)
+	}
+	resp.Removed = len(before)
+	return resp
+}
+
+// watchBank reloads the bank whenever its file's size or modification time
+// changes. Banks that are not local files, such as s3:// locations, are not
+// watched.
+func (s *Server) watchBank(ctx context.Context) {
+	info, err := os.Stat(s.reload.path)
+	if err != nil {
+		return
+	}
+	ticker := time.NewTicker(reloadPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		next, err := os.Stat(s.reload.path)
+		if err != nil || (next.ModTime().Equal(info.ModTime()) && next.Size() == info.Size()) {
+			continue
+		}
+		info = next
+		resp, err := s.reloadBank(ctx)
+		switch {
+		case err != nil:
+			s.log().Warn("not reloading the bank", "file", s.reload.path, "err", err)
+		case resp.Added+resp.Changed+resp.Removed > 0:
+			s.log().Info("reloaded the bank", "file", s.reload.path, "questions", resp.Questions,
+				"added", resp.Added, "changed", resp.Changed, "removed", resp.Removed, "applied", resp.Applied)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package webapp
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-cli/report"
+)
+
+// handleReport renders the current session's completion report. Query
+// parameters: format (pdf, the default, html, or md for the Markdown study
+// sheet), name, and pass (the pass mark in percent, omitted from the report
+// when absent).
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "pdf"
+	}
+	if format != "pdf" && format != "html" && format != "md" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var passMark float64
+	if v := q.Get("pass"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p < 0 || p > 100 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		passMark = p
+	}
+	name := strings.TrimSpace(q.Get("name"))
+	if runes := []rune(name); len(runes) > maxNameLen {
+		name = string(runes[:maxNameLen])
+	}
+
+	s.mu.Lock()
+	session, started, finished := s.session, s.started, s.finished
+	s.mu.Unlock()
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	if _, answered := session.Score(); answered == 0 {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	rep := report.FromSession("CSSLP Review Quiz", session, started, finished)
+	rep.Name, rep.PassMark = name, passMark
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf, rep, format); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	switch format {
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="quiz-report.pdf"`)
+	case "md":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="study-sheet.md"`)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.Write(buf.Bytes())
+}
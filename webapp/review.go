@@ -0,0 +1,101 @@
+package webapp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"quiz-cli/markdown"
+	"quiz-cli/quiz"
+)
+
+// reviewPayload is a summary row's question in full, with the answer given,
+// the correct answer and the explanation.
+type reviewPayload struct {
+	questionPayload
+	UserAnswer      string `json:"userAnswer"`
+	CorrectAnswer   string `json:"correctAnswer"`
+	Correct         bool   `json:"correct"`
+	ExplanationHTML string `json:"explanationHtml,omitempty"`
+	Source          string `json:"source,omitempty"`
+}
+
+// requeueRequest names summary rows by their index, counted from 1.
+type requeueRequest struct {
+	Indexes []int `json:"indexes"`
+}
+
+// handleReviewQuestion serves GET /api/summary/question?index=N: the
+// question of row N of the summary in full. Questions not yet answered are
+// 404 Not Found, so the answers stay back until they are.
+func (s *Server) handleReviewQuestion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	index, err := strconv.Atoi(r.URL.Query().Get("index"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	session := s.current()
+	if !session.Attempted(index - 1) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	q, res := session.Questions[index-1], session.Results()[index-1]
+	writeJSON(w, r, reviewPayload{
+		questionPayload: *s.payloadFor(index, q),
+		UserAnswer:      res.UserAnswer,
+		CorrectAnswer:   q.Answer,
+		Correct:         res.Correct,
+		ExplanationHTML: markdown.HTML(q.Explanation),
+		Source:          q.Source,
+	})
+}
+
+// handleRequeue serves POST /api/requeue: a quick session on the answered
+// questions of the summary rows named, in place of the current one. The bank
+// is left as it is, so the next reset starts a full run again.
+func (s *Server) handleRequeue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req requeueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	session := s.current()
+	var questions []quiz.Question
+	seen := map[int]bool{}
+	for _, index := range req.Indexes {
+		if !session.Attempted(index-1) || seen[index] {
+			continue
+		}
+		seen[index] = true
+		questions = append(questions, session.Questions[index-1])
+	}
+	if len(questions) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.startSession(s.quickSession(questions))
+	writeJSON(w, r, s.buildState(r.Context()))
+}
+
+// quickSession is a session on questions without the sections, sudden death,
+// breaks or order of a full run. Its answers still reach the listeners, so
+// they count towards the stats.
+func (s *Server) quickSession(questions []quiz.Question) *quiz.Session {
+	session := quiz.NewSession(questions)
+	for _, l := range s.listeners {
+		session.AddListener(l)
+	}
+	session.UsePenalty(s.penalty)
+	if s.feedback.Silent {
+		session.UseSinglePass()
+	}
+	return session
+}
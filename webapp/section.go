@@ -0,0 +1,90 @@
+package webapp
+
+import (
+	"net/http"
+
+	"quiz-cli/quiz"
+)
+
+// WithSections runs every session as a sectioned exam (see
+// quiz.Session.UseSections). Each section opens with an intro card, and its
+// clock starts when the learner presses Start.
+func WithSections(sections []quiz.Section) Option {
+	return func(s *Server) {
+		s.sections = sections
+	}
+}
+
+type sectionPayload struct {
+	Index            int     `json:"index"`
+	Count            int     `json:"count"`
+	Domain           int     `json:"domain"`
+	Title            string  `json:"title"`
+	Total            int     `json:"total"`
+	Answered         int     `json:"answered"`
+	Correct          int     `json:"correct"`
+	BudgetSeconds    float64 `json:"budgetSeconds"`
+	ElapsedSeconds   float64 `json:"elapsedSeconds"`
+	RemainingSeconds float64 `json:"remainingSeconds"`
+	Started          bool    `json:"started"`
+	Locked           bool    `json:"locked"`
+	TimedOut         bool    `json:"timedOut"`
+}
+
+func newSectionPayload(sec quiz.SectionSummary, count int) *sectionPayload {
+	return &sectionPayload{
+		Index:            sec.Index,
+		Count:            count,
+		Domain:           sec.Domain,
+		Title:            sec.Title(),
+		Total:            sec.Total,
+		Answered:         sec.Answered,
+		Correct:          sec.Correct,
+		BudgetSeconds:    sec.Budget.Seconds(),
+		ElapsedSeconds:   sec.Elapsed.Seconds(),
+		RemainingSeconds: sec.Remaining.Seconds(),
+		Started:          sec.Started,
+		Locked:           sec.Locked,
+		TimedOut:         sec.TimedOut,
+	}
+}
+
+func sectionPayloads(secs []quiz.SectionSummary) []sectionPayload {
+	if secs == nil {
+		return nil
+	}
+	out := make([]sectionPayload, len(secs))
+	for i, sec := range secs {
+		out[i] = *newSectionPayload(sec, len(secs))
+	}
+	return out
+}
+
+// sectionIntro fills in resp's section fields and reports whether the current
+// section hasn't started yet, in which case the intro card is shown instead of
+// a question.
+func sectionIntro(session *quiz.Session, resp *stateResponse) bool {
+	sec, ok := session.CurrentSection()
+	if !ok {
+		return false
+	}
+	all := session.Sections()
+	resp.Section = newSectionPayload(sec, len(all))
+	if sec.Started {
+		return false
+	}
+	resp.SectionIntro = true
+	if sec.Index > 0 {
+		resp.PreviousSection = newSectionPayload(all[sec.Index-1], len(all))
+	}
+	return true
+}
+
+func (s *Server) handleStartSection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.current().StartSection()
+	writeJSON(w, r, s.buildState(r.Context()))
+}
@@ -1,44 +1,377 @@
+// Package webapp serves a quiz.Session over HTTP with a single-page UI.
 package webapp
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html/template"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"quiz-cli/autosave"
+	"quiz-cli/challenge"
+	"quiz-cli/markdown"
 	"quiz-cli/quiz"
+	"quiz-cli/stats"
 )
 
+// Server holds the active web session.
 type Server struct {
-	session   *quiz.Session
-	questions []quiz.Question
-	mu        sync.Mutex
+	session *quiz.Session
+	// questions are the current session's; pending is a reloaded bank
+	// waiting for the next session (see WithReload).
+	questions  []quiz.Question
+	pending    []quiz.Question
+	reload     *reloader
+	listeners  []quiz.Listener
+	stats      *stats.Store
+	policy     stats.ReviewPolicy
+	graphQL    bool
+	grpc       bool
+	mediaDir   string
+	sections   []quiz.Section
+	confidence bool
+	penalty    float64
+	// confirmAnswers makes the page ask for a second click before it
+	// submits an answer, unless the learner turned that off.
+	confirmAnswers bool
+	// suddenDeath ends each session at the first wrong answer and ranks
+	// runs by streak on the board.
+	suddenDeath bool
+	// pacing gives each question a shrinking time limit (see WithPacing).
+	pacing *quiz.Pacing
+	// hardestFirst orders sessions by the stats history's difficulty.
+	hardestFirst bool
+	// ordering is how sessions shuffle their questions (see WithOrdering).
+	ordering quiz.Ordering
+	// breaks says when sessions suggest a break (see WithBreaks).
+	breaks quiz.Breaks
+	// feedback sets how the page responds to each answer (see
+	// WithFeedback).
+	feedback quiz.Feedback
+	textDir  string
+	seed     int64
+	seeded   bool
+	board    *challenge.Board
+	// started and finished time the current session for the leaderboard;
+	// posted records that its score was submitted.
+	started  time.Time
+	finished time.Time
+	posted   bool
+	tlsCert  string
+	tlsKey   string
+	ready    func(url string)
+	// presenterKey turns on instructor mode (see WithPresenter), whose poll
+	// is present; baseURL is where Run is serving.
+	presenterKey string
+	present      *presentation
+	baseURL      string
+	// adminKey opens the /api/admin routes (see WithAdminKey).
+	adminKey string
+	// webhook receives each finished session (see WithWebhook).
+	webhook       string
+	webhookFailed func(error)
+	// lti makes the server an LTI tool (see WithLTI); a learner's server
+	// has grade set to send their finished session to the LMS. opts are
+	// the options the server was made with, for learners' servers.
+	lti   *ltiState
+	grade func(*quiz.Session)
+	opts  []Option
+	// sessionTTL and maxSessions bound the per-client sessions (see
+	// WithSessionLimits).
+	sessionTTL  time.Duration
+	maxSessions int
+	// csrfKey signs the CSRF token each browser session must send with
+	// requests that change state, unless they come from allowedOrigins
+	// (see guard).
+	csrfKey        []byte
+	allowedOrigins []string
+	build          BuildInfo
+	logger         *slog.Logger
+	// autosave saves the session as it is answered, and resume is the run
+	// it saved before (see WithAutosave).
+	autosave       *autosave.Saver
+	resume         *quiz.State
+	autosaveFailed func(error)
+	mu             sync.Mutex
 }
 
-func Run(addr string, questions []quiz.Question) error {
-	s := &Server{
-		session:   quiz.NewSession(questions),
-		questions: questions,
+// Option configures a Server.
+type Option func(*Server)
+
+// WithListener attaches l to every session the server starts, including after
+// a reset.
+func WithListener(l quiz.Listener) Option {
+	return func(s *Server) {
+		s.listeners = append(s.listeners, l)
+	}
+}
+
+// WithStats enables learner flagging and the admin review queue, backed by
+// store and governed by policy.
+func WithStats(store *stats.Store, policy stats.ReviewPolicy) Option {
+	return func(s *Server) {
+		s.stats = store
+		s.policy = policy
+	}
+}
+
+// WithGraphQL serves a GraphQL endpoint at /graphql alongside the REST API.
+func WithGraphQL() Option {
+	return func(s *Server) {
+		s.graphQL = true
+	}
+}
+
+// WithMediaDir serves question images with relative paths from dir under
+// /media/. Only files referenced by a question are served.
+func WithMediaDir(dir string) Option {
+	return func(s *Server) {
+		s.mediaDir = dir
+	}
+}
+
+// WithConfirmAnswers makes the page submit an answer in two steps, Submit
+// then Confirm, so a learner can still change their mind. It is the page's
+// default; each learner can turn it on or off with the page's Confirm
+// answers switch, which their browser remembers.
+func WithConfirmAnswers() Option {
+	return func(s *Server) {
+		s.confirmAnswers = true
+	}
+}
+
+// WithConfidence has the web UI collect a guessing/unsure/sure rating with
+// each answer and report calibration in the summary.
+func WithConfidence() Option {
+	return func(s *Server) {
+		s.confidence = true
+	}
+}
+
+// WithPenalty marks exam-style: each wrong first attempt costs penalty times
+// the question's weight in the summary's points (see quiz.Session.UsePenalty).
+func WithPenalty(penalty float64) Option {
+	return func(s *Server) {
+		s.penalty = penalty
+	}
+}
+
+// WithSuddenDeath ends each session at the first wrong answer, for a streak
+// drill; the leaderboard, if any, ranks the longest streaks.
+func WithSuddenDeath() Option {
+	return func(s *Server) {
+		s.suddenDeath = true
+	}
+}
+
+// WithPacing gives each question of every session a time limit that
+// shrinks over the session as p sets; the page counts it down and answers
+// blank when it runs out, and late answers count as misses (see
+// quiz.Session.UsePacing).
+func WithPacing(p quiz.Pacing) Option {
+	return func(s *Server) {
+		s.pacing = &p
+	}
+}
+
+// WithOrdering sets how each new session shuffles its questions: across the
+// whole bank (the default), within each domain, or not at all (see
+// quiz.Session.UseOrdering).
+func WithOrdering(o quiz.Ordering) Option {
+	return func(s *Server) {
+		s.ordering = o
+	}
+}
+
+// WithFeedback sets how the page responds to each answer: moving on by
+// itself after Advance, keeping the correct answer back after a miss,
+// flashing a brief verdict, or saying nothing until the summary, with misses
+// not asked again.
+func WithFeedback(f quiz.Feedback) Option {
+	return func(s *Server) {
+		s.feedback = f
+	}
+}
+
+// WithBreaks has sessions suggest breaks as b says: when one is due the
+// session is paused, and the page asks the learner to take a break and
+// resume when they are back.
+func WithBreaks(b quiz.Breaks) Option {
+	return func(s *Server) {
+		s.breaks = b
+	}
+}
+
+// WithTextDir sets the page's base text direction, "ltr" or "rtl", for banks
+// written in right-to-left languages. Each prompt and option still follows its
+// own question's Dir.
+func WithTextDir(dir string) Option {
+	return func(s *Server) {
+		s.textDir = dir
+	}
+}
+
+// WithTLS makes Run serve HTTPS using the given certificate and key files.
+func WithTLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.tlsCert = certFile
+		s.tlsKey = keyFile
+	}
+}
+
+// WithReady calls fn with the server's URL once Run is listening, e.g. to
+// open a browser at it.
+func WithReady(fn func(url string)) Option {
+	return func(s *Server) {
+		s.ready = fn
+	}
+}
+
+// NewServer returns a Server quizzing over questions.
+func NewServer(questions []quiz.Question, opts ...Option) *Server {
+	s := &Server{questions: questions, opts: opts, csrfKey: []byte(randomToken())}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.session = s.newSession()
+	s.resumeSaved()
+	s.started = time.Now()
+	if s.presenterKey != "" {
+		s.present = newPresentation(questions, s.session.Seed())
+	}
+	return s
+}
+
+// Run serves the quiz on addr until the listener fails.
+func Run(addr string, questions []quiz.Question, opts ...Option) error {
+	s := NewServer(questions, opts...)
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      s.Handler(),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
 	}
+	if s.grpc && s.tlsCert == "" {
+		if err := enableH2C(server); err != nil {
+			return err
+		}
+	}
+	scheme := "http"
+	if s.tlsCert != "" {
+		// load the certificate before listening, so a bad one fails before
+		// anyone is pointed at the server
+		cert, err := tls.LoadX509KeyPair(s.tlsCert, s.tlsKey)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		scheme = "https"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	url := scheme + "://" + browseHost(ln.Addr())
+	s.baseURL = url
+	fmt.Printf("Web quiz available at %s\n", url)
+	if s.ready != nil {
+		s.ready(url)
+	}
+	if s.reload != nil {
+		go s.watchBank(context.Background())
+	}
+	if s.lti != nil && s.sessionTTL > 0 {
+		go s.expireSessions(context.Background())
+	}
+	if scheme == "https" {
+		return server.ServeTLS(ln, "", "")
+	}
+	return server.Serve(ln)
+}
+
+// browseHost is the host:port a browser on this machine reaches addr at:
+// a wildcard listen address becomes localhost.
+func browseHost(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Handler returns the HTTP routes for s.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleHome)
 	mux.HandleFunc("/api/state", s.handleState)
+	mux.HandleFunc("/api/csrf", s.handleCSRF)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/version", s.handleVersion)
 	mux.HandleFunc("/api/answer", s.handleAnswer)
 	mux.HandleFunc("/api/summary", s.handleSummary)
+	mux.HandleFunc("/api/summary/question", s.handleReviewQuestion)
+	mux.HandleFunc("/api/requeue", s.handleRequeue)
 	mux.HandleFunc("/api/reset", s.handleReset)
+	mux.HandleFunc("/api/reload", s.admin(s.handleReload))
 	mux.HandleFunc("/api/jump", s.handleJump)
-	server := &http.Server{
-		Addr:         addr,
-		Handler:      mux,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	mux.HandleFunc("/api/flag", s.handleFlag)
+	mux.HandleFunc("/api/note", s.handleNote)
+	mux.HandleFunc("/api/notes", s.handleNotes)
+	mux.HandleFunc("/api/reports", s.handleReports)
+	mux.HandleFunc("/api/section/start", s.handleStartSection)
+	mux.HandleFunc("/api/pause", s.handlePause)
+	mux.HandleFunc("/api/questions", s.handleQuestions)
+	mux.HandleFunc("/api/admin/questions", s.admin(s.handleAdminQuestions))
+	mux.HandleFunc("/api/admin/reviews", s.admin(s.handleReviews))
+	mux.HandleFunc("/api/admin/sessions", s.admin(s.handleAdminSessions))
+	mux.HandleFunc("/api/admin/sessions/expire", s.admin(s.handleExpireSession))
+	mux.HandleFunc("/api/admin/reviews/resolve", s.admin(s.handleResolveReview))
+	mux.HandleFunc("/api/readiness", s.handleReadiness)
+	mux.HandleFunc("/api/analytics", s.handleAnalytics)
+	mux.HandleFunc("/api/challenge", s.handleChallenge)
+	mux.HandleFunc("/api/challenge/start", s.handleStartChallenge)
+	mux.HandleFunc("/api/challenge/score", s.handleScore)
+	mux.HandleFunc("/api/report", s.handleReport)
+	if s.graphQL {
+		mux.HandleFunc("/graphql", s.handleGraphQL)
+	}
+	if s.mediaDir != "" {
+		mux.HandleFunc("/media/", s.handleMedia)
+	}
+	if s.presenterKey != "" {
+		mux.HandleFunc("/present", s.handlePresent)
+		mux.HandleFunc("/join", s.handleJoin)
+		mux.HandleFunc("/api/present", s.handlePresentState)
+		mux.HandleFunc("/api/present/vote", s.handleVote)
+		mux.HandleFunc("/api/present/reveal", s.handleReveal)
+		mux.HandleFunc("/api/present/next", s.handlePresentNext)
+		mux.HandleFunc("/api/present/restart", s.handlePresentRestart)
+		mux.HandleFunc("/api/present/join.svg", s.handleJoinQR)
+	}
+	if s.grpc {
+		mux.HandleFunc("/quiz.proto", s.handleProto)
+		mux.HandleFunc(grpcPrefix, s.handleGRPC)
+	}
+	var h http.Handler = s.guard(mux)
+	if s.lti != nil {
+		h = s.routeLearners(mux, h)
 	}
-	fmt.Printf("Web quiz available at http://%s\n", addr)
-	return server.ListenAndServe()
+	return s.logRequests(cacheable(h))
 }
 
 type stateResponse struct {
@@ -46,13 +379,71 @@ type stateResponse struct {
 	Question *questionPayload `json:"question,omitempty"`
 	Progress progressPayload  `json:"progress"`
 	Summary  *summaryPayload  `json:"summary,omitempty"`
+	// Section is set for sectioned exams. While SectionIntro is true the
+	// section has not started and Question is empty.
+	Section         *sectionPayload `json:"section,omitempty"`
+	SectionIntro    bool            `json:"sectionIntro,omitempty"`
+	PreviousSection *sectionPayload `json:"previousSection,omitempty"`
+	// Confidence asks the UI to collect a rating with each answer.
+	Confidence bool `json:"confidence,omitempty"`
+	// ConfirmAnswers is the page's default for submitting in two steps.
+	ConfirmAnswers bool `json:"confirmAnswers,omitempty"`
+	// Notes reports that notes can be kept through /api/note, and Reports
+	// that issues with questions can be reported through /api/reports.
+	Notes   bool `json:"notes,omitempty"`
+	Reports bool `json:"reports,omitempty"`
+	// Paused is set while the session is paused through /api/pause; the
+	// question is then withheld. Break is set when the pause is a break the
+	// session suggested (see WithBreaks).
+	Paused bool `json:"paused,omitempty"`
+	Break  bool `json:"break,omitempty"`
+	// AdvanceSeconds is how long the page shows feedback before moving on,
+	// Silent that it shows none, and Brief that it flashes only right or
+	// wrong and the correct answer.
+	AdvanceSeconds float64 `json:"advanceSeconds,omitempty"`
+	Silent         bool    `json:"silent,omitempty"`
+	Brief          bool    `json:"brief,omitempty"`
+	// Blind asks the page to hide the progress and section results until
+	// the summary.
+	Blind bool `json:"blind,omitempty"`
+	// Pace is the question's time limit in a paced session.
+	Pace *pacePayload `json:"pace,omitempty"`
+}
+
+// pacePayload is the time allowed for the question on screen and how much of
+// it is left, for the page to count down.
+type pacePayload struct {
+	LimitSeconds float64 `json:"limitSeconds"`
+	LeftSeconds  float64 `json:"leftSeconds"`
 }
 
 type questionPayload struct {
+	ID      string            `json:"id"`
 	Index   int               `json:"index"`
 	Domain  int               `json:"domain"`
 	Prompt  string            `json:"prompt"`
 	Options map[string]string `json:"options"`
+	// PromptHTML and OptionsHTML are the Markdown-rendered, escaped forms of
+	// Prompt and Options.
+	PromptHTML  string            `json:"promptHtml"`
+	OptionsHTML map[string]string `json:"optionsHtml"`
+	Image       string            `json:"image,omitempty"`
+	ImageAlt    string            `json:"imageAlt,omitempty"`
+	// Dir is the text direction for the prompt and options.
+	Dir    string `json:"dir"`
+	Notice string `json:"notice,omitempty"`
+	// Note is the learner's note on the question.
+	Note string   `json:"note,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+	// Scenario is the case study the question belongs to, shown above it.
+	Scenario *scenarioPayload `json:"scenario,omitempty"`
+}
+
+type scenarioPayload struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	// HTML is the Markdown-rendered, escaped text.
+	HTML string `json:"html"`
 }
 
 type progressPayload struct {
@@ -64,56 +455,257 @@ type progressPayload struct {
 
 type answerRequest struct {
 	Answer string `json:"answer"`
+	// Confidence optionally rates the answer: 1-3 or guessing, unsure, sure.
+	Confidence string `json:"confidence,omitempty"`
 }
 
 type answerResponse struct {
-	Result        quiz.Result     `json:"result"`
-	Finished      bool            `json:"finished"`
+	Result   quiz.Result `json:"result"`
+	Finished bool        `json:"finished"`
+	// TimeUp reports that the section's time ran out before the answer
+	// arrived; the answer was not recorded.
+	TimeUp        bool            `json:"timeUp,omitempty"`
 	CorrectAnswer string          `json:"correctAnswer"`
+	Source        string          `json:"source,omitempty"`
 	Progress      progressPayload `json:"progress"`
+	// Silent reports that the answer was taken without feedback; Result
+	// then only echoes the answer given.
+	Silent bool `json:"silent,omitempty"`
 }
 
 type summaryPayload struct {
-	Score    int          `json:"score"`
-	Answered int          `json:"answered"`
-	Total    int          `json:"total"`
-	Percent  float64      `json:"percent"`
-	Rows     []summaryRow `json:"rows"`
+	Score    int     `json:"score"`
+	Answered int     `json:"answered"`
+	Total    int     `json:"total"`
+	Percent  float64 `json:"percent"`
+	// Mastered counts the questions answered that were eventually answered
+	// correctly, retries included, and MasteredPercent its share of Answered.
+	Mastered        int              `json:"mastered"`
+	MasteredPercent float64          `json:"masteredPercent"`
+	Rows            []summaryRow     `json:"rows"`
+	Sections        []sectionPayload `json:"sections,omitempty"`
+	// Weighted is set when the bank weights questions or wrong answers carry
+	// a Penalty; Points, PossiblePoints and WeightedPercent then grade the
+	// answers by weight, less the penalty.
+	Weighted        bool    `json:"weighted,omitempty"`
+	Points          float64 `json:"points"`
+	PossiblePoints  float64 `json:"possiblePoints"`
+	WeightedPercent float64 `json:"weightedPercent"`
+	Penalty         float64 `json:"penalty,omitempty"`
+	// Calibration is set once any answer has a confidence rating.
+	Calibration []quiz.CalibrationBucket `json:"calibration,omitempty"`
+	// Challenge is the code that replays this run; Leaderboard reports
+	// whether scores can be posted to /api/challenge/score.
+	Challenge    string `json:"challenge"`
+	ChallengeKey string `json:"challengeKey"`
+	Leaderboard  bool   `json:"leaderboard,omitempty"`
+	// SuddenDeath is set when the run ended at its first miss; Streak is
+	// then the correct answers before it, and the leaderboard ranks streaks.
+	SuddenDeath bool `json:"suddenDeath,omitempty"`
+	Streak      int  `json:"streak,omitempty"`
+	// Pacing is the time limits of a paced session, from the first
+	// question to the last, and Late counts the first attempts that ran
+	// out of time.
+	Pacing string `json:"pacing,omitempty"`
+	Late   int    `json:"late,omitempty"`
 }
 
 type summaryRow struct {
+	ID            string `json:"id"`
 	Index         int    `json:"index"`
 	Correct       bool   `json:"correct"`
 	UserAnswer    string `json:"userAnswer"`
 	CorrectAnswer string `json:"correctAnswer"`
+	Source        string `json:"source,omitempty"`
+	// Params holds the values a template question was answered with.
+	Weight     float64            `json:"weight"`
+	Params     map[string]float64 `json:"params,omitempty"`
+	Confidence quiz.Confidence    `json:"confidence,omitempty"`
 }
 
+// flagRequest names the question by ID, or by Index when ID is empty.
+type flagRequest struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+}
+
+type flagResponse struct {
+	Flags       int  `json:"flags"`
+	UnderReview bool `json:"underReview"`
+}
+
+type resolveRequest struct {
+	Key string `json:"key"`
+}
+
+const underReviewNotice = "Under review: this question has been reported and is excluded from exams."
+
 type jumpRequest struct {
 	Term string `json:"term"`
+	// Again asks a question already answered correctly again, to
+	// re-verify it (see quiz.Session.Requeue).
+	Again bool `json:"again,omitempty"`
 }
 
+// jumpResponse describes the question a search matched. Mastered is set
+// when it was already answered correctly and left where it was, because the
+// request did not ask for it again; Error says why any other match could
+// not be brought forward.
 type jumpResponse struct {
-	Found  bool   `json:"found"`
-	Index  int    `json:"index,omitempty"`
-	Domain int    `json:"domain,omitempty"`
-	Prompt string `json:"prompt,omitempty"`
+	Found    bool   `json:"found"`
+	ID       string `json:"id,omitempty"`
+	Index    int    `json:"index,omitempty"`
+	Domain   int    `json:"domain,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+	Mastered bool   `json:"mastered,omitempty"`
+	Error    string `json:"error,omitempty"`
 }
 
 func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
-	t := template.Must(template.New("home").Parse(indexHTML))
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	_ = t.Execute(w, nil)
+	s.servePage(w, r, indexHTML)
 }
 
 func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, s.buildState(r.Context()))
+}
+
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req answerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var confidence quiz.Confidence
+	if req.Confidence != "" {
+		c, err := quiz.ParseConfidence(req.Confidence)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		confidence = c
+	}
+	resp, err := s.answer(r.Context(), req.Answer, confidence)
+	if errors.Is(err, quiz.ErrPaused) {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, r, resp)
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	session := s.current()
+	results := session.Results()
+	w.Header().Set("Content-Type", "application/json")
+	_ = writeSummary(w, s.summarize(session, results), session.Questions, results, prettyJSON(r))
+}
+
+// writeSummary writes sum to w with a row per result. The rows are encoded
+// one at a time, so a long run needs neither a slice of rows nor the whole
+// summary in memory at once.
+func writeSummary(w io.Writer, sum summaryPayload, questions []quiz.Question, results []quiz.Result, pretty bool) error {
+	sum.Rows = []summaryRow{}
+	marker, indent := `"rows":[]`, ""
+	var head []byte
+	var err error
+	if pretty {
+		head, err = json.MarshalIndent(sum, "", "  ")
+		marker, indent = `"rows": []`, "\n    "
+	} else {
+		head, err = json.Marshal(sum)
+	}
+	if err != nil {
+		return err
+	}
+	// split the empty list between its brackets and fill in the rows
+	at := bytes.Index(head, []byte(marker)) + len(marker) - 1
+	if _, err := w.Write(head[:at]); err != nil {
+		return err
+	}
+	for i, res := range results {
+		var row []byte
+		if pretty {
+			row, err = json.MarshalIndent(summaryRowFor(questions[i], i, res), "    ", "  ")
+		} else {
+			row, err = json.Marshal(summaryRowFor(questions[i], i, res))
+		}
+		if err != nil {
+			return err
+		}
+		sep := indent
+		if i > 0 {
+			sep = "," + indent
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	if pretty && len(results) > 0 {
+		if _, err := io.WriteString(w, "\n  "); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write(head[at:]); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.reset()
+	writeJSON(w, r, map[string]string{"status": "reset"})
+}
+
+func (s *Server) handleJump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req jumpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, r, s.jump(req.Term, req.Again))
+}
+
+func (s *Server) current() *quiz.Session {
 	s.mu.Lock()
-	session := s.session
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+	return s.session
+}
 
+func (s *Server) buildState(ctx context.Context) stateResponse {
+	session := s.current()
 	completed, total := session.Progress()
 	attempted := session.AttemptedCount()
-	idx, q, ok := session.Current()
+	if s.feedback.Silent {
+		completed = attempted
+	}
 	resp := stateResponse{
+		Confidence:     s.confidence,
+		ConfirmAnswers: s.confirmAnswers,
+		Notes:          s.stats != nil,
+		Reports:        s.stats != nil,
+		AdvanceSeconds: s.feedback.Advance.Seconds(),
+		Silent:         s.feedback.Silent,
+		Brief:          s.feedback.Brief,
+		Blind:          s.feedback.Blind,
 		Progress: progressPayload{
 			Completed: completed,
 			Total:     total,
@@ -121,163 +713,440 @@ func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
 			Attempted: attempted,
 		},
 	}
+	if sectionIntro(session, &resp) {
+		return resp
+	}
+	if session.BreakDue() {
+		session.TakeBreak()
+	}
+	if session.Paused() && !session.Completed() {
+		resp.Paused = true
+		resp.Break = session.OnBreak()
+		return resp
+	}
+	idx, q, ok := session.Current(ctx)
 	if !ok {
+		resp.Section = nil
 		summary := s.buildSummary()
 		resp.Finished = true
 		resp.Summary = &summary
-		writeJSON(w, resp)
-		return
+		return resp
+	}
+	resp.Question = s.payloadFor(idx, q)
+	if limit, left, ok := session.TimeLimit(); ok {
+		resp.Pace = &pacePayload{LimitSeconds: limit.Seconds(), LeftSeconds: left.Seconds()}
 	}
-	resp.Question = &questionPayload{
-		Index:   idx,
-		Domain:  q.Domain,
-		Prompt:  q.Prompt,
-		Options: q.Options,
+	if s.stats != nil {
+		if s.stats.UnderReview(q) {
+			resp.Question.Notice = underReviewNotice
+		}
+		resp.Question.Note = s.stats.Note(session.Questions[idx])
 	}
-	writeJSON(w, resp)
+	return resp
 }
 
-func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
+func (s *Server) answer(ctx context.Context, answer string, confidence quiz.Confidence) (answerResponse, error) {
+	session := s.current()
+	idx, q, ok := session.Current(ctx)
+	if !ok {
+		return answerResponse{Finished: true}, nil
 	}
-	s.mu.Lock()
-	session := s.session
-	s.mu.Unlock()
-	var req answerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+	attempted := session.AttemptedCount()
+	res, finished, err := session.Answer(ctx, answer)
+	timeUp := errors.Is(err, quiz.ErrSectionTimeUp)
+	if err != nil && !timeUp {
+		return answerResponse{}, err
 	}
-	_, q, ok := session.Current()
-	if !ok {
-		writeJSON(w, answerResponse{Finished: true})
-		return
+	if finished {
+		s.mu.Lock()
+		first := s.session == session && s.finished.IsZero()
+		if first {
+			s.finished = time.Now()
+		}
+		started, ended := s.started, s.finished
+		s.mu.Unlock()
+		if first && s.webhook != "" {
+			go s.notify(session, started, ended)
+		}
+		if first && s.grade != nil {
+			go s.grade(session)
+		}
+	}
+	if confidence != quiz.Unrated && session.AttemptedCount() > attempted && session.Rate(idx, confidence) {
+		res.Confidence = confidence
 	}
-	res, finished, _ := session.Answer(req.Answer)
 	completed, total := session.Progress()
-	resp := answerResponse{
+	correct := q.Answer
+	if timeUp || (s.feedback.HideAnswer && !res.Correct) {
+		correct = ""
+	}
+	source := q.Source
+	if s.feedback.Silent {
+		res = quiz.Result{UserAnswer: res.UserAnswer}
+		correct, source = "", ""
+		completed = session.AttemptedCount()
+	}
+	return answerResponse{
 		Result:        res,
 		Finished:      finished,
-		CorrectAnswer: q.Answer,
+		TimeUp:        timeUp,
+		CorrectAnswer: correct,
+		Source:        source,
+		Silent:        s.feedback.Silent,
 		Progress: progressPayload{
 			Completed: completed,
 			Total:     total,
 			Remaining: total - completed,
 			Attempted: session.AttemptedCount(),
 		},
-	}
-	writeJSON(w, resp)
+	}, nil
 }
 
-func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
-	summary := s.buildSummary()
-	writeJSON(w, summary)
+func (s *Server) reset() {
+	s.setSession(s.newSession())
 }
 
-func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+// setSession makes session the active one, and its questions the bank, and
+// restarts its clock.
+func (s *Server) setSession(session *quiz.Session) {
 	s.mu.Lock()
-	s.session = quiz.NewSession(s.questions)
+	s.questions = session.Questions
 	s.mu.Unlock()
-	writeJSON(w, map[string]string{"status": "reset"})
+	s.startSession(session)
 }
 
-func (s *Server) handleJump(w http.ResponseWriter, r *http.Request) {
+// startSession makes session the active one and restarts its clock, leaving
+// the bank as it is.
+func (s *Server) startSession(session *quiz.Session) {
+	s.mu.Lock()
+	s.session = session
+	s.started, s.finished, s.posted = time.Now(), time.Time{}, false
+	s.mu.Unlock()
+	if s.autosave != nil {
+		_ = s.autosave.Remove()
+	}
+}
+
+func (s *Server) jump(term string, again bool) jumpResponse {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return jumpResponse{Found: false}
+	}
+	session := s.current()
+	if session.Completed() {
+		return jumpResponse{Found: false}
+	}
+	idx := findQuestionIndex(session.Questions, term)
+	if idx < 0 {
+		return jumpResponse{Found: false}
+	}
+	q := session.Questions[idx]
+	resp := jumpResponse{
+		Found:  true,
+		ID:     q.ID,
+		Index:  idx + 1,
+		Domain: q.Domain,
+		Prompt: q.Prompt,
+	}
+	switch err := session.Requeue(idx, again); {
+	case errors.Is(err, quiz.ErrMastered):
+		resp.Mastered = true
+	case err != nil:
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+func (s *Server) handleFlag(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	var req jumpRequest
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req flagRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	term := strings.TrimSpace(req.Term)
-	if term == "" {
-		writeJSON(w, jumpResponse{Found: false})
+	questions := s.bank()
+	if req.ID != "" {
+		req.Index = questionByID(questions, req.ID)
+	}
+	if req.Index < 0 || req.Index >= len(questions) {
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	s.mu.Lock()
-	session := s.session
-	s.mu.Unlock()
-	if session.Completed() {
-		writeJSON(w, jumpResponse{Found: false})
+	rec := s.stats.Flag(questions[req.Index], s.policy)
+	_ = s.stats.Save(r.Context())
+	writeJSON(w, r, flagResponse{Flags: rec.Flags, UnderReview: rec.UnderReview})
+}
+
+func (s *Server) handleReviews(w http.ResponseWriter, r *http.Request) {
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	idx := s.findQuestionIndex(term)
-	if idx < 0 {
-		writeJSON(w, jumpResponse{Found: false})
+	items := s.stats.Reviews()
+	if items == nil {
+		items = []stats.ReviewItem{}
+	}
+	writeJSON(w, r, items)
+}
+
+func (s *Server) handleResolveReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	session.BringToFront(idx)
-	q := s.questions[idx]
-	writeJSON(w, jumpResponse{
-		Found:  true,
-		Index:  idx + 1,
-		Domain: q.Domain,
-		Prompt: q.Prompt,
-	})
+	if s.stats == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req resolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	resolved := s.stats.Resolve(req.Key)
+	if resolved {
+		_ = s.stats.Save(r.Context())
+	}
+	writeJSON(w, r, map[string]bool{"resolved": resolved})
+}
+
+func (s *Server) newSession() *quiz.Session {
+	if s.seeded {
+		return s.sessionWithSeed(s.seed)
+	}
+	return s.sessionWithSeed(time.Now().UnixNano())
+}
+
+func (s *Server) sessionWithSeed(seed int64) *quiz.Session {
+	questions := s.takeBank()
+	session := quiz.NewSeededSession(questions, seed)
+	for _, l := range s.listeners {
+		session.AddListener(l)
+	}
+	session.UsePenalty(s.penalty)
+	if s.sections != nil {
+		session.UseSections(s.sections)
+	}
+	if s.suddenDeath {
+		session.UseSuddenDeath()
+	}
+	if s.pacing != nil {
+		session.UsePacing(*s.pacing)
+	}
+	if s.feedback.Silent {
+		session.UseSinglePass()
+	}
+	if s.hardestFirst && s.stats != nil {
+		session.UseOrder(stats.HardestFirst(questions, s.stats))
+	}
+	session.UseOrdering(s.ordering)
+	session.UseBreaks(s.breaks)
+	if s.autosave != nil {
+		session.AddListener(s.autosave.Listener(session))
+	}
+	return session
+}
+
+func (s *Server) buildSummary() summaryPayload {
+	session := s.current()
+	results := session.Results()
+	summary := s.summarize(session, results)
+	summary.Rows = make([]summaryRow, 0, len(results))
+	for i, res := range results {
+		summary.Rows = append(summary.Rows, summaryRowFor(session.Questions[i], i, res))
+	}
+	return summary
+}
+
+// summaryRowFor is the summary row for results[i], the answer to q.
+func summaryRowFor(q quiz.Question, i int, res quiz.Result) summaryRow {
+	return summaryRow{
+		ID:            q.ID,
+		Index:         i + 1,
+		Correct:       res.Correct,
+		UserAnswer:    res.UserAnswer,
+		CorrectAnswer: q.Answer,
+		Source:        q.Source,
+		Weight:        q.Points(),
+		Params:        res.Params,
+		Confidence:    res.Confidence,
+	}
+}
+
+// summarize is session's summary without its rows.
+func (s *Server) summarize(session *quiz.Session, results []quiz.Result) summaryPayload {
+	score, answered := session.Score()
+	ch := sessionChallenge(session)
+	total := len(results)
+	mastered, _ := session.Progress()
+	percent, masteredPercent := 0.0, 0.0
+	if answered > 0 {
+		percent = float64(score) * 100 / float64(answered)
+		masteredPercent = float64(mastered) * 100 / float64(answered)
+	}
+	points, possible := session.WeightedScore()
+	weightedPercent := 0.0
+	if possible > 0 {
+		weightedPercent = points * 100 / possible
+	}
+	penalty := session.Penalty()
+	streak := 0
+	if s.suddenDeath {
+		_, streak = session.Streak()
+	}
+	var pacing string
+	late := 0
+	if p, ok := session.Pacing(); ok {
+		pacing = p.String()
+		for _, res := range results {
+			if res.TimedOut {
+				late++
+			}
+		}
+	}
+	weighted := penalty > 0
+	for _, q := range session.Questions {
+		if q.Points() != 1 {
+			weighted = true
+		}
+	}
+	return summaryPayload{
+		Score:           score,
+		Answered:        answered,
+		Total:           total,
+		Percent:         percent,
+		Mastered:        mastered,
+		MasteredPercent: masteredPercent,
+		Weighted:        weighted,
+		Points:          points,
+		PossiblePoints:  possible,
+		WeightedPercent: weightedPercent,
+		Penalty:         penalty,
+		Sections:        sectionPayloads(session.Sections()),
+		Calibration:     quiz.Calibrate(results),
+		Challenge:       ch.Code(),
+		ChallengeKey:    ch.Key(),
+		Leaderboard:     s.board != nil,
+		SuddenDeath:     s.suddenDeath,
+		Streak:          streak,
+		Pacing:          pacing,
+		Late:            late,
+	}
+}
+
+func findQuestionIndex(questions []quiz.Question, term string) int {
+	if i := questionByID(questions, term); i >= 0 {
+		return i
+	}
+	if n, err := strconv.Atoi(term); err == nil {
+		n-- // convert to 0-based
+		if n >= 0 && n < len(questions) {
+			return n
+		}
+	}
+	needle := strings.ToLower(term)
+	for i, q := range questions {
+		if strings.Contains(strings.ToLower(q.Prompt), needle) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) payloadFor(idx int, q quiz.Question) *questionPayload {
+	optionsHTML := make(map[string]string, len(q.Options))
+	for k, v := range q.Options {
+		optionsHTML[k] = markdown.InlineHTML(v)
+	}
+	p := &questionPayload{
+		ID:          q.ID,
+		Index:       idx,
+		Domain:      q.Domain,
+		Prompt:      q.Prompt,
+		Options:     q.Options,
+		PromptHTML:  markdown.HTML(q.Prompt),
+		OptionsHTML: optionsHTML,
+		Image:       s.imageURL(q),
+		ImageAlt:    q.ImageAlt,
+		Dir:         textDir(q),
+		Tags:        q.Tags,
+	}
+	if q.Stem != nil {
+		p.Scenario = &scenarioPayload{ID: q.Stem.ID, Title: q.Stem.Title, HTML: markdown.HTML(q.Stem.Text)}
+	}
+	return p
 }
 
-func (s *Server) buildSummary() summaryPayload {
-	s.mu.Lock()
-	session := s.session
-	s.mu.Unlock()
-	score, answered := session.Score()
-	results := session.Results()
-	rows := make([]summaryRow, 0, len(results))
-	for i, res := range results {
-		rows = append(rows, summaryRow{
-			Index:         i + 1,
-			Correct:       res.Correct,
-			UserAnswer:    res.UserAnswer,
-			CorrectAnswer: session.Questions[i].Answer,
-		})
-	}
-	total := len(results)
-	percent := 0.0
-	if answered > 0 {
-		percent = float64(score) * 100 / float64(answered)
+// textDir returns q's text direction, falling back to "auto" for anything
+// but ltr or rtl since the UI puts it in an attribute.
+func textDir(q quiz.Question) string {
+	if q.Dir == "ltr" || q.Dir == "rtl" {
+		return q.Dir
 	}
-	return summaryPayload{
-		Score:    score,
-		Answered: answered,
-		Total:    total,
-		Percent:  percent,
-		Rows:     rows,
+	return "auto"
+}
+
+// imageURL returns the URL the browser loads q's image from. Relative paths
+// are served from the media directory when one is configured, and otherwise
+// left relative to the page (as in the WASM build).
+func (s *Server) imageURL(q quiz.Question) string {
+	if q.Image == "" || q.ImageIsURL() || s.mediaDir == "" {
+		return q.Image
 	}
+	return "/media/" + strings.TrimPrefix(filepath.ToSlash(q.Image), "/")
 }
 
-func (s *Server) findQuestionIndex(term string) int {
-	if n, err := strconv.Atoi(term); err == nil {
-		n-- // convert to 0-based
-		if n >= 0 && n < len(s.questions) {
-			return n
+func (s *Server) handleMedia(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/media/")
+	for _, q := range s.bank() {
+		if q.Image != "" && !q.ImageIsURL() && strings.TrimPrefix(filepath.ToSlash(q.Image), "/") == name {
+			path := q.Image
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(s.mediaDir, path)
+			}
+			http.ServeFile(w, r, path)
+			return
 		}
 	}
-	needle := strings.ToLower(term)
-	for i, q := range s.questions {
-		if strings.Contains(strings.ToLower(q.Prompt), needle) {
+	http.NotFound(w, r)
+}
+
+// questionByID returns the index of the question in questions with the
+// given ID, or -1.
+func questionByID(questions []quiz.Question, id string) int {
+	for i, q := range questions {
+		if q.ID == id {
 			return i
 		}
 	}
 	return -1
 }
 
-func writeJSON(w http.ResponseWriter, v any) {
+// writeJSON encodes v to w, compactly unless the request asks for
+// ?pretty=1.
+func writeJSON(w http.ResponseWriter, r *http.Request, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
+	if prettyJSON(r) {
+		enc.SetIndent("", "  ")
+	}
 	_ = enc.Encode(v)
 }
 
+func prettyJSON(r *http.Request) bool {
+	pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty"))
+	return pretty
+}
+
 const indexHTML = `<!doctype html>
-<html lang="en">
+<html lang="en" dir="{{.Dir}}">
 <head>
   <meta charset="utf-8">
   <meta name="viewport" content="width=device-width, initial-scale=1">
@@ -389,6 +1258,32 @@ const indexHTML = `<!doctype html>
       border-color: var(--accent);
       box-shadow: 0 0 0 3px rgba(34,211,238,0.18);
     }
+    .note {
+      display: flex;
+      flex-direction: column;
+      gap: 8px;
+      margin-top: 14px;
+    }
+    .note textarea {
+      background: rgba(255,255,255,0.04);
+      border: 1px solid rgba(255,255,255,0.06);
+      color: var(--text);
+      border-radius: 12px;
+      padding: 10px 12px;
+      outline: none;
+      font: inherit;
+      resize: vertical;
+    }
+    .note textarea:focus {
+      border-color: var(--accent);
+      box-shadow: 0 0 0 3px rgba(34,211,238,0.18);
+    }
+    .note-actions {
+      display: flex;
+      gap: 12px;
+      align-items: center;
+      flex-wrap: wrap;
+    }
     .card {
       background: var(--panel-strong);
       border: 1px solid rgba(255,255,255,0.06);
@@ -402,6 +1297,42 @@ const indexHTML = `<!doctype html>
       margin-bottom: 14px;
       line-height: 1.4;
     }
+    .question p { margin: 8px 0; }
+    .scenario {
+      margin-bottom: 14px;
+      padding: 12px 14px;
+      border-left: 3px solid var(--accent);
+      border-radius: 8px;
+      background: rgba(255,255,255,0.04);
+      font-size: 15px;
+      line-height: 1.5;
+    }
+    .scenario p { margin: 6px 0; }
+    .question ul, .question ol { margin: 8px 0; padding-left: 24px; font-weight: 500; }
+    .question pre, .option pre {
+      background: rgba(0, 0, 0, 0.35);
+      border-radius: 8px;
+      padding: 10px 12px;
+      overflow-x: auto;
+      font-size: 14px;
+      font-weight: 400;
+    }
+    code {
+      font-family: ui-monospace, SFMono-Regular, Menlo, monospace;
+      font-size: 0.92em;
+    }
+    :not(pre) > code {
+      background: rgba(0, 0, 0, 0.3);
+      border-radius: 4px;
+      padding: 1px 5px;
+    }
+    .figure {
+      display: block;
+      max-width: 100%;
+      max-height: 420px;
+      margin: 0 auto 14px;
+      border-radius: 10px;
+    }
     .options {
       display: grid;
       gap: 10px;
@@ -437,6 +1368,10 @@ const indexHTML = `<!doctype html>
       border-color: rgba(244,63,94,0.8);
       background: rgba(244,63,94,0.12);
     }
+    .option.struck {
+      opacity: 0.45;
+    }
+    .option.struck span[dir] { text-decoration: line-through; }
     .option input { display: none; }
     .letter {
       width: 32px;
@@ -489,6 +1424,13 @@ const indexHTML = `<!doctype html>
     .pill.good { background: rgba(52,211,153,0.15); color: #34d399; }
     .pill.bad { background: rgba(244,63,94,0.15); color: #f871a6; }
     .muted { color: var(--muted); }
+    .pref {
+      display: flex;
+      align-items: center;
+      gap: 6px;
+      font-size: 14px;
+      cursor: pointer;
+    }
     .good { color: var(--good); }
     .bad { color: var(--bad); }
     .summary {
@@ -498,6 +1440,7 @@ const indexHTML = `<!doctype html>
     }
     .summary-row {
       display: flex;
+      flex-wrap: wrap;
       justify-content: space-between;
       padding: 10px 12px;
       border-radius: 10px;
@@ -505,6 +1448,22 @@ const indexHTML = `<!doctype html>
       border: 1px solid rgba(255,255,255,0.06);
       font-size: 14px;
     }
+    .summary-source {
+      flex-basis: 100%;
+      margin-top: 4px;
+      color: var(--muted);
+      font-size: 12px;
+    }
+    .summary-row.clickable { cursor: pointer; }
+    .summary-row.clickable:hover, .summary-row.clickable:focus { border-color: var(--accent); }
+    .review {
+      margin-top: 12px;
+      padding: 12px;
+      border-radius: 10px;
+      border: 1px solid rgba(255,255,255,0.12);
+      font-size: 14px;
+    }
+    .review p { margin: 6px 0; }
     .modal {
       position: fixed;
       inset: 0;
@@ -547,6 +1506,8 @@ const indexHTML = `<!doctype html>
       <div class="title">CSSLP Review Quiz</div>
       <div class="header-actions">
         <div class="badge" id="statusBadge">CLI heritage · now on the web</div>
+        <label class="pref muted" title="Submit each answer in two steps, Submit then Confirm (remembered by this browser)"><input type="checkbox" id="confirmPref"> Confirm answers</label>
+        <button class="cta ghost small" id="pauseBtn" aria-label="Pause quiz">Pause</button>
         <button class="cta ghost small" id="resetBtn" aria-label="Reset quiz">Try Again</button>
       </div>
     </header>
@@ -556,23 +1517,74 @@ const indexHTML = `<!doctype html>
       <div id="progressCounts">0 / 0</div>
     </div>
     <div class="search">
-      <input id="searchTerm" type="search" placeholder="Search question text or number..." aria-label="Search question" />
+      <input id="searchTerm" type="search" list="recentSearches" autocomplete="off" placeholder="Search question text or number..." aria-label="Search question" />
+      <datalist id="recentSearches"></datalist>
       <button class="cta ghost" id="searchBtn">Search & Jump</button>
       <div id="searchFeedback" class="pill muted">Search to jump to a question.</div>
+      <button class="cta ghost small" id="askAgainBtn" style="display:none;">Ask it again</button>
     </div>
     <div class="card" id="card">
+      <div id="sectionStatus" class="pill muted" style="display:none; margin-bottom: 12px;"></div>
+      <div id="paceStatus" class="pill muted" style="display:none; margin-bottom: 12px;"></div>
+      <div id="notice" class="pill bad" style="display:none; margin-bottom: 12px;"></div>
+      <div class="scenario" id="scenario" style="display:none;"></div>
       <div class="question" id="prompt">Loading question...</div>
+      <img id="figure" class="figure" alt="" style="display:none;">
       <div class="options" id="options"></div>
+      <div class="note" id="noteBox" style="display:none;">
+        <textarea id="noteText" rows="2" placeholder="Your note on this question, shown whenever it comes back" aria-label="Your note on this question"></textarea>
+        <div class="note-actions">
+          <button class="cta ghost small" id="saveNoteBtn">Save note</button>
+          <a class="muted" href="/api/notes" download="notes.md">Export all notes (Markdown)</a>
+          <span id="noteStatus" class="muted"></span>
+        </div>
+      </div>
+      <div class="note" id="reportBox" style="display:none;">
+        <div class="note-actions">
+          <button class="cta ghost small" id="reportOpenBtn">Report an issue with this question</button>
+          <span id="reportStatus" class="muted"></span>
+        </div>
+        <div class="note" id="reportForm" style="display:none; margin-top: 0;">
+          <textarea id="reportText" rows="2" maxlength="2000" placeholder="What is wrong? e.g. the answer should be C, or a typo in option B" aria-label="What is wrong with this question"></textarea>
+          <div class="note-actions">
+            <button class="cta ghost small" id="reportSendBtn">Send report</button>
+            <button class="cta ghost small" id="reportCancelBtn">Cancel</button>
+          </div>
+        </div>
+      </div>
       <div class="footer">
         <div id="feedback" class="pill muted">Pick an answer to begin.</div>
         <button class="cta" id="actionBtn">Submit</button>
+        <div class="header-actions" id="confidenceBtns" style="display:none;">
+          <button class="cta ghost small" data-confidence="guessing">Guessing</button>
+          <button class="cta ghost small" data-confidence="unsure">Unsure</button>
+          <button class="cta small" data-confidence="sure">Sure</button>
+        </div>
       </div>
     </div>
     <div class="card" id="summary" style="display:none;">
       <div class="question">Quiz Complete</div>
       <div id="scoreLine" class="muted"></div>
+      <div class="summary" id="sectionRows"></div>
+      <div class="summary" id="calibrationRows"></div>
+      <div class="summary" id="readinessRows"></div>
       <div class="summary" id="summaryRows"></div>
+      <div class="review" id="reviewBox" style="display:none;"></div>
+      <div id="challengeBox" class="muted" style="margin: 12px 0;">
+        <div id="challengeLine"></div>
+        <div class="search" id="scoreForm" style="display:none;">
+          <input id="playerName" type="text" maxlength="40" placeholder="Your name" aria-label="Your name for the leaderboard" />
+          <button class="cta ghost" id="postScoreBtn">Post score</button>
+        </div>
+        <div class="summary" id="boardRows"></div>
+      </div>
+      <div class="muted" style="margin: 12px 0;">
+        Completion report: <a id="reportPdf" href="/api/report?format=pdf" download>PDF</a> ·
+        <a id="reportHtml" href="/api/report?format=html" target="_blank" rel="noopener">printable page</a> ·
+        <a id="reportMd" href="/api/report?format=md" download>study sheet of the misses (Markdown)</a>
+      </div>
       <button class="cta" id="summaryResetBtn">Try Again</button>
+      <button class="cta ghost" id="requeueBtn" style="display:none;"></button>
     </div>
   </div>
   <div class="modal hidden" id="partialModal" role="dialog" aria-modal="true" aria-labelledby="partialTitle">
@@ -590,33 +1602,227 @@ const indexHTML = `<!doctype html>
     let selected = "";
     let lock = false;
     let optionNodes = {};
+    let sectionTimer = null;
+    let paceTimer = null;
+    let confidenceMode = false;
+    // confirmAnswers asks for a second click before an answer is sent;
+    // confirming holds the answer (and rating) awaiting it.
+    let confirmAnswers = false;
+    let confirming = null;
+    const CONFIRM_PREF = "quiz.confirmAnswers";
+    // SEARCHES keeps this browser's recent search terms, newest first, for
+    // the search box's suggestions.
+    const SEARCHES = "quiz.searches";
+    const MAX_SEARCHES = 20;
+    let notesMode = false;
+    let reportsMode = false;
+    let currentQuestion = null;
+    let paused = false;
+    // blind keeps the progress and section results hidden until the summary.
+    let blind = false;
     const FEEDBACK_PAUSE = 1400;
+    let feedbackPause = FEEDBACK_PAUSE;
+    // brief cuts the feedback to a flash of right or wrong (-feedback cram).
+    let brief = false;
     const searchInput = document.getElementById("searchTerm");
     const searchFeedback = document.getElementById("searchFeedback");
     const partialModal = document.getElementById("partialModal");
     const partialRows = document.getElementById("partialRows");
     const partialScoreLine = document.getElementById("partialScoreLine");
 
-    function optionTemplate(letter, text) {
+    function optionTemplate(letter, text, dir) {
       return '<label class="option">' +
         '<span class="letter">' + letter + '</span>' +
         '<input type="radio" name="option" value="' + letter + '">' +
-        '<span>' + text + '</span>' +
+        '<span dir="' + dir + '">' + text + '</span>' +
         '</label>';
     }
 
     async function loadState() {
       const res = await fetch("/api/state");
       const data = await res.json();
+      confidenceMode = !!data.confidence;
+      const pref = storedConfirmPref();
+      confirmAnswers = pref === null ? !!data.confirmAnswers : pref;
+      document.getElementById("confirmPref").checked = confirmAnswers;
+      notesMode = !!data.notes;
+      reportsMode = !!data.reports;
+      feedbackPause = data.silent ? 0 : (data.advanceSeconds ? data.advanceSeconds * 1000 : FEEDBACK_PAUSE);
+      brief = !!data.brief;
       updateProgress(data.progress);
+      showPace(null);
+      blind = !!data.blind && !data.finished;
+      document.querySelector(".progress").style.display = blind ? "none" : "";
+      document.querySelector(".progress-text").style.display = blind ? "none" : "";
+      paused = !!data.paused;
+      const pauseBtn = document.getElementById("pauseBtn");
+      pauseBtn.innerText = paused ? "Resume" : "Pause";
+      pauseBtn.style.display = data.finished || data.sectionIntro ? "none" : "";
       if (data.finished) {
+        showSection(null);
         showSummary(data.summary);
         return;
       }
+      if (paused) {
+        renderPaused(data.section, !!data.break);
+        return;
+      }
+      if (data.sectionIntro) {
+        renderSectionIntro(data.section, data.previousSection);
+        return;
+      }
       renderQuestion(data.question);
+      showSection(data.section);
+      showPace(data.pace);
+    }
+
+    function formatClock(seconds) {
+      const s = Math.max(0, Math.round(seconds));
+      return Math.floor(s / 60) + ":" + String(s % 60).padStart(2, "0");
+    }
+
+    function sectionResult(sec) {
+      return "Section " + (sec.index + 1) + " (" + sec.title + "): " + sec.answered + " of " + sec.total +
+        " answered, " + sec.correct + " correct in " + formatClock(sec.elapsedSeconds) + (sec.timedOut ? " (time ran out)" : "");
+    }
+
+    // showSection updates the section pill and its countdown; when the clock
+    // reaches zero the state is reloaded so the server can lock the section.
+    function showSection(sec) {
+      const pill = document.getElementById("sectionStatus");
+      clearInterval(sectionTimer);
+      sectionTimer = null;
+      if (!sec) {
+        pill.style.display = "none";
+        return;
+      }
+      const label = "Section " + (sec.index + 1) + " of " + sec.count + " · " + sec.title;
+      pill.style.display = "inline-block";
+      pill.innerText = label;
+      if (!sec.budgetSeconds) return;
+      const deadline = Date.now() + sec.remainingSeconds * 1000;
+      const tick = () => {
+        const left = (deadline - Date.now()) / 1000;
+        pill.innerText = label + " · " + formatClock(left) + " left";
+        pill.className = left < 60 ? "pill bad" : "pill muted";
+        if (left <= 0) {
+          clearInterval(sectionTimer);
+          sectionTimer = null;
+          if (!lock) loadState();
+        }
+      };
+      tick();
+      sectionTimer = setInterval(tick, 1000);
+    }
+
+    // showPace counts the question's time limit down in a paced session;
+    // when it runs out the answer picked so far, if any, is sent, and the
+    // server counts it as a miss.
+    function showPace(pace) {
+      const pill = document.getElementById("paceStatus");
+      clearInterval(paceTimer);
+      paceTimer = null;
+      if (!pace) {
+        pill.style.display = "none";
+        return;
+      }
+      pill.style.display = "inline-block";
+      const deadline = Date.now() + pace.leftSeconds * 1000;
+      const tick = () => {
+        const left = (deadline - Date.now()) / 1000;
+        pill.innerText = "⏱ " + formatClock(Math.ceil(Math.max(left, 0))) + " left of " + formatClock(pace.limitSeconds) + " for this question";
+        pill.className = left <= pace.limitSeconds / 4 ? "pill bad" : "pill muted";
+        if (left <= 0) {
+          clearInterval(paceTimer);
+          paceTimer = null;
+          if (!lock) submitAnswer("", true);
+        }
+      };
+      tick();
+      paceTimer = setInterval(tick, 250);
+    }
+
+    function renderSectionIntro(sec, prev) {
+      showSection(null);
+      lock = false;
+      optionNodes = {};
+      document.getElementById("notice").style.display = "none";
+      document.getElementById("figure").style.display = "none";
+      document.getElementById("scenario").style.display = "none";
+      document.getElementById("prompt").dir = "auto";
+      document.getElementById("prompt").innerText = "Section " + (sec.index + 1) + " of " + sec.count + " · " + sec.title;
+      const opts = document.getElementById("options");
+      opts.innerHTML = "";
+      if (prev) {
+        const done = document.createElement("div");
+        done.className = prev.timedOut ? "pill bad" : "pill good";
+        done.innerText = sectionResult(prev) + ". That section is now locked.";
+        if (blind) {
+          done.className = "pill muted";
+          done.innerText = "Section " + (prev.index + 1) + " (" + prev.title + ") is over. That section is now locked.";
+        }
+        opts.appendChild(done);
+      }
+      const info = document.createElement("div");
+      info.className = "muted";
+      info.innerText = sec.total + (sec.total === 1 ? " question · " : " questions · ") + (sec.budgetSeconds ? formatClock(sec.budgetSeconds) + " time limit" : "untimed");
+      opts.appendChild(info);
+      const pill = document.getElementById("feedback");
+      pill.className = "pill muted";
+      pill.innerText = "The clock starts when you begin.";
+      showConfidence(false);
+      const btn = document.getElementById("actionBtn");
+      btn.innerText = "Start section";
+      btn.onclick = async () => {
+        await fetch("/api/section/start", { method: "POST" });
+        loadState();
+      };
+    }
+
+    // renderPaused hides the question while the session is paused, leaving
+    // the section clock showing the time left, stopped. brk is set for a
+    // break the session suggested.
+    function renderPaused(sec, brk = false) {
+      showSection(sec);
+      clearInterval(sectionTimer);
+      sectionTimer = null;
+      lock = false;
+      optionNodes = {};
+      document.getElementById("notice").style.display = "none";
+      document.getElementById("figure").style.display = "none";
+      document.getElementById("scenario").style.display = "none";
+      document.getElementById("noteBox").style.display = "none";
+      document.getElementById("reportBox").style.display = "none";
+      document.getElementById("prompt").dir = "auto";
+      document.getElementById("prompt").innerText = brk ? "Time for a break" : "Paused";
+      document.getElementById("options").innerHTML = "";
+      if (brk) {
+        const tip = document.createElement("div");
+        tip.className = "muted";
+        tip.innerText = "Stand up, stretch and look away from the screen for a few minutes.";
+        document.getElementById("options").appendChild(tip);
+      }
+      const pill = document.getElementById("feedback");
+      pill.className = "pill muted";
+      pill.innerText = brk ? "The clock is stopped. Resume when you're back." : "The clock is stopped. Resume when you're ready.";
+      showConfidence(false);
+      const btn = document.getElementById("actionBtn");
+      btn.innerText = "Resume";
+      btn.onclick = () => setPaused(false);
+    }
+
+    async function setPaused(on) {
+      await fetch("/api/pause", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ paused: on })
+      });
+      if (!lock) loadState();
     }
 
-    function renderRows(rows, target, emptyText = "") {
+    // renderRows lists summary rows in target; with onSelect, clicking a row
+    // or pressing Enter on it calls onSelect with its index.
+    function renderRows(rows, target, emptyText = "", onSelect = null) {
       target.innerHTML = "";
       if (!rows || rows.length === 0) {
         if (emptyText) {
@@ -630,13 +1836,117 @@ const indexHTML = `<!doctype html>
       rows.forEach(row => {
         const div = document.createElement("div");
         const emoji = row.correct ? "✅" : "❌";
+        const rated = row.confidence ? ' · ' + CONFIDENCE_LABELS[row.confidence] : '';
         const tone = row.correct ? "good" : "bad";
         div.className = "summary-row";
-        div.innerHTML = '<span>' + emoji + ' Q' + row.index + '</span><span class="' + (tone === "good" ? "good" : "bad") + '">You: ' + (row.userAnswer || "–") + ' · Correct: ' + row.correctAnswer + '</span>';
+        div.innerHTML = '<span>' + emoji + ' Q' + row.index + '</span><span class="' + (tone === "good" ? "good" : "bad") + '">You: ' + (row.userAnswer || "–") + ' · Correct: ' + row.correctAnswer + rated + '</span>';
+        if (row.source && !row.correct) {
+          // the source is the bank's text, so it is set as text, and linked
+          // only when it is a web address
+          const link = /^https?:\/\//.test(row.source);
+          const source = document.createElement(link ? "a" : "span");
+          source.className = "summary-source";
+          source.textContent = "Source: " + row.source;
+          if (link) {
+            source.href = row.source;
+            source.target = "_blank";
+            source.rel = "noopener";
+          }
+          div.appendChild(source);
+        }
+        if (onSelect) {
+          div.classList.add("clickable");
+          div.tabIndex = 0;
+          div.setAttribute("role", "button");
+          div.title = "Show Q" + row.index + " in full";
+          div.addEventListener("click", e => { if (e.target.tagName !== "A") onSelect(row.index); });
+          div.addEventListener("keydown", e => { if (e.key === "Enter") onSelect(row.index); });
+        }
         target.appendChild(div);
       });
     }
 
+    // requeued holds the summary rows picked for a quick session.
+    const requeued = new Set();
+
+    // openReview shows summary row index's question in full below the rows,
+    // with a button to requeue it.
+    async function openReview(index) {
+      const box = document.getElementById("reviewBox");
+      const res = await fetch("/api/summary/question?index=" + index);
+      if (!res.ok) {
+        box.style.display = "none";
+        return;
+      }
+      const q = await res.json();
+      box.innerHTML = "";
+      box.dir = q.dir || "auto";
+      const prompt = document.createElement("p");
+      prompt.innerHTML = "<strong><bdi>Q" + index + " · Domain " + q.domain + " ·</bdi></strong> " + q.promptHtml;
+      box.appendChild(prompt);
+      Object.keys(q.optionsHtml).sort().forEach(letter => {
+        const option = document.createElement("p");
+        const mark = letter === q.correctAnswer ? " ✅" : (letter === q.userAnswer ? " ❌" : "");
+        option.className = letter === q.correctAnswer ? "good" : (letter === q.userAnswer ? "bad" : "");
+        option.innerHTML = letter + ") " + q.optionsHtml[letter] + mark;
+        box.appendChild(option);
+      });
+      const answers = document.createElement("p");
+      answers.className = q.correct ? "good" : "bad";
+      answers.textContent = "Your answer: " + (q.userAnswer || "–") + " · Correct answer: " + q.correctAnswer;
+      box.appendChild(answers);
+      if (q.explanationHtml) {
+        const why = document.createElement("div");
+        why.innerHTML = q.explanationHtml;
+        box.appendChild(why);
+      }
+      if (q.source) {
+        const source = document.createElement("p");
+        source.className = "muted";
+        source.textContent = "Source: " + q.source;
+        box.appendChild(source);
+      }
+      const toggle = document.createElement("button");
+      toggle.className = "cta ghost small";
+      const label = () => { toggle.innerText = requeued.has(index) ? "Requeued ✓ (click to drop)" : "Requeue for a quick session"; };
+      label();
+      toggle.addEventListener("click", () => {
+        if (requeued.has(index)) requeued.delete(index); else requeued.add(index);
+        label();
+        updateRequeue();
+      });
+      box.appendChild(toggle);
+      box.style.display = "block";
+      box.scrollIntoView({ block: "nearest" });
+    }
+
+    // updateRequeue offers the quick session once a row is requeued.
+    function updateRequeue() {
+      const btn = document.getElementById("requeueBtn");
+      btn.style.display = requeued.size ? "" : "none";
+      btn.innerText = "Practise " + requeued.size + " requeued question" + (requeued.size === 1 ? "" : "s");
+    }
+
+    // startRequeued replaces the finished session with a quick one on the
+    // requeued questions.
+    async function startRequeued() {
+      const res = await fetch("/api/requeue", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ indexes: [...requeued] })
+      });
+      if (!res.ok) return;
+      requeued.clear();
+      updateRequeue();
+      selected = "";
+      lock = false;
+      document.getElementById("reviewBox").style.display = "none";
+      document.getElementById("summary").style.display = "none";
+      document.getElementById("card").style.display = "block";
+      setSearchStatus("Quick session on the requeued questions.", "muted");
+      loadState();
+    }
+
     function setSearchStatus(text, tone = "muted") {
       searchFeedback.innerText = text;
       const toneClass = tone === "good" ? "pill good" : tone === "bad" ? "pill bad" : "pill muted";
@@ -644,39 +1954,201 @@ const indexHTML = `<!doctype html>
     }
 
     function renderQuestion(q) {
+      currentQuestion = q;
+      document.getElementById("noteBox").style.display = notesMode ? "flex" : "none";
+      document.getElementById("noteText").value = q.note || "";
+      document.getElementById("noteStatus").innerText = "";
+      document.getElementById("reportBox").style.display = reportsMode ? "flex" : "none";
+      showReportForm(false);
+      document.getElementById("reportStatus").innerText = "";
       selected = "";
+      confirming = null;
       lock = false;
       optionNodes = {};
       document.getElementById("feedback").className = "pill muted";
-      document.getElementById("feedback").innerText = "Choose an option.";
+      document.getElementById("feedback").innerText = "Choose an option. Right-click or long-press to strike one out.";
       const qNumber = (q.index ?? 0) + 1;
-      document.getElementById("prompt").innerText = "Q" + qNumber + " · Domain " + q.domain + " · " + q.prompt;
+      const notice = document.getElementById("notice");
+      notice.innerText = q.notice || "";
+      notice.style.display = q.notice ? "block" : "none";
+      // The label is isolated in a <bdi> so dir="auto" takes the direction
+      // from the prompt itself.
+      const scenario = document.getElementById("scenario");
+      if (q.scenario) {
+        scenario.dir = q.dir || "auto";
+        scenario.innerHTML = q.scenario.html;
+        const title = document.createElement("strong");
+        title.innerText = "Scenario" + (q.scenario.title ? ": " + q.scenario.title : "");
+        scenario.prepend(title);
+        scenario.style.display = "block";
+      } else {
+        scenario.innerHTML = "";
+        scenario.style.display = "none";
+      }
+      const prompt = document.getElementById("prompt");
+      prompt.dir = q.dir || "auto";
+      prompt.innerHTML = "<bdi>Q" + qNumber + " · Domain " + q.domain + " ·</bdi> " + q.promptHtml;
+      const figure = document.getElementById("figure");
+      if (q.image) {
+        figure.src = q.image;
+        figure.alt = q.imageAlt || "";
+        figure.style.display = "block";
+      } else {
+        figure.removeAttribute("src");
+        figure.alt = "";
+        figure.style.display = "none";
+      }
       const opts = document.getElementById("options");
       opts.innerHTML = "";
       const letters = Object.keys(q.options).sort();
       letters.forEach(letter => {
         const node = document.createElement("div");
-        node.innerHTML = optionTemplate(letter, q.options[letter]);
+        node.innerHTML = optionTemplate(letter, q.optionsHtml[letter], q.dir || "auto");
         const label = node.firstElementChild;
         label.dataset.letter = letter;
-        label.addEventListener("click", () => selectOption(letter));
+        label.addEventListener("click", (e) => {
+          if (label.dataset.pressed === "long") {
+            // the long press already struck the option out
+            e.preventDefault();
+            delete label.dataset.pressed;
+            return;
+          }
+          selectOption(letter);
+        });
+        addStrikeOut(label);
         optionNodes[letter] = label;
         opts.appendChild(label);
       });
       document.getElementById("actionBtn").innerText = "Submit";
-      document.getElementById("actionBtn").onclick = submitAnswer;
+      document.getElementById("actionBtn").onclick = () => submitAnswer("");
+      showConfidence(confidenceMode);
+      if (confidenceMode) {
+        document.getElementById("feedback").innerText = "Choose an option, then say how sure you are.";
+      }
       setSearchStatus("Search text or a number, then jump.", "muted");
+      document.getElementById("askAgainBtn").style.display = "none";
+    }
+
+    function showReportForm(open) {
+      document.getElementById("reportForm").style.display = open ? "flex" : "none";
+      document.getElementById("reportOpenBtn").style.display = open ? "none" : "";
+      if (open) {
+        document.getElementById("reportText").value = "";
+        document.getElementById("reportText").focus();
+      }
+    }
+
+    async function sendReport() {
+      if (!currentQuestion) return;
+      const status = document.getElementById("reportStatus");
+      const comment = document.getElementById("reportText").value.trim();
+      if (!comment) {
+        status.innerText = "Say what is wrong first.";
+        return;
+      }
+      const res = await fetch("/api/reports", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ id: currentQuestion.id || "", index: currentQuestion.index ?? 0, comment }),
+      });
+      if (!res.ok) {
+        status.innerText = "Could not send the report.";
+        return;
+      }
+      showReportForm(false);
+      status.innerText = "Thanks, the report was saved for the bank's maintainers.";
+    }
+
+    async function saveNote() {
+      if (!currentQuestion) return;
+      const status = document.getElementById("noteStatus");
+      const res = await fetch("/api/note", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ id: currentQuestion.id || "", index: currentQuestion.index ?? 0, note: document.getElementById("noteText").value }),
+      });
+      if (!res.ok) {
+        status.innerText = "Could not save the note.";
+        return;
+      }
+      const data = await res.json();
+      currentQuestion.note = data.note;
+      status.innerText = data.note ? "Note saved." : "Note removed.";
+    }
+
+    // addStrikeOut lets the learner cross out an option they have ruled out
+    // with a right-click or a long press. It is only a visual aid: nothing is
+    // submitted, and the next question starts clean.
+    function addStrikeOut(label) {
+      // Android fires contextmenu on a long press as well, so ignore a
+      // second toggle straight after the first.
+      let toggledAt = 0;
+      const toggle = () => {
+        if (lock || Date.now() - toggledAt < 800) return;
+        toggledAt = Date.now();
+        label.classList.toggle("struck");
+      };
+      label.addEventListener("contextmenu", (e) => {
+        e.preventDefault();
+        toggle();
+      });
+      let pressTimer = null;
+      label.addEventListener("touchstart", () => {
+        pressTimer = setTimeout(() => {
+          pressTimer = null;
+          label.dataset.pressed = "long";
+          toggle();
+        }, 500);
+      }, { passive: true });
+      const cancelPress = () => {
+        if (pressTimer) clearTimeout(pressTimer);
+        pressTimer = null;
+      };
+      label.addEventListener("touchend", cancelPress);
+      label.addEventListener("touchmove", cancelPress, { passive: true });
+    }
+
+    // storedConfirmPref returns the learner's saved choice, or null to follow
+    // the server's default.
+    function storedConfirmPref() {
+      try {
+        const v = localStorage.getItem(CONFIRM_PREF);
+        return v === null ? null : v === "1";
+      } catch (err) {
+        return null;
+      }
+    }
+
+    function setConfirmPref(on) {
+      confirmAnswers = on;
+      try {
+        localStorage.setItem(CONFIRM_PREF, on ? "1" : "0");
+      } catch (err) {
+        // private browsing: keep the choice for this page only
+      }
+      if (selected) selectOption(selected);
     }
 
     function selectOption(letter) {
       if (lock) return;
       selected = letter;
+      confirming = null;
+      if (!confidenceMode) document.getElementById("actionBtn").innerText = "Submit";
       Object.values(optionNodes).forEach(node => {
         node.classList.toggle("selected", node.dataset.letter === letter);
       });
       const pill = document.getElementById("feedback");
       pill.className = "pill muted";
-      pill.innerText = "Ready to submit " + letter + ".";
+      pill.innerText = confidenceMode ? "How sure are you about " + letter + "?" : "Ready to submit " + letter + ".";
+    }
+
+    const CONFIDENCE_LABELS = { 1: "guessing", 2: "unsure", 3: "sure" };
+
+    // showConfidence swaps the Submit button for the rating buttons, each of
+    // which submits the selected answer with its rating.
+    function showConfidence(on) {
+      document.getElementById("confidenceBtns").style.display = on ? "flex" : "none";
+      document.getElementById("actionBtn").style.display = on ? "none" : "";
     }
 
     function updateProgress(p) {
@@ -686,26 +2158,70 @@ const indexHTML = `<!doctype html>
       document.getElementById("progressCounts").innerText = p.completed + " of " + p.total + " correct · " + p.attempted + " attempted";
     }
 
-    async function searchAndJump() {
+    function recentSearches() {
+      try {
+        const terms = JSON.parse(localStorage.getItem(SEARCHES));
+        return Array.isArray(terms) ? terms : [];
+      } catch (err) {
+        return [];
+      }
+    }
+
+    function showRecentSearches() {
+      const list = document.getElementById("recentSearches");
+      list.innerHTML = "";
+      recentSearches().forEach((term) => {
+        const opt = document.createElement("option");
+        opt.value = term;
+        list.appendChild(opt);
+      });
+    }
+
+    function rememberSearch(term) {
+      const terms = [term].concat(recentSearches().filter((t) => t !== term)).slice(0, MAX_SEARCHES);
+      try {
+        localStorage.setItem(SEARCHES, JSON.stringify(terms));
+      } catch (err) {
+        // private browsing: suggestions last for this page only
+      }
+      showRecentSearches();
+    }
+
+    // searchAndJump brings the question matching the search forward. A
+    // question already answered correctly is only asked again, leaving its
+    // first-attempt result alone, when again is set.
+    async function searchAndJump(again = false) {
       if (lock) return;
       const term = searchInput.value.trim();
       if (!term) {
         setSearchStatus("Enter text or a question number to jump.", "bad");
         return;
       }
+      const askAgain = document.getElementById("askAgainBtn");
+      askAgain.style.display = "none";
+      rememberSearch(term);
       setSearchStatus("Searching...", "muted");
       try {
         const res = await fetch("/api/jump", {
           method: "POST",
           headers: { "Content-Type": "application/json" },
-          body: JSON.stringify({ term })
+          body: JSON.stringify({ term, again })
         });
         const data = await res.json();
         if (!data.found) {
           setSearchStatus("No question matched that search.", "bad");
           return;
         }
-        setSearchStatus("Jumped to Q" + data.index + " (Domain " + data.domain + ")", "good");
+        if (data.mastered) {
+          setSearchStatus("Q" + data.index + " is already answered correctly. Ask it again to re-check it; your score stays as it is.", "muted");
+          askAgain.style.display = "";
+          return;
+        }
+        if (data.error) {
+          setSearchStatus("Cannot jump to Q" + data.index + ": " + data.error + ".", "bad");
+          return;
+        }
+        setSearchStatus((again ? "Asking Q" + data.index + " again" : "Jumped to Q" + data.index) + " (Domain " + data.domain + ")", "good");
         selected = "";
         lock = false;
         loadState();
@@ -715,29 +2231,75 @@ const indexHTML = `<!doctype html>
       }
     }
 
-    async function submitAnswer() {
+    async function submitAnswer(confidence, outOfTime) {
       if (lock) return;
-      if (!selected) {
+      if (!selected && !outOfTime) {
         const pill = document.getElementById("feedback");
         pill.innerText = "Please pick an option.";
         pill.className = "pill bad";
         return;
       }
+      const pending = selected + "|" + (confidence || "");
+      if (confirmAnswers && confirming !== pending && !outOfTime) {
+        confirming = pending;
+        const pill = document.getElementById("feedback");
+        pill.className = "pill muted";
+        if (confidence) {
+          pill.innerText = "Answer " + selected + ", " + confidence + "? Click " + confidence + " again to confirm, or pick another option.";
+        } else {
+          pill.innerText = "Answer " + selected + "? Click Confirm, or pick another option to change it.";
+          document.getElementById("actionBtn").innerText = "Confirm " + selected;
+        }
+        return;
+      }
+      confirming = null;
       lock = true;
+      clearInterval(paceTimer);
+      paceTimer = null;
       const res = await fetch("/api/answer", {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ answer: selected })
+        body: JSON.stringify({ answer: selected || "", confidence: confidence || "" })
       });
+      if (res.status === 409) {
+        // paused from another tab
+        lock = false;
+        loadState();
+        return;
+      }
       const data = await res.json();
       updateProgress(data.progress);
       const pill = document.getElementById("feedback");
-      if (data.result.correct) {
+      if (data.timeUp) {
+        pill.innerText = "⏱ Time is up for this section; that answer was not recorded.";
+        pill.className = "pill bad";
+        setTimeout(() => { lock = false; loadState(); }, FEEDBACK_PAUSE);
+        return;
+      }
+      if (data.silent) {
+        // exam mode: no feedback until the summary
+        lock = false;
+        loadState();
+        return;
+      }
+      if (data.result.timedOut) {
+        pill.innerText = "⏱ Out of time; this counts as a miss." + (data.correctAnswer ? " Correct answer: " + data.correctAnswer + "." : "");
+        pill.className = "pill bad";
+      } else if (brief) {
+        pill.innerText = data.result.correct ? "✅ Correct" : "❌ Incorrect" + (data.correctAnswer ? ": " + data.correctAnswer : "");
+        pill.className = data.result.correct ? "pill good" : "pill bad";
+      } else if (data.result.correct) {
         pill.innerText = "✅ Correct! Moving to the next question shortly.";
         pill.className = "pill good";
-      } else {
+      } else if (data.correctAnswer) {
         pill.innerText = "❌ Incorrect. Correct answer: " + data.correctAnswer + ". Take a moment - next question incoming.";
         pill.className = "pill bad";
+      } else {
+        pill.innerText = "❌ Incorrect. Work it out when it comes back.";
+        pill.className = "pill bad";
+      }
+      if (!data.result.correct && data.source && !brief && !data.result.timedOut) {
+        pill.innerText += " Source: " + data.source;
       }
       Object.entries(optionNodes).forEach(([letter, node]) => {
         node.classList.remove("correct", "incorrect", "selected");
@@ -746,9 +2308,9 @@ const indexHTML = `<!doctype html>
         if (letter === selected && data.result.correct) node.classList.add("correct");
       });
       if (data.finished) {
-        setTimeout(() => loadState(), FEEDBACK_PAUSE);
+        setTimeout(() => loadState(), feedbackPause);
       } else {
-        setTimeout(() => { lock = false; loadState(); }, FEEDBACK_PAUSE);
+        setTimeout(() => { lock = false; loadState(); }, feedbackPause);
       }
     }
 
@@ -757,8 +2319,156 @@ const indexHTML = `<!doctype html>
       const summaryBox = document.getElementById("summary");
       summaryBox.style.display = "block";
       const pct = summary.answered === 0 ? 0 : (summary.score / summary.answered * 100).toFixed(1);
-      document.getElementById("scoreLine").innerText = "First-attempt score: " + summary.score + "/" + summary.answered + " (" + pct + "%)";
-      renderRows(summary.rows, document.getElementById("summaryRows"));
+      document.getElementById("scoreLine").innerText = summary.suddenDeath ? streakLine(summary) :
+        "First-attempt score: " + summary.score + "/" + summary.answered + " (" + pct + "%)" + weightedScore(summary) + masteredScore(summary) + paceNote(summary);
+      renderRows(summary.rows, document.getElementById("summaryRows"), "", openReview);
+      const sectionRows = document.getElementById("sectionRows");
+      sectionRows.innerHTML = "";
+      (summary.sections || []).forEach(sec => {
+        const div = document.createElement("div");
+        div.className = "summary-row";
+        div.innerText = sectionResult(sec);
+        sectionRows.appendChild(div);
+      });
+      const calibrationRows = document.getElementById("calibrationRows");
+      calibrationRows.innerHTML = "";
+      (summary.calibration || []).forEach(b => {
+        const div = document.createElement("div");
+        div.className = "summary-row";
+        div.innerText = "When " + b.label + ": " + (b.answered ? b.correct + " of " + b.answered + " correct (" + (b.correct / b.answered * 100).toFixed(1) + "%)" : "no answers");
+        calibrationRows.appendChild(div);
+      });
+      showChallenge(summary);
+      showReadiness();
+      updateReportLinks();
+    }
+
+    // showReadiness lists each domain's recent first-attempt accuracy with
+    // its confidence interval, flagging domains that could be below the
+    // pass mark. Servers without -stats have no readiness to show.
+    function showReadiness() {
+      const rows = document.getElementById("readinessRows");
+      rows.innerHTML = "";
+      fetch("/api/readiness").then(res => res.ok ? res.json() : null).then(data => {
+        if (!data || !data.intervals.length) return;
+        data.intervals.forEach(d => {
+          const div = document.createElement("div");
+          div.className = "summary-row " + (d.verdict === "ready" ? "good" : "bad");
+          div.innerText = "Domain " + d.domain + ": " + d.accuracy.toFixed(1) + "% of the last " + d.answers +
+            " first attempts, " + data.level + "% interval " + d.lower.toFixed(1) + "–" + d.upper.toFixed(1) + "% (" + d.verdict + ")";
+          rows.appendChild(div);
+        });
+        const note = document.createElement("div");
+        note.className = "muted";
+        note.innerText = "Readiness against a " + data.passMark + "% pass mark; domains not ready could still be below it.";
+        rows.appendChild(note);
+      }).catch(() => {});
+    }
+
+    // updateReportLinks puts the name typed for the leaderboard, if any, on
+    // the completion report.
+    function updateReportLinks() {
+      const name = document.getElementById("playerName").value.trim();
+      const suffix = name ? "&name=" + encodeURIComponent(name) : "";
+      document.getElementById("reportPdf").href = "/api/report?format=pdf" + suffix;
+      document.getElementById("reportHtml").href = "/api/report?format=html" + suffix;
+      document.getElementById("reportMd").href = "/api/report?format=md" + suffix;
+    }
+
+    // streakLine reports a sudden-death run: how many answers in a row were
+    // right before the first miss, or that there was no miss.
+    function streakLine(summary) {
+      if (summary.answered === summary.streak) {
+        return "Sudden death: all " + summary.streak + " correct, no misses!";
+      }
+      return "Sudden death: a streak of " + summary.streak + " before the first miss.";
+    }
+
+    // weightedScore describes the weighted or marked score when the bank
+    // weights questions or penalizes wrong answers, and is empty otherwise.
+    function masteredScore(summary) {
+      if (!summary.answered) return "";
+      return "; mastered " + summary.mastered + "/" + summary.answered + " after retries (" + summary.masteredPercent.toFixed(1) + "%)";
+    }
+
+    // paceNote says how many answers of a paced session ran out of time.
+    function paceNote(summary) {
+      if (!summary.pacing) return "";
+      return summary.late ? "; " + summary.late + " ran out of time (pacing " + summary.pacing + ")" : "; every answer in time (pacing " + summary.pacing + ")";
+    }
+
+    function weightedScore(summary) {
+      if (!summary.weighted || !summary.possiblePoints) return "";
+      const label = summary.penalty ? ", marked " : ", weighted ";
+      const points = Math.round(summary.points * 100) / 100;
+      const note = summary.penalty ? "; wrong answers cost " + summary.penalty + " of their points" : "";
+      return label + points + "/" + summary.possiblePoints + " points (" + summary.weightedPercent.toFixed(1) + "%" + note + ")";
+    }
+
+    // showChallenge offers a link that replays this exact run and, when the
+    // server keeps a leaderboard, a form to post the score.
+    function showChallenge(summary) {
+      streakBoard = !!summary.suddenDeath;
+      const line = document.getElementById("challengeLine");
+      line.innerHTML = "";
+      if (!summary.challenge) return;
+      const link = document.createElement("a");
+      link.href = location.origin + "/?challenge=" + encodeURIComponent(summary.challenge);
+      link.innerText = "challenge link";
+      line.append("Challenge " + summary.challengeKey + ": share this ", link, " to let others take the same run.");
+      document.getElementById("scoreForm").style.display = summary.leaderboard ? "flex" : "none";
+      document.getElementById("postScoreBtn").disabled = false;
+      renderBoard([]);
+      if (summary.leaderboard) {
+        fetch("/api/challenge").then(res => res.json()).then(data => renderBoard(data.entries));
+      }
+    }
+
+    // streakBoard is set when the leaderboard ranks sudden-death streaks
+    // rather than scores on one challenge.
+    let streakBoard = false;
+
+    function renderBoard(entries) {
+      const rows = document.getElementById("boardRows");
+      rows.innerHTML = "";
+      (entries || []).forEach((e, i) => {
+        const div = document.createElement("div");
+        div.className = "summary-row";
+        const result = streakBoard ? "streak " + e.score : e.score + "/" + e.total;
+        div.innerText = "#" + (i + 1) + " " + e.name + " · " + result + " · " + formatClock(e.seconds);
+        rows.appendChild(div);
+      });
+    }
+
+    async function postScore() {
+      const name = document.getElementById("playerName").value.trim();
+      if (!name) return;
+      const btn = document.getElementById("postScoreBtn");
+      btn.disabled = true;
+      const res = await fetch("/api/challenge/score", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ name })
+      });
+      if (!res.ok) {
+        document.getElementById("challengeLine").append(" Your score was already posted.");
+        return;
+      }
+      const data = await res.json();
+      renderBoard(data.entries);
+    }
+
+    // startChallengeFromURL replays the run named by a ?challenge= link.
+    async function startChallengeFromURL() {
+      const code = new URLSearchParams(location.search).get("challenge");
+      if (!code) return;
+      history.replaceState(null, "", location.pathname);
+      const res = await fetch("/api/challenge/start", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ code })
+      });
+      setSearchStatus(res.ok ? "Challenge loaded. Good luck!" : "That challenge uses different questions than this server.", res.ok ? "good" : "bad");
     }
 
     function resetPage() {
@@ -766,6 +2476,9 @@ const indexHTML = `<!doctype html>
         selected = "";
         lock = false;
         document.getElementById("summary").style.display = "none";
+        document.getElementById("reviewBox").style.display = "none";
+        requeued.clear();
+        updateRequeue();
         document.getElementById("card").style.display = "block";
         setSearchStatus("Session reset. Start anywhere.", "muted");
         closePartial();
@@ -782,7 +2495,7 @@ const indexHTML = `<!doctype html>
         const pct = data.answered === 0 ? 0 : (data.score / data.answered * 100).toFixed(1);
         partialScoreLine.innerText = data.answered === 0
           ? "No answers yet. Ready to start over?"
-          : "Partial score: " + data.score + "/" + data.answered + " (" + pct + "%) so far.";
+          : "Partial score: " + data.score + "/" + data.answered + " (" + pct + "%)" + weightedScore(data) + " so far.";
         const attemptedRows = (data.rows || []).filter(r => r.userAnswer);
         renderRows(attemptedRows, partialRows, attemptedRows.length ? "" : "No answers recorded yet.");
         partialModal.classList.remove("hidden");
@@ -797,19 +2510,33 @@ const indexHTML = `<!doctype html>
       lock = false;
     }
 
-    document.getElementById("searchBtn").addEventListener("click", searchAndJump);
+    document.querySelectorAll("#confidenceBtns button").forEach(btn => {
+      btn.addEventListener("click", () => submitAnswer(btn.dataset.confidence));
+    });
+    document.getElementById("postScoreBtn").addEventListener("click", postScore);
+    document.getElementById("playerName").addEventListener("input", updateReportLinks);
+    document.getElementById("searchBtn").addEventListener("click", () => searchAndJump());
+    document.getElementById("askAgainBtn").addEventListener("click", () => searchAndJump(true));
     searchInput.addEventListener("keydown", (e) => {
       if (e.key === "Enter") {
         e.preventDefault();
         searchAndJump();
       }
     });
+    document.getElementById("saveNoteBtn").addEventListener("click", saveNote);
+    document.getElementById("reportOpenBtn").addEventListener("click", () => showReportForm(true));
+    document.getElementById("reportCancelBtn").addEventListener("click", () => showReportForm(false));
+    document.getElementById("reportSendBtn").addEventListener("click", sendReport);
+    document.getElementById("pauseBtn").addEventListener("click", () => setPaused(!paused));
+    document.getElementById("confirmPref").addEventListener("change", (e) => setConfirmPref(e.target.checked));
     document.getElementById("resetBtn").addEventListener("click", openPartialSummary);
     document.getElementById("summaryResetBtn").addEventListener("click", openPartialSummary);
+    document.getElementById("requeueBtn").addEventListener("click", startRequeued);
     document.getElementById("readyBtn").addEventListener("click", resetPage);
     document.getElementById("cancelPartial").addEventListener("click", closePartial);
 
-    loadState();
+    showRecentSearches();
+    startChallengeFromURL().finally(loadState);
   </script>
 </body>
 </html>`
@@ -2,12 +2,35 @@ package webapp
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"quiz-cli/autosave"
+	"quiz-cli/challenge"
+	"quiz-cli/lti"
 	"quiz-cli/quiz"
+	"quiz-cli/stats"
 )
 
 func TestServerFlowStateAnswerReset(t *testing.T) {
@@ -115,7 +138,7 @@ func TestJumpSearchMovesQuestionToFront(t *testing.T) {
 		t.Fatalf("unexpected response: %+v", resp)
 	}
 
-	idx, _, ok := s.session.Current()
+	idx, _, ok := s.session.Current(context.Background())
 	if !ok {
 		t.Fatalf("session should still have questions")
 	}
@@ -124,9 +147,1321 @@ func TestJumpSearchMovesQuestionToFront(t *testing.T) {
 	}
 }
 
+func TestJumpAsksMasteredQuestionAgainOnlyOnRequest(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Blue", "B": "Red"}, Answer: "A"},
+		{Domain: 2, Prompt: "Grass color?", Options: map[string]string{"A": "Blue", "B": "Green"}, Answer: "B"},
+	}
+	s := NewServer(qs)
+	session := s.current()
+	session.UseOrder([]int{0, 1})
+	session.Answer(context.Background(), "A")
+
+	jump := func(body string) jumpResponse {
+		rr := httptest.NewRecorder()
+		s.handleJump(rr, httptest.NewRequest(http.MethodPost, "/api/jump", strings.NewReader(body)))
+		var resp jumpResponse
+		decodeBody(t, rr.Body.Bytes(), &resp)
+		return resp
+	}
+	if resp := jump(`{"term":"sky"}`); !resp.Found || !resp.Mastered || resp.Index != 1 {
+		t.Fatalf("jump to a mastered question = %+v", resp)
+	}
+	if idx, _, _ := session.Current(context.Background()); idx != 1 {
+		t.Fatalf("current = %d; a mastered question moved without again", idx)
+	}
+	if resp := jump(`{"term":"sky","again":true}`); !resp.Found || resp.Mastered || resp.Error != "" {
+		t.Fatalf("jump again = %+v", resp)
+	}
+	if idx, _, _ := session.Current(context.Background()); idx != 0 {
+		t.Fatalf("current = %d, want the question asked again", idx)
+	}
+	if done, _ := session.Progress(); done != 1 {
+		t.Fatalf("completed = %d, asking again changed progress", done)
+	}
+}
+
 func decodeBody(t *testing.T, data []byte, v any) {
 	t.Helper()
 	if err := json.Unmarshal(data, v); err != nil {
 		t.Fatalf("decode body: %v\nbody: %s", err, string(data))
 	}
 }
+
+func TestAdminQuestionsFilterSortPage(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Blue"}, Answer: "A", Updated: "2024-03-01"},
+		{Domain: 2, Prompt: "Grass color?", Options: map[string]string{"A": "Green"}, Answer: "A", Updated: "2024-05-01"},
+		{Domain: 2, Prompt: "Routing layer?", Options: map[string]string{"A": "Network"}, Answer: "A", Updated: "2024-01-01"},
+	}
+	s := NewServer(qs)
+
+	get := func(url string) adminQuestionPage {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		s.handleAdminQuestions(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		var page adminQuestionPage
+		decodeBody(t, rr.Body.Bytes(), &page)
+		return page
+	}
+
+	page := get("/api/admin/questions?query=COLOR&sort=updated&order=desc")
+	if page.Total != 2 || page.Items[0].Prompt != "Grass color?" {
+		t.Fatalf("filtered page = %+v", page)
+	}
+	page = get("/api/admin/questions?domain=2&pageSize=1&page=2")
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Index != 2 {
+		t.Fatalf("paged result = %+v", page)
+	}
+	page = get("/api/admin/questions?query=network")
+	if page.Total != 1 || page.Items[0].Prompt != "Routing layer?" {
+		t.Fatalf("option text search = %+v", page)
+	}
+}
+
+func TestMediaServesOnlyReferencedImages(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "diagram.png"), []byte("png"), 0o644)
+	os.WriteFile(filepath.Join(dir, "questions.json"), []byte("[]"), 0o644)
+	qs := []quiz.Question{{Prompt: "Which tier?", Options: map[string]string{"A": "Web", "B": "DB"}, Answer: "A", Image: "diagram.png"}}
+	h := NewServer(qs, WithMediaDir(dir)).Handler()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var state stateResponse
+	decodeBody(t, rr.Body.Bytes(), &state)
+	if state.Question == nil || state.Question.Image != "/media/diagram.png" {
+		t.Fatalf("question payload = %+v", state.Question)
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/diagram.png", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "png" {
+		t.Fatalf("image: %d %q", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/questions.json", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("unreferenced file served with status %d", rr.Code)
+	}
+}
+
+func TestSectionIntroBeforeEachSection(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{Domain: 2, Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	h := NewServer(qs, WithSections([]quiz.Section{{Domain: 1}, {Domain: 2, Budget: time.Minute}})).Handler()
+	state := func(method, path string) stateResponse {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		var st stateResponse
+		decodeBody(t, rr.Body.Bytes(), &st)
+		return st
+	}
+
+	if st := state(http.MethodGet, "/api/state"); !st.SectionIntro || st.Question != nil || st.Section.Domain != 1 {
+		t.Fatalf("first state = %+v", st)
+	}
+	if st := state(http.MethodPost, "/api/section/start"); st.SectionIntro || st.Question == nil || st.Question.Domain != 1 {
+		t.Fatalf("after start = %+v", st)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"A"}`)))
+	st := state(http.MethodGet, "/api/state")
+	if !st.SectionIntro || st.Section.Domain != 2 || st.Section.BudgetSeconds != 60 || st.PreviousSection == nil || st.PreviousSection.Correct != 1 {
+		t.Fatalf("second intro = %+v", st)
+	}
+}
+
+func TestQuestionsPageFilterAndSearch(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Blue", "B": "Red"}, Answer: "A", Tags: []string{"nature"}},
+		{Domain: 2, Prompt: "Grass color?", Options: map[string]string{"A": "Green", "B": "Red"}, Answer: "A", Tags: []string{"Nature", "plants"}},
+		{Domain: 2, Prompt: "Routing layer?", Options: map[string]string{"A": "Network", "B": "Link"}, Answer: "A", Tags: []string{"networks"}},
+	}
+	h := NewServer(qs).Handler()
+	get := func(url string) (questionPage, int) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		var page questionPage
+		if rr.Code == http.StatusOK {
+			decodeBody(t, rr.Body.Bytes(), &page)
+		}
+		return page, rr.Code
+	}
+
+	page, _ := get("/api/questions?limit=1&offset=1")
+	if page.Total != 3 || len(page.Items) != 1 || page.Items[0].Prompt != "Grass color?" {
+		t.Fatalf("paged = %+v", page)
+	}
+	page, _ = get("/api/questions?tag=nature&tag=PLANTS")
+	if page.Total != 1 || page.Items[0].Index != 1 || len(page.Items[0].Tags) != 2 {
+		t.Fatalf("tagged = %+v", page)
+	}
+	page, _ = get("/api/questions?domain=2&q=network")
+	if page.Total != 1 || page.Items[0].Prompt != "Routing layer?" {
+		t.Fatalf("searched = %+v", page)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/questions", nil))
+	if strings.Contains(rr.Body.String(), `"answer"`) {
+		t.Fatalf("listing gives the answers away: %s", rr.Body.String())
+	}
+	for _, bad := range []string{"?limit=0", "?offset=-1", "?domain=x"} {
+		if _, code := get("/api/questions" + bad); code != http.StatusBadRequest {
+			t.Errorf("%s returned %d", bad, code)
+		}
+	}
+}
+
+func TestPauseWithholdsQuestionAndAnswers(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	h := NewServer(qs).Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	var st stateResponse
+	decodeBody(t, do(http.MethodPost, "/api/pause", `{"paused":true}`).Body.Bytes(), &st)
+	if !st.Paused || st.Question != nil {
+		t.Fatalf("paused state = %+v", st)
+	}
+	if rr := do(http.MethodPost, "/api/answer", `{"answer":"B"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("answer while paused returned %d", rr.Code)
+	}
+	st = stateResponse{}
+	decodeBody(t, do(http.MethodPost, "/api/pause", `{"paused":false}`).Body.Bytes(), &st)
+	if st.Paused || st.Question == nil || st.Question.Prompt != "Sky color?" {
+		t.Fatalf("resumed state = %+v", st)
+	}
+	var resp answerResponse
+	decodeBody(t, do(http.MethodPost, "/api/answer", `{"answer":"B"}`).Body.Bytes(), &resp)
+	if !resp.Result.Correct || !resp.Finished {
+		t.Fatalf("answer after resume = %+v", resp)
+	}
+	if rr := do(http.MethodGet, "/api/pause", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET /api/pause returned %d", rr.Code)
+	}
+}
+
+func TestSummaryQuestionAndRequeue(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A", Explanation: "Because **x**."},
+		{Domain: 1, Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A", Explanation: "Because **x**."},
+	}
+	h := NewServer(qs).Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// unanswered questions keep their answers back
+	if rr := do(http.MethodGet, "/api/summary/question?index=1", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("unanswered question returned %d", rr.Code)
+	}
+	do(http.MethodPost, "/api/answer", `{"answer":"A"}`)
+	do(http.MethodPost, "/api/answer", `{"answer":"A"}`)
+	var q reviewPayload
+	decodeBody(t, do(http.MethodGet, "/api/summary/question?index=2", "").Body.Bytes(), &q)
+	if q.Index != 2 || q.UserAnswer != "A" || q.CorrectAnswer != "A" || !q.Correct || !strings.Contains(q.ExplanationHTML, "<strong>x</strong>") {
+		t.Fatalf("summary question = %+v", q)
+	}
+	if rr := do(http.MethodGet, "/api/summary/question?index=3", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("row past the end returned %d", rr.Code)
+	}
+
+	// a quick session on one row, then a reset back to the whole bank
+	if rr := do(http.MethodPost, "/api/requeue", `{"indexes":[]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("empty requeue returned %d", rr.Code)
+	}
+	var st stateResponse
+	decodeBody(t, do(http.MethodPost, "/api/requeue", `{"indexes":[2,2,9]}`).Body.Bytes(), &st)
+	if st.Finished || st.Progress.Total != 1 || st.Question == nil || st.Question.Prompt != q.Prompt {
+		t.Fatalf("requeued state = %+v", st)
+	}
+	do(http.MethodPost, "/api/reset", "")
+	st = stateResponse{}
+	decodeBody(t, do(http.MethodGet, "/api/state", "").Body.Bytes(), &st)
+	if st.Progress.Total != 2 {
+		t.Fatalf("reset after a quick session has %d questions, want 2", st.Progress.Total)
+	}
+}
+
+func TestBreakEveryPausesTheSession(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{Domain: 1, Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	h := NewServer(qs, WithBreaks(quiz.Breaks{Every: 1})).Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	var st stateResponse
+	decodeBody(t, do(http.MethodGet, "/api/state", "").Body.Bytes(), &st)
+	if st.Paused || st.Question == nil {
+		t.Fatalf("first state = %+v", st)
+	}
+	do(http.MethodPost, "/api/answer", `{"answer":"A"}`)
+	st = stateResponse{}
+	decodeBody(t, do(http.MethodGet, "/api/state", "").Body.Bytes(), &st)
+	if !st.Paused || !st.Break || st.Question != nil {
+		t.Fatalf("state after an answer = %+v, want a break", st)
+	}
+	st = stateResponse{}
+	decodeBody(t, do(http.MethodPost, "/api/pause", `{"paused":false}`).Body.Bytes(), &st)
+	if st.Paused || st.Break || st.Question == nil {
+		t.Fatalf("state after the break = %+v", st)
+	}
+}
+
+func TestFeedbackModesMaskTheAnswer(t *testing.T) {
+	qs := []quiz.Question{
+		{Domain: 1, Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A", Source: "ch. 1"},
+		{Domain: 1, Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A", Source: "ch. 2"},
+	}
+	answer := func(h http.Handler, ans string) answerResponse {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"`+ans+`"}`)))
+		var resp answerResponse
+		decodeBody(t, rr.Body.Bytes(), &resp)
+		return resp
+	}
+
+	h := NewServer(qs, WithFeedback(quiz.Feedback{HideAnswer: true})).Handler()
+	if resp := answer(h, "B"); resp.Result.Correct || resp.CorrectAnswer != "" || resp.Source == "" {
+		t.Fatalf("no-reveal miss = %+v", resp)
+	}
+	if resp := answer(h, "A"); resp.CorrectAnswer != "A" {
+		t.Fatalf("no-reveal hit = %+v", resp)
+	}
+
+	h = NewServer(qs, WithFeedback(quiz.Feedback{Silent: true})).Handler()
+	if resp := answer(h, "A"); !resp.Silent || resp.Result.Correct || resp.CorrectAnswer != "" || resp.Source != "" || resp.Progress.Completed != 1 {
+		t.Fatalf("silent hit = %+v", resp)
+	}
+	if resp := answer(h, "B"); !resp.Finished || resp.Result.UserAnswer != "B" || resp.Progress.Completed != 2 {
+		t.Fatalf("silent miss = %+v", resp)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var st stateResponse
+	decodeBody(t, rr.Body.Bytes(), &st)
+	if !st.Finished || st.Summary.Score != 1 || st.Summary.Answered != 2 || st.Summary.Rows[0].Source != "ch. 1" {
+		t.Fatalf("silent summary = %+v", st.Summary)
+	}
+
+	h = NewServer(qs, WithFeedback(quiz.Feedback{Silent: true, Blind: true})).Handler()
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	st = stateResponse{}
+	decodeBody(t, rr.Body.Bytes(), &st)
+	if !st.Blind || !st.Silent {
+		t.Fatalf("blind state = %+v, want blind and silent", st)
+	}
+}
+
+func TestAutosaveResumesSession(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "a", Domain: 1, Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{ID: "b", Domain: 1, Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	path := filepath.Join(t.TempDir(), "autosave.json")
+	answer := func(h http.Handler) answerResponse {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"A"}`)))
+		var resp answerResponse
+		decodeBody(t, rr.Body.Bytes(), &resp)
+		return resp
+	}
+	answer(NewServer(qs, WithAutosave(autosave.New(path, 1), nil, nil)).Handler())
+
+	saved, ok, err := autosave.Load(context.Background(), path)
+	if err != nil || !ok || saved.Answered() != 1 {
+		t.Fatalf("saved = %+v, %v, %v", saved, ok, err)
+	}
+	var failed error
+	h := NewServer(qs, WithAutosave(autosave.New(path, 1), &saved, func(err error) { failed = err })).Handler()
+	if resp := answer(h); failed != nil || !resp.Finished || resp.Progress.Completed != 2 {
+		t.Fatalf("resumed answer = %+v, restore error %v", resp, failed)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("save file left after the run finished: %v", err)
+	}
+
+	NewServer(qs[:1], WithAutosave(autosave.New(path, 1), &saved, func(err error) { failed = err }))
+	if failed == nil {
+		t.Fatal("resumed a run over other questions")
+	}
+}
+
+func TestSummaryReportsMasteryAfterRetries(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "a", Domain: 1, Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{ID: "b", Domain: 1, Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	s := NewServer(qs)
+	ctx := context.Background()
+	for _, answer := range []string{"B", "A", "A"} {
+		if _, err := s.answer(ctx, answer, quiz.Unrated); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sum := s.buildSummary()
+	if sum.Score != 1 || sum.Percent != 50 || sum.Mastered != 2 || sum.MasteredPercent != 100 {
+		t.Fatalf("summary = %+v", sum)
+	}
+}
+
+func TestAnswerWithConfidence(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	s := NewServer(qs, WithConfidence())
+
+	rr := httptest.NewRecorder()
+	s.handleAnswer(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"B","confidence":"2"}`)))
+	var resp answerResponse
+	decodeBody(t, rr.Body.Bytes(), &resp)
+	if resp.Result.Confidence != quiz.Unsure {
+		t.Fatalf("result = %+v, want unsure rating", resp.Result)
+	}
+
+	rr = httptest.NewRecorder()
+	s.handleState(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var state stateResponse
+	decodeBody(t, rr.Body.Bytes(), &state)
+	if !state.Confidence || state.Summary == nil || len(state.Summary.Calibration) != 3 || state.Summary.Calibration[1].Correct != 1 {
+		t.Fatalf("state = %+v", state)
+	}
+
+	rr = httptest.NewRecorder()
+	s.handleAnswer(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"B","confidence":"very"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad rating returned %d", rr.Code)
+	}
+}
+
+func TestChallengeStartAndScore(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "a", Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{ID: "b", Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	board, err := challenge.OpenBoard(context.Background(), filepath.Join(t.TempDir(), "board.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewServer(qs, WithBoard(board)).Handler()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	code := challenge.New(99, qs).Code()
+	if rr := post("/api/challenge/start", `{"code":"`+challenge.New(99, qs[:1]).Code()+`"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("mismatched challenge returned %d", rr.Code)
+	}
+	if rr := post("/api/challenge/start", `{"code":"`+code+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("start returned %d", rr.Code)
+	}
+	if rr := post("/api/challenge/score", `{"name":"ann"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("unfinished run posted: %d", rr.Code)
+	}
+	post("/api/answer", `{"answer":"A"}`)
+	post("/api/answer", `{"answer":"A"}`)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+	var summary summaryPayload
+	decodeBody(t, rr.Body.Bytes(), &summary)
+	if summary.Challenge != code || !summary.Leaderboard {
+		t.Fatalf("summary challenge = %q, want %q", summary.Challenge, code)
+	}
+
+	var lb leaderboardResponse
+	decodeBody(t, post("/api/challenge/score", `{"name":"ann"}`).Body.Bytes(), &lb)
+	if lb.Rank != 1 || len(lb.Entries) != 1 || lb.Entries[0].Score != 2 || lb.Key != challenge.New(99, qs).Key() {
+		t.Fatalf("leaderboard = %+v", lb)
+	}
+	if rr := post("/api/challenge/score", `{"name":"ann"}`); rr.Code != http.StatusConflict {
+		t.Fatalf("second post returned %d", rr.Code)
+	}
+}
+
+func TestSuddenDeathRanksStreaks(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "a", Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{ID: "b", Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+		{ID: "c", Prompt: "c", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	board, err := challenge.OpenBoard(context.Background(), filepath.Join(t.TempDir(), "board.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewServer(qs, WithBoard(board), WithSuddenDeath()).Handler()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	post("/api/answer", `{"answer":"A"}`)
+	var res answerResponse
+	decodeBody(t, post("/api/answer", `{"answer":"B"}`).Body.Bytes(), &res)
+	if !res.Finished {
+		t.Fatal("a miss did not end the run")
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+	var summary summaryPayload
+	decodeBody(t, rr.Body.Bytes(), &summary)
+	if !summary.SuddenDeath || summary.Streak != 1 || summary.Answered != 2 {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	var lb leaderboardResponse
+	decodeBody(t, post("/api/challenge/score", `{"name":"ann"}`).Body.Bytes(), &lb)
+	if lb.Key != challenge.StreakKey || len(lb.Entries) != 1 || lb.Entries[0].Score != 1 {
+		t.Fatalf("leaderboard = %+v", lb)
+	}
+}
+
+func TestTextDirection(t *testing.T) {
+	qs := []quiz.Question{{Prompt: "מה צבע השמיים?", Dir: "rtl", Options: map[string]string{"A": "כחול", "B": "ירוק"}, Answer: "A"}}
+	h := NewServer(qs, WithTextDir("rtl")).Handler()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rr.Body.String(), `<html lang="en" dir="rtl">`) {
+		t.Fatalf("page is not rtl")
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var st stateResponse
+	decodeBody(t, rr.Body.Bytes(), &st)
+	if st.Question == nil || st.Question.Dir != "rtl" {
+		t.Fatalf("question = %+v", st.Question)
+	}
+	if got := textDir(quiz.Question{Dir: `"><script>`}); got != "auto" {
+		t.Fatalf("textDir passed through %q", got)
+	}
+}
+
+func TestReportDownload(t *testing.T) {
+	qs := []quiz.Question{{Domain: 4, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	h := NewServer(qs).Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+	if rr := get("/api/report"); rr.Code != http.StatusConflict {
+		t.Fatalf("report before any answer returned %d", rr.Code)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"B"}`)))
+
+	rr = get("/api/report?name=Ann&pass=70")
+	if rr.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(rr.Body.String(), "%PDF-") || !strings.Contains(rr.Body.String(), "(Ann) Tj") {
+		t.Fatalf("pdf report: %d %q", rr.Code, rr.Header())
+	}
+	rr = get("/api/report?format=html")
+	if !strings.Contains(rr.Body.String(), "<td>Domain 4</td>") {
+		t.Fatalf("html report:\n%s", rr.Body.String())
+	}
+	rr = get("/api/report?format=md")
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/markdown") || !strings.Contains(rr.Body.String(), "No questions missed.") {
+		t.Fatalf("markdown report: %q\n%s", rr.Header(), rr.Body.String())
+	}
+	if rr := get("/api/report?format=docx"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown format returned %d", rr.Code)
+	}
+}
+
+func TestSummaryWeightsQuestions(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Weight: 2.5, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	h := NewServer(qs).Handler()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"B"}`)))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+	var summary summaryPayload
+	decodeBody(t, rr.Body.Bytes(), &summary)
+	if !summary.Weighted || summary.Points != 2.5 || summary.PossiblePoints != 2.5 || summary.WeightedPercent != 100 || summary.Rows[0].Weight != 2.5 {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	h = NewServer(qs, WithPenalty(0.2)).Handler()
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"A"}`)))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+	decodeBody(t, rr.Body.Bytes(), &summary)
+	if summary.Penalty != 0.2 || summary.Points != -0.5 || summary.WeightedPercent != -20 {
+		t.Fatalf("penalized summary = %+v", summary)
+	}
+}
+
+func TestNotesSavedShownAndExported(t *testing.T) {
+	qs := []quiz.Question{{ID: "q1", Domain: 4, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	store, err := stats.Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewServer(qs, WithStats(store, stats.DefaultReviewPolicy)).Handler()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/note", bytes.NewBufferString(`{"id":"q1","note":"Rayleigh"}`)))
+	if rr.Code != http.StatusOK || store.Note(qs[0]) != "Rayleigh" {
+		t.Fatalf("save note: %d, stored %q", rr.Code, store.Note(qs[0]))
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var state stateResponse
+	decodeBody(t, rr.Body.Bytes(), &state)
+	if !state.Notes || state.Question == nil || state.Question.Note != "Rayleigh" {
+		t.Fatalf("state = %+v", state)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/notes", nil))
+	if !strings.Contains(rr.Body.String(), "## q1 (Domain 4)") || !strings.Contains(rr.Body.String(), "Rayleigh") {
+		t.Fatalf("notes export:\n%s", rr.Body.String())
+	}
+}
+
+func TestIssueReportsStoredAndExported(t *testing.T) {
+	qs := []quiz.Question{{ID: "q1", Domain: 4, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "A"}}
+	path := filepath.Join(t.TempDir(), "stats.json")
+	store, err := stats.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewServer(qs, WithStats(store, stats.DefaultReviewPolicy), WithAdminKey("admin-1")).Handler()
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if method == http.MethodGet {
+			req.Header.Set("X-Admin-Key", "admin-1")
+		}
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := do(http.MethodPost, "/api/reports", `{"id":"q1","comment":"The answer should be B"}`); rr.Code != http.StatusOK {
+		t.Fatalf("report returned %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/api/reports", `{"id":"q1","comment":"  "}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("blank report returned %d", rr.Code)
+	}
+
+	for _, target := range []string{"/api/reports", "/api/reports?format=csv"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusForbidden {
+			t.Fatalf("anonymous GET %s = %d, want 403", target, rr.Code)
+		}
+	}
+
+	var issues []stats.Issue
+	decodeBody(t, do(http.MethodGet, "/api/reports", "").Body.Bytes(), &issues)
+	if len(issues) != 1 || issues[0].Key != "q1" || issues[0].Comment != "The answer should be B" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	csv := do(http.MethodGet, "/api/reports?format=csv", "").Body.String()
+	if !strings.HasPrefix(csv, "reported_at,id,domain,question,comment,reporter\n") || !strings.Contains(csv, ",q1,4,Sky color?,The answer should be B,") {
+		t.Fatalf("CSV export:\n%s", csv)
+	}
+	reopened, err := stats.Open(context.Background(), path)
+	if err != nil || len(reopened.Issues()) != 1 {
+		t.Fatalf("issues not saved: %v", err)
+	}
+}
+
+func TestGRPCStateAnswerSummary(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "sky", Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"},
+	}
+	ts := httptest.NewUnstartedServer(NewServer(qs, WithGRPC()).Handler())
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	call := func(method string, req pbMessage) (map[int]pbField, string) {
+		t.Helper()
+		frame := append([]byte{0, 0, 0, 0, byte(len(req))}, req...)
+		httpReq, _ := http.NewRequest(http.MethodPost, ts.URL+grpcPrefix+method, bytes.NewReader(frame))
+		httpReq.Header.Set("Content-Type", "application/grpc")
+		resp, err := ts.Client().Do(httpReq)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		status := resp.Trailer.Get("Grpc-Status")
+		if status != "0" {
+			return nil, status
+		}
+		if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			t.Fatalf("%s: bad frame %x", method, body)
+		}
+		fields, err := pbDecode(body[5:])
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		out := map[int]pbField{}
+		for _, f := range fields {
+			out[f.Num] = f
+		}
+		return out, status
+	}
+
+	state, _ := call("GetState", nil)
+	question, err := pbStrings(state[2].Bytes)
+	if err != nil || question[1] != "sky" || question[4] != "Sky color?" {
+		t.Fatalf("question = %v, %v", question, err)
+	}
+
+	answer, _ := call("Answer", pbMessage(nil).string(1, "b"))
+	if answer[1].Varint != 1 || answer[4].Varint != 1 || string(answer[3].Bytes) != "B" {
+		t.Fatalf("answer reply = %+v", answer)
+	}
+
+	summary, _ := call("Summary", nil)
+	if summary[1].Varint != 1 || summary[3].Varint != 1 || math.Float64frombits(summary[4].Varint) != 100 {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	if _, status := call("Answer", pbMessage(nil).string(1, "B").string(2, "certain")); status != "3" {
+		t.Errorf("bad confidence status = %q, want 3", status)
+	}
+	if _, status := call("Nope", nil); status != "12" {
+		t.Errorf("unknown method status = %q, want 12", status)
+	}
+}
+
+func TestPresenterCollectsVotesAndReveals(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "a", Prompt: "a", Options: map[string]string{"A": "x", "B": "y"}, Answer: "B"},
+		{ID: "b", Prompt: "b", Options: map[string]string{"A": "x", "B": "y"}, Answer: "A"},
+	}
+	h := NewServer(qs, WithPresenter("secret")).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set("X-Presenter-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/present", "", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("presenter view without key returned %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/present?key=secret", "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("presenter view returned %d", rr.Code)
+	}
+	var st presentState
+	decodeBody(t, do(http.MethodGet, "/api/present", "", "").Body.Bytes(), &st)
+	if st.Question == nil || st.Votes != nil || st.Join != "http://example.com/join" {
+		t.Fatalf("participant state = %+v", st)
+	}
+	answer := map[string]string{"a": "B", "b": "A"}[st.Question.ID]
+	vote := func(voter, choice string, round int) int {
+		return do(http.MethodPost, "/api/present/vote", "", fmt.Sprintf(`{"round":%d,"voter":%q,"answer":%q}`, round, voter, choice)).Code
+	}
+	vote("ann", "A", st.Round)
+	vote("ann", "b", st.Round) // changes her vote
+	vote("bob", "B", st.Round)
+	if code := vote("cy", "C", st.Round); code != http.StatusBadRequest {
+		t.Fatalf("vote for a missing option returned %d", code)
+	}
+	decodeBody(t, do(http.MethodGet, "/api/present", "secret", "").Body.Bytes(), &st)
+	if st.Voters != 2 || st.Votes["A"] != 0 || st.Votes["B"] != 2 || st.Answer != "" {
+		t.Fatalf("presenter state = %+v", st)
+	}
+
+	if rr := do(http.MethodPost, "/api/present/reveal", "", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("reveal without key returned %d", rr.Code)
+	}
+	decodeBody(t, do(http.MethodPost, "/api/present/reveal", "secret", "").Body.Bytes(), &st)
+	if !st.Revealed || st.Answer != answer {
+		t.Fatalf("revealed state = %+v", st)
+	}
+	if code := vote("dee", "A", st.Round); code != http.StatusConflict {
+		t.Fatalf("vote after reveal returned %d", code)
+	}
+	round := st.Round
+	decodeBody(t, do(http.MethodPost, "/api/present/next", "secret", "").Body.Bytes(), &st)
+	if st.Round == round || st.Number != 2 || st.Voters != 0 || st.Revealed {
+		t.Fatalf("next state = %+v", st)
+	}
+	if code := vote("ann", "A", round); code != http.StatusConflict {
+		t.Fatalf("vote for the previous question returned %d", code)
+	}
+	decodeBody(t, do(http.MethodPost, "/api/present/next", "secret", "").Body.Bytes(), &st)
+	if !st.Finished {
+		t.Fatalf("state after the last question = %+v", st)
+	}
+	if rr := do(http.MethodGet, "/api/present/join.svg", "", ""); rr.Code != http.StatusOK || !strings.HasPrefix(rr.Body.String(), "<svg") {
+		t.Fatalf("join QR returned %d", rr.Code)
+	}
+}
+
+func TestWebhookOnFinish(t *testing.T) {
+	got := make(chan map[string]any, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		got <- body
+	}))
+	defer hook.Close()
+	qs := []quiz.Question{{Domain: 4, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	h := NewServer(qs, WithWebhook(hook.URL, func(err error) { t.Error(err) })).Handler()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"B"}`)))
+	select {
+	case body := <-got:
+		if body["event"] != "session.finished" || body["score"] != 1.0 || len(body["domains"].([]any)) != 1 {
+			t.Fatalf("payload = %v", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+}
+
+func TestLTILaunchGivesLearnerOwnSessionAndGrade(t *testing.T) {
+	platformKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := make(chan lti.Score, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, lti.JWKS{Keys: []lti.JWK{{
+			Kty: "RSA", Kid: "p1",
+			N: base64.RawURLEncoding.EncodeToString(platformKey.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(platformKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, map[string]string{"access_token": "tok"})
+	})
+	mux.HandleFunc("/items/1/scores", func(w http.ResponseWriter, r *http.Request) {
+		var s lti.Score
+		json.NewDecoder(r.Body).Decode(&s)
+		scores <- s
+	})
+	platform := httptest.NewServer(mux)
+	defer platform.Close()
+	tool, err := lti.NewTool(lti.Config{Platforms: []lti.Platform{{
+		Issuer: "https://lms.example", ClientID: "c1",
+		AuthURL: platform.URL + "/auth", TokenURL: platform.URL + "/token", JWKSURL: platform.URL + "/jwks",
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := []quiz.Question{{Domain: 4, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	h := NewServer(qs, WithLTI(tool, func(err error) { t.Error(err) })).Handler()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/lti/login?iss=https://lms.example&login_hint=u1", nil))
+	if rr.Code != http.StatusFound {
+		t.Fatalf("login status = %d: %s", rr.Code, rr.Body)
+	}
+	auth, _ := url.Parse(rr.Header().Get("Location"))
+	claims, _ := json.Marshal(map[string]any{
+		"iss": "https://lms.example", "sub": "u1", "aud": "c1",
+		"exp": time.Now().Add(time.Minute).Unix(), "nonce": auth.Query().Get("nonce"),
+		"https://purl.imsglobal.org/spec/lti/claim/message_type":  "LtiResourceLinkRequest",
+		"https://purl.imsglobal.org/spec/lti/claim/version":       "1.3.0",
+		"https://purl.imsglobal.org/spec/lti/claim/resource_link": map[string]string{"id": "link-1"},
+		"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint": map[string]any{
+			"scope": []string{lti.ScopeScore}, "lineitem": platform.URL + "/items/1",
+		},
+	})
+	signing := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"p1"}`)) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, platformKey, crypto.SHA256, sum[:])
+	form := url.Values{"id_token": {signing + "." + base64.RawURLEncoding.EncodeToString(sig)}, "state": {auth.Query().Get("state")}}
+	req := httptest.NewRequest(http.MethodPost, "/lti/launch", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusSeeOther || len(rr.Result().Cookies()) != 1 {
+		t.Fatalf("launch status = %d: %s", rr.Code, rr.Body)
+	}
+	cookie := rr.Result().Cookies()[0]
+
+	// the cookie alone does not let a request change state
+	req = httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"B"}`))
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("answer without a CSRF token = %d", rr.Code)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/csrf", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	var csrf csrfResponse
+	decodeBody(t, rr.Body.Bytes(), &csrf)
+	req = httptest.NewRequest(http.MethodPost, "/api/answer", bytes.NewBufferString(`{"answer":"B"}`))
+	req.AddCookie(cookie)
+	req.Header.Set(csrfHeader, csrf.Token)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	select {
+	case s := <-scores:
+		if s.UserID != "u1" || s.ScoreGiven != 1 || s.ScoreMaximum != 1 {
+			t.Fatalf("score = %+v", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("grade not posted")
+	}
+
+	// the learner's session is their own
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var state stateResponse
+	decodeBody(t, rr.Body.Bytes(), &state)
+	if state.Finished {
+		t.Fatal("learner's answer finished the shared session")
+	}
+}
+
+func TestAnalyticsAndHardestFirst(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "easy", Domain: 4, Prompt: "Easy?", Options: map[string]string{"A": "Yes", "B": "No"}, Answer: "A"},
+		{ID: "hard", Domain: 5, Prompt: "Hard?", Options: map[string]string{"A": "Yes", "B": "No"}, Answer: "B"},
+	}
+	store, err := stats.Open(context.Background(), filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		store.Record(qs[0], true, now)
+		store.Record(qs[1], i == 0, now)
+	}
+	store.RecordTime(qs[1], 30*time.Second)
+	h := NewServer(qs, WithStats(store, stats.DefaultReviewPolicy), WithHardestFirst()).Handler()
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/analytics", nil))
+	var resp analyticsResponse
+	decodeBody(t, rr.Body.Bytes(), &resp)
+	if len(resp.Questions) != 2 || resp.Questions[0].ID != "hard" || resp.Questions[0].Misses != 2 || resp.Questions[0].AvgSeconds != 30 {
+		t.Fatalf("analytics = %+v", resp.Questions)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var state stateResponse
+	decodeBody(t, rr.Body.Bytes(), &state)
+	if state.Question == nil || state.Question.ID != "hard" {
+		t.Fatalf("first question = %+v, want the hard one", state.Question)
+	}
+}
+
+func TestConditionalGetAndGzip(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	h := NewServer(qs).Handler()
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/state", nil)
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("state = %d, ETag %q", rr.Code, etag)
+	}
+	rr = get("/api/state", map[string]string{"If-None-Match": etag})
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("revalidated state = %d with %d bytes", rr.Code, rr.Body.Len())
+	}
+
+	// the page carries its session's CSRF token, so both ask as one session
+	plain := get("/", nil)
+	session := plain.Result().Cookies()[0]
+	rr = get("/", map[string]string{"Accept-Encoding": "br, gzip", "Cookie": session.Name + "=" + session.Value})
+	if rr.Header().Get("Content-Encoding") != "gzip" || !strings.Contains(rr.Header().Get("Vary"), "Accept-Encoding") {
+		t.Fatalf("page headers = %v", rr.Header())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(page, plain.Body.Bytes()) || rr.Body.Len() >= plain.Body.Len() || !strings.Contains(rr.Header().Get("Cache-Control"), "private") {
+		t.Fatalf("gzipped page is %d bytes, %d unzipped, %d plain", rr.Body.Len(), len(page), plain.Body.Len())
+	}
+	if rr = get("/", map[string]string{"Accept-Encoding": "gzip;q=0"}); rr.Header().Get("Content-Encoding") != "" {
+		t.Fatal("gzipped a page the client refused gzip for")
+	}
+}
+
+func TestSummaryStreamsCompactOrPrettyJSON(t *testing.T) {
+	qs := []quiz.Question{
+		{ID: "q1", Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B", Source: "Atlas p. 3"},
+		{ID: "q2", Domain: 1, Prompt: "Grass color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "A"},
+	}
+	s := NewServer(qs)
+	h := s.Handler()
+	for _, answer := range []string{"A", "A"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/answer", strings.NewReader(`{"answer":"`+answer+`"}`)))
+	}
+	want := s.buildSummary()
+	if len(want.Rows) != 2 {
+		t.Fatalf("rows = %+v", want.Rows)
+	}
+	compact, _ := json.Marshal(want)
+	pretty, _ := json.MarshalIndent(want, "", "  ")
+	for url, body := range map[string][]byte{"/api/summary": compact, "/api/summary?pretty=1": pretty} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		if got := rr.Body.String(); got != string(body)+"\n" {
+			t.Errorf("%s =\n%s\nwant\n%s", url, got, body)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	if strings.Contains(rr.Body.String(), "\n ") {
+		t.Fatalf("state is indented: %s", rr.Body.String())
+	}
+}
+
+func TestReloadKeepsSessionInProgress(t *testing.T) {
+	bank := []quiz.Question{
+		{ID: "q1", Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"},
+		{ID: "q2", Domain: 1, Prompt: "Grass color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "A"},
+	}
+	load := func(context.Context) ([]quiz.Question, error) { return bank, nil }
+	h := NewServer(bank, WithReload("", load), WithAdminKey("admin-1")).Handler()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if path == "/api/reload" {
+			req.Header.Set("X-Admin-Key", "admin-1")
+		}
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	total := func() int {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+		var st stateResponse
+		decodeBody(t, rr.Body.Bytes(), &st)
+		return st.Progress.Total
+	}
+
+	anon := httptest.NewRecorder()
+	h.ServeHTTP(anon, httptest.NewRequest(http.MethodPost, "/api/reload", nil))
+	if anon.Code != http.StatusForbidden {
+		t.Fatalf("anonymous reload = %d, want 403", anon.Code)
+	}
+
+	post("/api/answer", `{"answer":"A"}`)
+	edited := slices.Clone(bank)
+	edited[1].Prompt = "Lawn color?"
+	bank = append(edited, quiz.Question{ID: "q3", Domain: 2, Prompt: "Snow color?", Options: map[string]string{"A": "White", "B": "Blue"}, Answer: "A"})
+	var resp reloadResponse
+	decodeBody(t, post("/api/reload", "").Body.Bytes(), &resp)
+	if resp != (reloadResponse{Questions: 3, Added: 1, Changed: 1}) {
+		t.Fatalf("reload = %+v", resp)
+	}
+	if n := total(); n != 2 {
+		t.Fatalf("session in progress has %d questions after the reload, want 2", n)
+	}
+	post("/api/reset", "")
+	if n := total(); n != 3 {
+		t.Fatalf("new session has %d questions, want 3", n)
+	}
+
+	bank = bank[:1]
+	decodeBody(t, post("/api/reload", "").Body.Bytes(), &resp)
+	if !resp.Applied || resp.Removed != 2 || total() != 1 {
+		t.Fatalf("reload of an unanswered session = %+v, %d questions", resp, total())
+	}
+}
+
+func TestSessionLimitsExpireAndCap(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	s := NewServer(qs, WithLTI(nil, nil), WithSessionLimits(time.Hour, 2), WithAdminKey("admin-1"))
+	h := s.Handler()
+	launch := func(user string) (*learner, error) {
+		return s.launchLearner(&lti.Launch{Platform: &lti.Platform{Issuer: "https://lms.example"}, UserID: user, ResourceLinkID: "link-1"})
+	}
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Admin-Key", "admin-1")
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	list := func() sessionList {
+		t.Helper()
+		rr := admin(http.MethodGet, "/api/admin/sessions", "")
+		var l sessionList
+		decodeBody(t, rr.Body.Bytes(), &l)
+		return l
+	}
+
+	first, _ := launch("u1")
+	second, _ := launch("u2")
+	if _, err := launch("u3"); err != errTooManySessions {
+		t.Fatalf("third launch over the cap: %v", err)
+	}
+	if l := list(); len(l.Sessions) != 2 || l.Max != 2 || l.Sessions[0].Expires == nil {
+		t.Fatalf("sessions = %+v", l)
+	}
+
+	first.touch(time.Now().Add(-2 * time.Hour))
+	if _, err := launch("u3"); err != nil {
+		t.Fatalf("launch after u1 expired: %v", err)
+	}
+	if rr := admin(http.MethodPost, "/api/admin/sessions/expire", `{"id":"`+second.id+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("expire = %d", rr.Code)
+	}
+	if l := list(); len(l.Sessions) != 1 || l.Sessions[0].User != "u3" {
+		t.Fatalf("sessions after expiry = %+v", l)
+	}
+	if rr := admin(http.MethodPost, "/api/admin/sessions/expire", `{"id":"`+second.id+`"}`); rr.Code != http.StatusNotFound {
+		t.Fatalf("expiring a gone session = %d", rr.Code)
+	}
+}
+
+func TestAdminRoutesNeedTheKey(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	send := func(h http.Handler, method, path string, header map[string]string) int {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"id":"x"}`))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/api/admin/questions"},
+		{http.MethodGet, "/api/admin/reviews"},
+		{http.MethodGet, "/api/admin/sessions"},
+		{http.MethodPost, "/api/admin/sessions/expire"},
+		{http.MethodPost, "/api/admin/reviews/resolve"},
+		{http.MethodPost, "/api/reload"},
+	}
+
+	open := NewServer(qs, WithLTI(nil, nil)).Handler()
+	keyed := NewServer(qs, WithLTI(nil, nil), WithAdminKey("admin-1")).Handler()
+	for _, rt := range routes {
+		if code := send(open, rt.method, rt.path, nil); code != http.StatusForbidden {
+			t.Errorf("%s %s without any key configured = %d", rt.method, rt.path, code)
+		}
+		if code := send(keyed, rt.method, rt.path, nil); code != http.StatusForbidden {
+			t.Errorf("anonymous %s %s = %d", rt.method, rt.path, code)
+		}
+		if code := send(keyed, rt.method, rt.path, map[string]string{"X-Admin-Key": "guess"}); code != http.StatusForbidden {
+			t.Errorf("%s %s with a wrong key = %d", rt.method, rt.path, code)
+		}
+	}
+	if code := send(keyed, http.MethodGet, "/api/admin/sessions", map[string]string{"X-Admin-Key": "admin-1"}); code != http.StatusOK {
+		t.Fatalf("sessions with the admin key = %d", code)
+	}
+	presented := NewServer(qs, WithPresenter("present-1")).Handler()
+	if code := send(presented, http.MethodGet, "/api/admin/questions", map[string]string{"X-Presenter-Key": "present-1"}); code != http.StatusOK {
+		t.Fatalf("questions with the presenter key = %d", code)
+	}
+}
+
+func TestCSRFAndAllowedOrigins(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	s := NewServer(qs, WithAllowedOrigins([]string{"https://lms.example/"}))
+	h := s.Handler()
+	send := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"term":"1"}`))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	page := send(http.MethodGet, "/", nil)
+	cookies := page.Result().Cookies()
+	if len(cookies) != 1 || !strings.Contains(page.Body.String(), `const csrfToken = "`+s.csrfFor(cookies[0].Value)+`"`) {
+		t.Fatal("page does not carry the CSRF token of its session")
+	}
+	cookie := cookies[0].Name + "=" + cookies[0].Value
+	if rr := send(http.MethodPost, "/api/jump", nil); rr.Code != http.StatusOK {
+		t.Fatalf("jump from a script = %d", rr.Code)
+	}
+	same := map[string]string{"Origin": "http://example.com", "Sec-Fetch-Site": "same-origin", "Cookie": cookie}
+	if rr := send(http.MethodPost, "/api/reset", same); rr.Code != http.StatusForbidden {
+		t.Fatalf("reset from the browser without a token = %d", rr.Code)
+	}
+	same[csrfHeader] = s.csrfFor(cookies[0].Value)
+	if rr := send(http.MethodPost, "/api/reset", same); rr.Code != http.StatusOK {
+		t.Fatalf("reset from the page = %d", rr.Code)
+	}
+	evil := map[string]string{"Origin": "https://evil.example", csrfHeader: same[csrfHeader], "Cookie": cookie}
+	if rr := send(http.MethodPost, "/api/answer", evil); rr.Code != http.StatusForbidden || rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("answer from another site = %d, %v", rr.Code, rr.Header())
+	}
+	preflight := map[string]string{"Origin": "https://lms.example", "Access-Control-Request-Method": "POST"}
+	rr := send(http.MethodOptions, "/api/answer", preflight)
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Origin") != "https://lms.example" || !strings.Contains(rr.Header().Get("Access-Control-Allow-Headers"), csrfHeader) {
+		t.Fatalf("preflight from an allowed origin = %d, %v", rr.Code, rr.Header())
+	}
+	preflight["Origin"] = "https://evil.example"
+	if rr := send(http.MethodOptions, "/api/answer", preflight); rr.Code != http.StatusForbidden {
+		t.Fatalf("preflight from another site = %d", rr.Code)
+	}
+}
+
+func TestCSRFTokenIsPerSession(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	h := NewServer(qs).Handler()
+	session := func() (cookie *http.Cookie, token string) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/csrf", nil))
+		var csrf csrfResponse
+		decodeBody(t, rr.Body.Bytes(), &csrf)
+		if len(rr.Result().Cookies()) != 1 {
+			t.Fatalf("no session cookie: %v", rr.Result().Cookies())
+		}
+		return rr.Result().Cookies()[0], csrf.Token
+	}
+	reset := func(cookie *http.Cookie, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/reset", nil)
+		req.Header.Set("Sec-Fetch-Site", "same-origin")
+		req.Header.Set(csrfHeader, token)
+		req.AddCookie(cookie)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	alice, aliceToken := session()
+	bob, bobToken := session()
+	if aliceToken == bobToken {
+		t.Fatal("two sessions share a CSRF token")
+	}
+	if code := reset(bob, aliceToken); code != http.StatusForbidden {
+		t.Fatalf("one session's token with another's cookie = %d", code)
+	}
+	if code := reset(alice, aliceToken); code != http.StatusOK {
+		t.Fatalf("a session's own token = %d", code)
+	}
+	if code := reset(bob, bobToken); code != http.StatusOK {
+		t.Fatalf("a session's own token = %d", code)
+	}
+}
+
+func TestHealthReadinessAndVersion(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	path := filepath.Join(t.TempDir(), "stats.json")
+	store, err := stats.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewServer(qs, WithStats(store, stats.DefaultReviewPolicy), WithBuildInfo(BuildInfo{Version: "1.4.0", Commit: "abc123"})).Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if rr := get("/healthz"); rr.Code != http.StatusOK {
+		t.Fatalf("healthz = %d", rr.Code)
+	}
+	var ready readiness
+	rr := get("/readyz")
+	decodeBody(t, rr.Body.Bytes(), &ready)
+	if rr.Code != http.StatusOK || !ready.Ready || ready.Checks["bank"] != "ok" || ready.Checks["stats"] != "ok" {
+		t.Fatalf("readyz = %d %+v", rr.Code, ready)
+	}
+	// a store that cannot be read makes the server unready
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rr = get("/readyz")
+	decodeBody(t, rr.Body.Bytes(), &ready)
+	if rr.Code != http.StatusServiceUnavailable || ready.Ready || ready.Checks["stats"] == "ok" {
+		t.Fatalf("readyz with a broken store = %d %+v", rr.Code, ready)
+	}
+	var info BuildInfo
+	decodeBody(t, get("/version").Body.Bytes(), &info)
+	if info.Version != "1.4.0" || info.Commit != "abc123" || info.GoVersion == "" {
+		t.Fatalf("version = %+v", info)
+	}
+}
+
+func TestRequestsLoggedAtDebugLevel(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"}}
+	var buf bytes.Buffer
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelDebug} {
+		buf.Reset()
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+		h := NewServer(qs, WithLogger(logger)).Handler()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/answer", strings.NewReader("{")))
+		if level == slog.LevelInfo {
+			if buf.Len() != 0 {
+				t.Fatalf("info level logged requests: %s", buf.String())
+			}
+			continue
+		}
+		var entry struct {
+			Msg    string `json:"msg"`
+			Path   string `json:"path"`
+			Status int    `json:"status"`
+		}
+		decodeBody(t, buf.Bytes(), &entry)
+		if entry.Msg != "request" || entry.Path != "/api/answer" || entry.Status != http.StatusBadRequest {
+			t.Fatalf("log entry = %s", buf.String())
+		}
+	}
+}
+
+func TestConfirmAnswersIsThePageDefault(t *testing.T) {
+	qs := []quiz.Question{{Domain: 1, Prompt: "Sky color?", Options: map[string]string{"A": "Blue", "B": "Red"}, Answer: "A"}}
+	for _, confirm := range []bool{false, true} {
+		var opts []Option
+		if confirm {
+			opts = append(opts, WithConfirmAnswers())
+		}
+		h := NewServer(qs, opts...).Handler()
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+		var state stateResponse
+		decodeBody(t, rr.Body.Bytes(), &state)
+		if state.ConfirmAnswers != confirm {
+			t.Fatalf("with WithConfirmAnswers %v, state confirmAnswers = %v", confirm, state.ConfirmAnswers)
+		}
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if !strings.Contains(rr.Body.String(), `id="confirmPref"`) {
+			t.Fatal("page has no Confirm answers switch")
+		}
+	}
+}
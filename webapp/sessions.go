@@ -0,0 +1,196 @@
+package webapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// errTooManySessions refuses a launch while the server holds as many
+// sessions as WithSessionLimits allows.
+var errTooManySessions = errors.New("too many sessions in progress; try again later")
+
+// WithSessionLimits bounds the per-client sessions the server keeps, such as
+// those of LTI learners (see WithLTI). A session nobody has used for ttl is
+// dropped, and while limit sessions are live a new client is refused with
+// 503 Service Unavailable rather than given one. Zero leaves either
+// unbounded. Clients of an expired session start over on their next launch.
+func WithSessionLimits(ttl time.Duration, limit int) Option {
+	return func(s *Server) {
+		s.sessionTTL, s.maxSessions = ttl, limit
+	}
+}
+
+// sessionInfo describes one per-client session for /api/admin/sessions.
+type sessionInfo struct {
+	ID       string    `json:"id"`
+	User     string    `json:"user"`
+	Email    string    `json:"email,omitempty"`
+	Context  string    `json:"context,omitempty"`
+	Started  time.Time `json:"started"`
+	LastSeen time.Time `json:"lastSeen"`
+	// Expires is when the session is dropped unless used again; nil
+	// without a TTL.
+	Expires  *time.Time `json:"expires,omitempty"`
+	Answered int        `json:"answered"`
+	Total    int        `json:"total"`
+	Finished bool       `json:"finished"`
+}
+
+type sessionList struct {
+	TTLSeconds float64       `json:"ttlSeconds,omitempty"`
+	Max        int           `json:"max,omitempty"`
+	Sessions   []sessionInfo `json:"sessions"`
+}
+
+type expireRequest struct {
+	ID string `json:"id"`
+}
+
+// touch records that l was used at now.
+func (l *learner) touch(now time.Time) {
+	l.mu.Lock()
+	l.lastSeen = now
+	l.mu.Unlock()
+}
+
+func (l *learner) idleSince() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastSeen
+}
+
+// expired reports whether l has been idle for longer than the TTL at now.
+func (s *Server) expired(l *learner, now time.Time) bool {
+	return s.sessionTTL > 0 && now.Sub(l.idleSince()) > s.sessionTTL
+}
+
+// sweepSessions drops the learners idle for longer than the TTL. The caller
+// holds s.lti.mu.
+func (s *Server) sweepSessions(now time.Time) {
+	for key, l := range s.lti.learners {
+		if s.expired(l, now) {
+			s.dropLearner(key, l)
+		}
+	}
+}
+
+// dropLearner forgets l, stored under key, and every token naming it. The
+// caller holds s.lti.mu.
+func (s *Server) dropLearner(key string, l *learner) {
+	delete(s.lti.learners, key)
+	for token, tl := range s.lti.tokens {
+		if tl == l {
+			delete(s.lti.tokens, token)
+		}
+	}
+}
+
+// expireSessions sweeps expired sessions until ctx is done, checking a few
+// times per TTL and at least every minute.
+func (s *Server) expireSessions(ctx context.Context) {
+	ticker := time.NewTicker(min(s.sessionTTL/4+time.Second, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.lti.mu.Lock()
+			s.sweepSessions(now)
+			s.lti.mu.Unlock()
+		}
+	}
+}
+
+// handleAdminSessions lists the per-client sessions, least recently used
+// first. Servers without them list none.
+func (s *Server) handleAdminSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	list := sessionList{TTLSeconds: s.sessionTTL.Seconds(), Max: s.maxSessions, Sessions: []sessionInfo{}}
+	if s.lti != nil {
+		now := time.Now()
+		s.lti.mu.Lock()
+		s.sweepSessions(now)
+		learners := make([]*learner, 0, len(s.lti.learners))
+		for _, l := range s.lti.learners {
+			learners = append(learners, l)
+		}
+		s.lti.mu.Unlock()
+		for _, l := range learners {
+			list.Sessions = append(list.Sessions, s.describeLearner(l))
+		}
+	}
+	sort.Slice(list.Sessions, func(i, j int) bool {
+		return list.Sessions[i].LastSeen.Before(list.Sessions[j].LastSeen)
+	})
+	writeJSON(w, r, list)
+}
+
+func (s *Server) describeLearner(l *learner) sessionInfo {
+	l.mu.Lock()
+	info := sessionInfo{ID: l.id, Started: l.started, LastSeen: l.lastSeen}
+	if l.launch != nil {
+		info.User, info.Email, info.Context = l.launch.Name, l.launch.Email, l.launch.ContextID
+		if info.User == "" {
+			info.User = l.launch.UserID
+		}
+	}
+	l.mu.Unlock()
+	if s.sessionTTL > 0 {
+		expires := info.LastSeen.Add(s.sessionTTL)
+		info.Expires = &expires
+	}
+	session := l.server.current()
+	info.Answered = session.AttemptedCount()
+	_, info.Total = session.Progress()
+	info.Finished = session.Completed()
+	return info
+}
+
+// handleExpireSession drops the session with the given ID at once, as the
+// TTL would: its client starts over on their next launch.
+func (s *Server) handleExpireSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req expireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	expired := false
+	if s.lti != nil {
+		s.lti.mu.Lock()
+		for key, l := range s.lti.learners {
+			if l.id == req.ID {
+				s.dropLearner(key, l)
+				expired = true
+			}
+		}
+		s.lti.mu.Unlock()
+	}
+	if !expired {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writeJSON(w, r, map[string]bool{"expired": true})
+}
+
+// refuseSession answers a client the session cap keeps out.
+func (s *Server) refuseSession(w http.ResponseWriter) {
+	retry := time.Minute
+	if s.sessionTTL > 0 {
+		retry = min(retry, s.sessionTTL)
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())))
+	http.Error(w, errTooManySessions.Error(), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,29 @@
+package webapp
+
+import (
+	"context"
+	"time"
+
+	"quiz-cli/quiz"
+	"quiz-cli/report"
+	"quiz-cli/webhook"
+)
+
+// WithWebhook posts a summary of each finished session to url (see
+// package webhook). Delivery happens in the background; failed is called with
+// any error, and may be nil.
+func WithWebhook(url string, failed func(error)) Option {
+	return func(s *Server) {
+		s.webhook = url
+		s.webhookFailed = failed
+	}
+}
+
+// notify posts session, run between started and finished, to the webhook.
+func (s *Server) notify(session *quiz.Session, started, finished time.Time) {
+	rep := report.FromSession("CSSLP Review Quiz", session, started, finished)
+	err := webhook.Post(context.Background(), s.webhook, webhook.FromReport(rep))
+	if err != nil && s.webhookFailed != nil {
+		s.webhookFailed(err)
+	}
+}
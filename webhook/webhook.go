@@ -0,0 +1,112 @@
+// Package webhook posts a JSON summary of each finished quiz session to a
+// URL, so results flow into chat tools, notebooks or a personal dashboard.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"quiz-cli/report"
+)
+
+// Event names the only payload sent so far.
+const Event = "session.finished"
+
+// Payload is the JSON body posted when a session finishes.
+type Payload struct {
+	Event string `json:"event"`
+	// Text is a one-line summary. Slack and compatible incoming webhooks
+	// show it as the message; other receivers can use the fields below.
+	Text     string  `json:"text"`
+	Title    string  `json:"title"`
+	Name     string  `json:"name,omitempty"`
+	Score    int     `json:"score"`
+	Answered int     `json:"answered"`
+	Total    int     `json:"total"`
+	Percent  float64 `json:"percent"`
+	// Mastered counts the questions answered that were eventually answered
+	// correctly, retries included.
+	Mastered        int     `json:"mastered"`
+	MasteredPercent float64 `json:"masteredPercent"`
+	// PassMark and Passed are set when the run had a pass mark.
+	PassMark        float64   `json:"passMark,omitempty"`
+	Passed          *bool     `json:"passed,omitempty"`
+	Started         time.Time `json:"started"`
+	Finished        time.Time `json:"finished"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	Domains         []Domain  `json:"domains"`
+}
+
+// Domain is one domain's first-attempt results.
+type Domain struct {
+	report.Domain
+	Percent float64 `json:"percent"`
+}
+
+// FromReport builds the payload for a finished run's completion report.
+func FromReport(r report.Report) Payload {
+	p := Payload{
+		Event:           Event,
+		Title:           r.Title,
+		Name:            r.Name,
+		Score:           r.Score,
+		Answered:        r.Answered,
+		Total:           r.Total,
+		Percent:         r.Percent(),
+		Mastered:        r.Mastered,
+		MasteredPercent: r.MasteredPercent(),
+		PassMark:        r.PassMark,
+		Started:         r.Started,
+		Finished:        r.Finished,
+		DurationSeconds: (r.Finished.Sub(r.Started) - r.Paused).Seconds(),
+		Domains:         make([]Domain, len(r.Domains)),
+	}
+	for i, d := range r.Domains {
+		p.Domains[i] = Domain{Domain: d, Percent: d.Percent()}
+	}
+	who := r.Title
+	if r.Name != "" {
+		who = r.Name + " finished " + r.Title
+	}
+	p.Text = fmt.Sprintf("%s: %d/%d correct (%.1f%%) in %s", who, r.Score, r.Answered, p.Percent, (r.Finished.Sub(r.Started) - r.Paused).Round(time.Second))
+	if r.PassMark > 0 {
+		passed := p.Percent >= r.PassMark
+		p.Passed = &passed
+		if passed {
+			p.Text += ", passed"
+		} else {
+			p.Text += ", not passed"
+		}
+	}
+	return p
+}
+
+// client bounds each delivery, so a slow receiver cannot hold up the quiz.
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Post sends p to url as JSON. A response outside 2xx is an error.
+func Post(ctx context.Context, url string, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quiz-cli")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s returned %s", url, resp.Status)
+	}
+	return nil
+}
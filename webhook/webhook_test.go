@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"quiz-cli/report"
+)
+
+func TestPostSendsSummary(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	r := report.Report{
+		Title: "CSSLP Review Quiz", Name: "ann", Score: 3, Answered: 4, Total: 5, PassMark: 70,
+		Started: start, Finished: start.Add(90 * time.Second),
+		Domains: []report.Domain{{Domain: 4, Questions: 3, Answered: 2, Correct: 1}, {Domain: 5, Questions: 2, Answered: 2, Correct: 2}},
+	}
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", req.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	if err := Post(context.Background(), srv.URL, FromReport(r)); err != nil {
+		t.Fatal(err)
+	}
+	if got["event"] != Event || got["percent"] != 75.0 || got["durationSeconds"] != 90.0 || got["passed"] != true {
+		t.Fatalf("payload = %v", got)
+	}
+	if text := got["text"].(string); !strings.Contains(text, "ann finished CSSLP Review Quiz: 3/4 correct (75.0%) in 1m30s") {
+		t.Fatalf("text = %q", text)
+	}
+	domains := got["domains"].([]any)
+	if d := domains[0].(map[string]any); len(domains) != 2 || d["domain"] != 4.0 || d["percent"] != 50.0 {
+		t.Fatalf("domains = %v", domains)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := Post(context.Background(), failing.URL, FromReport(r)); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("error = %v", err)
+	}
+}
@@ -0,0 +1,350 @@
+// Package xapi reports study activity to a Learning Record Store as xAPI
+// (Tin Can) statements, through a quiz session's listener hooks: one
+// "answered" statement per answer and a "completed" statement with the score
+// when the session finishes.
+package xapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+// Version is the xAPI version statements are sent as.
+const Version = "1.0.3"
+
+// Verb IRIs from the ADL vocabulary.
+const (
+	VerbAnswered  = "http://adlnet.gov/expapi/verbs/answered"
+	VerbCompleted = "http://adlnet.gov/expapi/verbs/completed"
+)
+
+// Config says where statements go and whom they are about.
+type Config struct {
+	// Endpoint is the LRS's xAPI base URL; statements are POSTed to
+	// Endpoint + "/statements".
+	Endpoint string
+	// Username and Password authenticate with HTTP basic auth, as LRS
+	// keys and secrets do, when Username is set.
+	Username string
+	Password string
+	// Actor is the learner: an email address, or else an account name.
+	Actor string
+	// Activity is the IRI of the quiz; questions are Activity/questions/ID.
+	Activity string
+	// Title names the quiz activity.
+	Title string
+	// PassMark, if set, marks completed statements passed or failed on the
+	// first-attempt percentage.
+	PassMark float64
+}
+
+// Enabled reports whether cfg names an LRS.
+func (cfg Config) Enabled() bool {
+	return cfg.Endpoint != ""
+}
+
+// Statement is an xAPI statement, with the parts the quiz uses.
+type Statement struct {
+	ID        string    `json:"id"`
+	Actor     Agent     `json:"actor"`
+	Verb      Verb      `json:"verb"`
+	Object    Activity  `json:"object"`
+	Result    *Result   `json:"result,omitempty"`
+	Context   *Context  `json:"context,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Agent identifies the learner by mailbox or by account.
+type Agent struct {
+	ObjectType string   `json:"objectType"`
+	Name       string   `json:"name,omitempty"`
+	Mbox       string   `json:"mbox,omitempty"`
+	Account    *Account `json:"account,omitempty"`
+}
+
+// Account is a login on a system, identified by its home page.
+type Account struct {
+	HomePage string `json:"homePage"`
+	Name     string `json:"name"`
+}
+
+// Verb is what the learner did.
+type Verb struct {
+	ID      string            `json:"id"`
+	Display map[string]string `json:"display"`
+}
+
+// Activity is the quiz or one of its questions.
+type Activity struct {
+	ObjectType string      `json:"objectType"`
+	ID         string      `json:"id"`
+	Definition *Definition `json:"definition,omitempty"`
+}
+
+// Definition describes an activity; questions are choice interactions.
+type Definition struct {
+	Name                    map[string]string `json:"name,omitempty"`
+	Type                    string            `json:"type"`
+	InteractionType         string            `json:"interactionType,omitempty"`
+	CorrectResponsesPattern []string          `json:"correctResponsesPattern,omitempty"`
+	Choices                 []Choice          `json:"choices,omitempty"`
+}
+
+// Choice is one option of a choice interaction.
+type Choice struct {
+	ID          string            `json:"id"`
+	Description map[string]string `json:"description"`
+}
+
+// Result is the outcome of an answer or of the whole quiz.
+type Result struct {
+	Score      *Score `json:"score,omitempty"`
+	Success    *bool  `json:"success,omitempty"`
+	Completion *bool  `json:"completion,omitempty"`
+	Response   string `json:"response,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+}
+
+// Score is a quiz score: Raw of Max, and Scaled between 0 and 1.
+type Score struct {
+	Scaled float64 `json:"scaled"`
+	Raw    int     `json:"raw"`
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+}
+
+// Context ties statements of one attempt together under Registration, and a
+// question to its quiz.
+type Context struct {
+	Registration      string                `json:"registration,omitempty"`
+	ContextActivities map[string][]Activity `json:"contextActivities,omitempty"`
+}
+
+// Recorder sends statements for session events. Statements are sent in order
+// by a background worker, so a slow LRS does not hold up the quiz; Close
+// waits for them.
+type Recorder struct {
+	cfg    Config
+	errs   io.Writer
+	client *http.Client
+	queue  chan Statement
+	done   chan struct{}
+	mu     sync.Mutex
+	// registration identifies the current attempt; started and shown time
+	// it and the question on screen.
+	registration string
+	started      time.Time
+	shown        map[int]time.Time
+}
+
+// NewRecorder returns a Recorder for cfg that reports delivery failures to
+// errs, which may be nil.
+func NewRecorder(cfg Config, errs io.Writer) *Recorder {
+	if cfg.Activity == "" {
+		cfg.Activity = "urn:quiz-cli"
+	}
+	if cfg.Title == "" {
+		cfg.Title = "Quiz"
+	}
+	r := &Recorder{
+		cfg:          cfg,
+		errs:         errs,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		queue:        make(chan Statement, 64),
+		done:         make(chan struct{}),
+		registration: newUUID(),
+		shown:        map[int]time.Time{},
+	}
+	go r.deliver()
+	return r
+}
+
+// Listener returns the quiz.Listener that feeds r.
+func (r *Recorder) Listener() quiz.Listener {
+	return quiz.ListenerFuncs{
+		QuestionShown: func(_ context.Context, index int, _ quiz.Question) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			now := time.Now()
+			if r.started.IsZero() {
+				r.started = now
+			}
+			r.shown[index] = now
+		},
+		Answered: func(_ context.Context, index int, q quiz.Question, res quiz.Result) {
+			r.queue <- r.answered(index, q, res, time.Now())
+		},
+		Finished: func(_ context.Context, score, answered int) {
+			r.queue <- r.completed(score, answered, time.Now())
+		},
+	}
+}
+
+// Close sends the statements still queued and stops r.
+func (r *Recorder) Close() {
+	close(r.queue)
+	<-r.done
+}
+
+func (r *Recorder) answered(index int, q quiz.Question, res quiz.Result, now time.Time) Statement {
+	r.mu.Lock()
+	shown, ok := r.shown[index]
+	registration := r.registration
+	r.mu.Unlock()
+	def := &Definition{
+		Name:                    map[string]string{"en-US": q.Prompt},
+		Type:                    "http://adlnet.gov/expapi/activities/cmi.interaction",
+		InteractionType:         "choice",
+		CorrectResponsesPattern: []string{strings.ToUpper(q.Answer)},
+	}
+	keys := make([]string, 0, len(q.Options))
+	for k := range q.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		def.Choices = append(def.Choices, Choice{ID: k, Description: map[string]string{"en-US": q.Options[k]}})
+	}
+	result := &Result{Success: &res.Correct, Response: res.UserAnswer}
+	if ok {
+		result.Duration = Duration(now.Sub(shown))
+	}
+	return Statement{
+		ID:     newUUID(),
+		Actor:  r.actor(),
+		Verb:   Verb{ID: VerbAnswered, Display: map[string]string{"en-US": "answered"}},
+		Object: Activity{ObjectType: "Activity", ID: r.cfg.Activity + "/questions/" + q.ID, Definition: def},
+		Result: result,
+		Context: &Context{
+			Registration:      registration,
+			ContextActivities: map[string][]Activity{"parent": {r.quizActivity(false)}},
+		},
+		Timestamp: now,
+	}
+}
+
+// completed builds the statement for a finished attempt and starts the next.
+func (r *Recorder) completed(score, answered int, now time.Time) Statement {
+	r.mu.Lock()
+	registration, started := r.registration, r.started
+	r.registration, r.started, r.shown = newUUID(), time.Time{}, map[int]time.Time{}
+	r.mu.Unlock()
+	complete := true
+	result := &Result{Score: &Score{Raw: score, Max: answered}, Completion: &complete}
+	if answered > 0 {
+		result.Score.Scaled = float64(score) / float64(answered)
+	}
+	if r.cfg.PassMark > 0 {
+		passed := result.Score.Scaled*100 >= r.cfg.PassMark
+		result.Success = &passed
+	}
+	if !started.IsZero() {
+		result.Duration = Duration(now.Sub(started))
+	}
+	return Statement{
+		ID:        newUUID(),
+		Actor:     r.actor(),
+		Verb:      Verb{ID: VerbCompleted, Display: map[string]string{"en-US": "completed"}},
+		Object:    r.quizActivity(true),
+		Result:    result,
+		Context:   &Context{Registration: registration},
+		Timestamp: now,
+	}
+}
+
+func (r *Recorder) quizActivity(withDefinition bool) Activity {
+	a := Activity{ObjectType: "Activity", ID: r.cfg.Activity}
+	if withDefinition {
+		a.Definition = &Definition{
+			Name: map[string]string{"en-US": r.cfg.Title},
+			Type: "http://adlnet.gov/expapi/activities/assessment",
+		}
+	}
+	return a
+}
+
+// actor identifies the learner by mailbox when Actor is an email address and
+// by an account on the activity otherwise.
+func (r *Recorder) actor() Agent {
+	a := Agent{ObjectType: "Agent"}
+	if addr, err := mail.ParseAddress(r.cfg.Actor); err == nil {
+		a.Name, a.Mbox = addr.Name, "mailto:"+addr.Address
+		return a
+	}
+	a.Name = r.cfg.Actor
+	a.Account = &Account{HomePage: r.cfg.Activity, Name: r.cfg.Actor}
+	return a
+}
+
+func (r *Recorder) deliver() {
+	defer close(r.done)
+	for st := range r.queue {
+		if err := r.send(st); err != nil && r.errs != nil {
+			fmt.Fprintf(r.errs, "xAPI: %v\n", err)
+		}
+	}
+}
+
+func (r *Recorder) send(st Statement) error {
+	body, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(r.cfg.Endpoint, "/")+"/statements", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Experience-API-Version", Version)
+	if r.cfg.Username != "" {
+		req.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("LRS returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Duration formats d as an ISO 8601 duration, e.g. PT1M30.5S, as xAPI wants.
+func Duration(d time.Duration) string {
+	d = d.Round(10 * time.Millisecond)
+	h, d := d/time.Hour, d%time.Hour
+	m, d := d/time.Minute, d%time.Minute
+	out := "PT"
+	if h > 0 {
+		out += fmt.Sprintf("%dH", h)
+	}
+	if m > 0 {
+		out += fmt.Sprintf("%dM", m)
+	}
+	if d > 0 || out == "PT" {
+		out += strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", d.Seconds()), "0"), ".") + "S"
+	}
+	return out
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
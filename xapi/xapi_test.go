@@ -0,0 +1,88 @@
+package xapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"quiz-cli/quiz"
+)
+
+func TestRecorderSendsAnsweredAndCompleted(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []Statement
+	)
+	lrs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xapi/statements" || r.Header.Get("X-Experience-API-Version") != Version {
+			t.Errorf("request %s with version %q", r.URL.Path, r.Header.Get("X-Experience-API-Version"))
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "key" || pass != "secret" {
+			t.Errorf("basic auth = %q, %q, %v", user, pass, ok)
+		}
+		var st Statement
+		if err := json.NewDecoder(r.Body).Decode(&st); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		got = append(got, st)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer lrs.Close()
+
+	qs := []quiz.Question{
+		{ID: "q1", Prompt: "Sky color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "B"},
+		{ID: "q2", Prompt: "Grass color?", Options: map[string]string{"A": "Green", "B": "Blue"}, Answer: "A"},
+	}
+	rec := NewRecorder(Config{Endpoint: lrs.URL + "/xapi/", Username: "key", Password: "secret", Actor: "Ann <ann@example.com>", Activity: "https://example.com/csslp", PassMark: 70}, nil)
+	s := quiz.NewSession(qs)
+	s.AddListener(rec.Listener())
+	ctx := context.Background()
+	missed := false
+	for {
+		_, q, ok := s.Current(ctx)
+		if !ok {
+			break
+		}
+		ans := q.Answer
+		if q.ID == "q2" && !missed {
+			ans, missed = "B", true
+		}
+		s.Answer(ctx, ans)
+	}
+	rec.Close()
+
+	if len(got) != 4 {
+		t.Fatalf("%d statements, want 3 answers and a completion", len(got))
+	}
+	first := got[0]
+	if first.Verb.ID != VerbAnswered || first.Actor.Mbox != "mailto:ann@example.com" || first.Result.Response == "" || first.Result.Duration == "" {
+		t.Fatalf("answered statement = %+v", first)
+	}
+	if first.Context.ContextActivities["parent"][0].ID != "https://example.com/csslp" || len(first.Object.Definition.Choices) != 2 {
+		t.Fatalf("answered activity = %+v", first.Object)
+	}
+	last := got[3]
+	if last.Verb.ID != VerbCompleted || last.Result.Score.Raw != 1 || last.Result.Score.Max != 2 || *last.Result.Success || last.Context.Registration != first.Context.Registration {
+		t.Fatalf("completed statement = %+v", last)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                     "PT0S",
+		1500 * time.Millisecond:               "PT1.5S",
+		90 * time.Second:                      "PT1M30S",
+		2*time.Hour + 3*time.Second:           "PT2H3S",
+		time.Hour + time.Minute + time.Second: "PT1H1M1S",
+	} {
+		if got := Duration(d); got != want {
+			t.Errorf("Duration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}